@@ -0,0 +1,71 @@
+// package main 实现了一个命令行工具，用于将公开站点导出为静态文件。
+//
+// 用法示例（在项目根目录下执行，以便读取 ./configs/config.yaml）：
+//
+//	go run ./cmd/export -out ./public
+//	go run ./cmd/export -out ./public -base-url https://blog.example.com -full
+//
+// 导出内容包括所有已发布的文章、首页和分类/标签的列表页、归档页、订阅源、站点地图、robots.txt、
+// 主题的静态文件以及文章引用的媒体文件。默认进行增量导出，只重新渲染修改过的文章；
+// 修改主题或站点配置后会自动完整导出，也可以通过 -full 强制完整导出。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/export"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/storage"
+	"github.com/KeLes-Coding/gopress/internal/theme"
+	"go.uber.org/zap"
+)
+
+func main() {
+	out := flag.String("out", "./public", "输出目录")
+	baseURL := flag.String("base-url", "", "静态站点的公开访问地址，为空时使用配置中的 site.url")
+	full := flag.Bool("full", false, "忽略上次导出的记录，重新渲染所有页面")
+	flag.Parse()
+
+	// --- 初始化配置、日志、数据库和存储，与 cmd/server 保持一致 ---
+	if err := config.Init(); err != nil {
+		panic(fmt.Sprintf("Failed to initialize config: %v", err))
+	}
+	if err := logger.Init(); err != nil {
+		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+	}
+	defer func() {
+		_ = logger.L.Sync()
+	}()
+	if *baseURL != "" {
+		// 页面中的规范链接、订阅源和站点地图都基于 site.url 生成
+		config.Conf.Site.URL = *baseURL
+	}
+	if err := dao.InitMySQL(); err != nil {
+		logger.L.Fatal("Failed to initialize MySQL", zap.Error(err))
+	}
+	if err := storage.Init(); err != nil {
+		logger.L.Fatal("Failed to initialize storage", zap.Error(err))
+	}
+	// 导出不依赖 theme.enabled，即使服务端没有启用公开站点也可以导出
+	if err := theme.Init(); err != nil {
+		logger.L.Fatal("Failed to load theme", zap.Error(err))
+	}
+
+	stats, err := export.New(export.Options{OutDir: *out, Full: *full}).Run()
+	if err != nil {
+		logger.L.Error("Static export failed", zap.Error(err))
+		os.Exit(1)
+	}
+	logger.L.Info("Static export finished",
+		zap.String("out", *out),
+		zap.Int("written", stats.Written),
+		zap.Int("unchanged", stats.Unchanged),
+		zap.Int("skipped_posts", stats.Skipped),
+		zap.Int("removed", stats.Removed),
+		zap.Int("media_copied", stats.Media),
+	)
+}
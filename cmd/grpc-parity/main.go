@@ -0,0 +1,48 @@
+// package main 实现了一个命令行工具，用于检查 gRPC 接口与 REST 接口是否一致。
+//
+// 用法示例（在项目根目录下执行）：
+//
+//	go run ./cmd/grpc-parity -check  # 不一致时列出差异并以状态码 1 退出
+//
+// proto/gopress/v1 中的每个方法都通过 google.api.http 注解声明了对应的 REST 接口，-check 检查这些接口是否已注册、
+// 是否在 OpenAPI 文档中声明、认证要求是否相同，以及请求消息的字段与 REST 接口的参数是否一一对应、类型是否相同。
+// go test ./internal/rpc 会执行同样的检查，并比较两种接口对同一组数据返回的结果。
+// 修改 REST 接口的参数后没有同步修改 .proto 文件（或者反过来）时，-check 会失败。
+// 该工具不连接数据库，也不读取配置文件。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/KeLes-Coding/gopress/internal/api"
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/rpc"
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	check := flag.Bool("check", false, "检查 gRPC 接口与 REST 接口是否一致")
+	flag.Parse()
+
+	if !*check {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// 只注册路由和服务而不处理请求，使用空配置即可；/api/v1 下的路由不受配置开关影响
+	config.Conf = &config.Config{}
+	gin.SetMode(gin.ReleaseMode)
+	r := gin.New()
+	api.RegisterRoutes(r)
+
+	if problems := rpc.CheckParity(r); len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p)
+		}
+		fmt.Fprintf(os.Stderr, "gRPC 接口与 REST 接口不一致，共 %d 处，请修改 proto/gopress/v1 中的定义并重新生成代码\n", len(problems))
+		os.Exit(1)
+	}
+	fmt.Fprintln(os.Stderr, "gRPC 接口与 REST 接口一致")
+}
@@ -0,0 +1,37 @@
+// package main 实现了一个命令行工具，用于检查接口消息的翻译是否完整。
+//
+// 用法示例（在项目根目录下执行）：
+//
+//	go run ./cmd/i18n -check  # 有缺失、多余或占位符不一致的翻译时列出问题并以状态码 1 退出，适合在 CI 中运行
+//
+// 需要翻译的消息是所有登记到 apperr 错误目录中的错误，它们以包级变量的形式定义在各个包中，
+// 因此这里导入 internal/api 以加载所有注册路由时用到的包。该工具不连接数据库，也不读取配置文件。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	_ "github.com/KeLes-Coding/gopress/internal/api"
+	"github.com/KeLes-Coding/gopress/internal/apperr"
+	"github.com/KeLes-Coding/gopress/internal/i18n"
+)
+
+func main() {
+	check := flag.Bool("check", false, "检查各语言的消息目录是否完整")
+	flag.Parse()
+
+	if !*check {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if problems := i18n.Check(apperr.Catalog()); len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintln(os.Stderr, p)
+		}
+		os.Exit(1)
+	}
+	fmt.Printf("消息目录完整，共 %d 条消息，支持的语言: %v\n", len(apperr.Catalog()), i18n.Languages())
+}
@@ -0,0 +1,147 @@
+// package main 实现了一个命令行工具，用于在不同的存储后端之间迁移媒体文件。
+//
+// 用法示例（在项目根目录下执行，以便读取 ./configs/config.yaml）：
+//
+//	go run ./cmd/migrate-media -from local -to s3
+//
+// 迁移完成后，将配置中的 storage.driver 修改为目标驱动即可。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/storage"
+	"go.uber.org/zap"
+)
+
+func main() {
+	from := flag.String("from", "local", "源存储驱动 (local, s3)")
+	to := flag.String("to", "s3", "目标存储驱动 (local, s3)")
+	dryRun := flag.Bool("dry-run", false, "只打印需要迁移的文件，不实际复制")
+	deleteSource := flag.Bool("delete-source", false, "复制成功后删除源存储中的文件")
+	flag.Parse()
+
+	if *from == *to {
+		fmt.Fprintln(os.Stderr, "源存储和目标存储不能相同")
+		os.Exit(2)
+	}
+
+	// --- 初始化配置、日志和数据库，与 cmd/server 保持一致 ---
+	if err := config.Init(); err != nil {
+		panic(fmt.Sprintf("Failed to initialize config: %v", err))
+	}
+	if err := logger.Init(); err != nil {
+		panic(fmt.Sprintf("Failed to initialize logger: %v", err))
+	}
+	defer func() {
+		_ = logger.L.Sync()
+	}()
+	if err := dao.InitMySQL(); err != nil {
+		logger.L.Fatal("Failed to initialize MySQL", zap.Error(err))
+	}
+
+	src, err := storage.New(*from, config.Conf.Storage)
+	if err != nil {
+		logger.L.Fatal("Failed to initialize source storage", zap.Error(err))
+	}
+	dst, err := storage.New(*to, config.Conf.Storage)
+	if err != nil {
+		logger.L.Fatal("Failed to initialize destination storage", zap.Error(err))
+	}
+
+	keys, err := mediaKeys()
+	if err != nil {
+		logger.L.Fatal("Failed to list media files", zap.Error(err))
+	}
+
+	var copied, skipped, failed int
+	for _, key := range keys {
+		ok, err := migrate(src, dst, key, *dryRun, *deleteSource)
+		switch {
+		case err != nil:
+			failed++
+			logger.L.Error("Failed to migrate media file", zap.String("key", key), zap.Error(err))
+		case ok:
+			copied++
+			logger.L.Info("Media file migrated", zap.String("key", key), zap.Bool("dry_run", *dryRun))
+		default:
+			skipped++
+		}
+	}
+
+	logger.L.Info("Media migration finished",
+		zap.Int("total", len(keys)),
+		zap.Int("copied", copied),
+		zap.Int("skipped", skipped),
+		zap.Int("failed", failed),
+	)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// mediaKeys 返回数据库中引用的所有存储键（包括原图和生成的各个尺寸，去重后）。
+func mediaKeys() ([]string, error) {
+	db := dao.GetDB()
+	var keys, variantKeys []string
+	if err := db.Model(&model.Media{}).Distinct("storage_key").Order("storage_key").Pluck("storage_key", &keys).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Model(&model.MediaVariant{}).Distinct("storage_key").Order("storage_key").Pluck("storage_key", &variantKeys).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		seen[key] = true
+	}
+	for _, key := range variantKeys {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// migrate 将单个文件从 src 复制到 dst。目标中已存在的文件会被跳过，
+// 由于存储键是内容寻址的，已存在即意味着内容相同。返回值表示是否实际复制了文件。
+func migrate(src, dst storage.Storage, key string, dryRun, deleteSource bool) (bool, error) {
+	exists, err := dst.Exists(key)
+	if err != nil {
+		return false, err
+	}
+	if exists {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+
+	obj, err := src.Get(key)
+	if err != nil {
+		return false, err
+	}
+	defer obj.Body.Close()
+
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if err := dst.Put(key, obj.Body, obj.Size, contentType); err != nil {
+		return false, err
+	}
+	if deleteSource {
+		if err := src.Delete(key); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
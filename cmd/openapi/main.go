@@ -0,0 +1,45 @@
+// package main 实现了一个命令行工具，用于输出 OpenAPI 文档。
+//
+// 用法示例（在项目根目录下执行）：
+//
+//	go run ./cmd/openapi -out ./openapi.json  # 将文档写入文件，供前端生成客户端代码
+//	go run ./cmd/openapi -out -               # 将文档写入标准输出
+//
+// 文档中的请求和响应结构直接由代码中的类型生成，不会与代码不一致；internal/api/openapi.go 中的路由声明
+// 是否与实际注册的路由一致由 go test ./internal/api 检查。
+// 该工具不连接数据库，也不读取配置文件。
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/KeLes-Coding/gopress/internal/api"
+)
+
+func main() {
+	out := flag.String("out", "", "将文档写入该文件，为 - 时写入标准输出")
+	flag.Parse()
+
+	if *out == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := api.OpenAPIJSON()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "生成文档失败:", err)
+		os.Exit(1)
+	}
+	data = append(data, '\n')
+	if *out == "-" {
+		_, err = os.Stdout.Write(data)
+	} else {
+		err = os.WriteFile(*out, data, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "写入文档失败:", err)
+		os.Exit(1)
+	}
+}
@@ -4,6 +4,7 @@ package main
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -15,6 +16,10 @@ import (
 	"github.com/KeLes-Coding/gopress/internal/config"
 	"github.com/KeLes-Coding/gopress/internal/dao"
 	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/rpc"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/KeLes-Coding/gopress/internal/storage"
+	"github.com/KeLes-Coding/gopress/internal/theme"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
@@ -52,6 +57,46 @@ func main() {
 		logger.L.Fatal("Failed to auto-migrate tables", zap.Error(err))
 	}
 
+	// --- 初始化媒体文件存储 ---
+	// 根据配置选择本地文件系统或 S3 兼容的对象存储。
+	if err := storage.Init(); err != nil {
+		logger.L.Fatal("Failed to initialize storage", zap.Error(err))
+	}
+
+	// --- 启动后台图片处理 ---
+	// 上传的图片在后台生成缩略图和占位信息，上次未处理完的图片会重新排队。
+	mediaProcessor := service.StartMediaProcessor()
+
+	// --- 启动后台任务队列 ---
+	// 按队列执行数据库中到期的任务，执行者崩溃时未完成的任务在租约到期后重新执行。
+	jobRunner := service.StartJobRunner()
+
+	// --- 启动领域事件 outbox 后台任务 ---
+	// 事件在提交后立即投递，后台任务负责重新投递进程崩溃时未完成的事件和订阅者失败的事件。
+	outboxDispatcher := service.StartOutboxDispatcher()
+
+	// --- 启动 Webmention 后台任务 ---
+	// 校验收到的通知、发送文章中链接的外部页面，失败的任务按退避策略重试。
+	var webmentionWorker *service.WebmentionWorker
+	if config.Conf.Webmention.Enabled || config.Conf.Webmention.Send {
+		webmentionWorker = service.StartWebmentionWorker()
+	}
+
+	// --- 启动 Webhook 后台任务 ---
+	// 向订阅的地址投递事件，失败的投递按退避策略重试。
+	var webhookWorker *service.WebhookWorker
+	if config.Conf.Webhooks.Enabled {
+		webhookWorker = service.StartWebhookWorker()
+	}
+
+	// --- 加载公开站点的主题 ---
+	// 只有启用了服务端渲染时才需要加载主题，主题模板有错误时拒绝启动。
+	if config.Conf.Theme.Enabled {
+		if err := theme.Init(); err != nil {
+			logger.L.Fatal("Failed to load theme", zap.Error(err))
+		}
+	}
+
 	// --- 5. 设置 Gin 模式并创建引擎 ---
 	gin.SetMode(config.Conf.Server.Mode)
 	// gin.New() 创建一个不带任何默认中间件的纯净的 Gin 引擎。
@@ -106,6 +151,23 @@ func main() {
 		}
 	}()
 
+	// --- 启动 gRPC 服务 ---
+	// 供内部的其他服务读取和发布内容，与 HTTP 服务使用不同的端口，关停时同样等待正在处理的请求完成。
+	var grpcServer *rpc.Server
+	if config.Conf.GRPC.Enabled {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%d", config.Conf.GRPC.Port))
+		if err != nil {
+			logger.L.Fatal("Failed to listen for gRPC", zap.Error(err))
+		}
+		grpcServer = rpc.NewServer()
+		go func() {
+			logger.L.Info("gRPC server is starting...", zap.Int("port", config.Conf.GRPC.Port))
+			if err := grpcServer.Serve(lis); err != nil {
+				logger.L.Fatal("Failed to serve gRPC", zap.Error(err))
+			}
+		}()
+	}
+
 	// 主 goroutine 会在这里等待中断信号。
 	// 我们创建一个 channel 来接收 os.Signal。
 	quit := make(chan os.Signal, 1)
@@ -117,15 +179,59 @@ func main() {
 
 	logger.L.Info("Shutting down server...")
 
-	// 创建一个带有超时的 context，用于通知 srv.Shutdown 我们最多等待 5 秒。
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel() // 确保在退出时释放 context 相关的资源。
-
+	// 每个组件使用各自的超时依次关停，前面的组件用完了等待时间不会导致后面的组件被立即强制关闭。
 	// srv.Shutdown 会尝试优雅地关闭服务器。
 	// 它会停止接收新的请求，并等待当前正在处理的请求完成，直到超时。
-	if err := srv.Shutdown(ctx); err != nil {
+	if err := stopWithin(shutdownTimeout, srv.Shutdown); err != nil {
 		logger.L.Fatal("Server forced to shutdown", zap.Error(err))
 	}
 
+	// gRPC 服务同样等待正在处理的请求完成，超时后强制关闭连接。
+	if grpcServer != nil {
+		if err := stopWithin(shutdownTimeout, grpcServer.Shutdown); err != nil {
+			logger.L.Warn("gRPC server forced to shutdown", zap.Error(err))
+		}
+	}
+
+	// 等待正在处理的图片完成，队列中剩余的图片会在下次启动时继续处理。
+	if err := stopWithin(shutdownTimeout, mediaProcessor.Shutdown); err != nil {
+		logger.L.Warn("Media processor did not stop in time", zap.Error(err))
+	}
+
+	// 停止取出新任务并等待执行中的任务完成，最多等待 jobs.drain_timeout，超时后被取消的任务重新排队。
+	// 任务中可能写入事件，因此先于 outbox 后台任务停止。
+	if err := stopWithin(service.JobDrainTimeout(), jobRunner.Shutdown); err != nil {
+		logger.L.Warn("Job runner did not stop in time", zap.Error(err))
+	}
+
+	// 停止 outbox 后台任务，它会唤醒 Webmention 和 Webhook 后台任务，因此先于它们停止。
+	if err := stopWithin(shutdownTimeout, outboxDispatcher.Shutdown); err != nil {
+		logger.L.Warn("Outbox dispatcher did not stop in time", zap.Error(err))
+	}
+
+	// 停止 Webmention 后台任务，未完成的任务会在下次启动时继续处理。
+	if webmentionWorker != nil {
+		if err := stopWithin(shutdownTimeout, webmentionWorker.Shutdown); err != nil {
+			logger.L.Warn("Webmention worker did not stop in time", zap.Error(err))
+		}
+	}
+
+	// 停止 Webhook 后台任务，未完成的投递会在下次启动时继续处理。
+	if webhookWorker != nil {
+		if err := stopWithin(shutdownTimeout, webhookWorker.Shutdown); err != nil {
+			logger.L.Warn("Webhook worker did not stop in time", zap.Error(err))
+		}
+	}
+
 	logger.L.Info("Server exiting.")
 }
+
+// shutdownTimeout 是关停 HTTP / gRPC 服务和各个后台任务时，每个组件最多等待的时间。
+const shutdownTimeout = 5 * time.Second
+
+// stopWithin 调用 stop 关停一个组件，最多等待 timeout。
+func stopWithin(timeout time.Duration, stop func(context.Context) error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return stop(ctx)
+}
@@ -57,14 +57,19 @@ service.interceptors.response.use(
         }
     },
     error => {
-        // 处理 HTTP 网络错误
+        // 业务错误的 HTTP 状态码与 code 一致（400、401、404 等），响应体中的 message 可以直接展示
+        // 没有响应体时是网络错误
+        const res = error.response && error.response.data
         console.log('err' + error)
         ElMessage({
-            message: error.message,
+            message: (res && res.message) || error.message,
             type: 'error',
             duration: 5 * 1000
         })
-        return Promise.reject(error)
+        if (res && res.code === 401) {
+            // TODO: 处理 token 失效，例如跳转到登录页
+        }
+        return Promise.reject(res && res.message ? new Error(res.message) : error)
     }
 )
 
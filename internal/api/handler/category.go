@@ -11,13 +11,18 @@ import (
 
 // CategoryHandler 结构体，用于挂载与分类相关的 API 方法。
 type CategoryHandler struct {
-	categoryService *service.CategoryService
+	categoryService service.CategoryStore
 }
 
 // NewCategoryHandler 是 CategoryHandler 的构造函数。
 func NewCategoryHandler() *CategoryHandler {
+	return NewCategoryHandlerWithStore(service.NewCategoryService())
+}
+
+// NewCategoryHandlerWithStore 使用指定的 CategoryStore 创建 CategoryHandler，用于测试。
+func NewCategoryHandlerWithStore(store service.CategoryStore) *CategoryHandler {
 	return &CategoryHandler{
-		categoryService: service.NewCategoryService(),
+		categoryService: store,
 	}
 }
 
@@ -32,14 +37,14 @@ func (h *CategoryHandler) CreateCategoryHandler(c *gin.Context) {
 	var req CreateCategoryRequest
 	// 绑定并校验 JSON 请求体
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error("参数校验失败:"+err.Error(), c)
+		response.ValidationError(err, c)
 		return
 	}
 
 	// 调用 service 层来处理业务逻辑
 	category, err := h.categoryService.Create(req.Name)
 	if err != nil {
-		response.Error(err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 
@@ -51,7 +56,7 @@ func (h *CategoryHandler) CreateCategoryHandler(c *gin.Context) {
 func (h *CategoryHandler) ListCategoriesHandler(c *gin.Context) {
 	categories, err := h.categoryService.List()
 	if err != nil {
-		response.Error("获取分类列表失败:"+err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 
@@ -70,21 +75,21 @@ func (h *CategoryHandler) UpdateCategoryHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		response.Error("无效的分类 ID", c)
+		response.Fail(errInvalidCategoryID, c)
 		return
 	}
 
 	// 2. 绑定并校验请求体
 	var req UpdateCategoryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error("参数校验失败"+err.Error(), c)
+		response.ValidationError(err, c)
 		return
 	}
 
 	// 3. 调用 service 层处理更新逻辑
 	updatedCategory, err := h.categoryService.Update(uint(id), req.Name)
 	if err != nil {
-		response.Error(err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 
@@ -98,16 +103,44 @@ func (h *CategoryHandler) DeleteCategoryHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		response.Error("无效的分类 ID", c)
+		response.Fail(errInvalidCategoryID, c)
 		return
 	}
 
 	// 2. 调用 service 层处理删除逻辑
 	if err := h.categoryService.Delete(uint(id)); err != nil {
-		response.Error(err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 
 	// 3. 删除成功，返回成功的响应，通常 data 为 nil
 	response.Success(nil, c)
 }
+
+// SetTranslationsRequest 定义了设置名称翻译接口的请求体，分类和标签共用。
+// translations 是语言到名称的映射，例如 {"en": "Programming"}，会替换现有的所有翻译；名称为空的翻译会被删除。
+type SetTranslationsRequest struct {
+	Translations map[string]string `json:"translations" binding:"dive,keys,max=16,endkeys,max=100"`
+}
+
+// SetCategoryTranslationsHandler 是处理设置分类名称翻译请求的 Gin Handler。
+func (h *CategoryHandler) SetCategoryTranslationsHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidCategoryID, c)
+		return
+	}
+
+	var req SetTranslationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+
+	category, err := h.categoryService.SetTranslations(uint(id), req.Translations)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(category, c)
+}
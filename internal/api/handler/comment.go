@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/KeLes-Coding/gopress/internal/api/middleware"
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/KeLes-Coding/gopress/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+// CommentHandler 结构体，用于挂载与评论相关的方法。
+type CommentHandler struct {
+	commentService *service.CommentService
+}
+
+// NewCommentHandler 是 CommentHandler 的构造函数。
+func NewCommentHandler() *CommentHandler {
+	return &CommentHandler{
+		commentService: service.NewCommentService(),
+	}
+}
+
+// CreateCommentRequest 定义了发表评论接口的请求体。
+// 登录用户只需要填写 content；游客还需要填写 author_name 和 author_email。
+type CreateCommentRequest struct {
+	ParentID    *uint  `json:"parent_id"` // 回复的评论 ID，发表顶层评论时不传
+	AuthorName  string `json:"author_name" binding:"max=100"`
+	AuthorEmail string `json:"author_email" binding:"omitempty,email,max=255"`
+	AuthorURL   string `json:"author_url" binding:"omitempty,url,max=255"`
+	Content     string `json:"content" binding:"required"`
+
+	// 垃圾内容检测使用的字段
+	Website   string `json:"website"`    // 蜜罐字段，表单中对用户隐藏，正常提交时应当为空
+	FormToken string `json:"form_token"` // 通过 GET /api/v1/form-token 获取的表单令牌
+}
+
+// CreateCommentHandler 是发表评论的 Gin Handler。
+// 需要审核的评论返回的 status 为 pending，审核通过前不会出现在评论列表中。
+func (h *CommentHandler) CreateCommentHandler(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidPostID, c)
+		return
+	}
+
+	var req CreateCommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+
+	dto := &service.CreateCommentDTO{
+		PostID:      uint(postID),
+		ParentID:    req.ParentID,
+		AuthorName:  req.AuthorName,
+		AuthorEmail: req.AuthorEmail,
+		AuthorURL:   req.AuthorURL,
+		Content:     req.Content,
+		IP:          c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		Referrer:    c.Request.Referer(),
+		Honeypot:    req.Website,
+		FormToken:   req.FormToken,
+	}
+	// 登录用户的 claims 由 OptionalJWTAuthMiddleware 写入，游客没有
+	if _claims, ok := c.Get(middleware.CtxUserClaimsKey); ok {
+		dto.UserID = _claims.(*util.MyClaims).UserID
+	}
+
+	comment, err := h.commentService.Create(dto)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(comment, c)
+}
+
+// ListPostCommentsHandler 是获取文章评论列表的 Gin Handler，只返回已通过审核的评论。
+func (h *CommentHandler) ListPostCommentsHandler(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidPostID, c)
+		return
+	}
+	comments, err := h.commentService.ListForPost(uint(postID))
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(comments, c)
+}
+
+// ListCommentsHandler 是后台获取评论列表的 Gin Handler。
+// 支持 page、pageSize 分页参数，以及 status (pending, approved, spam, trash) 和 post_id 过滤参数。
+func (h *CommentHandler) ListCommentsHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	dto := &service.ListCommentsDTO{
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if name := c.Query("status"); name != "" {
+		status, ok := service.ParseCommentStatus(name)
+		if !ok {
+			response.Fail(errInvalidCommentStatus, c)
+			return
+		}
+		dto.Status = &status
+	}
+	if postID := c.Query("post_id"); postID != "" {
+		id, err := strconv.ParseUint(postID, 10, 32)
+		if err != nil {
+			response.Fail(errInvalidPostID, c)
+			return
+		}
+		dto.PostID = uint(id)
+	}
+
+	result, err := h.commentService.List(dto)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(result, c)
+}
+
+// ModerateCommentsRequest 定义了批量审核评论接口的请求体。
+type ModerateCommentsRequest struct {
+	IDs    []uint `json:"ids" binding:"required,min=1"`
+	Status string `json:"status" binding:"required,oneof=pending approved spam trash"`
+}
+
+// ModerateResponse 定义了批量审核接口返回的数据结构，评论和 Webmention 的审核共用。
+type ModerateResponse struct {
+	Updated int64 `json:"updated"` // 状态被修改的记录数
+}
+
+// ModerateCommentsHandler 是批量修改评论状态的 Gin Handler。
+func (h *CommentHandler) ModerateCommentsHandler(c *gin.Context) {
+	var req ModerateCommentsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+	status, _ := service.ParseCommentStatus(req.Status)
+	updated, err := h.commentService.Moderate(req.IDs, status)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(ModerateResponse{Updated: updated}, c)
+}
+
+// UpdateCommentStatusRequest 定义了修改单条评论状态接口的请求体。
+type UpdateCommentStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=pending approved spam trash"`
+}
+
+// UpdateCommentStatusHandler 是修改单条评论状态的 Gin Handler。
+func (h *CommentHandler) UpdateCommentStatusHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidCommentID, c)
+		return
+	}
+	var req UpdateCommentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+	status, _ := service.ParseCommentStatus(req.Status)
+	if err := h.commentService.UpdateStatus(uint(id), status); err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(nil, c)
+}
+
+// DeleteCommentHandler 是彻底删除评论（连同其回复）的 Gin Handler。
+func (h *CommentHandler) DeleteCommentHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidCommentID, c)
+		return
+	}
+	if err := h.commentService.Delete(uint(id)); err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(nil, c)
+}
+
+// EmptyTrashResponse 定义了清空回收站接口返回的数据结构。
+type EmptyTrashResponse struct {
+	Deleted int64 `json:"deleted"` // 被删除的评论数
+}
+
+// EmptyTrashHandler 是清空回收站和垃圾评论的 Gin Handler。
+func (h *CommentHandler) EmptyTrashHandler(c *gin.Context) {
+	deleted, err := h.commentService.EmptyTrash()
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(EmptyTrashResponse{Deleted: deleted}, c)
+}
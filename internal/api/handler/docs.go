@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/openapi"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DocsHandler 结构体，用于挂载 OpenAPI 文档和交互式文档页面。
+type DocsHandler struct {
+	spec func() ([]byte, error)
+}
+
+// NewDocsHandler 是 DocsHandler 的构造函数，spec 返回 JSON 格式的 OpenAPI 文档。
+// 文档由 api 包根据路由声明生成，handler 包不能反过来依赖 api 包，因此以函数的形式传入。
+func NewDocsHandler(spec func() ([]byte, error)) *DocsHandler {
+	return &DocsHandler{spec: spec}
+}
+
+// OpenAPIHandler 返回 OpenAPI 文档。
+func (h *DocsHandler) OpenAPIHandler(c *gin.Context) {
+	data, err := h.spec()
+	if err != nil {
+		logger.L.Error("Failed to build OpenAPI document", zap.Error(err))
+		c.String(http.StatusInternalServerError, "生成接口文档失败")
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// DocsPageHandler 返回交互式文档页面。
+func (h *DocsHandler) DocsPageHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", openapi.DocsPage)
+}
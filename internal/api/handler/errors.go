@@ -0,0 +1,36 @@
+package handler
+
+import "github.com/KeLes-Coding/gopress/internal/apperr"
+
+// handler 中直接返回的错误，错误码都是通用的 invalid_argument，消息各不相同。
+var (
+	errInvalidPostID           = apperr.ErrInvalidArgument.Variant("invalid_argument.post_id", "无效的文章 ID")
+	errInvalidCategoryID       = apperr.ErrInvalidArgument.Variant("invalid_argument.category_id", "无效的分类 ID")
+	errInvalidTagID            = apperr.ErrInvalidArgument.Variant("invalid_argument.tag_id", "无效的标签 ID")
+	errInvalidMediaID          = apperr.ErrInvalidArgument.Variant("invalid_argument.media_id", "无效的媒体 ID")
+	errInvalidCommentID        = apperr.ErrInvalidArgument.Variant("invalid_argument.comment_id", "无效的评论 ID")
+	errInvalidWebmentionID     = apperr.ErrInvalidArgument.Variant("invalid_argument.webmention_id", "无效的通知 ID")
+	errInvalidTokenID          = apperr.ErrInvalidArgument.Variant("invalid_argument.token_id", "无效的令牌 ID")
+	errInvalidWebhookID        = apperr.ErrInvalidArgument.Variant("invalid_argument.webhook_id", "无效的 Webhook ID")
+	errInvalidDeliveryID       = apperr.ErrInvalidArgument.Variant("invalid_argument.delivery_id", "无效的投递记录 ID")
+	errInvalidJobID            = apperr.ErrInvalidArgument.Variant("invalid_argument.job_id", "无效的任务 ID")
+	errInvalidCommentStatus    = apperr.ErrInvalidArgument.Variant("invalid_argument.comment_status", "无效的评论状态")
+	errInvalidWebmentionStatus = apperr.ErrInvalidArgument.Variant("invalid_argument.webmention_status", "无效的审核状态")
+	errInvalidVerification     = apperr.ErrInvalidArgument.Variant("invalid_argument.verification", "无效的校验状态")
+	errInvalidSendStatus       = apperr.ErrInvalidArgument.Variant("invalid_argument.send_status", "无效的发送状态")
+	errInvalidDeliveryStatus   = apperr.ErrInvalidArgument.Variant("invalid_argument.delivery_status", "无效的投递状态")
+	errInvalidJobStatus        = apperr.ErrInvalidArgument.Variant("invalid_argument.job_status", "无效的任务状态")
+	errNoUploadFile            = apperr.ErrInvalidArgument.Variant("invalid_argument.no_file", "请选择要上传的文件")
+	errSignUpRejected          = apperr.ErrInvalidArgument.Variant("invalid_argument.signup_rejected", "注册失败，请稍后重试")
+	errInvalidUserID           = apperr.ErrInvalidArgument.Variant("invalid_argument.user_id", "无效的用户 ID")
+	errInvalidPageSize         = apperr.ErrInvalidArgument.Variant("invalid_argument.page_size", "每页数量必须在 1 到 %d 之间")
+	errInvalidOffset           = apperr.ErrInvalidArgument.Variant("invalid_argument.offset", "偏移量不能为负数")
+
+	// GraphQL 请求本身无效，无法执行
+	errMissingGraphQLQuery     = apperr.ErrInvalidArgument.Variant("invalid_argument.graphql_query", "缺少 GraphQL 查询")
+	errInvalidGraphQLBody      = apperr.ErrInvalidArgument.Variant("invalid_argument.graphql_body", "无效的 GraphQL 请求体")
+	errInvalidGraphQLVariables = apperr.ErrInvalidArgument.Variant("invalid_argument.graphql_variables", "variables 必须是 JSON 对象")
+
+	// GraphQL 中需要登录的操作在未登录时返回
+	errLoginRequired = apperr.ErrUnauthenticated.Variant("unauthenticated.login_required", "该操作需要登录")
+)
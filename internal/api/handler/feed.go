@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/feed"
+	"github.com/KeLes-Coding/gopress/internal/i18n"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// FeedHandler 结构体，用于挂载与订阅源相关的方法。
+type FeedHandler struct {
+	feedService *service.FeedService
+}
+
+// NewFeedHandler 是 FeedHandler 的构造函数。
+func NewFeedHandler() *FeedHandler {
+	return &FeedHandler{
+		feedService: service.NewFeedService(),
+	}
+}
+
+// RSSHandler 输出 RSS 2.0 格式的订阅源。
+func (h *FeedHandler) RSSHandler(c *gin.Context) {
+	h.serve(c, feed.FormatRSS)
+}
+
+// AtomHandler 输出 Atom 1.0 格式的订阅源。
+func (h *FeedHandler) AtomHandler(c *gin.Context) {
+	h.serve(c, feed.FormatAtom)
+}
+
+// JSONFeedHandler 输出 JSON Feed 1.1 格式的订阅源。
+func (h *FeedHandler) JSONFeedHandler(c *gin.Context) {
+	h.serve(c, feed.FormatJSON)
+}
+
+// serve 生成订阅源并处理条件请求。
+// 路由中的 :category_id、:tag_id、:user_id 参数决定了订阅源的范围，查询参数 mode 可以选择 full 或 summary，
+// 查询参数 lang 可以只订阅某种语言的文章。
+// 订阅源不是给前端调用的 JSON API，因此这里使用标准的 HTTP 状态码。
+func (h *FeedHandler) serve(c *gin.Context, format string) {
+	query := &service.FeedQuery{
+		Format:  format,
+		Mode:    c.Query("mode"),
+		Lang:    c.Query("lang"),
+		FeedURL: requestURL(c),
+	}
+	// 不同语言的订阅源是不同的文档，自身地址需要带上语言参数
+	if query.Lang != "" {
+		query.FeedURL += "?lang=" + url.QueryEscape(query.Lang)
+	}
+	var ok bool
+	if query.CategoryID, ok = optionalIDParam(c, "category_id"); !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if query.TagID, ok = optionalIDParam(c, "tag_id"); !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	if query.UserID, ok = optionalIDParam(c, "user_id"); !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	result, err := h.feedService.Build(query)
+	if err != nil {
+		if errors.Is(err, service.ErrCategoryNotFound) || errors.Is(err, service.ErrTagNotFound) || errors.Is(err, service.ErrAuthorNotFound) ||
+			errors.Is(err, service.ErrUnsupportedContentLanguage) {
+			c.String(http.StatusNotFound, i18n.Error(i18n.FromContext(c), err))
+			return
+		}
+		logger.L.Error("Failed to build feed", zap.Error(err))
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	serveDocument(c, result)
+}
+
+// notModified 判断条件请求是否可以返回 304。
+// 按照 RFC 9110，请求同时携带 If-None-Match 和 If-Modified-Since 时只比较 ETag。
+func notModified(c *gin.Context, etag string, lastModified time.Time) bool {
+	if inm := c.GetHeader("If-None-Match"); inm != "" {
+		return inm == "*" || etagListContains(inm, etag)
+	}
+	if ims := c.GetHeader("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		if t, err := http.ParseTime(ims); err == nil {
+			return !lastModified.After(t)
+		}
+	}
+	return false
+}
+
+// etagListContains 判断 If-None-Match 头中的 ETag 列表是否包含 etag（弱比较）。
+func etagListContains(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// optionalIDParam 读取可选的数字路由参数，参数不存在时返回 0；参数存在但无效时 ok 为 false。
+func optionalIDParam(c *gin.Context, name string) (id uint, ok bool) {
+	value := c.Param(name)
+	if value == "" {
+		return 0, true
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil || n == 0 {
+		return 0, false
+	}
+	return uint(n), true
+}
+
+// requestURL 返回当前请求的完整地址（不含查询参数），会参考反向代理设置的 X-Forwarded-Proto 头。
+func requestURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + c.Request.URL.Path
+}
@@ -0,0 +1,297 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/KeLes-Coding/gopress/internal/api/middleware"
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/apperr"
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/graphql"
+	"github.com/KeLes-Coding/gopress/internal/i18n"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/KeLes-Coding/gopress/internal/util"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+)
+
+// graphQLMaxBodySize 是 POST 请求体的大小上限。
+const graphQLMaxBodySize = 1 << 20
+
+// GraphQLHandler 结构体，用于挂载 GraphQL 接口。
+// 模式在创建时构建一次，resolver 直接调用 service 层；每个请求单独创建 Loader，用于批量加载关联数据。
+// 按照 GraphQL over HTTP 的约定，请求能够执行时总是返回 200，错误放在响应的 errors 中。
+type GraphQLHandler struct {
+	schema *graphql.Schema
+
+	postService     *service.PostService
+	categoryService *service.CategoryService
+	tagService      *service.TagService
+	userService     *service.UserService
+	commentService  *service.CommentService
+}
+
+// NewGraphQLHandler 是 GraphQLHandler 的构造函数。模式定义有误时会 panic，这属于编程错误。
+func NewGraphQLHandler() *GraphQLHandler {
+	h := &GraphQLHandler{
+		postService:     service.NewPostService(),
+		categoryService: service.NewCategoryService(),
+		tagService:      service.NewTagService(),
+		userService:     service.NewUserService(),
+		commentService:  service.NewCommentService(),
+	}
+	schema, err := h.buildSchema()
+	if err != nil {
+		panic(err)
+	}
+	h.schema = schema
+	return h
+}
+
+// graphQLRequest 是 GraphQL 请求的参数。
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQLHandler 是 GraphQL 接口的 Gin Handler。
+// GET 请求通过查询参数 query、operationName 和 variables（JSON 字符串）传参，只能执行查询；
+// POST 请求使用 JSON 请求体，或者 Content-Type 为 application/graphql 时请求体就是查询文本。
+func (h *GraphQLHandler) GraphQLHandler(c *gin.Context) {
+	var req graphQLRequest
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := decodeJSONNumber(strings.NewReader(variables), &req.Variables); err != nil {
+				h.writeRequestError(c, errInvalidGraphQLVariables)
+				return
+			}
+		}
+	} else {
+		body := http.MaxBytesReader(c.Writer, c.Request.Body, graphQLMaxBodySize)
+		mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+		if mediaType == "application/graphql" {
+			data, err := io.ReadAll(body)
+			if err != nil {
+				h.writeRequestError(c, errInvalidGraphQLBody)
+				return
+			}
+			req.Query = string(data)
+		} else if err := decodeJSONNumber(body, &req); err != nil {
+			h.writeRequestError(c, errInvalidGraphQLBody)
+			return
+		}
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		h.writeRequestError(c, errMissingGraphQLQuery)
+		return
+	}
+
+	gqlCtx := &graphQLContext{gin: c, loaders: h.newLoaders()}
+	if _claims, ok := c.Get(middleware.CtxUserClaimsKey); ok {
+		gqlCtx.claims = _claims.(*util.MyClaims)
+	}
+	cfg := config.Conf.GraphQL
+	result := graphql.Do(graphql.Params{
+		Schema:        h.schema,
+		Query:         req.Query,
+		OperationName: req.OperationName,
+		Variables:     req.Variables,
+		Context:       context.WithValue(c.Request.Context(), graphQLContextKey{}, gqlCtx),
+		QueryOnly:     c.Request.Method == http.MethodGet,
+		MaxDepth:      cfg.MaxDepth,
+		MaxComplexity: cfg.MaxComplexity,
+		MaxFields:     cfg.MaxFields,
+		PresentError:  presentGraphQLError(i18n.FromContext(c)),
+	})
+	c.JSON(http.StatusOK, result)
+}
+
+// decodeJSONNumber 解码 JSON，数字保留为 json.Number，避免大整数丢失精度。
+func decodeJSONNumber(r io.Reader, v interface{}) error {
+	d := json.NewDecoder(r)
+	d.UseNumber()
+	return d.Decode(v)
+}
+
+// writeRequestError 写入无法执行的请求的错误，使用 GraphQL 的错误格式和 400 状态码。
+func (h *GraphQLHandler) writeRequestError(c *gin.Context, err *apperr.Error) {
+	c.JSON(http.StatusBadRequest, gin.H{"errors": []*graphql.Error{{
+		Message:    i18n.Message(i18n.FromContext(c), err),
+		Extensions: map[string]interface{}{"code": err.Code},
+	}}})
+}
+
+// presentGraphQLError 返回将 resolver 的错误转换为响应中的错误的函数，消息使用 lang 语言。
+// 与标准响应一致：extensions.code 是稳定的错误码，参数校验失败时 extensions.fields 是字段错误列表，
+// 其他错误按服务器内部错误处理，错误只记录在日志中。
+func presentGraphQLError(lang string) func(err error) *graphql.Error {
+	return func(err error) *graphql.Error {
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			msg, fields := response.ValidationDetails(err, lang)
+			return &graphql.Error{Message: msg, Extensions: map[string]interface{}{
+				"code":   apperr.CodeValidationFailed,
+				"fields": fields,
+			}}
+		}
+		if e, ok := apperr.From(err); ok {
+			return &graphql.Error{Message: i18n.Message(lang, e), Extensions: map[string]interface{}{"code": e.Code}}
+		}
+		logger.L.Error("GraphQL resolver failed", zap.Error(err))
+		return &graphql.Error{
+			Message:    i18n.Message(lang, apperr.ErrInternal),
+			Extensions: map[string]interface{}{"code": apperr.CodeInternal},
+		}
+	}
+}
+
+// graphQLContextKey 是请求上下文在 context.Context 中的键。
+type graphQLContextKey struct{}
+
+// graphQLContext 是一次 GraphQL 请求的上下文，resolver 通过 requestContext 获取。
+type graphQLContext struct {
+	gin     *gin.Context
+	claims  *util.MyClaims // 当前登录用户，未登录时为 nil
+	loaders *graphQLLoaders
+}
+
+// requestContext 返回 resolver 所在请求的上下文。
+func requestContext(ctx context.Context) *graphQLContext {
+	return ctx.Value(graphQLContextKey{}).(*graphQLContext)
+}
+
+// viewer 返回当前登录用户，未登录时返回 errLoginRequired，用于需要登录的修改操作。
+func viewer(ctx context.Context) (*util.MyClaims, error) {
+	if claims := requestContext(ctx).claims; claims != nil {
+		return claims, nil
+	}
+	return nil, errLoginRequired
+}
+
+// graphQLLoaders 是一个请求内使用的 Loader，键都是 ID。
+type graphQLLoaders struct {
+	users        *graphql.Loader // 用户 ID -> *model.User
+	posts        *graphql.Loader // 文章 ID -> *model.Post（包含草稿）
+	categories   *graphql.Loader // 分类 ID -> *model.Category（包含翻译）
+	tags         *graphql.Loader // 标签 ID -> *model.Tag（包含翻译）
+	translations *graphql.Loader // 文章 ID -> []model.PostTranslation，其他已发布的语言版本
+	comments     *graphql.Loader // 文章 ID -> []*service.CommentDTO，已通过审核的顶层评论
+	replies      *graphql.Loader // 评论 ID -> []*service.CommentDTO，已通过审核的直接回复
+
+	categoryPostCounts *graphql.Loader // 分类 ID -> int64，已发布的文章数
+	tagPostCounts      *graphql.Loader // 标签 ID -> int64，已发布的文章数
+	userPostCounts     *graphql.Loader // 用户 ID -> int64，已发布的文章数
+}
+
+// newLoaders 为一个请求创建 Loader。
+func (h *GraphQLHandler) newLoaders() *graphQLLoaders {
+	return &graphQLLoaders{
+		users: graphql.NewLoader(func(ids []uint) (map[uint]interface{}, error) {
+			users, err := h.userService.GetByIDs(ids)
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[uint]interface{}, len(users))
+			for i := range users {
+				result[users[i].ID] = &users[i]
+			}
+			return result, nil
+		}),
+		posts: graphql.NewLoader(func(ids []uint) (map[uint]interface{}, error) {
+			posts, err := h.postService.GetByIDs(ids)
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[uint]interface{}, len(posts))
+			for i := range posts {
+				result[posts[i].ID] = &posts[i]
+			}
+			return result, nil
+		}),
+		categories: graphql.NewLoader(func(ids []uint) (map[uint]interface{}, error) {
+			categories, err := h.categoryService.GetByIDs(ids)
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[uint]interface{}, len(categories))
+			for i := range categories {
+				result[categories[i].ID] = &categories[i]
+			}
+			return result, nil
+		}),
+		tags: graphql.NewLoader(func(ids []uint) (map[uint]interface{}, error) {
+			tags, err := h.tagService.GetByIDs(ids)
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[uint]interface{}, len(tags))
+			for i := range tags {
+				result[tags[i].ID] = &tags[i]
+			}
+			return result, nil
+		}),
+		translations: graphql.NewLoader(func(ids []uint) (map[uint]interface{}, error) {
+			groups, err := h.postService.PublishedTranslations(ids)
+			if err != nil {
+				return nil, err
+			}
+			result := make(map[uint]interface{}, len(ids))
+			for _, id := range ids {
+				result[id] = groups[id]
+			}
+			return result, nil
+		}),
+		comments:           commentGroupLoader(h.commentService.ListApprovedByPosts),
+		replies:            commentGroupLoader(h.commentService.ListApprovedReplies),
+		categoryPostCounts: countLoader(h.categoryService.CountPublishedPosts),
+		tagPostCounts:      countLoader(h.tagService.CountPublishedPosts),
+		userPostCounts:     countLoader(h.postService.CountPublishedByUsers),
+	}
+}
+
+// commentGroupLoader 创建按 ID 分组加载评论的 Loader，没有评论的 ID 结果为空列表。
+func commentGroupLoader(list func(ids []uint) (map[uint][]*service.CommentDTO, error)) *graphql.Loader {
+	return graphql.NewLoader(func(ids []uint) (map[uint]interface{}, error) {
+		groups, err := list(ids)
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[uint]interface{}, len(ids))
+		for _, id := range ids {
+			result[id] = groups[id]
+		}
+		return result, nil
+	})
+}
+
+// countLoader 创建批量统计数量的 Loader，没有统计结果的 ID 数量为 0。
+func countLoader(count func(ids []uint) (map[uint]int64, error)) *graphql.Loader {
+	return graphql.NewLoader(func(ids []uint) (map[uint]interface{}, error) {
+		counts, err := count(ids)
+		if err != nil {
+			return nil, err
+		}
+		result := make(map[uint]interface{}, len(ids))
+		for _, id := range ids {
+			result[id] = counts[id]
+		}
+		return result, nil
+	})
+}
+
+// visiblePost 判断当前请求能否看到文章：已发布的文章对所有人可见，草稿只对登录用户可见，与后台接口的权限一致。
+func visiblePost(ctx context.Context, post *model.Post) bool {
+	return post.Status == model.PostStatusPublished || requestContext(ctx).claims != nil
+}
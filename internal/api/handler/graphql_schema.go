@@ -0,0 +1,906 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/KeLes-Coding/gopress/internal/apperr"
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/graphql"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// 未分页的列表字段（分类列表、评论、标签等）在计算复杂度时按 listComplexityFactor 个元素估算。
+const listComplexityFactor = 10
+
+// listComplexity 是未分页的列表字段的复杂度。
+func listComplexity(_ map[string]interface{}, childComplexity int) int {
+	return 1 + listComplexityFactor*childComplexity
+}
+
+// buildSchema 构建 GraphQL 模式。
+// 对象类型的 resolver 的 Source 分别是 *model.Post、*model.User、*model.Category、*model.Tag、
+// *service.CommentDTO、model.PostTranslation 和 nameTranslation。
+func (h *GraphQLHandler) buildSchema() (*graphql.Schema, error) {
+	postStatus := &graphql.Enum{
+		Name:        "PostStatus",
+		Description: "文章的状态。",
+		Values: []*graphql.EnumValue{
+			{Name: "DRAFT", Description: "草稿，只有登录用户可见。", Value: model.PostStatusDraft},
+			{Name: "PUBLISHED", Description: "已发布。", Value: model.PostStatusPublished},
+		},
+	}
+	commentStatus := &graphql.Enum{
+		Name:        "CommentStatus",
+		Description: "评论的审核状态。",
+		Values: []*graphql.EnumValue{
+			{Name: "PENDING", Description: "待审核。", Value: service.CommentStatusNames[model.CommentStatusPending]},
+			{Name: "APPROVED", Description: "已通过。", Value: service.CommentStatusNames[model.CommentStatusApproved]},
+			{Name: "SPAM", Description: "垃圾评论。", Value: service.CommentStatusNames[model.CommentStatusSpam]},
+			{Name: "TRASH", Description: "已移入回收站。", Value: service.CommentStatusNames[model.CommentStatusTrash]},
+		},
+	}
+
+	nameTranslationType := &graphql.Object{
+		Name:        "NameTranslation",
+		Description: "分类或标签名称在某种语言下的翻译。",
+		Fields: []*graphql.Field{
+			{Name: "lang", Type: graphql.NonNullOf(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(nameTranslation).Lang, nil
+			}},
+			{Name: "name", Type: graphql.NonNullOf(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(nameTranslation).Name, nil
+			}},
+		},
+	}
+	langArg := &graphql.Argument{Name: "lang", Type: graphql.String, Description: "语言，例如 en；不传时返回原名称。"}
+
+	userType := &graphql.Object{Name: "User", Description: "用户，即文章的作者。"}
+	categoryType := &graphql.Object{Name: "Category", Description: "文章分类。"}
+	tagType := &graphql.Object{Name: "Tag", Description: "文章标签。"}
+	postType := &graphql.Object{Name: "Post", Description: "文章。"}
+	commentType := &graphql.Object{Name: "Comment", Description: "已通过审核的评论，不包含邮箱、IP 等隐私信息。"}
+	postTranslationType := &graphql.Object{
+		Name:        "PostTranslation",
+		Description: "文章的一个已发布的其他语言版本。",
+		Fields: []*graphql.Field{
+			{Name: "id", Type: graphql.NonNullOf(graphql.ID), Description: "该语言版本的文章 ID。", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.PostTranslation).ID, nil
+			}},
+			{Name: "lang", Type: graphql.NonNullOf(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.PostTranslation).Lang, nil
+			}},
+			{Name: "title", Type: graphql.NonNullOf(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(model.PostTranslation).Title, nil
+			}},
+		},
+	}
+	postConnectionType := &graphql.Object{
+		Name:        "PostConnection",
+		Description: "分页的文章列表。",
+		Fields: []*graphql.Field{
+			{Name: "totalCount", Type: graphql.NonNullOf(graphql.Int), Description: "符合条件的文章总数。"},
+			{Name: "nodes", Type: graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(postType))), Description: "当前页的文章。"},
+		},
+	}
+
+	userType.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.NonNullOf(graphql.ID), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.User).ID, nil
+		}},
+		{Name: "username", Type: graphql.NonNullOf(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.User).Username, nil
+		}},
+		{Name: "nickname", Type: graphql.NonNullOf(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.User).Nickname, nil
+		}},
+		{Name: "email", Type: graphql.String, Description: "邮箱，只有用户本人可见，其他情况为 null。", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			user := p.Source.(*model.User)
+			if claims := requestContext(p.Context).claims; claims == nil || claims.UserID != user.ID {
+				return nil, nil
+			}
+			return user.Email, nil
+		}},
+		{Name: "postCount", Type: graphql.NonNullOf(graphql.Int), Description: "已发布的文章数。", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return requestContext(p.Context).loaders.userPostCounts.Load(p.Source.(*model.User).ID), nil
+		}},
+		{Name: "createdAt", Type: graphql.NonNullOf(graphql.DateTime), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.User).CreatedAt, nil
+		}},
+	}
+
+	categoryType.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.NonNullOf(graphql.ID), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Category).ID, nil
+		}},
+		{Name: "name", Type: graphql.NonNullOf(graphql.String), Args: []*graphql.Argument{langArg}, Description: "分类名称，没有对应语言的翻译时返回原名称。",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				category := p.Source.(*model.Category)
+				return localizedName(p.Args, category.Name, categoryNameTranslations(category))
+			}},
+		{Name: "translations", Type: graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(nameTranslationType))), Complexity: listComplexity,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return categoryNameTranslations(p.Source.(*model.Category)), nil
+			}},
+		{Name: "postCount", Type: graphql.NonNullOf(graphql.Int), Description: "已发布的文章数。", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return requestContext(p.Context).loaders.categoryPostCounts.Load(p.Source.(*model.Category).ID), nil
+		}},
+	}
+
+	tagType.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.NonNullOf(graphql.ID), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Tag).ID, nil
+		}},
+		{Name: "name", Type: graphql.NonNullOf(graphql.String), Args: []*graphql.Argument{langArg}, Description: "标签名称，没有对应语言的翻译时返回原名称。",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				tag := p.Source.(*model.Tag)
+				return localizedName(p.Args, tag.Name, tagNameTranslations(tag))
+			}},
+		{Name: "translations", Type: graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(nameTranslationType))), Complexity: listComplexity,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return tagNameTranslations(p.Source.(*model.Tag)), nil
+			}},
+		{Name: "postCount", Type: graphql.NonNullOf(graphql.Int), Description: "已发布的文章数。", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return requestContext(p.Context).loaders.tagPostCounts.Load(p.Source.(*model.Tag).ID), nil
+		}},
+	}
+
+	postType.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.NonNullOf(graphql.ID), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Post).ID, nil
+		}},
+		{Name: "title", Type: graphql.NonNullOf(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Post).Title, nil
+		}},
+		{Name: "content", Type: graphql.NonNullOf(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Post).Content, nil
+		}},
+		{Name: "summary", Type: graphql.NonNullOf(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Post).Summary, nil
+		}},
+		{Name: "status", Type: graphql.NonNullOf(postStatus), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Post).Status, nil
+		}},
+		{Name: "lang", Type: graphql.NonNullOf(graphql.String), Description: "文章的语言，没有记录语言的文章为站点的默认语言。", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return service.PostLang(p.Source.(*model.Post)), nil
+		}},
+		{Name: "commentsEnabled", Type: graphql.NonNullOf(graphql.Boolean), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Post).CommentsEnabled, nil
+		}},
+		{Name: "commentCount", Type: graphql.NonNullOf(graphql.Int), Description: "已通过审核的评论数。", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Post).CommentCount, nil
+		}},
+		{Name: "createdAt", Type: graphql.NonNullOf(graphql.DateTime), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Post).CreatedAt, nil
+		}},
+		{Name: "updatedAt", Type: graphql.NonNullOf(graphql.DateTime), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*model.Post).UpdatedAt, nil
+		}},
+		{Name: "author", Type: graphql.NonNullOf(userType), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			post := p.Source.(*model.Post)
+			if post.User.ID != 0 {
+				return &post.User, nil
+			}
+			return requestContext(p.Context).loaders.users.Load(post.UserID), nil
+		}},
+		{Name: "category", Type: graphql.NonNullOf(categoryType), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			// 预加载的分类不包含翻译，统一通过 Loader 加载
+			return requestContext(p.Context).loaders.categories.Load(p.Source.(*model.Post).CategoryID), nil
+		}},
+		{Name: "tags", Type: graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(tagType))), Complexity: listComplexity,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				post := p.Source.(*model.Post)
+				loader := requestContext(p.Context).loaders.tags
+				thunks := make([]graphql.Thunk, len(post.Tags))
+				for i, tag := range post.Tags {
+					thunks[i] = loader.Load(tag.ID)
+				}
+				return graphql.Thunk(func() (interface{}, error) {
+					tags := make([]*model.Tag, 0, len(thunks))
+					for _, thunk := range thunks {
+						tag, err := thunk()
+						if err != nil {
+							return nil, err
+						}
+						if tag != nil {
+							tags = append(tags, tag.(*model.Tag))
+						}
+					}
+					return tags, nil
+				}), nil
+			}},
+		{Name: "translations", Type: graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(postTranslationType))), Complexity: listComplexity,
+			Description: "文章的其他已发布的语言版本。",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return requestContext(p.Context).loaders.translations.Load(p.Source.(*model.Post).ID), nil
+			}},
+		{Name: "translationOf", Type: postType, Description: "原文，文章本身是原文时为 null。", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			post := p.Source.(*model.Post)
+			if post.TranslationOf == nil {
+				return nil, nil
+			}
+			return h.loadVisiblePost(p.Context, *post.TranslationOf), nil
+		}},
+		{Name: "comments", Type: graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(commentType))), Complexity: listComplexity,
+			Description: "已通过审核的顶层评论，按发表时间排列，回复通过 replies 获取。",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return requestContext(p.Context).loaders.comments.Load(p.Source.(*model.Post).ID), nil
+			}},
+	}
+
+	commentType.Fields = []*graphql.Field{
+		{Name: "id", Type: graphql.NonNullOf(graphql.ID), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*service.CommentDTO).ID, nil
+		}},
+		{Name: "parentId", Type: graphql.ID, Description: "回复的评论 ID，顶层评论为 null。", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if parentID := p.Source.(*service.CommentDTO).ParentID; parentID != nil {
+				return *parentID, nil
+			}
+			return nil, nil
+		}},
+		{Name: "content", Type: graphql.NonNullOf(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*service.CommentDTO).Content, nil
+		}},
+		{Name: "authorName", Type: graphql.NonNullOf(graphql.String), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*service.CommentDTO).AuthorName, nil
+		}},
+		{Name: "authorUrl", Type: graphql.String, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if url := p.Source.(*service.CommentDTO).AuthorURL; url != "" {
+				return url, nil
+			}
+			return nil, nil
+		}},
+		{Name: "author", Type: userType, Description: "登录用户发表的评论的作者，游客的评论为 null。", Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			if userID := p.Source.(*service.CommentDTO).UserID; userID != nil {
+				return requestContext(p.Context).loaders.users.Load(*userID), nil
+			}
+			return nil, nil
+		}},
+		{Name: "status", Type: graphql.NonNullOf(commentStatus), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*service.CommentDTO).Status, nil
+		}},
+		{Name: "createdAt", Type: graphql.NonNullOf(graphql.DateTime), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return p.Source.(*service.CommentDTO).CreatedAt, nil
+		}},
+		{Name: "replies", Type: graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(commentType))), Complexity: listComplexity,
+			Description: "已通过审核的直接回复。",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return requestContext(p.Context).loaders.replies.Load(p.Source.(*service.CommentDTO).ID), nil
+			}},
+	}
+
+	query := &graphql.Object{
+		Name: "Query",
+		Fields: []*graphql.Field{
+			{
+				Name:        "post",
+				Type:        postType,
+				Description: "获取单篇文章，不存在时为 null；草稿只有登录用户可以获取。传入 lang 时返回该语言的版本，没有时依次回退到默认语言的版本和原文。",
+				Args: []*graphql.Argument{
+					{Name: "id", Type: graphql.NonNullOf(graphql.ID)},
+					{Name: "lang", Type: graphql.String},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := parseID(p.Args["id"], errInvalidPostID)
+					if err != nil {
+						return nil, err
+					}
+					lang, err := contentLangArg(p.Args)
+					if err != nil {
+						return nil, err
+					}
+					post, err := h.postService.GetTranslation(id, lang)
+					if err != nil {
+						if errors.Is(err, service.ErrPostNotFound) {
+							return nil, nil
+						}
+						return nil, err
+					}
+					if !visiblePost(p.Context, post) {
+						return nil, nil
+					}
+					return post, nil
+				},
+			},
+			{
+				Name:        "posts",
+				Type:        graphql.NonNullOf(postConnectionType),
+				Description: "按发布时间倒序列出已发布的文章。",
+				Args: []*graphql.Argument{
+					{Name: "first", Type: graphql.Int, DefaultValue: 10, Description: "每页数量，最大值由配置项 graphql.max_page_size 决定。"},
+					{Name: "offset", Type: graphql.Int, DefaultValue: 0},
+					{Name: "categoryId", Type: graphql.ID},
+					{Name: "tagId", Type: graphql.ID},
+					{Name: "authorId", Type: graphql.ID},
+					{Name: "lang", Type: graphql.String, Description: "只列出该语言的文章。"},
+				},
+				Complexity: func(args map[string]interface{}, childComplexity int) int {
+					first, _ := args["first"].(int)
+					if first < 1 {
+						first = 1
+					}
+					return 1 + first*childComplexity
+				},
+				Resolve: h.resolvePosts,
+			},
+			{
+				Name:        "category",
+				Type:        categoryType,
+				Description: "获取单个分类，不存在时为 null。",
+				Args:        []*graphql.Argument{{Name: "id", Type: graphql.NonNullOf(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := parseID(p.Args["id"], errInvalidCategoryID)
+					if err != nil {
+						return nil, err
+					}
+					return requestContext(p.Context).loaders.categories.Load(id), nil
+				},
+			},
+			{
+				Name:       "categories",
+				Type:       graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(categoryType))),
+				Complexity: listComplexity,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					categories, err := h.categoryService.List()
+					if err != nil {
+						return nil, err
+					}
+					result := make([]*model.Category, len(categories))
+					for i := range categories {
+						result[i] = &categories[i]
+					}
+					return result, nil
+				},
+			},
+			{
+				Name:        "tag",
+				Type:        tagType,
+				Description: "获取单个标签，不存在时为 null。",
+				Args:        []*graphql.Argument{{Name: "id", Type: graphql.NonNullOf(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := parseID(p.Args["id"], errInvalidTagID)
+					if err != nil {
+						return nil, err
+					}
+					return requestContext(p.Context).loaders.tags.Load(id), nil
+				},
+			},
+			{
+				Name:       "tags",
+				Type:       graphql.NonNullOf(graphql.ListOf(graphql.NonNullOf(tagType))),
+				Complexity: listComplexity,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					tags, err := h.tagService.List()
+					if err != nil {
+						return nil, err
+					}
+					result := make([]*model.Tag, len(tags))
+					for i := range tags {
+						result[i] = &tags[i]
+					}
+					return result, nil
+				},
+			},
+			{
+				Name:        "user",
+				Type:        userType,
+				Description: "获取单个用户，不存在时为 null。",
+				Args:        []*graphql.Argument{{Name: "id", Type: graphql.NonNullOf(graphql.ID)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					id, err := parseID(p.Args["id"], errInvalidUserID)
+					if err != nil {
+						return nil, err
+					}
+					return requestContext(p.Context).loaders.users.Load(id), nil
+				},
+			},
+			{
+				Name:        "me",
+				Type:        userType,
+				Description: "当前登录的用户，未登录时为 null。",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					claims := requestContext(p.Context).claims
+					if claims == nil {
+						return nil, nil
+					}
+					return requestContext(p.Context).loaders.users.Load(claims.UserID), nil
+				},
+			},
+		},
+	}
+
+	mutation := h.buildMutation(postType, categoryType, tagType, commentType, postStatus)
+	return graphql.NewSchema(query, mutation)
+}
+
+// buildMutation 构建修改操作。除了发表评论，其他操作都需要登录，与 REST 接口的权限一致。
+func (h *GraphQLHandler) buildMutation(postType, categoryType, tagType, commentType *graphql.Object, postStatus *graphql.Enum) *graphql.Object {
+	postInputFields := func(create bool) []*graphql.Argument {
+		fields := []*graphql.Argument{
+			{Name: "title", Type: graphql.NonNullOf(graphql.String)},
+			{Name: "content", Type: graphql.NonNullOf(graphql.String)},
+			{Name: "summary", Type: graphql.String},
+			{Name: "status", Type: graphql.NonNullOf(postStatus)},
+			{Name: "categoryId", Type: graphql.NonNullOf(graphql.ID)},
+			{Name: "tagIds", Type: graphql.ListOf(graphql.NonNullOf(graphql.ID)), Description: "已有标签的 ID。"},
+			{Name: "tags", Type: graphql.ListOf(graphql.NonNullOf(graphql.String)), Description: "标签名称，不存在的标签会被自动创建。"},
+			{Name: "lang", Type: graphql.String},
+			{Name: "metaTitle", Type: graphql.String},
+			{Name: "metaDescription", Type: graphql.String},
+			{Name: "canonicalUrl", Type: graphql.String},
+			{Name: "noIndex", Type: graphql.Boolean},
+			{Name: "ogImageId", Type: graphql.ID, Description: "分享卡片图片的媒体 ID，不传时自动使用正文中的第一张图片。"},
+		}
+		if create {
+			return append(fields,
+				&graphql.Argument{Name: "commentsEnabled", Type: graphql.Boolean, Description: "是否允许评论，不传时默认允许。"},
+				&graphql.Argument{Name: "translationOf", Type: graphql.ID, Description: "原文的 ID，传入时新文章作为原文的翻译版本。"},
+			)
+		}
+		return append(fields, &graphql.Argument{Name: "commentsEnabled", Type: graphql.Boolean, Description: "是否允许评论，不传时保持不变。"})
+	}
+	createPostInput := &graphql.InputObject{Name: "CreatePostInput", Fields: postInputFields(true)}
+	updatePostInput := &graphql.InputObject{Name: "UpdatePostInput", Fields: postInputFields(false)}
+	createCommentInput := &graphql.InputObject{
+		Name:        "CreateCommentInput",
+		Description: "登录用户只需要填写 content；游客还需要填写 authorName 和 authorEmail。",
+		Fields: []*graphql.Argument{
+			{Name: "parentId", Type: graphql.ID, Description: "回复的评论 ID，发表顶层评论时不传。"},
+			{Name: "authorName", Type: graphql.String},
+			{Name: "authorEmail", Type: graphql.String},
+			{Name: "authorUrl", Type: graphql.String},
+			{Name: "content", Type: graphql.NonNullOf(graphql.String)},
+			{Name: "website", Type: graphql.String, Description: "蜜罐字段，正常提交时应当为空。"},
+			{Name: "formToken", Type: graphql.String, Description: "通过 GET /api/v1/form-token 获取的表单令牌。"},
+		},
+	}
+
+	idArg := &graphql.Argument{Name: "id", Type: graphql.NonNullOf(graphql.ID)}
+	nameArg := &graphql.Argument{Name: "name", Type: graphql.NonNullOf(graphql.String)}
+
+	return &graphql.Object{
+		Name: "Mutation",
+		Fields: []*graphql.Field{
+			{
+				Name:    "createPost",
+				Type:    graphql.NonNullOf(postType),
+				Args:    []*graphql.Argument{{Name: "input", Type: graphql.NonNullOf(createPostInput)}},
+				Resolve: h.resolveCreatePost,
+			},
+			{
+				Name:    "updatePost",
+				Type:    graphql.NonNullOf(postType),
+				Args:    []*graphql.Argument{idArg, {Name: "input", Type: graphql.NonNullOf(updatePostInput)}},
+				Resolve: h.resolveUpdatePost,
+			},
+			{
+				Name: "deletePost",
+				Type: graphql.NonNullOf(graphql.Boolean),
+				Args: []*graphql.Argument{idArg},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return h.deleteByID(p, errInvalidPostID, h.postService.Delete)
+				},
+			},
+			{
+				Name: "createCategory",
+				Type: graphql.NonNullOf(categoryType),
+				Args: []*graphql.Argument{nameArg},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					name, err := nameInputArg(p)
+					if err != nil {
+						return nil, err
+					}
+					return h.categoryService.Create(name)
+				},
+			},
+			{
+				Name: "updateCategory",
+				Type: graphql.NonNullOf(categoryType),
+				Args: []*graphql.Argument{idArg, nameArg},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					name, err := nameInputArg(p)
+					if err != nil {
+						return nil, err
+					}
+					id, err := parseID(p.Args["id"], errInvalidCategoryID)
+					if err != nil {
+						return nil, err
+					}
+					return h.categoryService.Update(id, name)
+				},
+			},
+			{
+				Name: "deleteCategory",
+				Type: graphql.NonNullOf(graphql.Boolean),
+				Args: []*graphql.Argument{idArg},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return h.deleteByID(p, errInvalidCategoryID, h.categoryService.Delete)
+				},
+			},
+			{
+				Name: "createTag",
+				Type: graphql.NonNullOf(tagType),
+				Args: []*graphql.Argument{nameArg},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					name, err := nameInputArg(p)
+					if err != nil {
+						return nil, err
+					}
+					return h.tagService.Create(name)
+				},
+			},
+			{
+				Name: "updateTag",
+				Type: graphql.NonNullOf(tagType),
+				Args: []*graphql.Argument{idArg, nameArg},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					name, err := nameInputArg(p)
+					if err != nil {
+						return nil, err
+					}
+					id, err := parseID(p.Args["id"], errInvalidTagID)
+					if err != nil {
+						return nil, err
+					}
+					return h.tagService.Update(id, name)
+				},
+			},
+			{
+				Name: "deleteTag",
+				Type: graphql.NonNullOf(graphql.Boolean),
+				Args: []*graphql.Argument{idArg},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return h.deleteByID(p, errInvalidTagID, h.tagService.Delete)
+				},
+			},
+			{
+				Name:        "createComment",
+				Type:        graphql.NonNullOf(commentType),
+				Description: "发表评论，游客也可以发表。需要审核的评论返回的 status 为 PENDING，审核通过前不会出现在评论列表中。",
+				Args: []*graphql.Argument{
+					{Name: "postId", Type: graphql.NonNullOf(graphql.ID)},
+					{Name: "input", Type: graphql.NonNullOf(createCommentInput)},
+				},
+				Resolve: h.resolveCreateComment,
+			},
+		},
+	}
+}
+
+// resolvePosts 解析 posts 字段，返回的 map 的键对应 PostConnection 的字段。
+func (h *GraphQLHandler) resolvePosts(p graphql.ResolveParams) (interface{}, error) {
+	first, _ := p.Args["first"].(int)
+	offset, _ := p.Args["offset"].(int)
+	if maxPageSize := config.Conf.GraphQL.MaxPageSize; first < 1 || first > maxPageSize {
+		return nil, errInvalidPageSize.WithArgs(maxPageSize)
+	}
+	if offset < 0 {
+		return nil, errInvalidOffset
+	}
+	dto := &service.ListPublishedDTO{Limit: first, Offset: offset}
+	var err error
+	if dto.CategoryID, err = optionalID(p.Args, "categoryId", errInvalidCategoryID); err != nil {
+		return nil, err
+	}
+	if dto.TagID, err = optionalID(p.Args, "tagId", errInvalidTagID); err != nil {
+		return nil, err
+	}
+	if dto.UserID, err = optionalID(p.Args, "authorId", errInvalidUserID); err != nil {
+		return nil, err
+	}
+	if dto.Lang, err = contentLangArg(p.Args); err != nil {
+		return nil, err
+	}
+
+	total, err := h.postService.CountPublished(dto)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := h.postService.ListPublished(dto)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*model.Post, len(posts))
+	for i := range posts {
+		nodes[i] = &posts[i]
+	}
+	return map[string]interface{}{"totalCount": total, "nodes": nodes}, nil
+}
+
+// loadVisiblePost 通过 Loader 加载文章，不存在或当前请求不可见时为 nil。
+func (h *GraphQLHandler) loadVisiblePost(ctx context.Context, id uint) graphql.Thunk {
+	thunk := requestContext(ctx).loaders.posts.Load(id)
+	return func() (interface{}, error) {
+		v, err := thunk()
+		if err != nil || v == nil {
+			return nil, err
+		}
+		if post := v.(*model.Post); visiblePost(ctx, post) {
+			return post, nil
+		}
+		return nil, nil
+	}
+}
+
+// postInput 是创建和更新文章的输入，校验规则与 CreatePostRequest 相同。更新文章时没有 translationOf。
+type postInput struct {
+	Title           string   `json:"title" binding:"required,min=2,max=255"`
+	Content         string   `json:"content" binding:"required,min=10"`
+	Summary         string   `json:"summary"`
+	Status          *int     `json:"status" binding:"required,oneof=0 1"`
+	CategoryID      string   `json:"categoryId" binding:"required"`
+	TagIDs          []string `json:"tagIds"`
+	Tags            []string `json:"tags"`
+	CommentsEnabled *bool    `json:"commentsEnabled"`
+	Lang            string   `json:"lang" binding:"max=16"`
+	TranslationOf   *string  `json:"translationOf"`
+	MetaTitle       string   `json:"metaTitle" binding:"max=255"`
+	MetaDescription string   `json:"metaDescription" binding:"max=500"`
+	CanonicalURL    string   `json:"canonicalUrl" binding:"omitempty,url,max=500"`
+	NoIndex         bool     `json:"noIndex"`
+	OGImageID       *string  `json:"ogImageId"`
+}
+
+// postRefs 解析输入中的分类、标签和分享卡片图片的 ID。
+func (in *postInput) postRefs() (categoryID uint, tagIDs []uint, seo service.PostSEODTO, err error) {
+	if categoryID, err = parseID(in.CategoryID, errInvalidCategoryID); err != nil {
+		return
+	}
+	for _, s := range in.TagIDs {
+		var id uint
+		if id, err = parseID(s, errInvalidTagID); err != nil {
+			return
+		}
+		tagIDs = append(tagIDs, id)
+	}
+	seo = service.PostSEODTO{
+		MetaTitle:       in.MetaTitle,
+		MetaDescription: in.MetaDescription,
+		CanonicalURL:    in.CanonicalURL,
+		NoIndex:         in.NoIndex,
+	}
+	if in.OGImageID != nil {
+		var id uint
+		if id, err = parseID(*in.OGImageID, errInvalidMediaID); err != nil {
+			return
+		}
+		seo.OGImageID = &id
+	}
+	return
+}
+
+// resolveCreatePost 解析 createPost 字段。
+func (h *GraphQLHandler) resolveCreatePost(p graphql.ResolveParams) (interface{}, error) {
+	claims, err := viewer(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	var in postInput
+	if err := decodeInput(p.Args["input"], &in); err != nil {
+		return nil, err
+	}
+	categoryID, tagIDs, seo, err := in.postRefs()
+	if err != nil {
+		return nil, err
+	}
+	dto := &service.CreatePostDTO{
+		Title:      in.Title,
+		Content:    in.Content,
+		Summary:    in.Summary,
+		Status:     *in.Status,
+		UserID:     claims.UserID,
+		CategoryID: categoryID,
+		TagIDs:     tagIDs,
+		TagNames:   in.Tags,
+		SEO:        seo,
+
+		CommentsEnabled: in.CommentsEnabled,
+		Lang:            in.Lang,
+	}
+	if in.TranslationOf != nil {
+		id, err := parseID(*in.TranslationOf, errInvalidPostID)
+		if err != nil {
+			return nil, err
+		}
+		dto.TranslationOf = &id
+	}
+	return h.postService.Create(dto)
+}
+
+// resolveUpdatePost 解析 updatePost 字段。
+func (h *GraphQLHandler) resolveUpdatePost(p graphql.ResolveParams) (interface{}, error) {
+	if _, err := viewer(p.Context); err != nil {
+		return nil, err
+	}
+	id, err := parseID(p.Args["id"], errInvalidPostID)
+	if err != nil {
+		return nil, err
+	}
+	var in postInput
+	if err := decodeInput(p.Args["input"], &in); err != nil {
+		return nil, err
+	}
+	categoryID, tagIDs, seo, err := in.postRefs()
+	if err != nil {
+		return nil, err
+	}
+	return h.postService.Update(&service.UpdatePostDTO{
+		ID:         id,
+		Title:      in.Title,
+		Content:    in.Content,
+		Summary:    in.Summary,
+		Status:     *in.Status,
+		CategoryID: categoryID,
+		TagIDs:     tagIDs,
+		TagNames:   in.Tags,
+		SEO:        seo,
+
+		CommentsEnabled: in.CommentsEnabled,
+		Lang:            in.Lang,
+	})
+}
+
+// commentInput 是发表评论的输入，校验规则与 CreateCommentRequest 相同。
+type commentInput struct {
+	ParentID    *string `json:"parentId"`
+	AuthorName  string  `json:"authorName" binding:"max=100"`
+	AuthorEmail string  `json:"authorEmail" binding:"omitempty,email,max=255"`
+	AuthorURL   string  `json:"authorUrl" binding:"omitempty,url,max=255"`
+	Content     string  `json:"content" binding:"required"`
+	Website     string  `json:"website"`
+	FormToken   string  `json:"formToken"`
+}
+
+// resolveCreateComment 解析 createComment 字段。
+func (h *GraphQLHandler) resolveCreateComment(p graphql.ResolveParams) (interface{}, error) {
+	postID, err := parseID(p.Args["postId"], errInvalidPostID)
+	if err != nil {
+		return nil, err
+	}
+	var in commentInput
+	if err := decodeInput(p.Args["input"], &in); err != nil {
+		return nil, err
+	}
+	rc := requestContext(p.Context)
+	dto := &service.CreateCommentDTO{
+		PostID:      postID,
+		AuthorName:  in.AuthorName,
+		AuthorEmail: in.AuthorEmail,
+		AuthorURL:   in.AuthorURL,
+		Content:     in.Content,
+		IP:          rc.gin.ClientIP(),
+		UserAgent:   rc.gin.Request.UserAgent(),
+		Referrer:    rc.gin.Request.Referer(),
+		Honeypot:    in.Website,
+		FormToken:   in.FormToken,
+	}
+	if in.ParentID != nil {
+		parentID, err := parseID(*in.ParentID, errInvalidCommentID)
+		if err != nil {
+			return nil, err
+		}
+		dto.ParentID = &parentID
+	}
+	if rc.claims != nil {
+		dto.UserID = rc.claims.UserID
+	}
+	return h.commentService.Create(dto)
+}
+
+// deleteByID 解析删除操作：需要登录，参数 id 无效时返回 invalid，删除成功返回 true。
+func (h *GraphQLHandler) deleteByID(p graphql.ResolveParams, invalid *apperr.Error, del func(id uint) error) (interface{}, error) {
+	if _, err := viewer(p.Context); err != nil {
+		return nil, err
+	}
+	id, err := parseID(p.Args["id"], invalid)
+	if err != nil {
+		return nil, err
+	}
+	if err := del(id); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// nameInput 是分类和标签名称的输入，校验规则与 CreateCategoryRequest 相同。
+type nameInput struct {
+	Name string `json:"name" binding:"required,min=2,max=100"`
+}
+
+// nameInputArg 检查登录状态并校验参数 name。
+func nameInputArg(p graphql.ResolveParams) (string, error) {
+	if _, err := viewer(p.Context); err != nil {
+		return "", err
+	}
+	in := nameInput{}
+	in.Name, _ = p.Args["name"].(string)
+	if err := binding.Validator.ValidateStruct(&in); err != nil {
+		return "", err
+	}
+	return in.Name, nil
+}
+
+// decodeInput 将输入对象转换为结构体 v 并按 binding 标签校验。
+// 输入对象已经由 graphql 包按类型转换过，这里通过 JSON 转换为结构体，json 标签即是输入对象的字段名。
+func decodeInput(input interface{}, v interface{}) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	return binding.Validator.ValidateStruct(v)
+}
+
+// parseID 将 ID 类型的参数转换为数据库 ID，无效时返回 invalid。
+func parseID(v interface{}, invalid *apperr.Error) (uint, error) {
+	s, _ := v.(string)
+	id, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || id == 0 {
+		return 0, invalid
+	}
+	return uint(id), nil
+}
+
+// optionalID 转换可选的 ID 参数，没有传入时返回 0。
+func optionalID(args map[string]interface{}, name string, invalid *apperr.Error) (uint, error) {
+	if v, ok := args[name]; ok && v != nil {
+		return parseID(v, invalid)
+	}
+	return 0, nil
+}
+
+// contentLangArg 校验参数 lang 并返回对应的文章语言，没有传入时返回空字符串。
+func contentLangArg(args map[string]interface{}) (string, error) {
+	lang, _ := args["lang"].(string)
+	if strings.TrimSpace(lang) == "" {
+		return "", nil
+	}
+	matched, ok := service.MatchContentLanguage(lang)
+	if !ok {
+		return "", service.ErrUnsupportedContentLanguage.WithArgs(lang)
+	}
+	return matched, nil
+}
+
+// nameTranslation 是分类或标签名称的一个翻译。
+type nameTranslation struct {
+	Lang string
+	Name string
+}
+
+func categoryNameTranslations(category *model.Category) []nameTranslation {
+	result := make([]nameTranslation, len(category.Translations))
+	for i, t := range category.Translations {
+		result[i] = nameTranslation{Lang: t.Lang, Name: t.Name}
+	}
+	return result
+}
+
+func tagNameTranslations(tag *model.Tag) []nameTranslation {
+	result := make([]nameTranslation, len(tag.Translations))
+	for i, t := range tag.Translations {
+		result[i] = nameTranslation{Lang: t.Lang, Name: t.Name}
+	}
+	return result
+}
+
+// localizedName 返回名称在参数 lang 指定的语言下的翻译，没有传入 lang 或没有翻译时返回原名称。
+func localizedName(args map[string]interface{}, name string, translations []nameTranslation) (interface{}, error) {
+	lang, err := contentLangArg(args)
+	if err != nil || lang == "" {
+		return name, err
+	}
+	for _, t := range translations {
+		if strings.EqualFold(t.Lang, lang) {
+			return t.Name, nil
+		}
+	}
+	return name, nil
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// JobHandler 结构体，用于挂载与后台任务相关的 API 方法。
+type JobHandler struct {
+	jobService *service.JobService
+}
+
+// NewJobHandler 是 JobHandler 的构造函数。
+func NewJobHandler() *JobHandler {
+	return &JobHandler{
+		jobService: service.NewJobService(),
+	}
+}
+
+// ListJobsHandler 获取后台任务列表。
+// 支持 page、pageSize 分页参数，以及 queue、kind、status (queued, running, succeeded, dead) 过滤参数。
+func (h *JobHandler) ListJobsHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	dto := &service.ListJobsDTO{
+		Page:     page,
+		PageSize: pageSize,
+		Queue:    c.Query("queue"),
+		Kind:     c.Query("kind"),
+	}
+	if name := c.Query("status"); name != "" {
+		status, ok := service.ParseJobStatus(name)
+		if !ok {
+			response.Fail(errInvalidJobStatus, c)
+			return
+		}
+		dto.Status = &status
+	}
+
+	result, err := h.jobService.List(dto)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(result, c)
+}
+
+// GetJobHandler 获取一个后台任务，包含任务参数。
+func (h *JobHandler) GetJobHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidJobID, c)
+		return
+	}
+	job, err := h.jobService.Get(uint(id))
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(job, c)
+}
+
+// RetryJobHandler 让等待执行或执行失败的任务立即执行，返回更新后的任务。
+func (h *JobHandler) RetryJobHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidJobID, c)
+		return
+	}
+	job, err := h.jobService.Retry(uint(id))
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(job, c)
+}
+
+// JobStatsHandler 返回每个队列中各状态的任务数。
+func (h *JobHandler) JobStatsHandler(c *gin.Context) {
+	stats, err := h.jobService.Stats()
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(stats, c)
+}
@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/KeLes-Coding/gopress/internal/api/middleware"
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/KeLes-Coding/gopress/internal/storage"
+	"github.com/KeLes-Coding/gopress/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+// MediaHandler 结构体，用于挂载与媒体库相关的 API 方法。
+type MediaHandler struct {
+	mediaService *service.MediaService
+}
+
+// NewMediaHandler 是 MediaHandler 的构造函数。
+func NewMediaHandler() *MediaHandler {
+	return &MediaHandler{
+		mediaService: service.NewMediaService(),
+	}
+}
+
+// actorFrom 返回当前登录的用户，用于检查是否有权管理他人上传的文件。
+func actorFrom(c *gin.Context) service.Actor {
+	_claims, _ := c.Get(middleware.CtxUserClaimsKey)
+	claims := _claims.(*util.MyClaims)
+	return service.Actor{UserID: claims.UserID, Admin: claims.Admin}
+}
+
+// multipartOverhead 是 multipart 请求中除文件内容以外的其他部分（边界、表单字段等）允许占用的字节数。
+const multipartOverhead = 1 << 20
+
+// UploadMediaHandler 是处理媒体文件上传请求的 Gin Handler。
+// 请求格式为 multipart/form-data，字段 file 为文件内容，alt 为可选的替代文本。
+func (h *MediaHandler) UploadMediaHandler(c *gin.Context) {
+	// 在读取请求体之前限制其大小，防止超大文件占满磁盘或内存
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxUploadSize()+multipartOverhead)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			response.Fail(service.ErrMediaTooLarge, c)
+			return
+		}
+		response.Fail(errNoUploadFile, c)
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	defer file.Close()
+
+	_claims, _ := c.Get(middleware.CtxUserClaimsKey)
+	claims := _claims.(*util.MyClaims)
+
+	media, err := h.mediaService.Upload(&service.UploadMediaDTO{
+		UserID:   claims.UserID,
+		FileName: path.Base(fileHeader.Filename),
+		AltText:  c.PostForm("alt"),
+		Reader:   file,
+	})
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(media, c)
+}
+
+// ListMediaHandler 是处理获取媒体列表请求的 Gin Handler。
+// 支持 page、pageSize 分页参数，以及按 MIME 类型前缀过滤的 type 参数。
+func (h *MediaHandler) ListMediaHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+
+	result, err := h.mediaService.List(&service.ListMediaDTO{
+		Page:     page,
+		PageSize: pageSize,
+		MimeType: c.Query("type"),
+	})
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(result, c)
+}
+
+// UpdateMediaRequest 定义了更新媒体信息接口的请求体。
+type UpdateMediaRequest struct {
+	AltText string `json:"alt_text" binding:"max=255"`
+}
+
+// UpdateMediaHandler 是处理更新媒体信息请求的 Gin Handler。
+func (h *MediaHandler) UpdateMediaHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidMediaID, c)
+		return
+	}
+	var req UpdateMediaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+	media, err := h.mediaService.UpdateAltText(uint(id), actorFrom(c), req.AltText)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(media, c)
+}
+
+// MediaInUseResponse 定义了删除仍被引用的媒体文件时，随错误一起返回的数据结构。
+type MediaInUseResponse struct {
+	Posts []service.MediaReferenceDTO `json:"posts"` // 引用了该文件的文章
+}
+
+// DeleteMediaHandler 是处理删除媒体文件请求的 Gin Handler。
+// 如果文件仍被文章引用，会返回引用它的文章列表作为警告；
+// 确认后可以携带查询参数 force=true 强制删除。
+func (h *MediaHandler) DeleteMediaHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidMediaID, c)
+		return
+	}
+	force := c.Query("force") == "true"
+
+	if err := h.mediaService.Delete(uint(id), actorFrom(c), force); err != nil {
+		var inUseErr *service.MediaInUseError
+		if errors.As(err, &inUseErr) {
+			response.FailWithData(err, MediaInUseResponse{Posts: inUseErr.Posts}, c)
+			return
+		}
+		response.Fail(err, c)
+		return
+	}
+	response.Success(nil, c)
+}
+
+// ReprocessMediaHandler 是处理重新生成图片缩略图请求的 Gin Handler。
+// 处理在后台进行，返回的媒体信息处于待处理状态。
+func (h *MediaHandler) ReprocessMediaHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidMediaID, c)
+		return
+	}
+	media, err := h.mediaService.Reprocess(uint(id), actorFrom(c))
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(media, c)
+}
+
+// ServeMediaHandler 用于公开访问媒体文件。
+// 由于存储键由文件内容推导而来，同一个地址的内容永远不会改变，因此可以让浏览器和 CDN 长期缓存。
+func (h *MediaHandler) ServeMediaHandler(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("filepath"), "/")
+	// 只接受规范的存储键，否则 //incoming/... 或 ./incoming/... 这样的地址可以绕过下面的检查，
+	// 存储后端规范化后仍然读到临时文件；包含 .. 的键同样会被拒绝
+	if key == "" || path.Clean("/"+key) != "/"+key || strings.HasPrefix(key, "incoming/") {
+		// 直传的临时文件不对外公开
+		c.Status(http.StatusNotFound)
+		return
+	}
+
+	// 文件名（去掉扩展名）即为内容摘要，直接用作 ETag
+	checksum := strings.TrimSuffix(path.Base(key), path.Ext(key))
+	etag := `"` + checksum + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	// 内容不会改变，只要 ETag 匹配就可以直接返回 304，无需访问存储
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	obj, err := h.mediaService.Storage().Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	defer obj.Body.Close()
+
+	// 本地文件支持 Seek，交给 http.ServeContent 处理 Range 请求和 Content-Type
+	if rs, ok := obj.Body.(io.ReadSeeker); ok {
+		http.ServeContent(c.Writer, c.Request, key, obj.ModTime, rs)
+		return
+	}
+
+	// 远程对象存储的内容以流的方式转发
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(key))
+	}
+	extraHeaders := map[string]string{}
+	if !obj.ModTime.IsZero() {
+		extraHeaders["Last-Modified"] = obj.ModTime.UTC().Format(http.TimeFormat)
+	}
+	c.DataFromReader(http.StatusOK, obj.Size, contentType, obj.Body, extraHeaders)
+}
+
+// PresignMediaRequest 定义了申请直传地址接口的请求体。
+type PresignMediaRequest struct {
+	FileName string `json:"file_name" binding:"required,max=255"`
+}
+
+// PresignMediaHandler 是处理申请直传地址请求的 Gin Handler。
+// 客户端拿到地址后直接把文件 PUT 到对象存储，然后调用 CompleteMediaHandler 完成登记。
+func (h *MediaHandler) PresignMediaHandler(c *gin.Context) {
+	var req PresignMediaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+
+	_claims, _ := c.Get(middleware.CtxUserClaimsKey)
+	claims := _claims.(*util.MyClaims)
+
+	result, err := h.mediaService.PresignUpload(claims.UserID, req.FileName)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(result, c)
+}
+
+// CompleteMediaRequest 定义了完成直传接口的请求体。
+type CompleteMediaRequest struct {
+	Key      string `json:"key" binding:"required"`
+	FileName string `json:"file_name" binding:"required,max=255"`
+	AltText  string `json:"alt_text" binding:"max=255"`
+}
+
+// CompleteMediaHandler 是处理完成直传请求的 Gin Handler。
+func (h *MediaHandler) CompleteMediaHandler(c *gin.Context) {
+	var req CompleteMediaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+
+	_claims, _ := c.Get(middleware.CtxUserClaimsKey)
+	claims := _claims.(*util.MyClaims)
+
+	media, err := h.mediaService.CompleteUpload(&service.CompleteUploadDTO{
+		UserID:   claims.UserID,
+		Key:      req.Key,
+		FileName: path.Base(req.FileName),
+		AltText:  req.AltText,
+	})
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(media, c)
+}
@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/storage"
+	"github.com/gin-gonic/gin"
+)
+
+// TestServeMediaHandlerIncoming 检查直传的临时文件不能通过任何形式的地址访问。
+func TestServeMediaHandlerIncoming(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	saved := config.Conf
+	config.Conf = &config.Config{}
+	config.Conf.Storage.Local.Dir = t.TempDir()
+	defer func() { config.Conf = saved }()
+	if err := storage.Init(); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"incoming/1/x.html", "ab/cd/abcd.txt"} {
+		p := filepath.Join(config.Conf.Storage.Local.Dir, filepath.FromSlash(key))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("<script>alert(1)</script>"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := gin.New()
+	r.GET("/uploads/*filepath", NewMediaHandler().ServeMediaHandler)
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{"/uploads/ab/cd/abcd.txt", http.StatusOK},
+		{"/uploads/incoming/1/x.html", http.StatusNotFound},
+		{"/uploads//incoming/1/x.html", http.StatusNotFound},
+		{"/uploads/./incoming/1/x.html", http.StatusNotFound},
+		{"/uploads/ab/../incoming/1/x.html", http.StatusNotFound},
+		{"/uploads/ab/cd/../cd/abcd.txt", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,335 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/KeLes-Coding/gopress/internal/apperr"
+	"github.com/KeLes-Coding/gopress/internal/i18n"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// micropubMaxJSONSize 是 JSON 请求体的大小上限。
+const micropubMaxJSONSize = 4 << 20
+
+// MicropubHandler 结构体，用于挂载 Micropub 接口。
+// 按照 Micropub 规范使用 HTTP 状态码和 {"error", "error_description"} 格式响应，
+// 客户端使用个人访问令牌认证，而不是登录 JWT。
+type MicropubHandler struct {
+	tokenService    *service.TokenService
+	micropubService *service.MicropubService
+	mediaService    *service.MediaService
+}
+
+// NewMicropubHandler 是 MicropubHandler 的构造函数。
+func NewMicropubHandler() *MicropubHandler {
+	return &MicropubHandler{
+		tokenService:    service.NewTokenService(),
+		micropubService: service.NewMicropubService(),
+		mediaService:    service.NewMediaService(),
+	}
+}
+
+// micropubJSONRequest 是 JSON 格式的 Micropub 请求，创建请求使用 type 和 properties，其他操作使用 action 和 url。
+type micropubJSONRequest struct {
+	Type       []string                   `json:"type"`
+	Properties service.MicropubProperties `json:"properties"`
+	Action     string                     `json:"action"`
+	URL        string                     `json:"url"`
+	Replace    service.MicropubProperties `json:"replace"`
+	Add        service.MicropubProperties `json:"add"`
+	// Delete 可以是要删除的属性名列表，也可以是要从属性中删除的值
+	Delete json.RawMessage `json:"delete"`
+}
+
+// writeMicropubError 以 Micropub 规范的格式写入错误响应。
+func writeMicropubError(c *gin.Context, status int, code, description string) {
+	c.JSON(status, gin.H{"error": code, "error_description": description})
+}
+
+// micropubStatus 返回错误码对应的 HTTP 状态码。
+var micropubStatus = map[string]int{
+	service.MicropubUnauthorized:      http.StatusUnauthorized,
+	service.MicropubForbidden:         http.StatusForbidden,
+	service.MicropubInsufficientScope: http.StatusForbidden,
+	service.MicropubInvalidRequest:    http.StatusBadRequest,
+}
+
+// writeError 将业务逻辑返回的错误写入响应。
+// 除 *service.MicropubError 外，业务逻辑返回的 *apperr.Error（分类不存在、标签名无效、文件类型不允许等）都是请求本身的问题，
+// 按 invalid_request 处理，没有权限的错误按 forbidden 处理；其他错误按服务器内部错误处理。
+func (h *MicropubHandler) writeError(c *gin.Context, err error) {
+	var mpErr *service.MicropubError
+	if errors.As(err, &mpErr) {
+		writeMicropubError(c, micropubStatus[mpErr.Code], mpErr.Code, mpErr.Description)
+		return
+	}
+	if e, ok := apperr.From(err); ok {
+		if e.Kind == apperr.KindForbidden {
+			writeMicropubError(c, http.StatusForbidden, service.MicropubForbidden, i18n.Message(i18n.FromContext(c), e))
+			return
+		}
+		writeMicropubError(c, http.StatusBadRequest, service.MicropubInvalidRequest, i18n.Message(i18n.FromContext(c), e))
+		return
+	}
+	logger.L.Error("Micropub request failed", zap.String("path", c.Request.URL.Path), zap.Error(err))
+	writeMicropubError(c, http.StatusInternalServerError, service.MicropubServerError, i18n.Message(i18n.FromContext(c), apperr.ErrInternal))
+}
+
+// authenticate 从 Authorization 请求头或表单的 access_token 参数中读取并校验令牌。
+// 校验失败时写入 401 响应并返回 false。
+func (h *MicropubHandler) authenticate(c *gin.Context) (*model.AccessToken, bool) {
+	var plain string
+	if auth := c.GetHeader("Authorization"); auth != "" {
+		parts := strings.SplitN(auth, " ", 2)
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			writeMicropubError(c, http.StatusUnauthorized, service.MicropubUnauthorized, "Token 格式不正确")
+			return nil, false
+		}
+		plain = strings.TrimSpace(parts[1])
+	} else if c.ContentType() != "application/json" {
+		plain = c.PostForm("access_token")
+	}
+	if plain == "" {
+		c.Header("WWW-Authenticate", "Bearer")
+		writeMicropubError(c, http.StatusUnauthorized, service.MicropubUnauthorized, "请求未携带访问令牌")
+		return nil, false
+	}
+
+	token, err := h.tokenService.Authenticate(plain)
+	if err != nil {
+		if !errors.Is(err, service.ErrInvalidAccessToken) {
+			logger.L.Error("Failed to authenticate access token", zap.Error(err))
+		}
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		writeMicropubError(c, http.StatusUnauthorized, service.MicropubUnauthorized, i18n.Message(i18n.FromContext(c), service.ErrInvalidAccessToken))
+		return nil, false
+	}
+	return token, true
+}
+
+// requireScope 检查令牌是否拥有 scopes 中的任意一个权限范围，没有时写入 insufficient_scope 响应并返回 false。
+func (h *MicropubHandler) requireScope(c *gin.Context, token *model.AccessToken, scopes ...string) bool {
+	for _, scope := range scopes {
+		if service.TokenHasScope(token, scope) {
+			return true
+		}
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":             service.MicropubInsufficientScope,
+		"error_description": "访问令牌缺少所需的权限范围",
+		"scope":             strings.Join(scopes, " "),
+	})
+	return false
+}
+
+// MicropubQueryHandler 处理 GET /micropub 的查询请求，支持 q=config、source、syndicate-to 和 category。
+func (h *MicropubHandler) MicropubQueryHandler(c *gin.Context) {
+	if _, ok := h.authenticate(c); !ok {
+		return
+	}
+
+	switch q := c.Query("q"); q {
+	case "config":
+		c.JSON(http.StatusOK, gin.H{
+			"media-endpoint": absoluteURL(c, "/micropub/media"),
+			"syndicate-to":   []interface{}{},
+			"post-types": []gin.H{
+				{"type": "note", "name": "笔记"},
+				{"type": "article", "name": "文章"},
+				{"type": "photo", "name": "图片"},
+			},
+			"q": []string{"config", "source", "syndicate-to", "category"},
+		})
+	case "syndicate-to":
+		c.JSON(http.StatusOK, gin.H{"syndicate-to": []interface{}{}})
+	case "category":
+		categories, err := h.micropubService.Categories(c.Query("filter"))
+		if err != nil {
+			h.writeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"categories": categories})
+	case "source":
+		properties := append(c.QueryArray("properties[]"), c.QueryArray("properties")...)
+		source, err := h.micropubService.Source(c.Query("url"), properties)
+		if err != nil {
+			h.writeError(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, source)
+	case "":
+		writeMicropubError(c, http.StatusBadRequest, service.MicropubInvalidRequest, "缺少 q 参数")
+	default:
+		writeMicropubError(c, http.StatusBadRequest, service.MicropubInvalidRequest, "不支持的查询: "+q)
+	}
+}
+
+// MicropubHandler 处理 POST /micropub 的请求。
+// 创建文章支持表单（包括带图片文件的 multipart 表单）和 JSON 两种格式，修改文章只支持 JSON 格式。
+func (h *MicropubHandler) MicropubHandler(c *gin.Context) {
+	isJSON := c.ContentType() == "application/json"
+	var req micropubJSONRequest
+	if isJSON {
+		if err := json.NewDecoder(http.MaxBytesReader(c.Writer, c.Request.Body, micropubMaxJSONSize)).Decode(&req); err != nil {
+			writeMicropubError(c, http.StatusBadRequest, service.MicropubInvalidRequest, "无法解析请求: "+err.Error())
+			return
+		}
+	} else {
+		if c.ContentType() == "multipart/form-data" {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxUploadSize()*4+1<<20)
+		}
+		if _, err := c.MultipartForm(); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			writeMicropubError(c, http.StatusBadRequest, service.MicropubInvalidRequest, "无法解析请求: "+err.Error())
+			return
+		}
+		req.Action = c.PostForm("action")
+		req.URL = c.PostForm("url")
+	}
+
+	token, ok := h.authenticate(c)
+	if !ok {
+		return
+	}
+
+	switch req.Action {
+	case "", "create":
+		h.create(c, token, &req, isJSON)
+	case "update":
+		if !h.requireScope(c, token, service.ScopeUpdate) {
+			return
+		}
+		if !isJSON {
+			writeMicropubError(c, http.StatusBadRequest, service.MicropubInvalidRequest, "修改文章只支持 JSON 格式的请求")
+			return
+		}
+		update := &service.MicropubUpdateDTO{URL: req.URL, Replace: req.Replace, Add: req.Add}
+		if len(req.Delete) > 0 {
+			if err := json.Unmarshal(req.Delete, &update.DeleteNames); err != nil {
+				if err := json.Unmarshal(req.Delete, &update.Delete); err != nil {
+					writeMicropubError(c, http.StatusBadRequest, service.MicropubInvalidRequest, "delete 必须是属性名列表或属性值对象")
+					return
+				}
+			}
+		}
+		if _, err := h.micropubService.Update(update); err != nil {
+			h.writeError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	case "delete":
+		if !h.requireScope(c, token, service.ScopeDelete) {
+			return
+		}
+		if err := h.micropubService.Delete(req.URL); err != nil {
+			h.writeError(c, err)
+			return
+		}
+		c.Status(http.StatusNoContent)
+	case "undelete":
+		writeMicropubError(c, http.StatusBadRequest, service.MicropubInvalidRequest, "文章删除后无法恢复")
+	default:
+		writeMicropubError(c, http.StatusBadRequest, service.MicropubInvalidRequest, "不支持的操作: "+req.Action)
+	}
+}
+
+// create 创建一篇文章，成功后返回 201 并在 Location 中给出文章的永久链接。
+// 令牌只有 draft 权限时，文章始终保存为草稿。
+func (h *MicropubHandler) create(c *gin.Context, token *model.AccessToken, req *micropubJSONRequest, isJSON bool) {
+	if !h.requireScope(c, token, service.ScopeCreate, service.ScopeDraft) {
+		return
+	}
+	dto := &service.MicropubCreateDTO{
+		UserID:     token.UserID,
+		Properties: req.Properties,
+		DraftOnly:  !service.TokenHasScope(token, service.ScopeCreate),
+	}
+	if isJSON {
+		if len(req.Type) > 0 {
+			dto.Type = req.Type[0]
+		}
+	} else {
+		dto.Type = "h-" + c.DefaultPostForm("h", "entry")
+		dto.Properties = make(service.MicropubProperties)
+		for key, values := range c.Request.PostForm {
+			// access_token、h 等参数以及 mp- 开头的服务器指令不属于文章的属性
+			if key == "access_token" || key == "h" || key == "action" || key == "url" || strings.HasPrefix(key, "mp-") {
+				continue
+			}
+			name := strings.TrimSuffix(key, "[]")
+			for _, v := range values {
+				dto.Properties[name] = append(dto.Properties[name], v)
+			}
+		}
+		// multipart 表单中的图片文件先上传到媒体库，再以地址的形式加入 photo 属性
+		if form := c.Request.MultipartForm; form != nil {
+			for _, key := range []string{"photo", "photo[]"} {
+				for _, fileHeader := range form.File[key] {
+					media, err := h.upload(token.UserID, fileHeader)
+					if err != nil {
+						h.writeError(c, err)
+						return
+					}
+					dto.Properties["photo"] = append(dto.Properties["photo"], absoluteURL(c, media.URL))
+				}
+			}
+		}
+	}
+
+	post, err := h.micropubService.Create(dto)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.Header("Location", absoluteURL(c, service.PostURL(post.ID)))
+	c.Status(http.StatusCreated)
+}
+
+// upload 将客户端上传的文件保存到媒体库。
+func (h *MicropubHandler) upload(userID uint, fileHeader *multipart.FileHeader) (*model.Media, error) {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return h.mediaService.Upload(&service.UploadMediaDTO{
+		UserID:   userID,
+		FileName: path.Base(fileHeader.Filename),
+		Reader:   file,
+	})
+}
+
+// MicropubMediaHandler 是 Micropub 的媒体接口，接收 multipart 表单中名为 file 的文件，
+// 成功后返回 201 并在 Location 中给出文件的地址，客户端随后在创建文章时以 photo 属性引用它。
+func (h *MicropubHandler) MicropubMediaHandler(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxUploadSize()+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		writeMicropubError(c, http.StatusBadRequest, service.MicropubInvalidRequest, "请通过 file 字段上传文件")
+		return
+	}
+
+	token, ok := h.authenticate(c)
+	if !ok {
+		return
+	}
+	if !h.requireScope(c, token, service.ScopeMedia, service.ScopeCreate) {
+		return
+	}
+
+	media, err := h.upload(token.UserID, fileHeader)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.Header("Location", absoluteURL(c, media.URL))
+	c.Status(http.StatusCreated)
+}
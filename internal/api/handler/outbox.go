@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// OutboxHandler 结构体，用于挂载与领域事件 outbox 相关的 API 方法。
+type OutboxHandler struct {
+	outboxService *service.OutboxService
+}
+
+// NewOutboxHandler 是 OutboxHandler 的构造函数。
+func NewOutboxHandler() *OutboxHandler {
+	return &OutboxHandler{
+		outboxService: service.NewOutboxService(),
+	}
+}
+
+// RetryOutboxResponse 是重新投递失败事件的结果。
+type RetryOutboxResponse struct {
+	Retried int64 `json:"retried"` // 重新放回队列的事件数
+}
+
+// OutboxStatsHandler 返回 outbox 的积压情况和投递统计。
+func (h *OutboxHandler) OutboxStatsHandler(c *gin.Context) {
+	stats, err := h.outboxService.Stats()
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(stats, c)
+}
+
+// RetryOutboxHandler 将所有投递失败的事件重新放回队列。
+func (h *OutboxHandler) RetryOutboxHandler(c *gin.Context) {
+	retried, err := h.outboxService.RetryFailed()
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(RetryOutboxResponse{Retried: retried}, c)
+}
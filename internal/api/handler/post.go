@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"strconv"
 
 	"github.com/KeLes-Coding/gopress/internal/api/middleware"
@@ -13,30 +16,95 @@ import (
 // PostHandler 结构体...
 type PostHandler struct {
 	postService *service.PostService
+	seoService  *service.SEOService
 }
 
 // NewPostHandler 是 PostHandler 的构造函数。
 func NewPostHandler() *PostHandler {
 	return &PostHandler{
 		postService: service.NewPostService(),
+		seoService:  service.NewSEOService(),
+	}
+}
+
+// TagRef 表示请求中对一个标签的引用。
+// 在 JSON 中它既可以是数字（已有标签的 ID），也可以是字符串（标签名称）。
+type TagRef struct {
+	ID   uint
+	Name string
+}
+
+// UnmarshalJSON 实现了 json.Unmarshaler 接口，用于解析数字或字符串形式的标签引用。
+func (r *TagRef) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &r.Name)
+	}
+	if err := json.Unmarshal(data, &r.ID); err != nil {
+		return errors.New("标签必须是数字 ID 或字符串名称")
+	}
+	return nil
+}
+
+// splitTagRefs 将标签引用拆分为 ID 列表和名称列表，并与旧的 tag_ids 字段合并。
+func splitTagRefs(tagIDs []uint, refs []TagRef) ([]uint, []string) {
+	ids := append([]uint{}, tagIDs...)
+	var names []string
+	for _, ref := range refs {
+		if ref.Name != "" {
+			names = append(names, ref.Name)
+		} else {
+			ids = append(ids, ref.ID)
+		}
+	}
+	return ids, names
+}
+
+// PostSEORequest 定义了文章的 SEO 字段，嵌入在创建和更新文章的请求体中，均为可选。
+type PostSEORequest struct {
+	MetaTitle       string `json:"meta_title" binding:"max=255"`
+	MetaDescription string `json:"meta_description" binding:"max=500"`
+	CanonicalURL    string `json:"canonical_url" binding:"omitempty,url,max=500"`
+	NoIndex         bool   `json:"noindex"`
+	OGImageID       *uint  `json:"og_image_id"` // 分享卡片图片的媒体 ID，不传时自动使用正文中的第一张图片
+}
+
+// ToDTO 将请求参数转换为 service 层的 DTO，gRPC 接口也使用它。
+func (r *PostSEORequest) ToDTO() service.PostSEODTO {
+	return service.PostSEODTO{
+		MetaTitle:       r.MetaTitle,
+		MetaDescription: r.MetaDescription,
+		CanonicalURL:    r.CanonicalURL,
+		NoIndex:         r.NoIndex,
+		OGImageID:       r.OGImageID,
 	}
 }
 
 // CreatePostRequest 定义了创建文章接口的请求体。
+// 标签可以通过 tag_ids 传入已有标签的 ID，也可以通过 tags 混合传入 ID 和名称，
+// 例如 "tags": [1, "golang"]，不存在的标签会被自动创建。
 type CreatePostRequest struct {
-	Title      string `json:"title" binding:"required,min=2,max=255"`
-	Content    string `json:"content" binding:"required,min=10"`
-	Summary    string `json:"summary"`
-	Status     *int   `json:"status" binding:"required,oneof=0 1"` // 使用指针以区分 0 和未提供
-	CategoryID uint   `json:"category_id" binding:"required"`
-	TagIDs     []uint `json:"tag_ids"`
+	Title      string   `json:"title" binding:"required,min=2,max=255"`
+	Content    string   `json:"content" binding:"required,min=10"`
+	Summary    string   `json:"summary"`
+	Status     *int     `json:"status" binding:"required,oneof=0 1"` // 使用指针以区分 0 和未提供
+	CategoryID uint     `json:"category_id" binding:"required"`
+	TagIDs     []uint   `json:"tag_ids"`
+	Tags       []TagRef `json:"tags"`
+	// CommentsEnabled 表示是否允许评论，不传时默认允许
+	CommentsEnabled *bool `json:"comments_enabled"`
+	// Lang 是文章的语言，例如 en，不传时使用站点的默认语言
+	Lang string `json:"lang" binding:"max=16"`
+	// TranslationOf 是原文的 ID，传入时新文章作为原文的翻译版本
+	TranslationOf *uint `json:"translation_of"`
+	PostSEORequest
 }
 
 // CreatePostHandler ...
 func (h *PostHandler) CreatePostHandler(c *gin.Context) {
 	var req CreatePostRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error("参数校验失败: "+err.Error(), c)
+		response.ValidationError(err, c)
 		return
 	}
 
@@ -44,6 +112,7 @@ func (h *PostHandler) CreatePostHandler(c *gin.Context) {
 	_claims, _ := c.Get(middleware.CtxUserClaimsKey)
 	claims := _claims.(*util.MyClaims)
 
+	tagIDs, tagNames := splitTagRefs(req.TagIDs, req.Tags)
 	dto := &service.CreatePostDTO{
 		Title:      req.Title,
 		Content:    req.Content,
@@ -51,12 +120,18 @@ func (h *PostHandler) CreatePostHandler(c *gin.Context) {
 		Status:     *req.Status,
 		UserID:     claims.UserID,
 		CategoryID: req.CategoryID,
-		TagIDs:     req.TagIDs,
+		TagIDs:     tagIDs,
+		TagNames:   tagNames,
+		SEO:        req.PostSEORequest.ToDTO(),
+
+		CommentsEnabled: req.CommentsEnabled,
+		Lang:            req.Lang,
+		TranslationOf:   req.TranslationOf,
 	}
 
 	post, err := h.postService.Create(dto)
 	if err != nil {
-		response.Error(err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 
@@ -64,6 +139,7 @@ func (h *PostHandler) CreatePostHandler(c *gin.Context) {
 }
 
 // ListPostsHandler ...
+// 查询参数 lang 可以只列出某种语言的文章，分类和标签名称也会使用该语言。
 func (h *PostHandler) ListPostsHandler(c *gin.Context) {
 	// 从查询参数获取分页信息，并提供默认值
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -72,55 +148,80 @@ func (h *PostHandler) ListPostsHandler(c *gin.Context) {
 	dto := &service.ListPostsDTO{
 		Page:     page,
 		PageSize: pageSize,
+		Lang:     c.Query("lang"),
 	}
 
 	result, err := h.postService.List(dto)
 	if err != nil {
-		response.Error("获取文章列表失败: "+err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 	response.Success(result, c)
 }
 
 // GetPostHandler ...
+// 查询参数 lang 用于获取文章在该语言下的版本，没有该语言的版本时回退到默认语言的版本或原文，返回文章的 Lang 是实际的语言。
 func (h *PostHandler) GetPostHandler(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		response.Error("无效的文章 ID", c)
+		response.Fail(errInvalidPostID, c)
 		return
 	}
-	post, err := h.postService.GetByID(uint(id))
+	post, err := h.postService.GetTranslation(uint(id), c.Query("lang"))
 	if err != nil {
-		response.Error(err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 	response.Success(post, c)
 }
 
+// GetPostMetaHandler 是获取文章 SEO 元数据的 Gin Handler。
+// 返回的数据包含可以直接输出到 <head> 中的标签和 JSON-LD 结构化数据，供服务端渲染或预渲染使用。
+func (h *PostHandler) GetPostMetaHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidPostID, c)
+		return
+	}
+	meta, err := h.seoService.PostMeta(uint(id))
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(meta, c)
+}
+
 // UpdatePostRequest 定义了更新文章接口的请求体。
 type UpdatePostRequest struct {
-	Title      string `json:"title" binding:"required,min=2,max=255"`
-	Content    string `json:"content" binding:"required,min=10"`
-	Summary    string `json:"summary"`
-	Status     *int   `json:"status" binding:"required,oneof=0 1"`
-	CategoryID uint   `json:"category_id" binding:"required"`
-	TagIDs     []uint `json:"tag_ids"`
+	Title      string   `json:"title" binding:"required,min=2,max=255"`
+	Content    string   `json:"content" binding:"required,min=10"`
+	Summary    string   `json:"summary"`
+	Status     *int     `json:"status" binding:"required,oneof=0 1"`
+	CategoryID uint     `json:"category_id" binding:"required"`
+	TagIDs     []uint   `json:"tag_ids"`
+	Tags       []TagRef `json:"tags"`
+	// CommentsEnabled 表示是否允许评论，不传时保持不变
+	CommentsEnabled *bool `json:"comments_enabled"`
+	// Lang 是文章的语言，不传时保持不变
+	Lang string `json:"lang" binding:"max=16"`
+	PostSEORequest
 }
 
 // UpdatePostHandler ...
 func (h *PostHandler) UpdatePostHandler(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		response.Error("无效的文章 ID", c)
+		response.Fail(errInvalidPostID, c)
 		return
 	}
 
 	var req UpdatePostRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error("参数校验失败: "+err.Error(), c)
+		response.ValidationError(err, c)
 		return
 	}
 
+	tagIDs, tagNames := splitTagRefs(req.TagIDs, req.Tags)
 	dto := &service.UpdatePostDTO{
 		ID:         uint(id),
 		Title:      req.Title,
@@ -128,12 +229,17 @@ func (h *PostHandler) UpdatePostHandler(c *gin.Context) {
 		Summary:    req.Summary,
 		Status:     *req.Status,
 		CategoryID: req.CategoryID,
-		TagIDs:     req.TagIDs,
+		TagIDs:     tagIDs,
+		TagNames:   tagNames,
+		SEO:        req.PostSEORequest.ToDTO(),
+
+		CommentsEnabled: req.CommentsEnabled,
+		Lang:            req.Lang,
 	}
 
 	post, err := h.postService.Update(dto)
 	if err != nil {
-		response.Error(err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 
@@ -144,12 +250,12 @@ func (h *PostHandler) UpdatePostHandler(c *gin.Context) {
 func (h *PostHandler) DeletePostHandler(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		response.Error("无效的文章 ID", c)
+		response.Fail(errInvalidPostID, c)
 		return
 	}
 
 	if err := h.postService.Delete(uint(id)); err != nil {
-		response.Error(err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 
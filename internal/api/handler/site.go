@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/KeLes-Coding/gopress/internal/theme"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// htmlContentType 是服务端渲染页面的 HTTP Content-Type。
+const htmlContentType = "text/html; charset=utf-8"
+
+// SiteHandler 结构体，用于挂载服务端渲染的公开站点页面。
+// 页面使用当前主题渲染，出错时返回真实的 HTTP 状态码，而不是 API 的业务状态码。
+type SiteHandler struct {
+	siteService *service.SiteService
+	themes      *theme.Manager
+}
+
+// NewSiteHandler 是 SiteHandler 的构造函数。
+func NewSiteHandler() *SiteHandler {
+	return &SiteHandler{
+		siteService: service.NewSiteService(),
+		themes:      theme.GetManager(),
+	}
+}
+
+// HomeHandler 渲染首页，支持 ?page=N 翻页。
+func (h *SiteHandler) HomeHandler(c *gin.Context) {
+	page, err := h.siteService.Home(pageParam(c))
+	h.render(c, "home", page, err)
+}
+
+// PostHandler 渲染文章页，草稿返回 404。
+func (h *SiteHandler) PostHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.NotFoundHandler(c)
+		return
+	}
+	page, err := h.siteService.Post(uint(id))
+	// 通过 Link 头声明 Webmention 接收地址，对方不必解析 HTML 就能发现
+	if page != nil && page.Site.Webmention != "" {
+		c.Header("Link", "<"+page.Site.Webmention+`>; rel="webmention"`)
+	}
+	h.render(c, "post", page, err)
+}
+
+// CategoryHandler 渲染分类页，支持 ?page=N 翻页。
+func (h *SiteHandler) CategoryHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.NotFoundHandler(c)
+		return
+	}
+	page, err := h.siteService.Category(uint(id), pageParam(c))
+	h.render(c, "category", page, err)
+}
+
+// TagHandler 渲染标签页，支持 ?page=N 翻页。
+func (h *SiteHandler) TagHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.NotFoundHandler(c)
+		return
+	}
+	page, err := h.siteService.Tag(uint(id), pageParam(c))
+	h.render(c, "tag", page, err)
+}
+
+// ArchiveHandler 渲染按月份分组的归档页。
+func (h *SiteHandler) ArchiveHandler(c *gin.Context) {
+	page, err := h.siteService.Archive()
+	h.render(c, "archive", page, err)
+}
+
+// NotFoundHandler 渲染主题的 404 页面。
+// 作为 NoRoute 使用时，/api/ 下的地址保持 Gin 默认的 404 响应。
+func (h *SiteHandler) NotFoundHandler(c *gin.Context) {
+	if strings.HasPrefix(c.Request.URL.Path, "/api/") {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	h.write(c, http.StatusNotFound, "404", h.siteService.NotFound())
+}
+
+// AssetHandler 输出当前主题的静态文件，例如 /theme/style.css。
+func (h *SiteHandler) AssetHandler(c *gin.Context) {
+	name, ok := theme.AssetPath(c.Param("filepath"))
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	t, err := h.themes.Theme()
+	if err != nil {
+		logger.L.Error("Failed to load theme", zap.Error(err))
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	data, err := fs.ReadFile(t.Assets(), name)
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	// 热重载时不缓存，方便调试样式
+	if h.themes.HotReload() {
+		c.Header("Cache-Control", "no-cache")
+	} else {
+		c.Header("Cache-Control", "public, max-age=3600")
+	}
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// render 根据查询结果渲染页面：页面不存在时渲染 404 页面，其他错误返回 500。
+func (h *SiteHandler) render(c *gin.Context, name string, page *service.SitePageDTO, err error) {
+	if err != nil {
+		if errors.Is(err, service.ErrSitePageNotFound) {
+			h.NotFoundHandler(c)
+			return
+		}
+		logger.L.Error("Failed to build site page", zap.String("page", name), zap.Error(err))
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	h.write(c, http.StatusOK, name, page)
+}
+
+// write 使用当前主题渲染页面并写入响应。
+func (h *SiteHandler) write(c *gin.Context, status int, name string, page *service.SitePageDTO) {
+	var buf bytes.Buffer
+	if err := h.themes.Render(&buf, name, page); err != nil {
+		logger.L.Error("Failed to render theme page", zap.String("page", name), zap.Error(err))
+		// 热重载时直接在页面上显示模板错误，便于主题开发
+		if h.themes.HotReload() {
+			c.String(http.StatusInternalServerError, "theme error: %v", err)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	c.Data(status, htmlContentType, buf.Bytes())
+}
+
+// pageParam 读取 ?page=N 参数，缺省或无效时返回 1。
+func pageParam(c *gin.Context) int {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		return 1
+	}
+	return page
+}
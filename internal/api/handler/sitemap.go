@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// SitemapHandler 结构体，用于挂载与站点地图和 robots.txt 相关的方法。
+type SitemapHandler struct {
+	sitemapService *service.SitemapService
+}
+
+// NewSitemapHandler 是 SitemapHandler 的构造函数。
+func NewSitemapHandler() *SitemapHandler {
+	return &SitemapHandler{
+		sitemapService: service.NewSitemapService(),
+	}
+}
+
+// SitemapHandler 输出 /sitemap.xml，地址过多时输出站点地图索引。
+func (h *SitemapHandler) SitemapHandler(c *gin.Context) {
+	doc, err := h.sitemapService.Sitemap(sitemapBaseURL(c))
+	if err != nil {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	serveDocument(c, doc)
+}
+
+// SitemapChunkHandler 输出站点地图的分片，例如 /sitemaps/sitemap-2.xml。
+func (h *SitemapHandler) SitemapChunkHandler(c *gin.Context) {
+	name := c.Param("file")
+	if !strings.HasPrefix(name, "sitemap-") || !strings.HasSuffix(name, ".xml") {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(name, "sitemap-"), ".xml"))
+	if err != nil {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	doc, err := h.sitemapService.Chunk(n)
+	if err != nil {
+		if errors.Is(err, service.ErrSitemapNotFound) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+	serveDocument(c, doc)
+}
+
+// RobotsHandler 输出 /robots.txt。
+func (h *SitemapHandler) RobotsHandler(c *gin.Context) {
+	sitemapURL := config.Conf.Sitemap.PublicURL
+	if sitemapURL == "" {
+		sitemapURL = sitemapBaseURL(c) + "/sitemap.xml"
+	}
+	c.String(http.StatusOK, h.sitemapService.Robots(sitemapURL))
+}
+
+// sitemapBaseURL 返回站点地图所在的地址前缀（不含末尾的斜杠）。
+// 配置了 sitemap.public_url 时以它为准，否则根据当前请求推断。
+func sitemapBaseURL(c *gin.Context) string {
+	if publicURL := config.Conf.Sitemap.PublicURL; publicURL != "" {
+		return strings.TrimSuffix(publicURL, "/sitemap.xml")
+	}
+	return strings.TrimSuffix(requestURL(c), c.Request.URL.Path)
+}
+
+// serveDocument 输出一个渲染好的文档，并处理基于 ETag 和 Last-Modified 的条件请求。
+func serveDocument(c *gin.Context, doc *service.RenderedDocument) {
+	lastModified := doc.LastModified.UTC().Truncate(time.Second)
+	c.Header("ETag", doc.ETag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	}
+	// 允许缓存，但每次使用前都要向服务端确认，内容变化后可以立刻拿到新版本
+	c.Header("Cache-Control", "public, no-cache")
+
+	if notModified(c, doc.ETag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, doc.ContentType, doc.Body)
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// SpamHandler 结构体，用于挂载与垃圾内容检测相关的 API 方法。
+type SpamHandler struct {
+	spamService *service.SpamService
+}
+
+// NewSpamHandler 是 SpamHandler 的构造函数。
+func NewSpamHandler() *SpamHandler {
+	return &SpamHandler{
+		spamService: service.NewSpamService(),
+	}
+}
+
+// FormTokenHandler 签发表单令牌。
+// 前端在展示评论或注册表单时调用，提交时将令牌放在 form_token 字段中带回。
+func (h *SpamHandler) FormTokenHandler(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	response.Success(h.spamService.FormToken(), c)
+}
+
+// SpamStatsHandler 返回贝叶斯分类器的训练情况。
+func (h *SpamHandler) SpamStatsHandler(c *gin.Context) {
+	stats, err := h.spamService.Stats()
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(stats, c)
+}
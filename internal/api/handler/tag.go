@@ -11,13 +11,18 @@ import (
 
 // TagHandler 结构体，用于挂载与标签相关的 API 方法。
 type TagHandler struct {
-	tagService *service.TagService
+	tagService service.TagStore
 }
 
 // NewTagHandler 是 TagHandler 的构造函数。
 func NewTagHandler() *TagHandler {
+	return NewTagHandlerWithStore(service.NewTagService())
+}
+
+// NewTagHandlerWithStore 使用指定的 TagStore 创建 TagHandler，用于测试。
+func NewTagHandlerWithStore(store service.TagStore) *TagHandler {
 	return &TagHandler{
-		tagService: service.NewTagService(),
+		tagService: store,
 	}
 }
 
@@ -30,12 +35,12 @@ type CreateTagRequest struct {
 func (h *TagHandler) CreateTagHandler(c *gin.Context) {
 	var req CreateCategoryRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error("参数校验失败:"+err.Error(), c)
+		response.ValidationError(err, c)
 		return
 	}
 	tag, err := h.tagService.Create(req.Name)
 	if err != nil {
-		response.Error(err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 	response.Success(tag, c)
@@ -45,7 +50,7 @@ func (h *TagHandler) CreateTagHandler(c *gin.Context) {
 func (h *TagHandler) ListTagsHandler(c *gin.Context) {
 	tags, err := h.tagService.List()
 	if err != nil {
-		response.Error("获取标签列表失败："+err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 	response.Success(tags, c)
@@ -61,17 +66,17 @@ func (h *TagHandler) UpdateTagHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		response.Error("无效的标签 ID", c)
+		response.Fail(errInvalidTagID, c)
 		return
 	}
 	var req UpdateTagRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error("参数校验失败"+err.Error(), c)
+		response.ValidationError(err, c)
 		return
 	}
 	updatedTag, err := h.tagService.Update(uint(id), req.Name)
 	if err != nil {
-		response.Error(err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 	response.Success(updatedTag, c)
@@ -82,12 +87,95 @@ func (h *TagHandler) DeleteTagHandler(c *gin.Context) {
 	idStr := c.Param("id")
 	id, err := strconv.ParseUint(idStr, 10, 32)
 	if err != nil {
-		response.Error("无效的标签 ID", c)
+		response.Fail(errInvalidTagID, c)
 		return
 	}
 	if err := h.tagService.Delete(uint(id)); err != nil {
-		response.Error(err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 	response.Success(nil, c)
 }
+
+// MergeTagsRequest 定义了合并标签接口的请求体。
+type MergeTagsRequest struct {
+	SourceIDs []uint `json:"source_ids" binding:"required,min=1"`
+	TargetID  uint   `json:"target_id" binding:"required"`
+}
+
+// MergeTagsHandler 是处理合并标签请求的 Gin Handler。
+func (h *TagHandler) MergeTagsHandler(c *gin.Context) {
+	var req MergeTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+	tag, err := h.tagService.Merge(&service.MergeTagsDTO{
+		SourceIDs: req.SourceIDs,
+		TargetID:  req.TargetID,
+	})
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(tag, c)
+}
+
+// ListTagUsageHandler 是处理获取标签使用次数统计请求的 Gin Handler。
+func (h *TagHandler) ListTagUsageHandler(c *gin.Context) {
+	usages, err := h.tagService.ListWithUsage()
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(usages, c)
+}
+
+// TagCloudHandler 是处理获取标签云请求的 Gin Handler。
+// 可以通过查询参数 limit 限制返回的标签数量，默认返回全部。
+func (h *TagHandler) TagCloudHandler(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	items, err := h.tagService.Cloud(limit)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(items, c)
+}
+
+// SuggestTagsHandler 是处理标签自动补全请求的 Gin Handler。
+// 查询参数 q 为标签名称前缀，limit 为返回数量上限（默认 10，最多 50）。
+func (h *TagHandler) SuggestTagsHandler(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if limit <= 0 || limit > 50 {
+		limit = 10
+	}
+	tags, err := h.tagService.Suggest(c.Query("q"), limit)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(tags, c)
+}
+
+// SetTagTranslationsHandler 是处理设置标签名称翻译请求的 Gin Handler。
+func (h *TagHandler) SetTagTranslationsHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidTagID, c)
+		return
+	}
+
+	var req SetTranslationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+
+	tag, err := h.tagService.SetTranslations(uint(id), req.Translations)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(tag, c)
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/KeLes-Coding/gopress/internal/api/middleware"
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/KeLes-Coding/gopress/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+// TokenHandler 结构体，用于挂载与个人访问令牌相关的 API 方法。
+type TokenHandler struct {
+	tokenService *service.TokenService
+}
+
+// NewTokenHandler 是 TokenHandler 的构造函数。
+func NewTokenHandler() *TokenHandler {
+	return &TokenHandler{
+		tokenService: service.NewTokenService(),
+	}
+}
+
+// CreateTokenRequest 定义了创建令牌接口的请求体。
+type CreateTokenRequest struct {
+	Name      string   `json:"name" binding:"required,max=100"`
+	Scopes    []string `json:"scopes" binding:"required"`
+	ExpiresIn int      `json:"expires_in" binding:"min=0,max=3650"` // 有效天数，0 表示永不过期
+}
+
+// ListTokensHandler 获取当前用户的所有令牌。
+func (h *TokenHandler) ListTokensHandler(c *gin.Context) {
+	_claims, _ := c.Get(middleware.CtxUserClaimsKey)
+	claims := _claims.(*util.MyClaims)
+
+	tokens, err := h.tokenService.List(claims.UserID)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(tokens, c)
+}
+
+// CreateTokenHandler 为当前用户创建一个令牌，令牌明文只在响应中出现这一次。
+func (h *TokenHandler) CreateTokenHandler(c *gin.Context) {
+	var req CreateTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+
+	_claims, _ := c.Get(middleware.CtxUserClaimsKey)
+	claims := _claims.(*util.MyClaims)
+
+	token, err := h.tokenService.Create(&service.CreateAccessTokenDTO{
+		UserID:    claims.UserID,
+		Name:      req.Name,
+		Scopes:    req.Scopes,
+		ExpiresIn: req.ExpiresIn,
+	})
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	response.Success(token, c)
+}
+
+// RevokeTokenHandler 撤销当前用户的一个令牌。
+func (h *TokenHandler) RevokeTokenHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidTokenID, c)
+		return
+	}
+
+	_claims, _ := c.Get(middleware.CtxUserClaimsKey)
+	claims := _claims.(*util.MyClaims)
+
+	if err := h.tokenService.Revoke(claims.UserID, uint(id)); err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(nil, c)
+}
@@ -6,9 +6,13 @@
 package handler
 
 import (
+	"errors"
+
 	"github.com/KeLes-Coding/gopress/internal/api/middleware"
 	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/i18n"
 	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/KeLes-Coding/gopress/internal/spam"
 	"github.com/KeLes-Coding/gopress/internal/util"
 	"github.com/gin-gonic/gin"
 )
@@ -16,12 +20,14 @@ import (
 // UserHandler 结构体，用于挂载与用户相关的 API 方法。
 type UserHandler struct {
 	userService *service.UserService
+	spamService *service.SpamService
 }
 
 // NewUserHandler 是 UserHandler 的构造函数。
 func NewUserHandler() *UserHandler {
 	return &UserHandler{
 		userService: service.NewUserService(),
+		spamService: service.NewSpamService(),
 	}
 }
 
@@ -32,6 +38,10 @@ type SignUpRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
+
+	// 垃圾内容检测使用的字段，与发表评论接口相同
+	Website   string `json:"website"`
+	FormToken string `json:"form_token"`
 }
 
 // SignUpHandler 是处理用户注册请求的 Gin Handler。
@@ -42,19 +52,35 @@ func (h *UserHandler) SignUpHandler(c *gin.Context) {
 	// 如果 JSON 格式错误或缺少 `binding:"required"` 的字段，会返回一个错误。
 	if err := c.ShouldBindJSON(&req); err != nil {
 		// 如果参数绑定失败，说明客户端请求格式有误，返回一个错误响应。
-		response.Error("请求参数无效"+err.Error(), c)
+		response.ValidationError(err, c)
+		return
+	}
+
+	// 2. 垃圾注册检测，被判定为垃圾注册时返回笼统的错误信息，不透露具体原因
+	check := h.spamService.Check(&spam.Submission{
+		Kind:        spam.KindSignup,
+		AuthorName:  req.Username,
+		AuthorEmail: req.Email,
+		IP:          c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+		Referrer:    c.Request.Referer(),
+		Honeypot:    req.Website,
+		FormToken:   req.FormToken,
+	})
+	if check.Verdict == spam.Spam {
+		response.Fail(errSignUpRejected, c)
 		return
 	}
 
-	// 2. 调用 service 层处理注册逻辑
+	// 3. 调用 service 层处理注册逻辑
 	err := h.userService.SignUp(req.Username, req.Password, req.Email)
 	if err != nil {
 		// 如果 service 层返回错误，将错误信息返回给客户端。
-		response.Error(err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 
-	// 3. 注册成功，返回成功响应
+	// 4. 注册成功，返回成功响应
 	// 注册成功后，通常不返回具体数据，或者只返回一些基本信息（如用户ID），这里我们返回 nil。
 	response.Success(nil, c)
 }
@@ -71,12 +97,31 @@ type LoginResponse struct {
 	Token string `json:"token"`
 }
 
+// ProfileResponse 定义了获取当前用户信息接口返回的数据结构。
+type ProfileResponse struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Language string `json:"language"` // 设置的接口语言，为空表示跟随 Accept-Language
+}
+
+// UpdateLanguageRequest 定义了设置接口语言的请求体。
+type UpdateLanguageRequest struct {
+	Language string `json:"language" binding:"max=16"` // 语言标签，例如 en、zh-CN；为空表示跟随 Accept-Language
+}
+
+// LanguageResponse 定义了设置接口语言后返回的数据结构。
+type LanguageResponse struct {
+	Language string `json:"language"`
+	// 记录了新语言的 token，客户端应替换原来的 token；原来的 token 在过期前仍然有效，但使用修改前的语言
+	Token string `json:"token"`
+}
+
 // LoginHandler 是处理用户登录请求的 Gin Handler。
 func (h *UserHandler) LoginHandler(c *gin.Context) {
 	// 1. 绑定和校验请求参数
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		response.Error("请求参数无效", c)
+		response.ValidationError(err, c)
 		return
 	}
 
@@ -84,7 +129,7 @@ func (h *UserHandler) LoginHandler(c *gin.Context) {
 	token, err := h.userService.Login(req.Username, req.Password)
 	if err != nil {
 		// 如果 service 返回错误，将其返回给客户端
-		response.Error(err.Error(), c)
+		response.Fail(err, c)
 		return
 	}
 
@@ -99,7 +144,7 @@ func (h *UserHandler) GetMyProfileHandler(c *gin.Context) {
 	_claims, exists := c.Get(middleware.CtxUserClaimsKey)
 	if !exists {
 		// 如果 claims 不存在，通常意味着中间件逻辑有误，是一个服务端错误
-		response.Error("无法获取用户信息", c)
+		response.Fail(errors.New("missing user claims in context"), c)
 		return
 	}
 
@@ -107,13 +152,51 @@ func (h *UserHandler) GetMyProfileHandler(c *gin.Context) {
 	claims, ok := _claims.(*util.MyClaims)
 	if !ok {
 		// 如果类型断言失败，也是一个服务端错误
-		response.Error("用户信息类型错误", c)
+		response.Fail(errors.New("unexpected user claims type"), c)
+		return
+	}
+
+	language, err := h.userService.PreferredLanguage(claims.UserID)
+	if err != nil {
+		response.Fail(err, c)
 		return
 	}
 
-	// 返回 claims 中的用户信息，构造一个简单的 map 作为 data
-	response.Success(gin.H{
-		"user_id":  claims.UserID,
-		"username": claims.Username,
+	// 返回 claims 中的用户信息
+	response.Success(ProfileResponse{
+		UserID:   claims.UserID,
+		Username: claims.Username,
+		Language: language,
 	}, c)
 }
+
+// UpdateLanguageHandler 用于设置当前用户的接口语言，之后使用响应中新 token 的请求即使没有 Accept-Language 也会使用该语言。
+func (h *UserHandler) UpdateLanguageHandler(c *gin.Context) {
+	var req UpdateLanguageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+
+	_claims, _ := c.Get(middleware.CtxUserClaimsKey)
+	claims := _claims.(*util.MyClaims)
+
+	language, err := h.userService.SetPreferredLanguage(claims.UserID, req.Language)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	// 接口语言记录在 token 中，签发新的 token 供之后的请求使用
+	token, err := h.userService.RefreshToken(claims.UserID)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	// 本次响应就使用新的语言
+	if language != "" {
+		i18n.SetLanguage(c, language)
+	} else {
+		i18n.SetLanguage(c, i18n.Negotiate(c.GetHeader("Accept-Language")))
+	}
+	response.Success(LanguageResponse{Language: language, Token: token}, c)
+}
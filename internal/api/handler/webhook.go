@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"strconv"
+
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler 结构体，用于挂载与 Webhook 订阅和投递记录相关的 API 方法。
+type WebhookHandler struct {
+	webhookService *service.WebhookService
+}
+
+// NewWebhookHandler 是 WebhookHandler 的构造函数。
+func NewWebhookHandler() *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: service.NewWebhookService(),
+	}
+}
+
+// SaveWebhookRequest 定义了创建和修改订阅接口的请求体。
+type SaveWebhookRequest struct {
+	Name   string   `json:"name" binding:"required,max=100"`
+	URL    string   `json:"url" binding:"required,max=700"`
+	Events []string `json:"events" binding:"required"`
+	Active *bool    `json:"active"` // 创建时默认为 true，修改时不传则保持不变
+	// 签名密钥，创建时不传则自动生成；修改时传入表示更换密钥，不传则保持不变
+	Secret string `json:"secret" binding:"max=100"`
+}
+
+// toDTO 将请求体转换为 service 层的参数。
+func (r *SaveWebhookRequest) toDTO(id uint) *service.SaveWebhookDTO {
+	return &service.SaveWebhookDTO{
+		ID:     id,
+		Name:   r.Name,
+		URL:    r.URL,
+		Events: r.Events,
+		Active: r.Active,
+		Secret: r.Secret,
+	}
+}
+
+// ListWebhooksHandler 获取所有订阅。
+func (h *WebhookHandler) ListWebhooksHandler(c *gin.Context) {
+	webhooks, err := h.webhookService.List()
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(webhooks, c)
+}
+
+// CreateWebhookHandler 创建一个订阅，签名密钥只在响应中出现这一次。
+func (h *WebhookHandler) CreateWebhookHandler(c *gin.Context) {
+	var req SaveWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+
+	webhook, err := h.webhookService.Create(req.toDTO(0))
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	response.Success(webhook, c)
+}
+
+// UpdateWebhookHandler 修改一个订阅，更换了密钥时新的密钥只在响应中出现这一次。
+func (h *WebhookHandler) UpdateWebhookHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidWebhookID, c)
+		return
+	}
+	var req SaveWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+
+	webhook, err := h.webhookService.Update(req.toDTO(uint(id)))
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	response.Success(webhook, c)
+}
+
+// DeleteWebhookHandler 删除一个订阅及其投递记录。
+func (h *WebhookHandler) DeleteWebhookHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidWebhookID, c)
+		return
+	}
+	if err := h.webhookService.Delete(uint(id)); err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(nil, c)
+}
+
+// ListWebhookDeliveriesHandler 获取一个订阅的投递记录。
+// 支持 page、pageSize 分页参数，以及 status (pending, delivered, failed) 过滤参数。
+func (h *WebhookHandler) ListWebhookDeliveriesHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidWebhookID, c)
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	dto := &service.ListWebhookDeliveriesDTO{
+		WebhookID: uint(id),
+		Page:      page,
+		PageSize:  pageSize,
+	}
+	if name := c.Query("status"); name != "" {
+		status, ok := service.ParseWebhookDeliveryStatus(name)
+		if !ok {
+			response.Fail(errInvalidDeliveryStatus, c)
+			return
+		}
+		dto.Status = &status
+	}
+
+	result, err := h.webhookService.ListDeliveries(dto)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(result, c)
+}
+
+// GetWebhookDeliveryHandler 获取一条投递记录，包含请求体和最近一次响应的正文。
+func (h *WebhookHandler) GetWebhookDeliveryHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidDeliveryID, c)
+		return
+	}
+	delivery, err := h.webhookService.GetDelivery(uint(id))
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(delivery, c)
+}
+
+// RedeliverWebhookHandler 使用原来的请求体重新投递一次，返回新创建的投递记录。
+func (h *WebhookHandler) RedeliverWebhookHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidDeliveryID, c)
+		return
+	}
+	delivery, err := h.webhookService.Redeliver(uint(id))
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(delivery, c)
+}
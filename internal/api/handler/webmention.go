@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/apperr"
+	"github.com/KeLes-Coding/gopress/internal/i18n"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// WebmentionHandler 结构体，用于挂载与 Webmention 相关的 API 方法。
+type WebmentionHandler struct {
+	webmentionService *service.WebmentionService
+}
+
+// NewWebmentionHandler 是 WebmentionHandler 的构造函数。
+func NewWebmentionHandler() *WebmentionHandler {
+	return &WebmentionHandler{
+		webmentionService: service.NewWebmentionService(),
+	}
+}
+
+// ReceiveWebmentionHandler 是 Webmention 的接收地址，请求体为表单格式的 source 和 target。
+// 按照协议使用 HTTP 状态码响应：参数有误时返回 400，接受后返回 202，来源页面在后台异步校验。
+func (h *WebmentionHandler) ReceiveWebmentionHandler(c *gin.Context) {
+	source := c.PostForm("source")
+	target := c.PostForm("target")
+	postID, err := h.webmentionService.Validate(source, target)
+	if err != nil {
+		if _, ok := apperr.From(err); ok {
+			c.String(http.StatusBadRequest, i18n.Error(i18n.FromContext(c), err))
+			return
+		}
+		logger.L.Error("Failed to validate webmention", zap.String("source", source), zap.Error(err))
+		c.String(http.StatusInternalServerError, "服务器内部错误")
+		return
+	}
+	if err := h.webmentionService.Receive(postID, source, target, c.ClientIP()); err != nil {
+		logger.L.Error("Failed to save webmention", zap.String("source", source), zap.Error(err))
+		c.String(http.StatusInternalServerError, "服务器内部错误")
+		return
+	}
+	c.String(http.StatusAccepted, "Webmention 已接收，将在校验来源页面后处理")
+}
+
+// ListPostWebmentionsHandler 是获取文章下 Webmention 的 Gin Handler，只返回已校验并通过审核的通知。
+func (h *WebmentionHandler) ListPostWebmentionsHandler(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidPostID, c)
+		return
+	}
+	mentions, err := h.webmentionService.ListForPost(uint(postID))
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(mentions, c)
+}
+
+// ListWebmentionsHandler 是后台获取收到的 Webmention 列表的 Gin Handler。
+// 支持 page、pageSize 分页参数，以及 status (pending, approved, rejected)、
+// verification (queued, verified, failed) 和 post_id 过滤参数。
+func (h *WebmentionHandler) ListWebmentionsHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	dto := &service.ListWebmentionsDTO{
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if name := c.Query("status"); name != "" {
+		status, ok := service.ParseWebmentionStatus(name)
+		if !ok {
+			response.Fail(errInvalidWebmentionStatus, c)
+			return
+		}
+		dto.Status = &status
+	}
+	if name := c.Query("verification"); name != "" {
+		verification, ok := service.ParseWebmentionVerification(name)
+		if !ok {
+			response.Fail(errInvalidVerification, c)
+			return
+		}
+		dto.Verification = &verification
+	}
+	if postID := c.Query("post_id"); postID != "" {
+		id, err := strconv.ParseUint(postID, 10, 32)
+		if err != nil {
+			response.Fail(errInvalidPostID, c)
+			return
+		}
+		dto.PostID = uint(id)
+	}
+
+	result, err := h.webmentionService.List(dto)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(result, c)
+}
+
+// ModerateWebmentionsRequest 定义了批量审核 Webmention 接口的请求体。
+type ModerateWebmentionsRequest struct {
+	IDs    []uint `json:"ids" binding:"required,min=1"`
+	Status string `json:"status" binding:"required,oneof=pending approved rejected"`
+}
+
+// ModerateWebmentionsHandler 是批量修改 Webmention 审核状态的 Gin Handler。
+func (h *WebmentionHandler) ModerateWebmentionsHandler(c *gin.Context) {
+	var req ModerateWebmentionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.ValidationError(err, c)
+		return
+	}
+	status, _ := service.ParseWebmentionStatus(req.Status)
+	updated, err := h.webmentionService.Moderate(req.IDs, status)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(ModerateResponse{Updated: updated}, c)
+}
+
+// VerifyWebmentionHandler 是重新校验一条 Webmention 的 Gin Handler。
+func (h *WebmentionHandler) VerifyWebmentionHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidWebmentionID, c)
+		return
+	}
+	if err := h.webmentionService.Verify(uint(id)); err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(nil, c)
+}
+
+// DeleteWebmentionHandler 是删除一条收到的 Webmention 的 Gin Handler。
+func (h *WebmentionHandler) DeleteWebmentionHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidWebmentionID, c)
+		return
+	}
+	if err := h.webmentionService.Delete(uint(id)); err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(nil, c)
+}
+
+// ListWebmentionSendsHandler 是后台获取发出的 Webmention 列表的 Gin Handler。
+// 支持 page、pageSize 分页参数，以及 status (pending, sent, no_endpoint, failed) 和 post_id 过滤参数。
+func (h *WebmentionHandler) ListWebmentionSendsHandler(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("pageSize", "20"))
+	dto := &service.ListWebmentionSendsDTO{
+		Page:     page,
+		PageSize: pageSize,
+	}
+	if name := c.Query("status"); name != "" {
+		status, ok := service.ParseWebmentionSendStatus(name)
+		if !ok {
+			response.Fail(errInvalidSendStatus, c)
+			return
+		}
+		dto.Status = &status
+	}
+	if postID := c.Query("post_id"); postID != "" {
+		id, err := strconv.ParseUint(postID, 10, 32)
+		if err != nil {
+			response.Fail(errInvalidPostID, c)
+			return
+		}
+		dto.PostID = uint(id)
+	}
+
+	result, err := h.webmentionService.ListSends(dto)
+	if err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(result, c)
+}
+
+// RetryWebmentionSendHandler 是立即重新发送一条 Webmention 的 Gin Handler。
+func (h *WebmentionHandler) RetryWebmentionSendHandler(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Fail(errInvalidWebmentionID, c)
+		return
+	}
+	if err := h.webmentionService.RetrySend(uint(id)); err != nil {
+		response.Fail(err, c)
+		return
+	}
+	response.Success(nil, c)
+}
@@ -0,0 +1,462 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/KeLes-Coding/gopress/internal/apperr"
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/i18n"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/KeLes-Coding/gopress/internal/xmlrpc"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// metaWeblogBlogID 是返回给客户端的博客 ID。本站只有一个博客，客户端传入的博客 ID 会被忽略。
+const metaWeblogBlogID = "1"
+
+// xmlrpcMethod 是一个 XML-RPC 方法的实现。
+type xmlrpcMethod func(c *gin.Context, call *xmlrpc.Call) (interface{}, error)
+
+// XMLRPCHandler 结构体，用于挂载 MetaWeblog / Blogger XML-RPC 接口。
+// 与其他接口不同，XML-RPC 的每次调用都在参数中携带用户名和密码，不使用 JWT。
+type XMLRPCHandler struct {
+	userService       *service.UserService
+	metaWeblogService *service.MetaWeblogService
+	methods           map[string]xmlrpcMethod
+}
+
+// NewXMLRPCHandler 是 XMLRPCHandler 的构造函数。
+func NewXMLRPCHandler() *XMLRPCHandler {
+	h := &XMLRPCHandler{
+		userService:       service.NewUserService(),
+		metaWeblogService: service.NewMetaWeblogService(),
+	}
+	h.methods = map[string]xmlrpcMethod{
+		"blogger.getUsersBlogs":     h.getUsersBlogs,
+		"blogger.getUserInfo":       h.getUserInfo,
+		"blogger.deletePost":        h.deletePost,
+		"metaWeblog.newPost":        h.newPost,
+		"metaWeblog.editPost":       h.editPost,
+		"metaWeblog.getPost":        h.getPost,
+		"metaWeblog.getRecentPosts": h.getRecentPosts,
+		"metaWeblog.getCategories":  h.getCategories,
+		"metaWeblog.newMediaObject": h.newMediaObject,
+		"mt.getCategoryList":        h.getCategoryList,
+		"system.listMethods":        h.listMethods,
+	}
+	// 部分客户端使用 metaWeblog 前缀调用 Blogger 的方法
+	h.methods["metaWeblog.getUsersBlogs"] = h.getUsersBlogs
+	h.methods["metaWeblog.deletePost"] = h.deletePost
+	return h
+}
+
+// XMLRPCHandler 是 XML-RPC 接口的入口，解析 methodCall 并分发到对应的方法。
+// 按照 XML-RPC 的约定，调用失败时同样返回 200 状态码，错误信息放在 fault 中。
+func (h *XMLRPCHandler) XMLRPCHandler(c *gin.Context) {
+	// 请求体中可能包含 base64 编码的媒体文件，上限按文件大小上限放宽
+	limit := service.MaxUploadSize()*4/3 + 1<<20
+	call, err := xmlrpc.DecodeCall(http.MaxBytesReader(c.Writer, c.Request.Body, limit))
+	if err != nil {
+		var fault *xmlrpc.Fault
+		if !errors.As(err, &fault) {
+			fault = &xmlrpc.Fault{Code: xmlrpc.FaultParse, Message: "无法解析请求: " + err.Error()}
+		}
+		h.writeFault(c, fault)
+		return
+	}
+
+	method, ok := h.methods[call.Method]
+	if !ok {
+		h.writeFault(c, &xmlrpc.Fault{Code: xmlrpc.FaultMethodNotFound, Message: "不支持的方法: " + call.Method})
+		return
+	}
+	result, err := method(c, call)
+	if err != nil {
+		h.writeFault(c, h.toFault(c, call.Method, err))
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := xmlrpc.EncodeResponse(&buf, result); err != nil {
+		logger.L.Error("Failed to encode XML-RPC response", zap.String("method", call.Method), zap.Error(err))
+		h.writeFault(c, &xmlrpc.Fault{Code: xmlrpc.FaultInternal, Message: i18n.Message(i18n.FromContext(c), apperr.ErrInternal)})
+		return
+	}
+	c.Data(http.StatusOK, "text/xml; charset=utf-8", buf.Bytes())
+}
+
+// toFault 将方法返回的错误转换为 XML-RPC fault，错误码参考 WordPress 的实现以便客户端识别。
+// 业务错误的消息翻译为当前请求的语言；其他错误只记录在日志中，客户端收到的是统一的提示。
+func (h *XMLRPCHandler) toFault(c *gin.Context, method string, err error) *xmlrpc.Fault {
+	var fault *xmlrpc.Fault
+	if errors.As(err, &fault) {
+		return fault
+	}
+	lang := i18n.FromContext(c)
+	e, ok := apperr.From(err)
+	if !ok {
+		logger.L.Error("XML-RPC call failed", zap.String("method", method), zap.Error(err))
+		return &xmlrpc.Fault{Code: http.StatusInternalServerError, Message: i18n.Message(lang, apperr.ErrInternal)}
+	}
+	code := e.Kind.HTTPStatus()
+	if e.Kind == apperr.KindUnauthenticated {
+		// WordPress 对用户名或密码错误返回 403
+		code = http.StatusForbidden
+	}
+	return &xmlrpc.Fault{Code: code, Message: i18n.Message(lang, e)}
+}
+
+// writeFault 输出一个 fault 响应。
+func (h *XMLRPCHandler) writeFault(c *gin.Context, fault *xmlrpc.Fault) {
+	var buf bytes.Buffer
+	_ = xmlrpc.EncodeFault(&buf, fault)
+	c.Data(http.StatusOK, "text/xml; charset=utf-8", buf.Bytes())
+}
+
+// authenticate 使用第 i 个和第 i+1 个参数中的用户名和密码认证用户。
+func (h *XMLRPCHandler) authenticate(call *xmlrpc.Call, i int) (*model.User, error) {
+	username, err := call.String(i)
+	if err != nil {
+		return nil, err
+	}
+	password, err := call.String(i + 1)
+	if err != nil {
+		return nil, err
+	}
+	return h.userService.Authenticate(username, password)
+}
+
+// actorOf 返回以 user 的身份执行操作的 service.Actor。
+func actorOf(user *model.User) service.Actor {
+	return service.Actor{UserID: user.ID, Admin: user.Role == model.RoleAdmin}
+}
+
+// postID 解析第 i 个参数中的文章 ID。
+func postID(call *xmlrpc.Call, i int) (uint, error) {
+	s, err := call.String(i)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseUint(strings.TrimSpace(s), 10, 32)
+	if err != nil {
+		return 0, &xmlrpc.Fault{Code: 404, Message: "文章不存在"}
+	}
+	return uint(id), nil
+}
+
+// getUsersBlogs 实现了 blogger.getUsersBlogs(appkey, username, password)。
+func (h *XMLRPCHandler) getUsersBlogs(c *gin.Context, call *xmlrpc.Call) (interface{}, error) {
+	user, err := h.authenticate(call, 1)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{map[string]interface{}{
+		"blogid":   metaWeblogBlogID,
+		"blogName": config.Conf.Site.Title,
+		"url":      config.Conf.Site.URL,
+		"xmlrpc":   absoluteURL(c, "/xmlrpc"),
+		"isAdmin":  user.Role == model.RoleAdmin,
+	}}, nil
+}
+
+// getUserInfo 实现了 blogger.getUserInfo(appkey, username, password)。
+func (h *XMLRPCHandler) getUserInfo(_ *gin.Context, call *xmlrpc.Call) (interface{}, error) {
+	user, err := h.authenticate(call, 1)
+	if err != nil {
+		return nil, err
+	}
+	nickname := user.Nickname
+	if nickname == "" {
+		nickname = user.Username
+	}
+	return map[string]interface{}{
+		"userid":    strconv.FormatUint(uint64(user.ID), 10),
+		"nickname":  nickname,
+		"email":     user.Email,
+		"url":       config.Conf.Site.URL,
+		"firstname": "",
+		"lastname":  "",
+	}, nil
+}
+
+// deletePost 实现了 blogger.deletePost(appkey, postid, username, password, publish)。
+func (h *XMLRPCHandler) deletePost(_ *gin.Context, call *xmlrpc.Call) (interface{}, error) {
+	user, err := h.authenticate(call, 2)
+	if err != nil {
+		return nil, err
+	}
+	id, err := postID(call, 1)
+	if err != nil {
+		return nil, err
+	}
+	if err := h.metaWeblogService.DeletePost(id, actorOf(user)); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// newPost 实现了 metaWeblog.newPost(blogid, username, password, struct, publish)，返回新文章的 ID。
+func (h *XMLRPCHandler) newPost(_ *gin.Context, call *xmlrpc.Call) (interface{}, error) {
+	user, err := h.authenticate(call, 1)
+	if err != nil {
+		return nil, err
+	}
+	dto, err := metaWeblogPostDTO(call, 3, 4)
+	if err != nil {
+		return nil, err
+	}
+	post, err := h.metaWeblogService.NewPost(user.ID, dto)
+	if err != nil {
+		return nil, err
+	}
+	return strconv.FormatUint(uint64(post.ID), 10), nil
+}
+
+// editPost 实现了 metaWeblog.editPost(postid, username, password, struct, publish)。
+func (h *XMLRPCHandler) editPost(_ *gin.Context, call *xmlrpc.Call) (interface{}, error) {
+	user, err := h.authenticate(call, 1)
+	if err != nil {
+		return nil, err
+	}
+	id, err := postID(call, 0)
+	if err != nil {
+		return nil, err
+	}
+	dto, err := metaWeblogPostDTO(call, 3, 4)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := h.metaWeblogService.EditPost(id, actorOf(user), dto); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// getPost 实现了 metaWeblog.getPost(postid, username, password)。
+func (h *XMLRPCHandler) getPost(_ *gin.Context, call *xmlrpc.Call) (interface{}, error) {
+	user, err := h.authenticate(call, 1)
+	if err != nil {
+		return nil, err
+	}
+	id, err := postID(call, 0)
+	if err != nil {
+		return nil, err
+	}
+	post, err := h.metaWeblogService.GetPost(id, actorOf(user))
+	if err != nil {
+		return nil, err
+	}
+	return metaWeblogPost(post), nil
+}
+
+// getRecentPosts 实现了 metaWeblog.getRecentPosts(blogid, username, password, numberOfPosts)。
+func (h *XMLRPCHandler) getRecentPosts(_ *gin.Context, call *xmlrpc.Call) (interface{}, error) {
+	user, err := h.authenticate(call, 1)
+	if err != nil {
+		return nil, err
+	}
+	n := int64(0)
+	if len(call.Params) > 3 {
+		if n, err = call.Int(3); err != nil {
+			return nil, err
+		}
+	}
+	posts, err := h.metaWeblogService.RecentPosts(actorOf(user), int(n))
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, len(posts))
+	for i := range posts {
+		result[i] = metaWeblogPost(&posts[i])
+	}
+	return result, nil
+}
+
+// getCategories 实现了 metaWeblog.getCategories(blogid, username, password)。
+func (h *XMLRPCHandler) getCategories(_ *gin.Context, call *xmlrpc.Call) (interface{}, error) {
+	if _, err := h.authenticate(call, 1); err != nil {
+		return nil, err
+	}
+	categories, err := h.metaWeblogService.Categories()
+	if err != nil {
+		return nil, err
+	}
+	site := strings.TrimRight(config.Conf.Site.URL, "/")
+	result := make([]interface{}, len(categories))
+	for i, category := range categories {
+		id := strconv.FormatUint(uint64(category.ID), 10)
+		result[i] = map[string]interface{}{
+			"categoryId":   id,
+			"parentId":     "0",
+			"categoryName": category.Name,
+			"title":        category.Name,
+			"description":  category.Name,
+			"htmlUrl":      service.CategoryURL(category.ID),
+			"rssUrl":       site + "/category/" + id + "/feed.xml",
+		}
+	}
+	return result, nil
+}
+
+// getCategoryList 实现了 mt.getCategoryList(blogid, username, password)。
+func (h *XMLRPCHandler) getCategoryList(_ *gin.Context, call *xmlrpc.Call) (interface{}, error) {
+	if _, err := h.authenticate(call, 1); err != nil {
+		return nil, err
+	}
+	categories, err := h.metaWeblogService.Categories()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]interface{}, len(categories))
+	for i, category := range categories {
+		result[i] = map[string]interface{}{
+			"categoryId":   strconv.FormatUint(uint64(category.ID), 10),
+			"categoryName": category.Name,
+		}
+	}
+	return result, nil
+}
+
+// newMediaObject 实现了 metaWeblog.newMediaObject(blogid, username, password, struct{name, type, bits})。
+func (h *XMLRPCHandler) newMediaObject(c *gin.Context, call *xmlrpc.Call) (interface{}, error) {
+	user, err := h.authenticate(call, 1)
+	if err != nil {
+		return nil, err
+	}
+	file, err := call.Struct(3)
+	if err != nil {
+		return nil, err
+	}
+	bits, ok := file["bits"].([]byte)
+	if !ok || len(bits) == 0 {
+		return nil, &xmlrpc.Fault{Code: xmlrpc.FaultInvalidParams, Message: "缺少文件内容 bits"}
+	}
+	name, _ := file["name"].(string)
+	media, err := h.metaWeblogService.NewMediaObject(user.ID, name, bits)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"id":   strconv.FormatUint(uint64(media.ID), 10),
+		"file": media.FileName,
+		"url":  absoluteURL(c, media.URL),
+		"type": media.MimeType,
+	}, nil
+}
+
+// listMethods 实现了 system.listMethods()。
+func (h *XMLRPCHandler) listMethods(_ *gin.Context, _ *xmlrpc.Call) (interface{}, error) {
+	methods := make([]string, 0, len(h.methods))
+	for name := range h.methods {
+		methods = append(methods, name)
+	}
+	sort.Strings(methods)
+	return methods, nil
+}
+
+// metaWeblogPostDTO 从第 i 个参数的 post 结构体和第 p 个参数的 publish 标志中读取客户端提交的文章。
+func metaWeblogPostDTO(call *xmlrpc.Call, i, p int) (*service.MetaWeblogPostDTO, error) {
+	m, err := call.Struct(i)
+	if err != nil {
+		return nil, err
+	}
+	publish, err := call.Bool(p, true)
+	if err != nil {
+		return nil, err
+	}
+	dto := &service.MetaWeblogPostDTO{Publish: publish}
+	dto.Title, _ = m["title"].(string)
+	dto.Description, _ = m["description"].(string)
+	dto.TextMore, _ = m["mt_text_more"].(string)
+	if excerpt, ok := m["mt_excerpt"].(string); ok {
+		dto.Excerpt = &excerpt
+	}
+	if keywords, ok := m["mt_keywords"].(string); ok {
+		dto.Keywords = &keywords
+	}
+	if categories, ok := m["categories"].([]interface{}); ok {
+		for _, c := range categories {
+			if name, ok := c.(string); ok && strings.TrimSpace(name) != "" {
+				dto.Categories = append(dto.Categories, name)
+			}
+		}
+	}
+	// mt_allow_comments 在 Movable Type 中是整数 (0:无, 1:开放, 2:关闭)，WordPress 中是 open/closed
+	switch v := m["mt_allow_comments"].(type) {
+	case int64:
+		allow := v == 1
+		dto.AllowComments = &allow
+	case bool:
+		dto.AllowComments = &v
+	case string:
+		allow := v == "open" || v == "1"
+		dto.AllowComments = &allow
+	}
+	// WordPress 扩展的 post_status 优先于 publish 参数
+	switch m["post_status"] {
+	case "publish":
+		dto.Publish = true
+	case "draft", "pending", "private":
+		dto.Publish = false
+	}
+	return dto, nil
+}
+
+// metaWeblogPost 将文章转换为 MetaWeblog 的 post 结构体。
+func metaWeblogPost(post *model.Post) map[string]interface{} {
+	tags := make([]string, len(post.Tags))
+	for i, tag := range post.Tags {
+		tags[i] = tag.Name
+	}
+	categories := []string{}
+	if post.Category.Name != "" {
+		categories = append(categories, post.Category.Name)
+	}
+	status := "draft"
+	if post.Status == model.PostStatusPublished {
+		status = "publish"
+	}
+	allowComments := 2
+	if post.CommentsEnabled {
+		allowComments = 1
+	}
+	return map[string]interface{}{
+		"postid":            strconv.FormatUint(uint64(post.ID), 10),
+		"userid":            strconv.FormatUint(uint64(post.UserID), 10),
+		"title":             post.Title,
+		"description":       post.Content,
+		"mt_excerpt":        post.Summary,
+		"mt_text_more":      "",
+		"mt_keywords":       strings.Join(tags, ","),
+		"mt_allow_comments": allowComments,
+		"categories":        categories,
+		"dateCreated":       post.CreatedAt,
+		"date_modified":     post.UpdatedAt,
+		"link":              service.PostURL(post.ID),
+		"permaLink":         service.PostURL(post.ID),
+		"post_status":       status,
+	}
+}
+
+// absoluteURL 将本服务的相对地址转换为完整地址，协议和域名取自当前请求。
+func absoluteURL(c *gin.Context, path string) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + c.Request.Host + path
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/KeLes-Coding/gopress/internal/xmlrpc"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// TestToFault 检查业务错误被翻译为请求的语言，其他错误不会把内部信息返回给客户端。
+func TestToFault(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	saved := logger.L
+	logger.L = zap.NewNop()
+	defer func() { logger.L = saved }()
+
+	tests := []struct {
+		name    string
+		err     error
+		code    int
+		message string
+	}{
+		{"fault", &xmlrpc.Fault{Code: 404, Message: "文章不存在"}, 404, "文章不存在"},
+		{"credentials", service.ErrInvalidCredentials, http.StatusForbidden, "Incorrect username or password"},
+		{"forbidden", fmt.Errorf("edit: %w", service.ErrPostForbidden), http.StatusForbidden, "You can only manage your own posts"},
+		{"not found", service.ErrPostNotFound, http.StatusNotFound, "Post not found"},
+		{"internal", errors.New("Error 1146: Table 'gopress.posts' doesn't exist"), http.StatusInternalServerError, "Internal server error"},
+	}
+	h := &XMLRPCHandler{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPost, "/xmlrpc", nil)
+			c.Request.Header.Set("Accept-Language", "en")
+			fault := h.toFault(c, "metaWeblog.editPost", tt.err)
+			if fault.Code != tt.code {
+				t.Errorf("code = %d, want %d", fault.Code, tt.code)
+			}
+			if fault.Message != tt.message {
+				t.Errorf("message = %q, want %q", fault.Message, tt.message)
+			}
+		})
+	}
+}
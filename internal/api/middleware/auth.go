@@ -5,10 +5,22 @@ import (
 	"strings"
 
 	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/apperr"
+	"github.com/KeLes-Coding/gopress/internal/i18n"
 	"github.com/KeLes-Coding/gopress/internal/util"
 	"github.com/gin-gonic/gin"
 )
 
+// 认证失败的错误，错误码都是 unauthenticated。gRPC 接口的认证也使用这些错误。
+var (
+	ErrMissingToken   = apperr.ErrUnauthenticated.Variant("unauthenticated.missing", "请求未携带 token")
+	ErrMalformedToken = apperr.ErrUnauthenticated.Variant("unauthenticated.malformed", "Token 格式不正确")
+	ErrInvalidToken   = apperr.ErrUnauthenticated.Variant("unauthenticated.invalid", "无效的 token")
+)
+
+// ErrAdminRequired 表示接口只允许管理员访问。
+var ErrAdminRequired = apperr.Forbidden("admin_required", "需要管理员权限")
+
 // CtxUserClaimsKey 是一个常量，用作 Gin Context 中存储用户 Claims 的键。
 // 将其导出可以方便地在其他包（如 handler）中安全地引用，避免因手写字符串错误导致 bug。
 const CtxUserClaimsKey = "userClaims"
@@ -19,7 +31,7 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		// 1. 从 Authorization 请求头中获取 token 字符串
 		authHeader := c.Request.Header.Get("Authorization")
 		if authHeader == "" {
-			response.Unauthorized("请求未携带 token", c)
+			response.Abort(ErrMissingToken, c)
 			return
 		}
 
@@ -27,8 +39,7 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		// 一个标准的 token 格式是 "Bearer <token>"
 		parts := strings.SplitN(authHeader, " ", 2)
 		if !(len(parts) == 2 && parts[0] == "Bearer") {
-			response.Unauthorized("Token 格式不正确", c)
-			c.Abort()
+			response.Abort(ErrMalformedToken, c)
 			return
 		}
 
@@ -37,8 +48,7 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		claims, err := util.ParseToken(tokenString)
 		if err != nil {
 			// 如果 ParseToken 返回错误，则认证失败
-			response.Unauthorized("无效的 token", c)
-			c.Abort()
+			response.Abort(ErrInvalidToken, c)
 			return
 		}
 
@@ -46,7 +56,42 @@ func JWTAuthMiddleware() gin.HandlerFunc {
 		// 这样，后续的 handler 就可以从 context 中获取到当前登录用户的信息
 		c.Set(CtxUserClaimsKey, claims)
 
+		// 用户设置过接口语言时，优先于 Accept-Language 使用。语言在签发 token 时写入，不需要查询数据库
+		if claims.Language != "" {
+			i18n.SetLanguage(c, claims.Language)
+		}
+
 		// 5. 调用 c.Next() 将请求传递给下一个处理函数
 		c.Next()
 	}
 }
+
+// OptionalJWTAuthMiddleware 是一个可选认证的 Gin 中间件，用于游客和登录用户都可以访问的接口。
+// 请求没有携带 token 时直接放行；携带了 token 时必须有效，验证通过后与 JWTAuthMiddleware 一样将用户信息存入 Context。
+func OptionalJWTAuthMiddleware() gin.HandlerFunc {
+	required := JWTAuthMiddleware()
+	return func(c *gin.Context) {
+		if c.Request.Header.Get("Authorization") == "" {
+			c.Next()
+			return
+		}
+		required(c)
+	}
+}
+
+// RequireAdmin 是一个 Gin 中间件，只允许管理员访问，必须在 JWTAuthMiddleware 之后使用。
+// 评论审核、Webhook 等站点级的接口会返回评论者的邮箱和 IP 等数据，不能交给注册的普通用户。
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, ok := c.Get(CtxUserClaimsKey)
+		if !ok {
+			response.Abort(ErrMissingToken, c)
+			return
+		}
+		if !claims.(*util.MyClaims).Admin {
+			response.Abort(ErrAdminRequired, c)
+			return
+		}
+		c.Next()
+	}
+}
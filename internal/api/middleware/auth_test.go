@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/util"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireAdmin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name      string
+		claims    *util.MyClaims // 为 nil 时不设置登录信息
+		status    int
+		errorCode string
+	}{
+		{"admin", &util.MyClaims{UserID: 1, Admin: true}, http.StatusOK, ""},
+		{"user", &util.MyClaims{UserID: 2}, http.StatusForbidden, "admin_required"},
+		{"anonymous", nil, http.StatusUnauthorized, "unauthenticated"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/admin", func(c *gin.Context) {
+				if tt.claims != nil {
+					c.Set(CtxUserClaimsKey, tt.claims)
+				}
+			}, RequireAdmin(), func(c *gin.Context) {
+				response.Success(nil, c)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin", nil))
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+			var body response.Response
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.ErrorCode != tt.errorCode {
+				t.Errorf("error_code = %q, want %q", body.ErrorCode, tt.errorCode)
+			}
+		})
+	}
+}
+
+// 不包含 admin 字段的旧 token 应当被当作普通用户。
+func TestClaimsWithoutAdminField(t *testing.T) {
+	var claims util.MyClaims
+	if err := json.Unmarshal([]byte(`{"user_id":1,"username":"alice"}`), &claims); err != nil {
+		t.Fatal(err)
+	}
+	if claims.Admin {
+		t.Error("claims without admin field decoded as admin")
+	}
+}
+
+// JWTAuthMiddleware 使用 token 中记录的接口语言，不查询数据库。
+func TestJWTAuthMiddlewareLanguage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	saved := config.Conf
+	config.Conf = &config.Config{}
+	config.Conf.Server.JWTSecret = "test-secret"
+	defer func() { config.Conf = saved }()
+
+	tests := []struct {
+		name     string
+		language string
+		want     string
+	}{
+		{"preferred", "en", "en"},
+		{"unset", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := util.GenerateToken(&model.User{ID: 1, Username: "alice", Role: model.RoleUser, Language: tt.language})
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := gin.New()
+			r.GET("/me", JWTAuthMiddleware(), func(c *gin.Context) {
+				response.Success(nil, c)
+			})
+
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", w.Code)
+			}
+			if got := w.Header().Get("Content-Language"); got != tt.want {
+				t.Errorf("Content-Language = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/KeLes-Coding/gopress/internal/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// LanguageMiddleware 根据 Accept-Language 请求头选择接口消息使用的语言，并存入 Gin 的 Context。
+// 登录用户设置过接口语言时，JWTAuthMiddleware 会用用户的设置覆盖这里的选择。
+func LanguageMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		i18n.SetLanguage(c, i18n.Negotiate(c.GetHeader("Accept-Language")))
+		// 响应的消息随 Accept-Language 变化，缓存需要区分
+		c.Header("Vary", "Accept-Language")
+		c.Next()
+	}
+}
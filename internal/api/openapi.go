@@ -0,0 +1,378 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/KeLes-Coding/gopress/internal/api/handler"
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/apperr"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/openapi"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/gin-gonic/gin"
+)
+
+// openAPIPrefix 是文档覆盖的路由前缀。订阅源、Webmention、XML-RPC、Micropub 等遵循各自协议的接口不在文档中。
+const openAPIPrefix = "/api/v1/"
+
+// postIDParam 是后台列表接口按文章过滤的查询参数。
+var postIDParam = openapi.Param{Name: "post_id", Description: "只返回该文章下的记录", Type: "integer"}
+
+// pageParams 返回列表接口的分页参数以及 extra 中的其他查询参数，pageSize 是每页数量的默认值。
+func pageParams(pageSize int, extra ...openapi.Param) []openapi.Param {
+	return append([]openapi.Param{
+		{Name: "page", Description: "页码", Type: "integer", Default: 1},
+		{Name: "pageSize", Description: "每页数量", Type: "integer", Default: pageSize},
+	}, extra...)
+}
+
+// langParam 返回文章语言的查询参数。
+func langParam(description string) openapi.Param {
+	return openapi.Param{Name: "lang", Description: description + "。例如 en、zh-CN", Type: "string"}
+}
+
+// openAPIDescription 是文档的说明，介绍所有接口共用的响应格式和业务状态码。错误码列表在生成文档时追加。
+const openAPIDescription = `gopress 的 REST 接口。
+
+响应体为统一的 ` + "`{code, message, data}`" + ` 结构，code 与 HTTP 状态码一致：
+
+- ` + "`200`" + ` 成功，data 为接口返回的数据
+- ` + "`400`" + ` 请求参数不合法，参数校验失败时 data 为字段错误列表
+- ` + "`401`" + ` 认证失败：没有携带 token、token 格式不正确或已过期，需要重新登录
+- ` + "`403`" + ` 没有权限
+- ` + "`404`" + ` 记录不存在
+- ` + "`409`" + ` 与现有数据冲突，例如名称重复
+- ` + "`500`" + ` 服务器内部错误
+- ` + "`503`" + ` 服务暂时不可用，可以稍后重试
+
+失败时 error_code 字段是稳定的错误码，应当以它而不是 message 判断错误类型，message 是可以直接展示给用户的错误信息。` +
+	`开启 api_errors.problem_json 配置后，在 Accept 请求头中声明 application/problem+json 的客户端会收到 RFC 7807 格式的错误响应，错误码位于 code 字段。
+
+message 的语言由 Accept-Language 请求头决定，目前支持 zh-CN（默认）和 en，登录用户也可以通过 PUT /api/v1/me/language 设置（语言记录在 token 中，设置后应改用响应中返回的新 token），响应的 Content-Language 头是实际使用的语言；error_code 不随语言变化。
+
+需要认证的接口在请求头中携带登录接口返回的 token：` + "`Authorization: Bearer <token>`" + `。评论审核等站点级的管理接口只允许管理员访问，其他用户调用时返回 403；用户的角色在登录时写入 token，角色变更后需要重新登录。`
+
+// webhookDescription 说明 Webhook 请求的格式和签名的校验方法。
+const webhookDescription = "订阅的事件发生时，向订阅的地址发送 POST 请求，请求体为 JSON：" +
+	"`{\"event\": \"post.published\", \"created_at\": \"...\", \"data\": {...}}`。" +
+	"可以订阅的事件有 post.published、post.updated、post.unpublished、post.deleted 和 comment.created。\n\n" +
+	"请求头 X-Gopress-Event 是事件名称，X-Gopress-Delivery 是投递记录的 ID，X-Gopress-Timestamp 是发送时的 Unix 秒数，" +
+	"X-Gopress-Signature 是签名：以订阅的密钥为 key，对 `{X-Gopress-Timestamp}.{请求体}` 计算 HMAC-SHA256，" +
+	"格式为 `sha256=` 加十六进制编码。接收方应使用常量时间比较校验签名，并拒绝时间戳过旧的请求。\n\n" +
+	"接收方返回 2xx 状态码视为投递成功，其他状态码、重定向和超时都会按指数退避重试，多次失败后标记为投递失败。" +
+	"同一事件可能被投递多次，接收方可以用请求体中的 id 去重，重试和重新投递时 id 不变。"
+
+// openAPISpec 声明了 /api/v1 下的所有路由。新增或修改路由时需要同步修改这里，
+// go test ./internal/api 会检查两者是否一致。
+var openAPISpec = &openapi.Spec{
+	Info: openapi.Info{
+		Title:       "gopress API",
+		Description: openAPIDescription,
+		Version:     "1.0.0",
+	},
+	Tags: []openapi.Tag{
+		{Name: "用户"}, {Name: "文章"}, {Name: "分类"}, {Name: "标签"}, {Name: "评论"},
+		{Name: "媒体库"}, {Name: "Webmention"}, {Name: "垃圾内容"}, {Name: "访问令牌"},
+		{Name: "Webhook", Description: webhookDescription},
+		{Name: "领域事件", Description: "文章、评论等数据变化时产生的事件与数据在同一事务中写入 outbox，" +
+			"提交后投递给缓存失效、Webmention、Webhook 等订阅者，失败的订阅者按指数退避重试，多次失败后标记为投递失败。"},
+		{Name: "后台任务", Description: "通知搜索引擎等耗时的工作保存为数据库中的任务，由后台按队列执行，" +
+			"失败的任务按指数退避重试，多次失败后标记为执行失败 (dead)，可以手动重试。"},
+	},
+	Envelope:  response.Response{},
+	DataField: "data",
+	Problem:   response.Problem{},
+	Routes: []openapi.Route{
+		// --- 公共接口 ---
+		{Method: "POST", Path: "/api/v1/signup", Handler: (*handler.UserHandler).SignUpHandler, Tag: "用户",
+			Summary: "注册", Body: handler.SignUpRequest{}},
+		{Method: "POST", Path: "/api/v1/login", Handler: (*handler.UserHandler).LoginHandler, Tag: "用户",
+			Summary: "登录", Body: handler.LoginRequest{}, Response: handler.LoginResponse{}},
+		{Method: "GET", Path: "/api/v1/posts", Handler: (*handler.PostHandler).ListPostsHandler, Tag: "文章",
+			Summary: "获取文章列表", Query: pageParams(10, langParam("只列出该语言的文章，分类和标签名称也使用该语言")),
+			Response: service.ListResponseDTO{}},
+		{Method: "GET", Path: "/api/v1/posts/:id", Handler: (*handler.PostHandler).GetPostHandler, Tag: "文章",
+			Summary: "获取单篇文章", Description: "Translations 列出文章的其他语言版本。",
+			Query:    []openapi.Param{langParam("获取文章在该语言下的版本，没有该语言的版本时回退到站点默认语言的版本或原文，返回文章的 Lang 是实际的语言")},
+			Response: model.Post{}},
+		{Method: "GET", Path: "/api/v1/posts/:id/meta", Handler: (*handler.PostHandler).GetPostMetaHandler, Tag: "文章",
+			Summary: "获取文章的 SEO 元数据", Description: "返回可以直接输出到 <head> 中的标签和 JSON-LD 结构化数据，供服务端渲染或预渲染使用。",
+			Response: service.PostMetaDTO{}},
+		{Method: "GET", Path: "/api/v1/tags/cloud", Handler: (*handler.TagHandler).TagCloudHandler, Tag: "标签",
+			Summary:  "获取标签云",
+			Query:    []openapi.Param{{Name: "limit", Description: "返回的标签数量，0 表示全部", Type: "integer", Default: 0}},
+			Response: []service.TagCloudItemDTO{}},
+		{Method: "GET", Path: "/api/v1/posts/:id/comments", Handler: (*handler.CommentHandler).ListPostCommentsHandler, Tag: "评论",
+			Summary: "获取文章的评论", Description: "只返回已通过审核的评论，按回复关系组织为树形结构。", Response: service.PostCommentsDTO{}},
+		{Method: "POST", Path: "/api/v1/posts/:id/comments", Handler: (*handler.CommentHandler).CreateCommentHandler, Tag: "评论",
+			Summary: "发表评论", Description: "游客和登录用户都可以发表，携带 token 时以登录用户的身份发表，无需填写昵称和邮箱。form_token 通过 GET /api/v1/form-token 获取。",
+			Auth: openapi.AuthOptional, Body: handler.CreateCommentRequest{}, Response: service.CommentDTO{}},
+		{Method: "GET", Path: "/api/v1/posts/:id/webmentions", Handler: (*handler.WebmentionHandler).ListPostWebmentionsHandler, Tag: "Webmention",
+			Summary: "获取文章收到的 Webmention", Response: service.PostWebmentionsDTO{}},
+		{Method: "GET", Path: "/api/v1/form-token", Handler: (*handler.SpamHandler).FormTokenHandler, Tag: "垃圾内容",
+			Summary: "获取表单令牌", Description: "展示评论或注册表单时获取，提交时通过 form_token 字段带回，用于垃圾内容检测。",
+			Response: service.FormTokenDTO{}},
+
+		// --- 当前用户 ---
+		{Method: "GET", Path: "/api/v1/me", Handler: (*handler.UserHandler).GetMyProfileHandler, Tag: "用户",
+			Summary: "获取当前用户信息", Auth: openapi.AuthRequired, Response: handler.ProfileResponse{}},
+		{Method: "PUT", Path: "/api/v1/me/language", Handler: (*handler.UserHandler).UpdateLanguageHandler, Tag: "用户",
+			Summary: "设置接口语言", Description: "设置后接口消息使用该语言，优先于 Accept-Language 请求头；language 为空表示跟随 Accept-Language。支持 zh-CN 和 en。",
+			Auth: openapi.AuthRequired, Body: handler.UpdateLanguageRequest{}, Response: handler.LanguageResponse{}},
+		{Method: "GET", Path: "/api/v1/me/tokens", Handler: (*handler.TokenHandler).ListTokensHandler, Tag: "访问令牌",
+			Summary: "获取我的访问令牌", Auth: openapi.AuthRequired, Response: []service.AccessTokenDTO{}},
+		{Method: "POST", Path: "/api/v1/me/tokens", Handler: (*handler.TokenHandler).CreateTokenHandler, Tag: "访问令牌",
+			Summary: "创建访问令牌", Description: "令牌供 Micropub 等发布客户端使用，明文只在本次响应中返回。scopes 可选 create、update、delete、media、draft。",
+			Auth: openapi.AuthRequired, Body: handler.CreateTokenRequest{}, Response: service.CreatedAccessTokenDTO{}},
+		{Method: "DELETE", Path: "/api/v1/me/tokens/:id", Handler: (*handler.TokenHandler).RevokeTokenHandler, Tag: "访问令牌",
+			Summary: "撤销访问令牌", Auth: openapi.AuthRequired},
+
+		// --- 后台：分类 ---
+		{Method: "POST", Path: "/api/v1/admin/categories", Handler: (*handler.CategoryHandler).CreateCategoryHandler, Tag: "分类",
+			Summary: "创建分类", Auth: openapi.AuthRequired, Body: handler.CreateCategoryRequest{}, Response: model.Category{}},
+		{Method: "GET", Path: "/api/v1/admin/categories", Handler: (*handler.CategoryHandler).ListCategoriesHandler, Tag: "分类",
+			Summary: "获取分类列表", Auth: openapi.AuthRequired, Response: []model.Category{}},
+		{Method: "PUT", Path: "/api/v1/admin/categories/:id", Handler: (*handler.CategoryHandler).UpdateCategoryHandler, Tag: "分类",
+			Summary: "更新分类", Auth: openapi.AuthRequired, Body: handler.UpdateCategoryRequest{}, Response: model.Category{}},
+		{Method: "DELETE", Path: "/api/v1/admin/categories/:id", Handler: (*handler.CategoryHandler).DeleteCategoryHandler, Tag: "分类",
+			Summary: "删除分类", Auth: openapi.AuthRequired},
+		{Method: "PUT", Path: "/api/v1/admin/categories/:id/translations", Handler: (*handler.CategoryHandler).SetCategoryTranslationsHandler, Tag: "分类",
+			Summary: "设置分类名称的翻译", Description: "替换分类现有的所有翻译，缺少翻译的语言使用原名称。",
+			Auth: openapi.AuthRequired, Body: handler.SetTranslationsRequest{}, Response: model.Category{}},
+
+		// --- 后台：标签 ---
+		{Method: "POST", Path: "/api/v1/admin/tags", Handler: (*handler.TagHandler).CreateTagHandler, Tag: "标签",
+			Summary: "创建标签", Auth: openapi.AuthRequired, Body: handler.CreateTagRequest{}, Response: model.Tag{}},
+		{Method: "GET", Path: "/api/v1/admin/tags", Handler: (*handler.TagHandler).ListTagsHandler, Tag: "标签",
+			Summary: "获取标签列表", Auth: openapi.AuthRequired, Response: []model.Tag{}},
+		{Method: "GET", Path: "/api/v1/admin/tags/usage", Handler: (*handler.TagHandler).ListTagUsageHandler, Tag: "标签",
+			Summary: "获取标签使用统计", Auth: openapi.AuthRequired, Response: []service.TagUsageDTO{}},
+		{Method: "GET", Path: "/api/v1/admin/tags/suggest", Handler: (*handler.TagHandler).SuggestTagsHandler, Tag: "标签",
+			Summary: "标签自动补全", Auth: openapi.AuthRequired,
+			Query: []openapi.Param{
+				{Name: "q", Description: "标签名称前缀", Type: "string", Required: true},
+				{Name: "limit", Description: "返回数量上限，最多 50", Type: "integer", Default: 10},
+			},
+			Response: []model.Tag{}},
+		{Method: "POST", Path: "/api/v1/admin/tags/merge", Handler: (*handler.TagHandler).MergeTagsHandler, Tag: "标签",
+			Summary: "合并标签", Description: "将源标签下的文章转移到目标标签，并删除源标签。",
+			Auth: openapi.AuthRequired, Body: handler.MergeTagsRequest{}, Response: model.Tag{}},
+		{Method: "PUT", Path: "/api/v1/admin/tags/:id", Handler: (*handler.TagHandler).UpdateTagHandler, Tag: "标签",
+			Summary: "更新标签", Auth: openapi.AuthRequired, Body: handler.UpdateTagRequest{}, Response: model.Tag{}},
+		{Method: "DELETE", Path: "/api/v1/admin/tags/:id", Handler: (*handler.TagHandler).DeleteTagHandler, Tag: "标签",
+			Summary: "删除标签", Auth: openapi.AuthRequired},
+		{Method: "PUT", Path: "/api/v1/admin/tags/:id/translations", Handler: (*handler.TagHandler).SetTagTranslationsHandler, Tag: "标签",
+			Summary: "设置标签名称的翻译", Description: "替换标签现有的所有翻译，翻译与标签名称使用相同的规范化规则，缺少翻译的语言使用原名称。",
+			Auth: openapi.AuthRequired, Body: handler.SetTranslationsRequest{}, Response: model.Tag{}},
+
+		// --- 后台：文章 ---
+		{Method: "POST", Path: "/api/v1/admin/posts", Handler: (*handler.PostHandler).CreatePostHandler, Tag: "文章",
+			Summary: "创建文章", Description: "tag_ids 引用已有标签，tag_names 中不存在的标签会被自动创建。传入 translation_of 时新文章作为该文章的翻译版本，同一篇原文的每种语言最多一个版本。",
+			Auth: openapi.AuthRequired, Body: handler.CreatePostRequest{}, Response: model.Post{}},
+		{Method: "PUT", Path: "/api/v1/admin/posts/:id", Handler: (*handler.PostHandler).UpdatePostHandler, Tag: "文章",
+			Summary: "更新文章", Auth: openapi.AuthRequired, Body: handler.UpdatePostRequest{}, Response: model.Post{}},
+		{Method: "DELETE", Path: "/api/v1/admin/posts/:id", Handler: (*handler.PostHandler).DeletePostHandler, Tag: "文章",
+			Summary: "删除文章", Auth: openapi.AuthRequired},
+
+		// --- 后台：媒体库 ---
+		{Method: "POST", Path: "/api/v1/admin/media", Handler: (*handler.MediaHandler).UploadMediaHandler, Tag: "媒体库",
+			Summary: "上传文件", Description: "文件类型以内容嗅探的结果为准，缩略图在后台异步生成。",
+			Auth: openapi.AuthRequired,
+			Form: []openapi.Param{
+				{Name: "file", Description: "要上传的文件", Type: "file", Required: true},
+				{Name: "alt", Description: "替代文本", Type: "string"},
+			},
+			Response: model.Media{}},
+		{Method: "GET", Path: "/api/v1/admin/media", Handler: (*handler.MediaHandler).ListMediaHandler, Tag: "媒体库",
+			Summary: "获取媒体列表", Auth: openapi.AuthRequired,
+			Query: pageParams(20,
+				openapi.Param{Name: "type", Description: "按 MIME 类型前缀过滤，例如 image/", Type: "string"},
+			),
+			Response: service.ListMediaResponseDTO{}},
+		{Method: "POST", Path: "/api/v1/admin/media/presign", Handler: (*handler.MediaHandler).PresignMediaHandler, Tag: "媒体库",
+			Summary: "申请直传地址", Description: "仅在使用对象存储时可用，客户端将文件直接上传到返回的地址后调用完成直传登记接口。上传时的 Content-Type 请求头必须与返回的 content_type 相同。",
+			Auth: openapi.AuthRequired, Body: handler.PresignMediaRequest{}, Response: service.PresignedUploadDTO{}},
+		{Method: "POST", Path: "/api/v1/admin/media/complete", Handler: (*handler.MediaHandler).CompleteMediaHandler, Tag: "媒体库",
+			Summary: "完成直传登记", Auth: openapi.AuthRequired, Body: handler.CompleteMediaRequest{}, Response: model.Media{}},
+		{Method: "PUT", Path: "/api/v1/admin/media/:id", Handler: (*handler.MediaHandler).UpdateMediaHandler, Tag: "媒体库",
+			Summary: "更新替代文本", Description: "只有上传者和管理员可以修改，其他用户返回 403。", Auth: openapi.AuthRequired, Body: handler.UpdateMediaRequest{}, Response: model.Media{}},
+		{Method: "DELETE", Path: "/api/v1/admin/media/:id", Handler: (*handler.MediaHandler).DeleteMediaHandler, Tag: "媒体库",
+			Summary: "删除文件", Description: "只有上传者和管理员可以删除，其他用户返回 403。文件仍被文章引用时返回 code 409，data.posts 为引用它的文章；确认后携带 force=true 强制删除。",
+			Auth:  openapi.AuthRequired,
+			Query: []openapi.Param{{Name: "force", Description: "文件仍被引用时是否强制删除", Type: "boolean", Default: false}}},
+		{Method: "POST", Path: "/api/v1/admin/media/:id/reprocess", Handler: (*handler.MediaHandler).ReprocessMediaHandler, Tag: "媒体库",
+			Summary: "重新生成缩略图", Description: "只有上传者和管理员可以操作，其他用户返回 403。", Auth: openapi.AuthRequired, Response: model.Media{}},
+
+		// --- 后台：评论审核 ---
+		{Method: "GET", Path: "/api/v1/admin/comments", Handler: (*handler.CommentHandler).ListCommentsHandler, Tag: "评论",
+			Summary: "获取评论列表", Auth: openapi.AuthAdmin,
+			Query: pageParams(20, postIDParam,
+				openapi.Param{Name: "status", Description: "按状态过滤", Type: "string", Enum: []string{"pending", "approved", "spam", "trash"}},
+			),
+			Response: service.ListCommentsResponseDTO{}},
+		{Method: "POST", Path: "/api/v1/admin/comments/moderate", Handler: (*handler.CommentHandler).ModerateCommentsHandler, Tag: "评论",
+			Summary: "批量修改评论状态", Description: "标记为 approved 或 spam 时会同时训练垃圾内容分类器。",
+			Auth: openapi.AuthAdmin, Body: handler.ModerateCommentsRequest{}, Response: handler.ModerateResponse{}},
+		{Method: "DELETE", Path: "/api/v1/admin/comments/trash", Handler: (*handler.CommentHandler).EmptyTrashHandler, Tag: "评论",
+			Summary: "清空回收站和垃圾评论", Auth: openapi.AuthAdmin, Response: handler.EmptyTrashResponse{}},
+		{Method: "PUT", Path: "/api/v1/admin/comments/:id/status", Handler: (*handler.CommentHandler).UpdateCommentStatusHandler, Tag: "评论",
+			Summary: "修改评论状态", Auth: openapi.AuthAdmin, Body: handler.UpdateCommentStatusRequest{}},
+		{Method: "DELETE", Path: "/api/v1/admin/comments/:id", Handler: (*handler.CommentHandler).DeleteCommentHandler, Tag: "评论",
+			Summary: "彻底删除评论及其回复", Auth: openapi.AuthAdmin},
+
+		// --- 后台：Webmention ---
+		{Method: "GET", Path: "/api/v1/admin/webmentions", Handler: (*handler.WebmentionHandler).ListWebmentionsHandler, Tag: "Webmention",
+			Summary: "获取收到的通知", Auth: openapi.AuthAdmin,
+			Query: pageParams(20, postIDParam,
+				openapi.Param{Name: "status", Description: "按审核状态过滤", Type: "string", Enum: []string{"pending", "approved", "rejected"}},
+				openapi.Param{Name: "verification", Description: "按校验状态过滤", Type: "string", Enum: []string{"queued", "verified", "failed"}},
+			),
+			Response: service.ListWebmentionsResponseDTO{}},
+		{Method: "POST", Path: "/api/v1/admin/webmentions/moderate", Handler: (*handler.WebmentionHandler).ModerateWebmentionsHandler, Tag: "Webmention",
+			Summary: "批量审核通知", Auth: openapi.AuthAdmin, Body: handler.ModerateWebmentionsRequest{}, Response: handler.ModerateResponse{}},
+		{Method: "GET", Path: "/api/v1/admin/webmentions/outgoing", Handler: (*handler.WebmentionHandler).ListWebmentionSendsHandler, Tag: "Webmention",
+			Summary: "获取发出的通知", Auth: openapi.AuthAdmin,
+			Query: pageParams(20, postIDParam,
+				openapi.Param{Name: "status", Description: "按发送状态过滤", Type: "string", Enum: []string{"pending", "sent", "no_endpoint", "failed"}},
+			),
+			Response: service.ListWebmentionSendsResponseDTO{}},
+		{Method: "POST", Path: "/api/v1/admin/webmentions/outgoing/:id/retry", Handler: (*handler.WebmentionHandler).RetryWebmentionSendHandler, Tag: "Webmention",
+			Summary: "立即重新发送通知", Auth: openapi.AuthAdmin},
+		{Method: "POST", Path: "/api/v1/admin/webmentions/:id/verify", Handler: (*handler.WebmentionHandler).VerifyWebmentionHandler, Tag: "Webmention",
+			Summary: "重新校验来源页面", Auth: openapi.AuthAdmin},
+		{Method: "DELETE", Path: "/api/v1/admin/webmentions/:id", Handler: (*handler.WebmentionHandler).DeleteWebmentionHandler, Tag: "Webmention",
+			Summary: "删除收到的通知", Auth: openapi.AuthAdmin},
+
+		// --- 后台：Webhook ---
+		{Method: "GET", Path: "/api/v1/admin/webhooks", Handler: (*handler.WebhookHandler).ListWebhooksHandler, Tag: "Webhook",
+			Summary: "获取所有订阅", Auth: openapi.AuthAdmin, Response: []service.WebhookDTO{}},
+		{Method: "POST", Path: "/api/v1/admin/webhooks", Handler: (*handler.WebhookHandler).CreateWebhookHandler, Tag: "Webhook",
+			Summary: "创建订阅", Description: "不指定密钥时自动生成，响应中的 secret 只返回这一次。",
+			Auth: openapi.AuthAdmin, Body: handler.SaveWebhookRequest{}, Response: service.WebhookDTO{}},
+		{Method: "PUT", Path: "/api/v1/admin/webhooks/:id", Handler: (*handler.WebhookHandler).UpdateWebhookHandler, Tag: "Webhook",
+			Summary: "修改订阅", Description: "传入 secret 时更换密钥，已创建但尚未投递的请求也会使用新的密钥签名。",
+			Auth: openapi.AuthAdmin, Body: handler.SaveWebhookRequest{}, Response: service.WebhookDTO{}},
+		{Method: "DELETE", Path: "/api/v1/admin/webhooks/:id", Handler: (*handler.WebhookHandler).DeleteWebhookHandler, Tag: "Webhook",
+			Summary: "删除订阅及其投递记录", Auth: openapi.AuthAdmin},
+		{Method: "GET", Path: "/api/v1/admin/webhooks/:id/deliveries", Handler: (*handler.WebhookHandler).ListWebhookDeliveriesHandler, Tag: "Webhook",
+			Summary: "获取订阅的投递记录", Auth: openapi.AuthAdmin,
+			Query: pageParams(20,
+				openapi.Param{Name: "status", Description: "按投递状态过滤", Type: "string", Enum: []string{"pending", "delivered", "failed"}},
+			),
+			Response: service.ListWebhookDeliveriesResponseDTO{}},
+		{Method: "GET", Path: "/api/v1/admin/webhooks/deliveries/:id", Handler: (*handler.WebhookHandler).GetWebhookDeliveryHandler, Tag: "Webhook",
+			Summary: "获取投递详情", Description: "包含请求体和最近一次响应的正文。",
+			Auth: openapi.AuthAdmin, Response: service.WebhookDeliveryDTO{}},
+		{Method: "POST", Path: "/api/v1/admin/webhooks/deliveries/:id/redeliver", Handler: (*handler.WebhookHandler).RedeliverWebhookHandler, Tag: "Webhook",
+			Summary: "重新投递", Description: "使用原来的请求体创建一条新的投递记录，原记录保持不变。",
+			Auth: openapi.AuthAdmin, Response: service.WebhookDeliveryDTO{}},
+
+		// --- 后台：垃圾内容 ---
+		{Method: "GET", Path: "/api/v1/admin/spam/stats", Handler: (*handler.SpamHandler).SpamStatsHandler, Tag: "垃圾内容",
+			Summary: "获取垃圾内容分类器的训练情况", Auth: openapi.AuthAdmin, Response: service.SpamStatsDTO{}},
+
+		// --- 后台：领域事件 ---
+		{Method: "GET", Path: "/api/v1/admin/outbox/stats", Handler: (*handler.OutboxHandler).OutboxStatsHandler, Tag: "领域事件",
+			Summary: "获取 outbox 的积压情况", Description: "pending 和 lag_seconds 反映积压程度，*_total 是进程启动以来的统计。",
+			Auth: openapi.AuthAdmin, Response: service.OutboxStatsDTO{}},
+		{Method: "POST", Path: "/api/v1/admin/outbox/retry", Handler: (*handler.OutboxHandler).RetryOutboxHandler, Tag: "领域事件",
+			Summary: "重新投递失败的事件", Description: "只投递给之前失败的订阅者。",
+			Auth: openapi.AuthAdmin, Response: handler.RetryOutboxResponse{}},
+
+		// --- 后台：后台任务 ---
+		{Method: "GET", Path: "/api/v1/admin/jobs", Handler: (*handler.JobHandler).ListJobsHandler, Tag: "后台任务",
+			Summary: "获取任务列表", Description: "列表中不包含任务参数。", Auth: openapi.AuthAdmin,
+			Query: pageParams(20,
+				openapi.Param{Name: "queue", Description: "按队列过滤", Type: "string"},
+				openapi.Param{Name: "kind", Description: "按任务类型过滤", Type: "string"},
+				openapi.Param{Name: "status", Description: "按任务状态过滤", Type: "string", Enum: []string{"queued", "running", "succeeded", "dead"}},
+			),
+			Response: service.ListJobsResponseDTO{}},
+		{Method: "GET", Path: "/api/v1/admin/jobs/stats", Handler: (*handler.JobHandler).JobStatsHandler, Tag: "后台任务",
+			Summary: "获取各队列的任务数", Auth: openapi.AuthAdmin, Response: service.JobStatsDTO{}},
+		{Method: "GET", Path: "/api/v1/admin/jobs/:id", Handler: (*handler.JobHandler).GetJobHandler, Tag: "后台任务",
+			Summary: "获取任务详情", Description: "包含任务参数。", Auth: openapi.AuthAdmin, Response: service.JobDTO{}},
+		{Method: "POST", Path: "/api/v1/admin/jobs/:id/retry", Handler: (*handler.JobHandler).RetryJobHandler, Tag: "后台任务",
+			Summary: "立即重试任务", Description: "只能重试等待执行或执行失败的任务，执行失败的任务会清零尝试次数。",
+			Auth: openapi.AuthAdmin, Response: service.JobDTO{}},
+	},
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+	openAPIErr  error
+)
+
+// OpenAPIDocument 生成 OpenAPI 文档，业务状态码的取值写入统一响应结构的 code 字段。
+func OpenAPIDocument() (*openapi.Document, error) {
+	doc, err := openAPISpec.Build()
+	if err != nil {
+		return nil, err
+	}
+
+	// 通用的错误码由 handler 和中间件使用，单独说明；其余来自 service 层的错误目录，
+	// 同一错误码的不同消息（Key 与错误码不同）只列出一次
+	errorCodes := []string{
+		"- `" + apperr.CodeInvalidArgument + "` (400) 请求参数不合法，例如路径中的 ID 不是数字",
+		"- `" + apperr.CodeValidationFailed + "` (400) 请求体校验失败，data 为字段错误列表",
+		"- `" + apperr.CodeUnauthenticated + "` (401) 没有携带 token 或 token 无效",
+		"- `" + apperr.CodeInternal + "` (500) 服务器内部错误",
+	}
+	codes := []interface{}{apperr.CodeInvalidArgument, apperr.CodeValidationFailed, apperr.CodeUnauthenticated, apperr.CodeInternal}
+	generic := map[string]bool{apperr.CodeInvalidArgument: true, apperr.CodeValidationFailed: true,
+		apperr.CodeUnauthenticated: true, apperr.CodeInternal: true}
+	for _, e := range apperr.Catalog() {
+		if e.Key != e.Code || generic[e.Code] {
+			continue
+		}
+		errorCodes = append(errorCodes, fmt.Sprintf("- `%s` (%d) %s", e.Code, e.Kind.HTTPStatus(), e.Message))
+		codes = append(codes, e.Code)
+	}
+	doc.Info.Description += "\n\n错误码：\n\n" + strings.Join(errorCodes, "\n")
+
+	envelope := doc.Components.Schemas["Response"]
+	envelope.Required = []string{"code", "message", "data"}
+	envelope.Properties["code"].Enum = []interface{}{
+		response.CodeSuccess, response.CodeBadRequest, response.CodeUnauthorized, response.CodeForbidden,
+		response.CodeNotFound, response.CodeConflict, response.CodeError, response.CodeUnavailable,
+	}
+	envelope.Properties["code"].Description = "业务状态码，与 HTTP 状态码一致"
+	envelope.Properties["message"].Description = "成功时为 success，失败时为错误信息"
+	envelope.Properties["error_code"].Description = "稳定的错误码，仅在失败时返回"
+	envelope.Properties["error_code"].Enum = codes
+	problem := doc.Components.Schemas["Problem"]
+	problem.Required = []string{"type", "title", "status", "code"}
+	problem.Properties["code"].Enum = codes
+	return doc, nil
+}
+
+// OpenAPIJSON 返回 JSON 格式的 OpenAPI 文档。文档只依赖代码中的类型，生成一次后缓存。
+func OpenAPIJSON() ([]byte, error) {
+	openAPIOnce.Do(func() {
+		doc, err := OpenAPIDocument()
+		if err != nil {
+			openAPIErr = err
+			return
+		}
+		openAPIJSON, openAPIErr = json.MarshalIndent(doc, "", "  ")
+	})
+	return openAPIJSON, openAPIErr
+}
+
+// CheckOpenAPI 比较 r 中注册的 /api/v1 路由与文档中的声明，返回所有不一致之处。
+func CheckOpenAPI(r *gin.Engine) []string {
+	var routes []openapi.RouteInfo
+	for _, route := range r.Routes() {
+		routes = append(routes, openapi.RouteInfo{Method: route.Method, Path: route.Path, Handler: route.Handler})
+	}
+	return openAPISpec.Check(routes, openAPIPrefix)
+}
+
+// OpenAPIRoutes 返回文档中声明的所有路由，gRPC 接口用它检查请求字段和认证要求是否与 REST 接口一致。
+func OpenAPIRoutes() []openapi.Route {
+	return openAPISpec.Routes
+}
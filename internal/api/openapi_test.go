@@ -0,0 +1,39 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// TestOpenAPIMatchesRoutes 检查 openAPISpec 中的声明与 RegisterRoutes 注册的 /api/v1 路由是否一致，
+// 新增、删除路由或更换 handler 后没有同步修改声明时失败。
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	// 只注册路由而不处理请求，使用空配置即可；/api/v1 下的路由不受配置开关影响
+	saved := config.Conf
+	config.Conf = &config.Config{}
+	defer func() { config.Conf = saved }()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	RegisterRoutes(r)
+
+	for _, p := range CheckOpenAPI(r) {
+		t.Error(p)
+	}
+}
+
+// TestOpenAPIDocument 检查文档可以生成，例如 operationId 没有重复。
+func TestOpenAPIDocument(t *testing.T) {
+	doc, err := OpenAPIDocument()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Paths) == 0 {
+		t.Error("document has no paths")
+	}
+	if _, err := OpenAPIJSON(); err != nil {
+		t.Fatal(err)
+	}
+}
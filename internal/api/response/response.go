@@ -2,32 +2,49 @@ package response
 
 import (
 	"net/http"
+	"strings"
 
+	"github.com/KeLes-Coding/gopress/internal/apperr"
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/i18n"
+	"github.com/KeLes-Coding/gopress/internal/logger"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
 // Response 是返回给前端的标准化 JSON 结构体。
 // 使用 `json:"..."` 标签来定义在序列化为 JSON 时每个字段的键名。
 type Response struct {
-	Code    int         `json:"code"`    // 业务状态码
-	Message string      `json:"message"` // 响应消息
-	Data    interface{} `json:"data"`    // 响应数据
+	Code      int         `json:"code"`                 // 业务状态码，与 HTTP 状态码一致
+	Message   string      `json:"message"`              // 响应消息
+	Data      interface{} `json:"data"`                 // 响应数据
+	ErrorCode string      `json:"error_code,omitempty"` // 稳定的错误码，仅在失败时返回，例如 post_not_found
 }
 
-// 定义常用业务状态吗
+// 定义常用业务状态码。失败时的业务状态码与 HTTP 状态码相同。
 const (
 	CodeSuccess      = 200 // 成功
-	CodeError        = 500 // 通用错误
+	CodeBadRequest   = 400 // 请求参数不合法
 	CodeUnauthorized = 401 // 认证失败
+	CodeForbidden    = 403 // 没有权限
+	CodeNotFound     = 404 // 资源不存在
+	CodeConflict     = 409 // 与现有数据冲突
+	CodeError        = 500 // 服务器内部错误
+	CodeUnavailable  = 503 // 服务暂时不可用
 )
 
 // result 是一个内部辅助函数，用于构造并发送 JSON 响应。
-// 它接受业务状态码、消息、数据以及 Gin 上下文作为参数。
-func result(code int, msg string, data interface{}, c *gin.Context) {
-	c.JSON(http.StatusOK, Response{
-		Code:    code,
-		Message: msg,
-		Data:    data,
+// 它接受业务状态码、消息、数据以及 Gin 上下文作为参数，HTTP 状态码与业务状态码一致。
+func result(code int, errorCode, msg string, data interface{}, c *gin.Context) {
+	if code != CodeSuccess && wantsProblem(c) {
+		writeProblem(code, errorCode, msg, data, c)
+		return
+	}
+	c.JSON(code, Response{
+		Code:      code,
+		Message:   msg,
+		Data:      data,
+		ErrorCode: errorCode,
 	})
 }
 
@@ -35,23 +52,74 @@ func result(code int, msg string, data interface{}, c *gin.Context) {
 // 它封装了成功的状态码和默认消息。
 // data 参数是需要返回给客户端的具体数据。
 func Success(data interface{}, c *gin.Context) {
-	result(CodeSuccess, "success", data, c)
+	result(CodeSuccess, "", "success", data, c)
 }
 
-// Error 函数用于返回一个表示失败的响应。
-// 它封装了失败的状态码和错误消息。
-func Error(msg string, c *gin.Context) {
-	result(CodeError, msg, nil, c)
+// Abort 与 Fail 相同，但会中止后续的处理函数，用于中间件中的认证失败等情况。
+func Abort(err error, c *gin.Context) {
+	Fail(err, c)
+	c.Abort()
 }
 
-// ErrorWithData 函数用于返回一个表示失败的响应，但可以携带一些额外数据。
-// 例如，在表单验证失败时，可以用 data 字段返回具体的字段错误信息。
-func ErrorWithData(msg string, data interface{}, c *gin.Context) {
-	result(CodeError, msg, data, c)
+// Fail 函数用于将业务逻辑返回的错误写入响应。
+// 错误链中包含 *apperr.Error 时，按其分类返回对应的状态码和错误码，消息翻译为当前请求的语言；
+// 否则按服务器内部错误处理，错误只记录在日志中，客户端收到的是统一的提示。
+func Fail(err error, c *gin.Context) {
+	FailWithData(err, nil, c)
 }
 
-// Unauthorized 函数用于返回一个表示认证失败的响应。
-// 我们遵循项目现有的约定，HTTP 状态码依然返回 200，由前端根据 JSON 中的 code 字段来处理逻辑。
-func Unauthorized(msg string, c *gin.Context) {
-	result(CodeUnauthorized, msg, nil, c)
+// FailWithData 与 Fail 相同，但可以携带一些额外数据，例如删除被引用的文件失败时返回引用它的文章。
+func FailWithData(err error, data interface{}, c *gin.Context) {
+	e, ok := apperr.From(err)
+	if !ok {
+		logger.L.Error("Request failed",
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path),
+			zap.Error(err),
+		)
+		result(CodeError, apperr.CodeInternal, i18n.Message(i18n.FromContext(c), apperr.ErrInternal), data, c)
+		return
+	}
+	result(e.Kind.HTTPStatus(), e.Code, i18n.Message(i18n.FromContext(c), e), data, c)
+}
+
+// ProblemContentType 是 RFC 7807 错误响应的内容类型。
+const ProblemContentType = "application/problem+json"
+
+// Problem 是 RFC 7807 格式的错误响应。除标准字段外，code 是稳定的错误码，
+// errors 和 data 分别对应标准响应中参数校验失败的字段列表和额外数据。
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Code     string       `json:"code"`
+	Errors   []FieldError `json:"errors,omitempty"`
+	Data     interface{}  `json:"data,omitempty"`
+}
+
+// wantsProblem 判断是否应当以 RFC 7807 格式返回错误：需要在配置中开启，并且客户端在 Accept 请求头中声明接受。
+func wantsProblem(c *gin.Context) bool {
+	return config.Conf.APIErrors.ProblemJSON &&
+		strings.Contains(c.GetHeader("Accept"), ProblemContentType)
+}
+
+// writeProblem 以 RFC 7807 格式写入错误响应。错误码没有对应的说明文档，因此 type 使用 about:blank，title 为状态码的标准描述。
+func writeProblem(status int, errorCode, msg string, data interface{}, c *gin.Context) {
+	p := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   msg,
+		Instance: c.Request.URL.Path,
+		Code:     errorCode,
+		Data:     data,
+	}
+	if fields, ok := data.([]FieldError); ok {
+		p.Errors, p.Data = fields, nil
+	}
+	// gin 只在响应没有 Content-Type 时设置 JSON 的内容类型，因此这里预先设置的值会被保留
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(status, p)
 }
@@ -0,0 +1,168 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/KeLes-Coding/gopress/internal/apperr"
+	"github.com/KeLes-Coding/gopress/internal/i18n"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	zh_translations "github.com/go-playground/validator/v10/translations/zh"
+	"go.uber.org/zap"
+)
+
+// FieldError 描述一个字段的校验错误，参数校验失败时以列表的形式放在响应的 data 字段中。
+type FieldError struct {
+	Field   string `json:"field"`           // 字段在请求体中的名称，例如 title、tags[0]
+	Rule    string `json:"rule"`            // 未通过的校验规则，例如 required、max；请求体格式错误时为 type 或 syntax
+	Param   string `json:"param,omitempty"` // 校验规则的参数，例如 max=255 中的 255
+	Message string `json:"message"`         // 展示给用户的错误消息
+}
+
+// 绑定请求体失败时使用的错误，与校验规则无关，因此由 i18n 的消息目录翻译。
+var (
+	errInvalidJSON = apperr.ErrValidationFailed.Variant("validation_failed.syntax", "请求体不是有效的 JSON")
+	errFieldType   = apperr.ErrValidationFailed.Variant("validation_failed.type", "%s 的类型不正确，应为 %s")
+)
+
+// translators 是校验规则消息的翻译器，按 i18n 的语言标签索引。校验规则的消息由 validator 自带的翻译提供。
+var translators = make(map[string]ut.Translator)
+
+// SetupValidator 让 binding 的校验错误使用 json 标签中的字段名，而不是 Go 结构体的字段名，
+// 并注册各语言的校验规则消息。需要在处理请求之前调用，REST 和 gRPC 接口共用同一个校验器，重复调用只有第一次生效。
+func SetupValidator() {
+	setupValidatorOnce.Do(setupValidator)
+}
+
+// setupValidatorOnce 保证校验器只初始化一次。
+var setupValidatorOnce sync.Once
+
+// setupValidator 初始化校验器，由 SetupValidator 调用。
+func setupValidator() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	zhLocale, enLocale := zh.New(), en.New()
+	uni := ut.New(zhLocale, zhLocale, enLocale)
+	registers := map[string]func(*validator.Validate, ut.Translator) error{
+		"zh-CN": zh_translations.RegisterDefaultTranslations,
+		"en":    en_translations.RegisterDefaultTranslations,
+	}
+	locales := map[string]string{"zh-CN": zhLocale.Locale(), "en": enLocale.Locale()}
+	for lang, register := range registers {
+		trans, _ := uni.GetTranslator(locales[lang])
+		if err := register(v, trans); err != nil {
+			logger.L.Error("Failed to register validation translations", zap.String("language", lang), zap.Error(err))
+			continue
+		}
+		translators[lang] = trans
+	}
+}
+
+// translator 按 i18n 的回退链返回 lang 语言的校验规则翻译器，没有可用的翻译器时返回 nil。
+func translator(lang string) ut.Translator {
+	for _, l := range i18n.Fallbacks(lang) {
+		if trans, ok := translators[l]; ok {
+			return trans
+		}
+	}
+	return nil
+}
+
+// ValidationError 函数用于返回一个表示参数校验失败的响应，err 是 ShouldBindJSON 等方法返回的错误。
+// 每个字段的错误会以 []FieldError 的形式放在 data 字段中，message 中也会列出它们，方便直接展示。
+// 消息使用当前请求的语言。
+func ValidationError(err error, c *gin.Context) {
+	msg, fields := ValidationDetails(err, i18n.FromContext(c))
+	result(CodeBadRequest, apperr.CodeValidationFailed, msg, fields, c)
+}
+
+// ValidationDetails 返回参数校验失败时的消息和字段错误列表，消息使用 lang 语言。
+// 供 GraphQL 等不使用标准响应格式的接口复用与 ValidationError 相同的消息。
+func ValidationDetails(err error, lang string) (string, []FieldError) {
+	fields := fieldErrors(err, lang)
+	msg := i18n.Message(lang, apperr.ErrValidationFailed)
+	if len(fields) > 0 {
+		messages := make([]string, len(fields))
+		for i, f := range fields {
+			messages[i] = f.Message
+		}
+		msg += ": " + strings.Join(messages, "; ")
+	} else {
+		msg += ": " + err.Error()
+	}
+	return msg, fields
+}
+
+// fieldErrors 将绑定请求参数时的错误转换为字段错误列表，无法对应到具体字段的错误返回 nil。
+func fieldErrors(err error, lang string) []FieldError {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &validationErrs):
+		trans := translator(lang)
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			msg := fe.Error()
+			if trans != nil {
+				msg = fe.Translate(trans)
+			}
+			fields = append(fields, FieldError{
+				Field:   fe.Field(),
+				Rule:    fe.Tag(),
+				Param:   fe.Param(),
+				Message: msg,
+			})
+		}
+		return fields
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			field = "(root)"
+		}
+		return []FieldError{{Field: field, Rule: "type", Param: typeErr.Type.String(),
+			Message: i18n.Message(lang, errFieldType.WithArgs(field, jsonTypeName(typeErr.Type)))}}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return []FieldError{{Field: "(root)", Rule: "syntax", Message: i18n.Message(lang, errInvalidJSON)}}
+	}
+	return nil
+}
+
+// jsonTypeName 返回 Go 类型在 JSON 中对应的类型名称，它们是 JSON 的术语，不需要翻译。
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}
@@ -2,22 +2,119 @@
 package api
 
 import (
+	"strings"
+
 	"github.com/KeLes-Coding/gopress/internal/api/handler"
 	"github.com/KeLes-Coding/gopress/internal/api/middleware"
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/config"
 	"github.com/gin-gonic/gin"
 )
 
 // RegisterRoutes 函数用于注册项目的所有 API 路由。
 func RegisterRoutes(r *gin.Engine) {
+	// 参数校验失败时，响应中的字段名与请求体中的一致
+	response.SetupValidator()
+
 	// 创建一个 API v1 版本的路由分组。
 	// 在 URL 路径中保留 /v1 是 RESTful API 的标准实践，这对于 API 版本管理很有好处。
 	apiV1Group := r.Group("/api/v1")
+	// 根据 Accept-Language 选择接口消息的语言
+	apiV1Group.Use(middleware.LanguageMiddleware())
 
 	// 实例化各个 handler
 	userHandler := handler.NewUserHandler() // <--- 修改实例化方式
 	categoryHandler := handler.NewCategoryHandler()
 	tagHandler := handler.NewTagHandler()
 	postHandler := handler.NewPostHandler()
+	mediaHandler := handler.NewMediaHandler()
+	feedHandler := handler.NewFeedHandler()
+	sitemapHandler := handler.NewSitemapHandler()
+	commentHandler := handler.NewCommentHandler()
+	spamHandler := handler.NewSpamHandler()
+	webmentionHandler := handler.NewWebmentionHandler()
+	tokenHandler := handler.NewTokenHandler()
+	webhookHandler := handler.NewWebhookHandler()
+	outboxHandler := handler.NewOutboxHandler()
+	jobHandler := handler.NewJobHandler()
+
+	// 媒体文件的公开访问路由，不属于 /api/v1 分组
+	// GET /uploads/ab/cd/abcd....jpg
+	mediaPrefix := strings.TrimRight(config.Conf.Media.URLPrefix, "/")
+	if mediaPrefix == "" {
+		mediaPrefix = "/uploads"
+	}
+	r.GET(mediaPrefix+"/*filepath", mediaHandler.ServeMediaHandler)
+	r.HEAD(mediaPrefix+"/*filepath", mediaHandler.ServeMediaHandler)
+
+	// 订阅源，不属于 /api/v1 分组，支持 ?mode=full|summary
+	// GET /feed.xml (RSS 2.0), /atom.xml (Atom 1.0), /feed.json (JSON Feed 1.1)
+	// 分类、标签和作者的订阅源: /category/:category_id/feed.xml, /tag/:tag_id/atom.xml, /author/:user_id/feed.json 等
+	for _, prefix := range []string{"", "/category/:category_id", "/tag/:tag_id", "/author/:user_id"} {
+		r.GET(prefix+"/feed.xml", feedHandler.RSSHandler)
+		r.GET(prefix+"/atom.xml", feedHandler.AtomHandler)
+		r.GET(prefix+"/feed.json", feedHandler.JSONFeedHandler)
+	}
+
+	// 站点地图和 robots.txt，不属于 /api/v1 分组
+	// GET /sitemap.xml, /sitemaps/sitemap-1.xml (地址超过单个文件上限时的分片), /robots.txt
+	r.GET("/sitemap.xml", sitemapHandler.SitemapHandler)
+	r.GET("/sitemaps/:file", sitemapHandler.SitemapChunkHandler)
+	r.GET("/robots.txt", sitemapHandler.RobotsHandler)
+
+	// Webmention 接收地址，不属于 /api/v1 分组，按照协议使用表单格式的请求体和 HTTP 状态码
+	// POST /webmention (source=...&target=...)
+	if config.Conf.Webmention.Enabled {
+		r.POST("/webmention", webmentionHandler.ReceiveWebmentionHandler)
+	}
+
+	// MetaWeblog / Blogger XML-RPC 接口，供桌面博客客户端使用，不属于 /api/v1 分组
+	// POST /xmlrpc，每次调用都在参数中携带用户名和密码
+	if config.Conf.XMLRPC.Enabled {
+		xmlrpcHandler := handler.NewXMLRPCHandler()
+		r.POST("/xmlrpc", xmlrpcHandler.XMLRPCHandler)
+	}
+
+	// Micropub 接口，供 Micropub 客户端发布笔记和文章，不属于 /api/v1 分组
+	// 按照协议使用 HTTP 状态码响应，使用个人访问令牌 (Authorization: Bearer gp_...) 认证
+	// GET /micropub?q=config|source|syndicate-to|category, POST /micropub, POST /micropub/media
+	if config.Conf.Micropub.Enabled {
+		micropubHandler := handler.NewMicropubHandler()
+		r.GET("/micropub", micropubHandler.MicropubQueryHandler)
+		r.POST("/micropub", micropubHandler.MicropubHandler)
+		r.POST("/micropub/media", micropubHandler.MicropubMediaHandler)
+	}
+
+	// GraphQL 接口，不属于 /api/v1 分组，覆盖文章、分类、标签、用户和评论
+	// GET /graphql?query=... 只能执行查询，POST /graphql 可以执行查询和修改；修改操作（发表评论除外）需要登录
+	if config.Conf.GraphQL.Enabled {
+		graphQLHandler := handler.NewGraphQLHandler()
+		graphQLGroup := r.Group("/graphql", middleware.LanguageMiddleware(), middleware.OptionalJWTAuthMiddleware())
+		graphQLGroup.GET("", graphQLHandler.GraphQLHandler)
+		graphQLGroup.POST("", graphQLHandler.GraphQLHandler)
+	}
+
+	// OpenAPI 文档和交互式文档页面，文档覆盖 /api/v1 下的所有接口
+	// GET /api/openapi.json, /api/docs
+	if config.Conf.APIDocs.Enabled {
+		docsHandler := handler.NewDocsHandler(OpenAPIJSON)
+		r.GET("/api/openapi.json", docsHandler.OpenAPIHandler)
+		r.GET("/api/docs", docsHandler.DocsPageHandler)
+	}
+
+	// 服务端渲染的公开站点，启用 theme.enabled 后注册，页面由当前主题渲染
+	// GET /, /posts/:id, /categories/:id, /tags/:id, /archive, 主题静态文件 /theme/*filepath
+	if config.Conf.Theme.Enabled {
+		siteHandler := handler.NewSiteHandler()
+		r.GET("/", siteHandler.HomeHandler)
+		r.GET("/posts/:id", siteHandler.PostHandler)
+		r.GET("/categories/:id", siteHandler.CategoryHandler)
+		r.GET("/tags/:id", siteHandler.TagHandler)
+		r.GET("/archive", siteHandler.ArchiveHandler)
+		r.GET("/theme/*filepath", siteHandler.AssetHandler)
+		// 其他未匹配的地址渲染主题的 404 页面
+		r.NoRoute(siteHandler.NotFoundHandler)
+	}
 
 	// 公共路由组（无需认证）
 	{
@@ -31,6 +128,20 @@ func RegisterRoutes(r *gin.Engine) {
 		apiV1Group.GET("/posts", postHandler.ListPostsHandler)
 		// 获取单篇文章: GET /api/v1/posts/:id
 		apiV1Group.GET("/posts/:id", postHandler.GetPostHandler)
+		// 获取文章的 SEO 元数据: GET /api/v1/posts/:id/meta
+		apiV1Group.GET("/posts/:id/meta", postHandler.GetPostMetaHandler)
+		// 获取标签云: GET /api/v1/tags/cloud
+		apiV1Group.GET("/tags/cloud", tagHandler.TagCloudHandler)
+		// 获取文章的评论（树形结构）: GET /api/v1/posts/:id/comments
+		apiV1Group.GET("/posts/:id/comments", commentHandler.ListPostCommentsHandler)
+		// 发表评论: POST /api/v1/posts/:id/comments
+		// 游客和登录用户都可以发表，携带 token 时以登录用户的身份发表
+		apiV1Group.POST("/posts/:id/comments", middleware.OptionalJWTAuthMiddleware(), commentHandler.CreateCommentHandler)
+		// 获取文章收到的 Webmention: GET /api/v1/posts/:id/webmentions
+		apiV1Group.GET("/posts/:id/webmentions", webmentionHandler.ListPostWebmentionsHandler)
+		// 获取表单令牌: GET /api/v1/form-token
+		// 发表评论和注册时通过 form_token 字段带回，用于垃圾内容检测
+		apiV1Group.GET("/form-token", spamHandler.FormTokenHandler)
 	}
 
 	// 认证路由组（需要 JWT 认证）
@@ -39,6 +150,16 @@ func RegisterRoutes(r *gin.Engine) {
 	{
 		// 注册获取当前用户信息的接口
 		authGroup.GET("/me", userHandler.GetMyProfileHandler)
+		// 设置接口消息的语言: PUT /api/v1/me/language
+		authGroup.PUT("/me/language", userHandler.UpdateLanguageHandler)
+
+		// 个人访问令牌，供 Micropub 等发布客户端使用
+		tokenGroup := authGroup.Group("/me/tokens")
+		{
+			tokenGroup.GET("", tokenHandler.ListTokensHandler)         // 获取我的令牌: GET /api/v1/me/tokens
+			tokenGroup.POST("", tokenHandler.CreateTokenHandler)       // 创建令牌（明文只返回一次）: POST /api/v1/me/tokens
+			tokenGroup.DELETE("/:id", tokenHandler.RevokeTokenHandler) // 撤销令牌: DELETE /api/v1/me/tokens/:id
+		}
 
 		// 为后台管理接口创建一个专门的路由组 /admin
 		adminGroup := authGroup.Group("/admin")
@@ -50,15 +171,22 @@ func RegisterRoutes(r *gin.Engine) {
 				categoryGroup.GET("", categoryHandler.ListCategoriesHandler)        // 获取分类列表: GET /api/v1/admin/categories
 				categoryGroup.PUT("/:id", categoryHandler.UpdateCategoryHandler)    // 更新分类: PUT /api/v1/admin/categories/:id
 				categoryGroup.DELETE("/:id", categoryHandler.DeleteCategoryHandler) // 删除分类: DELETE /api/v1/admin/categories/:id
+				// 设置分类名称的翻译: PUT /api/v1/admin/categories/:id/translations
+				categoryGroup.PUT("/:id/translations", categoryHandler.SetCategoryTranslationsHandler)
 			}
 
 			// 标签 (Tag) 相关路由
 			tagGroup := adminGroup.Group("/tags")
 			{
-				tagGroup.POST("", tagHandler.CreateTagHandler)       // 创建标签: POST /api/v1/admin/tags
-				tagGroup.GET("", tagHandler.ListTagsHandler)         // 获取标签列表: GET /api/v1/admin/tags
-				tagGroup.PUT("/:id", tagHandler.UpdateTagHandler)    // 更新标签: PUT /api/v1/admin/tags/:id
-				tagGroup.DELETE("/:id", tagHandler.DeleteTagHandler) // 删除标签: DELETE /api/v1/admin/tags/:id
+				tagGroup.POST("", tagHandler.CreateTagHandler)          // 创建标签: POST /api/v1/admin/tags
+				tagGroup.GET("", tagHandler.ListTagsHandler)            // 获取标签列表: GET /api/v1/admin/tags
+				tagGroup.GET("/usage", tagHandler.ListTagUsageHandler)  // 获取标签使用统计: GET /api/v1/admin/tags/usage
+				tagGroup.GET("/suggest", tagHandler.SuggestTagsHandler) // 标签自动补全: GET /api/v1/admin/tags/suggest?q=go
+				tagGroup.POST("/merge", tagHandler.MergeTagsHandler)    // 合并标签: POST /api/v1/admin/tags/merge
+				tagGroup.PUT("/:id", tagHandler.UpdateTagHandler)       // 更新标签: PUT /api/v1/admin/tags/:id
+				tagGroup.DELETE("/:id", tagHandler.DeleteTagHandler)    // 删除标签: DELETE /api/v1/admin/tags/:id
+				// 设置标签名称的翻译: PUT /api/v1/admin/tags/:id/translations
+				tagGroup.PUT("/:id/translations", tagHandler.SetTagTranslationsHandler)
 			}
 
 			// 文章 (Post) 相关路由
@@ -68,6 +196,73 @@ func RegisterRoutes(r *gin.Engine) {
 				postGroup.PUT("/:id", postHandler.UpdatePostHandler)    // 更新文章: PUT /api/v1/admin/posts/:id
 				postGroup.DELETE("/:id", postHandler.DeletePostHandler) // 删除文章: DELETE /api/v1/admin/posts/:id
 			}
+
+			// 媒体库 (Media) 相关路由
+			mediaGroup := adminGroup.Group("/media")
+			{
+				mediaGroup.POST("", mediaHandler.UploadMediaHandler)                  // 上传文件: POST /api/v1/admin/media
+				mediaGroup.GET("", mediaHandler.ListMediaHandler)                     // 获取媒体列表: GET /api/v1/admin/media
+				mediaGroup.POST("/presign", mediaHandler.PresignMediaHandler)         // 申请直传地址: POST /api/v1/admin/media/presign
+				mediaGroup.POST("/complete", mediaHandler.CompleteMediaHandler)       // 完成直传登记: POST /api/v1/admin/media/complete
+				mediaGroup.PUT("/:id", mediaHandler.UpdateMediaHandler)               // 更新替代文本: PUT /api/v1/admin/media/:id
+				mediaGroup.DELETE("/:id", mediaHandler.DeleteMediaHandler)            // 删除文件: DELETE /api/v1/admin/media/:id?force=true
+				mediaGroup.POST("/:id/reprocess", mediaHandler.ReprocessMediaHandler) // 重新生成缩略图: POST /api/v1/admin/media/:id/reprocess
+			}
+
+			// 站点级的管理接口只允许管理员访问，其余后台接口登录用户都可以使用
+			siteGroup := adminGroup.Group("", middleware.RequireAdmin())
+			{
+				// 评论审核 (Comment) 相关路由
+				commentGroup := siteGroup.Group("/comments")
+				{
+					commentGroup.GET("", commentHandler.ListCommentsHandler)                   // 获取评论列表: GET /api/v1/admin/comments?status=pending
+					commentGroup.POST("/moderate", commentHandler.ModerateCommentsHandler)     // 批量修改状态: POST /api/v1/admin/comments/moderate
+					commentGroup.DELETE("/trash", commentHandler.EmptyTrashHandler)            // 清空回收站和垃圾评论: DELETE /api/v1/admin/comments/trash
+					commentGroup.PUT("/:id/status", commentHandler.UpdateCommentStatusHandler) // 修改评论状态: PUT /api/v1/admin/comments/:id/status
+					commentGroup.DELETE("/:id", commentHandler.DeleteCommentHandler)           // 彻底删除评论及其回复: DELETE /api/v1/admin/comments/:id
+				}
+
+				// Webmention 相关路由
+				webmentionGroup := siteGroup.Group("/webmentions")
+				{
+					webmentionGroup.GET("", webmentionHandler.ListWebmentionsHandler)                         // 获取收到的通知: GET /api/v1/admin/webmentions?status=pending
+					webmentionGroup.POST("/moderate", webmentionHandler.ModerateWebmentionsHandler)           // 批量审核: POST /api/v1/admin/webmentions/moderate
+					webmentionGroup.GET("/outgoing", webmentionHandler.ListWebmentionSendsHandler)            // 获取发出的通知: GET /api/v1/admin/webmentions/outgoing?status=failed
+					webmentionGroup.POST("/outgoing/:id/retry", webmentionHandler.RetryWebmentionSendHandler) // 立即重新发送: POST /api/v1/admin/webmentions/outgoing/:id/retry
+					webmentionGroup.POST("/:id/verify", webmentionHandler.VerifyWebmentionHandler)            // 重新校验来源页面: POST /api/v1/admin/webmentions/:id/verify
+					webmentionGroup.DELETE("/:id", webmentionHandler.DeleteWebmentionHandler)                 // 删除收到的通知: DELETE /api/v1/admin/webmentions/:id
+				}
+
+				// 垃圾内容检测的训练情况: GET /api/v1/admin/spam/stats
+				// 审核评论时标记为垃圾或通过会自动训练分类器
+				siteGroup.GET("/spam/stats", spamHandler.SpamStatsHandler)
+
+				// Webhook 相关路由
+				webhookGroup := siteGroup.Group("/webhooks")
+				{
+					webhookGroup.GET("", webhookHandler.ListWebhooksHandler)                               // 获取所有订阅: GET /api/v1/admin/webhooks
+					webhookGroup.POST("", webhookHandler.CreateWebhookHandler)                             // 创建订阅（密钥只返回一次）: POST /api/v1/admin/webhooks
+					webhookGroup.PUT("/:id", webhookHandler.UpdateWebhookHandler)                          // 修改订阅: PUT /api/v1/admin/webhooks/:id
+					webhookGroup.DELETE("/:id", webhookHandler.DeleteWebhookHandler)                       // 删除订阅及其投递记录: DELETE /api/v1/admin/webhooks/:id
+					webhookGroup.GET("/:id/deliveries", webhookHandler.ListWebhookDeliveriesHandler)       // 获取投递记录: GET /api/v1/admin/webhooks/:id/deliveries?status=failed
+					webhookGroup.GET("/deliveries/:id", webhookHandler.GetWebhookDeliveryHandler)          // 获取投递详情: GET /api/v1/admin/webhooks/deliveries/:id
+					webhookGroup.POST("/deliveries/:id/redeliver", webhookHandler.RedeliverWebhookHandler) // 重新投递: POST /api/v1/admin/webhooks/deliveries/:id/redeliver
+				}
+
+				// 领域事件 outbox 的积压情况: GET /api/v1/admin/outbox/stats
+				// 重新投递所有失败的事件: POST /api/v1/admin/outbox/retry
+				siteGroup.GET("/outbox/stats", outboxHandler.OutboxStatsHandler)
+				siteGroup.POST("/outbox/retry", outboxHandler.RetryOutboxHandler)
+
+				// 后台任务
+				jobGroup := siteGroup.Group("/jobs")
+				{
+					jobGroup.GET("", jobHandler.ListJobsHandler)            // 获取任务列表: GET /api/v1/admin/jobs
+					jobGroup.GET("/stats", jobHandler.JobStatsHandler)      // 获取各队列的任务数: GET /api/v1/admin/jobs/stats
+					jobGroup.GET("/:id", jobHandler.GetJobHandler)          // 获取任务详情: GET /api/v1/admin/jobs/:id
+					jobGroup.POST("/:id/retry", jobHandler.RetryJobHandler) // 立即重试任务: POST /api/v1/admin/jobs/:id/retry
+				}
+			}
 		}
 	}
 }
@@ -0,0 +1,170 @@
+// package apperr 定义了业务错误的分类，以及分类到 HTTP 状态码的统一映射。
+//
+// service 层返回 *Error 表示调用方可以理解并处理的错误，例如资源不存在、名称冲突或参数不合法，
+// 每个错误都带有一个稳定的、机器可读的错误码（例如 post_not_found），客户端应以错误码而不是错误消息判断错误类型。
+// 其他错误（数据库连接失败等）都按服务器内部错误处理，错误消息不会返回给客户端。
+//
+// 错误的消息以简体中文写在源码中，同时通过 Key 在 i18n 包的消息目录中查找其他语言的翻译。
+// 同一个错误码可以有多条消息（例如 invalid_argument 下的"无效的文章 ID"和"无效的分类 ID"），它们的 Key 不同。
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Kind 是错误的分类，决定了返回给客户端的 HTTP 状态码。
+type Kind int
+
+const (
+	KindInternal        Kind = iota // 服务器内部错误
+	KindInvalid                     // 请求参数不合法
+	KindUnauthenticated             // 未登录或认证失败
+	KindForbidden                   // 已登录但没有权限
+	KindNotFound                    // 资源不存在
+	KindConflict                    // 与现有数据冲突，例如名称重复
+	KindUnavailable                 // 服务暂时不可用，稍后可以重试
+)
+
+// HTTPStatus 返回该分类对应的 HTTP 状态码。
+func (k Kind) HTTPStatus() int {
+	switch k {
+	case KindInvalid:
+		return http.StatusBadRequest
+	case KindUnauthenticated:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindUnavailable:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
+
+// 通用的错误码，用于没有更具体错误码的情况。
+const (
+	CodeInternal         = "internal_error"
+	CodeInvalidArgument  = "invalid_argument"
+	CodeValidationFailed = "validation_failed"
+	CodeUnauthenticated  = "unauthenticated"
+)
+
+// 通用的错误，handler 通过 Variant 为它们定义更具体的消息。
+var (
+	ErrInternal         = New(KindInternal, CodeInternal, "服务器内部错误")
+	ErrInvalidArgument  = Invalid(CodeInvalidArgument, "请求参数不合法")
+	ErrValidationFailed = Invalid(CodeValidationFailed, "参数校验失败")
+	ErrUnauthenticated  = Unauthenticated(CodeUnauthenticated, "认证失败")
+)
+
+// Error 是一个业务错误。
+type Error struct {
+	Kind    Kind
+	Code    string        // 稳定的错误码，由小写字母和下划线组成
+	Key     string        // 消息在消息目录中的键，与错误码相同或以错误码加后缀组成，例如 invalid_argument.post_id
+	Message string        // 简体中文的消息，可以包含 fmt 格式的占位符，由 Args 填充
+	Args    []interface{} // 消息中占位符的值
+}
+
+// Error 实现了 error 接口，返回简体中文的消息。
+func (e *Error) Error() string {
+	if len(e.Args) == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf(e.Message, e.Args...)
+}
+
+// Is 使 errors.Is 按错误码比较，因此通过 Variant 和 WithArgs 得到的错误仍然与原错误相等。
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithArgs 返回一个填充了消息占位符的副本。
+func (e *Error) WithArgs(args ...interface{}) *Error {
+	c := *e
+	c.Args = args
+	return &c
+}
+
+// Variant 创建一个错误码和分类相同、消息不同的错误并登记到错误目录中，key 应以错误码加后缀组成。
+func (e *Error) Variant(key, msg string) *Error {
+	return register(&Error{Kind: e.Kind, Code: e.Code, Key: key, Message: msg})
+}
+
+// catalog 记录了通过 New 和 Variant 创建的所有错误，按 Key 索引，用于生成接口文档中的错误码列表和检查翻译是否完整。
+var (
+	catalogMu sync.Mutex
+	catalog   = make(map[string]*Error)
+)
+
+// register 将错误登记到错误目录中。
+func register(e *Error) *Error {
+	catalogMu.Lock()
+	catalog[e.Key] = e
+	catalogMu.Unlock()
+	return e
+}
+
+// New 创建一个业务错误并登记到错误目录中。错误应当定义为包级变量，需要更具体的消息时使用 Variant 或 WithArgs。
+func New(kind Kind, code, msg string) *Error {
+	return register(&Error{Kind: kind, Code: code, Key: code, Message: msg})
+}
+
+// Catalog 返回登记过的所有错误，按 Key 排序。
+func Catalog() []*Error {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	list := make([]*Error, 0, len(catalog))
+	for _, e := range catalog {
+		list = append(list, e)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
+
+// Invalid 创建一个参数不合法的错误。
+func Invalid(code, msg string) *Error {
+	return New(KindInvalid, code, msg)
+}
+
+// Unauthenticated 创建一个认证失败的错误。
+func Unauthenticated(code, msg string) *Error {
+	return New(KindUnauthenticated, code, msg)
+}
+
+// Forbidden 创建一个没有权限的错误。
+func Forbidden(code, msg string) *Error {
+	return New(KindForbidden, code, msg)
+}
+
+// NotFound 创建一个资源不存在的错误。
+func NotFound(code, msg string) *Error {
+	return New(KindNotFound, code, msg)
+}
+
+// Conflict 创建一个与现有数据冲突的错误。
+func Conflict(code, msg string) *Error {
+	return New(KindConflict, code, msg)
+}
+
+// Unavailable 创建一个服务暂时不可用的错误。
+func Unavailable(code, msg string) *Error {
+	return New(KindUnavailable, code, msg)
+}
+
+// From 从错误链中取出业务错误。err 不是业务错误时返回 false，调用方应按服务器内部错误处理。
+func From(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
@@ -5,6 +5,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/viper" // 引入 viper 库，它是一个功能强大的配置解决方案
 )
@@ -19,9 +20,30 @@ var Conf = new(Config)
 // `mapstructure:"server"` 这种标签(tag)是给 viper 用的，
 // 告诉 viper 在解析 YAML 文件时，如何将键(key)映射到结构体的字段(field)。
 type Config struct {
-	Server `mapstructure:"server"`
-	MySQL  `mapstructure:"mysql"`
-	Log    `mapstructure:"log"`
+	Server       `mapstructure:"server"`
+	MySQL        `mapstructure:"mysql"`
+	Log          `mapstructure:"log"`
+	Tag          `mapstructure:"tag"`
+	Media        `mapstructure:"media"`
+	Storage      `mapstructure:"storage"`
+	Site         `mapstructure:"site"`
+	Sitemap      `mapstructure:"sitemap"`
+	ContentCache `mapstructure:"content_cache"`
+	Robots       `mapstructure:"robots"`
+	Theme        `mapstructure:"theme"`
+	Comment      `mapstructure:"comment"`
+	Spam         `mapstructure:"spam"`
+	Webmention   `mapstructure:"webmention"`
+	Webhooks     `mapstructure:"webhooks"`
+	Outbox       `mapstructure:"outbox"`
+	Jobs         `mapstructure:"jobs"`
+	XMLRPC       `mapstructure:"xmlrpc"`
+	Micropub     `mapstructure:"micropub"`
+	GraphQL      `mapstructure:"graphql"`
+	GRPC         `mapstructure:"grpc"`
+	APIDocs      `mapstructure:"api_docs"`
+	APIErrors    `mapstructure:"api_errors"`
+	I18n         `mapstructure:"i18n"`
 }
 
 // Server 结构体定义了服务相关的配置。
@@ -52,6 +74,233 @@ type Log struct {
 	Compress   bool   `mapstructure:"compress"`    // 是否压缩旧日志
 }
 
+// Site 结构体定义了站点的基本信息，用于生成订阅源、站点地图等面向外部的内容。
+type Site struct {
+	Title       string   `mapstructure:"title"`        // 站点名称
+	Description string   `mapstructure:"description"`  // 站点简介
+	URL         string   `mapstructure:"url"`          // 站点（前端）的公开访问地址，用于生成文章的永久链接
+	Language    string   `mapstructure:"language"`     // 站点的默认语言，例如 zh-CN；文章缺少所请求语言的翻译时回退到该语言
+	Languages   []string `mapstructure:"languages"`    // 文章可以使用的语言，为空时只有默认语言
+	FeedLimit   int      `mapstructure:"feed_limit"`   // 订阅源中包含的最新文章数量
+	FeedMode    string   `mapstructure:"feed_mode"`    // 订阅源的默认内容模式 (full: 全文, summary: 摘要)
+	TwitterSite string   `mapstructure:"twitter_site"` // 站点的 Twitter/X 账号，例如 @gopress，用于分享卡片
+}
+
+// Sitemap 结构体定义了站点地图相关的配置。
+type Sitemap struct {
+	PublicURL string        `mapstructure:"public_url"` // 站点地图的公开访问地址，用于 robots.txt 和通知搜索引擎；为空时根据请求推断，且不发送通知
+	ChunkSize int           `mapstructure:"chunk_size"` // 单个站点地图文件包含的最大地址数，超过后自动拆分并生成索引，最大 50000
+	PingURLs  []string      `mapstructure:"ping_urls"`  // 站点地图变化后需要通知的地址，其中的 {sitemap} 会被替换为转义后的站点地图地址
+	PingDelay time.Duration `mapstructure:"ping_delay"` // 内容变化后延迟多久发送通知，期间的多次变化只通知一次
+}
+
+// ContentCache 结构体定义了订阅源和站点地图缓存的配置。
+// 每个实例在内存中缓存渲染结果，内容变化时数据库中的共享版本号加一，各实例定期检查版本号并清空过期的缓存。
+type ContentCache struct {
+	SyncInterval time.Duration `mapstructure:"sync_interval"` // 检查共享版本号的最小间隔，其他实例上的修改最迟在这段时间后生效
+}
+
+// Robots 结构体定义了 robots.txt 的内容。
+type Robots struct {
+	Disallow []string `mapstructure:"disallow"` // 禁止抓取的路径
+	Extra    string   `mapstructure:"extra"`    // 追加到 robots.txt 末尾的自定义内容
+}
+
+// Theme 结构体定义了服务端渲染的公开站点及其主题的配置。
+type Theme struct {
+	Enabled  bool   `mapstructure:"enabled"`   // 是否启用服务端渲染的公开站点（首页、文章、分类、标签、归档页面）
+	Name     string `mapstructure:"name"`      // 当前使用的主题名称，对应主题目录下的子目录；default 为内置主题
+	Dir      string `mapstructure:"dir"`       // 存放主题的目录，每个子目录是一个主题
+	PageSize int    `mapstructure:"page_size"` // 列表页每页显示的文章数
+}
+
+// Comment 结构体定义了评论相关的配置。
+type Comment struct {
+	AllowGuests   bool   `mapstructure:"allow_guests"`    // 是否允许未登录的游客发表评论（需要填写昵称和邮箱）
+	Moderation    string `mapstructure:"moderation"`      // 需要审核的评论: all (全部), guests (仅游客), none (不审核)
+	AutoCloseDays int    `mapstructure:"auto_close_days"` // 文章发布超过多少天后自动关闭评论，0 表示不自动关闭
+	MaxDepth      int    `mapstructure:"max_depth"`       // 回复嵌套的最大层数，顶层评论为第 1 层
+	MaxLength     int    `mapstructure:"max_length"`      // 评论内容允许的最大字符数
+}
+
+// Spam 结构体定义了评论和注册时的垃圾内容检测配置。
+type Spam struct {
+	Enabled          bool          `mapstructure:"enabled"`            // 是否启用垃圾内容检测
+	Honeypot         bool          `mapstructure:"honeypot"`           // 是否检查蜜罐字段 (website)
+	MinSubmitSeconds int           `mapstructure:"min_submit_seconds"` // 从获取表单令牌到提交的最短秒数
+	FormTokenMaxAge  time.Duration `mapstructure:"form_token_max_age"` // 表单令牌的有效期，超过后提交被视为可疑
+	RequireToken     bool          `mapstructure:"require_token"`      // 是否必须携带表单令牌，未接入令牌的客户端应保持 false
+	MaxLinks         int           `mapstructure:"max_links"`          // 评论中允许的最大链接数，超过时需要审核，超过两倍时视为垃圾内容；-1 表示不限制
+	BlockedWords     []string      `mapstructure:"blocked_words"`      // 关键词黑名单
+	BlockedIPs       []string      `mapstructure:"blocked_ips"`        // IP 黑名单，支持 CIDR 网段
+	BlockedEmails    []string      `mapstructure:"blocked_emails"`     // 邮箱黑名单，以 @ 开头时匹配整个域名
+	Bayes            SpamBayes     `mapstructure:"bayes"`
+	External         SpamExternal  `mapstructure:"external"`
+}
+
+// SpamBayes 结构体定义了贝叶斯分类器的配置。
+type SpamBayes struct {
+	Enabled          bool    `mapstructure:"enabled"`           // 是否启用贝叶斯分类器
+	MinDocs          int64   `mapstructure:"min_docs"`          // 垃圾和正常内容都至少训练过多少篇后才开始打分
+	SpamThreshold    float64 `mapstructure:"spam_threshold"`    // 概率不低于该值时视为垃圾内容
+	SuspectThreshold float64 `mapstructure:"suspect_threshold"` // 概率不低于该值时需要审核
+}
+
+// SpamExternal 结构体定义了外部垃圾内容检测服务的配置。
+type SpamExternal struct {
+	Driver string `mapstructure:"driver"`  // 外部检测服务: 为空 (不使用), akismet, fake (本地测试用，不访问网络)
+	APIKey string `mapstructure:"api_key"` // 外部检测服务的 API Key
+}
+
+// Webmention 结构体定义了 Webmention 的接收和发送配置。
+type Webmention struct {
+	Enabled      bool          `mapstructure:"enabled"`       // 是否接收其他站点发来的 Webmention
+	Send         bool          `mapstructure:"send"`          // 发布文章时是否通知文章中链接的外部页面
+	EndpointURL  string        `mapstructure:"endpoint_url"`  // 对外声明的接收地址，为空且启用了主题时使用 {site.url}/webmention
+	AutoApprove  bool          `mapstructure:"auto_approve"`  // 校验通过的通知是否直接展示，否则需要审核
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // 发送或校验遇到网络错误时的最大尝试次数
+	RetryDelay   time.Duration `mapstructure:"retry_delay"`   // 第一次重试的间隔，之后每次翻倍
+	PollInterval time.Duration `mapstructure:"poll_interval"` // 后台检查待处理任务的间隔
+	Timeout      time.Duration `mapstructure:"timeout"`       // 抓取页面和发送通知的超时时间
+	AllowPrivate bool          `mapstructure:"allow_private"` // 是否允许访问内网地址，仅用于本地开发
+}
+
+// Webhooks 结构体定义了内容变化时向外部服务发送事件的配置。订阅本身通过后台接口管理。
+type Webhooks struct {
+	Enabled      bool          `mapstructure:"enabled"`       // 是否发送 Webhook，关闭后不再创建新的投递
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // 投递失败时的最大尝试次数
+	RetryDelay   time.Duration `mapstructure:"retry_delay"`   // 第一次重试的间隔，之后每次翻倍
+	PollInterval time.Duration `mapstructure:"poll_interval"` // 后台检查待投递任务的间隔
+	Timeout      time.Duration `mapstructure:"timeout"`       // 每次请求的超时时间
+	AllowPrivate bool          `mapstructure:"allow_private"` // 是否允许投递到内网地址
+}
+
+// Outbox 结构体定义了领域事件 outbox 的投递配置。
+// 事件在业务数据的事务中写入 outbox，提交后由提交者立即投递，后台任务负责接手超时未完成和投递失败的事件。
+type Outbox struct {
+	PollInterval time.Duration `mapstructure:"poll_interval"` // 后台检查待投递事件的间隔
+	Lease        time.Duration `mapstructure:"lease"`         // 事件被取出投递后，超过这个时间仍未完成时由其他投递者接手
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // 订阅者失败时的最大尝试次数，超过后标记为投递失败
+	RetryDelay   time.Duration `mapstructure:"retry_delay"`   // 第一次重试的间隔，之后每次翻倍
+	Retention    time.Duration `mapstructure:"retention"`     // 已投递的事件保留的时间，过期后由后台任务删除
+}
+
+// Jobs 结构体定义了后台任务队列的配置。任务保存在数据库中，取出任务依赖 MySQL 8.0 的 SKIP LOCKED。
+type Jobs struct {
+	PollInterval time.Duration  `mapstructure:"poll_interval"` // 空闲时检查到期任务的间隔
+	Queues       map[string]int `mapstructure:"queues"`        // 每个队列在本进程中的最大并发数，未列出的队列为 1
+	MaxAttempts  int            `mapstructure:"max_attempts"`  // 任务类型没有指定时的最大尝试次数
+	RetryDelay   time.Duration  `mapstructure:"retry_delay"`   // 第一次重试的间隔，之后每次翻倍
+	Timeout      time.Duration  `mapstructure:"timeout"`       // 任务类型没有指定时的执行超时，也是执行租约的时长
+	Retention    time.Duration  `mapstructure:"retention"`     // 执行成功的任务保留的时间，过期后删除
+	DrainTimeout time.Duration  `mapstructure:"drain_timeout"` // 关停时等待执行中的任务完成的最长时间
+}
+
+// XMLRPC 结构体定义了供桌面博客客户端使用的 MetaWeblog / Blogger XML-RPC 接口的配置。
+type XMLRPC struct {
+	Enabled           bool `mapstructure:"enabled"`             // 是否启用 /xmlrpc 接口
+	DefaultCategoryID uint `mapstructure:"default_category_id"` // 客户端没有指定分类时使用的分类，0 表示使用最早创建的分类
+	MaxRecentPosts    int  `mapstructure:"max_recent_posts"`    // getRecentPosts 最多返回的文章数
+}
+
+// Micropub 结构体定义了供 Micropub 客户端使用的发布接口的配置。
+type Micropub struct {
+	Enabled           bool `mapstructure:"enabled"`             // 是否启用 /micropub 接口
+	DefaultCategoryID uint `mapstructure:"default_category_id"` // 发布的文章所属的分类，0 表示使用最早创建的分类
+	NoteTitleLength   int  `mapstructure:"note_title_length"`   // 没有标题的笔记从正文中截取标题的长度（字符数）
+}
+
+// GraphQL 结构体定义了 /graphql 接口的配置。
+type GraphQL struct {
+	Enabled       bool `mapstructure:"enabled"`        // 是否启用 /graphql 接口
+	MaxDepth      int  `mapstructure:"max_depth"`      // 查询的最大嵌套深度，0 表示不限制
+	MaxComplexity int  `mapstructure:"max_complexity"` // 查询的最大复杂度，0 表示不限制
+	MaxFields     int  `mapstructure:"max_fields"`     // 展开片段后查询中的最大字段数，0 表示使用默认值 10000
+	MaxPageSize   int  `mapstructure:"max_page_size"`  // 列表字段的 first 参数的上限
+}
+
+// GRPC 结构体定义了供内部服务使用的 gRPC 接口的配置。
+type GRPC struct {
+	Enabled    bool `mapstructure:"enabled"`    // 是否启动 gRPC 服务
+	Port       int  `mapstructure:"port"`       // gRPC 服务监听的端口，与 HTTP 服务的端口不同
+	Reflection bool `mapstructure:"reflection"` // 是否启用服务反射，供 grpcurl 等工具查询接口定义
+}
+
+// APIDocs 结构体定义了接口文档的配置。
+type APIDocs struct {
+	Enabled bool `mapstructure:"enabled"` // 是否提供 /api/openapi.json 和 /api/docs
+}
+
+// APIErrors 结构体定义了接口错误响应的格式。
+type APIErrors struct {
+	// ProblemJSON 为 true 时，Accept 请求头包含 application/problem+json 的客户端会收到 RFC 7807 格式的错误响应
+	ProblemJSON bool `mapstructure:"problem_json"`
+}
+
+// I18n 结构体定义了接口消息本地化的配置。
+type I18n struct {
+	// DefaultLanguage 是客户端没有声明语言、声明的语言都不受支持，并且用户没有设置偏好时使用的语言
+	DefaultLanguage string `mapstructure:"default_language"`
+}
+
+// Tag 结构体定义了标签名称的规范化规则。
+// 通过名称创建标签（例如保存文章时内联创建）时会按照这些规则处理名称，
+// 标签之间的重复判断始终不区分大小写。
+type Tag struct {
+	Lowercase        bool   `mapstructure:"lowercase"`         // 是否将标签名统一转换为小写
+	SpaceReplacement string `mapstructure:"space_replacement"` // 用于替换标签名中空白的字符串，例如 "-"，为空时仅折叠连续空白
+	MaxLength        int    `mapstructure:"max_length"`        // 标签名允许的最大字符数，0 表示使用默认值 100
+}
+
+// Media 结构体定义了媒体库相关的配置。
+type Media struct {
+	MaxSizeMB    int             `mapstructure:"max_size_mb"`   // 单个文件的大小上限 (MB)
+	MaxPixels    int64           `mapstructure:"max_pixels"`    // 图片的像素数 (宽 x 高) 上限，解码前检查，防止声明了极大尺寸的图片耗尽内存
+	AllowedTypes []string        `mapstructure:"allowed_types"` // 允许上传的 MIME 类型，以内容嗅探的结果为准
+	URLPrefix    string          `mapstructure:"url_prefix"`    // 通过本服务访问媒体文件的路径前缀，例如 /uploads
+	Processing   MediaProcessing `mapstructure:"processing"`    // 图片处理配置
+}
+
+// MediaProcessing 结构体定义了上传图片的后台处理配置。
+type MediaProcessing struct {
+	Workers     int            `mapstructure:"workers"`      // 后台处理图片的并发数
+	JPEGQuality int            `mapstructure:"jpeg_quality"` // 生成 JPEG 时使用的质量 (1-100)
+	WebP        bool           `mapstructure:"webp"`         // 是否为每个尺寸额外生成一份 WebP 版本（无损压缩）
+	Variants    []ImageVariant `mapstructure:"variants"`     // 需要生成的图片尺寸
+}
+
+// ImageVariant 结构体定义了一种图片尺寸。
+type ImageVariant struct {
+	Name   string `mapstructure:"name"`   // 尺寸名称，例如 thumbnail、medium、large
+	Width  int    `mapstructure:"width"`  // 最大宽度 (像素)，0 表示不限制
+	Height int    `mapstructure:"height"` // 最大高度 (像素)，0 表示不限制
+	Crop   bool   `mapstructure:"crop"`   // 是否居中裁剪为恰好 width x height，常用于缩略图
+}
+
+// Storage 结构体定义了媒体文件存储后端的配置。
+type Storage struct {
+	Driver        string        `mapstructure:"driver"`          // 存储驱动 (local, s3)
+	PublicBaseURL string        `mapstructure:"public_base_url"` // 媒体文件的公开访问地址前缀，例如 CDN 地址；为空时通过本服务的 media.url_prefix 访问
+	PresignExpiry time.Duration `mapstructure:"presign_expiry"`  // 直传预签名地址的有效期，例如 15m
+	Local         LocalStorage  `mapstructure:"local"`           // 本地文件系统驱动的配置
+	S3            S3Storage     `mapstructure:"s3"`              // S3 兼容对象存储驱动的配置
+}
+
+// LocalStorage 结构体定义了本地文件系统存储驱动的配置。
+type LocalStorage struct {
+	Dir string `mapstructure:"dir"` // 文件在本地磁盘上的存储目录
+}
+
+// S3Storage 结构体定义了 S3 兼容对象存储驱动（AWS S3、MinIO 等）的配置。
+type S3Storage struct {
+	Endpoint        string `mapstructure:"endpoint"`          // 服务地址，例如 http://127.0.0.1:9000
+	Region          string `mapstructure:"region"`            // 区域，MinIO 可以使用默认的 us-east-1
+	Bucket          string `mapstructure:"bucket"`            // 存储桶名称
+	AccessKeyID     string `mapstructure:"access_key_id"`     // 访问密钥 ID
+	SecretAccessKey string `mapstructure:"secret_access_key"` // 访问密钥
+	UsePathStyle    bool   `mapstructure:"use_path_style"`    // 是否使用路径风格的地址，MinIO 需要开启
+}
+
 // Init 函数负责初始化配置。它会在程序启动时被调用。
 func Init() error {
 	// 设置配置文件的名称（不带扩展名）
@@ -93,6 +93,21 @@ func AutoMigrateTables() error {
 		&model.Category{},
 		&model.Tag{},
 		&model.Post{},
+		&model.Media{},
+		&model.MediaVariant{},
+		&model.Comment{},
+		&model.SpamToken{},
+		&model.SpamStat{},
+		&model.Webmention{},
+		&model.WebmentionSend{},
+		&model.AccessToken{},
+		&model.CategoryTranslation{},
+		&model.TagTranslation{},
+		&model.Webhook{},
+		&model.WebhookDelivery{},
+		&model.OutboxMessage{},
+		&model.CacheVersion{},
+		&model.Job{},
 		// &model.Post{},
 		// &model.Category{},
 	)
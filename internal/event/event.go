@@ -0,0 +1,144 @@
+// Package event 实现了进程内的领域事件总线和过滤器钩子。
+//
+// 业务代码在数据库事务中把事件写入 outbox 表，事务提交后由 service 层的 outbox 投递给订阅者
+// （见 events.go 中的事件类型），订阅者据此完成缓存失效、发送通知等附加工作；过滤器在数据保存之前调用，
+// 可以修改将要保存的内容或拒绝保存。可选的模块在自己的 init 函数或启动流程中调用 Subscribe 和 AddFilter
+// 注册，不需要修改 service 层；模块定义的新事件还要调用 Register，以便从 outbox 中还原。
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Event 是所有事件都要实现的接口，Name 返回事件的名称，它保存在 outbox 中，注册后不能修改。
+// 事件会被编码为 JSON 保存，字段中不应包含密码哈希等敏感数据。
+type Event interface {
+	Name() string
+}
+
+// Deduplicated 是可以提供去重键的事件。同一去重键的事件只会写入 outbox 一次，
+// 没有实现该接口的事件使用随机生成的去重键。
+type Deduplicated interface {
+	Event
+	DedupKey() string
+}
+
+// subscriber 是一个已注册的订阅者。
+type subscriber struct {
+	name string
+	fn   func(context.Context, Event) error
+}
+
+var (
+	_mu          sync.RWMutex
+	_subscribers = make(map[reflect.Type][]subscriber)
+	_types       = make(map[string]reflect.Type)
+)
+
+// Register 登记事件 E，使 outbox 中保存的事件可以还原为 E。同一名称只能对应一种事件类型。
+func Register[E Event]() {
+	var zero E
+	t := reflect.TypeOf(zero)
+	_mu.Lock()
+	defer _mu.Unlock()
+	if registered, ok := _types[zero.Name()]; ok && registered != t {
+		panic(fmt.Sprintf("event: %s 已经登记为 %s", zero.Name(), registered))
+	}
+	_types[zero.Name()] = t
+}
+
+// Decode 将 outbox 中保存的事件还原为登记过的事件类型。
+func Decode(name string, payload []byte) (Event, error) {
+	_mu.RLock()
+	t, ok := _types[name]
+	_mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("未登记的事件 %s", name)
+	}
+	v := reflect.New(t)
+	if err := json.Unmarshal(payload, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface().(Event), nil
+}
+
+// Subscribe 注册事件 E 的订阅者，name 是订阅者的名称，同一事件的订阅者名称不能重复。
+// 同一事件的订阅者按注册顺序依次同步调用，耗时的工作应放到后台任务中完成。
+//
+// 事件至少投递一次：订阅者返回错误或 panic 时，outbox 稍后会重新投递给它，已经成功的订阅者不会再收到；
+// 但进程在订阅者返回后、投递结果保存前崩溃时，订阅者会再次收到同一事件，应使用 DedupKey(ctx) 去重。
+func Subscribe[E Event](name string, fn func(ctx context.Context, e E) error) {
+	Register[E]()
+	t := reflect.TypeOf((*E)(nil)).Elem()
+	_mu.Lock()
+	defer _mu.Unlock()
+	for _, s := range _subscribers[t] {
+		if s.name == name {
+			panic(fmt.Sprintf("event: %s 的订阅者 %s 已经注册", t, name))
+		}
+	}
+	_subscribers[t] = append(_subscribers[t], subscriber{
+		name: name,
+		fn:   func(ctx context.Context, e Event) error { return fn(ctx, e.(E)) },
+	})
+}
+
+// Deliver 将事件投递给 delivered 中没有的订阅者，成功的订阅者会加入 delivered，
+// 返回失败的订阅者及其错误，全部成功时返回空映射。
+func Deliver(ctx context.Context, e Event, delivered map[string]bool) map[string]error {
+	_mu.RLock()
+	subscribers := _subscribers[reflect.TypeOf(e)]
+	_mu.RUnlock()
+	failed := make(map[string]error)
+	for _, s := range subscribers {
+		if delivered[s.name] {
+			continue
+		}
+		if err := call(ctx, s, e); err != nil {
+			failed[s.name] = err
+			continue
+		}
+		delivered[s.name] = true
+	}
+	return failed
+}
+
+// Publish 立即将事件投递给所有订阅者，失败时只记录日志。它不经过 outbox，
+// 进程在投递前崩溃时事件会丢失，只适合不影响数据一致性的通知。
+func Publish(ctx context.Context, e Event) {
+	for name, err := range Deliver(ctx, e, make(map[string]bool)) {
+		logger.L.Error("Event subscriber failed", zap.String("event", e.Name()), zap.String("subscriber", name), zap.Error(err))
+	}
+}
+
+// call 调用一个订阅者，并将其中的 panic 转换为错误。
+func call(ctx context.Context, s subscriber, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.L.Error("Event subscriber panicked",
+				zap.String("event", e.Name()), zap.String("subscriber", s.name), zap.String("panic", fmt.Sprint(r)), zap.Stack("stack"))
+			err = fmt.Errorf("订阅者 panic: %v", r)
+		}
+	}()
+	return s.fn(ctx, e)
+}
+
+type dedupKeyCtxKey struct{}
+
+// WithDedupKey 返回携带事件去重键的 context，由 outbox 在投递时设置。
+func WithDedupKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, dedupKeyCtxKey{}, key)
+}
+
+// DedupKey 返回正在投递的事件的去重键，同一事件重复投递时去重键相同。不经过 outbox 投递时返回空字符串。
+func DedupKey(ctx context.Context) string {
+	key, _ := ctx.Value(dedupKeyCtxKey{}).(string)
+	return key
+}
@@ -0,0 +1,106 @@
+package event
+
+import (
+	"fmt"
+
+	"github.com/KeLes-Coding/gopress/internal/model"
+)
+
+// 事件中的模型是事务中保存的数据，不保证包含作者、分类等关联数据，需要时由订阅者查询。
+// 订阅者只能读取，不应修改。
+
+func init() {
+	Register[PostCreated]()
+	Register[PostUpdated]()
+	Register[PostDeleted]()
+	Register[CategoryUpdated]()
+	Register[CategoryDeleted]()
+	Register[TagUpdated]()
+	Register[TagDeleted]()
+	Register[TagsMerged]()
+	Register[CommentCreated]()
+	Register[UserSignedUp]()
+}
+
+// PostCreated 在创建文章后发布。
+type PostCreated struct {
+	Post *model.Post
+}
+
+func (PostCreated) Name() string       { return "post.created" }
+func (e PostCreated) DedupKey() string { return fmt.Sprintf("post.created:%d", e.Post.ID) }
+
+// PostUpdated 在修改文章后发布。Post 是修改后的文章，Previous 是修改前的文章，
+// 可以用来判断状态等字段的变化。
+type PostUpdated struct {
+	Post     *model.Post
+	Previous *model.Post
+}
+
+func (PostUpdated) Name() string { return "post.updated" }
+
+// PostDeleted 在删除文章后发布，Post 是删除前的文章。
+type PostDeleted struct {
+	Post *model.Post
+}
+
+func (PostDeleted) Name() string       { return "post.deleted" }
+func (e PostDeleted) DedupKey() string { return fmt.Sprintf("post.deleted:%d", e.Post.ID) }
+
+// CategoryUpdated 在修改分类的名称或翻译后发布。
+type CategoryUpdated struct {
+	Category *model.Category
+}
+
+func (CategoryUpdated) Name() string { return "category.updated" }
+
+// CategoryDeleted 在删除分类后发布，ID 是被删除的分类。
+type CategoryDeleted struct {
+	ID uint
+}
+
+func (CategoryDeleted) Name() string       { return "category.deleted" }
+func (e CategoryDeleted) DedupKey() string { return fmt.Sprintf("category.deleted:%d", e.ID) }
+
+// TagUpdated 在修改标签的名称或翻译后发布。
+type TagUpdated struct {
+	Tag *model.Tag
+}
+
+func (TagUpdated) Name() string { return "tag.updated" }
+
+// TagDeleted 在删除标签后发布，ID 是被删除的标签。
+type TagDeleted struct {
+	ID uint
+}
+
+func (TagDeleted) Name() string       { return "tag.deleted" }
+func (e TagDeleted) DedupKey() string { return fmt.Sprintf("tag.deleted:%d", e.ID) }
+
+// TagsMerged 在合并标签后发布，SourceIDs 是被合并并删除的标签，Target 是保留的标签。
+type TagsMerged struct {
+	SourceIDs []uint
+	Target    *model.Tag
+}
+
+func (TagsMerged) Name() string { return "tag.merged" }
+
+// CommentCreated 在保存新评论后发布，包括等待审核和被判定为垃圾内容的评论，
+// 订阅者应根据 Comment.Status 决定如何处理。AuthorName 是评论显示的作者名称，
+// 登录用户发表的评论为用户的昵称，Comment.User 为 nil。
+type CommentCreated struct {
+	Comment    *model.Comment
+	AuthorName string
+}
+
+func (CommentCreated) Name() string       { return "comment.created" }
+func (e CommentCreated) DedupKey() string { return fmt.Sprintf("comment.created:%d", e.Comment.ID) }
+
+// UserSignedUp 在用户注册成功后发布。事件不包含邮箱等个人信息，需要时由订阅者按 UserID 查询。
+type UserSignedUp struct {
+	UserID   uint
+	Username string
+}
+
+func (UserSignedUp) Name() string       { return "user.signed_up" }
+func (e UserSignedUp) DedupKey() string { return fmt.Sprintf("user.signed_up:%d", e.UserID) }
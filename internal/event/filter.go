@@ -0,0 +1,58 @@
+package event
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// 过滤器的默认优先级，数值小的先调用，优先级相同时按注册顺序调用。
+const DefaultPriority = 10
+
+// filter 是一个已注册的过滤器。
+type filter struct {
+	name     string
+	priority int
+	fn       func(interface{}) error
+}
+
+var (
+	_filtersMu sync.RWMutex
+	_filters   = make(map[reflect.Type][]filter)
+)
+
+// AddFilter 注册类型 T 的过滤器，name 是过滤器的名称，priority 决定调用顺序。
+// 过滤器在 T 保存到数据库之前同步调用，可以修改 v 中将要保存的内容，返回错误时中止保存，
+// 错误会原样返回给调用方，应使用 apperr 中的错误以便返回合适的状态码。
+// 过滤器可能在数据库事务中调用，不应访问数据库或执行耗时的操作。
+//
+// 目前会调用过滤器的类型：
+//   - model.Post: 创建和修改文章时，ID 为 0 表示创建
+//   - model.Comment: 提交评论时，在垃圾内容检测之前调用
+func AddFilter[T any](name string, priority int, fn func(v *T) error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	_filtersMu.Lock()
+	defer _filtersMu.Unlock()
+	// 复制一份再排序，正在调用 ApplyFilters 的 goroutine 仍使用原来的切片
+	filters := append(append([]filter(nil), _filters[t]...), filter{
+		name:     name,
+		priority: priority,
+		fn:       func(v interface{}) error { return fn(v.(*T)) },
+	})
+	sort.SliceStable(filters, func(i, j int) bool { return filters[i].priority < filters[j].priority })
+	_filters[t] = filters
+}
+
+// ApplyFilters 按优先级依次调用类型 T 的过滤器，遇到第一个错误时停止并返回该错误。
+func ApplyFilters[T any](v *T) error {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	_filtersMu.RLock()
+	filters := _filters[t]
+	_filtersMu.RUnlock()
+	for _, f := range filters {
+		if err := f.fn(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,590 @@
+// package export 实现了将公开站点导出为静态文件的功能，导出的目录可以直接部署到任意静态文件服务器上。
+//
+// 页面使用“美化”的地址，每个页面保存为目录下的 index.html，例如：
+//
+//	/                      -> index.html
+//	/page/2/               -> page/2/index.html
+//	/posts/12              -> posts/12/index.html
+//	/categories/3/page/2/  -> categories/3/page/2/index.html
+//
+// 导出目录中会保存一个清单文件，记录上次导出的文件和每篇文章的修改时间。
+// 再次导出时只重新渲染修改过的文章，内容没有变化的文件不会被重写，不再存在的文件会被删除。
+package export
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/feed"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/KeLes-Coding/gopress/internal/storage"
+	"github.com/KeLes-Coding/gopress/internal/theme"
+	"gorm.io/gorm"
+)
+
+// ManifestName 是导出目录中清单文件的名称。
+const ManifestName = ".gopress-export.json"
+
+// manifestVersion 是清单文件的格式版本，格式变化时旧清单会被忽略并完整重建。
+const manifestVersion = 1
+
+// manifest 记录了上一次导出的结果，用于增量导出。
+type manifest struct {
+	Version     int                  `json:"version"`
+	Fingerprint string               `json:"fingerprint"` // 主题和站点配置的摘要，变化后需要重新渲染所有文章
+	ExportedAt  time.Time            `json:"exported_at"`
+	Posts       map[string]time.Time `json:"posts"` // 文章 ID -> 渲染时的修改时间
+	Files       []string             `json:"files"` // 导出的所有文件（相对路径，不含媒体文件）
+}
+
+// Options 是导出时的选项。
+type Options struct {
+	OutDir string // 输出目录
+	Full   bool   // 忽略清单，重新渲染所有页面
+}
+
+// Stats 是一次导出的统计信息。
+type Stats struct {
+	Written   int // 新写入或内容有变化的文件数
+	Unchanged int // 内容没有变化的文件数
+	Skipped   int // 未修改而跳过渲染的文章数
+	Removed   int // 删除的过期文件数
+	Media     int // 新复制的媒体文件数
+}
+
+// Exporter 负责导出静态站点。
+type Exporter struct {
+	opts         Options
+	themes       *theme.Manager
+	siteService  *service.SiteService
+	feedService  *service.FeedService
+	sitemap      *service.SitemapService
+	mediaService *service.MediaService
+
+	old   *manifest
+	next  *manifest
+	stats Stats
+}
+
+// New 创建一个 Exporter，调用前需要初始化配置、数据库、存储和主题。
+func New(opts Options) *Exporter {
+	return &Exporter{
+		opts:         opts,
+		themes:       theme.GetManager(),
+		siteService:  service.NewStaticSiteService(),
+		feedService:  service.NewFeedService(),
+		sitemap:      service.NewSitemapService(),
+		mediaService: service.NewMediaService(),
+	}
+}
+
+// Run 执行一次导出。
+func (e *Exporter) Run() (*Stats, error) {
+	if config.Conf.Site.URL == "" {
+		return nil, errors.New("site.url 未配置，无法生成订阅源和站点地图中的绝对地址")
+	}
+	if err := os.MkdirAll(e.opts.OutDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	fingerprint, err := e.fingerprint()
+	if err != nil {
+		return nil, err
+	}
+	e.old = e.readManifest()
+	if e.opts.Full || e.old.Version != manifestVersion || e.old.Fingerprint != fingerprint {
+		e.old = &manifest{Posts: map[string]time.Time{}}
+	}
+	e.next = &manifest{
+		Version:     manifestVersion,
+		Fingerprint: fingerprint,
+		ExportedAt:  time.Now(),
+		Posts:       map[string]time.Time{},
+	}
+
+	steps := []func() error{
+		e.exportPosts,
+		e.exportListings,
+		e.exportFeeds,
+		e.exportSitemap,
+		e.exportAssets,
+		e.exportMedia,
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.removeStale(); err != nil {
+		return nil, err
+	}
+	if err := e.writeManifest(); err != nil {
+		return nil, err
+	}
+	return &e.stats, nil
+}
+
+// fingerprint 计算主题文件和影响页面内容的配置的摘要。
+func (e *Exporter) fingerprint() (string, error) {
+	t, err := e.themes.Theme()
+	if err != nil {
+		return "", err
+	}
+	themeSum, err := t.Fingerprint()
+	if err != nil {
+		return "", err
+	}
+	conf, err := json.Marshal(struct {
+		Site          config.Site
+		PageSize      int
+		URLPrefix     string
+		PublicBaseURL string
+	}{config.Conf.Site, config.Conf.Theme.PageSize, config.Conf.Media.URLPrefix, config.Conf.Storage.PublicBaseURL})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(themeSum), conf...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// postStamp 是导出时查询文章修改时间使用的轻量结构。
+type postStamp struct {
+	ID         uint
+	UpdatedAt  time.Time
+	CategoryID uint
+	UserID     uint
+}
+
+// exportPosts 渲染所有已发布的文章。
+// 文章页还会显示分类、标签和作者，因此以它们中最新的修改时间作为文章页的修改时间。
+func (e *Exporter) exportPosts() error {
+	db := dao.GetDB()
+	var posts []postStamp
+	if err := db.Model(&model.Post{}).Select("id, updated_at, category_id, user_id").
+		Where("status = ?", model.PostStatusPublished).Order("id ASC").Scan(&posts).Error; err != nil {
+		return err
+	}
+
+	categories, err := updatedAtByID(db.Model(&model.Category{}))
+	if err != nil {
+		return err
+	}
+	users, err := updatedAtByID(db.Model(&model.User{}))
+	if err != nil {
+		return err
+	}
+	var tagRows []struct {
+		PostID    uint
+		UpdatedAt time.Time
+	}
+	if err := db.Table("post_tags").Select("post_tags.post_id, tags.updated_at").
+		Joins("JOIN tags ON tags.id = post_tags.tag_id").Scan(&tagRows).Error; err != nil {
+		return err
+	}
+	postTags := make(map[uint]time.Time)
+	for _, row := range tagRows {
+		if row.UpdatedAt.After(postTags[row.PostID]) {
+			postTags[row.PostID] = row.UpdatedAt
+		}
+	}
+
+	for _, post := range posts {
+		stamp := latest(post.UpdatedAt, categories[post.CategoryID], users[post.UserID], postTags[post.ID]).UTC()
+		id := strconv.FormatUint(uint64(post.ID), 10)
+		file := "posts/" + id + "/index.html"
+
+		if prev, ok := e.old.Posts[id]; ok && prev.Equal(stamp) && e.exists(file) {
+			e.keep(file)
+			e.next.Posts[id] = stamp
+			e.stats.Skipped++
+			continue
+		}
+		page, err := e.siteService.Post(post.ID)
+		if err != nil {
+			return fmt.Errorf("post %d: %w", post.ID, err)
+		}
+		if err := e.render(file, "post", page); err != nil {
+			return err
+		}
+		e.next.Posts[id] = stamp
+	}
+	return nil
+}
+
+// exportListings 渲染首页、分类页、标签页、归档页和 404 页面。列表页依赖多篇文章，每次都重新渲染。
+func (e *Exporter) exportListings() error {
+	if err := e.renderPages("", func(page int) (*service.SitePageDTO, error) {
+		return e.siteService.Home(page)
+	}, "home"); err != nil {
+		return err
+	}
+
+	db := dao.GetDB()
+	var categoryIDs, tagIDs []uint
+	if err := db.Model(&model.Category{}).
+		Where("EXISTS (SELECT 1 FROM posts WHERE posts.category_id = categories.id AND posts.status = ?)", 1).
+		Order("id ASC").Pluck("id", &categoryIDs).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&model.Tag{}).
+		Where("EXISTS (SELECT 1 FROM post_tags JOIN posts ON posts.id = post_tags.post_id WHERE post_tags.tag_id = tags.id AND posts.status = ?)", 1).
+		Order("id ASC").Pluck("id", &tagIDs).Error; err != nil {
+		return err
+	}
+	for _, id := range categoryIDs {
+		id := id
+		if err := e.renderPages("categories/"+strconv.FormatUint(uint64(id), 10), func(page int) (*service.SitePageDTO, error) {
+			return e.siteService.Category(id, page)
+		}, "category"); err != nil {
+			return err
+		}
+	}
+	for _, id := range tagIDs {
+		id := id
+		if err := e.renderPages("tags/"+strconv.FormatUint(uint64(id), 10), func(page int) (*service.SitePageDTO, error) {
+			return e.siteService.Tag(id, page)
+		}, "tag"); err != nil {
+			return err
+		}
+	}
+
+	archive, err := e.siteService.Archive()
+	if err != nil {
+		return err
+	}
+	if err := e.render("archive/index.html", "archive", archive); err != nil {
+		return err
+	}
+	// 大多数静态托管服务会在找不到文件时返回根目录下的 404.html
+	return e.render("404.html", "404", e.siteService.NotFound())
+}
+
+// renderPages 渲染一个列表页的所有分页，dir 为列表页所在的目录（首页为空）。
+func (e *Exporter) renderPages(dir string, load func(page int) (*service.SitePageDTO, error), name string) error {
+	for n := 1; ; n++ {
+		page, err := load(n)
+		if err != nil {
+			return err
+		}
+		file := path.Join(dir, "index.html")
+		if n > 1 {
+			file = path.Join(dir, "page", strconv.Itoa(n), "index.html")
+		}
+		if err := e.render(file, name, page); err != nil {
+			return err
+		}
+		if page.Pagination == nil || n >= page.Pagination.TotalPages {
+			return nil
+		}
+	}
+}
+
+// exportFeeds 生成站点、分类、标签和作者的订阅源，路径与服务端的订阅源路由一致。
+func (e *Exporter) exportFeeds() error {
+	db := dao.GetDB()
+	siteURL := strings.TrimRight(config.Conf.Site.URL, "/")
+
+	type scope struct {
+		prefix string
+		query  service.FeedQuery
+	}
+	scopes := []scope{{prefix: ""}}
+	var categoryIDs, tagIDs, userIDs []uint
+	if err := db.Model(&model.Post{}).Where("status = ?", model.PostStatusPublished).Distinct("category_id").Pluck("category_id", &categoryIDs).Error; err != nil {
+		return err
+	}
+	if err := db.Table("post_tags").Joins("JOIN posts ON posts.id = post_tags.post_id").
+		Where("posts.status = ?", model.PostStatusPublished).Distinct("post_tags.tag_id").Pluck("post_tags.tag_id", &tagIDs).Error; err != nil {
+		return err
+	}
+	if err := db.Model(&model.Post{}).Where("status = ?", model.PostStatusPublished).Distinct("user_id").Pluck("user_id", &userIDs).Error; err != nil {
+		return err
+	}
+	for _, id := range categoryIDs {
+		if id != 0 {
+			scopes = append(scopes, scope{prefix: "category/" + strconv.FormatUint(uint64(id), 10), query: service.FeedQuery{CategoryID: id}})
+		}
+	}
+	for _, id := range tagIDs {
+		scopes = append(scopes, scope{prefix: "tag/" + strconv.FormatUint(uint64(id), 10), query: service.FeedQuery{TagID: id}})
+	}
+	for _, id := range userIDs {
+		scopes = append(scopes, scope{prefix: "author/" + strconv.FormatUint(uint64(id), 10), query: service.FeedQuery{UserID: id}})
+	}
+
+	files := map[string]string{
+		feed.FormatRSS:  "feed.xml",
+		feed.FormatAtom: "atom.xml",
+		feed.FormatJSON: "feed.json",
+	}
+	for _, s := range scopes {
+		for _, format := range []string{feed.FormatRSS, feed.FormatAtom, feed.FormatJSON} {
+			file := path.Join(s.prefix, files[format])
+			q := s.query
+			q.Format = format
+			q.FeedURL = siteURL + "/" + file
+			doc, err := e.feedService.Build(&q)
+			if err != nil {
+				return fmt.Errorf("%s: %w", file, err)
+			}
+			if err := e.write(file, doc.Body); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exportSitemap 生成站点地图（及其分片）和 robots.txt。
+func (e *Exporter) exportSitemap() error {
+	siteURL := strings.TrimRight(config.Conf.Site.URL, "/")
+	doc, err := e.sitemap.Sitemap(siteURL)
+	if err != nil {
+		return err
+	}
+	if err := e.write("sitemap.xml", doc.Body); err != nil {
+		return err
+	}
+	for n := 1; ; n++ {
+		chunk, err := e.sitemap.Chunk(n)
+		if errors.Is(err, service.ErrSitemapNotFound) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		// 只有一个分片时 sitemap.xml 本身就是完整的站点地图，不需要输出分片
+		if n == 1 && bytes.Equal(chunk.Body, doc.Body) {
+			break
+		}
+		if err := e.write(fmt.Sprintf("sitemaps/sitemap-%d.xml", n), chunk.Body); err != nil {
+			return err
+		}
+	}
+	return e.write("robots.txt", []byte(e.sitemap.Robots(siteURL+"/sitemap.xml")))
+}
+
+// exportAssets 复制当前主题的静态文件到 theme/ 目录。
+func (e *Exporter) exportAssets() error {
+	t, err := e.themes.Theme()
+	if err != nil {
+		return err
+	}
+	assets := t.Assets()
+	return fs.WalkDir(assets, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// 主题没有 assets 目录
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		data, err := fs.ReadFile(assets, name)
+		if err != nil {
+			return err
+		}
+		return e.write(path.Join("theme", name), data)
+	})
+}
+
+// exportMedia 复制已发布文章引用的媒体文件。媒体文件是内容寻址的，已存在的文件不会重复复制。
+// 配置了 storage.public_base_url 时媒体文件由 CDN 提供，不需要复制。
+func (e *Exporter) exportMedia() error {
+	if config.Conf.Storage.PublicBaseURL != "" {
+		return nil
+	}
+	keys, err := e.mediaService.PublishedMediaKeys()
+	if err != nil {
+		return err
+	}
+	prefix := strings.Trim(config.Conf.Media.URLPrefix, "/")
+	if prefix == "" {
+		prefix = "uploads"
+	}
+	store := storage.GetStorage()
+	for _, key := range keys {
+		dst := e.path(path.Join(prefix, key))
+		if _, err := os.Stat(dst); err == nil {
+			continue
+		}
+		if err := copyObject(store, key, dst); err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				// 正文中引用的文件已经从存储中删除，页面上的图片本来就无法显示，跳过即可
+				continue
+			}
+			return fmt.Errorf("media %s: %w", key, err)
+		}
+		e.stats.Media++
+	}
+	return nil
+}
+
+// copyObject 将存储中的一个文件复制到本地路径 dst。
+func copyObject(store storage.Storage, key, dst string) error {
+	obj, err := store.Get(key)
+	if err != nil {
+		return err
+	}
+	defer obj.Body.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	tmp := dst + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, obj.Body); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// render 使用当前主题渲染页面并写入 file。
+func (e *Exporter) render(file, name string, page *service.SitePageDTO) error {
+	var buf bytes.Buffer
+	if err := e.themes.Render(&buf, name, page); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return e.write(file, buf.Bytes())
+}
+
+// write 将内容写入导出目录中的 file。内容没有变化时不重写文件，保留原来的修改时间，便于 rsync 等工具增量同步。
+func (e *Exporter) write(file string, data []byte) error {
+	e.keep(file)
+	dst := e.path(file)
+	if old, err := os.ReadFile(dst); err == nil && bytes.Equal(old, data) {
+		e.stats.Unchanged++
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	// 先写入临时文件再重命名，避免静态服务器读到写了一半的文件
+	tmp := dst + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	e.stats.Written++
+	return nil
+}
+
+// keep 将 file 记录到本次导出的清单中。
+func (e *Exporter) keep(file string) {
+	e.next.Files = append(e.next.Files, file)
+}
+
+// exists 判断导出目录中是否存在 file。
+func (e *Exporter) exists(file string) bool {
+	_, err := os.Stat(e.path(file))
+	return err == nil
+}
+
+// path 返回 file 在导出目录中的实际路径。
+func (e *Exporter) path(file string) string {
+	return filepath.Join(e.opts.OutDir, filepath.FromSlash(file))
+}
+
+// removeStale 删除上次导出过、但本次没有导出的文件（例如被删除或撤回发布的文章），以及因此变空的目录。
+// 完整导出时旧清单会被丢弃，因此这里重新读取清单文件。
+func (e *Exporter) removeStale() error {
+	current := make(map[string]bool, len(e.next.Files))
+	for _, file := range e.next.Files {
+		current[file] = true
+	}
+	for _, file := range e.readManifest().Files {
+		if current[file] || !fs.ValidPath(file) {
+			continue
+		}
+		if err := os.Remove(e.path(file)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		e.stats.Removed++
+		// 向上删除空目录，直到导出目录为止
+		for dir := path.Dir(file); dir != "."; dir = path.Dir(dir) {
+			if os.Remove(e.path(dir)) != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// readManifest 读取上次导出的清单，不存在或无法解析时返回空清单。
+func (e *Exporter) readManifest() *manifest {
+	m := &manifest{Posts: map[string]time.Time{}}
+	data, err := os.ReadFile(e.path(ManifestName))
+	if err != nil {
+		return m
+	}
+	if err := json.Unmarshal(data, m); err != nil || m.Posts == nil {
+		return &manifest{Posts: map[string]time.Time{}}
+	}
+	return m
+}
+
+// writeManifest 保存本次导出的清单。
+func (e *Exporter) writeManifest() error {
+	sort.Strings(e.next.Files)
+	data, err := json.MarshalIndent(e.next, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(e.path(ManifestName), data, 0o644)
+}
+
+// updatedAtByID 查询 query 对应的表中每条记录的修改时间。
+func updatedAtByID(query *gorm.DB) (map[uint]time.Time, error) {
+	var rows []struct {
+		ID        uint
+		UpdatedAt time.Time
+	}
+	if err := query.Select("id, updated_at").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	m := make(map[uint]time.Time, len(rows))
+	for _, row := range rows {
+		m[row.ID] = row.UpdatedAt
+	}
+	return m, nil
+}
+
+// latest 返回多个时间中最晚的一个。
+func latest(times ...time.Time) time.Time {
+	var t time.Time
+	for _, v := range times {
+		if v.After(t) {
+			t = v
+		}
+	}
+	return t
+}
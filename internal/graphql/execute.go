@@ -0,0 +1,290 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// 执行按照执行计划逐层解析字段。resolver 返回 Thunk 时，字段的值暂不求值而是放入队列，
+// 等同一轮的其他字段都解析完（它们通过 Loader 登记了需要加载的键）后再依次求值，
+// 这样同一层的多个对象只需要一次批量查询。
+//
+// 字段出错时值为 null 并记录错误；非空字段为 null 时，null 向上传递到最近的可空字段，
+// 传递在所有字段执行完之后统一进行，因为延迟求值的字段可能比它的父对象晚完成。
+
+// executor 执行一个操作。executor 不是并发安全的，resolver 在同一个 goroutine 中依次调用。
+type executor struct {
+	ctx     context.Context
+	schema  *Schema
+	present func(err error) *Error
+	errors  []*Error
+	queue   []func()
+}
+
+// resultObject 是对象的结果，按照选择集的顺序输出字段。
+type resultObject struct {
+	keys    []string
+	values  []interface{}
+	nonNull []bool // 字段是否为非空类型，用于传递 null
+}
+
+// MarshalJSON 按字段顺序输出对象。
+func (o *resultObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		v, err := json.Marshal(o.values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// resultList 是列表的结果。
+type resultList struct {
+	items   []interface{}
+	nonNull bool // 元素是否为非空类型
+}
+
+// MarshalJSON 输出列表。
+func (l *resultList) MarshalJSON() ([]byte, error) {
+	return json.Marshal(l.items)
+}
+
+// run 执行根字段并返回 data 的值。修改操作的根字段按顺序逐个执行完（包括延迟求值的部分）再执行下一个。
+func (e *executor) run(root *Object, fields []*planField, serial bool) interface{} {
+	out := newResultObject(fields)
+	if serial {
+		for i, f := range fields {
+			i := i
+			e.executeField(root, f, nil, []interface{}{f.key}, func(v interface{}) { out.values[i] = v })
+			e.drain()
+		}
+	} else {
+		e.executeFields(root, fields, nil, nil, out)
+		e.drain()
+	}
+	return propagateNull(out)
+}
+
+// drain 依次对队列中延迟求值的字段求值，直到队列为空。
+func (e *executor) drain() {
+	for len(e.queue) > 0 {
+		queue := e.queue
+		e.queue = nil
+		for _, fn := range queue {
+			fn()
+		}
+	}
+}
+
+func newResultObject(fields []*planField) *resultObject {
+	out := &resultObject{
+		keys:    make([]string, len(fields)),
+		values:  make([]interface{}, len(fields)),
+		nonNull: make([]bool, len(fields)),
+	}
+	for i, f := range fields {
+		out.keys[i] = f.key
+		out.nonNull[i] = isNonNullType(f.field.Type)
+	}
+	return out
+}
+
+// executeFields 解析对象 source 的字段，结果写入 out。
+func (e *executor) executeFields(t *Object, fields []*planField, source interface{}, path []interface{}, out *resultObject) {
+	for i, f := range fields {
+		i := i
+		e.executeField(t, f, source, appendPath(path, f.key), func(v interface{}) { out.values[i] = v })
+	}
+}
+
+// executeField 解析一个字段，set 用于写入字段的结果。
+func (e *executor) executeField(t *Object, f *planField, source interface{}, path []interface{}, set func(interface{})) {
+	var value interface{}
+	var err error
+	switch f.meta {
+	case metaTypename:
+		set(t.Name)
+		return
+	case metaSchema:
+		value = e.schema
+	case metaType:
+		name, _ := f.args["name"].(string)
+		if nt, ok := e.schema.types[name]; ok {
+			value = nt
+		}
+	default:
+		if err = e.ctx.Err(); err == nil {
+			value, err = e.resolve(f, source)
+		}
+	}
+	if err != nil {
+		e.fieldError(err, f, path)
+		set(nil)
+		return
+	}
+	e.complete(f.field.Type, f, value, path, set)
+}
+
+// resolve 调用字段的 resolver，resolver 中的 panic 转换为错误。
+func (e *executor) resolve(f *planField, source interface{}) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("graphql: panic in resolver for %s: %v", f.fieldName(), r)
+		}
+	}()
+	if f.field.Resolve == nil {
+		if m, ok := source.(map[string]interface{}); ok {
+			return m[f.field.Name], nil
+		}
+		return nil, fmt.Errorf("graphql: field %s has no resolver", f.fieldName())
+	}
+	return f.field.Resolve(ResolveParams{Context: e.ctx, Source: source, Args: f.args})
+}
+
+// force 对延迟求值的字段求值，Thunk 中的 panic 转换为错误。
+func (e *executor) force(f *planField, thunk Thunk) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("graphql: panic in loader for %s: %v", f.fieldName(), r)
+		}
+	}()
+	return thunk()
+}
+
+// complete 按字段的类型转换值：检查非空、展开列表、序列化标量和枚举、解析对象的子字段。
+func (e *executor) complete(t Type, f *planField, value interface{}, path []interface{}, set func(interface{})) {
+	if thunk, ok := value.(Thunk); ok {
+		e.queue = append(e.queue, func() {
+			v, err := e.force(f, thunk)
+			if err != nil {
+				e.fieldError(err, f, path)
+				set(nil)
+				return
+			}
+			e.complete(t, f, v, path, set)
+		})
+		return
+	}
+
+	if nn, ok := t.(*NonNull); ok {
+		if isNil(value) {
+			e.errors = append(e.errors, &Error{
+				Message:   fmt.Sprintf("Cannot return null for non-nullable field %s.", f.fieldName()),
+				Locations: []Location{f.loc},
+				Path:      path,
+			})
+			set(nil)
+			return
+		}
+		e.complete(nn.OfType, f, value, path, set)
+		return
+	}
+	if isNil(value) {
+		set(nil)
+		return
+	}
+
+	switch t := t.(type) {
+	case *List:
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			e.fieldError(fmt.Errorf("graphql: expected a list for %s, got %T", f.fieldName(), value), f, path)
+			set(nil)
+			return
+		}
+		list := &resultList{items: make([]interface{}, rv.Len()), nonNull: isNonNullType(t.OfType)}
+		set(list)
+		for i := 0; i < rv.Len(); i++ {
+			i := i
+			e.complete(t.OfType, f, rv.Index(i).Interface(), appendPath(path, i), func(v interface{}) { list.items[i] = v })
+		}
+	case *Scalar:
+		v, err := t.Serialize(value)
+		if err != nil {
+			e.fieldError(err, f, path)
+			v = nil
+		}
+		set(v)
+	case *Enum:
+		ev := t.byValue(value)
+		if ev == nil {
+			e.fieldError(fmt.Errorf("graphql: enum %s cannot represent value %v", t.Name, value), f, path)
+			set(nil)
+			return
+		}
+		set(ev.Name)
+	case *Object:
+		out := newResultObject(f.children)
+		set(out)
+		e.executeFields(t, f.children, value, path, out)
+	}
+}
+
+// fieldError 记录字段的错误。
+func (e *executor) fieldError(err error, f *planField, path []interface{}) {
+	var gqlErr *Error
+	if e.present != nil {
+		gqlErr = e.present(err)
+	}
+	if gqlErr == nil {
+		gqlErr = &Error{Message: err.Error()}
+	}
+	gqlErr.Locations = []Location{f.loc}
+	gqlErr.Path = path
+	e.errors = append(e.errors, gqlErr)
+}
+
+// propagateNull 将非空字段的 null 传递到最近的可空字段，返回处理后的值。
+func propagateNull(v interface{}) interface{} {
+	switch r := v.(type) {
+	case *resultObject:
+		for i := range r.values {
+			r.values[i] = propagateNull(r.values[i])
+			if r.values[i] == nil && r.nonNull[i] {
+				return nil
+			}
+		}
+	case *resultList:
+		for i := range r.items {
+			r.items[i] = propagateNull(r.items[i])
+			if r.items[i] == nil && r.nonNull {
+				return nil
+			}
+		}
+	}
+	return v
+}
+
+// isNil 判断值是否为 null。nil 切片不算 null，作为空列表处理。
+func isNil(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Interface, reflect.Func:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// appendPath 返回追加了一级的新路径，不修改原路径。
+func appendPath(path []interface{}, key interface{}) []interface{} {
+	p := make([]interface{}, len(path), len(path)+1)
+	copy(p, path)
+	return append(p, key)
+}
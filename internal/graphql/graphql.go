@@ -0,0 +1,220 @@
+// package graphql 是一个精简的 GraphQL (https://spec.graphql.org/October2021/) 服务端实现，
+// 负责解析查询、校验、限制查询的深度、字段数和复杂度，以及执行查询并组装响应。
+//
+// 模式在 Go 代码中定义（*Object、*Field 等），不解析 SDL。支持的特性：
+//
+//	查询和修改操作（不支持订阅）
+//	变量、默认值、别名、命名片段、内联片段
+//	@skip 和 @include 指令
+//	内省（__schema、__type、__typename），可以配合 GraphiQL 等工具使用
+//	Loader 批量加载，避免逐个对象查询数据库 (N+1)
+//
+// 不支持接口和联合类型，片段的类型条件只能是所在位置的对象类型。
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// 框架产生的错误在 extensions.code 中使用的错误码，resolver 返回的错误由 Params.PresentError 决定。
+const (
+	CodeParseFailed      = "graphql_parse_failed"      // 查询不符合语法
+	CodeValidationFailed = "graphql_validation_failed" // 查询与模式不符，或变量的值不合法
+	CodeTooDeep          = "query_too_deep"            // 查询的嵌套深度超过上限
+	CodeTooComplex       = "query_too_complex"         // 查询的复杂度超过上限
+	CodeTooLarge         = "query_too_large"           // 展开片段后的字段数超过上限
+)
+
+// DefaultMaxFields 是 Params.MaxFields 为 0 时执行计划中的最大字段数。
+const DefaultMaxFields = 10000
+
+// Location 是查询文本中的位置，行号和列号均从 1 开始。
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error 是响应中 errors 列表里的一项。
+type Error struct {
+	Message    string                 `json:"message"`
+	Locations  []Location             `json:"locations,omitempty"`
+	Path       []interface{}          `json:"path,omitempty"` // 出错字段在 data 中的路径，由字段名和列表下标组成
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// syntaxError 返回查询的语法错误。
+func syntaxError(loc Location, format string, args ...interface{}) *Error {
+	return &Error{
+		Message:    "Syntax Error: " + fmt.Sprintf(format, args...),
+		Locations:  []Location{loc},
+		Extensions: map[string]interface{}{"code": CodeParseFailed},
+	}
+}
+
+// validationError 返回查询与模式不符的错误。
+func validationError(locs []Location, format string, args ...interface{}) *Error {
+	return &Error{
+		Message:    fmt.Sprintf(format, args...),
+		Locations:  locs,
+		Extensions: map[string]interface{}{"code": CodeValidationFailed},
+	}
+}
+
+// Params 是执行一次请求的参数。
+type Params struct {
+	Schema        *Schema
+	Query         string
+	OperationName string                 // 文档中有多个操作时必须指定
+	Variables     map[string]interface{} // 变量的 JSON 值
+	Context       context.Context        // 传给 resolver 的上下文，为 nil 时使用 context.Background()
+
+	// QueryOnly 为 true 时只允许执行查询操作，用于 GET 请求
+	QueryOnly bool
+	// MaxDepth 是查询的最大嵌套深度，0 表示不限制。内省字段不计入
+	MaxDepth int
+	// MaxComplexity 是查询的最大复杂度，0 表示不限制。内省字段不计入
+	MaxComplexity int
+	// MaxFields 是展开片段、合并同名字段后执行计划中的最大字段数，包括内省字段，0 表示使用 DefaultMaxFields。
+	// 引用同一个片段多次的查询展开后可能呈指数增长，该上限在展开过程中检查，不能关闭
+	MaxFields int
+	// PresentError 将 resolver 返回的错误转换为响应中的错误，Path 和 Locations 由调用方填充。
+	// 为 nil 时使用 err.Error() 作为消息
+	PresentError func(err error) *Error
+}
+
+// Result 是请求的结果。
+type Result struct {
+	Data   interface{}
+	Errors []*Error
+
+	// executed 表示请求进入了执行阶段，此时响应中总是包含 data（可能为 null）；
+	// 解析、校验失败等请求错误的响应中没有 data
+	executed bool
+}
+
+// MarshalJSON 按照规范输出 {"data": ..., "errors": [...]}。
+func (r *Result) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	if r.executed {
+		data, err := json.Marshal(r.Data)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(`"data":`)
+		buf.Write(data)
+	}
+	if len(r.Errors) > 0 {
+		errs, err := json.Marshal(r.Errors)
+		if err != nil {
+			return nil, err
+		}
+		if r.executed {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(`"errors":`)
+		buf.Write(errs)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// requestError 返回没有进入执行阶段的结果。
+func requestError(err error) *Result {
+	if e, ok := err.(*Error); ok {
+		return &Result{Errors: []*Error{e}}
+	}
+	return &Result{Errors: []*Error{{Message: err.Error()}}}
+}
+
+// Do 执行一次 GraphQL 请求。
+func Do(p Params) *Result {
+	doc, err := parse(p.Query)
+	if err != nil {
+		return requestError(err)
+	}
+	op, err := selectOperation(doc, p.OperationName)
+	if err != nil {
+		return requestError(err)
+	}
+
+	var root *Object
+	switch op.kind {
+	case "query":
+		root = p.Schema.Query
+	case "mutation":
+		if p.QueryOnly {
+			return requestError(validationError([]Location{op.loc}, "Can only perform a mutation operation from a POST request."))
+		}
+		root = p.Schema.Mutation
+		if root == nil {
+			return requestError(validationError([]Location{op.loc}, "Schema is not configured for mutations."))
+		}
+	default:
+		return requestError(validationError([]Location{op.loc}, "Subscriptions are not supported."))
+	}
+
+	maxFields := p.MaxFields
+	if maxFields <= 0 {
+		maxFields = DefaultMaxFields
+	}
+	// 深度和字段数在展开片段的过程中检查，超过上限时立即停止，不会生成完整的执行计划
+	pl := newPlanner(p.Schema, doc, p.MaxDepth, maxFields)
+	fields := pl.plan(op, root, p.Variables)
+	if pl.limit != nil {
+		return requestError(pl.limit)
+	}
+	if len(pl.errors) > 0 {
+		return &Result{Errors: pl.errors}
+	}
+	if p.MaxComplexity > 0 {
+		if complexity := selectionComplexity(fields); complexity > p.MaxComplexity {
+			return requestError(&Error{
+				Message:    fmt.Sprintf("Query complexity %d exceeds the maximum allowed complexity of %d.", complexity, p.MaxComplexity),
+				Locations:  []Location{op.loc},
+				Extensions: map[string]interface{}{"code": CodeTooComplex, "complexity": complexity, "maxComplexity": p.MaxComplexity},
+			})
+		}
+	}
+
+	ctx := p.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	e := &executor{ctx: ctx, schema: p.Schema, present: p.PresentError}
+	data := e.run(root, fields, op.kind == "mutation")
+	return &Result{Data: data, Errors: e.errors, executed: true}
+}
+
+// selectOperation 选择要执行的操作。
+func selectOperation(doc *document, name string) (*operation, error) {
+	if len(doc.operations) == 0 {
+		return nil, validationError(nil, "Must provide an operation.")
+	}
+	if name == "" {
+		if len(doc.operations) > 1 {
+			return nil, validationError(nil, "Must provide operation name if query contains multiple operations.")
+		}
+		return doc.operations[0], nil
+	}
+	var found *operation
+	for _, op := range doc.operations {
+		if op.name == name {
+			if found != nil {
+				return nil, validationError([]Location{op.loc}, "There can be only one operation named %q.", name)
+			}
+			found = op
+		}
+	}
+	if found == nil {
+		return nil, validationError(nil, "Unknown operation named %q.", name)
+	}
+	return found, nil
+}
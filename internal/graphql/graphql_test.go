@@ -0,0 +1,425 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 测试使用的模式：用户可以有好友和文章，文章有作者。数据保存在 map 中，字段默认按名称取值。
+var (
+	testUsers = map[string]map[string]interface{}{
+		"1": {"id": "1", "name": "alice"},
+		"2": {"id": "2", "name": "bob"},
+	}
+	testPosts = []map[string]interface{}{
+		{"id": "10", "title": "Hello", "authorID": "1"},
+		{"id": "11", "title": "World", "authorID": "2"},
+		{"id": "12", "title": "Again", "authorID": "1"},
+	}
+)
+
+func init() {
+	testUsers["1"]["friend"] = testUsers["2"]
+	testUsers["2"]["friend"] = testUsers["1"]
+}
+
+// newTestSchema 创建测试模式。batches 记录作者 Loader 每次批量加载的键，为 nil 时不使用 Loader。
+func newTestSchema(t *testing.T, batches *[][]uint) *Schema {
+	t.Helper()
+	var authors *Loader
+	if batches != nil {
+		authors = NewLoader(func(keys []uint) (map[uint]interface{}, error) {
+			*batches = append(*batches, keys)
+			result := map[uint]interface{}{}
+			for _, key := range keys {
+				result[key] = testUsers[fmt.Sprint(key)]
+			}
+			return result, nil
+		})
+	}
+
+	user := &Object{Name: "User"}
+	post := &Object{Name: "Post"}
+	user.Fields = []*Field{
+		{Name: "id", Type: NonNullOf(ID)},
+		{Name: "name", Type: String},
+		{Name: "friend", Type: user},
+		{Name: "fail", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return nil, errors.New("boom")
+		}},
+		{Name: "failNonNull", Type: NonNullOf(String), Resolve: func(p ResolveParams) (interface{}, error) {
+			return nil, errors.New("boom")
+		}},
+	}
+	post.Fields = []*Field{
+		{Name: "id", Type: NonNullOf(ID)},
+		{Name: "title", Type: String},
+		{Name: "author", Type: user, Resolve: func(p ResolveParams) (interface{}, error) {
+			id := p.Source.(map[string]interface{})["authorID"].(string)
+			if authors != nil {
+				var key uint
+				fmt.Sscan(id, &key)
+				return authors.Load(key), nil
+			}
+			return testUsers[id], nil
+		}},
+	}
+	query := &Object{Name: "Query", Fields: []*Field{
+		{Name: "hello", Type: String, Args: []*Argument{{Name: "name", Type: String, DefaultValue: "world"}},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				return "hello " + p.Args["name"].(string), nil
+			}},
+		{Name: "user", Type: user, Args: []*Argument{{Name: "id", Type: NonNullOf(ID)}},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				if u, ok := testUsers[p.Args["id"].(string)]; ok {
+					return u, nil
+				}
+				return nil, nil
+			}},
+		{Name: "posts", Type: NonNullOf(ListOf(NonNullOf(post))), Args: []*Argument{{Name: "first", Type: Int, DefaultValue: 10}},
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				first := p.Args["first"].(int)
+				if first > len(testPosts) {
+					first = len(testPosts)
+				}
+				return testPosts[:first], nil
+			},
+			Complexity: func(args map[string]interface{}, child int) int {
+				return 1 + args["first"].(int)*child
+			}},
+	}}
+	mutation := &Object{Name: "Mutation", Fields: []*Field{
+		{Name: "echo", Type: String, Args: []*Argument{{Name: "msg", Type: NonNullOf(String)}},
+			Resolve: func(p ResolveParams) (interface{}, error) { return p.Args["msg"], nil }},
+	}}
+	schema, err := NewSchema(query, mutation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+// run 执行查询并返回 JSON 格式的结果。
+func run(t *testing.T, p Params) string {
+	t.Helper()
+	if p.Schema == nil {
+		p.Schema = newTestSchema(t, nil)
+	}
+	data, err := json.Marshal(Do(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// assertJSON 比较两个 JSON 文本是否等价。
+func assertJSON(t *testing.T, got, want string) {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal([]byte(got), &g); err != nil {
+		t.Fatalf("invalid JSON %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("invalid expected JSON %s: %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+}
+
+// errorCodes 返回结果中每个错误的 extensions.code。
+func errorCodes(r *Result) []string {
+	var codes []string
+	for _, e := range r.Errors {
+		code, _ := e.Extensions["code"].(string)
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]interface{}
+		want  string
+	}{
+		{"default argument", `{ hello }`, nil, `{"data":{"hello":"hello world"}}`},
+		{"literal argument", `{ hello(name: "go") }`, nil, `{"data":{"hello":"hello go"}}`},
+		{"variable", `query Q($n: String) { hello(name: $n) }`, map[string]interface{}{"n": "vars"},
+			`{"data":{"hello":"hello vars"}}`},
+		{"variable default", `query Q($n: String = "dflt") { hello(name: $n) }`, nil,
+			`{"data":{"hello":"hello dflt"}}`},
+		{"aliases", `{ a: hello(name: "a") b: hello(name: "b") }`, nil,
+			`{"data":{"a":"hello a","b":"hello b"}}`},
+		{"nested objects", `{ user(id: 1) { name friend { name } } }`, nil,
+			`{"data":{"user":{"name":"alice","friend":{"name":"bob"}}}}`},
+		{"null object", `{ user(id: "9") { name } }`, nil, `{"data":{"user":null}}`},
+		{"list", `{ posts(first: 2) { id title } }`, nil,
+			`{"data":{"posts":[{"id":"10","title":"Hello"},{"id":"11","title":"World"}]}}`},
+		{"fragments", `{ user(id: 1) { ...U ... on User { id } } } fragment U on User { name }`, nil,
+			`{"data":{"user":{"name":"alice","id":"1"}}}`},
+		{"merged fields", `{ user(id: 1) { friend { name } friend { id } } }`, nil,
+			`{"data":{"user":{"friend":{"name":"bob","id":"2"}}}}`},
+		{"skip and include", `query Q($t: Boolean!) { a: hello @skip(if: $t) b: hello @include(if: $t) c: hello @include(if: false) }`,
+			map[string]interface{}{"t": true}, `{"data":{"b":"hello world"}}`},
+		{"typename", `{ __typename user(id: 2) { __typename } }`, nil,
+			`{"data":{"__typename":"Query","user":{"__typename":"User"}}}`},
+		{"mutation", `mutation { echo(msg: "hi") }`, nil, `{"data":{"echo":"hi"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertJSON(t, run(t, Params{Query: tt.query, Variables: tt.vars}), tt.want)
+		})
+	}
+}
+
+func TestFieldErrors(t *testing.T) {
+	// 可空字段出错时为 null，其他字段不受影响
+	assertJSON(t, run(t, Params{Query: `{ user(id: 1) { name fail } }`}),
+		`{"data":{"user":{"name":"alice","fail":null}},"errors":[{"message":"boom","locations":[{"line":1,"column":22}],"path":["user","fail"]}]}`)
+	// 非空字段出错时 null 传递到最近的可空字段
+	assertJSON(t, run(t, Params{Query: `{ user(id: 1) { name failNonNull } }`}),
+		`{"data":{"user":null},"errors":[{"message":"boom","locations":[{"line":1,"column":22}],"path":["user","failNonNull"]}]}`)
+	// PresentError 决定错误的内容
+	got := run(t, Params{Query: `{ user(id: 1) { fail } }`, PresentError: func(err error) *Error {
+		return &Error{Message: "presented: " + err.Error(), Extensions: map[string]interface{}{"code": "custom"}}
+	}})
+	assertJSON(t, got, `{"data":{"user":{"fail":null}},"errors":[{"message":"presented: boom","locations":[{"line":1,"column":17}],"path":["user","fail"],"extensions":{"code":"custom"}}]}`)
+}
+
+func TestRequestErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		vars      map[string]interface{}
+		queryOnly bool
+		code      string
+		message   string // 第一个错误消息中应包含的内容
+	}{
+		{"syntax", `{ hello `, nil, false, CodeParseFailed, "Syntax Error"},
+		{"no operation", `fragment F on User { id }`, nil, false, CodeValidationFailed, "Must provide an operation"},
+		{"unknown field", `{ nope }`, nil, false, CodeValidationFailed, `Cannot query field "nope" on type "Query"`},
+		{"missing selection", `{ user(id: 1) }`, nil, false, CodeValidationFailed, "must have a selection of subfields"},
+		{"selection on scalar", `{ hello { x } }`, nil, false, CodeValidationFailed, "must not have a selection"},
+		{"missing argument", `{ user { id } }`, nil, false, CodeValidationFailed, `Argument "id" of required type "ID!"`},
+		{"unknown argument", `{ hello(x: 1) }`, nil, false, CodeValidationFailed, `Unknown argument "x"`},
+		{"bad argument", `{ posts(first: "x") { id } }`, nil, false, CodeValidationFailed, `Argument "first"`},
+		{"undefined variable", `{ hello(name: $n) }`, nil, false, CodeValidationFailed, `Variable "$n" is not defined`},
+		{"missing variable", `query Q($id: ID!) { user(id: $id) { id } }`, nil, false, CodeValidationFailed, "was not provided"},
+		{"invalid variable", `query Q($n: Int) { posts(first: $n) { id } }`, map[string]interface{}{"n": "x"}, false,
+			CodeValidationFailed, `Variable "$n" got invalid value`},
+		{"wrong variable type", `query Q($n: String) { posts(first: $n) { id } }`, nil, false,
+			CodeValidationFailed, `used in position expecting type "Int"`},
+		{"conflicting fields", `{ user(id: 1) { x: name x: id } }`, nil, false, CodeValidationFailed, "are different fields"},
+		{"unknown fragment", `{ user(id: 1) { ...F } }`, nil, false, CodeValidationFailed, `Unknown fragment "F"`},
+		{"wrong fragment type", `{ user(id: 1) { ...F } } fragment F on Post { id }`, nil, false,
+			CodeValidationFailed, "can never be of type"},
+		{"unknown directive", `{ hello @nope }`, nil, false, CodeValidationFailed, `Unknown directive "@nope"`},
+		{"mutation over GET", `mutation { echo(msg: "x") }`, nil, true, CodeValidationFailed, "Can only perform a mutation"},
+		{"subscription", `subscription { hello }`, nil, false, CodeValidationFailed, "Subscriptions are not supported"},
+	}
+	schema := newTestSchema(t, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Do(Params{Schema: schema, Query: tt.query, Variables: tt.vars, QueryOnly: tt.queryOnly})
+			if r.executed {
+				t.Fatal("request with errors was executed")
+			}
+			if len(r.Errors) == 0 {
+				t.Fatal("no errors")
+			}
+			if codes := errorCodes(r); codes[0] != tt.code {
+				t.Errorf("code = %v, want %s", codes, tt.code)
+			}
+			if !strings.Contains(r.Errors[0].Message, tt.message) {
+				t.Errorf("message = %q, want it to contain %q", r.Errors[0].Message, tt.message)
+			}
+			data, _ := json.Marshal(r)
+			if strings.Contains(string(data), `"data"`) {
+				t.Errorf("request error response contains data: %s", data)
+			}
+		})
+	}
+}
+
+func TestFragmentCycles(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		message string
+	}{
+		{"self", `{ user(id: 1) { ...A } } fragment A on User { ...A }`,
+			`Cannot spread fragment "A" within itself.`},
+		{"self through field", `{ user(id: 1) { ...A } } fragment A on User { friend { ...A } }`,
+			`Cannot spread fragment "A" within itself.`},
+		{"indirect", `{ user(id: 1) { ...A } } fragment A on User { friend { ...B } } fragment B on User { friend { ...A } }`,
+			`Cannot spread fragment "A" within itself via "B".`},
+		{"three fragments", `{ user(id: 1) { ...A } }
+			fragment A on User { ... on User { ...B } }
+			fragment B on User { friend { ...C } }
+			fragment C on User { name ...A }`,
+			`Cannot spread fragment "A" within itself via "B", "C".`},
+		{"unused cycle", `{ hello } fragment A on User { ...B } fragment B on User { ...A }`,
+			`Cannot spread fragment "A" within itself via "B".`},
+	}
+	schema := newTestSchema(t, nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Do(Params{Schema: schema, Query: tt.query})
+			if len(r.Errors) != 1 {
+				t.Fatalf("errors = %v, want exactly one", r.Errors)
+			}
+			if r.Errors[0].Message != tt.message {
+				t.Errorf("message = %q, want %q", r.Errors[0].Message, tt.message)
+			}
+		})
+	}
+
+	// 同一个片段在不同位置使用多次不是循环
+	got := run(t, Params{Schema: schema, Query: `{ user(id: 1) { ...N friend { ...N } } } fragment N on User { name }`})
+	assertJSON(t, got, `{"data":{"user":{"name":"alice","friend":{"name":"bob"}}}}`)
+}
+
+func TestMaxDepth(t *testing.T) {
+	schema := newTestSchema(t, nil)
+	query := `{ user(id: 1) { friend { friend { name } } } }` // 深度为 4
+
+	r := Do(Params{Schema: schema, Query: query, MaxDepth: 4})
+	if len(r.Errors) > 0 {
+		t.Fatalf("depth 4 rejected: %v", r.Errors[0])
+	}
+	r = Do(Params{Schema: schema, Query: query, MaxDepth: 3})
+	if codes := errorCodes(r); !reflect.DeepEqual(codes, []string{CodeTooDeep}) {
+		t.Fatalf("codes = %v, want %s", codes, CodeTooDeep)
+	}
+	if r.executed {
+		t.Error("query exceeding the depth was executed")
+	}
+
+	// 通过片段达到的深度同样计入
+	r = Do(Params{Schema: schema, Query: `{ user(id: 1) { ...F } } fragment F on User { friend { friend { name } } }`, MaxDepth: 3})
+	if codes := errorCodes(r); !reflect.DeepEqual(codes, []string{CodeTooDeep}) {
+		t.Errorf("fragment: codes = %v, want %s", codes, CodeTooDeep)
+	}
+
+	// 内省字段不计入深度
+	r = Do(Params{Schema: schema, Query: `{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`, MaxDepth: 1})
+	if len(r.Errors) > 0 {
+		t.Errorf("introspection rejected: %v", r.Errors[0])
+	}
+}
+
+func TestMaxComplexity(t *testing.T) {
+	schema := newTestSchema(t, nil)
+	// posts: 1 + first * (id + author(1 + name)) = 1 + 3 * 3 = 10
+	query := `{ posts(first: 3) { id author { name } } }`
+	if r := Do(Params{Schema: schema, Query: query, MaxComplexity: 10}); len(r.Errors) > 0 {
+		t.Fatalf("complexity 10 rejected: %v", r.Errors[0])
+	}
+	r := Do(Params{Schema: schema, Query: query, MaxComplexity: 9})
+	if codes := errorCodes(r); !reflect.DeepEqual(codes, []string{CodeTooComplex}) {
+		t.Fatalf("codes = %v, want %s", codes, CodeTooComplex)
+	}
+	if got := r.Errors[0].Extensions["complexity"]; got != 10 {
+		t.Errorf("complexity = %v, want 10", got)
+	}
+}
+
+// fragmentChain 返回一个由 n 个片段组成的查询，每个片段在两个别名下引用下一个片段，
+// 展开后的字段数为 2^(n+1) 左右，而查询文本只随 n 线性增长。
+func fragmentChain(n int, aliased bool) string {
+	var b strings.Builder
+	b.WriteString("{ user(id: 1) { ...F0 } }\n")
+	for i := 0; i < n; i++ {
+		if aliased {
+			fmt.Fprintf(&b, "fragment F%d on User { a: friend { ...F%d } b: friend { ...F%d } }\n", i, i+1, i+1)
+		} else {
+			fmt.Fprintf(&b, "fragment F%d on User { friend { ...F%d } friend { ...F%d } }\n", i, i+1, i+1)
+		}
+	}
+	fmt.Fprintf(&b, "fragment F%d on User { name }\n", n)
+	return b.String()
+}
+
+func TestMaxFields(t *testing.T) {
+	schema := newTestSchema(t, nil)
+
+	// 展开后超过上限的查询在展开过程中被拒绝，不会生成完整的执行计划
+	start := time.Now()
+	r := Do(Params{Schema: schema, Query: fragmentChain(40, true)})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("fragment chain took %s", elapsed)
+	}
+	if codes := errorCodes(r); !reflect.DeepEqual(codes, []string{CodeTooLarge}) {
+		t.Fatalf("codes = %v, want %s", codes, CodeTooLarge)
+	}
+	if got := r.Errors[0].Extensions["maxFields"]; got != DefaultMaxFields {
+		t.Errorf("maxFields = %v, want %d", got, DefaultMaxFields)
+	}
+
+	// 上限可以调整，字段数包括内省字段
+	r = Do(Params{Schema: schema, Query: `{ __typename user(id: 1) { name } }`, MaxFields: 2})
+	if codes := errorCodes(r); !reflect.DeepEqual(codes, []string{CodeTooLarge}) {
+		t.Errorf("codes = %v, want %s", codes, CodeTooLarge)
+	}
+	if r := Do(Params{Schema: schema, Query: `{ __typename user(id: 1) { name } }`, MaxFields: 3}); len(r.Errors) > 0 {
+		t.Errorf("3 fields rejected: %v", r.Errors[0])
+	}
+
+	// 没有别名时同名字段合并，同一个片段在一个选择集中只展开一次，字段数随片段数线性增长
+	start = time.Now()
+	r = Do(Params{Schema: schema, Query: fragmentChain(40, false), MaxFields: 100})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("merged fragment chain took %s", elapsed)
+	}
+	if len(r.Errors) > 0 {
+		t.Errorf("merged fragment chain rejected: %v", r.Errors[0])
+	}
+}
+
+func TestNestingLimit(t *testing.T) {
+	tests := map[string]string{
+		"selection sets": strings.Repeat("{ user(id: 1) ", 200) + strings.Repeat("}", 200),
+		"lists":          `{ hello(name: ` + strings.Repeat("[", 200) + strings.Repeat("]", 200) + `) }`,
+		"types":          `query Q($v: ` + strings.Repeat("[", 200) + "Int" + strings.Repeat("]", 200) + `) { hello }`,
+	}
+	schema := newTestSchema(t, nil)
+	for name, query := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := Do(Params{Schema: schema, Query: query})
+			if codes := errorCodes(r); !reflect.DeepEqual(codes, []string{CodeParseFailed}) {
+				t.Errorf("codes = %v, want %s", codes, CodeParseFailed)
+			}
+		})
+	}
+}
+
+func TestLoaderBatching(t *testing.T) {
+	var batches [][]uint
+	schema := newTestSchema(t, &batches)
+	got := run(t, Params{Schema: schema, Query: `{ posts { title author { name } } }`})
+	assertJSON(t, got, `{"data":{"posts":[
+		{"title":"Hello","author":{"name":"alice"}},
+		{"title":"World","author":{"name":"bob"}},
+		{"title":"Again","author":{"name":"alice"}}]}}`)
+	// 三篇文章的作者只批量加载一次，重复的键只加载一次
+	if !reflect.DeepEqual(batches, [][]uint{{1, 2}}) {
+		t.Errorf("batches = %v, want [[1 2]]", batches)
+	}
+}
+
+func TestIntrospection(t *testing.T) {
+	got := run(t, Params{Query: `{ __type(name: "User") { name kind fields { name type { kind name ofType { name } } } } }`})
+	assertJSON(t, got, `{"data":{"__type":{"name":"User","kind":"OBJECT","fields":[
+		{"name":"id","type":{"kind":"NON_NULL","name":null,"ofType":{"name":"ID"}}},
+		{"name":"name","type":{"kind":"SCALAR","name":"String","ofType":null}},
+		{"name":"friend","type":{"kind":"OBJECT","name":"User","ofType":null}},
+		{"name":"fail","type":{"kind":"SCALAR","name":"String","ofType":null}},
+		{"name":"failNonNull","type":{"kind":"NON_NULL","name":null,"ofType":{"name":"String"}}}]}}}`)
+}
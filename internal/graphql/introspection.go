@@ -0,0 +1,289 @@
+package graphql
+
+// 内省 (https://spec.graphql.org/October2021/#sec-Introspection)。
+// 内省类型也是普通的对象类型，字段的父对象分别是 *Schema、Type、*Field、*Argument、*EnumValue 和 *directiveDef。
+
+// directiveDef 是指令的定义。
+type directiveDef struct {
+	name        string
+	description string
+	locations   []string
+	args        []*Argument
+}
+
+// directives 是支持的指令。@deprecated 只用于在内省结果中说明字段和枚举值已废弃，不能在查询中使用。
+var directives = []*directiveDef{
+	{
+		name:        "include",
+		description: "Directs the executor to include this field or fragment only when the `if` argument is true.",
+		locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		args:        []*Argument{{Name: "if", Description: "Included when true.", Type: NonNullOf(Boolean)}},
+	},
+	{
+		name:        "skip",
+		description: "Directs the executor to skip this field or fragment when the `if` argument is true.",
+		locations:   []string{"FIELD", "FRAGMENT_SPREAD", "INLINE_FRAGMENT"},
+		args:        []*Argument{{Name: "if", Description: "Skipped when true.", Type: NonNullOf(Boolean)}},
+	},
+	{
+		name:        "deprecated",
+		description: "Marks an element of a GraphQL schema as no longer supported.",
+		locations:   []string{"FIELD_DEFINITION", "ARGUMENT_DEFINITION", "INPUT_FIELD_DEFINITION", "ENUM_VALUE"},
+		args:        []*Argument{{Name: "reason", Description: "Explains why this element was deprecated.", Type: String, DefaultValue: "No longer supported"}},
+	},
+}
+
+// findDirective 返回可以在查询中使用的指令。
+func findDirective(name string) *directiveDef {
+	if name == "deprecated" {
+		return nil
+	}
+	for _, d := range directives {
+		if d.name == name {
+			return d
+		}
+	}
+	return nil
+}
+
+// 内省类型，字段在 init 中设置，因为它们互相引用。
+var (
+	schemaType      = &Object{Name: "__Schema", Description: "A GraphQL Schema defines the capabilities of a GraphQL server. It exposes all available types and directives on the server, as well as the entry points for query and mutation operations."}
+	typeType        = &Object{Name: "__Type", Description: "The fundamental unit of any GraphQL Schema is the type."}
+	fieldType       = &Object{Name: "__Field", Description: "Object and Interface types are described by a list of Fields, each of which has a name, potentially a list of arguments, and a return type."}
+	inputValueType  = &Object{Name: "__InputValue", Description: "Arguments provided to Fields or Directives and the input fields of an InputObject are represented as Input Values which describe their type and optionally a default value."}
+	enumValueType   = &Object{Name: "__EnumValue", Description: "One possible value for a given Enum."}
+	directiveType   = &Object{Name: "__Directive", Description: "A Directive provides a way to describe alternate runtime execution and type validation behavior in a GraphQL document."}
+	typeKindType    = newStringEnum("__TypeKind", "An enum describing what kind of type a given `__Type` is.", "SCALAR", "OBJECT", "INTERFACE", "UNION", "ENUM", "INPUT_OBJECT", "LIST", "NON_NULL")
+	dirLocationType = newStringEnum("__DirectiveLocation", "A Directive can be adjacent to many parts of the GraphQL language, a __DirectiveLocation describes one such possible adjacencies.",
+		"QUERY", "MUTATION", "SUBSCRIPTION", "FIELD", "FRAGMENT_DEFINITION", "FRAGMENT_SPREAD", "INLINE_FRAGMENT", "VARIABLE_DEFINITION",
+		"SCHEMA", "SCALAR", "OBJECT", "FIELD_DEFINITION", "ARGUMENT_DEFINITION", "INTERFACE", "UNION", "ENUM", "ENUM_VALUE", "INPUT_OBJECT", "INPUT_FIELD_DEFINITION")
+
+	// 内省使用的特殊字段，__schema 和 __type 只能在根查询类型上使用
+	typenameField = &Field{Name: "__typename", Description: "The name of the current Object type at runtime.", Type: NonNullOf(String)}
+	schemaField   = &Field{Name: "__schema", Description: "Access the current type schema of this server.", Type: NonNullOf(schemaType)}
+	typeField     = &Field{Name: "__type", Description: "Request the type information of a single type.", Type: typeType,
+		Args: []*Argument{{Name: "name", Type: NonNullOf(String)}}}
+)
+
+// newStringEnum 创建值与名称相同的枚举类型。
+func newStringEnum(name, description string, values ...string) *Enum {
+	t := &Enum{Name: name, Description: description}
+	for _, v := range values {
+		t.Values = append(t.Values, &EnumValue{Name: v, Value: v})
+	}
+	return t
+}
+
+// includeDeprecatedArg 是列出字段、参数和枚举值时是否包含已废弃项的参数。
+func includeDeprecatedArg() []*Argument {
+	return []*Argument{{Name: "includeDeprecated", Type: Boolean, DefaultValue: false}}
+}
+
+// nullableString 将空字符串转换为 null，用于可选的描述等字段。
+func nullableString(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func init() {
+	schemaType.Fields = []*Field{
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) { return nil, nil }},
+		{Name: "types", Description: "A list of all types supported by this server.", Type: NonNullOf(ListOf(NonNullOf(typeType))),
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				s := p.Source.(*Schema)
+				types := make([]Type, 0, len(s.types))
+				for _, name := range s.typeNames() {
+					types = append(types, s.types[name])
+				}
+				return types, nil
+			}},
+		{Name: "queryType", Description: "The type that query operations will be rooted at.", Type: NonNullOf(typeType),
+			Resolve: func(p ResolveParams) (interface{}, error) { return p.Source.(*Schema).Query, nil }},
+		{Name: "mutationType", Description: "If this server supports mutation, the type that mutation operations will be rooted at.", Type: typeType,
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				if m := p.Source.(*Schema).Mutation; m != nil {
+					return m, nil
+				}
+				return nil, nil
+			}},
+		{Name: "subscriptionType", Description: "If this server support subscription, the type that subscription operations will be rooted at.", Type: typeType,
+			Resolve: func(p ResolveParams) (interface{}, error) { return nil, nil }},
+		{Name: "directives", Description: "A list of all directives supported by this server.", Type: NonNullOf(ListOf(NonNullOf(directiveType))),
+			Resolve: func(p ResolveParams) (interface{}, error) { return directives, nil }},
+	}
+
+	typeType.Fields = []*Field{
+		{Name: "kind", Type: NonNullOf(typeKindType), Resolve: func(p ResolveParams) (interface{}, error) {
+			switch p.Source.(type) {
+			case *Scalar:
+				return "SCALAR", nil
+			case *Object:
+				return "OBJECT", nil
+			case *Enum:
+				return "ENUM", nil
+			case *InputObject:
+				return "INPUT_OBJECT", nil
+			case *List:
+				return "LIST", nil
+			case *NonNull:
+				return "NON_NULL", nil
+			}
+			return nil, nil
+		}},
+		{Name: "name", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			switch p.Source.(type) {
+			case *List, *NonNull:
+				return nil, nil
+			}
+			return p.Source.(Type).String(), nil
+		}},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			switch t := p.Source.(type) {
+			case *Scalar:
+				return nullableString(t.Description), nil
+			case *Object:
+				return nullableString(t.Description), nil
+			case *Enum:
+				return nullableString(t.Description), nil
+			case *InputObject:
+				return nullableString(t.Description), nil
+			}
+			return nil, nil
+		}},
+		{Name: "specifiedByURL", Type: String, Resolve: func(p ResolveParams) (interface{}, error) { return nil, nil }},
+		{Name: "fields", Type: ListOf(NonNullOf(fieldType)), Args: includeDeprecatedArg(), Resolve: func(p ResolveParams) (interface{}, error) {
+			t, ok := p.Source.(*Object)
+			if !ok {
+				return nil, nil
+			}
+			all, _ := p.Args["includeDeprecated"].(bool)
+			fields := []*Field{}
+			for _, f := range t.Fields {
+				if all || f.DeprecationReason == "" {
+					fields = append(fields, f)
+				}
+			}
+			return fields, nil
+		}},
+		{Name: "interfaces", Type: ListOf(NonNullOf(typeType)), Resolve: func(p ResolveParams) (interface{}, error) {
+			if _, ok := p.Source.(*Object); ok {
+				return []Type{}, nil
+			}
+			return nil, nil
+		}},
+		{Name: "possibleTypes", Type: ListOf(NonNullOf(typeType)), Resolve: func(p ResolveParams) (interface{}, error) { return nil, nil }},
+		{Name: "enumValues", Type: ListOf(NonNullOf(enumValueType)), Args: includeDeprecatedArg(), Resolve: func(p ResolveParams) (interface{}, error) {
+			t, ok := p.Source.(*Enum)
+			if !ok {
+				return nil, nil
+			}
+			all, _ := p.Args["includeDeprecated"].(bool)
+			values := []*EnumValue{}
+			for _, v := range t.Values {
+				if all || v.DeprecationReason == "" {
+					values = append(values, v)
+				}
+			}
+			return values, nil
+		}},
+		{Name: "inputFields", Type: ListOf(NonNullOf(inputValueType)), Args: includeDeprecatedArg(), Resolve: func(p ResolveParams) (interface{}, error) {
+			t, ok := p.Source.(*InputObject)
+			if !ok {
+				return nil, nil
+			}
+			all, _ := p.Args["includeDeprecated"].(bool)
+			return filterArgs(t.Fields, all), nil
+		}},
+		{Name: "ofType", Type: typeType, Resolve: func(p ResolveParams) (interface{}, error) {
+			switch t := p.Source.(type) {
+			case *List:
+				return t.OfType, nil
+			case *NonNull:
+				return t.OfType, nil
+			}
+			return nil, nil
+		}},
+	}
+
+	fieldType.Fields = []*Field{
+		{Name: "name", Type: NonNullOf(String), Resolve: func(p ResolveParams) (interface{}, error) { return p.Source.(*Field).Name, nil }},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return nullableString(p.Source.(*Field).Description), nil
+		}},
+		{Name: "args", Type: NonNullOf(ListOf(NonNullOf(inputValueType))), Args: includeDeprecatedArg(), Resolve: func(p ResolveParams) (interface{}, error) {
+			all, _ := p.Args["includeDeprecated"].(bool)
+			return filterArgs(p.Source.(*Field).Args, all), nil
+		}},
+		{Name: "type", Type: NonNullOf(typeType), Resolve: func(p ResolveParams) (interface{}, error) { return p.Source.(*Field).Type, nil }},
+		{Name: "isDeprecated", Type: NonNullOf(Boolean), Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*Field).DeprecationReason != "", nil
+		}},
+		{Name: "deprecationReason", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return nullableString(p.Source.(*Field).DeprecationReason), nil
+		}},
+	}
+
+	inputValueType.Fields = []*Field{
+		{Name: "name", Type: NonNullOf(String), Resolve: func(p ResolveParams) (interface{}, error) { return p.Source.(*Argument).Name, nil }},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return nullableString(p.Source.(*Argument).Description), nil
+		}},
+		{Name: "type", Type: NonNullOf(typeType), Resolve: func(p ResolveParams) (interface{}, error) { return p.Source.(*Argument).Type, nil }},
+		{Name: "defaultValue", Description: "A GraphQL-formatted string representing the default value for this input value.", Type: String,
+			Resolve: func(p ResolveParams) (interface{}, error) {
+				arg := p.Source.(*Argument)
+				if arg.DefaultValue == nil {
+					return nil, nil
+				}
+				return printValue(arg.DefaultValue, arg.Type), nil
+			}},
+		{Name: "isDeprecated", Type: NonNullOf(Boolean), Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*Argument).DeprecationReason != "", nil
+		}},
+		{Name: "deprecationReason", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return nullableString(p.Source.(*Argument).DeprecationReason), nil
+		}},
+	}
+
+	enumValueType.Fields = []*Field{
+		{Name: "name", Type: NonNullOf(String), Resolve: func(p ResolveParams) (interface{}, error) { return p.Source.(*EnumValue).Name, nil }},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return nullableString(p.Source.(*EnumValue).Description), nil
+		}},
+		{Name: "isDeprecated", Type: NonNullOf(Boolean), Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*EnumValue).DeprecationReason != "", nil
+		}},
+		{Name: "deprecationReason", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return nullableString(p.Source.(*EnumValue).DeprecationReason), nil
+		}},
+	}
+
+	directiveType.Fields = []*Field{
+		{Name: "name", Type: NonNullOf(String), Resolve: func(p ResolveParams) (interface{}, error) { return p.Source.(*directiveDef).name, nil }},
+		{Name: "description", Type: String, Resolve: func(p ResolveParams) (interface{}, error) {
+			return nullableString(p.Source.(*directiveDef).description), nil
+		}},
+		{Name: "isRepeatable", Type: NonNullOf(Boolean), Resolve: func(p ResolveParams) (interface{}, error) { return false, nil }},
+		{Name: "locations", Type: NonNullOf(ListOf(NonNullOf(dirLocationType))), Resolve: func(p ResolveParams) (interface{}, error) {
+			return p.Source.(*directiveDef).locations, nil
+		}},
+		{Name: "args", Type: NonNullOf(ListOf(NonNullOf(inputValueType))), Args: includeDeprecatedArg(), Resolve: func(p ResolveParams) (interface{}, error) {
+			all, _ := p.Args["includeDeprecated"].(bool)
+			return filterArgs(p.Source.(*directiveDef).args, all), nil
+		}},
+	}
+}
+
+// filterArgs 返回参数列表，all 为 false 时排除已废弃的参数。
+func filterArgs(args []*Argument, all bool) []*Argument {
+	result := []*Argument{}
+	for _, arg := range args {
+		if all || arg.DeprecationReason == "" {
+			result = append(result, arg)
+		}
+	}
+	return result
+}
@@ -0,0 +1,333 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// tokenKind 是词法单元的种类。
+type tokenKind int
+
+const (
+	tokEOF    tokenKind = iota
+	tokPunct            // 标点：! $ & ( ) ... : = @ [ ] { | }
+	tokName             // 名称
+	tokInt              // 整数
+	tokFloat            // 浮点数
+	tokString           // 字符串（包括块字符串），value 是转义处理后的内容
+)
+
+// token 是一个词法单元。
+type token struct {
+	kind  tokenKind
+	value string
+	loc   Location
+}
+
+// describe 返回词法单元在错误消息中的描述。
+func (t token) describe() string {
+	switch t.kind {
+	case tokEOF:
+		return "<EOF>"
+	case tokString:
+		return strconv.Quote(t.value)
+	case tokName:
+		return fmt.Sprintf("Name %q", t.value)
+	}
+	return fmt.Sprintf("%q", t.value)
+}
+
+// lexer 将查询文本切分为词法单元 (https://spec.graphql.org/October2021/#sec-Language.Source-Text)。
+type lexer struct {
+	src       string
+	pos       int
+	line      int
+	lineStart int
+}
+
+func newLexer(src string) *lexer {
+	return &lexer{src: src, line: 1}
+}
+
+// location 返回当前位置的行号和列号，均从 1 开始。
+func (l *lexer) location() Location {
+	return Location{Line: l.line, Column: l.pos - l.lineStart + 1}
+}
+
+// syntaxError 返回当前位置的语法错误。
+func (l *lexer) syntaxError(format string, args ...interface{}) *Error {
+	return syntaxError(l.location(), format, args...)
+}
+
+// skipIgnored 跳过空白、逗号、换行、注释和 BOM，它们在 GraphQL 中都没有意义。
+func (l *lexer) skipIgnored() {
+	for l.pos < len(l.src) {
+		switch c := l.src[l.pos]; c {
+		case ' ', '\t', ',':
+			l.pos++
+		case '\n':
+			l.pos++
+			l.newline()
+		case '\r':
+			l.pos++
+			if l.pos < len(l.src) && l.src[l.pos] == '\n' {
+				l.pos++
+			}
+			l.newline()
+		case '#':
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' && l.src[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			if strings.HasPrefix(l.src[l.pos:], "\ufeff") {
+				l.pos += len("\ufeff")
+				continue
+			}
+			return
+		}
+	}
+}
+
+func (l *lexer) newline() {
+	l.line++
+	l.lineStart = l.pos
+}
+
+// next 返回下一个词法单元。
+func (l *lexer) next() (token, error) {
+	l.skipIgnored()
+	loc := l.location()
+	if l.pos >= len(l.src) {
+		return token{kind: tokEOF, loc: loc}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case strings.IndexByte("!$&():=@[]{}|", c) >= 0:
+		l.pos++
+		return token{kind: tokPunct, value: string(c), loc: loc}, nil
+	case c == '.':
+		if strings.HasPrefix(l.src[l.pos:], "...") {
+			l.pos += 3
+			return token{kind: tokPunct, value: "...", loc: loc}, nil
+		}
+		return token{}, l.syntaxError("Unexpected character \".\"")
+	case isNameStart(c):
+		start := l.pos
+		for l.pos < len(l.src) && isNameContinue(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokName, value: l.src[start:l.pos], loc: loc}, nil
+	case c == '-' || isDigit(c):
+		return l.number(loc)
+	case c == '"':
+		if strings.HasPrefix(l.src[l.pos:], `"""`) {
+			return l.blockString(loc)
+		}
+		return l.string(loc)
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos:])
+	return token{}, l.syntaxError("Unexpected character %q", r)
+}
+
+// number 读取整数或浮点数。
+func (l *lexer) number(loc Location) (token, error) {
+	start := l.pos
+	kind := tokInt
+	if l.src[l.pos] == '-' {
+		l.pos++
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '0' {
+		l.pos++
+		if l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			return token{}, l.syntaxError("Invalid number, unexpected digit after 0")
+		}
+	} else if err := l.digits(); err != nil {
+		return token{}, err
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		kind = tokFloat
+		l.pos++
+		if err := l.digits(); err != nil {
+			return token{}, err
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		kind = tokFloat
+		l.pos++
+		if l.pos < len(l.src) && (l.src[l.pos] == '+' || l.src[l.pos] == '-') {
+			l.pos++
+		}
+		if err := l.digits(); err != nil {
+			return token{}, err
+		}
+	}
+	// 数字后面不能紧跟名称或小数点，例如 1a、1.2.3
+	if l.pos < len(l.src) && (l.src[l.pos] == '.' || isNameStart(l.src[l.pos])) {
+		return token{}, l.syntaxError("Invalid number, unexpected character %q", l.src[l.pos])
+	}
+	return token{kind: kind, value: l.src[start:l.pos], loc: loc}, nil
+}
+
+// digits 读取至少一个数字。
+func (l *lexer) digits() error {
+	if l.pos >= len(l.src) || !isDigit(l.src[l.pos]) {
+		return l.syntaxError("Invalid number, expected digit")
+	}
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+	return nil
+}
+
+// string 读取普通字符串并处理转义。
+func (l *lexer) string(loc Location) (token, error) {
+	l.pos++ // 开头的引号
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '"':
+			l.pos++
+			return token{kind: tokString, value: b.String(), loc: loc}, nil
+		case c == '\n' || c == '\r':
+			return token{}, l.syntaxError("Unterminated string")
+		case c == '\\':
+			if l.pos+1 >= len(l.src) {
+				return token{}, l.syntaxError("Unterminated string")
+			}
+			esc := l.src[l.pos+1]
+			l.pos += 2
+			switch esc {
+			case '"', '\\', '/':
+				b.WriteByte(esc)
+			case 'b':
+				b.WriteByte('\b')
+			case 'f':
+				b.WriteByte('\f')
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case 'u':
+				r, err := l.unicodeEscape()
+				if err != nil {
+					return token{}, err
+				}
+				b.WriteRune(r)
+			default:
+				return token{}, l.syntaxError("Invalid character escape sequence \\%c", esc)
+			}
+		default:
+			r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+			b.WriteRune(r)
+			l.pos += size
+		}
+	}
+	return token{}, l.syntaxError("Unterminated string")
+}
+
+// unicodeEscape 读取 \u 之后的四位十六进制数，支持以两个转义表示的代理对。
+func (l *lexer) unicodeEscape() (rune, error) {
+	hex := func() (rune, error) {
+		if l.pos+4 > len(l.src) {
+			return 0, l.syntaxError("Invalid Unicode escape sequence")
+		}
+		n, err := strconv.ParseUint(l.src[l.pos:l.pos+4], 16, 32)
+		if err != nil {
+			return 0, l.syntaxError("Invalid Unicode escape sequence \\u%s", l.src[l.pos:l.pos+4])
+		}
+		l.pos += 4
+		return rune(n), nil
+	}
+	r, err := hex()
+	if err != nil {
+		return 0, err
+	}
+	if r >= 0xD800 && r <= 0xDBFF && strings.HasPrefix(l.src[l.pos:], `\u`) {
+		l.pos += 2
+		low, err := hex()
+		if err != nil {
+			return 0, err
+		}
+		if low < 0xDC00 || low > 0xDFFF {
+			return 0, l.syntaxError("Invalid Unicode escape sequence")
+		}
+		return (r-0xD800)<<10 + (low - 0xDC00) + 0x10000, nil
+	}
+	if r >= 0xD800 && r <= 0xDFFF {
+		return 0, l.syntaxError("Invalid Unicode escape sequence")
+	}
+	return r, nil
+}
+
+// blockString 读取块字符串 """..."""，按规范去除公共缩进和首尾的空行。
+func (l *lexer) blockString(loc Location) (token, error) {
+	l.pos += 3
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		switch {
+		case strings.HasPrefix(l.src[l.pos:], `"""`):
+			l.pos += 3
+			return token{kind: tokString, value: blockStringValue(b.String()), loc: loc}, nil
+		case strings.HasPrefix(l.src[l.pos:], `\"""`):
+			b.WriteString(`"""`)
+			l.pos += 4
+		default:
+			c := l.src[l.pos]
+			b.WriteByte(c)
+			l.pos++
+			if c == '\n' || (c == '\r' && (l.pos >= len(l.src) || l.src[l.pos] != '\n')) {
+				l.newline()
+			}
+		}
+	}
+	return token{}, l.syntaxError("Unterminated string")
+}
+
+// blockStringValue 实现了规范中的 BlockStringValue 算法。
+func blockStringValue(raw string) string {
+	raw = strings.ReplaceAll(raw, "\r\n", "\n")
+	raw = strings.ReplaceAll(raw, "\r", "\n")
+	lines := strings.Split(raw, "\n")
+
+	common := -1
+	for _, line := range lines[1:] {
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < len(line) && (common < 0 || indent < common) {
+			common = indent
+		}
+	}
+	if common > 0 {
+		for i := 1; i < len(lines); i++ {
+			if len(lines[i]) >= common {
+				lines[i] = lines[i][common:]
+			} else {
+				lines[i] = ""
+			}
+		}
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[0], " \t") == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimLeft(lines[len(lines)-1], " \t") == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z')
+}
+
+func isNameContinue(c byte) bool {
+	return isNameStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,71 @@
+package graphql
+
+// BatchFunc 根据一组键批量加载数据，返回键到值的映射，映射中没有的键加载结果为 nil。
+type BatchFunc func(keys []uint) (map[uint]interface{}, error)
+
+// Loader 将执行过程中对同一类数据的多次加载合并为批量查询（DataLoader 模式）。
+//
+// resolver 调用 Load 登记需要的键并返回 Thunk，执行器在同一层的字段都解析完后才对 Thunk 求值，
+// 第一个求值的 Thunk 会用所有已登记的键调用一次 BatchFunc。加载过的结果会被缓存，
+// 因此 Loader 应当为每个请求单独创建，不能在请求之间共享，也不是并发安全的。
+type Loader struct {
+	batch   BatchFunc
+	pending []uint
+	queued  map[uint]bool
+	results map[uint]interface{}
+	errs    map[uint]error
+}
+
+// NewLoader 创建一个 Loader。
+func NewLoader(batch BatchFunc) *Loader {
+	return &Loader{
+		batch:   batch,
+		queued:  map[uint]bool{},
+		results: map[uint]interface{}{},
+		errs:    map[uint]error{},
+	}
+}
+
+// Load 登记键 key 并返回获取其结果的 Thunk。
+func (l *Loader) Load(key uint) Thunk {
+	if !l.loaded(key) && !l.queued[key] {
+		l.queued[key] = true
+		l.pending = append(l.pending, key)
+	}
+	return func() (interface{}, error) {
+		if !l.loaded(key) {
+			l.dispatch()
+		}
+		if err := l.errs[key]; err != nil {
+			return nil, err
+		}
+		return l.results[key], nil
+	}
+}
+
+// loaded 判断键是否已经加载过。
+func (l *Loader) loaded(key uint) bool {
+	if _, ok := l.results[key]; ok {
+		return true
+	}
+	_, ok := l.errs[key]
+	return ok
+}
+
+// dispatch 批量加载所有已登记的键。
+func (l *Loader) dispatch() {
+	keys := l.pending
+	l.pending = nil
+	l.queued = map[uint]bool{}
+	if len(keys) == 0 {
+		return
+	}
+	values, err := l.batch(keys)
+	for _, key := range keys {
+		if err != nil {
+			l.errs[key] = err
+		} else {
+			l.results[key] = values[key]
+		}
+	}
+}
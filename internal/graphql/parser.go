@@ -0,0 +1,647 @@
+package graphql
+
+import (
+	"strconv"
+)
+
+// 以下是查询文档的语法树，只包含可执行的定义（操作和片段），不支持在查询中定义类型。
+
+// document 是解析后的查询文档。
+type document struct {
+	operations []*operation
+	fragments  map[string]*fragment
+}
+
+// operation 是一个操作：query、mutation 或 subscription。
+type operation struct {
+	kind       string // query、mutation 或 subscription
+	name       string
+	vars       []*varDef
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+// varDef 是操作中的变量定义，例如 $id: ID! = 1。
+type varDef struct {
+	name string
+	typ  *typeRef
+	def  *value // 默认值，没有默认值时为 nil
+	loc  Location
+}
+
+// typeRef 是查询中引用的类型，例如 [String!]!。
+type typeRef struct {
+	name    string   // 命名类型的名称，列表类型为空
+	elem    *typeRef // 列表的元素类型
+	nonNull bool
+}
+
+// String 返回类型在查询中的写法。
+func (t *typeRef) String() string {
+	s := t.name
+	if t.elem != nil {
+		s = "[" + t.elem.String() + "]"
+	}
+	if t.nonNull {
+		s += "!"
+	}
+	return s
+}
+
+// directive 是指令，例如 @include(if: $flag)。
+type directive struct {
+	name string
+	args []*argument
+	loc  Location
+}
+
+// argument 是字段或指令的参数。
+type argument struct {
+	name  string
+	value *value
+	loc   Location
+}
+
+// selection 是选择集中的一项：*fieldNode、*fragmentSpread 或 *inlineFragment。
+type selection interface{}
+
+// fieldNode 是选择集中的字段。
+type fieldNode struct {
+	alias      string
+	name       string
+	args       []*argument
+	directives []*directive
+	selections []selection
+	loc        Location
+}
+
+// responseKey 返回字段在响应中的键，有别名时使用别名。
+func (f *fieldNode) responseKey() string {
+	if f.alias != "" {
+		return f.alias
+	}
+	return f.name
+}
+
+// fragmentSpread 是对命名片段的引用，例如 ...PostFields。
+type fragmentSpread struct {
+	name       string
+	directives []*directive
+	loc        Location
+}
+
+// inlineFragment 是内联片段，例如 ... on Post { title }。
+type inlineFragment struct {
+	typeCondition string // 为空表示没有类型条件
+	directives    []*directive
+	selections    []selection
+	loc           Location
+}
+
+// fragment 是命名片段的定义。
+type fragment struct {
+	name          string
+	typeCondition string
+	directives    []*directive
+	selections    []selection
+	loc           Location
+}
+
+// valueKind 是字面量的种类。
+type valueKind int
+
+const (
+	valVariable valueKind = iota
+	valInt
+	valFloat
+	valString
+	valBoolean
+	valNull
+	valEnum
+	valList
+	valObject
+)
+
+// value 是查询中的字面量或变量引用。
+type value struct {
+	kind   valueKind
+	raw    string         // 变量名、数字、字符串内容、true/false 或枚举值的名称
+	list   []*value       // 列表的元素
+	fields []*objectField // 对象的字段
+	loc    Location
+}
+
+// objectField 是对象字面量中的一个字段。
+type objectField struct {
+	name  string
+	value *value
+	loc   Location
+}
+
+// maxNesting 是选择集、列表和对象字面量以及类型引用的最大嵌套层数。
+// 语法分析器和之后的校验都是递归实现的，限制层数避免畸形的查询耗尽栈空间。
+const maxNesting = 128
+
+// parser 是递归下降的语法分析器 (https://spec.graphql.org/October2021/#sec-Document)。
+type parser struct {
+	lex     *lexer
+	tok     token
+	nesting int // 当前的嵌套层数
+}
+
+// enter 进入一层嵌套，超过 maxNesting 时返回语法错误。离开时必须调用 leave。
+func (p *parser) enter() error {
+	p.nesting++
+	if p.nesting > maxNesting {
+		return syntaxError(p.tok.loc, "Document is nested more than %d levels deep.", maxNesting)
+	}
+	return nil
+}
+
+// leave 离开一层嵌套。
+func (p *parser) leave() {
+	p.nesting--
+}
+
+// parse 解析查询文档。
+func parse(src string) (*document, error) {
+	p := &parser{lex: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	doc := &document{fragments: map[string]*fragment{}}
+	if p.tok.kind == tokEOF {
+		return nil, syntaxError(p.tok.loc, "Unexpected <EOF>")
+	}
+	for p.tok.kind != tokEOF {
+		switch {
+		case p.peekPunct("{"):
+			loc := p.tok.loc
+			sels, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, &operation{kind: "query", selections: sels, loc: loc})
+		case p.tok.kind == tokName && (p.tok.value == "query" || p.tok.value == "mutation" || p.tok.value == "subscription"):
+			op, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.operations = append(doc.operations, op)
+		case p.tok.kind == tokName && p.tok.value == "fragment":
+			frag, err := p.fragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.fragments[frag.name]; ok {
+				return nil, validationError([]Location{frag.loc}, "There can be only one fragment named %q.", frag.name)
+			}
+			doc.fragments[frag.name] = frag
+		case p.tok.kind == tokName:
+			// schema、type 等类型系统定义
+			return nil, validationError([]Location{p.tok.loc}, "The %q definition is not executable.", p.tok.value)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+	return doc, nil
+}
+
+// advance 读取下一个词法单元。
+func (p *parser) advance() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+	p.tok = tok
+	return nil
+}
+
+// unexpected 返回当前词法单元不符合语法的错误。
+func (p *parser) unexpected() error {
+	return syntaxError(p.tok.loc, "Unexpected %s", p.tok.describe())
+}
+
+// peekPunct 判断当前词法单元是否为标点 s。
+func (p *parser) peekPunct(s string) bool {
+	return p.tok.kind == tokPunct && p.tok.value == s
+}
+
+// expectPunct 要求当前词法单元为标点 s 并跳过它。
+func (p *parser) expectPunct(s string) error {
+	if !p.peekPunct(s) {
+		return syntaxError(p.tok.loc, "Expected %q, found %s", s, p.tok.describe())
+	}
+	return p.advance()
+}
+
+// skipPunct 在当前词法单元为标点 s 时跳过它，返回是否跳过。
+func (p *parser) skipPunct(s string) (bool, error) {
+	if !p.peekPunct(s) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+// name 读取一个名称。
+func (p *parser) name() (string, error) {
+	if p.tok.kind != tokName {
+		return "", syntaxError(p.tok.loc, "Expected Name, found %s", p.tok.describe())
+	}
+	name := p.tok.value
+	return name, p.advance()
+}
+
+// operation 解析带关键字的操作定义。
+func (p *parser) operation() (*operation, error) {
+	op := &operation{kind: p.tok.value, loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var err error
+	if p.tok.kind == tokName {
+		if op.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peekPunct("(") {
+		if op.vars, err = p.varDefs(); err != nil {
+			return nil, err
+		}
+	}
+	if op.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if op.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// varDefs 解析变量定义列表。
+func (p *parser) varDefs() ([]*varDef, error) {
+	if err := p.expectPunct("("); err != nil {
+		return nil, err
+	}
+	var defs []*varDef
+	for {
+		if ok, err := p.skipPunct(")"); err != nil || ok {
+			return defs, err
+		}
+		def := &varDef{loc: p.tok.loc}
+		if err := p.expectPunct("$"); err != nil {
+			return nil, err
+		}
+		var err error
+		if def.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		if def.typ, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skipPunct("="); err != nil {
+			return nil, err
+		} else if ok {
+			if def.def, err = p.value(true); err != nil {
+				return nil, err
+			}
+		}
+		// 变量定义上的指令没有意义，解析后忽略
+		if _, err := p.directives(); err != nil {
+			return nil, err
+		}
+		defs = append(defs, def)
+	}
+}
+
+// typeRef 解析类型引用。
+func (p *parser) typeRef() (*typeRef, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	t := &typeRef{}
+	if ok, err := p.skipPunct("["); err != nil {
+		return nil, err
+	} else if ok {
+		if t.elem, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return nil, err
+		}
+	} else {
+		if t.name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	ok, err := p.skipPunct("!")
+	t.nonNull = ok
+	return t, err
+}
+
+// directives 解析指令列表。
+func (p *parser) directives() ([]*directive, error) {
+	var dirs []*directive
+	for p.peekPunct("@") {
+		d := &directive{loc: p.tok.loc}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		var err error
+		if d.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if d.args, err = p.arguments(); err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, d)
+	}
+	return dirs, nil
+}
+
+// arguments 解析参数列表，没有括号时返回 nil。
+func (p *parser) arguments() ([]*argument, error) {
+	if ok, err := p.skipPunct("("); err != nil || !ok {
+		return nil, err
+	}
+	var args []*argument
+	for {
+		if len(args) > 0 {
+			if ok, err := p.skipPunct(")"); err != nil || ok {
+				return args, err
+			}
+		}
+		arg := &argument{loc: p.tok.loc}
+		var err error
+		if arg.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		if arg.value, err = p.value(false); err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+}
+
+// selectionSet 解析选择集 { ... }。
+func (p *parser) selectionSet() ([]selection, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	var sels []selection
+	for {
+		if ok, err := p.skipPunct("}"); err != nil {
+			return nil, err
+		} else if ok {
+			if len(sels) == 0 {
+				return nil, syntaxError(p.tok.loc, "Expected Name, found \"}\"")
+			}
+			return sels, nil
+		}
+		sel, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		sels = append(sels, sel)
+	}
+}
+
+// selection 解析选择集中的一项。
+func (p *parser) selection() (selection, error) {
+	loc := p.tok.loc
+	if ok, err := p.skipPunct("..."); err != nil {
+		return nil, err
+	} else if ok {
+		return p.fragmentSelection(loc)
+	}
+
+	f := &fieldNode{loc: loc}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skipPunct(":"); err != nil {
+		return nil, err
+	} else if ok {
+		f.alias = name
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	f.name = name
+	if f.args, err = p.arguments(); err != nil {
+		return nil, err
+	}
+	if f.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if p.peekPunct("{") {
+		if f.selections, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// fragmentSelection 解析 ... 之后的片段引用或内联片段。
+func (p *parser) fragmentSelection(loc Location) (selection, error) {
+	if p.tok.kind == tokName && p.tok.value != "on" {
+		spread := &fragmentSpread{loc: loc}
+		var err error
+		if spread.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if spread.directives, err = p.directives(); err != nil {
+			return nil, err
+		}
+		return spread, nil
+	}
+
+	inline := &inlineFragment{loc: loc}
+	var err error
+	if p.tok.kind == tokName && p.tok.value == "on" {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if inline.typeCondition, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if inline.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if inline.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return inline, nil
+}
+
+// fragment 解析命名片段的定义。
+func (p *parser) fragment() (*fragment, error) {
+	frag := &fragment{loc: p.tok.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	var err error
+	if frag.name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if frag.name == "on" {
+		return nil, syntaxError(frag.loc, "Unexpected Name \"on\"")
+	}
+	if p.tok.kind != tokName || p.tok.value != "on" {
+		return nil, syntaxError(p.tok.loc, "Expected \"on\", found %s", p.tok.describe())
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if frag.typeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if frag.directives, err = p.directives(); err != nil {
+		return nil, err
+	}
+	if frag.selections, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return frag, nil
+}
+
+// value 解析字面量。isConst 为 true 时不允许引用变量，用于变量的默认值。
+func (p *parser) value(isConst bool) (*value, error) {
+	tok := p.tok
+	v := &value{loc: tok.loc, raw: tok.value}
+	switch tok.kind {
+	case tokInt:
+		v.kind = valInt
+	case tokFloat:
+		v.kind = valFloat
+	case tokString:
+		v.kind = valString
+	case tokName:
+		switch tok.value {
+		case "true", "false":
+			v.kind = valBoolean
+		case "null":
+			v.kind = valNull
+		default:
+			v.kind = valEnum
+		}
+	case tokPunct:
+		switch tok.value {
+		case "$":
+			if isConst {
+				return nil, p.unexpected()
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			v.kind, v.raw = valVariable, name
+			return v, nil
+		case "[":
+			return p.listValue(v, isConst)
+		case "{":
+			return p.objectValue(v, isConst)
+		}
+		return nil, p.unexpected()
+	default:
+		return nil, p.unexpected()
+	}
+	return v, p.advance()
+}
+
+// listValue 解析列表字面量。
+func (p *parser) listValue(v *value, isConst bool) (*value, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	v.kind, v.raw = valList, ""
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	for {
+		if ok, err := p.skipPunct("]"); err != nil || ok {
+			return v, err
+		}
+		item, err := p.value(isConst)
+		if err != nil {
+			return nil, err
+		}
+		v.list = append(v.list, item)
+	}
+}
+
+// objectValue 解析对象字面量。
+func (p *parser) objectValue(v *value, isConst bool) (*value, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	v.kind, v.raw = valObject, ""
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	for {
+		if ok, err := p.skipPunct("}"); err != nil || ok {
+			return v, err
+		}
+		field := &objectField{loc: p.tok.loc}
+		var err error
+		if field.name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		if field.value, err = p.value(isConst); err != nil {
+			return nil, err
+		}
+		v.fields = append(v.fields, field)
+	}
+}
+
+// String 返回字面量在查询中的写法，用于错误消息。
+func (v *value) String() string {
+	switch v.kind {
+	case valVariable:
+		return "$" + v.raw
+	case valString:
+		return strconv.Quote(v.raw)
+	case valList:
+		s := "["
+		for i, item := range v.list {
+			if i > 0 {
+				s += ", "
+			}
+			s += item.String()
+		}
+		return s + "]"
+	case valObject:
+		s := "{"
+		for i, field := range v.fields {
+			if i > 0 {
+				s += ", "
+			}
+			s += field.name + ": " + field.value.String()
+		}
+		return s + "}"
+	}
+	return v.raw
+}
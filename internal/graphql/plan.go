@@ -0,0 +1,555 @@
+package graphql
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 执行前先根据变量的值把操作展开为执行计划：处理 @skip/@include、展开片段、合并同名字段、转换参数，
+// 同时完成与模式相关的校验。片段可以被多次引用，展开后的执行计划可能比查询文本大得多，
+// 因此在展开的过程中就检查嵌套深度和字段数，超过上限时立即停止；复杂度在执行计划确定后计算。
+
+// planField 是执行计划中的一个字段，同一个响应键下的多个字段已经合并。
+type planField struct {
+	key      string
+	parent   *Object
+	field    *Field
+	meta     metaField // 内省字段的种类，普通字段为 metaNone
+	args     map[string]interface{}
+	loc      Location
+	children []*planField // 对象类型字段的子字段
+}
+
+// metaField 是内省使用的特殊字段。
+type metaField int
+
+const (
+	metaNone metaField = iota
+	metaTypename
+	metaSchema
+	metaType
+)
+
+// planner 生成执行计划。
+type planner struct {
+	schema   *Schema
+	doc      *document
+	vars     map[string]interface{} // 转换后的变量值
+	varTypes map[string]*varInfo
+	coercer  *literalCoercer
+	errors   []*Error
+
+	maxDepth  int    // 最大嵌套深度，0 表示不限制
+	maxFields int    // 执行计划中的最大字段数
+	fields    int    // 已生成的字段数
+	limit     *Error // 超过深度或字段数上限的错误，不为 nil 时停止展开
+}
+
+// varInfo 记录变量的类型，用于检查变量的使用位置。
+type varInfo struct {
+	typ        Type
+	hasDefault bool
+}
+
+func newPlanner(schema *Schema, doc *document, maxDepth, maxFields int) *planner {
+	return &planner{schema: schema, doc: doc, maxDepth: maxDepth, maxFields: maxFields}
+}
+
+// errorf 记录一个校验错误。
+func (p *planner) errorf(loc Location, format string, args ...interface{}) {
+	p.errors = append(p.errors, validationError([]Location{loc}, format, args...))
+}
+
+// plan 转换变量并生成操作的执行计划，出错时错误记录在 p.errors 中，超过上限时记录在 p.limit 中。
+func (p *planner) plan(op *operation, root *Object, variables map[string]interface{}) []*planField {
+	p.checkFragmentCycles()
+	if len(p.errors) > 0 {
+		return nil
+	}
+	p.coerceVariables(op, variables)
+	if len(p.errors) > 0 {
+		return nil
+	}
+	for _, d := range op.directives {
+		p.errorf(d.loc, "Directive \"@%s\" may not be used on %s.", d.name, op.kind)
+	}
+	return p.selectionSet(root, op.selections, 1)
+}
+
+// checkFragmentCycles 检查片段之间的循环引用，包括经过其他片段和子字段的间接引用，
+// 例如 A 的子字段引用 B、B 又引用 A。存在循环时展开永远不会结束，因此在展开之前检查。
+func (p *planner) checkFragmentCycles() {
+	names := make([]string, 0, len(p.doc.fragments))
+	for name := range p.doc.fragments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var stack []*fragmentSpread // 从起点到当前片段经过的引用
+	var visit func(name string)
+	visit = func(name string) {
+		state[name] = visiting
+		for _, spread := range fragmentSpreads(p.doc.fragments[name].selections, nil) {
+			if _, ok := p.doc.fragments[spread.name]; !ok {
+				continue // 未定义的片段在展开时报告
+			}
+			switch state[spread.name] {
+			case visiting:
+				// 找到循环的起点，错误消息中列出经过的片段
+				var via []string
+				for i := len(stack) - 1; i >= 0 && stack[i].name != spread.name; i-- {
+					via = append([]string{strconv.Quote(stack[i].name)}, via...)
+				}
+				if len(via) == 0 {
+					p.errorf(spread.loc, "Cannot spread fragment %q within itself.", spread.name)
+				} else {
+					p.errorf(spread.loc, "Cannot spread fragment %q within itself via %s.", spread.name, strings.Join(via, ", "))
+				}
+			case unvisited:
+				stack = append(stack, spread)
+				visit(spread.name)
+				stack = stack[:len(stack)-1]
+			}
+		}
+		state[name] = done
+	}
+	for _, name := range names {
+		if state[name] == unvisited {
+			stack = append(stack[:0], &fragmentSpread{name: name})
+			visit(name)
+		}
+	}
+}
+
+// fragmentSpreads 返回选择集中（包括子字段和内联片段中）引用的所有片段，追加到 spreads 后面。
+func fragmentSpreads(sels []selection, spreads []*fragmentSpread) []*fragmentSpread {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *fieldNode:
+			spreads = fragmentSpreads(sel.selections, spreads)
+		case *fragmentSpread:
+			spreads = append(spreads, sel)
+		case *inlineFragment:
+			spreads = fragmentSpreads(sel.selections, spreads)
+		}
+	}
+	return spreads
+}
+
+// coerceVariables 按变量定义转换请求中的变量值。
+func (p *planner) coerceVariables(op *operation, variables map[string]interface{}) {
+	p.vars = map[string]interface{}{}
+	p.varTypes = map[string]*varInfo{}
+	p.coercer = &literalCoercer{vars: map[string]interface{}{}}
+	for _, def := range op.vars {
+		if _, dup := p.varTypes[def.name]; dup {
+			p.errorf(def.loc, "There can be only one variable named \"$%s\".", def.name)
+			continue
+		}
+		t := p.resolveTypeRef(def.typ)
+		if t == nil {
+			p.errorf(def.loc, "Unknown type %q.", def.typ)
+			continue
+		}
+		if !isInputType(t) {
+			p.errorf(def.loc, "Variable \"$%s\" cannot be non-input type %q.", def.name, def.typ)
+			continue
+		}
+		p.varTypes[def.name] = &varInfo{typ: t, hasDefault: def.def != nil}
+
+		raw, provided := variables[def.name]
+		switch {
+		case provided:
+			val, err := coerceVariable(raw, t)
+			if err != nil {
+				p.errorf(def.loc, "Variable \"$%s\" got invalid value %s; %v", def.name, formatJSONValue(raw), err)
+				continue
+			}
+			p.vars[def.name] = val
+		case def.def != nil:
+			val, _, err := p.coercer.coerce(def.def, t)
+			if err != nil {
+				p.errorf(def.loc, "Variable \"$%s\" has invalid default value: %v", def.name, err)
+				continue
+			}
+			p.vars[def.name] = val
+		case isNonNullType(t):
+			p.errorf(def.loc, "Variable \"$%s\" of required type %q was not provided.", def.name, def.typ)
+		}
+	}
+	p.coercer.vars = p.vars
+}
+
+// resolveTypeRef 将查询中的类型引用解析为模式中的类型。
+func (p *planner) resolveTypeRef(ref *typeRef) Type {
+	var t Type
+	if ref.elem != nil {
+		elem := p.resolveTypeRef(ref.elem)
+		if elem == nil {
+			return nil
+		}
+		t = ListOf(elem)
+	} else {
+		t = p.schema.types[ref.name]
+		if t == nil {
+			return nil
+		}
+	}
+	if ref.nonNull {
+		t = NonNullOf(t)
+	}
+	return t
+}
+
+// collected 是收集选择集时同一个响应键下的字段。
+type collected struct {
+	key   string
+	nodes []*fieldNode
+}
+
+// selectionSet 生成对象类型 t 的选择集的执行计划。depth 是选择集中字段的嵌套深度，为 0 表示不计算深度（内省字段的子字段）。
+func (p *planner) selectionSet(t *Object, sels []selection, depth int) []*planField {
+	if p.limit != nil {
+		return nil
+	}
+	var groups []*collected
+	index := map[string]*collected{}
+	p.collectFields(t, sels, &groups, index, map[string]bool{})
+
+	fields := make([]*planField, 0, len(groups))
+	for _, g := range groups {
+		f := p.planField(t, g, depth)
+		if p.limit != nil {
+			return nil
+		}
+		if f != nil {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// collectFields 展开片段并按响应键分组，实现了规范中的 CollectFields 算法。
+// visited 记录本选择集中已经展开过的片段，同一个片段在一个选择集中只展开一次。
+func (p *planner) collectFields(t *Object, sels []selection, groups *[]*collected, index map[string]*collected, visited map[string]bool) {
+	for _, sel := range sels {
+		switch sel := sel.(type) {
+		case *fieldNode:
+			if !p.included(sel.directives) {
+				continue
+			}
+			key := sel.responseKey()
+			g, ok := index[key]
+			if !ok {
+				g = &collected{key: key}
+				index[key] = g
+				*groups = append(*groups, g)
+			}
+			g.nodes = append(g.nodes, sel)
+		case *fragmentSpread:
+			if !p.included(sel.directives) {
+				continue
+			}
+			frag, ok := p.doc.fragments[sel.name]
+			if !ok {
+				p.errorf(sel.loc, "Unknown fragment %q.", sel.name)
+				continue
+			}
+			if visited[sel.name] {
+				continue
+			}
+			visited[sel.name] = true
+			for _, d := range frag.directives {
+				p.errorf(d.loc, "Directive \"@%s\" may not be used on FRAGMENT_DEFINITION.", d.name)
+			}
+			if !p.typeConditionMatches(t, frag.typeCondition, frag.loc) {
+				continue
+			}
+			p.collectFields(t, frag.selections, groups, index, visited)
+		case *inlineFragment:
+			if !p.included(sel.directives) {
+				continue
+			}
+			if sel.typeCondition != "" && !p.typeConditionMatches(t, sel.typeCondition, sel.loc) {
+				continue
+			}
+			p.collectFields(t, sel.selections, groups, index, visited)
+		}
+	}
+}
+
+// typeConditionMatches 检查片段的类型条件。模式中只有对象类型，类型条件必须是所在位置的类型。
+func (p *planner) typeConditionMatches(t *Object, cond string, loc Location) bool {
+	if cond == t.Name {
+		return true
+	}
+	ct, ok := p.schema.types[cond]
+	if !ok {
+		p.errorf(loc, "Unknown type %q.", cond)
+		return false
+	}
+	if _, ok := ct.(*Object); !ok {
+		p.errorf(loc, "Fragment cannot condition on non composite type %q.", cond)
+		return false
+	}
+	p.errorf(loc, "Fragment cannot be spread here as objects of type %q can never be of type %q.", t.Name, cond)
+	return false
+}
+
+// included 根据 @skip 和 @include 指令判断选择是否需要执行。
+func (p *planner) included(dirs []*directive) bool {
+	include := true
+	for _, d := range dirs {
+		def := findDirective(d.name)
+		if def == nil {
+			p.errorf(d.loc, "Unknown directive \"@%s\".", d.name)
+			continue
+		}
+		args, ok := p.coerceArgs(def.args, d.args, "@"+d.name, d.loc)
+		if !ok {
+			continue
+		}
+		cond, _ := args["if"].(bool)
+		switch d.name {
+		case "skip":
+			include = include && !cond
+		case "include":
+			include = include && cond
+		default:
+			p.errorf(d.loc, "Directive \"@%s\" may not be used here.", d.name)
+		}
+	}
+	return include
+}
+
+// planField 生成一组同名字段的执行计划，depth 是字段的嵌套深度，为 0 表示不计算深度。
+func (p *planner) planField(t *Object, g *collected, depth int) *planField {
+	first := g.nodes[0]
+	p.fields++
+	if p.fields > p.maxFields {
+		p.limit = &Error{
+			Message:    fmt.Sprintf("Query has more than the maximum allowed %d fields after expanding fragments.", p.maxFields),
+			Locations:  []Location{first.loc},
+			Extensions: map[string]interface{}{"code": CodeTooLarge, "maxFields": p.maxFields},
+		}
+		return nil
+	}
+	f := &planField{key: g.key, parent: t, loc: first.loc}
+	switch {
+	case first.name == "__typename":
+		f.field, f.meta = typenameField, metaTypename
+	case first.name == "__schema" && t == p.schema.Query:
+		f.field, f.meta = schemaField, metaSchema
+	case first.name == "__type" && t == p.schema.Query:
+		f.field, f.meta = typeField, metaType
+	default:
+		f.field = t.fields[first.name]
+	}
+	if f.field == nil {
+		p.errorf(first.loc, "Cannot query field %q on type %q.", first.name, t.Name)
+		return nil
+	}
+	// 内省字段及其子字段不计入深度
+	if f.meta != metaNone {
+		depth = 0
+	}
+	if depth > 0 && p.maxDepth > 0 && depth > p.maxDepth {
+		p.limit = &Error{
+			Message:    fmt.Sprintf("Query depth exceeds the maximum allowed depth of %d.", p.maxDepth),
+			Locations:  []Location{first.loc},
+			Extensions: map[string]interface{}{"code": CodeTooDeep, "maxDepth": p.maxDepth},
+		}
+		return nil
+	}
+
+	args, ok := p.coerceArgs(f.field.Args, first.args, t.Name+"."+first.name, first.loc)
+	if !ok {
+		return nil
+	}
+	f.args = args
+
+	var sels []selection
+	for _, node := range g.nodes {
+		if node.name != first.name {
+			p.errorf(node.loc, "Fields %q conflict because %q and %q are different fields. Use different aliases on the fields to fetch both if this was intentional.",
+				g.key, first.name, node.name)
+			return nil
+		}
+		if node != first {
+			other, ok := p.coerceArgs(f.field.Args, node.args, t.Name+"."+node.name, node.loc)
+			if !ok {
+				return nil
+			}
+			if !reflect.DeepEqual(args, other) {
+				p.errorf(node.loc, "Fields %q conflict because they have differing arguments. Use different aliases on the fields to fetch both if this was intentional.", g.key)
+				return nil
+			}
+		}
+		sels = append(sels, node.selections...)
+	}
+
+	switch nt := namedType(f.field.Type).(type) {
+	case *Object:
+		if len(sels) == 0 {
+			p.errorf(first.loc, "Field %q of type %q must have a selection of subfields. Did you mean \"%s { ... }\"?", first.name, f.field.Type, first.name)
+			return nil
+		}
+		childDepth := 0
+		if depth > 0 {
+			childDepth = depth + 1
+		}
+		f.children = p.selectionSet(nt, sels, childDepth)
+	default:
+		if len(sels) > 0 {
+			p.errorf(first.loc, "Field %q must not have a selection since type %q has no subfields.", first.name, f.field.Type)
+			return nil
+		}
+	}
+	return f
+}
+
+// coerceArgs 转换字段或指令的参数，owner 用于错误消息。
+func (p *planner) coerceArgs(defs []*Argument, nodes []*argument, owner string, loc Location) (map[string]interface{}, bool) {
+	ok := true
+	provided := map[string]*argument{}
+	for _, node := range nodes {
+		if _, dup := provided[node.name]; dup {
+			p.errorf(node.loc, "There can be only one argument named %q.", node.name)
+			ok = false
+			continue
+		}
+		provided[node.name] = node
+		if findArg(defs, node.name) == nil {
+			p.errorf(node.loc, "Unknown argument %q on %q.", node.name, owner)
+			ok = false
+		}
+	}
+
+	args := map[string]interface{}{}
+	for _, def := range defs {
+		node, has := provided[def.Name]
+		if has {
+			if !p.checkVariableUsages(node.value, def.Type, def.DefaultValue != nil) {
+				ok = false
+				continue
+			}
+			val, present, err := p.coercer.coerce(node.value, def.Type)
+			if err != nil {
+				p.errorf(node.loc, "Argument %q on %q has invalid value %s: %v", def.Name, owner, node.value, err)
+				ok = false
+				continue
+			}
+			if present {
+				args[def.Name] = val
+				continue
+			}
+		}
+		if def.DefaultValue != nil {
+			args[def.Name] = def.DefaultValue
+		} else if isNonNullType(def.Type) {
+			p.errorf(loc, "Argument %q of required type %q on %q was not provided.", def.Name, def.Type, owner)
+			ok = false
+		}
+	}
+	return args, ok
+}
+
+// checkVariableUsages 检查字面量中引用的变量是否已定义，以及变量的类型能否用于该位置。
+func (p *planner) checkVariableUsages(v *value, t Type, locationHasDefault bool) bool {
+	switch v.kind {
+	case valVariable:
+		info, ok := p.varTypes[v.raw]
+		if !ok {
+			p.errorf(v.loc, "Variable \"$%s\" is not defined.", v.raw)
+			return false
+		}
+		varType := info.typ
+		// 变量或参数有默认值时，可以将可空的变量用于非空的位置
+		if nn, ok := t.(*NonNull); ok && !isNonNullType(varType) && (info.hasDefault || locationHasDefault) {
+			t = nn.OfType
+		}
+		if !typeAssignable(varType, t) {
+			p.errorf(v.loc, "Variable \"$%s\" of type %q used in position expecting type %q.", v.raw, varType, t)
+			return false
+		}
+		return true
+	case valList:
+		elem := t
+		if nn, ok := elem.(*NonNull); ok {
+			elem = nn.OfType
+		}
+		if l, ok := elem.(*List); ok {
+			elem = l.OfType
+		}
+		ok := true
+		for _, item := range v.list {
+			ok = p.checkVariableUsages(item, elem, false) && ok
+		}
+		return ok
+	case valObject:
+		obj, isObj := namedType(t).(*InputObject)
+		ok := true
+		for _, field := range v.fields {
+			var ft Type = String
+			hasDefault := false
+			if isObj {
+				if def := findArg(obj.Fields, field.name); def != nil {
+					ft, hasDefault = def.Type, def.DefaultValue != nil
+				}
+			}
+			ok = p.checkVariableUsages(field.value, ft, hasDefault) && ok
+		}
+		return ok
+	}
+	return true
+}
+
+// typeAssignable 判断类型为 from 的变量能否用于类型为 to 的位置。
+func typeAssignable(from, to Type) bool {
+	if nn, ok := to.(*NonNull); ok {
+		fromNN, ok := from.(*NonNull)
+		return ok && typeAssignable(fromNN.OfType, nn.OfType)
+	}
+	if nn, ok := from.(*NonNull); ok {
+		return typeAssignable(nn.OfType, to)
+	}
+	if l, ok := to.(*List); ok {
+		fromList, ok := from.(*List)
+		return ok && typeAssignable(fromList.OfType, l.OfType)
+	}
+	if _, ok := from.(*List); ok {
+		return false
+	}
+	return from == to
+}
+
+// selectionComplexity 返回执行计划的复杂度，内省字段不计入。
+func selectionComplexity(fields []*planField) int {
+	total := 0
+	for _, f := range fields {
+		if f.meta != metaNone {
+			continue
+		}
+		child := selectionComplexity(f.children)
+		if f.field.Complexity != nil {
+			total += f.field.Complexity(f.args, child)
+		} else {
+			total += 1 + child
+		}
+	}
+	return total
+}
+
+// fieldName 返回字段在错误消息中的名称，例如 Post.author。
+func (f *planField) fieldName() string {
+	return fmt.Sprintf("%s.%s", f.parent.Name, f.field.Name)
+}
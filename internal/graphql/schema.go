@@ -0,0 +1,503 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Type 是 GraphQL 的类型：*Scalar、*Enum、*Object、*InputObject、*List 或 *NonNull。
+type Type interface {
+	// String 返回类型在 SDL 中的写法，例如 [Post!]!
+	String() string
+}
+
+// Scalar 是标量类型。
+type Scalar struct {
+	Name        string
+	Description string
+	// Serialize 将 resolver 返回的值转换为响应中的 JSON 值
+	Serialize func(v interface{}) (interface{}, error)
+	// ParseValue 将输入值转换为 resolver 使用的值。
+	// 输入值来自查询中的字面量或请求中的变量，可能是 string、bool、int64、float64 或 json.Number
+	ParseValue func(v interface{}) (interface{}, error)
+}
+
+func (t *Scalar) String() string { return t.Name }
+
+// Enum 是枚举类型。
+type Enum struct {
+	Name        string
+	Description string
+	Values      []*EnumValue
+}
+
+func (t *Enum) String() string { return t.Name }
+
+// EnumValue 是枚举类型的一个值，Value 是它在 resolver 中对应的 Go 值。
+type EnumValue struct {
+	Name              string
+	Description       string
+	DeprecationReason string
+	Value             interface{}
+}
+
+// byName 返回名称为 name 的枚举值。
+func (t *Enum) byName(name string) *EnumValue {
+	for _, v := range t.Values {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// byValue 返回 Go 值为 v 的枚举值。
+func (t *Enum) byValue(v interface{}) *EnumValue {
+	for _, ev := range t.Values {
+		if ev.Value == v {
+			return ev
+		}
+	}
+	return nil
+}
+
+// Object 是对象类型。对象之间可以互相引用，可以先创建对象再设置 Fields。
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+
+	fields map[string]*Field // 由 NewSchema 根据 Fields 建立
+}
+
+func (t *Object) String() string { return t.Name }
+
+// Field 是对象的字段。
+type Field struct {
+	Name              string
+	Description       string
+	DeprecationReason string
+	Type              Type
+	Args              []*Argument
+	// Resolve 返回字段的值。返回 Thunk 时，值在同一层的其他字段解析完之后才会求值，用于批量加载。
+	// 为 nil 时从 map[string]interface{} 类型的父对象中按字段名取值。
+	Resolve ResolveFunc
+	// Complexity 计算字段的复杂度，childComplexity 是子字段复杂度之和。
+	// 为 nil 时为 1 + childComplexity，返回列表的字段应当根据分页参数放大子字段的复杂度
+	Complexity ComplexityFunc
+}
+
+// Argument 是字段或指令的参数，也用作输入对象的字段。
+type Argument struct {
+	Name              string
+	Description       string
+	DeprecationReason string
+	Type              Type
+	// DefaultValue 是没有传入参数时使用的值，为 nil 表示没有默认值
+	DefaultValue interface{}
+}
+
+// InputObject 是输入对象类型，转换后的值为 map[string]interface{}。
+type InputObject struct {
+	Name        string
+	Description string
+	Fields      []*Argument
+}
+
+func (t *InputObject) String() string { return t.Name }
+
+// List 是列表类型。
+type List struct {
+	OfType Type
+}
+
+func (t *List) String() string { return "[" + t.OfType.String() + "]" }
+
+// NonNull 是非空类型。
+type NonNull struct {
+	OfType Type
+}
+
+func (t *NonNull) String() string { return t.OfType.String() + "!" }
+
+// ListOf 返回元素类型为 t 的列表类型。
+func ListOf(t Type) *List {
+	return &List{OfType: t}
+}
+
+// NonNullOf 返回 t 的非空类型。
+func NonNullOf(t Type) *NonNull {
+	return &NonNull{OfType: t}
+}
+
+// ResolveParams 是调用 resolver 时的参数。
+type ResolveParams struct {
+	Context context.Context
+	Source  interface{}            // 父对象的值，根字段为 nil
+	Args    map[string]interface{} // 转换后的参数，没有传入且没有默认值的参数不在其中
+}
+
+// ResolveFunc 是字段的 resolver。
+type ResolveFunc func(p ResolveParams) (interface{}, error)
+
+// ComplexityFunc 计算字段的复杂度。
+type ComplexityFunc func(args map[string]interface{}, childComplexity int) int
+
+// Thunk 是延迟求值的字段值，由 resolver 返回，通常来自 Loader.Load。
+type Thunk func() (interface{}, error)
+
+// Schema 是完整的 GraphQL 模式。
+type Schema struct {
+	Query    *Object
+	Mutation *Object
+
+	types map[string]Type // 所有命名类型，包括内置标量和内省类型
+}
+
+// NewSchema 创建模式，收集 query 和 mutation 可以到达的所有类型并校验它们的定义。
+// mutation 为 nil 表示不支持修改操作。
+func NewSchema(query, mutation *Object) (*Schema, error) {
+	if query == nil {
+		return nil, fmt.Errorf("graphql: query type is required")
+	}
+	s := &Schema{Query: query, Mutation: mutation, types: map[string]Type{}}
+	for _, t := range []Type{Int, Float, String, Boolean, ID, schemaType} {
+		if err := s.collect(t); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.collect(query); err != nil {
+		return nil, err
+	}
+	if mutation != nil {
+		if err := s.collect(mutation); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// collect 将类型 t 及其引用的类型加入模式，同名的类型必须是同一个实例。
+func (s *Schema) collect(t Type) error {
+	t = namedType(t)
+	name := t.String()
+	if existing, ok := s.types[name]; ok {
+		if existing != t {
+			return fmt.Errorf("graphql: schema must contain uniquely named types but contains multiple types named %q", name)
+		}
+		return nil
+	}
+	if !validName(name) {
+		return fmt.Errorf("graphql: invalid type name %q", name)
+	}
+	s.types[name] = t
+
+	switch t := t.(type) {
+	case *Object:
+		if len(t.Fields) == 0 {
+			return fmt.Errorf("graphql: type %s must define one or more fields", t.Name)
+		}
+		t.fields = make(map[string]*Field, len(t.Fields))
+		for _, f := range t.Fields {
+			if !validName(f.Name) {
+				return fmt.Errorf("graphql: invalid field name %s.%s", t.Name, f.Name)
+			}
+			if _, ok := t.fields[f.Name]; ok {
+				return fmt.Errorf("graphql: duplicate field %s.%s", t.Name, f.Name)
+			}
+			t.fields[f.Name] = f
+			if !isOutputType(f.Type) {
+				return fmt.Errorf("graphql: the type of %s.%s must be an output type", t.Name, f.Name)
+			}
+			if err := s.collect(f.Type); err != nil {
+				return err
+			}
+			if err := s.collectArgs(t.Name+"."+f.Name, f.Args); err != nil {
+				return err
+			}
+		}
+	case *InputObject:
+		if len(t.Fields) == 0 {
+			return fmt.Errorf("graphql: input type %s must define one or more fields", t.Name)
+		}
+		return s.collectArgs(t.Name, t.Fields)
+	case *Enum:
+		if len(t.Values) == 0 {
+			return fmt.Errorf("graphql: enum type %s must define one or more values", t.Name)
+		}
+		for _, v := range t.Values {
+			if !validName(v.Name) || v.Name == "true" || v.Name == "false" || v.Name == "null" {
+				return fmt.Errorf("graphql: invalid enum value %s.%s", t.Name, v.Name)
+			}
+		}
+	case *Scalar:
+		if t.Serialize == nil || t.ParseValue == nil {
+			return fmt.Errorf("graphql: scalar %s must define Serialize and ParseValue", t.Name)
+		}
+	}
+	return nil
+}
+
+// collectArgs 收集参数或输入对象字段引用的类型。
+func (s *Schema) collectArgs(owner string, args []*Argument) error {
+	seen := map[string]bool{}
+	for _, arg := range args {
+		if !validName(arg.Name) || seen[arg.Name] {
+			return fmt.Errorf("graphql: invalid or duplicate argument %s(%s)", owner, arg.Name)
+		}
+		seen[arg.Name] = true
+		if !isInputType(arg.Type) {
+			return fmt.Errorf("graphql: the type of %s(%s) must be an input type", owner, arg.Name)
+		}
+		if err := s.collect(arg.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Type 返回名称为 name 的命名类型，不存在时返回 nil。
+func (s *Schema) Type(name string) Type {
+	return s.types[name]
+}
+
+// typeNames 返回所有命名类型的名称，按字母顺序排列。
+func (s *Schema) typeNames() []string {
+	names := make([]string, 0, len(s.types))
+	for name := range s.types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// namedType 去掉类型外层的列表和非空修饰。
+func namedType(t Type) Type {
+	for {
+		switch w := t.(type) {
+		case *List:
+			t = w.OfType
+		case *NonNull:
+			t = w.OfType
+		default:
+			return t
+		}
+	}
+}
+
+// isNonNullType 判断类型是否为非空类型。
+func isNonNullType(t Type) bool {
+	_, ok := t.(*NonNull)
+	return ok
+}
+
+// isInputType 判断类型能否用于参数和变量。
+func isInputType(t Type) bool {
+	switch namedType(t).(type) {
+	case *Scalar, *Enum, *InputObject:
+		return true
+	}
+	return false
+}
+
+// isOutputType 判断类型能否用于对象的字段。
+func isOutputType(t Type) bool {
+	switch namedType(t).(type) {
+	case *Scalar, *Enum, *Object:
+		return true
+	}
+	return false
+}
+
+// isLeafType 判断类型是否为标量或枚举，它们的字段不能有子选择。
+func isLeafType(t Type) bool {
+	switch t.(type) {
+	case *Scalar, *Enum:
+		return true
+	}
+	return false
+}
+
+// validName 判断名称是否符合 /[_A-Za-z][_0-9A-Za-z]*/。
+func validName(name string) bool {
+	if name == "" || !isNameStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isNameContinue(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// 内置标量 (https://spec.graphql.org/October2021/#sec-Scalars)。
+var (
+	// Int 是 32 位有符号整数，转换后的值为 int。
+	Int = &Scalar{
+		Name:        "Int",
+		Description: "The `Int` scalar type represents non-fractional signed whole numeric values. Int can represent values between -(2^31) and 2^31 - 1.",
+		Serialize:   func(v interface{}) (interface{}, error) { return coerceInt(v) },
+		ParseValue:  func(v interface{}) (interface{}, error) { return coerceInt(v) },
+	}
+	// Float 是双精度浮点数，转换后的值为 float64。
+	Float = &Scalar{
+		Name:        "Float",
+		Description: "The `Float` scalar type represents signed double-precision fractional values as specified by [IEEE 754](https://en.wikipedia.org/wiki/IEEE_floating_point).",
+		Serialize:   func(v interface{}) (interface{}, error) { return coerceFloat(v) },
+		ParseValue:  func(v interface{}) (interface{}, error) { return coerceFloat(v) },
+	}
+	// String 是 UTF-8 字符串，转换后的值为 string。
+	String = &Scalar{
+		Name:        "String",
+		Description: "The `String` scalar type represents textual data, represented as UTF-8 character sequences.",
+		Serialize: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case string:
+				return v, nil
+			case fmt.Stringer:
+				return v.String(), nil
+			case bool:
+				return strconv.FormatBool(v), nil
+			}
+			if n, ok := toFloat(v); ok {
+				return strconv.FormatFloat(n, 'f', -1, 64), nil
+			}
+			return nil, fmt.Errorf("String cannot represent value: %v", v)
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			if s, ok := v.(string); ok {
+				return s, nil
+			}
+			return nil, fmt.Errorf("String cannot represent a non string value: %v", v)
+		},
+	}
+	// Boolean 是布尔值，转换后的值为 bool。
+	Boolean = &Scalar{
+		Name:        "Boolean",
+		Description: "The `Boolean` scalar type represents `true` or `false`.",
+		Serialize: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent a non boolean value: %v", v)
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			if b, ok := v.(bool); ok {
+				return b, nil
+			}
+			return nil, fmt.Errorf("Boolean cannot represent a non boolean value: %v", v)
+		},
+	}
+	// ID 是唯一标识，在响应中总是字符串，输入时可以是字符串或整数，转换后的值为 string。
+	ID = &Scalar{
+		Name:        "ID",
+		Description: "The `ID` scalar type represents a unique identifier. It is serialized as a String; input accepts strings and integers.",
+		Serialize:   func(v interface{}) (interface{}, error) { return coerceID(v) },
+		ParseValue:  func(v interface{}) (interface{}, error) { return coerceID(v) },
+	}
+	// DateTime 是 RFC 3339 格式的时间，例如 2024-01-02T15:04:05+08:00，转换后的值为 time.Time。
+	DateTime = &Scalar{
+		Name:        "DateTime",
+		Description: "A date-time string in RFC 3339 format, e.g. `2024-01-02T15:04:05+08:00`.",
+		Serialize: func(v interface{}) (interface{}, error) {
+			switch v := v.(type) {
+			case time.Time:
+				return v.Format(time.RFC3339), nil
+			case *time.Time:
+				return v.Format(time.RFC3339), nil
+			}
+			return nil, fmt.Errorf("DateTime cannot represent value: %v", v)
+		},
+		ParseValue: func(v interface{}) (interface{}, error) {
+			s, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("DateTime cannot represent a non string value: %v", v)
+			}
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return nil, fmt.Errorf("DateTime cannot represent an invalid date-time string: %q", s)
+			}
+			return t, nil
+		},
+	}
+)
+
+// coerceInt 将数字转换为 32 位范围内的 int，不接受有小数部分的数字和字符串。
+func coerceInt(v interface{}) (interface{}, error) {
+	n, ok := toFloat(v)
+	if !ok {
+		return nil, fmt.Errorf("Int cannot represent non-integer value: %v", v)
+	}
+	if n != math.Trunc(n) {
+		return nil, fmt.Errorf("Int cannot represent non-integer value: %v", v)
+	}
+	if n > math.MaxInt32 || n < math.MinInt32 {
+		return nil, fmt.Errorf("Int cannot represent non 32-bit signed integer value: %v", v)
+	}
+	return int(n), nil
+}
+
+// coerceFloat 将数字转换为 float64。
+func coerceFloat(v interface{}) (interface{}, error) {
+	n, ok := toFloat(v)
+	if !ok || math.IsInf(n, 0) || math.IsNaN(n) {
+		return nil, fmt.Errorf("Float cannot represent non numeric value: %v", v)
+	}
+	return n, nil
+}
+
+// coerceID 将字符串或整数转换为 string。
+func coerceID(v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v), nil
+	}
+	if n, ok := toFloat(v); ok && n == math.Trunc(n) && math.Abs(n) < 1<<53 {
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	}
+	return nil, fmt.Errorf("ID cannot represent value: %v", v)
+}
+
+// toFloat 将各种数字类型转换为 float64。
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,285 @@
+package graphql
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 输入值的转换 (https://spec.graphql.org/October2021/#sec-Input-Values)。
+// 转换后标量为 ParseValue 的返回值，枚举为 EnumValue.Value，列表为 []interface{}，输入对象为 map[string]interface{}。
+
+// coerceVariable 将请求中的变量值（JSON 解码后的值）按类型 t 转换。
+func coerceVariable(v interface{}, t Type) (interface{}, error) {
+	if nn, ok := t.(*NonNull); ok {
+		if v == nil {
+			return nil, fmt.Errorf("Expected non-nullable type %q not to be null.", t)
+		}
+		return coerceVariable(v, nn.OfType)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch t := t.(type) {
+	case *List:
+		items, ok := v.([]interface{})
+		if !ok {
+			// 单个值视为只有一个元素的列表
+			item, err := coerceVariable(v, t.OfType)
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{item}, nil
+		}
+		result := make([]interface{}, len(items))
+		for i, item := range items {
+			c, err := coerceVariable(item, t.OfType)
+			if err != nil {
+				return nil, fmt.Errorf("at index %d: %v", i, err)
+			}
+			result[i] = c
+		}
+		return result, nil
+	case *InputObject:
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Expected type %q to be an object.", t.Name)
+		}
+		return coerceInputObject(t, func(name string) (interface{}, bool, error) {
+			raw, ok := obj[name]
+			return raw, ok, nil
+		}, obj, coerceVariable)
+	case *Enum:
+		name, ok := v.(string)
+		if ok {
+			if ev := t.byName(name); ev != nil {
+				return ev.Value, nil
+			}
+		}
+		return nil, fmt.Errorf("Value %s does not exist in %q enum.", formatJSONValue(v), t.Name)
+	case *Scalar:
+		return t.ParseValue(v)
+	}
+	return nil, fmt.Errorf("Type %q is not an input type.", t)
+}
+
+// coerceInputObject 转换输入对象。lookup 返回字段的原始值和是否提供了该字段，
+// provided 是提供的字段集合，用于检查未知字段，coerce 转换单个字段的值。
+func coerceInputObject(t *InputObject, lookup func(name string) (interface{}, bool, error), provided map[string]interface{},
+	coerce func(v interface{}, t Type) (interface{}, error)) (interface{}, error) {
+	known := map[string]bool{}
+	result := map[string]interface{}{}
+	for _, field := range t.Fields {
+		known[field.Name] = true
+		raw, ok, err := lookup(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			if field.DefaultValue != nil {
+				result[field.Name] = field.DefaultValue
+			} else if isNonNullType(field.Type) {
+				return nil, fmt.Errorf("Field %q of required type %q was not provided.", t.Name+"."+field.Name, field.Type)
+			}
+			continue
+		}
+		c, err := coerce(raw, field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("in field %q: %v", field.Name, err)
+		}
+		result[field.Name] = c
+	}
+	var unknown []string
+	for name := range provided {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("Field %q is not defined by type %q.", unknown[0], t.Name)
+	}
+	return result, nil
+}
+
+// literalCoercer 转换查询中的字面量，vars 是已经转换过的变量值。
+// 引用了没有提供的变量时，值视为没有提供（present 为 false），由调用方决定使用默认值还是报错。
+type literalCoercer struct {
+	vars map[string]interface{}
+}
+
+// coerce 将字面量 v 按类型 t 转换。
+func (c *literalCoercer) coerce(v *value, t Type) (result interface{}, present bool, err error) {
+	if v.kind == valVariable {
+		val, ok := c.vars[v.raw]
+		if !ok {
+			return nil, false, nil
+		}
+		if val == nil && isNonNullType(t) {
+			return nil, false, fmt.Errorf("Variable \"$%s\" of non-null type %q must not be null.", v.raw, t)
+		}
+		return val, true, nil
+	}
+
+	if nn, ok := t.(*NonNull); ok {
+		if v.kind == valNull {
+			return nil, false, fmt.Errorf("Expected value of type %q, found null.", t)
+		}
+		val, present, err := c.coerce(v, nn.OfType)
+		if err == nil && !present {
+			return nil, false, fmt.Errorf("Expected value of type %q, found %s; the variable was not provided.", t, v)
+		}
+		return val, present, err
+	}
+	if v.kind == valNull {
+		return nil, true, nil
+	}
+
+	switch t := t.(type) {
+	case *List:
+		if v.kind != valList {
+			item, present, err := c.coerce(v, t.OfType)
+			if err != nil || !present {
+				return nil, present, err
+			}
+			return []interface{}{item}, true, nil
+		}
+		items := make([]interface{}, len(v.list))
+		for i, item := range v.list {
+			val, _, err := c.coerce(item, t.OfType)
+			if err != nil {
+				return nil, false, err
+			}
+			items[i] = val
+		}
+		return items, true, nil
+	case *InputObject:
+		if v.kind != valObject {
+			return nil, false, fmt.Errorf("Expected value of type %q, found %s.", t.Name, v)
+		}
+		fields := map[string]*value{}
+		provided := map[string]interface{}{}
+		for _, f := range v.fields {
+			if _, dup := fields[f.name]; dup {
+				return nil, false, fmt.Errorf("There can be only one input field named %q.", f.name)
+			}
+			fields[f.name] = f.value
+			provided[f.name] = nil
+		}
+		obj, err := coerceInputObject(t, func(name string) (interface{}, bool, error) {
+			fv, ok := fields[name]
+			if !ok {
+				return nil, false, nil
+			}
+			val, present, err := c.coerce(fv, findArg(t.Fields, name).Type)
+			return val, present, err
+		}, provided, func(v interface{}, _ Type) (interface{}, error) { return v, nil })
+		if err != nil {
+			return nil, false, err
+		}
+		return obj, true, nil
+	case *Enum:
+		if v.kind != valEnum {
+			return nil, false, fmt.Errorf("Enum %q cannot represent non-enum value: %s.", t.Name, v)
+		}
+		ev := t.byName(v.raw)
+		if ev == nil {
+			return nil, false, fmt.Errorf("Value %q does not exist in %q enum.", v.raw, t.Name)
+		}
+		return ev.Value, true, nil
+	case *Scalar:
+		raw, err := literalToGo(v)
+		if err != nil {
+			return nil, false, err
+		}
+		val, err := t.ParseValue(raw)
+		if err != nil {
+			return nil, false, fmt.Errorf("Expected value of type %q, found %s; %v", t.Name, v, err)
+		}
+		return val, true, nil
+	}
+	return nil, false, fmt.Errorf("Type %q is not an input type.", t)
+}
+
+// literalToGo 将标量字面量转换为 Go 值，整数为 int64，浮点数为 float64。
+func literalToGo(v *value) (interface{}, error) {
+	switch v.kind {
+	case valInt:
+		if n, err := strconv.ParseInt(v.raw, 10, 64); err == nil {
+			return n, nil
+		}
+		return strconv.ParseFloat(v.raw, 64)
+	case valFloat:
+		return strconv.ParseFloat(v.raw, 64)
+	case valString:
+		return v.raw, nil
+	case valBoolean:
+		return v.raw == "true", nil
+	}
+	return nil, fmt.Errorf("Expected a scalar value, found %s.", v)
+}
+
+// findArg 返回名称为 name 的参数。
+func findArg(args []*Argument, name string) *Argument {
+	for _, arg := range args {
+		if arg.Name == name {
+			return arg
+		}
+	}
+	return nil
+}
+
+// formatJSONValue 返回 JSON 值在错误消息中的写法。
+func formatJSONValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return strconv.Quote(s)
+	}
+	return fmt.Sprint(v)
+}
+
+// printValue 将转换后的输入值按类型 t 输出为 GraphQL 字面量，用于内省中参数的默认值。
+func printValue(v interface{}, t Type) string {
+	if nn, ok := t.(*NonNull); ok {
+		t = nn.OfType
+	}
+	if v == nil {
+		return "null"
+	}
+	switch t := t.(type) {
+	case *List:
+		rv := reflect.ValueOf(v)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return printValue(v, t.OfType)
+		}
+		items := make([]string, rv.Len())
+		for i := range items {
+			items[i] = printValue(rv.Index(i).Interface(), t.OfType)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case *InputObject:
+		obj, _ := v.(map[string]interface{})
+		var fields []string
+		for _, f := range t.Fields {
+			if fv, ok := obj[f.Name]; ok {
+				fields = append(fields, f.Name+": "+printValue(fv, f.Type))
+			}
+		}
+		return "{" + strings.Join(fields, ", ") + "}"
+	case *Enum:
+		if ev := t.byValue(v); ev != nil {
+			return ev.Name
+		}
+	case *Scalar:
+		s, err := t.Serialize(v)
+		if err != nil {
+			return "null"
+		}
+		if str, ok := s.(string); ok {
+			return strconv.Quote(str)
+		}
+		return fmt.Sprint(s)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,270 @@
+// package i18n 负责接口消息的本地化和语言协商。
+//
+// 源码中的消息（例如 apperr.Error 的 Message）使用简体中文书写，简体中文的消息目录就是源码本身；
+// 其他语言的消息目录位于 locales 目录下，每种语言一个 JSON 文件，文件名为语言标签，内容是消息键到消息模板的映射。
+// 查找消息时依次尝试请求的语言、它的基础语言（例如 en-GB 的 en）、配置的默认语言，最后使用源码中的简体中文消息。
+// 添加一种语言只需新增一个 JSON 文件，运行 go run ./cmd/i18n -check 可以检查翻译是否完整。
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/KeLes-Coding/gopress/internal/apperr"
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// Source 是源码中消息使用的语言。
+const Source = "zh-CN"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+var (
+	loadOnce sync.Once
+	catalogs map[string]map[string]string // 语言标签 -> 消息键 -> 消息模板
+	loadErr  error
+)
+
+// load 读取内嵌的消息目录。消息目录随程序编译，格式错误属于程序错误，由 Check 报告。
+func load() {
+	catalogs = make(map[string]map[string]string)
+	files, err := localeFS.ReadDir("locales")
+	if err != nil {
+		loadErr = err
+		return
+	}
+	for _, f := range files {
+		lang := strings.TrimSuffix(f.Name(), path.Ext(f.Name()))
+		data, err := localeFS.ReadFile("locales/" + f.Name())
+		if err != nil {
+			loadErr = err
+			return
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(data, &messages); err != nil {
+			loadErr = fmt.Errorf("%s: %w", f.Name(), err)
+			return
+		}
+		catalogs[lang] = messages
+	}
+}
+
+func catalog(lang string) map[string]string {
+	loadOnce.Do(load)
+	return catalogs[lang]
+}
+
+// Languages 返回所有支持的语言，源语言排在第一位。
+func Languages() []string {
+	loadOnce.Do(load)
+	langs := make([]string, 0, len(catalogs)+1)
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return append([]string{Source}, langs...)
+}
+
+// Match 返回与语言标签 tag 对应的受支持语言，比较时不区分大小写。
+// 没有完全相同的语言时，en-US 可以匹配 en，zh 可以匹配 zh-CN。
+func Match(tag string) (string, bool) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", false
+	}
+	langs := Languages()
+	for _, lang := range langs {
+		if strings.EqualFold(lang, tag) {
+			return lang, true
+		}
+	}
+	for _, lang := range langs {
+		if strings.EqualFold(base(lang), base(tag)) {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// base 返回语言标签的基础语言，例如 zh-CN 的 zh。
+func base(tag string) string {
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		return tag[:i]
+	}
+	return tag
+}
+
+// Default 返回配置的默认语言，没有配置或配置的语言不受支持时返回源语言。
+func Default() string {
+	if config.Conf != nil {
+		if lang, ok := Match(config.Conf.I18n.DefaultLanguage); ok {
+			return lang
+		}
+	}
+	return Source
+}
+
+// Negotiate 根据 Accept-Language 请求头选择语言，按 q 值从高到低返回第一个受支持的语言，都不支持时返回默认语言。
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		tag string
+		q   float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if tag == "" || tag == "*" || q <= 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{tag, q})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	for _, c := range candidates {
+		if lang, ok := Match(c.tag); ok {
+			return lang
+		}
+	}
+	return Default()
+}
+
+// Fallbacks 返回查找 lang 的消息时依次尝试的语言，最后一个总是源语言。
+func Fallbacks(lang string) []string {
+	chain := make([]string, 0, 4)
+	add := func(l string) {
+		for _, existing := range chain {
+			if existing == l {
+				return
+			}
+		}
+		chain = append(chain, l)
+	}
+	// Match 会把 en-GB 这样的标签匹配到它的基础语言 en
+	if l, ok := Match(lang); ok {
+		add(l)
+	}
+	add(Default())
+	add(Source)
+	return chain
+}
+
+// Lookup 按回退链查找消息键对应的消息模板。回退到源语言时返回 false，调用方应使用源码中的消息。
+func Lookup(lang, key string) (string, bool) {
+	for _, l := range Fallbacks(lang) {
+		if l == Source {
+			break
+		}
+		if msg, ok := catalog(l)[key]; ok {
+			return msg, true
+		}
+	}
+	return "", false
+}
+
+// Message 返回业务错误在 lang 语言下的消息。消息参数中的业务错误也会被翻译。
+func Message(lang string, e *apperr.Error) string {
+	tmpl, ok := Lookup(lang, e.Key)
+	if !ok {
+		tmpl = e.Message
+	}
+	if len(e.Args) == 0 {
+		return tmpl
+	}
+	args := make([]interface{}, len(e.Args))
+	for i, arg := range e.Args {
+		if inner, ok := arg.(*apperr.Error); ok {
+			arg = Message(lang, inner)
+		}
+		args[i] = arg
+	}
+	return fmt.Sprintf(tmpl, args...)
+}
+
+// Error 返回错误在 lang 语言下的消息。err 不是业务错误时原样返回 err.Error()。
+func Error(lang string, err error) string {
+	if e, ok := apperr.From(err); ok {
+		return Message(lang, e)
+	}
+	return err.Error()
+}
+
+// CtxLanguageKey 是 Gin Context 中存储当前请求语言的键。
+const CtxLanguageKey = "language"
+
+// SetLanguage 设置当前请求使用的语言，并在响应头中声明内容的语言。
+func SetLanguage(c *gin.Context, lang string) {
+	c.Set(CtxLanguageKey, lang)
+	c.Header("Content-Language", lang)
+}
+
+// FromContext 返回当前请求使用的语言。没有经过语言中间件的请求根据 Accept-Language 请求头协商。
+func FromContext(c *gin.Context) string {
+	if lang := c.GetString(CtxLanguageKey); lang != "" {
+		return lang
+	}
+	return Negotiate(c.GetHeader("Accept-Language"))
+}
+
+// Check 检查所有语言的消息目录是否完整：每个登记过的业务错误都有翻译、没有多余的消息键，
+// 并且翻译中的占位符与源码中的消息一致。返回所有问题。
+func Check(errs []*apperr.Error) []string {
+	loadOnce.Do(load)
+	if loadErr != nil {
+		return []string{"读取消息目录失败: " + loadErr.Error()}
+	}
+	var problems []string
+	keys := make(map[string]*apperr.Error, len(errs))
+	for _, e := range errs {
+		keys[e.Key] = e
+	}
+	for _, lang := range Languages()[1:] {
+		messages := catalog(lang)
+		for key, e := range keys {
+			msg, ok := messages[key]
+			if !ok {
+				problems = append(problems, fmt.Sprintf("%s: 缺少 %s 的翻译 (%s)", lang, key, e.Message))
+				continue
+			}
+			if verbs(msg) != verbs(e.Message) {
+				problems = append(problems, fmt.Sprintf("%s: %s 的占位符与源消息不一致: %q / %q", lang, key, msg, e.Message))
+			}
+		}
+		for key := range messages {
+			if _, ok := keys[key]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: 消息键 %s 已不再使用", lang, key))
+			}
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// verbs 返回消息模板中 fmt 占位符的序列，例如 "%s %d"。
+func verbs(tmpl string) string {
+	var list []string
+	for i := 0; i < len(tmpl)-1; i++ {
+		if tmpl[i] != '%' {
+			continue
+		}
+		i++
+		if tmpl[i] != '%' {
+			list = append(list, "%"+string(tmpl[i]))
+		}
+	}
+	return strings.Join(list, " ")
+}
@@ -0,0 +1,203 @@
+// package imaging 提供纯 Go 实现的图片处理工具：元数据清理、缩放、WebP 编码以及占位图计算。
+// 这里的所有功能都不依赖外部程序或 cgo，便于在任何环境中部署。
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrUnsupportedFormat 表示给定的数据不是可以处理的图片格式。
+var ErrUnsupportedFormat = errors.New("unsupported image format")
+
+// StripMetadata 移除图片中的 EXIF、XMP 等元数据（包括 GPS 定位信息），返回清理后的数据。
+// 对于 JPEG、PNG 和 WebP，它只丢弃元数据片段而不重新编码像素，因此是无损的；
+// 其他格式会原样返回。
+//
+// 如果 JPEG 的 EXIF 中记录了非默认的方向，返回的 orientation 为该值，
+// 调用方需要在丢弃 EXIF 之后自行旋转像素，否则图片会以错误的方向显示。
+func StripMetadata(mimeType string, data []byte) (stripped []byte, orientation int, err error) {
+	switch mimeType {
+	case "image/jpeg":
+		return stripJPEG(data)
+	case "image/png":
+		stripped, err = stripPNG(data)
+		return stripped, 1, err
+	case "image/webp":
+		stripped, err = stripWebP(data)
+		return stripped, 1, err
+	default:
+		return data, 1, nil
+	}
+}
+
+// stripJPEG 丢弃 JPEG 中的 APP1 (EXIF/XMP)、APP13 (Photoshop/IPTC) 和注释片段。
+// APP0 (JFIF) 和 APP2 (ICC 色彩配置) 会被保留，以免影响颜色显示。
+func stripJPEG(data []byte) ([]byte, int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, 0, ErrUnsupportedFormat
+	}
+	orientation := 1
+	var out bytes.Buffer
+	out.Grow(len(data))
+	out.Write(data[:2]) // SOI
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, 0, ErrUnsupportedFormat
+		}
+		marker := data[pos+1]
+		// 标记之间允许出现填充用的 0xFF
+		if marker == 0xFF {
+			pos++
+			continue
+		}
+		// SOS 之后是熵编码的图像数据，直接原样复制剩余部分
+		if marker == 0xDA {
+			out.Write(data[pos:])
+			return out.Bytes(), orientation, nil
+		}
+		// 没有长度字段的独立标记
+		if marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			out.Write(data[pos : pos+2])
+			pos += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			return nil, 0, ErrUnsupportedFormat
+		}
+		segment := data[pos:end]
+
+		switch marker {
+		case 0xE1: // APP1: EXIF 或 XMP
+			if o := exifOrientation(segment[4:]); o != 0 {
+				orientation = o
+			}
+		case 0xED, 0xFE: // APP13 (Photoshop/IPTC) 和 COM 注释
+		default:
+			out.Write(segment)
+		}
+		pos = end
+	}
+	return nil, 0, ErrUnsupportedFormat
+}
+
+// exifOrientation 从 APP1 片段的内容中解析出方向 (Orientation, 0x0112) 标签，
+// 找不到时返回 0。
+func exifOrientation(payload []byte) int {
+	if len(payload) < 14 || string(payload[:6]) != "Exif\x00\x00" {
+		return 0
+	}
+	tiff := payload[6:]
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0
+	}
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 0
+	}
+	entries := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 0
+		}
+		if order.Uint16(tiff[entry:entry+2]) == 0x0112 {
+			value := int(order.Uint16(tiff[entry+8 : entry+10]))
+			if value >= 1 && value <= 8 {
+				return value
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+// pngSignature 是所有 PNG 文件开头的 8 字节签名。
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// pngMetadataChunks 是 PNG 中会被移除的元数据块。
+var pngMetadataChunks = map[string]bool{
+	"eXIf": true, // EXIF
+	"tEXt": true, // 文本（XMP 等常以文本块形式存储）
+	"zTXt": true,
+	"iTXt": true,
+	"tIME": true,
+}
+
+// stripPNG 丢弃 PNG 中的 EXIF 和文本块。每个块都带有独立的 CRC，保留的块可以原样复制。
+func stripPNG(data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrUnsupportedFormat
+	}
+	var out bytes.Buffer
+	out.Grow(len(data))
+	out.Write(pngSignature)
+
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, ErrUnsupportedFormat
+		}
+		chunkType := string(data[pos+4 : pos+8])
+		if !pngMetadataChunks[chunkType] {
+			out.Write(data[pos:end])
+		}
+		pos = end
+		if chunkType == "IEND" {
+			return out.Bytes(), nil
+		}
+	}
+	return nil, ErrUnsupportedFormat
+}
+
+// stripWebP 丢弃 WebP 扩展格式 (VP8X) 中的 EXIF 和 XMP 块，并清除 VP8X 头中对应的标志位。
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, ErrUnsupportedFormat
+	}
+	var body bytes.Buffer
+	body.Grow(len(data))
+	body.WriteString("WEBP")
+
+	pos := 12
+	for pos+8 <= len(data) {
+		fourCC := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + size + size%2 // 块的长度为奇数时有一个填充字节
+		if end > len(data) {
+			end = len(data)
+		}
+		chunk := append([]byte{}, data[pos:end]...)
+		switch fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			if len(chunk) > 8 {
+				// 第 3 位 (0x08) 为 EXIF 标志，第 2 位 (0x04) 为 XMP 标志
+				chunk[8] &^= 0x08 | 0x04
+			}
+			body.Write(chunk)
+		default:
+			body.Write(chunk)
+		}
+		pos = end
+	}
+
+	out := make([]byte, 8, 8+body.Len())
+	copy(out, "RIFF")
+	binary.LittleEndian.PutUint32(out[4:8], uint32(body.Len()))
+	return append(out, body.Bytes()...), nil
+}
@@ -0,0 +1,215 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// exifSegment 构造一个只包含方向标签的 APP1 (EXIF) 片段。
+func exifSegment(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:4], 42)
+	order.PutUint32(tiff[4:8], 8) // 第一个 IFD 紧跟在头部之后
+	order.PutUint16(tiff[8:10], 1)
+	entry := tiff[10:22]
+	order.PutUint16(entry[0:2], 0x0112) // Orientation
+	order.PutUint16(entry[2:4], 3)      // SHORT
+	order.PutUint32(entry[4:8], 1)
+	order.PutUint16(entry[8:10], orientation)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	return jpegSegment(0xE1, payload)
+}
+
+// jpegSegment 构造一个带长度字段的 JPEG 片段。
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := []byte{0xFF, marker, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:4], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// insertAfterSOI 在 JPEG 的 SOI 标记之后插入片段。
+func insertAfterSOI(data []byte, segments ...[]byte) []byte {
+	out := append([]byte{}, data[:2]...)
+	for _, s := range segments {
+		out = append(out, s...)
+	}
+	return append(out, data[2:]...)
+}
+
+func encodeTestJPEG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, testImage(24, 16, false), nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestStripJPEG(t *testing.T) {
+	original := encodeTestJPEG(t)
+	jfif := jpegSegment(0xE0, []byte("JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00"))
+	icc := jpegSegment(0xE2, []byte("ICC_PROFILE\x00\x01\x01"))
+	xmp := jpegSegment(0xE1, []byte("http://ns.adobe.com/xap/1.0/\x00<x:xmpmeta/>"))
+	iptc := jpegSegment(0xED, []byte("Photoshop 3.0\x00"))
+	comment := jpegSegment(0xFE, []byte("GPS 31.2304N 121.4737E"))
+
+	tests := []struct {
+		name            string
+		input           []byte
+		want            []byte
+		wantOrientation int
+	}{
+		{"没有元数据", original, original, 1},
+		{"小端 EXIF", insertAfterSOI(original, exifSegment(binary.LittleEndian, 6)), original, 6},
+		{"大端 EXIF", insertAfterSOI(original, exifSegment(binary.BigEndian, 3)), original, 3},
+		{"无效的方向值", insertAfterSOI(original, exifSegment(binary.BigEndian, 9)), original, 1},
+		{"XMP、IPTC 和注释", insertAfterSOI(original, xmp, iptc, comment), original, 1},
+		{"保留 JFIF 和 ICC", insertAfterSOI(original, jfif, exifSegment(binary.LittleEndian, 8), icc),
+			insertAfterSOI(original, jfif, icc), 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, orientation, err := StripMetadata("image/jpeg", tt.input)
+			if err != nil {
+				t.Fatalf("StripMetadata: %v", err)
+			}
+			if orientation != tt.wantOrientation {
+				t.Errorf("orientation = %d, want %d", orientation, tt.wantOrientation)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("stripped data differs from expected (%d bytes, want %d)", len(got), len(tt.want))
+			}
+			if _, err := jpeg.Decode(bytes.NewReader(got)); err != nil {
+				t.Errorf("stripped JPEG does not decode: %v", err)
+			}
+		})
+	}
+}
+
+// pngChunk 构造一个带 CRC 的 PNG 块。
+func pngChunk(chunkType string, data []byte) []byte {
+	chunk := make([]byte, 8, 12+len(data))
+	binary.BigEndian.PutUint32(chunk[0:4], uint32(len(data)))
+	copy(chunk[4:8], chunkType)
+	chunk = append(chunk, data...)
+	return binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+}
+
+func TestStripPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, testImage(9, 9, true)); err != nil {
+		t.Fatal(err)
+	}
+	original := buf.Bytes()
+	// 元数据块插入在 IHDR 之后
+	ihdrEnd := len(pngSignature) + 12 + 13
+	withMetadata := append([]byte{}, original[:ihdrEnd]...)
+	for _, c := range [][]byte{
+		pngChunk("eXIf", []byte("MM\x00\x2a")),
+		pngChunk("tEXt", []byte("Comment\x00secret")),
+		pngChunk("iTXt", []byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00<x:xmpmeta/>")),
+		pngChunk("tIME", []byte{0x07, 0xea, 1, 2, 3, 4, 5}),
+	} {
+		withMetadata = append(withMetadata, c...)
+	}
+	withMetadata = append(withMetadata, original[ihdrEnd:]...)
+
+	got, orientation, err := StripMetadata("image/png", withMetadata)
+	if err != nil {
+		t.Fatalf("StripMetadata: %v", err)
+	}
+	if orientation != 1 {
+		t.Errorf("orientation = %d, want 1", orientation)
+	}
+	if !bytes.Equal(got, original) {
+		t.Errorf("stripped PNG differs from the original encoding")
+	}
+}
+
+func TestStripWebP(t *testing.T) {
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, testImage(10, 6, false)); err != nil {
+		t.Fatal(err)
+	}
+	vp8l := buf.Bytes()[12:] // 去掉 RIFF 头，只保留 VP8L 块
+
+	vp8x := make([]byte, 10)
+	vp8x[0] = 0x08 | 0x04 // EXIF 和 XMP 标志
+	vp8x[4] = 10 - 1      // 画布宽度减一，24 位小端
+	vp8x[7] = 6 - 1       // 画布高度减一
+	chunk := func(fourCC string, data []byte) []byte {
+		c := append([]byte(fourCC), 0, 0, 0, 0)
+		binary.LittleEndian.PutUint32(c[4:8], uint32(len(data)))
+		c = append(c, data...)
+		if len(data)%2 == 1 {
+			c = append(c, 0)
+		}
+		return c
+	}
+	body := []byte("WEBP")
+	body = append(body, chunk("VP8X", vp8x)...)
+	body = append(body, vp8l...)
+	body = append(body, chunk("EXIF", []byte("MM\x00\x2a\x00"))...)
+	body = append(body, chunk("XMP ", []byte("<x:xmpmeta/>"))...)
+	input := binary.LittleEndian.AppendUint32([]byte("RIFF"), uint32(len(body)))
+	input = append(input, body...)
+
+	got, _, err := StripMetadata("image/webp", input)
+	if err != nil {
+		t.Fatalf("StripMetadata: %v", err)
+	}
+	if bytes.Contains(got, []byte("EXIF")) || bytes.Contains(got, []byte("XMP ")) {
+		t.Errorf("metadata chunks were not removed")
+	}
+	if flags := got[20]; flags&(0x08|0x04) != 0 {
+		t.Errorf("VP8X flags = %#x, metadata bits should be cleared", flags)
+	}
+	if size := binary.LittleEndian.Uint32(got[4:8]); int(size) != len(got)-8 {
+		t.Errorf("RIFF size = %d, want %d", size, len(got)-8)
+	}
+	if _, err := webp.Decode(bytes.NewReader(got)); err != nil {
+		t.Errorf("stripped WebP does not decode: %v", err)
+	}
+}
+
+func TestStripMetadataInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		mimeType string
+		data     []byte
+	}{
+		{"JPEG 签名错误", "image/jpeg", []byte("not a jpeg")},
+		{"JPEG 片段被截断", "image/jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x10, 0x00, 0x01}},
+		{"PNG 签名错误", "image/png", []byte("GIF89a")},
+		{"PNG 缺少 IEND", "image/png", append(append([]byte{}, pngSignature...), pngChunk("IHDR", make([]byte, 13))...)},
+		{"WebP 签名错误", "image/webp", []byte("RIFF\x00\x00\x00\x00WAVE")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := StripMetadata(tt.mimeType, tt.data); !errors.Is(err, ErrUnsupportedFormat) {
+				t.Fatalf("err = %v, want ErrUnsupportedFormat", err)
+			}
+		})
+	}
+}
+
+func TestStripMetadataOtherFormats(t *testing.T) {
+	data := []byte("GIF89a...")
+	got, orientation, err := StripMetadata("image/gif", data)
+	if err != nil || orientation != 1 || !bytes.Equal(got, data) {
+		t.Fatalf("StripMetadata(gif) = %q, %d, %v; want data unchanged", got, orientation, err)
+	}
+}
@@ -0,0 +1,150 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+)
+
+// placeholderSize 是计算占位信息前先把图片缩小到的最大边长，
+// BlurHash 只描述低频信息，在小图上计算即可，速度快得多。
+const placeholderSize = 64
+
+// BlurHash 计算图片的 BlurHash 字符串（https://blurha.sh），前端可以用它在图片加载前绘制模糊的占位图。
+// xComponents 和 yComponents 为水平和垂直方向上的分量数，取值范围 1-9，通常使用 4x3。
+func BlurHash(img image.Image, xComponents, yComponents int) string {
+	img = Fit(img, placeholderSize, placeholderSize)
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	// 预先把所有像素转换到线性色彩空间
+	pixels := make([][3]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			pixels[y*w+x] = [3]float64{
+				sRGBToLinear(int(r >> 8)),
+				sRGBToLinear(int(g >> 8)),
+				sRGBToLinear(int(bl >> 8)),
+			}
+		}
+	}
+
+	// 计算每个分量的 DCT 系数
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1.0
+			}
+			var sum [3]float64
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					basis := normalisation *
+						math.Cos(math.Pi*float64(i)*float64(x)/float64(w)) *
+						math.Cos(math.Pi*float64(j)*float64(y)/float64(h))
+					p := pixels[y*w+x]
+					sum[0] += basis * p[0]
+					sum[1] += basis * p[1]
+					sum[2] += basis * p[2]
+				}
+			}
+			scale := 1.0 / float64(w*h)
+			factors = append(factors, [3]float64{sum[0] * scale, sum[1] * scale, sum[2] * scale})
+		}
+	}
+
+	var hash strings.Builder
+	// 1. 分量数
+	hash.WriteString(encodeBase83((xComponents-1)+(yComponents-1)*9, 1))
+
+	// 2. 交流分量的最大值
+	maximumValue := 1.0
+	ac := factors[1:]
+	if len(ac) > 0 {
+		actualMaximum := 0.0
+		for _, f := range ac {
+			actualMaximum = math.Max(actualMaximum, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+		}
+		quantisedMaximum := int(math.Max(0, math.Min(82, math.Floor(actualMaximum*166-0.5))))
+		maximumValue = float64(quantisedMaximum+1) / 166
+		hash.WriteString(encodeBase83(quantisedMaximum, 1))
+	} else {
+		hash.WriteString(encodeBase83(0, 1))
+	}
+
+	// 3. 直流分量（平均颜色）
+	dc := factors[0]
+	hash.WriteString(encodeBase83(linearToSRGB(dc[0])<<16+linearToSRGB(dc[1])<<8+linearToSRGB(dc[2]), 4))
+
+	// 4. 交流分量
+	for _, f := range ac {
+		quantise := func(v float64) int {
+			return int(math.Max(0, math.Min(18, math.Floor(signPow(v/maximumValue, 0.5)*9+9.5))))
+		}
+		hash.WriteString(encodeBase83(quantise(f[0])*19*19+quantise(f[1])*19+quantise(f[2]), 2))
+	}
+	return hash.String()
+}
+
+// DominantColor 返回图片的主色调（所有像素的平均颜色），格式为 "#rrggbb"。
+func DominantColor(img image.Image) string {
+	img = Fit(img, placeholderSize, placeholderSize)
+	b := img.Bounds()
+	var r, g, bl, n float64
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			pr, pg, pb, pa := img.At(x, y).RGBA()
+			// 完全透明的像素不参与计算
+			if pa == 0 {
+				continue
+			}
+			r += sRGBToLinear(int(pr >> 8))
+			g += sRGBToLinear(int(pg >> 8))
+			bl += sRGBToLinear(int(pb >> 8))
+			n++
+		}
+	}
+	if n == 0 {
+		return "#ffffff"
+	}
+	return fmt.Sprintf("#%02x%02x%02x", linearToSRGB(r/n), linearToSRGB(g/n), linearToSRGB(bl/n))
+}
+
+// base83Chars 是 BlurHash 使用的 Base83 字符表。
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// encodeBase83 将 value 编码为固定长度的 Base83 字符串。
+func encodeBase83(value, length int) string {
+	result := make([]byte, length)
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		result[i-1] = base83Chars[digit]
+	}
+	return string(result)
+}
+
+// sRGBToLinear 将 0-255 的 sRGB 分量转换为 0-1 的线性值。
+func sRGBToLinear(value int) float64 {
+	v := float64(value) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB 将 0-1 的线性值转换为 0-255 的 sRGB 分量。
+func linearToSRGB(value float64) int {
+	v := math.Max(0, math.Min(1, value))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+// signPow 计算保留符号的幂。
+func signPow(value, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(value), exp), value)
+}
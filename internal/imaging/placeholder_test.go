@@ -0,0 +1,115 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// solidImage 生成一张纯色图片。
+func solidImage(width, height int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// gradientImage 生成一张水平方向红色、垂直方向绿色渐变的图片。
+func gradientImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 32, 24))
+	for y := 0; y < 24; y++ {
+		for x := 0; x < 32; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 8), G: uint8(y * 10), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func TestBlurHash(t *testing.T) {
+	tests := []struct {
+		name         string
+		img          image.Image
+		xComp, yComp int
+		want         string
+	}{
+		// 直流分量是原来的颜色 0x123456 = "27F4"。与参考实现一样，余弦基函数在离散采样上的和不为 0，
+		// 纯色图片的交流分量也有很小的非零值
+		{"纯色 4x3", solidImage(40, 30, color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}), 4, 3,
+			"L027F4pLfQpLpffkfQfkfQfQfQfQ"},
+		// 白色的直流分量 "TSUA" 与参考实现的结果一致
+		{"纯色 1x1", solidImage(5, 5, color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}), 1, 1, "00TSUA"},
+		{"渐变 4x3", gradientImage(), 4, 3, "LxH27k2swxX8mHWWjtf7gJfjfQfj"},
+		{"渐变 1x1", gradientImage(), 1, 1, "00H27k"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BlurHash(tt.img, tt.xComp, tt.yComp)
+			if got != tt.want {
+				t.Errorf("BlurHash = %q, want %q", got, tt.want)
+			}
+			if wantLen := 4 + 2*tt.xComp*tt.yComp; len(got) != wantLen {
+				t.Errorf("len = %d, want %d", len(got), wantLen)
+			}
+		})
+	}
+}
+
+func TestBlurHashLargeImage(t *testing.T) {
+	// 大图先缩小再计算，平均颜色（第 3 到 6 个字符）与小图相同
+	small := solidImage(16, 12, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+	large := solidImage(1600, 1200, color.NRGBA{R: 200, G: 100, B: 50, A: 255})
+	a, b := BlurHash(small, 4, 3), BlurHash(large, 4, 3)
+	if a[2:6] != b[2:6] {
+		t.Errorf("average color differs: %q vs %q", a, b)
+	}
+	if len(a) != len(b) {
+		t.Errorf("length differs: %q vs %q", a, b)
+	}
+}
+
+func TestDominantColor(t *testing.T) {
+	transparent := solidImage(4, 4, color.NRGBA{R: 0, G: 0, B: 0, A: 0})
+	half := solidImage(4, 4, color.NRGBA{R: 0xff, G: 0, B: 0, A: 0xff})
+	for x := 0; x < 4; x++ {
+		for y := 0; y < 2; y++ {
+			half.SetNRGBA(x, y, color.NRGBA{})
+		}
+	}
+	tests := []struct {
+		name string
+		img  image.Image
+		want string
+	}{
+		{"纯色", solidImage(8, 8, color.NRGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}), "#123456"},
+		{"全透明", transparent, "#ffffff"},
+		{"忽略透明像素", half, "#ff0000"},
+		{"渐变", gradientImage(), "#948a80"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DominantColor(tt.img); got != tt.want {
+				t.Errorf("DominantColor = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeBase83(t *testing.T) {
+	tests := []struct {
+		value, length int
+		want          string
+	}{
+		{0, 1, "0"},
+		{82, 1, "~"},
+		{83, 2, "10"},
+		{0x123456, 4, "27F4"},
+	}
+	for _, tt := range tests {
+		if got := encodeBase83(tt.value, tt.length); got != tt.want {
+			t.Errorf("encodeBase83(%d, %d) = %q, want %q", tt.value, tt.length, got, tt.want)
+		}
+	}
+}
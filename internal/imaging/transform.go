@@ -0,0 +1,109 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Orient 根据 EXIF 方向值 (1-8) 旋转或翻转图片，使其以正确的方向显示。
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	// 方向 5-8 需要交换宽高
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // 水平翻转
+				dx, dy = w-1-x, y
+			case 3: // 旋转 180°
+				dx, dy = w-1-x, h-1-y
+			case 4: // 垂直翻转
+				dx, dy = x, h-1-y
+			case 5: // 沿左上-右下对角线翻转
+				dx, dy = y, x
+			case 6: // 顺时针旋转 90°
+				dx, dy = h-1-y, x
+			case 7: // 沿右上-左下对角线翻转
+				dx, dy = h-1-y, w-1-x
+			case 8: // 逆时针旋转 90°
+				dx, dy = y, w-1-x
+			}
+			dst.Set(dx, dy, img.At(b.Min.X+x, b.Min.Y+y))
+		}
+	}
+	return dst
+}
+
+// Fit 将图片等比缩放到不超过 maxWidth x maxHeight 的尺寸，任一边为 0 表示该方向不限制。
+// 图片不会被放大，已经足够小时原样返回。
+func Fit(img image.Image, maxWidth, maxHeight int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	scale := 1.0
+	if maxWidth > 0 && w > maxWidth {
+		scale = float64(maxWidth) / float64(w)
+	}
+	if maxHeight > 0 && float64(h)*scale > float64(maxHeight) {
+		scale = float64(maxHeight) / float64(h)
+	}
+	if scale >= 1 {
+		return img
+	}
+	dw := max(1, int(float64(w)*scale+0.5))
+	dh := max(1, int(float64(h)*scale+0.5))
+	return scaleTo(img, b, dw, dh)
+}
+
+// Fill 将图片缩放并居中裁剪为恰好 width x height 的尺寸，常用于生成正方形缩略图。
+func Fill(img image.Image, width, height int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if width <= 0 || height <= 0 {
+		return img
+	}
+	// 先按目标宽高比计算裁剪区域，再缩放到目标尺寸
+	src := b
+	if w*height > h*width {
+		cw := h * width / height
+		src.Min.X = b.Min.X + (w-cw)/2
+		src.Max.X = src.Min.X + cw
+	} else {
+		ch := w * height / width
+		src.Min.Y = b.Min.Y + (h-ch)/2
+		src.Max.Y = src.Min.Y + ch
+	}
+	if src.Dx() <= width && src.Dy() <= height {
+		// 原图比目标尺寸还小，只裁剪不放大
+		dst := image.NewNRGBA(image.Rect(0, 0, src.Dx(), src.Dy()))
+		draw.Draw(dst, dst.Bounds(), img, src.Min, draw.Src)
+		return dst
+	}
+	return scaleTo(img, src, width, height)
+}
+
+// scaleTo 使用 Catmull-Rom 插值将 img 的 src 区域缩放到 w x h。
+func scaleTo(img image.Image, src image.Rectangle, w, h int) image.Image {
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, src, xdraw.Src, nil)
+	return dst
+}
+
+// Flatten 将带透明通道的图片合成到白色背景上，用于输出不支持透明度的 JPEG。
+func Flatten(img image.Image) image.Image {
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,519 @@
+package imaging
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"sort"
+)
+
+// MaxWebPDimension 是 WebP 图片宽高的上限，VP8L 头中的宽高字段只有 14 位。
+const MaxWebPDimension = 1 << 14
+
+// ErrWebPDimensions 表示图片的宽或高超出了 WebP 能表示的范围 (1-16384)。
+var ErrWebPDimensions = errors.New("image dimensions out of range for WebP")
+
+// EncodeWebP 将图片编码为无损 WebP (VP8L) 格式并写入 w。
+//
+// 这是一个精简的纯 Go 编码器：它使用“减绿”和“预测”两种变换，配合针对每张图片构建的
+// 哈夫曼编码来压缩像素，但不使用 LZ77 反向引用和颜色缓存。
+// 对于缩放后的图片变体，它的压缩率足以满足需求，并且不需要 cgo 或外部程序。
+// 宽或高超过 MaxWebPDimension 时返回 ErrWebPDimensions，调用方需要先缩小图片。
+func EncodeWebP(w io.Writer, img image.Image) error {
+	b := img.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width < 1 || height < 1 || width > MaxWebPDimension || height > MaxWebPDimension {
+		return ErrWebPDimensions
+	}
+
+	// 1. 转换为非预乘透明度的 ARGB 像素
+	nrgba, ok := img.(*image.NRGBA)
+	if !ok || nrgba.Rect.Min != (image.Point{}) {
+		nrgba = image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(nrgba, nrgba.Bounds(), img, b.Min, draw.Src)
+	}
+	argb := make([]uint32, width*height)
+	hasAlpha := false
+	for y := 0; y < height; y++ {
+		row := nrgba.Pix[y*nrgba.Stride:]
+		for x := 0; x < width; x++ {
+			p := row[x*4 : x*4+4]
+			if p[3] != 0xff {
+				hasAlpha = true
+			}
+			argb[y*width+x] = uint32(p[3])<<24 | uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+		}
+	}
+
+	bw := &bitWriter{}
+	// 2. VP8L 头：签名、宽高、透明度提示和版本号
+	bw.write(0x2f, 8)
+	bw.write(uint64(width-1), 14)
+	bw.write(uint64(height-1), 14)
+	if hasAlpha {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 3)
+
+	// 3. 变换：先减绿，再预测。解码器会按相反的顺序还原。
+	subtractGreen(argb)
+	bw.write(1, 1)
+	bw.write(transformSubtractGreen, 2)
+
+	modes, residuals := predict(argb, width, height)
+	bw.write(1, 1)
+	bw.write(transformPredictor, 2)
+	bw.write(predictorBits-2, 3)
+	writeImageData(bw, modes, false)
+
+	bw.write(0, 1) // 没有更多变换
+
+	// 4. 主图像数据
+	writeImageData(bw, residuals, true)
+	data := bw.bytes()
+
+	// 5. RIFF 容器
+	chunkSize := len(data)
+	padding := chunkSize % 2
+	header := make([]byte, 20)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(4+8+chunkSize+padding))
+	copy(header[8:12], "WEBP")
+	copy(header[12:16], "VP8L")
+	binary.LittleEndian.PutUint32(header[16:20], uint32(chunkSize))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if padding == 1 {
+		_, err := w.Write([]byte{0})
+		return err
+	}
+	return nil
+}
+
+// VP8L 的变换类型。
+const (
+	transformPredictor     = 0
+	transformSubtractGreen = 2
+)
+
+// predictorBits 是预测变换的块大小 (1<<predictorBits 像素)。
+const predictorBits = 4
+
+// 编码器使用的预测模式：左侧像素、上方像素，以及两者的平均值。
+var predictorModes = []uint32{1, 2, 7}
+
+// subtractGreen 将每个像素的红色和蓝色分量减去绿色分量。
+func subtractGreen(argb []uint32) {
+	for i, p := range argb {
+		g := (p >> 8) & 0xff
+		r := ((p >> 16) - g) & 0xff
+		b := (p - g) & 0xff
+		argb[i] = p&0xff00ff00 | r<<16 | b
+	}
+}
+
+// predict 为每个块选择残差最小的预测模式，返回模式子图和残差图像。
+func predict(argb []uint32, width, height int) (modes []uint32, residuals []uint32) {
+	blockSize := 1 << predictorBits
+	tilesX := (width + blockSize - 1) / blockSize
+	tilesY := (height + blockSize - 1) / blockSize
+	modes = make([]uint32, tilesX*tilesY)
+	residuals = make([]uint32, len(argb))
+
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			bestMode, bestCost := predictorModes[0], -1
+			for _, mode := range predictorModes {
+				cost := 0
+				forEachInTile(tx, ty, width, height, func(x, y int) {
+					cost += residualCost(sub(argb[y*width+x], predictPixel(argb, width, x, y, mode)))
+				})
+				if bestCost < 0 || cost < bestCost {
+					bestMode, bestCost = mode, cost
+				}
+			}
+			// 模式存放在子图像素的绿色分量中
+			modes[ty*tilesX+tx] = 0xff000000 | bestMode<<8
+			forEachInTile(tx, ty, width, height, func(x, y int) {
+				residuals[y*width+x] = sub(argb[y*width+x], predictPixel(argb, width, x, y, bestMode))
+			})
+		}
+	}
+	return modes, residuals
+}
+
+// forEachInTile 遍历块 (tx, ty) 内的所有像素。
+func forEachInTile(tx, ty, width, height int, fn func(x, y int)) {
+	blockSize := 1 << predictorBits
+	for y := ty * blockSize; y < min((ty+1)*blockSize, height); y++ {
+		for x := tx * blockSize; x < min((tx+1)*blockSize, width); x++ {
+			fn(x, y)
+		}
+	}
+}
+
+// predictPixel 按照 VP8L 规范计算 (x, y) 处像素的预测值。
+// 第一行和第一列有固定的预测规则，与块选择的模式无关。
+func predictPixel(argb []uint32, width, x, y int, mode uint32) uint32 {
+	switch {
+	case x == 0 && y == 0:
+		return 0xff000000
+	case y == 0:
+		return argb[y*width+x-1]
+	case x == 0:
+		return argb[(y-1)*width+x]
+	}
+	left := argb[y*width+x-1]
+	top := argb[(y-1)*width+x]
+	switch mode {
+	case 1:
+		return left
+	case 2:
+		return top
+	default: // 7: Average2(L, T)
+		return average2(left, top)
+	}
+}
+
+// average2 逐分量计算两个像素的平均值（向下取整）。
+func average2(a, b uint32) uint32 {
+	return (((a ^ b) & 0xfefefefe) >> 1) + (a & b)
+}
+
+// sub 逐分量计算 a - b（模 256）。
+func sub(a, b uint32) uint32 {
+	alphaGreen := 0x00ff00ff + (a & 0xff00ff00) - (b & 0xff00ff00)
+	redBlue := 0xff00ff00 + (a & 0x00ff00ff) - (b & 0x00ff00ff)
+	return (alphaGreen & 0xff00ff00) | (redBlue & 0x00ff00ff)
+}
+
+// residualCost 估算一个残差像素的编码代价：各分量按有符号数取绝对值后求和。
+func residualCost(p uint32) int {
+	cost := 0
+	for shift := 0; shift < 32; shift += 8 {
+		v := int(int8(p >> shift))
+		if v < 0 {
+			v = -v
+		}
+		cost += v
+	}
+	return cost
+}
+
+// 各个哈夫曼编码的字母表大小：绿色（含 24 个长度前缀）、红色、蓝色、透明度和距离。
+var alphabetSizes = [5]int{256 + 24, 256, 256, 256, 40}
+
+// writeImageData 将像素以“只有字面量”的方式进行熵编码并写入。
+// topLevel 表示主图像，主图像比变换子图像多一个“元前缀编码”标志位。
+func writeImageData(bw *bitWriter, argb []uint32, topLevel bool) {
+	bw.write(0, 1) // 不使用颜色缓存
+	if topLevel {
+		bw.write(0, 1) // 不使用元前缀编码，整张图共享同一组哈夫曼编码
+	}
+
+	// 统计各分量的直方图
+	var histograms [5][]int
+	for i := range histograms {
+		histograms[i] = make([]int, alphabetSizes[i])
+	}
+	for _, p := range argb {
+		histograms[0][(p>>8)&0xff]++
+		histograms[1][(p>>16)&0xff]++
+		histograms[2][p&0xff]++
+		histograms[3][p>>24]++
+	}
+
+	var codes [5]huffmanCode
+	for i := range codes {
+		codes[i] = writeHuffmanCode(bw, histograms[i])
+	}
+
+	for _, p := range argb {
+		codes[0].writeSymbol(bw, int((p>>8)&0xff))
+		codes[1].writeSymbol(bw, int((p>>16)&0xff))
+		codes[2].writeSymbol(bw, int(p&0xff))
+		codes[3].writeSymbol(bw, int(p>>24))
+	}
+}
+
+// huffmanCode 保存每个符号的码长和（已按位反转的）码字。
+type huffmanCode struct {
+	lengths []int
+	codes   []uint64
+}
+
+// writeSymbol 写入一个符号的码字。只有一个符号的编码码长为 0，不占用任何位。
+func (c huffmanCode) writeSymbol(bw *bitWriter, symbol int) {
+	if n := c.lengths[symbol]; n > 0 {
+		bw.write(c.codes[symbol], uint(n))
+	}
+}
+
+// codeLengthCodeOrder 是码长编码的码长在比特流中的写入顺序。
+var codeLengthCodeOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// writeHuffmanCode 根据直方图构建哈夫曼编码，写入它的描述，并返回用于编码符号的码表。
+func writeHuffmanCode(bw *bitWriter, histogram []int) huffmanCode {
+	var used []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+
+	// 最多两个符号且都小于 256 时使用“简单编码”。符号按升序写入，
+	// 这样无论解码器按写入顺序还是按符号大小分配码字，结果都一致。
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		lengths := make([]int, len(histogram))
+		codes := make([]uint64, len(histogram))
+		bw.write(1, 1)
+		if len(used) == 0 {
+			used = []int{0}
+		}
+		bw.write(uint64(len(used)-1), 1)
+		if used[0] < 2 {
+			bw.write(0, 1)
+			bw.write(uint64(used[0]), 1)
+		} else {
+			bw.write(1, 1)
+			bw.write(uint64(used[0]), 8)
+		}
+		if len(used) == 2 {
+			bw.write(uint64(used[1]), 8)
+			lengths[used[0]], lengths[used[1]] = 1, 1
+			codes[used[1]] = 1
+		}
+		return huffmanCode{lengths: lengths, codes: codes}
+	}
+
+	// 普通编码：先计算码长，再用“码长编码”压缩码长序列
+	lengths := huffmanLengths(histogram, 15)
+	bw.write(0, 1)
+
+	tokens, extras := codeLengthTokens(lengths)
+	tokenHistogram := make([]int, 19)
+	for _, t := range tokens {
+		tokenHistogram[t]++
+	}
+	tokenLengths := huffmanLengths(tokenHistogram, 7)
+	tokenCodes := canonicalCodes(tokenLengths)
+
+	numCodes := 4
+	for i := len(codeLengthCodeOrder) - 1; i >= 4; i-- {
+		if tokenLengths[codeLengthCodeOrder[i]] > 0 {
+			numCodes = i + 1
+			break
+		}
+	}
+	bw.write(uint64(numCodes-4), 4)
+	for i := 0; i < numCodes; i++ {
+		bw.write(uint64(tokenLengths[codeLengthCodeOrder[i]]), 3)
+	}
+
+	bw.write(0, 1) // 码长序列覆盖整个字母表
+	// 只有一个码长符号时，它的码长为 0，不占用任何位
+	singleToken := countNonZero(tokenLengths) == 1
+	for i, t := range tokens {
+		if !singleToken {
+			bw.write(tokenCodes[t], uint(tokenLengths[t]))
+		}
+		switch t {
+		case 17:
+			bw.write(uint64(extras[i]-3), 3)
+		case 18:
+			bw.write(uint64(extras[i]-11), 7)
+		}
+	}
+
+	return huffmanCode{lengths: lengths, codes: canonicalCodes(lengths)}
+}
+
+// codeLengthTokens 将码长序列转换为码长编码的符号：0-15 表示字面码长，
+// 17 和 18 分别表示 3-10 个和 11-138 个连续的 0。extras 中保存对应的重复次数。
+func codeLengthTokens(lengths []int) (tokens []int, extras []int) {
+	for i := 0; i < len(lengths); {
+		if lengths[i] != 0 {
+			tokens = append(tokens, lengths[i])
+			extras = append(extras, 0)
+			i++
+			continue
+		}
+		run := 1
+		for i+run < len(lengths) && lengths[i+run] == 0 {
+			run++
+		}
+		i += run
+		for run > 0 {
+			switch {
+			case run >= 11:
+				n := min(run, 138)
+				tokens = append(tokens, 18)
+				extras = append(extras, n)
+				run -= n
+			case run >= 3:
+				tokens = append(tokens, 17)
+				extras = append(extras, run)
+				run = 0
+			default:
+				tokens = append(tokens, 0)
+				extras = append(extras, 0)
+				run--
+			}
+		}
+	}
+	return tokens, extras
+}
+
+// huffmanLengths 根据直方图计算码长不超过 maxLength 的哈夫曼码长。
+// 如果最优编码超过长度限制，会逐步抬高出现次数较少的符号的计数后重新计算。
+func huffmanLengths(histogram []int, maxLength int) []int {
+	for minCount := 1; ; minCount *= 2 {
+		counts := make([]int, len(histogram))
+		for i, c := range histogram {
+			if c > 0 {
+				counts[i] = max(c, minCount)
+			}
+		}
+		lengths := buildHuffmanLengths(counts)
+		fits := true
+		for _, l := range lengths {
+			if l > maxLength {
+				fits = false
+				break
+			}
+		}
+		if fits {
+			return lengths
+		}
+	}
+}
+
+// buildHuffmanLengths 使用经典的哈夫曼算法计算每个符号的码长。
+func buildHuffmanLengths(counts []int) []int {
+	type node struct {
+		count       int
+		symbol      int // 叶子节点的符号，内部节点为 -1
+		left, right int
+	}
+	var nodes []node
+	var queue []int
+	for symbol, c := range counts {
+		if c > 0 {
+			nodes = append(nodes, node{count: c, symbol: symbol, left: -1, right: -1})
+			queue = append(queue, len(nodes)-1)
+		}
+	}
+	lengths := make([]int, len(counts))
+	if len(queue) == 1 {
+		lengths[nodes[0].symbol] = 1
+		return lengths
+	}
+
+	for len(queue) > 1 {
+		sort.SliceStable(queue, func(i, j int) bool {
+			return nodes[queue[i]].count < nodes[queue[j]].count
+		})
+		a, b := queue[0], queue[1]
+		nodes = append(nodes, node{count: nodes[a].count + nodes[b].count, symbol: -1, left: a, right: b})
+		queue = append(queue[2:], len(nodes)-1)
+	}
+
+	var walk func(n, depth int)
+	walk = func(n, depth int) {
+		if nodes[n].symbol >= 0 {
+			lengths[nodes[n].symbol] = depth
+			return
+		}
+		walk(nodes[n].left, depth+1)
+		walk(nodes[n].right, depth+1)
+	}
+	walk(queue[0], 0)
+	return lengths
+}
+
+// canonicalCodes 根据码长生成规范哈夫曼码字。VP8L 与 DEFLATE 一样从码字的最高位开始读取，
+// 而比特流按最低位优先写入，因此返回的码字已经做了位反转。
+func canonicalCodes(lengths []int) []uint64 {
+	maxLength := 0
+	for _, l := range lengths {
+		maxLength = max(maxLength, l)
+	}
+	blCount := make([]int, maxLength+1)
+	for _, l := range lengths {
+		if l > 0 {
+			blCount[l]++
+		}
+	}
+	nextCode := make([]int, maxLength+1)
+	code := 0
+	for bits := 1; bits <= maxLength; bits++ {
+		code = (code + blCount[bits-1]) << 1
+		nextCode[bits] = code
+	}
+
+	codes := make([]uint64, len(lengths))
+	for symbol, l := range lengths {
+		if l == 0 {
+			continue
+		}
+		codes[symbol] = reverseBits(uint64(nextCode[l]), l)
+		nextCode[l]++
+	}
+	return codes
+}
+
+// reverseBits 反转 v 的低 n 位。
+func reverseBits(v uint64, n int) uint64 {
+	var r uint64
+	for i := 0; i < n; i++ {
+		r = r<<1 | (v & 1)
+		v >>= 1
+	}
+	return r
+}
+
+// countNonZero 返回切片中非零元素的个数。
+func countNonZero(values []int) int {
+	n := 0
+	for _, v := range values {
+		if v != 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// bitWriter 按最低位优先的顺序写入比特流。
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nbits uint
+}
+
+// write 写入 v 的低 n 位（n 不超过 32）。
+func (w *bitWriter) write(v uint64, n uint) {
+	w.acc |= (v & (1<<n - 1)) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc >>= 8
+		w.nbits -= 8
+	}
+}
+
+// bytes 返回写入的全部数据，不足一个字节的部分用 0 填充。
+func (w *bitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.buf = append(w.buf, byte(w.acc))
+		w.acc, w.nbits = 0, 0
+	}
+	return w.buf
+}
@@ -0,0 +1,126 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// testImage 生成一张带有渐变和噪点的图片，alpha 为 true 时包含半透明和全透明像素。
+func testImage(width, height int, alpha bool) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	seed := uint32(1)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			seed = seed*1664525 + 1013904223
+			c := color.NRGBA{
+				R: uint8(x * 255 / max(1, width-1)),
+				G: uint8(y * 255 / max(1, height-1)),
+				B: uint8(seed >> 24),
+				A: 0xff,
+			}
+			if alpha {
+				c.A = uint8((x + y) * 37)
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestEncodeWebPRoundTrip(t *testing.T) {
+	tests := []struct {
+		name          string
+		width, height int
+		alpha         bool
+	}{
+		{"单个像素", 1, 1, false},
+		{"不足一个预测块", 7, 5, false},
+		{"跨越多个预测块", 37, 21, false},
+		{"半透明", 33, 17, true},
+		{"单列", 1, 40, true},
+		{"宽度等于上限", MaxWebPDimension, 2, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := testImage(tt.width, tt.height, tt.alpha)
+			var buf bytes.Buffer
+			if err := EncodeWebP(&buf, src); err != nil {
+				t.Fatalf("EncodeWebP: %v", err)
+			}
+			decoded, err := webp.Decode(&buf)
+			if err != nil {
+				t.Fatalf("webp.Decode: %v", err)
+			}
+			if decoded.Bounds() != src.Bounds() {
+				t.Fatalf("bounds = %v, want %v", decoded.Bounds(), src.Bounds())
+			}
+			// 无损编码，每个像素都应当完全一致
+			for y := 0; y < tt.height; y++ {
+				for x := 0; x < tt.width; x++ {
+					got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA)
+					want := src.NRGBAAt(x, y)
+					if got != want {
+						t.Fatalf("pixel (%d, %d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeWebPSubImage(t *testing.T) {
+	// 起点不在原点的子图也要按可见区域编码
+	src := testImage(20, 20, false).SubImage(image.Rect(5, 3, 15, 12))
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, src); err != nil {
+		t.Fatalf("EncodeWebP: %v", err)
+	}
+	decoded, err := webp.Decode(&buf)
+	if err != nil {
+		t.Fatalf("webp.Decode: %v", err)
+	}
+	if got := decoded.Bounds().Size(); got != (image.Point{X: 10, Y: 9}) {
+		t.Fatalf("size = %v, want 10x9", got)
+	}
+	if got, want := color.NRGBAModel.Convert(decoded.At(0, 0)), src.At(5, 3); got != want {
+		t.Fatalf("first pixel = %v, want %v", got, want)
+	}
+}
+
+func TestEncodeWebPDimensions(t *testing.T) {
+	tests := []struct {
+		name   string
+		bounds image.Rectangle
+	}{
+		{"空图片", image.Rect(0, 0, 0, 0)},
+		{"宽度超出上限", image.Rect(0, 0, MaxWebPDimension+1, 1)},
+		{"高度超出上限", image.Rect(0, 0, 1, MaxWebPDimension+1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 只读取 Bounds，不需要真正分配像素
+			img := image.NewUniform(color.White)
+			sized := boundedImage{Image: img, bounds: tt.bounds}
+			var buf bytes.Buffer
+			if err := EncodeWebP(&buf, sized); !errors.Is(err, ErrWebPDimensions) {
+				t.Fatalf("err = %v, want ErrWebPDimensions", err)
+			}
+			if buf.Len() != 0 {
+				t.Fatalf("wrote %d bytes for an invalid image", buf.Len())
+			}
+		})
+	}
+}
+
+// boundedImage 为 image.Uniform 指定一个有限的范围。
+type boundedImage struct {
+	image.Image
+	bounds image.Rectangle
+}
+
+func (b boundedImage) Bounds() image.Rectangle { return b.bounds }
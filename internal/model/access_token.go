@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// AccessToken 模型定义了用户创建的个人访问令牌，供 Micropub 等无法使用登录 JWT 的发布客户端长期使用。
+// 它将映射到数据库中的 `access_tokens` 表。令牌明文只在创建时返回一次，数据库中只保存其 SHA-256 摘要。
+type AccessToken struct {
+	ID uint `gorm:"primarykey"`
+
+	// UserID 是令牌的所有者，客户端使用令牌时以该用户的身份操作，用户被删除时令牌一并删除。
+	UserID uint  `gorm:"not null;index"`
+	User   *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+
+	Name      string `gorm:"type:varchar(100);not null"`    // 令牌的用途说明，例如客户端名称
+	TokenHash string `gorm:"type:char(64);not null;unique"` // 令牌明文的 SHA-256 摘要（十六进制）
+	Scopes    string `gorm:"type:varchar(255);not null"`    // 以空格分隔的权限范围，例如 "create update media"
+	Prefix    string `gorm:"type:varchar(16);not null"`     // 令牌明文的开头几个字符，用于在列表中辨认令牌
+
+	LastUsedAt *time.Time // 最近一次使用的时间
+	ExpiresAt  *time.Time // 过期时间，为 nil 表示永不过期
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (AccessToken) TableName() string {
+	return "access_tokens"
+}
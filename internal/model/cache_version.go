@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// CacheVersion 模型定义了一组缓存的共享版本号。它将映射到数据库中的 `cache_versions` 表。
+// 多个实例各自在内存中缓存渲染结果，内容变化时版本号加一，其他实例发现版本变化后清空自己的缓存。
+type CacheVersion struct {
+	Name    string `gorm:"type:varchar(50);primarykey"` // 缓存的名称，例如 content
+	Version uint64 `gorm:"not null;default:0"`          // 每次内容变化时加一
+
+	UpdatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (CacheVersion) TableName() string {
+	return "cache_versions"
+}
@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+// 评论的状态。
+const (
+	CommentStatusPending  = 0 // 待审核
+	CommentStatusApproved = 1 // 已通过，对外展示
+	CommentStatusSpam     = 2 // 垃圾评论
+	CommentStatusTrash    = 3 // 回收站
+)
+
+// Comment 模型定义了文章评论的数据结构。
+// 它将映射到数据库中的 `comments` 表。
+// 评论可以由登录用户发表（UserID 不为空），也可以由游客发表（填写昵称和邮箱）。
+type Comment struct {
+	ID uint `gorm:"primarykey"`
+
+	// PostID 是评论所属的文章，文章被删除时评论一并删除。
+	PostID uint  `gorm:"not null;index:idx_comments_post_status,priority:1"`
+	Post   *Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+
+	// ParentID 是被回复的评论，顶层评论为空。
+	ParentID *uint `gorm:"index"`
+
+	// UserID 是发表评论的登录用户，游客发表的评论为空。
+	UserID *uint `gorm:"index"`
+	User   *User `gorm:"foreignKey:UserID;constraint:OnDelete:SET NULL"`
+
+	// --- 游客信息，登录用户发表的评论不填写 ---
+
+	AuthorName  string `gorm:"type:varchar(100)"` // 游客昵称
+	AuthorEmail string `gorm:"type:varchar(255)"` // 游客邮箱，不对外展示
+	AuthorURL   string `gorm:"type:varchar(255)"` // 游客的个人网站
+
+	Content string `gorm:"type:text;not null"` // 评论内容，纯文本
+	// 状态 (0:待审核, 1:已通过, 2:垃圾评论, 3:回收站)，与 PostID 组成联合索引，用于统计文章的评论数
+	Status int `gorm:"type:tinyint;default:0;index:idx_comments_post_status,priority:2;index"`
+
+	IP        string `gorm:"type:varchar(45)"`  // 发表评论时的 IP 地址，用于审核
+	UserAgent string `gorm:"type:varchar(255)"` // 发表评论时的浏览器标识，用于审核
+
+	// --- 垃圾内容检测 ---
+
+	SpamScore   *float64 // 贝叶斯分类器给出的垃圾内容概率，分类器未参与时为空
+	SpamReasons string   `gorm:"type:varchar(500)"`      // 被判定为垃圾或可疑内容的原因
+	SpamTrained int      `gorm:"type:tinyint;default:0"` // 用于训练分类器的方式 (0:未训练, 1:正常内容, 2:垃圾内容)
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (Comment) TableName() string {
+	return "comments"
+}
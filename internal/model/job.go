@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// 后台任务的状态。
+const (
+	JobQueued    = 0 // 等待执行，包括定时执行和等待重试的任务
+	JobRunning   = 1 // 正在执行
+	JobSucceeded = 2 // 执行成功
+	JobDead      = 3 // 多次重试后仍然失败，或返回了不可重试的错误，需要人工处理
+)
+
+// Job 模型定义了一个后台任务。它将映射到数据库中的 `jobs` 表。
+// 任务按队列 (Queue) 分组，执行者用 SELECT ... FOR UPDATE SKIP LOCKED 取出到期的任务，
+// 多个进程可以同时处理同一个队列而不会重复执行。
+type Job struct {
+	ID uint `gorm:"primarykey"`
+
+	Queue   string `gorm:"type:varchar(50);not null;index:idx_jobs_claim,priority:1"` // 所属的队列，每个队列的并发数单独限制
+	Kind    string `gorm:"type:varchar(100);not null;index"`                          // 任务类型，决定由哪个处理函数执行
+	Payload string `gorm:"type:longtext;not null"`                                    // 任务参数的 JSON 编码
+
+	// 状态 (0:等待执行, 1:正在执行, 2:执行成功, 3:执行失败)
+	Status      int        `gorm:"type:tinyint;default:0;index:idx_jobs_claim,priority:2"`
+	RunAt       time.Time  `gorm:"index:idx_jobs_claim,priority:3"` // 最早执行的时间，定时任务和等待重试的任务在此之前不会执行
+	Attempts    int        `gorm:"default:0"`                       // 已开始执行的次数
+	MaxAttempts int        `gorm:"not null"`                        // 最大尝试次数，用完后标记为执行失败
+	LockedBy    string     `gorm:"type:varchar(100)"`               // 正在执行任务的进程
+	LockedUntil *time.Time // 执行的租约，超过这个时间仍未完成时视为执行者已崩溃，任务重新排队
+	LastError   string     `gorm:"type:varchar(1000)"` // 最近一次失败的原因
+	FinishedAt  *time.Time `gorm:"index"`              // 执行成功或最终失败的时间
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (Job) TableName() string {
+	return "jobs"
+}
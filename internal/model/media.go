@@ -0,0 +1,77 @@
+package model
+
+import "time"
+
+// Media 模型定义了媒体库中一个文件的数据结构。
+// 它将映射到数据库中的 `media` 表。
+// 文件本身以内容寻址的方式保存在存储中，多条 Media 记录可以共享同一个存储文件。
+type Media struct {
+	ID uint `gorm:"primarykey"`
+
+	// UserID 是上传者的 ID。
+	UserID uint `gorm:"not null;index"`
+
+	FileName   string `gorm:"type:varchar(255);not null"`       // 上传时的原始文件名
+	StorageKey string `gorm:"type:varchar(255);not null;index"` // 文件在存储中的键，由内容摘要推导而来
+	MimeType   string `gorm:"type:varchar(100);not null"`       // 通过内容嗅探得到的 MIME 类型
+	Size       int64  `gorm:"not null"`                         // 文件大小 (字节)
+	Width      int    `gorm:"default:0"`                        // 图片宽度 (像素)，非图片为 0
+	Height     int    `gorm:"default:0"`                        // 图片高度 (像素)，非图片为 0
+	Checksum   string `gorm:"type:char(64);not null;index"`     // 文件内容的 SHA-256 摘要 (十六进制)
+	AltText    string `gorm:"type:varchar(255)"`                // 图片的替代文本
+
+	// 后台图片处理的结果
+	ProcessingStatus int    `gorm:"type:tinyint;default:0;index"` // 处理状态 (0: 待处理, 1: 已完成, 2: 失败)
+	BlurHash         string `gorm:"type:varchar(64)"`             // BlurHash 占位图字符串
+	DominantColor    string `gorm:"type:varchar(7)"`              // 主色调，格式为 #rrggbb
+
+	// Variants 是后台生成的各个尺寸和格式的图片。
+	Variants []MediaVariant `gorm:"foreignKey:MediaID"`
+
+	// URL 是文件的公开访问地址，不存储在数据库中，由 service 层在返回前填充。
+	URL string `gorm:"-"`
+	// SrcSet 是按 MIME 类型分组的响应式图片 srcset 字符串，可直接用于 <picture> 的 <source> 标签，
+	// 不存储在数据库中，由 service 层在返回前填充。
+	SrcSet map[string]string `gorm:"-"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (Media) TableName() string {
+	return "media"
+}
+
+// 媒体文件的后台处理状态。
+const (
+	MediaProcessingPending = 0 // 等待处理
+	MediaProcessingDone    = 1 // 处理完成（非图片文件无需处理，直接标记为完成）
+	MediaProcessingFailed  = 2 // 处理失败
+)
+
+// MediaVariant 模型定义了由原图生成的一个缩略图或响应式尺寸。
+// 它将映射到数据库中的 `media_variants` 表。
+type MediaVariant struct {
+	ID uint `gorm:"primarykey"`
+
+	// MediaID 是原图对应的媒体记录 ID。
+	MediaID uint `gorm:"not null;index"`
+
+	Name       string `gorm:"type:varchar(50);not null"`        // 尺寸名称，对应配置中的 media.processing.variants
+	MimeType   string `gorm:"type:varchar(100);not null"`       // 输出格式，例如 image/jpeg、image/webp
+	StorageKey string `gorm:"type:varchar(255);not null;index"` // 文件在存储中的键
+	Width      int    `gorm:"not null"`                         // 宽度 (像素)
+	Height     int    `gorm:"not null"`                         // 高度 (像素)
+	Size       int64  `gorm:"not null"`                         // 文件大小 (字节)
+
+	// URL 是文件的公开访问地址，不存储在数据库中，由 service 层在返回前填充。
+	URL string `gorm:"-"`
+
+	CreatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (MediaVariant) TableName() string {
+	return "media_variants"
+}
@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// outbox 中事件的投递状态。
+const (
+	OutboxPending    = 0 // 等待投递或等待重试
+	OutboxDispatched = 1 // 所有订阅者都已成功处理
+	OutboxFailed     = 2 // 多次重试后仍有订阅者失败
+)
+
+// OutboxMessage 模型定义了 outbox 中的一个领域事件。它将映射到数据库中的 `outbox_messages` 表。
+// 事件与产生它的业务数据在同一事务中写入，事务提交后再投递给订阅者，因此不会因为进程崩溃而丢失。
+type OutboxMessage struct {
+	ID uint `gorm:"primarykey"`
+
+	Event    string `gorm:"type:varchar(50);not null"`              // 事件名称，例如 post.created
+	DedupKey string `gorm:"type:varchar(191);not null;uniqueIndex"` // 去重键，同一去重键的事件只保存一次
+	Payload  string `gorm:"type:longtext;not null"`                 // 事件的 JSON 编码
+
+	// 状态 (0:等待投递, 1:已投递, 2:投递失败)
+	Status        int        `gorm:"type:tinyint;default:0;index:idx_outbox_messages_status_next,priority:1"`
+	Attempts      int        `gorm:"default:0"`                                        // 已尝试的次数
+	NextAttemptAt time.Time  `gorm:"index:idx_outbox_messages_status_next,priority:2"` // 下次尝试的时间，投递中时为租约到期的时间
+	Delivered     string     `gorm:"type:varchar(500)"`                                // 已成功处理的订阅者，以空格分隔，重试时跳过
+	LastError     string     `gorm:"type:varchar(500)"`                                // 最近一次失败的原因
+	DispatchedAt  *time.Time `gorm:"index"`                                            // 投递完成的时间，用于清理过期的事件
+
+	CreatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (OutboxMessage) TableName() string {
+	return "outbox_messages"
+}
@@ -2,6 +2,12 @@ package model
 
 import "time"
 
+// 文章的状态。
+const (
+	PostStatusDraft     = 0 // 草稿，只有登录用户可见
+	PostStatusPublished = 1 // 已发布
+)
+
 // Post 模型定义了文章的数据结构。
 // 它将映射到数据库中的 `posts` 表。
 type Post struct {
@@ -9,7 +15,30 @@ type Post struct {
 	Title   string `gorm:"type:varchar(255);not null"` // 文章标题
 	Content string `gorm:"type:longtext;not null"`     // 文章内容，使用 longtext 以存储较长的文本
 	Summary string `gorm:"type:text"`                  // 文章摘要
-	Status  int    `gorm:"type:tinyint;default:1"`     // 状态 (0:草稿, 1:发布)
+	Status  int    `gorm:"type:tinyint;default:1"`     // 状态，取值为 PostStatusDraft 或 PostStatusPublished
+
+	// --- 多语言 ---
+
+	// 文章的语言，例如 zh-CN、en；为空表示站点的默认语言（升级前创建的文章）
+	Lang string `gorm:"type:varchar(16);not null;default:'';index"`
+	// TranslationOf 是原文的 ID，为 nil 表示这篇文章本身是原文。同一篇原文的所有翻译（以及原文本身）互为语言版本
+	TranslationOf *uint `gorm:"index"`
+	// Translations 是这篇文章的其他语言版本，由 service 层查询后填充
+	Translations []PostTranslation `gorm:"-"`
+
+	// --- 评论 ---
+
+	CommentsEnabled bool  `gorm:"default:true"` // 是否允许评论，超过配置的天数后评论也会自动关闭
+	CommentCount    int64 `gorm:"-"`            // 已通过的评论数，由 service 层查询后填充
+
+	// --- SEO 字段，均为可选，留空时由 service 层根据标题、摘要和正文自动推导 ---
+
+	MetaTitle       string `gorm:"type:varchar(255)"`                                 // 搜索结果和分享卡片中显示的标题
+	MetaDescription string `gorm:"type:varchar(500)"`                                 // 搜索结果和分享卡片中显示的描述
+	CanonicalURL    string `gorm:"type:varchar(500)"`                                 // 规范链接，用于转载文章指向原文
+	NoIndex         bool   `gorm:"default:false"`                                     // 是否禁止搜索引擎收录
+	OGImageID       *uint  `gorm:"index"`                                             // 分享卡片使用的图片，引用媒体库中的文件
+	OGImage         *Media `gorm:"foreignKey:OGImageID;constraint:OnDelete:SET NULL"` // 分享卡片图片，通过 Preload("OGImage") 填充，图片被删除时自动置空
 
 	// --- 关联字段 ---
 
@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+// SpamToken 模型保存了贝叶斯垃圾内容分类器中每个词的训练数据。
+// 它将映射到数据库中的 `spam_tokens` 表。
+type SpamToken struct {
+	Token string `gorm:"type:varchar(128);primarykey"` // 分词后的词
+	Spam  int64  `gorm:"not null;default:0"`           // 出现过该词的垃圾内容文档数
+	Ham   int64  `gorm:"not null;default:0"`           // 出现过该词的正常内容文档数
+
+	UpdatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (SpamToken) TableName() string {
+	return "spam_tokens"
+}
+
+// SpamStat 模型保存了贝叶斯分类器的训练文档总数，表中只有 ID 为 1 的一行。
+// 它将映射到数据库中的 `spam_stats` 表。
+type SpamStat struct {
+	ID       uint  `gorm:"primarykey"`
+	SpamDocs int64 `gorm:"not null;default:0"` // 训练过的垃圾内容文档数
+	HamDocs  int64 `gorm:"not null;default:0"` // 训练过的正常内容文档数
+
+	UpdatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (SpamStat) TableName() string {
+	return "spam_stats"
+}
+
+// 评论被用于训练贝叶斯分类器的方式，审核人员更正判定时需要先撤销之前的训练。
+const (
+	SpamTrainedNone = 0 // 未训练
+	SpamTrainedHam  = 1 // 作为正常内容训练过
+	SpamTrainedSpam = 2 // 作为垃圾内容训练过
+)
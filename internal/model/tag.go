@@ -14,6 +14,9 @@ type Tag struct {
 	// - not null:          此列不允许为 NULL。
 	Name string `gorm:"type:varchar(100);unique;not null"`
 
+	// Translations 是名称在其他语言下的翻译，通过 Preload("Translations") 填充，删除标签时一并删除
+	Translations []TagTranslation `gorm:"foreignKey:TagID"`
+
 	// GORM 会在创建记录时自动填充当前时间。
 	CreatedAt time.Time
 	// GORM 会在创建或更新记录时自动填充当前时间。
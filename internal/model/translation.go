@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// PostTranslation 描述了文章的一个语言版本，用于在文章详情中列出它的所有翻译。
+// 它不对应数据库表，由 service 层查询后填充。
+type PostTranslation struct {
+	ID    uint   // 该语言版本的文章 ID
+	Lang  string // 语言标签，例如 en
+	Title string
+}
+
+// CategoryTranslation 模型定义了分类名称的翻译。
+// 它将映射到数据库中的 `category_translations` 表，每个分类的每种语言最多一条。
+type CategoryTranslation struct {
+	ID         uint   `gorm:"primarykey"`
+	CategoryID uint   `gorm:"not null;uniqueIndex:idx_category_lang"`
+	Lang       string `gorm:"type:varchar(16);not null;uniqueIndex:idx_category_lang"` // 语言标签，例如 en
+	Name       string `gorm:"type:varchar(100);not null"`                              // 该语言下的分类名称
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (CategoryTranslation) TableName() string {
+	return "category_translations"
+}
+
+// TagTranslation 模型定义了标签名称的翻译。
+// 它将映射到数据库中的 `tag_translations` 表，每个标签的每种语言最多一条。
+type TagTranslation struct {
+	ID    uint   `gorm:"primarykey"`
+	TagID uint   `gorm:"not null;uniqueIndex:idx_tag_lang"`
+	Lang  string `gorm:"type:varchar(16);not null;uniqueIndex:idx_tag_lang"` // 语言标签，例如 en
+	Name  string `gorm:"type:varchar(100);not null"`                         // 该语言下的标签名称
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (TagTranslation) TableName() string {
+	return "tag_translations"
+}
@@ -4,6 +4,12 @@ package model
 
 import "time"
 
+// 用户的角色。
+const (
+	RoleAdmin = 0 // 管理员，可以审核评论、管理 Webhook 等站点级的数据
+	RoleUser  = 1 // 普通用户，可以管理自己的文章和媒体
+)
+
 // User 模型定义了用户的数据结构，它将映射到数据库中的 `users` 表。
 // GORM 会自动将结构体名 `User` 转换为蛇形复数 `users` 作为表名。
 // 我们也可以通过实现 TableName() 方法来显式指定表名。
@@ -25,7 +31,10 @@ type User struct {
 	// `gorm:"type:tinyint;default:1"`:
 	// - type:tinyint:     指定列类型为 TINYINT。
 	// - default:1:        设置此列的默认值为 1。
-	Role int `gorm:"type:tinyint;default:1"` // 角色 (0:Admin, 1:User)
+	Role int `gorm:"type:tinyint;default:1"` // 角色 (0:Admin, 1:User)，取值见 RoleAdmin 等常量
+
+	// 接口消息使用的语言，例如 en；为空时根据请求的 Accept-Language 协商
+	Language string `gorm:"type:varchar(16);not null;default:''"`
 
 	// GORM 的约定：
 	// `CreatedAt` 字段: GORM 在创建记录时会自动填充当前时间。
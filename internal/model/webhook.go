@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+// Webhook 模型定义了一个 Webhook 订阅：订阅的事件发生时，向 URL 发送带签名的 POST 请求。
+// 它将映射到数据库中的 `webhooks` 表。
+type Webhook struct {
+	ID     uint   `gorm:"primarykey"`
+	Name   string `gorm:"type:varchar(100);not null"` // 名称，便于在后台区分，例如 CDN 刷新
+	URL    string `gorm:"type:varchar(700);not null"` // 接收事件的地址
+	Secret string `gorm:"type:varchar(100);not null"` // 签名密钥，接收方用它校验请求确实来自本站
+	Events string `gorm:"type:varchar(500);not null"` // 订阅的事件，以空格分隔，例如 "post.published post.deleted"
+	Active bool   `gorm:"default:true"`               // 是否启用，停用后不再为它创建新的投递
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// Webhook 投递的状态。
+const (
+	WebhookDeliveryPending   = 0 // 等待投递或等待重试
+	WebhookDeliveryDelivered = 1 // 接收方返回了 2xx 状态码
+	WebhookDeliveryFailed    = 2 // 多次重试后仍然失败
+)
+
+// WebhookDelivery 模型定义了一次 Webhook 投递，同时作为投递日志保存请求和最近一次的响应。
+// 它将映射到数据库中的 `webhook_deliveries` 表。投递失败时按退避策略重试，
+// 手动重新投递时创建一条新的记录，原记录保持不变。
+type WebhookDelivery struct {
+	ID uint `gorm:"primarykey"`
+
+	// WebhookID 是投递的订阅，订阅被删除时投递记录一并删除。
+	WebhookID uint     `gorm:"not null;index;uniqueIndex:idx_webhook_deliveries_event,priority:1"`
+	Webhook   *Webhook `gorm:"foreignKey:WebhookID;constraint:OnDelete:CASCADE"`
+	// EventKey 是触发投递的领域事件的去重键，同一事件对同一订阅只创建一次投递；手动重新投递时为 nil
+	EventKey *string `gorm:"type:varchar(191);uniqueIndex:idx_webhook_deliveries_event,priority:2"`
+
+	Event   string `gorm:"type:varchar(50);not null"` // 事件名称，例如 post.published
+	Payload string `gorm:"type:longtext;not null"`    // 请求体，重试和重新投递时原样发送
+	// RedeliveryOf 是手动重新投递的原始记录，为 nil 表示由事件直接触发
+	RedeliveryOf *uint
+
+	// 状态 (0:等待投递, 1:已投递, 2:投递失败)
+	Status        int        `gorm:"type:tinyint;default:0;index:idx_webhook_deliveries_status_next,priority:1"`
+	Attempts      int        `gorm:"default:0"`                                           // 已尝试的次数
+	NextAttemptAt time.Time  `gorm:"index:idx_webhook_deliveries_status_next,priority:2"` // 下次尝试的时间
+	ResponseCode  int        // 最近一次响应的 HTTP 状态码，没有收到响应时为 0
+	ResponseBody  string     `gorm:"type:varchar(1000)"` // 最近一次响应的正文，只保留开头部分
+	Duration      int64      // 最近一次请求的耗时，单位为毫秒
+	LastError     string     `gorm:"type:varchar(500)"` // 最近一次失败的原因
+	DeliveredAt   *time.Time // 投递成功的时间
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
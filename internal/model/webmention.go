@@ -0,0 +1,94 @@
+package model
+
+import "time"
+
+// 收到的 Webmention 的审核状态，与评论的状态保持一致。
+const (
+	WebmentionStatusPending  = 0 // 待审核
+	WebmentionStatusApproved = 1 // 已通过，对外展示
+	WebmentionStatusRejected = 2 // 已拒绝
+)
+
+// 收到的 Webmention 的校验状态。
+const (
+	WebmentionVerifyQueued   = 0 // 等待后台抓取来源页面校验
+	WebmentionVerifyVerified = 1 // 来源页面确实链接了文章
+	WebmentionVerifyFailed   = 2 // 来源页面没有链接文章、已被删除或多次抓取失败
+)
+
+// Webmention 模型定义了其他站点发来的 Webmention 通知，表示对方的页面 (Source) 提到了本站的文章。
+// 它将映射到数据库中的 `webmentions` 表。同一来源页面对同一篇文章只保留一条记录，重复发送时重新校验。
+type Webmention struct {
+	ID uint `gorm:"primarykey"`
+
+	// PostID 是被提到的文章，文章被删除时通知一并删除。
+	PostID uint  `gorm:"not null;uniqueIndex:idx_webmentions_post_source,priority:1;index:idx_webmentions_post_status,priority:1"`
+	Post   *Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+
+	Source string `gorm:"type:varchar(700);not null;uniqueIndex:idx_webmentions_post_source,priority:2"` // 提到文章的页面地址
+	Target string `gorm:"type:varchar(700);not null"`                                                    // 对方通知中填写的文章地址
+
+	// --- 校验时从来源页面中读取的信息 ---
+
+	Type       string `gorm:"type:varchar(20)"`  // 类型 (mention, reply, like, repost, bookmark)
+	Title      string `gorm:"type:varchar(255)"` // 来源页面的标题
+	Excerpt    string `gorm:"type:text"`         // 来源页面的摘要
+	AuthorName string `gorm:"type:varchar(100)"` // 来源页面的作者
+	AuthorURL  string `gorm:"type:varchar(700)"` // 作者的主页
+
+	// 审核状态 (0:待审核, 1:已通过, 2:已拒绝)，与 PostID 组成联合索引，用于查询文章下已通过的通知
+	Status int `gorm:"type:tinyint;default:0;index:idx_webmentions_post_status,priority:2;index"`
+	// 校验状态 (0:等待校验, 1:已校验, 2:校验失败)
+	Verification int `gorm:"type:tinyint;default:0;index:idx_webmentions_verification_next,priority:1"`
+
+	Attempts      int        `gorm:"default:0"`                                          // 抓取来源页面的次数，网络错误时会按退避策略重试
+	NextAttemptAt time.Time  `gorm:"index:idx_webmentions_verification_next,priority:2"` // 下次抓取的时间
+	LastError     string     `gorm:"type:varchar(500)"`                                  // 最近一次校验失败的原因
+	VerifiedAt    *time.Time // 最近一次校验通过的时间
+	IP            string     `gorm:"type:varchar(45)"` // 发送通知的 IP 地址
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (Webmention) TableName() string {
+	return "webmentions"
+}
+
+// 发出的 Webmention 的状态。
+const (
+	WebmentionSendPending    = 0 // 等待发送或等待重试
+	WebmentionSendSent       = 1 // 已发送
+	WebmentionSendNoEndpoint = 2 // 对方页面不支持 Webmention
+	WebmentionSendFailed     = 3 // 多次重试后仍然失败
+)
+
+// WebmentionSend 模型定义了发给其他站点的 Webmention，即本站文章中链接的外部页面 (Target)。
+// 它将映射到数据库中的 `webmention_sends` 表。文章每次发布或更新时，链接过的页面都会重新通知一次，
+// 包括更新后被删掉的链接，以便对方同步变化。
+type WebmentionSend struct {
+	ID uint `gorm:"primarykey"`
+
+	// PostID 是发出通知的文章，文章被删除时记录一并删除。
+	PostID uint  `gorm:"not null;uniqueIndex:idx_webmention_sends_post_target,priority:1"`
+	Post   *Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+
+	Target   string `gorm:"type:varchar(700);not null;uniqueIndex:idx_webmention_sends_post_target,priority:2"` // 文章中链接的外部页面
+	Endpoint string `gorm:"type:varchar(700)"`                                                                  // 发现的接收地址
+
+	// 状态 (0:等待发送, 1:已发送, 2:对方不支持, 3:发送失败)
+	Status        int        `gorm:"type:tinyint;default:0;index:idx_webmention_sends_status_next,priority:1"`
+	Attempts      int        `gorm:"default:0"`                                         // 已尝试的次数
+	NextAttemptAt time.Time  `gorm:"index:idx_webmention_sends_status_next,priority:2"` // 下次尝试的时间
+	LastError     string     `gorm:"type:varchar(500)"`                                 // 最近一次失败的原因
+	SentAt        *time.Time // 最近一次发送成功的时间
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 方法用于显式指定模型对应的数据库表名。
+func (WebmentionSend) TableName() string {
+	return "webmention_sends"
+}
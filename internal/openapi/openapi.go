@@ -0,0 +1,322 @@
+// package openapi 根据接口的请求和响应类型生成 OpenAPI 3 文档。
+//
+// 调用方为每个路由声明一个 Route，给出处理它的 handler 方法、请求体和响应数据的 Go 类型，
+// 字段的名称、类型和校验规则通过反射从 json 和 binding 标签中读取，因此修改请求或响应结构体后文档会自动同步。
+// Routes 的 Handler 与实际注册的路由可以通过 Check 比较，发现遗漏或过期的声明。
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+)
+
+// Version 是生成的文档所遵循的 OpenAPI 规范版本。
+const Version = "3.0.3"
+
+// Document 是 OpenAPI 文档的根对象。
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Tags       []Tag                            `json:"tags,omitempty"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+// Info 是文档的基本信息。
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Tag 是接口的分组。
+type Tag struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// Operation 描述一个接口。
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter 描述一个路径或查询参数。
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody 描述请求体。
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// MediaType 描述某种内容类型的请求体或响应体。
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Response 描述一种响应。
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Components 存放文档中可复用的结构定义和认证方式。
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme 描述一种认证方式。
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Auth 表示接口的认证要求。
+type Auth int
+
+const (
+	AuthNone     Auth = iota // 无需认证
+	AuthRequired             // 必须携带 JWT
+	AuthOptional             // 可以携带 JWT，携带时必须有效
+	AuthAdmin                // 必须携带管理员的 JWT
+)
+
+// BearerAuth 是文档中 JWT 认证方式的名称。
+const BearerAuth = "bearerAuth"
+
+// Param 描述一个查询参数或 multipart 表单字段。
+type Param struct {
+	Name        string
+	Description string
+	Type        string // integer、string、boolean 或 file（仅用于表单字段）
+	Required    bool
+	Default     interface{}
+	Enum        []string
+}
+
+// Route 是调用方对一个路由的声明。
+type Route struct {
+	Method      string
+	Path        string      // gin 格式的路径，例如 /api/v1/posts/:id，路径参数都按整数 ID 处理
+	Handler     interface{} // 处理该路由的 handler 方法表达式，例如 (*handler.PostHandler).GetPostHandler
+	Tag         string
+	Summary     string
+	Description string
+	Auth        Auth
+	Query       []Param
+	Form        []Param     // multipart/form-data 请求体的字段
+	Body        interface{} // JSON 请求体类型的零值
+	Response    interface{} // 响应中数据字段类型的零值，为 nil 表示数据字段为 null
+}
+
+// Spec 是生成文档所需的全部输入。
+type Spec struct {
+	Info Info
+	Tags []Tag
+	// Envelope 是所有 JSON 响应共用的外层结构的零值，Route.Response 描述其中 DataField 字段的类型
+	Envelope  interface{}
+	DataField string
+	// Problem 是 RFC 7807 错误响应类型的零值，为 nil 时错误响应只有 Envelope 一种格式
+	Problem interface{}
+	Routes  []Route
+}
+
+// HandlerName 返回 handler 函数的完整名称。
+// 方法表达式 (*T).M 与 gin 中注册的方法值 t.M 的名称只差一个 "-fm" 后缀，这里统一去掉。
+func HandlerName(handler interface{}) string {
+	name := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name()
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// operationID 由 handler 的方法名去掉 Handler 后缀得到，例如 CreatePostHandler → CreatePost。
+func operationID(handler interface{}) string {
+	name := HandlerName(handler)
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.TrimSuffix(name, "Handler")
+}
+
+// pathParamRe 匹配 gin 路径中的参数。
+var pathParamRe = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+// Build 生成文档。同一路由重复声明或 operationId 重复时返回错误。
+func (s *Spec) Build() (*Document, error) {
+	g := newGenerator()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    s.Info,
+		Tags:    s.Tags,
+		Paths:   make(map[string]map[string]*Operation),
+		Components: Components{
+			Schemas: g.schemas,
+			SecuritySchemes: map[string]*SecurityScheme{
+				BearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "登录接口返回的 token"},
+			},
+		},
+	}
+	envelope := g.schemaFor(reflect.TypeOf(s.Envelope))
+	// 所有接口的错误响应格式相同，只有状态码不同，统一用 default 描述
+	failure := &Response{
+		Description: "请求失败，HTTP 状态码与 code 字段一致",
+		Content:     map[string]MediaType{"application/json": {Schema: envelope}},
+	}
+	if s.Problem != nil {
+		failure.Content["application/problem+json"] = MediaType{Schema: g.schemaFor(reflect.TypeOf(s.Problem))}
+	}
+
+	ids := make(map[string]string)
+	for _, r := range s.Routes {
+		op := &Operation{
+			OperationID: operationID(r.Handler),
+			Summary:     r.Summary,
+			Description: r.Description,
+			Responses:   make(map[string]*Response),
+		}
+		if r.Tag != "" {
+			op.Tags = []string{r.Tag}
+		}
+		key := r.Method + " " + r.Path
+		if prev, ok := ids[op.OperationID]; ok {
+			return nil, fmt.Errorf("operationId %s 重复: %s 与 %s", op.OperationID, prev, key)
+		}
+		ids[op.OperationID] = key
+
+		switch r.Auth {
+		case AuthRequired:
+			op.Security = []map[string][]string{{BearerAuth: {}}}
+		case AuthAdmin:
+			op.Security = []map[string][]string{{BearerAuth: {}}}
+			op.Description = strings.TrimSpace("只允许管理员访问。" + op.Description)
+		case AuthOptional:
+			op.Security = []map[string][]string{{}, {BearerAuth: {}}}
+		}
+
+		for _, m := range pathParamRe.FindAllStringSubmatch(r.Path, -1) {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name: m[1], In: "path", Required: true,
+				Schema: &Schema{Type: "integer", Minimum: float(1)},
+			})
+		}
+		for _, p := range r.Query {
+			op.Parameters = append(op.Parameters, &Parameter{
+				Name: p.Name, In: "query", Description: p.Description, Required: p.Required, Schema: p.schema(),
+			})
+		}
+
+		if r.Body != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: g.schemaFor(reflect.TypeOf(r.Body))}},
+			}
+		} else if len(r.Form) > 0 {
+			form := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+			for _, p := range r.Form {
+				form.Properties[p.Name] = p.schema()
+				if p.Required {
+					form.Required = append(form.Required, p.Name)
+				}
+			}
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"multipart/form-data": {Schema: form}},
+			}
+		}
+
+		data := &Schema{Nullable: true, Description: "成功时为 null"}
+		if r.Response != nil {
+			data = g.schemaFor(reflect.TypeOf(r.Response))
+		}
+		op.Responses["default"] = failure
+		op.Responses["200"] = &Response{
+			Description: "成功",
+			Content: map[string]MediaType{"application/json": {Schema: &Schema{AllOf: []*Schema{
+				envelope,
+				{Type: "object", Properties: map[string]*Schema{s.DataField: data}},
+			}}}},
+		}
+
+		path := pathParamRe.ReplaceAllString(r.Path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*Operation)
+		}
+		method := strings.ToLower(r.Method)
+		if _, ok := doc.Paths[path][method]; ok {
+			return nil, fmt.Errorf("路由重复声明: %s", key)
+		}
+		doc.Paths[path][method] = op
+	}
+	return doc, nil
+}
+
+// schema 返回参数对应的结构定义。
+func (p *Param) schema() *Schema {
+	s := &Schema{Type: p.Type, Description: p.Description, Default: p.Default}
+	if p.Type == "file" {
+		s.Type, s.Format = "string", "binary"
+	}
+	for _, v := range p.Enum {
+		s.Enum = append(s.Enum, v)
+	}
+	return s
+}
+
+// RouteInfo 是一个实际注册的路由。
+type RouteInfo struct {
+	Method  string
+	Path    string
+	Handler string // handler 函数的完整名称
+}
+
+// Check 比较实际注册的路由与 Spec 中的声明，返回所有不一致之处，例如新增的路由没有声明、
+// 声明的路由已被删除，或者路由改由另一个 handler 处理。只检查路径以 prefix 开头的路由。
+func (s *Spec) Check(registered []RouteInfo, prefix string) []string {
+	declared := make(map[string]string)
+	for _, r := range s.Routes {
+		declared[r.Method+" "+r.Path] = HandlerName(r.Handler)
+	}
+
+	var problems []string
+	seen := make(map[string]bool)
+	for _, r := range registered {
+		if !strings.HasPrefix(r.Path, prefix) {
+			continue
+		}
+		key := r.Method + " " + r.Path
+		seen[key] = true
+		handler, ok := declared[key]
+		switch {
+		case !ok:
+			problems = append(problems, "路由没有在文档中声明: "+key)
+		case handler != strings.TrimSuffix(r.Handler, "-fm"):
+			problems = append(problems, fmt.Sprintf("路由的 handler 与文档不一致: %s (注册的是 %s，文档中是 %s)", key, r.Handler, handler))
+		}
+	}
+	for key := range declared {
+		if !seen[key] {
+			problems = append(problems, "文档中的路由没有注册: "+key)
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
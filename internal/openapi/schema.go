@@ -0,0 +1,238 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema 是 OpenAPI 3.0 的结构定义，只包含本项目用到的关键字。
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+// float 返回 v 的指针，用于填写 Minimum 和 Maximum。
+func float(v float64) *float64 {
+	return &v
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// generator 通过反射将 Go 类型转换为结构定义。具名结构体放入 components 并以 $ref 引用，
+// 因此同一个类型在文档中只定义一次，自引用的类型（例如评论的回复）也不会无限展开。
+type generator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newGenerator() *generator {
+	return &generator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+// nameFor 返回具名类型在 components 中的名称。不同包中的同名类型以包名区分，例如 handlerLoginRequest。
+func (g *generator) nameFor(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	if _, taken := g.schemas[name]; taken {
+		pkg := t.PkgPath()
+		name = pkg[strings.LastIndex(pkg, "/")+1:] + name
+	}
+	g.names[t] = name
+	return name
+}
+
+// schemaFor 返回类型 t 的结构定义。
+func (g *generator) schemaFor(t reflect.Type) *Schema {
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem := g.schemaFor(t.Elem())
+		if elem.Ref != "" {
+			// OpenAPI 3.0 中 $ref 不能与其他关键字并列，可以为 null 的引用需要包一层 allOf
+			return &Schema{AllOf: []*Schema{elem}, Nullable: true}
+		}
+		elem.Nullable = true
+		return elem
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: float(0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := g.nameFor(t)
+		if _, ok := g.schemas[name]; !ok {
+			// 先占位再展开字段，使自引用的字段能够找到这个名称
+			g.schemas[name] = &Schema{}
+			*g.schemas[name] = *g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	// interface{} 等无法确定类型的字段可以是任意值
+	return &Schema{}
+}
+
+// structSchema 展开结构体的字段，字段名和是否输出与 encoding/json 的规则一致，嵌入的结构体的字段会被提升到外层。
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(s, t)
+	return s
+}
+
+// addFields 将结构体 t 的字段加入 s，外层已有的同名字段优先。
+func (g *generator) addFields(s *Schema, t reflect.Type) {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				embedded = append(embedded, ft)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if _, ok := s.Properties[name]; ok {
+			continue
+		}
+		prop := g.schemaFor(f.Type)
+		if applyBinding(prop, f) {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+	for _, et := range embedded {
+		g.addFields(s, et)
+	}
+}
+
+// applyBinding 将字段 binding 标签中的校验规则转换为结构定义中的约束，返回字段是否必填。
+// 支持 required、min、max、oneof、email 和 url，其他规则会被忽略。
+func applyBinding(s *Schema, f reflect.StructField) bool {
+	binding := f.Tag.Get("binding")
+	if binding == "" {
+		return false
+	}
+	kind := f.Type.Kind()
+	if kind == reflect.Ptr {
+		kind = f.Type.Elem().Kind()
+	}
+	// 引用其他结构的字段只处理 required，约束不能与 $ref 并列
+	constrain := s.Ref == "" && s.AllOf == nil
+
+	required := false
+	for _, rule := range strings.Split(binding, ",") {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			if constrain {
+				s.Format = "email"
+			}
+		case "url":
+			if constrain {
+				s.Format = "uri"
+			}
+		case "min", "max":
+			n, err := strconv.Atoi(value)
+			if err != nil || !constrain {
+				continue
+			}
+			switch kind {
+			case reflect.String:
+				if key == "min" {
+					s.MinLength = &n
+				} else {
+					s.MaxLength = &n
+				}
+			case reflect.Slice, reflect.Array, reflect.Map:
+				if key == "min" {
+					s.MinItems = &n
+				} else {
+					s.MaxItems = &n
+				}
+			default:
+				if key == "min" {
+					s.Minimum = float(float64(n))
+				} else {
+					s.Maximum = float(float64(n))
+				}
+			}
+		case "oneof":
+			if !constrain {
+				continue
+			}
+			for _, v := range strings.Fields(value) {
+				if n, err := strconv.Atoi(v); err == nil && kind != reflect.String {
+					s.Enum = append(s.Enum, n)
+				} else {
+					s.Enum = append(s.Enum, v)
+				}
+			}
+		}
+	}
+	return required
+}
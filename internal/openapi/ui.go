@@ -0,0 +1,9 @@
+package openapi
+
+import _ "embed"
+
+// DocsPage 是交互式接口文档页面。页面不依赖外部资源，加载同目录下的 openapi.json 后在浏览器中渲染，
+// 并可以填写参数和 token 直接调用接口。
+//
+//go:embed ui/index.html
+var DocsPage []byte
@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/KeLes-Coding/gopress/internal/api/middleware"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	"github.com/KeLes-Coding/gopress/internal/util"
+	gopressv1 "github.com/KeLes-Coding/gopress/proto/gopress/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// publicMethods 是不需要认证的方法，与 REST 接口中的公共接口对应。
+var publicMethods = map[string]bool{
+	gopressv1.UserService_Login_FullMethodName:     true,
+	gopressv1.PostService_ListPosts_FullMethodName: true,
+	gopressv1.PostService_GetPost_FullMethodName:   true,
+}
+
+// methodScopes 是使用个人访问令牌调用方法时需要的权限范围，拥有其中任意一个即可。
+// 不在表中的方法只需要令牌有效；使用 JWT 认证时与 REST 接口一样拥有全部权限。
+var methodScopes = map[string][]string{
+	// 只有 draft 权限的令牌只能创建草稿，由 CreatePost 检查
+	gopressv1.PostService_CreatePost_FullMethodName: {service.ScopeCreate, service.ScopeDraft},
+	gopressv1.PostService_UpdatePost_FullMethodName: {service.ScopeUpdate},
+	gopressv1.PostService_DeletePost_FullMethodName: {service.ScopeDelete},
+
+	gopressv1.CategoryService_CreateCategory_FullMethodName:          {service.ScopeCreate},
+	gopressv1.CategoryService_UpdateCategory_FullMethodName:          {service.ScopeUpdate},
+	gopressv1.CategoryService_SetCategoryTranslations_FullMethodName: {service.ScopeUpdate},
+	gopressv1.CategoryService_DeleteCategory_FullMethodName:          {service.ScopeDelete},
+
+	gopressv1.TagService_CreateTag_FullMethodName:          {service.ScopeCreate},
+	gopressv1.TagService_UpdateTag_FullMethodName:          {service.ScopeUpdate},
+	gopressv1.TagService_SetTagTranslations_FullMethodName: {service.ScopeUpdate},
+	gopressv1.TagService_DeleteTag_FullMethodName:          {service.ScopeDelete},
+}
+
+// principal 是调用方认证的用户。
+type principal struct {
+	UserID   uint
+	Username string
+	language string             // 用户设置的接口语言，来自 JWT 或随访问令牌一起查询的用户
+	token    *model.AccessToken // 使用个人访问令牌认证时不为 nil，使用 JWT 认证时为 nil
+}
+
+// hasScope 判断调用方是否拥有指定的权限范围，使用 JWT 认证时总是返回 true。
+func (p *principal) hasScope(scope string) bool {
+	return p.token == nil || service.TokenHasScope(p.token, scope)
+}
+
+// authInterceptor 认证调用方。令牌通过 metadata 以 authorization: Bearer <token> 的形式携带，
+// 可以是登录接口返回的 JWT，也可以是个人访问令牌 (gp_...)，后者还要检查方法需要的权限范围。
+// 不需要认证的方法没有携带令牌时直接放行，携带了令牌时必须有效，与 REST 接口的可选认证一致。
+func authInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	authorization := metadataValue(ctx, "authorization")
+	if authorization == "" && publicMethods[info.FullMethod] {
+		return handler(ctx, req)
+	}
+
+	user, err := authenticate(authorization)
+	if err != nil {
+		return nil, err
+	}
+	if scopes, ok := methodScopes[info.FullMethod]; ok && user.token != nil {
+		allowed := false
+		for _, scope := range scopes {
+			if user.hasScope(scope) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return nil, service.ErrInsufficientScope.WithArgs(strings.Join(scopes, " "))
+		}
+	}
+
+	call := callInfoFrom(ctx)
+	call.user = user
+	// 用户设置过接口语言时，优先于 accept-language 使用
+	if user.language != "" {
+		call.lang = user.language
+	}
+	return handler(ctx, req)
+}
+
+// authenticate 解析 authorization 的值并返回认证的用户。
+func authenticate(authorization string) (*principal, error) {
+	if authorization == "" {
+		return nil, middleware.ErrMissingToken
+	}
+	parts := strings.SplitN(authorization, " ", 2)
+	if !(len(parts) == 2 && parts[0] == "Bearer") {
+		return nil, middleware.ErrMalformedToken
+	}
+
+	if service.IsAccessToken(parts[1]) {
+		token, err := service.NewTokenService().Authenticate(parts[1])
+		if err != nil {
+			if !errors.Is(err, service.ErrInvalidAccessToken) {
+				logger.L.Error("Failed to authenticate access token", zap.Error(err))
+			}
+			return nil, service.ErrInvalidAccessToken
+		}
+		return &principal{UserID: token.UserID, Username: token.User.Username, language: token.User.Language, token: token}, nil
+	}
+
+	claims, err := util.ParseToken(parts[1])
+	if err != nil {
+		return nil, middleware.ErrInvalidToken
+	}
+	return &principal{UserID: claims.UserID, Username: claims.Username, language: claims.Language}, nil
+}
+
+// currentUser 返回 ctx 所在调用认证的用户。只能在需要认证的方法中调用。
+func currentUser(ctx context.Context) *principal {
+	return callInfoFrom(ctx).user
+}
@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/KeLes-Coding/gopress/internal/api/handler"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	gopressv1 "github.com/KeLes-Coding/gopress/proto/gopress/v1"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// categoryServer 实现了 CategoryService，与 CategoryHandler 调用相同的 service 方法。
+type categoryServer struct {
+	gopressv1.UnimplementedCategoryServiceServer
+	categoryService service.CategoryStore
+}
+
+// ListCategories 获取所有分类，与 GET /api/v1/admin/categories 一致。
+func (s *categoryServer) ListCategories(ctx context.Context, req *gopressv1.ListCategoriesRequest) (*gopressv1.ListCategoriesResponse, error) {
+	categories, err := s.categoryService.List()
+	if err != nil {
+		return nil, err
+	}
+	resp := &gopressv1.ListCategoriesResponse{}
+	for i := range categories {
+		resp.Categories = append(resp.Categories, categoryToProto(&categories[i]))
+	}
+	return resp, nil
+}
+
+// CreateCategory 创建分类，与 POST /api/v1/admin/categories 一致。
+func (s *categoryServer) CreateCategory(ctx context.Context, req *gopressv1.CreateCategoryRequest) (*gopressv1.Category, error) {
+	params := handler.CreateCategoryRequest{Name: req.Name}
+	if err := validate(&params); err != nil {
+		return nil, err
+	}
+	category, err := s.categoryService.Create(params.Name)
+	if err != nil {
+		return nil, err
+	}
+	return categoryToProto(category), nil
+}
+
+// UpdateCategory 修改分类的名称，与 PUT /api/v1/admin/categories/:id 一致。
+func (s *categoryServer) UpdateCategory(ctx context.Context, req *gopressv1.UpdateCategoryRequest) (*gopressv1.Category, error) {
+	params := handler.UpdateCategoryRequest{Name: req.Name}
+	if err := validate(&params); err != nil {
+		return nil, err
+	}
+	category, err := s.categoryService.Update(uint(req.Id), params.Name)
+	if err != nil {
+		return nil, err
+	}
+	return categoryToProto(category), nil
+}
+
+// DeleteCategory 删除分类，与 DELETE /api/v1/admin/categories/:id 一致。
+func (s *categoryServer) DeleteCategory(ctx context.Context, req *gopressv1.DeleteCategoryRequest) (*emptypb.Empty, error) {
+	if err := s.categoryService.Delete(uint(req.Id)); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// SetCategoryTranslations 设置分类名称的翻译，与 PUT /api/v1/admin/categories/:id/translations 一致。
+func (s *categoryServer) SetCategoryTranslations(ctx context.Context, req *gopressv1.SetCategoryTranslationsRequest) (*gopressv1.Category, error) {
+	params := handler.SetTranslationsRequest{Translations: req.Translations}
+	if err := validate(&params); err != nil {
+		return nil, err
+	}
+	category, err := s.categoryService.SetTranslations(uint(req.Id), params.Translations)
+	if err != nil {
+		return nil, err
+	}
+	return categoryToProto(category), nil
+}
@@ -0,0 +1,137 @@
+package rpc
+
+import (
+	"github.com/KeLes-Coding/gopress/internal/model"
+	gopressv1 "github.com/KeLes-Coding/gopress/proto/gopress/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// postStatusToModel 将请求中的文章状态转换为 REST 接口的 status 字段，未设置时返回 nil，
+// 未知的取值返回 -1，两者都会在参数校验时被拒绝。
+func postStatusToModel(s gopressv1.PostStatus) *int {
+	var status int
+	switch s {
+	case gopressv1.PostStatus_POST_STATUS_UNSPECIFIED:
+		return nil
+	case gopressv1.PostStatus_POST_STATUS_DRAFT:
+		status = model.PostStatusDraft
+	case gopressv1.PostStatus_POST_STATUS_PUBLISHED:
+		status = model.PostStatusPublished
+	default:
+		status = -1
+	}
+	return &status
+}
+
+// postStatusToProto 将数据库中的文章状态转换为 PostStatus。
+func postStatusToProto(status int) gopressv1.PostStatus {
+	switch status {
+	case model.PostStatusDraft:
+		return gopressv1.PostStatus_POST_STATUS_DRAFT
+	case model.PostStatusPublished:
+		return gopressv1.PostStatus_POST_STATUS_PUBLISHED
+	default:
+		return gopressv1.PostStatus_POST_STATUS_UNSPECIFIED
+	}
+}
+
+// uintPtr 将可选的 uint32 转换为 *uint。
+func uintPtr(v *uint32) *uint {
+	if v == nil {
+		return nil
+	}
+	u := uint(*v)
+	return &u
+}
+
+// uint32Ptr 将 *uint 转换为可选的 uint32。
+func uint32Ptr(v *uint) *uint32 {
+	if v == nil {
+		return nil
+	}
+	u := uint32(*v)
+	return &u
+}
+
+// uintSlice 将 uint32 列表转换为 uint 列表。
+func uintSlice(values []uint32) []uint {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make([]uint, len(values))
+	for i, v := range values {
+		result[i] = uint(v)
+	}
+	return result
+}
+
+// postToProto 将文章转换为 Post 消息，没有预加载的关联字段不设置。
+func postToProto(post *model.Post) *gopressv1.Post {
+	msg := &gopressv1.Post{
+		Id:              uint32(post.ID),
+		Title:           post.Title,
+		Content:         post.Content,
+		Summary:         post.Summary,
+		Status:          postStatusToProto(post.Status),
+		Lang:            post.Lang,
+		TranslationOf:   uint32Ptr(post.TranslationOf),
+		CommentsEnabled: post.CommentsEnabled,
+		CommentCount:    post.CommentCount,
+		Seo: &gopressv1.PostSEO{
+			MetaTitle:       post.MetaTitle,
+			MetaDescription: post.MetaDescription,
+			CanonicalUrl:    post.CanonicalURL,
+			Noindex:         post.NoIndex,
+			OgImageId:       uint32Ptr(post.OGImageID),
+		},
+		CreateTime: timestamppb.New(post.CreatedAt),
+		UpdateTime: timestamppb.New(post.UpdatedAt),
+	}
+	for _, t := range post.Translations {
+		msg.Translations = append(msg.Translations, &gopressv1.PostTranslation{Id: uint32(t.ID), Lang: t.Lang, Title: t.Title})
+	}
+	if post.User.ID != 0 {
+		msg.Author = &gopressv1.User{Id: uint32(post.User.ID), Username: post.User.Username, Nickname: post.User.Nickname}
+	}
+	if post.Category.ID != 0 {
+		msg.Category = categoryToProto(&post.Category)
+	}
+	for i := range post.Tags {
+		msg.Tags = append(msg.Tags, tagToProto(&post.Tags[i]))
+	}
+	return msg
+}
+
+// categoryToProto 将分类转换为 Category 消息。
+func categoryToProto(category *model.Category) *gopressv1.Category {
+	msg := &gopressv1.Category{
+		Id:         uint32(category.ID),
+		Name:       category.Name,
+		CreateTime: timestamppb.New(category.CreatedAt),
+		UpdateTime: timestamppb.New(category.UpdatedAt),
+	}
+	if len(category.Translations) > 0 {
+		msg.Translations = make(map[string]string, len(category.Translations))
+		for _, t := range category.Translations {
+			msg.Translations[t.Lang] = t.Name
+		}
+	}
+	return msg
+}
+
+// tagToProto 将标签转换为 Tag 消息。
+func tagToProto(tag *model.Tag) *gopressv1.Tag {
+	msg := &gopressv1.Tag{
+		Id:         uint32(tag.ID),
+		Name:       tag.Name,
+		CreateTime: timestamppb.New(tag.CreatedAt),
+		UpdateTime: timestamppb.New(tag.UpdatedAt),
+	}
+	if len(tag.Translations) > 0 {
+		msg.Translations = make(map[string]string, len(tag.Translations))
+		for _, t := range tag.Translations {
+			msg.Translations[t.Lang] = t.Name
+		}
+	}
+	return msg
+}
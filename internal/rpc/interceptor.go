@@ -0,0 +1,159 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/apperr"
+	"github.com/KeLes-Coding/gopress/internal/i18n"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/go-playground/validator/v10"
+	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain 是错误详情 ErrorInfo 中的 domain，reason 是与 REST 接口相同的错误码。
+const errorDomain = "gopress"
+
+// callInfoKey 是 callInfo 在 context.Context 中的键。
+type callInfoKey struct{}
+
+// callInfo 是一次调用的上下文，由 errorInterceptor 创建，authInterceptor 填充认证信息。
+type callInfo struct {
+	lang string     // 错误消息使用的语言，默认由 accept-language 决定，用户设置过接口语言时使用设置的语言
+	user *principal // 认证的用户，不需要认证的方法且没有携带令牌时为 nil
+}
+
+// callInfoFrom 返回 ctx 所在调用的上下文。
+func callInfoFrom(ctx context.Context) *callInfo {
+	return ctx.Value(callInfoKey{}).(*callInfo)
+}
+
+// metadataValue 返回请求 metadata 中 key 的第一个值，没有时返回空字符串。
+func metadataValue(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// loggingInterceptor 记录每次调用的结果和耗时，字段与 HTTP 请求的日志一致。
+func loggingInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	cost := time.Since(start)
+
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+	}
+	logger.L.Info(info.FullMethod,
+		zap.String("code", status.Code(err).String()),
+		zap.String("method", info.FullMethod),
+		zap.String("ip", ip),
+		zap.String("user-agent", metadataValue(ctx, "user-agent")),
+		zap.Duration("cost", cost),
+	)
+	return resp, err
+}
+
+// errorInterceptor 将方法返回的错误转换为 gRPC 状态，并通过 content-language 响应头返回消息使用的语言。
+func errorInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	call := &callInfo{lang: i18n.Negotiate(strings.Join(metadata.ValueFromIncomingContext(ctx, "accept-language"), ","))}
+	ctx = context.WithValue(ctx, callInfoKey{}, call)
+
+	resp, err := handler(ctx, req)
+	_ = grpc.SetHeader(ctx, metadata.Pairs("content-language", call.lang))
+	if err != nil {
+		return nil, toStatus(err, call.lang, info.FullMethod)
+	}
+	return resp, nil
+}
+
+// recoveryInterceptor 捕获方法中的 panic，按服务器内部错误返回，避免整个进程退出。
+func recoveryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.L.Error("gRPC handler panicked",
+				zap.String("method", info.FullMethod),
+				zap.Any("panic", r),
+				zap.ByteString("stack", debug.Stack()),
+			)
+			resp, err = nil, apperr.ErrInternal
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// toStatus 将错误转换为 gRPC 状态，消息使用 lang 语言，错误码与 REST 接口相同：
+//   - 参数校验失败时状态码为 InvalidArgument，详情中的 BadRequest 列出每个字段的错误
+//   - 业务错误按分类转换状态码，详情中的 ErrorInfo.reason 是稳定的错误码
+//   - 其他错误按服务器内部错误处理，错误只记录在日志中
+func toStatus(err error, lang, method string) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		msg, fields := response.ValidationDetails(err, lang)
+		violations := make([]*errdetails.BadRequest_FieldViolation, len(fields))
+		for i, f := range fields {
+			violations[i] = &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message}
+		}
+		return withDetails(status.New(codes.InvalidArgument, msg),
+			&errdetails.ErrorInfo{Reason: apperr.CodeValidationFailed, Domain: errorDomain},
+			&errdetails.BadRequest{FieldViolations: violations},
+		)
+	}
+
+	e, ok := apperr.From(err)
+	if !ok {
+		logger.L.Error("gRPC handler failed", zap.String("method", method), zap.Error(err))
+		e = apperr.ErrInternal
+	}
+	return withDetails(status.New(grpcCode(e.Kind), i18n.Message(lang, e)),
+		&errdetails.ErrorInfo{Reason: e.Code, Domain: errorDomain},
+	)
+}
+
+// withDetails 为状态附加错误详情，附加失败时返回不带详情的状态。
+func withDetails(st *status.Status, details ...protoadapt.MessageV1) error {
+	if withDetails, err := st.WithDetails(details...); err == nil {
+		st = withDetails
+	}
+	return st.Err()
+}
+
+// grpcCode 返回业务错误分类对应的 gRPC 状态码。
+func grpcCode(kind apperr.Kind) codes.Code {
+	switch kind {
+	case apperr.KindInvalid:
+		return codes.InvalidArgument
+	case apperr.KindUnauthenticated:
+		return codes.Unauthenticated
+	case apperr.KindForbidden:
+		return codes.PermissionDenied
+	case apperr.KindNotFound:
+		return codes.NotFound
+	case apperr.KindConflict:
+		return codes.AlreadyExists
+	case apperr.KindUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
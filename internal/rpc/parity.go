@@ -0,0 +1,277 @@
+package rpc
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/api"
+	"github.com/KeLes-Coding/gopress/internal/openapi"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// restFieldAliases 是 REST 请求中在 gRPC 请求里以其他名称表示的字段，值是 gRPC 请求中对应的字段。
+// REST 的 tags 可以混合传入 ID 和名称，gRPC 中名称通过 tag_names 传入，ID 与 REST 一样通过 tag_ids 传入。
+var restFieldAliases = map[string]string{"tags": "tag_names"}
+
+// pathParamPattern 匹配 google.api.http 路径模板中的参数，例如 {id}。
+var pathParamPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// CheckParity 比较 gRPC 方法与 r 中注册的 REST 接口，返回所有不一致之处：
+//   - 每个方法都要通过 google.api.http 注解声明对应的 REST 接口，该接口必须已注册并在 OpenAPI 文档中声明
+//   - 方法是否需要认证与 REST 接口一致
+//   - 请求消息中除路径参数外的字段与 REST 接口的查询参数或请求体字段一一对应，且 JSON 中的类型相同
+func CheckParity(r *gin.Engine) []string {
+	registered := make(map[string]bool)
+	for _, route := range r.Routes() {
+		registered[route.Method+" "+route.Path] = true
+	}
+	declared := make(map[string]openapi.Route)
+	for _, route := range api.OpenAPIRoutes() {
+		declared[route.Method+" "+route.Path] = route
+	}
+
+	// 检查实际注册到服务器上的所有服务，新增的服务不需要在这里登记
+	var names []string
+	for name := range NewServer().srv.GetServiceInfo() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var problems []string
+	for _, name := range names {
+		d, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: 找不到服务的描述: %v", name, err))
+			continue
+		}
+		methods := d.(protoreflect.ServiceDescriptor).Methods()
+		for i := 0; i < methods.Len(); i++ {
+			problems = append(problems, checkMethod(methods.Get(i), registered, declared)...)
+		}
+	}
+	return problems
+}
+
+// checkMethod 检查一个方法与对应的 REST 接口是否一致。
+func checkMethod(m protoreflect.MethodDescriptor, registered map[string]bool, declared map[string]openapi.Route) []string {
+	fullMethod := fmt.Sprintf("/%s/%s", m.Parent().FullName(), m.Name())
+	rule, _ := proto.GetExtension(m.Options(), annotations.E_Http).(*annotations.HttpRule)
+	method, pattern := httpPattern(rule)
+	if method == "" {
+		return []string{fullMethod + ": 没有通过 google.api.http 声明对应的 REST 接口"}
+	}
+	path := pathParamPattern.ReplaceAllString(pattern, ":$1")
+	key := method + " " + path
+
+	var problems []string
+	if !registered[key] {
+		problems = append(problems, fmt.Sprintf("%s: 对应的 REST 接口 %s 没有注册", fullMethod, key))
+	}
+	route, ok := declared[key]
+	if !ok {
+		return append(problems, fmt.Sprintf("%s: 对应的 REST 接口 %s 没有在 OpenAPI 文档中声明", fullMethod, key))
+	}
+	if publicMethods[fullMethod] != (route.Auth == openapi.AuthNone || route.Auth == openapi.AuthOptional) {
+		problems = append(problems, fmt.Sprintf("%s: 是否需要认证与 REST 接口 %s 不一致", fullMethod, key))
+	}
+
+	// REST 接口的参数，键是参数名
+	rest := make(map[string]restParam)
+	for _, p := range route.Query {
+		rest[p.Name] = restParam{in: "查询参数", typ: p.Type}
+	}
+	switch {
+	case route.Body != nil && rule.Body != "*":
+		problems = append(problems, fmt.Sprintf("%s: REST 接口 %s 有请求体，注解中应声明 body: \"*\"", fullMethod, key))
+	case route.Body == nil && rule.Body != "":
+		problems = append(problems, fmt.Sprintf("%s: REST 接口 %s 没有请求体，注解中不应声明 body", fullMethod, key))
+	}
+	if route.Body != nil {
+		for _, f := range jsonFields(reflect.TypeOf(route.Body)) {
+			rest[f.name] = restParam{in: "请求体字段", typ: jsonType(f.typ)}
+		}
+	}
+
+	fields := m.Input().Fields()
+	pathParams := make(map[string]bool)
+	for _, match := range pathParamPattern.FindAllStringSubmatch(pattern, -1) {
+		pathParams[match[1]] = true
+		if fields.ByName(protoreflect.Name(match[1])) == nil {
+			problems = append(problems, fmt.Sprintf("%s: 请求消息中没有路径参数 %s", fullMethod, match[1]))
+		}
+	}
+	matched := make(map[string]bool)
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		name := string(f.Name())
+		restName := ""
+		switch {
+		case pathParams[name]:
+			continue
+		case rest[name].in != "":
+			restName = name
+		case rest[f.JSONName()].in != "":
+			restName = f.JSONName()
+		case restAlias(rest, name) != "":
+			// 别名字段的取值在两边的表示不同，只检查名称
+			matched[restAlias(rest, name)] = true
+			continue
+		default:
+			problems = append(problems, fmt.Sprintf("%s: 请求字段 %s 在 REST 接口 %s 中没有对应的参数", fullMethod, name, key))
+			continue
+		}
+		matched[restName] = true
+		if want := rest[restName].typ; want != "" && protoType(f) != want {
+			problems = append(problems, fmt.Sprintf("%s: 请求字段 %s 的类型 %s 与 REST 接口 %s 的%s %s 的类型 %s 不一致",
+				fullMethod, name, protoType(f), key, rest[restName].in, restName, want))
+		}
+	}
+
+	var missing []string
+	for name := range rest {
+		if !matched[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	for _, name := range missing {
+		problems = append(problems, fmt.Sprintf("%s: REST 接口 %s 的%s %s 在请求消息中没有对应的字段", fullMethod, key, rest[name].in, name))
+	}
+	return problems
+}
+
+// restParam 是 REST 接口的一个参数。
+type restParam struct {
+	in  string // 参数的位置，用于错误消息
+	typ string // 参数在 JSON 中的类型，取值见 jsonType；为空时不检查类型
+}
+
+// restAlias 返回 gRPC 请求字段 name 在 REST 接口参数 rest 中以其他名称表示的参数，没有时返回空字符串。
+func restAlias(rest map[string]restParam, name string) string {
+	for restName, alias := range restFieldAliases {
+		if alias == name && rest[restName].in != "" {
+			return restName
+		}
+	}
+	return ""
+}
+
+// httpPattern 返回 google.api.http 注解中的 HTTP 方法和路径模板，没有注解时返回空字符串。
+func httpPattern(rule *annotations.HttpRule) (string, string) {
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return "GET", p.Get
+	case *annotations.HttpRule_Post:
+		return "POST", p.Post
+	case *annotations.HttpRule_Put:
+		return "PUT", p.Put
+	case *annotations.HttpRule_Delete:
+		return "DELETE", p.Delete
+	case *annotations.HttpRule_Patch:
+		return "PATCH", p.Patch
+	default:
+		return "", ""
+	}
+}
+
+// jsonField 是结构体在 JSON 中的一个字段。
+type jsonField struct {
+	name string
+	typ  reflect.Type
+}
+
+// jsonFields 返回结构体在 JSON 中的字段，嵌入的结构体展开为其中的字段。
+func jsonFields(t reflect.Type) []jsonField {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			fields = append(fields, jsonFields(f.Type)...)
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, jsonField{name: name, typ: f.Type})
+	}
+	return fields
+}
+
+// jsonType 返回 Go 类型在 JSON 中的类型：integer、number、string、boolean、object，
+// 数组在元素类型前加上 []，例如 []integer。无法确定时返回空字符串。
+func jsonType(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		if elem := jsonType(t.Elem()); elem != "" {
+			return "[]" + elem
+		}
+	}
+	return ""
+}
+
+// protoType 返回请求字段对应的 REST 参数在 JSON 中的类型，取值与 jsonType 相同。
+// 枚举对应 REST 接口中的整数，例如 PostStatus 对应 status 字段，转换见 postStatusToModel。
+func protoType(f protoreflect.FieldDescriptor) string {
+	if f.IsMap() {
+		return "object"
+	}
+	var typ string
+	switch f.Kind() {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind,
+		protoreflect.EnumKind:
+		typ = "integer"
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		typ = "number"
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		typ = "string"
+	case protoreflect.BoolKind:
+		typ = "boolean"
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if f.Message().FullName() == "google.protobuf.Timestamp" {
+			typ = "string"
+		} else {
+			typ = "object"
+		}
+	}
+	if f.IsList() {
+		return "[]" + typ
+	}
+	return typ
+}
@@ -0,0 +1,590 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/api"
+	"github.com/KeLes-Coding/gopress/internal/api/handler"
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/openapi"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	gopressv1 "github.com/KeLes-Coding/gopress/proto/gopress/v1"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// fakeTime 是内存实现中所有记录的创建和更新时间。
+var fakeTime = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// fakeCategories 是 service.CategoryStore 的内存实现，错误与 CategoryService 相同。
+type fakeCategories struct {
+	categories map[uint]*model.Category
+	nextID     uint
+}
+
+func newFakeCategories(names ...string) *fakeCategories {
+	f := &fakeCategories{categories: make(map[uint]*model.Category), nextID: 1}
+	for _, name := range names {
+		f.Create(name)
+	}
+	return f
+}
+
+func (f *fakeCategories) Create(name string) (*model.Category, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, service.ErrCategoryNameEmpty
+	}
+	for _, c := range f.categories {
+		if c.Name == name {
+			return nil, service.ErrCategoryNameTaken
+		}
+	}
+	c := &model.Category{ID: f.nextID, Name: name, CreatedAt: fakeTime, UpdatedAt: fakeTime}
+	f.categories[c.ID] = c
+	f.nextID++
+	return c, nil
+}
+
+func (f *fakeCategories) List() ([]model.Category, error) {
+	categories := make([]model.Category, 0, len(f.categories))
+	for _, c := range f.categories {
+		categories = append(categories, *c)
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID > categories[j].ID })
+	return categories, nil
+}
+
+func (f *fakeCategories) Update(id uint, name string) (*model.Category, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, service.ErrCategoryNameEmpty
+	}
+	c, ok := f.categories[id]
+	if !ok {
+		return nil, service.ErrCategoryNotFound
+	}
+	for _, other := range f.categories {
+		if other.ID != id && other.Name == name {
+			return nil, service.ErrCategoryNameTaken
+		}
+	}
+	c.Name = name
+	return c, nil
+}
+
+func (f *fakeCategories) Delete(id uint) error {
+	if _, ok := f.categories[id]; !ok {
+		return service.ErrCategoryNotFound
+	}
+	delete(f.categories, id)
+	return nil
+}
+
+func (f *fakeCategories) SetTranslations(id uint, translations map[string]string) (*model.Category, error) {
+	c, ok := f.categories[id]
+	if !ok {
+		return nil, service.ErrCategoryNotFound
+	}
+	c.Translations = nil
+	for _, lang := range sortedKeys(translations) {
+		if name := strings.TrimSpace(translations[lang]); name != "" {
+			c.Translations = append(c.Translations, model.CategoryTranslation{CategoryID: id, Lang: lang, Name: name})
+		}
+	}
+	return c, nil
+}
+
+// fakeTags 是 service.TagStore 的内存实现，只实现了 gRPC 接口用到的方法，错误与 TagService 相同。
+type fakeTags struct {
+	service.TagStore
+	tags   map[uint]*model.Tag
+	nextID uint
+}
+
+func newFakeTags(names ...string) *fakeTags {
+	f := &fakeTags{tags: make(map[uint]*model.Tag), nextID: 1}
+	for _, name := range names {
+		f.Create(name)
+	}
+	return f
+}
+
+func (f *fakeTags) Create(name string) (*model.Tag, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, service.ErrTagNameEmpty
+	}
+	for _, t := range f.tags {
+		if t.Name == name {
+			return nil, service.ErrTagNameTaken
+		}
+	}
+	t := &model.Tag{ID: f.nextID, Name: name, CreatedAt: fakeTime, UpdatedAt: fakeTime}
+	f.tags[t.ID] = t
+	f.nextID++
+	return t, nil
+}
+
+func (f *fakeTags) List() ([]model.Tag, error) {
+	tags := make([]model.Tag, 0, len(f.tags))
+	for _, t := range f.tags {
+		tags = append(tags, *t)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].ID > tags[j].ID })
+	return tags, nil
+}
+
+func (f *fakeTags) Update(id uint, name string) (*model.Tag, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, service.ErrTagNameEmpty
+	}
+	t, ok := f.tags[id]
+	if !ok {
+		return nil, service.ErrTagNotFound
+	}
+	for _, other := range f.tags {
+		if other.ID != id && other.Name == name {
+			return nil, service.ErrTagNameTaken
+		}
+	}
+	t.Name = name
+	return t, nil
+}
+
+func (f *fakeTags) Delete(id uint) error {
+	if _, ok := f.tags[id]; !ok {
+		return service.ErrTagNotFound
+	}
+	delete(f.tags, id)
+	return nil
+}
+
+func (f *fakeTags) SetTranslations(id uint, translations map[string]string) (*model.Tag, error) {
+	t, ok := f.tags[id]
+	if !ok {
+		return nil, service.ErrTagNotFound
+	}
+	t.Translations = nil
+	for _, lang := range sortedKeys(translations) {
+		if name := strings.TrimSpace(translations[lang]); name != "" {
+			t.Translations = append(t.Translations, model.TagTranslation{TagID: id, Lang: lang, Name: name})
+		}
+	}
+	return t, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parityBackend 是使用同一组内存数据的 REST 接口和 gRPC 接口。
+type parityBackend struct {
+	rest       *gin.Engine
+	categories gopressv1.CategoryServiceClient
+	tags       gopressv1.TagServiceClient
+}
+
+// newParityBackend 创建使用 categories 和 tags 的 REST 路由和 gRPC 客户端。
+// 两者都不经过认证，认证要求是否一致由 TestCheckParity 检查。
+func newParityBackend(t *testing.T, categories service.CategoryStore, tags service.TagStore) *parityBackend {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	response.SetupValidator()
+
+	r := gin.New()
+	categoryHandler := handler.NewCategoryHandlerWithStore(categories)
+	r.POST("/api/v1/admin/categories", categoryHandler.CreateCategoryHandler)
+	r.GET("/api/v1/admin/categories", categoryHandler.ListCategoriesHandler)
+	r.PUT("/api/v1/admin/categories/:id", categoryHandler.UpdateCategoryHandler)
+	r.DELETE("/api/v1/admin/categories/:id", categoryHandler.DeleteCategoryHandler)
+	r.PUT("/api/v1/admin/categories/:id/translations", categoryHandler.SetCategoryTranslationsHandler)
+	tagHandler := handler.NewTagHandlerWithStore(tags)
+	r.POST("/api/v1/admin/tags", tagHandler.CreateTagHandler)
+	r.GET("/api/v1/admin/tags", tagHandler.ListTagsHandler)
+	r.PUT("/api/v1/admin/tags/:id", tagHandler.UpdateTagHandler)
+	r.DELETE("/api/v1/admin/tags/:id", tagHandler.DeleteTagHandler)
+	r.PUT("/api/v1/admin/tags/:id/translations", tagHandler.SetTagTranslationsHandler)
+
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(errorInterceptor))
+	gopressv1.RegisterCategoryServiceServer(srv, &categoryServer{categoryService: categories})
+	gopressv1.RegisterTagServiceServer(srv, &tagServer{tagService: tags})
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &parityBackend{
+		rest:       r,
+		categories: gopressv1.NewCategoryServiceClient(conn),
+		tags:       gopressv1.NewTagServiceClient(conn),
+	}
+}
+
+// parityResult 是一次调用的结果，REST 响应中的数据转换为对应的 gRPC 消息后再比较。
+type parityResult struct {
+	msg       proto.Message
+	code      codes.Code
+	errorCode string
+	message   string
+	fields    map[string]string // 参数校验失败的字段及其错误消息
+}
+
+// restToGRPC 是 REST 接口的 HTTP 状态码对应的 gRPC 状态码，与 grpcCode 一致。
+var restToGRPC = map[int]codes.Code{
+	http.StatusOK:                  codes.OK,
+	http.StatusBadRequest:          codes.InvalidArgument,
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusNotFound:            codes.NotFound,
+	http.StatusConflict:            codes.AlreadyExists,
+	http.StatusInternalServerError: codes.Internal,
+	http.StatusServiceUnavailable:  codes.Unavailable,
+}
+
+// callREST 调用 REST 接口，convert 将成功响应中的数据转换为 gRPC 方法返回的消息。
+func (b *parityBackend) callREST(t *testing.T, lang, method, path, body string, convert func(json.RawMessage) (proto.Message, error)) parityResult {
+	t.Helper()
+	req := httptest.NewRequest(method, path, bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	if lang != "" {
+		req.Header.Set("Accept-Language", lang)
+	}
+	w := httptest.NewRecorder()
+	b.rest.ServeHTTP(w, req)
+
+	var resp struct {
+		response.Response
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("REST %s %s: %v: %s", method, path, err, w.Body.String())
+	}
+	code, ok := restToGRPC[w.Code]
+	if !ok {
+		t.Fatalf("REST %s %s: unexpected status %d", method, path, w.Code)
+	}
+	res := parityResult{code: code, errorCode: resp.ErrorCode}
+	if code == codes.OK {
+		msg, err := convert(resp.Data)
+		if err != nil {
+			t.Fatalf("REST %s %s: decode data: %v", method, path, err)
+		}
+		res.msg = msg
+		return res
+	}
+	res.message = resp.Message
+	var fields []response.FieldError
+	if resp.ErrorCode == "validation_failed" && json.Unmarshal(resp.Data, &fields) == nil {
+		res.fields = make(map[string]string)
+		for _, f := range fields {
+			res.fields[f.Field] = f.Message
+		}
+	}
+	return res
+}
+
+// grpcResult 将 gRPC 方法的返回值转换为 parityResult。
+func grpcResult(msg proto.Message, err error) parityResult {
+	if err == nil {
+		return parityResult{msg: msg, code: codes.OK}
+	}
+	st := status.Convert(err)
+	res := parityResult{code: st.Code(), message: st.Message()}
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			res.errorCode = d.Reason
+		case *errdetails.BadRequest:
+			res.fields = make(map[string]string)
+			for _, v := range d.FieldViolations {
+				res.fields[v.Field] = v.Description
+			}
+		}
+	}
+	return res
+}
+
+func decodeCategory(data json.RawMessage) (proto.Message, error) {
+	var c model.Category
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return categoryToProto(&c), nil
+}
+
+func decodeCategories(data json.RawMessage) (proto.Message, error) {
+	var categories []model.Category
+	if err := json.Unmarshal(data, &categories); err != nil {
+		return nil, err
+	}
+	resp := &gopressv1.ListCategoriesResponse{}
+	for i := range categories {
+		resp.Categories = append(resp.Categories, categoryToProto(&categories[i]))
+	}
+	return resp, nil
+}
+
+func decodeTag(data json.RawMessage) (proto.Message, error) {
+	var tag model.Tag
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return nil, err
+	}
+	return tagToProto(&tag), nil
+}
+
+func decodeTags(data json.RawMessage) (proto.Message, error) {
+	var tags []model.Tag
+	if err := json.Unmarshal(data, &tags); err != nil {
+		return nil, err
+	}
+	resp := &gopressv1.ListTagsResponse{}
+	for i := range tags {
+		resp.Tags = append(resp.Tags, tagToProto(&tags[i]))
+	}
+	return resp, nil
+}
+
+func decodeEmpty(data json.RawMessage) (proto.Message, error) {
+	return &emptypb.Empty{}, nil
+}
+
+// TestRESTAndGRPCParity 用同一组内存数据分别调用 REST 接口和 gRPC 方法，比较返回的数据、
+// 状态码、错误码、错误消息和参数校验失败的字段，两种语言下都应一致。
+func TestRESTAndGRPCParity(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		body    string
+		convert func(json.RawMessage) (proto.Message, error)
+		call    func(ctx context.Context, b *parityBackend) (proto.Message, error)
+	}{
+		{
+			name: "list categories", method: http.MethodGet, path: "/api/v1/admin/categories", convert: decodeCategories,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.categories.ListCategories(ctx, &gopressv1.ListCategoriesRequest{})
+			},
+		},
+		{
+			name: "create category", method: http.MethodPost, path: "/api/v1/admin/categories", body: `{"name":"Python"}`, convert: decodeCategory,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.categories.CreateCategory(ctx, &gopressv1.CreateCategoryRequest{Name: "Python"})
+			},
+		},
+		{
+			name: "create category with short name", method: http.MethodPost, path: "/api/v1/admin/categories", body: `{"name":"P"}`, convert: decodeCategory,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.categories.CreateCategory(ctx, &gopressv1.CreateCategoryRequest{Name: "P"})
+			},
+		},
+		{
+			name: "create blank category", method: http.MethodPost, path: "/api/v1/admin/categories", body: `{"name":"   "}`, convert: decodeCategory,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.categories.CreateCategory(ctx, &gopressv1.CreateCategoryRequest{Name: "   "})
+			},
+		},
+		{
+			name: "create duplicate category", method: http.MethodPost, path: "/api/v1/admin/categories", body: `{"name":"Go"}`, convert: decodeCategory,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.categories.CreateCategory(ctx, &gopressv1.CreateCategoryRequest{Name: "Go"})
+			},
+		},
+		{
+			name: "update category", method: http.MethodPut, path: "/api/v1/admin/categories/2", body: `{"name":"Rust lang"}`, convert: decodeCategory,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.categories.UpdateCategory(ctx, &gopressv1.UpdateCategoryRequest{Id: 2, Name: "Rust lang"})
+			},
+		},
+		{
+			name: "update missing category", method: http.MethodPut, path: "/api/v1/admin/categories/9", body: `{"name":"Zig"}`, convert: decodeCategory,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.categories.UpdateCategory(ctx, &gopressv1.UpdateCategoryRequest{Id: 9, Name: "Zig"})
+			},
+		},
+		{
+			name: "delete category", method: http.MethodDelete, path: "/api/v1/admin/categories/1", convert: decodeEmpty,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.categories.DeleteCategory(ctx, &gopressv1.DeleteCategoryRequest{Id: 1})
+			},
+		},
+		{
+			name: "delete missing category", method: http.MethodDelete, path: "/api/v1/admin/categories/9", convert: decodeEmpty,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.categories.DeleteCategory(ctx, &gopressv1.DeleteCategoryRequest{Id: 9})
+			},
+		},
+		{
+			name: "set category translations", method: http.MethodPut, path: "/api/v1/admin/categories/1/translations",
+			body: `{"translations":{"en":"Golang","ja":"ゴー"}}`, convert: decodeCategory,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.categories.SetCategoryTranslations(ctx, &gopressv1.SetCategoryTranslationsRequest{
+					Id: 1, Translations: map[string]string{"en": "Golang", "ja": "ゴー"},
+				})
+			},
+		},
+		{
+			name: "list tags", method: http.MethodGet, path: "/api/v1/admin/tags", convert: decodeTags,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.tags.ListTags(ctx, &gopressv1.ListTagsRequest{})
+			},
+		},
+		{
+			name: "create tag", method: http.MethodPost, path: "/api/v1/admin/tags", body: `{"name":"grpc"}`, convert: decodeTag,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.tags.CreateTag(ctx, &gopressv1.CreateTagRequest{Name: "grpc"})
+			},
+		},
+		{
+			name: "create tag without name", method: http.MethodPost, path: "/api/v1/admin/tags", body: `{}`, convert: decodeTag,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.tags.CreateTag(ctx, &gopressv1.CreateTagRequest{})
+			},
+		},
+		{
+			name: "update tag to existing name", method: http.MethodPut, path: "/api/v1/admin/tags/1", body: `{"name":"gorm"}`, convert: decodeTag,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.tags.UpdateTag(ctx, &gopressv1.UpdateTagRequest{Id: 1, Name: "gorm"})
+			},
+		},
+		{
+			name: "delete missing tag", method: http.MethodDelete, path: "/api/v1/admin/tags/9", convert: decodeEmpty,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.tags.DeleteTag(ctx, &gopressv1.DeleteTagRequest{Id: 9})
+			},
+		},
+		{
+			name: "set tag translations", method: http.MethodPut, path: "/api/v1/admin/tags/2/translations",
+			body: `{"translations":{"en":"GORM"}}`, convert: decodeTag,
+			call: func(ctx context.Context, b *parityBackend) (proto.Message, error) {
+				return b.tags.SetTagTranslations(ctx, &gopressv1.SetTagTranslationsRequest{
+					Id: 2, Translations: map[string]string{"en": "GORM"},
+				})
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, lang := range []string{"", "en"} {
+			t.Run(tt.name+"/"+lang, func(t *testing.T) {
+				// 两种接口各自使用一份相同的初始数据，写操作不会互相影响
+				rest := newParityBackend(t, newFakeCategories("Go", "Rust"), newFakeTags("gin", "gorm"))
+				want := rest.callREST(t, lang, tt.method, tt.path, tt.body, tt.convert)
+
+				b := newParityBackend(t, newFakeCategories("Go", "Rust"), newFakeTags("gin", "gorm"))
+				ctx := context.Background()
+				if lang != "" {
+					ctx = metadata.AppendToOutgoingContext(ctx, "accept-language", lang)
+				}
+				got := grpcResult(tt.call(ctx, b))
+
+				if got.code != want.code {
+					t.Fatalf("gRPC code = %v, REST status corresponds to %v", got.code, want.code)
+				}
+				if want.code == codes.OK {
+					if !proto.Equal(got.msg, want.msg) {
+						t.Errorf("gRPC response = %v, REST data = %v", got.msg, want.msg)
+					}
+					return
+				}
+				if got.errorCode != want.errorCode {
+					t.Errorf("gRPC reason = %q, REST error_code = %q", got.errorCode, want.errorCode)
+				}
+				if got.message != want.message {
+					t.Errorf("gRPC message = %q, REST message = %q", got.message, want.message)
+				}
+				if len(got.fields) != len(want.fields) {
+					t.Errorf("gRPC field violations = %v, REST fields = %v", got.fields, want.fields)
+				}
+				for field, msg := range want.fields {
+					if got.fields[field] != msg {
+						t.Errorf("field %s: gRPC = %q, REST = %q", field, got.fields[field], msg)
+					}
+				}
+			})
+		}
+	}
+}
+
+// TestCheckParity 检查 proto/gopress/v1 中的方法与 RegisterRoutes 注册的 REST 接口是否一致。
+func TestCheckParity(t *testing.T) {
+	// 只注册路由和服务而不处理请求，使用空配置即可；/api/v1 下的路由不受配置开关影响
+	saved := config.Conf
+	config.Conf = &config.Config{}
+	defer func() { config.Conf = saved }()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	api.RegisterRoutes(r)
+
+	for _, p := range CheckParity(r) {
+		t.Error(p)
+	}
+}
+
+// TestCheckMethodFieldTypes 检查请求字段与 REST 参数同名但类型不同时会被报告。
+func TestCheckMethodFieldTypes(t *testing.T) {
+	m := gopressv1.File_gopress_v1_category_proto.Services().ByName("CategoryService").Methods().ByName("CreateCategory")
+	key := "POST /api/v1/admin/categories"
+	registered := map[string]bool{key: true}
+
+	tests := []struct {
+		name string
+		body interface{}
+		want int
+	}{
+		{"same type", handler.CreateCategoryRequest{}, 0},
+		{"different type", struct {
+			Name int `json:"name"`
+		}{}, 1},
+		{"list", struct {
+			Name []string `json:"name"`
+		}{}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			declared := map[string]openapi.Route{key: {
+				Method: http.MethodPost,
+				Path:   "/api/v1/admin/categories",
+				Auth:   openapi.AuthRequired,
+				Body:   tt.body,
+			}}
+			problems := checkMethod(m, registered, declared)
+			if len(problems) != tt.want {
+				t.Errorf("problems = %q, want %d", problems, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/KeLes-Coding/gopress/internal/api/handler"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	gopressv1 "github.com/KeLes-Coding/gopress/proto/gopress/v1"
+	"github.com/gin-gonic/gin/binding"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// validate 使用 REST 接口的请求结构校验参数，校验失败的字段名与 REST 接口相同。
+func validate(req interface{}) error {
+	return binding.Validator.ValidateStruct(req)
+}
+
+// postServer 实现了 PostService，与 PostHandler 调用相同的 service 方法。
+type postServer struct {
+	gopressv1.UnimplementedPostServiceServer
+	postService *service.PostService
+}
+
+// ListPosts 分页获取文章，与 GET /api/v1/posts 一致，page 和 page_size 为 0 时使用默认值。
+func (s *postServer) ListPosts(ctx context.Context, req *gopressv1.ListPostsRequest) (*gopressv1.ListPostsResponse, error) {
+	dto := &service.ListPostsDTO{Page: int(req.Page), PageSize: int(req.PageSize), Lang: req.Lang}
+	if dto.Page == 0 {
+		dto.Page = 1
+	}
+	if dto.PageSize == 0 {
+		dto.PageSize = 10
+	}
+	result, err := s.postService.List(dto)
+	if err != nil {
+		return nil, err
+	}
+	resp := &gopressv1.ListPostsResponse{TotalCount: result.TotalCount}
+	for i := range result.Posts {
+		resp.Posts = append(resp.Posts, postToProto(&result.Posts[i]))
+	}
+	return resp, nil
+}
+
+// GetPost 获取单篇文章，与 GET /api/v1/posts/:id 一致。
+func (s *postServer) GetPost(ctx context.Context, req *gopressv1.GetPostRequest) (*gopressv1.Post, error) {
+	post, err := s.postService.GetTranslation(uint(req.Id), req.Lang)
+	if err != nil {
+		return nil, err
+	}
+	return postToProto(post), nil
+}
+
+// CreatePost 以当前用户的身份创建文章，与 POST /api/v1/admin/posts 一致。
+// 只有 draft 权限的个人访问令牌只能创建草稿。
+func (s *postServer) CreatePost(ctx context.Context, req *gopressv1.CreatePostRequest) (*gopressv1.Post, error) {
+	params := handler.CreatePostRequest{
+		Title:           req.Title,
+		Content:         req.Content,
+		Summary:         req.Summary,
+		Status:          postStatusToModel(req.Status),
+		CategoryID:      uint(req.CategoryId),
+		TagIDs:          uintSlice(req.TagIds),
+		CommentsEnabled: req.CommentsEnabled,
+		Lang:            req.Lang,
+		TranslationOf:   uintPtr(req.TranslationOf),
+		PostSEORequest: handler.PostSEORequest{
+			MetaTitle:       req.MetaTitle,
+			MetaDescription: req.MetaDescription,
+			CanonicalURL:    req.CanonicalUrl,
+			NoIndex:         req.Noindex,
+			OGImageID:       uintPtr(req.OgImageId),
+		},
+	}
+	if err := validate(&params); err != nil {
+		return nil, err
+	}
+
+	user := currentUser(ctx)
+	if *params.Status != model.PostStatusDraft && !user.hasScope(service.ScopeCreate) {
+		return nil, service.ErrInsufficientScope.WithArgs(service.ScopeCreate)
+	}
+
+	post, err := s.postService.Create(&service.CreatePostDTO{
+		Title:      params.Title,
+		Content:    params.Content,
+		Summary:    params.Summary,
+		Status:     *params.Status,
+		UserID:     user.UserID,
+		CategoryID: params.CategoryID,
+		TagIDs:     params.TagIDs,
+		TagNames:   req.TagNames,
+		SEO:        params.PostSEORequest.ToDTO(),
+
+		CommentsEnabled: params.CommentsEnabled,
+		Lang:            params.Lang,
+		TranslationOf:   params.TranslationOf,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return postToProto(post), nil
+}
+
+// UpdatePost 修改文章，与 PUT /api/v1/admin/posts/:id 一致。
+func (s *postServer) UpdatePost(ctx context.Context, req *gopressv1.UpdatePostRequest) (*gopressv1.Post, error) {
+	params := handler.UpdatePostRequest{
+		Title:           req.Title,
+		Content:         req.Content,
+		Summary:         req.Summary,
+		Status:          postStatusToModel(req.Status),
+		CategoryID:      uint(req.CategoryId),
+		TagIDs:          uintSlice(req.TagIds),
+		CommentsEnabled: req.CommentsEnabled,
+		Lang:            req.Lang,
+		PostSEORequest: handler.PostSEORequest{
+			MetaTitle:       req.MetaTitle,
+			MetaDescription: req.MetaDescription,
+			CanonicalURL:    req.CanonicalUrl,
+			NoIndex:         req.Noindex,
+			OGImageID:       uintPtr(req.OgImageId),
+		},
+	}
+	if err := validate(&params); err != nil {
+		return nil, err
+	}
+
+	post, err := s.postService.Update(&service.UpdatePostDTO{
+		ID:         uint(req.Id),
+		Title:      params.Title,
+		Content:    params.Content,
+		Summary:    params.Summary,
+		Status:     *params.Status,
+		CategoryID: params.CategoryID,
+		TagIDs:     params.TagIDs,
+		TagNames:   req.TagNames,
+		SEO:        params.PostSEORequest.ToDTO(),
+
+		CommentsEnabled: params.CommentsEnabled,
+		Lang:            params.Lang,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return postToProto(post), nil
+}
+
+// DeletePost 删除文章，与 DELETE /api/v1/admin/posts/:id 一致。
+func (s *postServer) DeletePost(ctx context.Context, req *gopressv1.DeletePostRequest) (*emptypb.Empty, error) {
+	if err := s.postService.Delete(uint(req.Id)); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,68 @@
+// package rpc 实现了供内部服务读取和发布内容的 gRPC 接口。
+//
+// 接口定义位于 proto/gopress/v1，每个方法都通过 google.api.http 注解标明对应的 REST 接口。
+// gRPC 方法与 REST 接口共用请求参数的校验规则、service 层的业务逻辑和错误码，
+// go test ./internal/rpc 会检查两者的路由、认证要求以及请求字段的名称和类型是否一致，
+// 并用同一组内存数据分别调用两种接口，比较返回的数据和错误。
+package rpc
+
+import (
+	"context"
+	"net"
+
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	gopressv1 "github.com/KeLes-Coding/gopress/proto/gopress/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server 是 gRPC 服务器，与 Gin 的 HTTP 服务器使用不同的端口。
+type Server struct {
+	srv *grpc.Server
+}
+
+// NewServer 创建 gRPC 服务器并注册所有服务。
+// 拦截器依次负责记录日志、转换错误、捕获 panic 和认证，认证方式与 REST 接口相同。
+func NewServer() *Server {
+	// 参数校验复用 REST 接口的校验器，字段名和错误消息与 REST 接口一致
+	response.SetupValidator()
+
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		loggingInterceptor,
+		errorInterceptor,
+		recoveryInterceptor,
+		authInterceptor,
+	))
+	gopressv1.RegisterPostServiceServer(srv, &postServer{postService: service.NewPostService()})
+	gopressv1.RegisterCategoryServiceServer(srv, &categoryServer{categoryService: service.NewCategoryService()})
+	gopressv1.RegisterTagServiceServer(srv, &tagServer{tagService: service.NewTagService()})
+	gopressv1.RegisterUserServiceServer(srv, &userServer{userService: service.NewUserService()})
+	if config.Conf.GRPC.Reflection {
+		reflection.Register(srv)
+	}
+	return &Server{srv: srv}
+}
+
+// Serve 在 lis 上接受连接，直到调用 Shutdown。正常关闭时返回 nil。
+func (s *Server) Serve(lis net.Listener) error {
+	return s.srv.Serve(lis)
+}
+
+// Shutdown 停止接受新的连接，并等待正在处理的请求完成。
+// ctx 结束时仍有请求没有完成，则强制关闭所有连接并返回 ctx 的错误。
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.srv.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/KeLes-Coding/gopress/internal/api/handler"
+	"github.com/KeLes-Coding/gopress/internal/service"
+	gopressv1 "github.com/KeLes-Coding/gopress/proto/gopress/v1"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// tagServer 实现了 TagService，与 TagHandler 调用相同的 service 方法。
+type tagServer struct {
+	gopressv1.UnimplementedTagServiceServer
+	tagService service.TagStore
+}
+
+// ListTags 获取所有标签，与 GET /api/v1/admin/tags 一致。
+func (s *tagServer) ListTags(ctx context.Context, req *gopressv1.ListTagsRequest) (*gopressv1.ListTagsResponse, error) {
+	tags, err := s.tagService.List()
+	if err != nil {
+		return nil, err
+	}
+	resp := &gopressv1.ListTagsResponse{}
+	for i := range tags {
+		resp.Tags = append(resp.Tags, tagToProto(&tags[i]))
+	}
+	return resp, nil
+}
+
+// CreateTag 创建标签，与 POST /api/v1/admin/tags 一致。
+func (s *tagServer) CreateTag(ctx context.Context, req *gopressv1.CreateTagRequest) (*gopressv1.Tag, error) {
+	params := handler.CreateTagRequest{Name: req.Name}
+	if err := validate(&params); err != nil {
+		return nil, err
+	}
+	tag, err := s.tagService.Create(params.Name)
+	if err != nil {
+		return nil, err
+	}
+	return tagToProto(tag), nil
+}
+
+// UpdateTag 修改标签的名称，与 PUT /api/v1/admin/tags/:id 一致。
+func (s *tagServer) UpdateTag(ctx context.Context, req *gopressv1.UpdateTagRequest) (*gopressv1.Tag, error) {
+	params := handler.UpdateTagRequest{Name: req.Name}
+	if err := validate(&params); err != nil {
+		return nil, err
+	}
+	tag, err := s.tagService.Update(uint(req.Id), params.Name)
+	if err != nil {
+		return nil, err
+	}
+	return tagToProto(tag), nil
+}
+
+// DeleteTag 删除标签，与 DELETE /api/v1/admin/tags/:id 一致。
+func (s *tagServer) DeleteTag(ctx context.Context, req *gopressv1.DeleteTagRequest) (*emptypb.Empty, error) {
+	if err := s.tagService.Delete(uint(req.Id)); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// SetTagTranslations 设置标签名称的翻译，与 PUT /api/v1/admin/tags/:id/translations 一致。
+func (s *tagServer) SetTagTranslations(ctx context.Context, req *gopressv1.SetTagTranslationsRequest) (*gopressv1.Tag, error) {
+	params := handler.SetTranslationsRequest{Translations: req.Translations}
+	if err := validate(&params); err != nil {
+		return nil, err
+	}
+	tag, err := s.tagService.SetTranslations(uint(req.Id), params.Translations)
+	if err != nil {
+		return nil, err
+	}
+	return tagToProto(tag), nil
+}
@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/KeLes-Coding/gopress/internal/api/handler"
+	"github.com/KeLes-Coding/gopress/internal/i18n"
+	gopressv1 "github.com/KeLes-Coding/gopress/proto/gopress/v1"
+)
+
+// userStore 是 userServer 调用的用户操作，由 service.UserService 实现，测试时可以替换为内存实现。
+type userStore interface {
+	Login(username, password string) (string, error)
+	PreferredLanguage(userID uint) (string, error)
+	SetPreferredLanguage(userID uint, language string) (string, error)
+	RefreshToken(userID uint) (string, error)
+}
+
+// userServer 实现了 UserService，与 UserHandler 调用相同的 service 方法。
+type userServer struct {
+	gopressv1.UnimplementedUserServiceServer
+	userService userStore
+}
+
+// Login 使用用户名和密码登录，与 POST /api/v1/login 一致。
+func (s *userServer) Login(ctx context.Context, req *gopressv1.LoginRequest) (*gopressv1.LoginResponse, error) {
+	params := handler.LoginRequest{Username: req.Username, Password: req.Password}
+	if err := validate(&params); err != nil {
+		return nil, err
+	}
+	token, err := s.userService.Login(params.Username, params.Password)
+	if err != nil {
+		return nil, err
+	}
+	return &gopressv1.LoginResponse{Token: token}, nil
+}
+
+// GetCurrentUser 获取当前用户的信息，与 GET /api/v1/me 一致。
+func (s *userServer) GetCurrentUser(ctx context.Context, req *gopressv1.GetCurrentUserRequest) (*gopressv1.Profile, error) {
+	user := currentUser(ctx)
+	language, err := s.userService.PreferredLanguage(user.UserID)
+	if err != nil {
+		return nil, err
+	}
+	return &gopressv1.Profile{UserId: uint32(user.UserID), Username: user.Username, Language: language}, nil
+}
+
+// UpdateLanguage 设置当前用户的接口语言，与 PUT /api/v1/me/language 一致。
+func (s *userServer) UpdateLanguage(ctx context.Context, req *gopressv1.UpdateLanguageRequest) (*gopressv1.UpdateLanguageResponse, error) {
+	params := handler.UpdateLanguageRequest{Language: req.Language}
+	if err := validate(&params); err != nil {
+		return nil, err
+	}
+	user := currentUser(ctx)
+	language, err := s.userService.SetPreferredLanguage(user.UserID, params.Language)
+	if err != nil {
+		return nil, err
+	}
+	// 接口语言记录在 JWT 中，签发新的 JWT 供之后的调用使用。
+	// 个人访问令牌认证时每次都会查询用户的语言，不需要新的 token；也不能签发，否则受限的令牌可以换取完整权限的 JWT
+	var token string
+	if user.token == nil {
+		token, err = s.userService.RefreshToken(user.UserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// 本次调用就使用新的语言
+	call := callInfoFrom(ctx)
+	if language != "" {
+		call.lang = language
+	} else {
+		call.lang = i18n.Negotiate(metadataValue(ctx, "accept-language"))
+	}
+	return &gopressv1.UpdateLanguageResponse{Language: language, Token: token}, nil
+}
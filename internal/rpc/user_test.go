@@ -0,0 +1,68 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/KeLes-Coding/gopress/internal/api/response"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	gopressv1 "github.com/KeLes-Coding/gopress/proto/gopress/v1"
+)
+
+// fakeUsers 是 userStore 的内存实现，记录签发 token 的次数。
+type fakeUsers struct {
+	languages map[uint]string
+	refreshed int
+}
+
+func (f *fakeUsers) Login(username, password string) (string, error) { return "", nil }
+
+func (f *fakeUsers) PreferredLanguage(userID uint) (string, error) {
+	return f.languages[userID], nil
+}
+
+func (f *fakeUsers) SetPreferredLanguage(userID uint, language string) (string, error) {
+	f.languages[userID] = language
+	return language, nil
+}
+
+func (f *fakeUsers) RefreshToken(userID uint) (string, error) {
+	f.refreshed++
+	return "jwt", nil
+}
+
+// TestUpdateLanguageToken 检查只有使用 JWT 调用时才返回新的 JWT，个人访问令牌不能换取 JWT。
+func TestUpdateLanguageToken(t *testing.T) {
+	response.SetupValidator()
+	tests := []struct {
+		name  string
+		token *model.AccessToken // 为 nil 时表示使用 JWT 认证
+		want  string
+	}{
+		{"jwt", nil, "jwt"},
+		{"access token", &model.AccessToken{UserID: 1, Scopes: "draft"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &fakeUsers{languages: make(map[uint]string)}
+			s := &userServer{userService: users}
+			ctx := context.WithValue(context.Background(), callInfoKey{}, &callInfo{
+				user: &principal{UserID: 1, Username: "alice", token: tt.token},
+			})
+
+			resp, err := s.UpdateLanguage(ctx, &gopressv1.UpdateLanguageRequest{Language: "en"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Token != tt.want {
+				t.Errorf("token = %q, want %q", resp.Token, tt.want)
+			}
+			if users.languages[1] != "en" {
+				t.Errorf("language = %q, want en", users.languages[1])
+			}
+			if tt.token != nil && users.refreshed != 0 {
+				t.Errorf("RefreshToken called %d times with an access token", users.refreshed)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Package sanitize 按白名单清理用户提交的 HTML，只保留排版用的元素和属性，
+// 去除脚本、事件属性、javascript: 地址等可以在站点页面中执行代码的内容。
+package sanitize
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// globalAttrs 是所有允许的元素都可以使用的属性。
+var globalAttrs = map[string]bool{
+	"title": true,
+	"lang":  true,
+	"dir":   true,
+	"class": true, // 代码高亮等样式使用，例如 language-go
+}
+
+// allowedElements 是允许保留的元素及其专有属性。
+var allowedElements = map[atom.Atom]map[string]bool{
+	atom.P: nil, atom.Br: nil, atom.Hr: nil, atom.Div: nil, atom.Span: nil,
+	atom.H1: nil, atom.H2: nil, atom.H3: nil, atom.H4: nil, atom.H5: nil, atom.H6: nil,
+	atom.Strong: nil, atom.B: nil, atom.Em: nil, atom.I: nil, atom.U: nil, atom.S: nil,
+	atom.Del: nil, atom.Ins: nil, atom.Mark: nil, atom.Sub: nil, atom.Sup: nil, atom.Small: nil,
+	atom.Abbr: nil, atom.Cite: nil, atom.Code: nil, atom.Pre: nil, atom.Kbd: nil, atom.Samp: nil, atom.Var: nil,
+	atom.Blockquote: {"cite": true},
+	atom.Q:          {"cite": true},
+	atom.Ul:         nil,
+	atom.Ol:         {"start": true, "reversed": true, "type": true},
+	atom.Li:         {"value": true},
+	atom.Dl:         nil, atom.Dt: nil, atom.Dd: nil,
+	atom.A:      {"href": true, "rel": true, "target": true},
+	atom.Img:    {"src": true, "alt": true, "width": true, "height": true, "loading": true},
+	atom.Figure: nil, atom.Figcaption: nil,
+	atom.Table: nil, atom.Caption: nil, atom.Thead: nil, atom.Tbody: nil, atom.Tfoot: nil, atom.Tr: nil,
+	atom.Th:      {"colspan": true, "rowspan": true, "scope": true},
+	atom.Td:      {"colspan": true, "rowspan": true},
+	atom.Details: {"open": true},
+	atom.Summary: nil,
+	atom.Time:    {"datetime": true},
+}
+
+// urlAttrs 是值为地址的属性，只允许 http、https、mailto 和相对地址。
+var urlAttrs = map[string]bool{"href": true, "src": true, "cite": true}
+
+// allowedSchemes 是地址属性中允许的协议。
+var allowedSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// droppedElements 是连同内容一起删除的元素，它们的内容不是正文或者本身可以执行代码。
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Iframe: true, atom.Object: true, atom.Embed: true,
+	atom.Applet: true, atom.Noscript: true, atom.Template: true, atom.Svg: true, atom.Math: true,
+	atom.Frame: true, atom.Frameset: true, atom.Noembed: true, atom.Noframes: true, atom.Textarea: true,
+	atom.Select: true, atom.Title: true, atom.Head: true, atom.Xmp: true, atom.Plaintext: true,
+}
+
+// voidElements 是没有结束标签的元素。
+var voidElements = map[atom.Atom]bool{atom.Br: true, atom.Hr: true, atom.Img: true}
+
+// HTML 按白名单清理 HTML 片段：不在白名单中的元素只保留其中的文本，脚本、样式等元素连同内容删除，
+// 不在白名单中的属性和不安全的地址被删除，未闭合的元素会被补上结束标签，多余的结束标签会被丢弃，
+// 因此结果可以安全地嵌入页面而不会破坏外层的结构。
+func HTML(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	z := html.NewTokenizerFragment(strings.NewReader(s), "div")
+
+	var open []atom.Atom // 已输出、尚未闭合的元素
+	skip := 0            // 正在删除的元素的嵌套层数
+	var skipping atom.Atom
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		tok := z.Token()
+		switch tt {
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(html.EscapeString(tok.Data))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if skip > 0 {
+				if tok.DataAtom == skipping && tt == html.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			if droppedElements[tok.DataAtom] {
+				// 浏览器忽略非空元素的自闭合写法，<script/> 之后的内容仍然是脚本
+				skip, skipping = 1, tok.DataAtom
+				continue
+			}
+			attrs, ok := allowedElements[tok.DataAtom]
+			if !ok {
+				continue
+			}
+			writeStartTag(&b, tok, attrs)
+			if !voidElements[tok.DataAtom] {
+				if tt == html.SelfClosingTagToken {
+					b.WriteString("</" + tok.DataAtom.String() + ">")
+				} else {
+					open = append(open, tok.DataAtom)
+				}
+			}
+		case html.EndTagToken:
+			if skip > 0 {
+				if tok.DataAtom == skipping {
+					skip--
+				}
+				continue
+			}
+			// 只闭合已经打开的元素，中间未闭合的元素一并闭合
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] == tok.DataAtom {
+					for j := len(open) - 1; j >= i; j-- {
+						b.WriteString("</" + open[j].String() + ">")
+					}
+					open = open[:i]
+					break
+				}
+			}
+		}
+		// 注释、DOCTYPE 一律丢弃
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		b.WriteString("</" + open[i].String() + ">")
+	}
+	return b.String()
+}
+
+// writeStartTag 输出开始标签，只保留允许的属性。
+func writeStartTag(b *strings.Builder, tok html.Token, attrs map[string]bool) {
+	b.WriteString("<" + tok.DataAtom.String())
+	blank := hasBlankTarget(tok)
+	hasRel := false
+	for _, a := range tok.Attr {
+		key := strings.ToLower(a.Key)
+		if a.Namespace != "" || !globalAttrs[key] && !attrs[key] {
+			continue
+		}
+		val := a.Val
+		if urlAttrs[key] {
+			var ok bool
+			if val, ok = safeURL(val); !ok {
+				continue
+			}
+		}
+		switch key {
+		case "target":
+			if !blank {
+				continue
+			}
+		case "rel":
+			hasRel = true
+			if blank {
+				val = ensureNoopener(val)
+			}
+		}
+		b.WriteString(" " + key + `="` + html.EscapeString(val) + `"`)
+	}
+	// 在新窗口打开的链接必须带有 noopener，否则目标页面可以通过 window.opener 操纵本站页面
+	if tok.DataAtom == atom.A && !hasRel && blank {
+		b.WriteString(` rel="noopener noreferrer"`)
+	}
+	b.WriteString(">")
+}
+
+// hasBlankTarget 判断标签是否带有 target="_blank"，其他 target 值会被删除。
+func hasBlankTarget(tok html.Token) bool {
+	for _, a := range tok.Attr {
+		if strings.ToLower(a.Key) == "target" && a.Val == "_blank" {
+			return true
+		}
+	}
+	return false
+}
+
+// ensureNoopener 在 rel 属性中补上 noopener。
+func ensureNoopener(rel string) string {
+	for _, v := range strings.Fields(rel) {
+		if strings.EqualFold(v, "noopener") {
+			return rel
+		}
+	}
+	return strings.TrimSpace(rel + " noopener")
+}
+
+// safeURL 检查地址属性的值，只允许白名单中的协议和相对地址。
+// 浏览器会忽略地址中的空白和控制字符（例如 "java\tscript:"），检查前先将它们去掉。
+func safeURL(raw string) (string, bool) {
+	cleaned := strings.Map(func(r rune) rune {
+		if r <= ' ' || r == 0x7f {
+			return -1
+		}
+		return r
+	}, raw)
+	u, err := url.Parse(cleaned)
+	if err != nil {
+		return "", false
+	}
+	if u.Scheme != "" && !allowedSchemes[strings.ToLower(u.Scheme)] {
+		return "", false
+	}
+	// url.Parse 无法解析出协议、但第一个路径段中包含冒号的地址，浏览器仍可能当作协议处理
+	if u.Scheme == "" {
+		first := cleaned
+		if i := strings.IndexAny(first, "/?#"); i >= 0 {
+			first = first[:i]
+		}
+		if strings.Contains(first, ":") {
+			return "", false
+		}
+	}
+	return strings.TrimSpace(raw), true
+}
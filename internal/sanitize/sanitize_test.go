@@ -0,0 +1,78 @@
+package sanitize
+
+import "testing"
+
+func TestHTML(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		// 正常的排版内容原样保留
+		{"段落和强调", `<p>Hello <strong>world</strong> <em>!</em></p>`, `<p>Hello <strong>world</strong> <em>!</em></p>`},
+		{"代码高亮的 class", `<pre><code class="language-go">x := 1 &lt; 2</code></pre>`, `<pre><code class="language-go">x := 1 &lt; 2</code></pre>`},
+		{"图片", `<img src="/uploads/a.jpg" alt="猫" width="300">`, `<img src="/uploads/a.jpg" alt="猫" width="300">`},
+		{"表格", `<table><tr><td colspan="2">x</td></tr></table>`, `<table><tr><td colspan="2">x</td></tr></table>`},
+		{"文本中的特殊字符", `a < b && c > "d"`, `a &lt; b &amp;&amp; c &gt; &#34;d&#34;`},
+
+		// 脚本和可执行内容
+		{"script 元素", `<p>a</p><script>alert(1)</script><p>b</p>`, `<p>a</p><p>b</p>`},
+		{"自闭合的 script", `<script/>alert(1)</script>ok`, `ok`},
+		{"大写的 script", `<SCRIPT>alert(1)</SCRIPT>`, ``},
+		{"style 元素", `<style>body{display:none}</style>x`, `x`},
+		{"iframe", `<iframe src="https://evil.example"></iframe>x`, `x`},
+		{"svg 中的脚本", `<svg><script>alert(1)</script></svg>x`, `x`},
+		{"嵌套的同名元素", `<object><object></object>a</object>b`, `b`},
+		{"事件属性", `<img src="x.jpg" onerror="alert(1)">`, `<img src="x.jpg">`},
+		{"style 属性", `<p style="position:fixed">x</p>`, `<p>x</p>`},
+		{"id 属性", `<p id="login">x</p>`, `<p>x</p>`},
+		{"注释", `a<!-- <script>alert(1)</script> -->b`, `ab`},
+
+		// 地址
+		{"javascript 链接", `<a href="javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"大小写混合的协议", `<a href="JaVaScRiPt:alert(1)">x</a>`, `<a>x</a>`},
+		{"协议中的空白", "<a href=\"java\tscript:alert(1)\">x</a>", `<a>x</a>`},
+		{"前导空白", `<a href="  javascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"字符引用编码的协议", `<a href="&#106;avascript:alert(1)">x</a>`, `<a>x</a>`},
+		{"编码的冒号", `<a href="javascript&colon;alert(1)">x</a>`, `<a>x</a>`},
+		{"data 地址", `<img src="data:text/html;base64,PHNjcmlwdD4=">`, `<img>`},
+		{"vbscript", `<a href="vbscript:msgbox">x</a>`, `<a>x</a>`},
+		{"https 链接", `<a href="https://example.com/a?b=1&amp;c=2">x</a>`, `<a href="https://example.com/a?b=1&amp;c=2">x</a>`},
+		{"相对地址", `<a href="/posts/1#c:2">x</a>`, `<a href="/posts/1#c:2">x</a>`},
+		{"mailto", `<a href="mailto:a@example.com">x</a>`, `<a href="mailto:a@example.com">x</a>`},
+		{"引号逃逸", `<a href='/a"onmouseover="alert(1)'>x</a>`, `<a href="/a&#34;onmouseover=&#34;alert(1)">x</a>`},
+
+		// 新窗口打开的链接
+		{"补上 noopener", `<a href="/a" target="_blank">x</a>`, `<a href="/a" target="_blank" rel="noopener noreferrer">x</a>`},
+		{"已有 rel", `<a rel="nofollow" href="/a" target="_blank">x</a>`, `<a rel="nofollow noopener" href="/a" target="_blank">x</a>`},
+		{"其他 target", `<a href="/a" target="main">x</a>`, `<a href="/a">x</a>`},
+
+		// 结构
+		{"未知元素只保留文本", `<form action="/x"><input name="a">text</form>`, `text`},
+		{"补上结束标签", `<div><p>a`, `<div><p>a</p></div>`},
+		{"丢弃多余的结束标签", `a</div></div><p>b</p>`, `a<p>b</p>`},
+		{"交错的结束标签", `<b><i>x</b>y</i>`, `<b><i>x</i></b>y`},
+		{"自闭合的非空元素", `<p/>x`, `<p></p>x`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HTML(tt.in); got != tt.want {
+				t.Errorf("HTML(%q)\n got %q\nwant %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTMLIdempotent(t *testing.T) {
+	inputs := []string{
+		`<p>a <a href="/x" target="_blank">b</a></p><script>c</script>`,
+		`<div><ul><li>1<li>2</ul>`,
+		`<img src="https://example.com/a.png" alt='"q"'>`,
+	}
+	for _, in := range inputs {
+		once := HTML(in)
+		if twice := HTML(once); twice != once {
+			t.Errorf("HTML is not idempotent for %q:\n once %q\ntwice %q", in, once, twice)
+		}
+	}
+}
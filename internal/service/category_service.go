@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/event"
 	"github.com/KeLes-Coding/gopress/internal/model"
 	"gorm.io/gorm"
 )
@@ -18,12 +19,22 @@ func NewCategoryService() *CategoryService {
 	return &CategoryService{}
 }
 
+// CategoryStore 是 CategoryHandler 和 gRPC 的 CategoryService 共同依赖的分类操作，由 CategoryService 实现。
+// 测试中可以替换为不访问数据库的实现，用同一份数据比较两种接口的结果。
+type CategoryStore interface {
+	Create(name string) (*model.Category, error)
+	List() ([]model.Category, error)
+	Update(id uint, name string) (*model.Category, error)
+	Delete(id uint) error
+	SetTranslations(id uint, translations map[string]string) (*model.Category, error)
+}
+
 // Create 用于创建一个新的分类。
 func (s *CategoryService) Create(name string) (*model.Category, error) {
 	// 对名称进行基本的处理，例如去除首尾空格
 	trimmedName := strings.TrimSpace(name)
 	if trimmedName == "" {
-		return nil, errors.New("分类名称不能为空")
+		return nil, ErrCategoryNameEmpty
 	}
 
 	// 检查同名分类是否已存在
@@ -32,7 +43,7 @@ func (s *CategoryService) Create(name string) (*model.Category, error) {
 	// GORM 的 First 方法在找到记录时返回 nil 错误，未找到时返回 gorm.ErrRecordNotFound
 	if err := db.Where("name = ?", trimmedName).First(&existingCategory).Error; err == nil {
 		// 如果 err 为 nil，说明已存在同名分类
-		return nil, errors.New("分类名称已存在")
+		return nil, ErrCategoryNameTaken
 	}
 
 	// 创建新的分类实例
@@ -53,18 +64,41 @@ func (s *CategoryService) List() ([]model.Category, error) {
 	var categories []model.Category
 	// GORM 的 Find 方法用于查询多条记录
 	// Order("created_at DESC") 表示按创建时间降序排序，最新的分类会排在最前面。
-	if err := db.Order("created_at DESC").Find(&categories).Error; err != nil {
+	if err := db.Preload("Translations").Order("created_at DESC").Find(&categories).Error; err != nil {
 		return nil, err
 	}
 	return categories, nil
 }
 
+// GetByIDs 用于批量获取分类及其名称的翻译，不存在的 ID 会被忽略，结果的顺序与 ids 无关。
+func (s *CategoryService) GetByIDs(ids []uint) ([]model.Category, error) {
+	var categories []model.Category
+	if len(ids) == 0 {
+		return categories, nil
+	}
+	err := dao.GetDB().Preload("Translations").Where("id IN ?", ids).Find(&categories).Error
+	return categories, err
+}
+
+// CountPublishedPosts 用于批量统计分类下已发布的文章数，返回分类 ID 到文章数的映射，没有文章的分类不在其中。
+func (s *CategoryService) CountPublishedPosts(ids []uint) (map[uint]int64, error) {
+	if len(ids) == 0 {
+		return map[uint]int64{}, nil
+	}
+	var rows []idCountRow
+	if err := dao.GetDB().Model(&model.Post{}).Select("category_id AS id, COUNT(*) AS count").
+		Where("category_id IN ? AND status = ?", ids, 1).Group("category_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return countMap(rows), nil
+}
+
 // Update 用于更新一个已存在的分类。
 // 它需要分类的 ID 和新的名称作为参数。
 func (s *CategoryService) Update(id uint, name string) (*model.Category, error) {
 	trimmedName := strings.TrimSpace(name)
 	if trimmedName == "" {
-		return nil, errors.New("分类名称不能为空")
+		return nil, ErrCategoryNameEmpty
 	}
 
 	db := dao.GetDB()
@@ -74,7 +108,7 @@ func (s *CategoryService) Update(id uint, name string) (*model.Category, error)
 	if err := db.First(&category, id).Error; err != nil {
 		// 如果 GORM 返回 ErrRecordNotFound，说明该分类不存在。
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("该分类不存在")
+			return nil, ErrCategoryNotFound
 		}
 		// 其他数据库错误
 		return nil, err
@@ -83,14 +117,22 @@ func (s *CategoryService) Update(id uint, name string) (*model.Category, error)
 	// 2. 检查新的名称是否存在其他分类的名称冲突
 	var existingCategory model.Category
 	if err := db.Where("name = ? AND id != ?", trimmedName, id).First(&existingCategory).Error; err == nil {
-		return nil, errors.New("该分类名称已存在")
+		return nil, ErrCategoryNameTaken
 	}
 
 	// 3. 更新分类名称
 	category.Name = trimmedName
-	if err := db.Save(&category).Error; err != nil {
+	var out outbox
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&category).Error; err != nil {
+			return err
+		}
+		return out.publish(tx, event.CategoryUpdated{Category: &category})
+	})
+	if err != nil {
 		return nil, err
 	}
+	out.flush()
 
 	return &category, nil
 }
@@ -99,16 +141,83 @@ func (s *CategoryService) Update(id uint, name string) (*model.Category, error)
 func (s *CategoryService) Delete(id uint) error {
 	db := dao.GetDB()
 
-	// GORM 的 Delete 方法可以通过主键删除记录
-	// 它会返回一个 result 对象，我们可以通过 .RowsAffected 检查是否有记录被删除。
-	result := db.Delete(&model.Category{}, id)
-	if result.Error != nil {
-		return result.Error
-	}
-	// 如果没有行受到影响，说明该 ID 的分类原本就不存在
-	if result.RowsAffected == 0 {
-		return errors.New("该分类不存在")
+	var out outbox
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 名称的翻译随分类一起删除
+		if err := tx.Where("category_id = ?", id).Delete(&model.CategoryTranslation{}).Error; err != nil {
+			return err
+		}
+		// GORM 的 Delete 方法可以通过主键删除记录
+		// 它会返回一个 result 对象，我们可以通过 .RowsAffected 检查是否有记录被删除。
+		result := tx.Delete(&model.Category{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		// 如果没有行受到影响，说明该 ID 的分类原本就不存在
+		if result.RowsAffected == 0 {
+			return ErrCategoryNotFound
+		}
+		return out.publish(tx, event.CategoryDeleted{ID: id})
+	})
+	if err != nil {
+		return err
 	}
+	out.flush()
 
 	return nil
 }
+
+// SetTranslations 用于设置分类名称在其他语言下的翻译，translations 是语言到名称的映射，会替换该分类现有的所有翻译。
+// 名称为空的翻译会被忽略，因此传入空映射可以删除所有翻译。
+func (s *CategoryService) SetTranslations(id uint, translations map[string]string) (*model.Category, error) {
+	names, err := normalizeNameTranslations(translations, strings.TrimSpace, func(string) error { return nil })
+	if err != nil {
+		return nil, err
+	}
+
+	db := dao.GetDB()
+	var category model.Category
+	var out outbox
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&category, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCategoryNotFound
+			}
+			return err
+		}
+		if err := tx.Where("category_id = ?", id).Delete(&model.CategoryTranslation{}).Error; err != nil {
+			return err
+		}
+		for lang, name := range names {
+			if err := tx.Create(&model.CategoryTranslation{CategoryID: id, Lang: lang, Name: name}).Error; err != nil {
+				return err
+			}
+		}
+		return out.publish(tx, event.CategoryUpdated{Category: &category})
+	})
+	if err != nil {
+		return nil, err
+	}
+	out.flush()
+
+	if err := db.Preload("Translations").First(&category, id).Error; err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+// defaultCategoryID 返回发布客户端（XML-RPC、Micropub 等）没有指定分类时使用的分类。
+// configured 是配置中指定的分类，为 0 时使用最早创建的分类。
+func defaultCategoryID(configured uint) (uint, error) {
+	if configured != 0 {
+		return configured, nil
+	}
+	var category model.Category
+	if err := dao.GetDB().Order("id ASC").Limit(1).Find(&category).Error; err != nil {
+		return 0, err
+	}
+	if category.ID == 0 {
+		return 0, ErrNoCategoryAvailable
+	}
+	return category.ID, nil
+}
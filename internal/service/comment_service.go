@@ -0,0 +1,540 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/event"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/spam"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// 评论审核模式，对应配置中的 comment.moderation。
+const (
+	CommentModerationAll    = "all"    // 所有评论都需要审核
+	CommentModerationGuests = "guests" // 只有游客的评论需要审核
+	CommentModerationNone   = "none"   // 不审核，评论直接通过
+)
+
+// CommentStatusNames 是评论状态在 API 中使用的名称。
+var CommentStatusNames = map[int]string{
+	model.CommentStatusPending:  "pending",
+	model.CommentStatusApproved: "approved",
+	model.CommentStatusSpam:     "spam",
+	model.CommentStatusTrash:    "trash",
+}
+
+// ParseCommentStatus 将 API 中的状态名称转换为评论状态。
+func ParseCommentStatus(name string) (int, bool) {
+	for status, n := range CommentStatusNames {
+		if n == name {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// CommentService 结构体封装了评论相关的业务逻辑。
+type CommentService struct {
+	spamService *SpamService
+}
+
+// NewCommentService 是 CommentService 的工厂函数。
+func NewCommentService() *CommentService {
+	return &CommentService{
+		spamService: NewSpamService(),
+	}
+}
+
+// CreateCommentDTO 封装了发表评论时需要的数据。UserID 为 0 时表示游客评论，此时需要填写昵称和邮箱。
+type CreateCommentDTO struct {
+	PostID      uint
+	ParentID    *uint
+	UserID      uint
+	AuthorName  string
+	AuthorEmail string
+	AuthorURL   string
+	Content     string
+	IP          string
+	UserAgent   string
+	Referrer    string
+
+	// 垃圾内容检测使用的字段
+	Honeypot  string // 蜜罐字段的值
+	FormToken string // 表单令牌
+}
+
+// CommentDTO 是对外展示的评论，不包含邮箱、IP 等隐私信息。
+type CommentDTO struct {
+	ID         uint          `json:"id"`
+	ParentID   *uint         `json:"parent_id"`
+	UserID     *uint         `json:"user_id"`
+	AuthorName string        `json:"author_name"`
+	AuthorURL  string        `json:"author_url,omitempty"`
+	Content    string        `json:"content"`
+	Status     string        `json:"status"`
+	CreatedAt  time.Time     `json:"created_at"`
+	Replies    []*CommentDTO `json:"replies"`
+}
+
+// PostCommentsDTO 是一篇文章的评论列表。
+type PostCommentsDTO struct {
+	Comments []*CommentDTO `json:"comments"` // 顶层评论，回复嵌套在 replies 中
+	Total    int64         `json:"total"`    // 已通过的评论总数（包含回复）
+	Open     bool          `json:"open"`     // 是否可以发表评论
+}
+
+// commentsOpen 判断文章当前是否可以发表评论。
+func commentsOpen(post *model.Post) bool {
+	if !post.CommentsEnabled || post.Status != model.PostStatusPublished {
+		return false
+	}
+	days := config.Conf.Comment.AutoCloseDays
+	return days <= 0 || time.Since(post.CreatedAt) < time.Duration(days)*24*time.Hour
+}
+
+// toCommentDTO 将评论模型转换为对外展示的结构。
+func toCommentDTO(comment *model.Comment) *CommentDTO {
+	dto := &CommentDTO{
+		ID:         comment.ID,
+		ParentID:   comment.ParentID,
+		UserID:     comment.UserID,
+		AuthorName: comment.AuthorName,
+		AuthorURL:  comment.AuthorURL,
+		Content:    comment.Content,
+		Status:     CommentStatusNames[comment.Status],
+		CreatedAt:  comment.CreatedAt,
+		Replies:    []*CommentDTO{},
+	}
+	if comment.User != nil {
+		dto.AuthorName = authorName(comment.User)
+		dto.AuthorURL = ""
+	}
+	return dto
+}
+
+// Create 用于发表一条评论。
+// 评论是否需要审核由配置决定，需要审核的评论创建后处于待审核状态，审核通过后才会对外展示。
+func (s *CommentService) Create(dto *CreateCommentDTO) (*CommentDTO, error) {
+	db := dao.GetDB()
+	cfg := config.Conf.Comment
+
+	content := strings.TrimSpace(dto.Content)
+	if content == "" {
+		return nil, ErrCommentEmpty
+	}
+	maxLength := cfg.MaxLength
+	if maxLength <= 0 {
+		maxLength = 5000
+	}
+	if utf8.RuneCountInString(content) > maxLength {
+		return nil, ErrCommentTooLong
+	}
+
+	var post model.Post
+	if err := db.Where("status = ?", model.PostStatusPublished).First(&post, dto.PostID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+	if !commentsOpen(&post) {
+		return nil, ErrCommentsClosed
+	}
+
+	comment := &model.Comment{
+		PostID:    post.ID,
+		ParentID:  dto.ParentID,
+		Content:   content,
+		IP:        dto.IP,
+		UserAgent: truncateRunes(dto.UserAgent, 255),
+	}
+	if dto.UserID != 0 {
+		comment.UserID = &dto.UserID
+	} else {
+		if !cfg.AllowGuests {
+			return nil, ErrCommentLoginRequired
+		}
+		comment.AuthorName = strings.TrimSpace(dto.AuthorName)
+		comment.AuthorEmail = strings.ToLower(strings.TrimSpace(dto.AuthorEmail))
+		comment.AuthorURL = strings.TrimSpace(dto.AuthorURL)
+		if comment.AuthorName == "" || comment.AuthorEmail == "" {
+			return nil, ErrCommentAuthorRequired
+		}
+	}
+
+	if dto.ParentID != nil {
+		if err := s.validateParent(db, post.ID, *dto.ParentID); err != nil {
+			return nil, err
+		}
+	}
+
+	comment.Status = model.CommentStatusApproved
+	switch cfg.Moderation {
+	case CommentModerationNone:
+	case CommentModerationAll:
+		comment.Status = model.CommentStatusPending
+	default:
+		if comment.UserID == nil {
+			comment.Status = model.CommentStatusPending
+		}
+	}
+
+	// 调用注册的过滤器，它们可以修改将要保存的内容，垃圾内容检测针对修改后的内容
+	if err := event.ApplyFilters(comment); err != nil {
+		return nil, err
+	}
+
+	// 垃圾内容检测：可疑的评论进入审核队列，垃圾评论直接归入垃圾箱
+	if comment.UserID != nil {
+		var user model.User
+		if err := db.First(&user, *comment.UserID).Error; err == nil {
+			comment.User = &user
+		}
+	}
+	sub := commentSubmission(comment)
+	sub.Referrer = dto.Referrer
+	sub.Honeypot = dto.Honeypot
+	sub.FormToken = dto.FormToken
+	check := s.spamService.Check(sub)
+	if check.Score >= 0 {
+		comment.SpamScore = &check.Score
+	}
+	comment.SpamReasons = truncateRunes(strings.Join(check.Reasons, "; "), 500)
+	switch check.Verdict {
+	case spam.Spam:
+		comment.Status = model.CommentStatusSpam
+	case spam.Suspect:
+		comment.Status = model.CommentStatusPending
+	}
+
+	comment.User = nil
+	var out outbox
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(comment).Error; err != nil {
+			return err
+		}
+		if err := tx.Preload("User").First(comment, comment.ID).Error; err != nil {
+			return err
+		}
+		// 事件中不包含用户，作者名称单独传入
+		created := *comment
+		created.User = nil
+		return out.publish(tx, event.CommentCreated{Comment: &created, AuthorName: toCommentDTO(comment).AuthorName})
+	})
+	if err != nil {
+		return nil, err
+	}
+	out.flush()
+	result := toCommentDTO(comment)
+	// 不向提交者透露评论被判定为垃圾内容，避免机器人据此调整策略
+	if comment.Status == model.CommentStatusSpam {
+		result.Status = CommentStatusNames[model.CommentStatusPending]
+	}
+	return result, nil
+}
+
+// validateParent 校验被回复的评论：必须属于同一篇文章、已通过审核，且嵌套层数不超过上限。
+func (s *CommentService) validateParent(db *gorm.DB, postID, parentID uint) error {
+	maxDepth := config.Conf.Comment.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = 5
+	}
+
+	depth := 1 // 新评论自身
+	id := &parentID
+	for id != nil {
+		var parent model.Comment
+		if err := db.Select("id, post_id, parent_id, status").First(&parent, *id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrInvalidParentComment
+			}
+			return err
+		}
+		// 只检查直接回复的评论的状态，祖先评论被隐藏不影响已有的回复链
+		if depth == 1 && (parent.PostID != postID || parent.Status != model.CommentStatusApproved) {
+			return ErrInvalidParentComment
+		}
+		depth++
+		if depth > maxDepth {
+			return ErrReplyTooDeep
+		}
+		id = parent.ParentID
+	}
+	return nil
+}
+
+// ListForPost 用于获取一篇文章已通过审核的评论，按发表时间排列并组织成树形结构。
+// 父评论未通过审核（例如被移入回收站）时，它的回复也不会展示。
+func (s *CommentService) ListForPost(postID uint) (*PostCommentsDTO, error) {
+	db := dao.GetDB()
+	var post model.Post
+	if err := db.Where("status = ?", model.PostStatusPublished).First(&post, postID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+
+	var comments []model.Comment
+	if err := db.Preload("User").Where("post_id = ? AND status = ?", postID, model.CommentStatusApproved).
+		Order("created_at ASC, id ASC").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	nodes := make(map[uint]*CommentDTO, len(comments))
+	for i := range comments {
+		nodes[comments[i].ID] = toCommentDTO(&comments[i])
+	}
+	roots := []*CommentDTO{}
+	for i := range comments {
+		node := nodes[comments[i].ID]
+		if node.ParentID == nil {
+			roots = append(roots, node)
+		} else if parent, ok := nodes[*node.ParentID]; ok {
+			parent.Replies = append(parent.Replies, node)
+		}
+	}
+
+	return &PostCommentsDTO{
+		Comments: roots,
+		Total:    int64(len(comments)),
+		Open:     commentsOpen(&post),
+	}, nil
+}
+
+// ListApprovedByPosts 用于批量获取多篇文章已通过审核的顶层评论，按发表时间排列，返回文章 ID 到评论的映射。
+// 回复通过 ListApprovedReplies 逐层获取，返回的评论的 Replies 为空。
+func (s *CommentService) ListApprovedByPosts(postIDs []uint) (map[uint][]*CommentDTO, error) {
+	return listApprovedComments("post_id IN ? AND parent_id IS NULL", postIDs, func(c *model.Comment) uint { return c.PostID })
+}
+
+// ListApprovedReplies 用于批量获取多条评论已通过审核的直接回复，按发表时间排列，返回被回复的评论 ID 到回复的映射。
+func (s *CommentService) ListApprovedReplies(parentIDs []uint) (map[uint][]*CommentDTO, error) {
+	return listApprovedComments("parent_id IN ?", parentIDs, func(c *model.Comment) uint { return *c.ParentID })
+}
+
+// listApprovedComments 查询符合条件的已通过审核的评论，并按 key 返回的 ID 分组。
+func listApprovedComments(cond string, ids []uint, key func(*model.Comment) uint) (map[uint][]*CommentDTO, error) {
+	groups := make(map[uint][]*CommentDTO)
+	if len(ids) == 0 {
+		return groups, nil
+	}
+	var comments []model.Comment
+	if err := dao.GetDB().Preload("User").Where(cond, ids).Where("status = ?", model.CommentStatusApproved).
+		Order("created_at ASC, id ASC").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+	for i := range comments {
+		id := key(&comments[i])
+		groups[id] = append(groups[id], toCommentDTO(&comments[i]))
+	}
+	return groups, nil
+}
+
+// ListCommentsDTO 封装了后台查询评论列表时的参数。
+type ListCommentsDTO struct {
+	Page     int
+	PageSize int
+	Status   *int // 按状态过滤，为 nil 时不过滤
+	PostID   uint // 按文章过滤，为 0 时不过滤
+}
+
+// AdminCommentDTO 是后台审核时使用的评论，包含游客的邮箱、IP 等信息。
+type AdminCommentDTO struct {
+	CommentDTO
+	AuthorEmail string `json:"author_email"`
+	IP          string `json:"ip"`
+	UserAgent   string `json:"user_agent"`
+	PostID      uint   `json:"post_id"`
+	PostTitle   string `json:"post_title"`
+
+	SpamScore   *float64 `json:"spam_score"`   // 贝叶斯分类器给出的垃圾内容概率，未参与分类时为 null
+	SpamReasons string   `json:"spam_reasons"` // 被标记为可疑或垃圾内容的原因
+}
+
+// ListCommentsResponseDTO 封装了后台的评论列表和总数。
+type ListCommentsResponseDTO struct {
+	Comments   []AdminCommentDTO `json:"comments"`
+	TotalCount int64             `json:"total_count"`
+}
+
+// List 用于后台分页查询评论，默认按发表时间倒序排列，可按状态过滤出审核队列。
+func (s *CommentService) List(dto *ListCommentsDTO) (*ListCommentsResponseDTO, error) {
+	db := dao.GetDB()
+	query := db.Model(&model.Comment{})
+	if dto.Status != nil {
+		query = query.Where("status = ?", *dto.Status)
+	}
+	if dto.PostID != 0 {
+		query = query.Where("post_id = ?", dto.PostID)
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	var comments []model.Comment
+	offset := (dto.Page - 1) * dto.PageSize
+	if err := query.Preload("User").Preload("Post", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id, title")
+	}).Order("created_at DESC").Limit(dto.PageSize).Offset(offset).Find(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]AdminCommentDTO, len(comments))
+	for i := range comments {
+		c := &comments[i]
+		result[i] = AdminCommentDTO{
+			CommentDTO:  *toCommentDTO(c),
+			AuthorEmail: c.AuthorEmail,
+			IP:          c.IP,
+			UserAgent:   c.UserAgent,
+			PostID:      c.PostID,
+			SpamScore:   c.SpamScore,
+			SpamReasons: c.SpamReasons,
+		}
+		result[i].Replies = nil
+		if c.Post != nil {
+			result[i].PostTitle = c.Post.Title
+		}
+	}
+	return &ListCommentsResponseDTO{
+		Comments:   result,
+		TotalCount: totalCount,
+	}, nil
+}
+
+// UpdateStatus 用于修改单条评论的状态。
+func (s *CommentService) UpdateStatus(id uint, status int) error {
+	if _, ok := CommentStatusNames[status]; !ok {
+		return ErrInvalidCommentStatus
+	}
+	db := dao.GetDB()
+	var comment model.Comment
+	if err := db.Preload("User").First(&comment, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCommentNotFound
+		}
+		return err
+	}
+	if err := db.Model(&comment).Update("status", status).Error; err != nil {
+		return err
+	}
+	s.train(&comment, status)
+	return nil
+}
+
+// Moderate 用于批量修改评论的状态（通过、标记为垃圾评论、移入回收站或恢复为待审核），返回实际修改的数量。
+func (s *CommentService) Moderate(ids []uint, status int) (int64, error) {
+	if _, ok := CommentStatusNames[status]; !ok {
+		return 0, ErrInvalidCommentStatus
+	}
+	if len(ids) == 0 {
+		return 0, ErrNoCommentsSelected
+	}
+	db := dao.GetDB()
+	var comments []model.Comment
+	if err := db.Preload("User").Where("id IN ?", ids).Find(&comments).Error; err != nil {
+		return 0, err
+	}
+	result := db.Model(&model.Comment{}).Where("id IN ?", ids).Update("status", status)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	for i := range comments {
+		s.train(&comments[i], status)
+	}
+	return result.RowsAffected, nil
+}
+
+// train 根据审核结果训练垃圾内容分类器：通过的评论作为正常内容，标记为垃圾的评论作为垃圾内容。
+// 移入回收站或恢复为待审核不代表对内容性质的判断，不参与训练。训练失败只记录日志，不影响审核操作。
+func (s *CommentService) train(comment *model.Comment, status int) {
+	if status != model.CommentStatusApproved && status != model.CommentStatusSpam {
+		return
+	}
+	if err := s.spamService.TrainComment(comment, status == model.CommentStatusSpam); err != nil {
+		logger.L.Warn("Failed to train spam classifier", zap.Uint("comment_id", comment.ID), zap.Error(err))
+	}
+}
+
+// Delete 用于彻底删除一条评论及其所有回复。
+func (s *CommentService) Delete(id uint) error {
+	db := dao.GetDB()
+	return db.Transaction(func(tx *gorm.DB) error {
+		var comment model.Comment
+		if err := tx.First(&comment, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrCommentNotFound
+			}
+			return err
+		}
+
+		// 逐层查找所有回复
+		ids := []uint{id}
+		for parents := []uint{id}; len(parents) > 0; {
+			var children []uint
+			if err := tx.Model(&model.Comment{}).Where("parent_id IN ?", parents).Pluck("id", &children).Error; err != nil {
+				return err
+			}
+			ids = append(ids, children...)
+			parents = children
+		}
+		return tx.Where("id IN ?", ids).Delete(&model.Comment{}).Error
+	})
+}
+
+// EmptyTrash 用于彻底删除回收站和垃圾评论中的所有评论，返回删除的数量。
+// 这些评论的回复会保留，但由于父评论已不存在，它们不会再对外展示。
+func (s *CommentService) EmptyTrash() (int64, error) {
+	result := dao.GetDB().Where("status IN ?", []int{model.CommentStatusSpam, model.CommentStatusTrash}).Delete(&model.Comment{})
+	return result.RowsAffected, result.Error
+}
+
+// commentCountRow 是统计评论数时使用的查询结果。
+type commentCountRow struct {
+	PostID uint
+	Count  int64
+}
+
+// fillCommentCounts 用一次分组查询填充多篇文章已通过的评论数，避免逐篇查询。
+func fillCommentCounts(db *gorm.DB, posts []model.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+	var rows []commentCountRow
+	if err := db.Model(&model.Comment{}).Select("post_id, COUNT(*) AS count").
+		Where("post_id IN ? AND status = ?", ids, model.CommentStatusApproved).
+		Group("post_id").Scan(&rows).Error; err != nil {
+		return err
+	}
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+	for i := range posts {
+		posts[i].CommentCount = counts[posts[i].ID]
+	}
+	return nil
+}
+
+// truncateRunes 将字符串截断到最多 n 个字符。
+func truncateRunes(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
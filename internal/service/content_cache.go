@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/event"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// RenderedDocument 是渲染好的对外文档（订阅源、站点地图等）及其缓存校验信息。
+type RenderedDocument struct {
+	Body         []byte
+	ContentType  string
+	ETag         string    // 由内容摘要生成的强 ETag
+	LastModified time.Time // 文档中最近一次更新的内容的时间
+}
+
+// newRenderedDocument 创建一个 RenderedDocument，并根据内容计算 ETag。
+func newRenderedDocument(body []byte, contentType string, lastModified time.Time) *RenderedDocument {
+	sum := sha256.Sum256(body)
+	return &RenderedDocument{
+		Body:         body,
+		ContentType:  contentType,
+		ETag:         `"` + hex.EncodeToString(sum[:16]) + `"`,
+		LastModified: lastModified,
+	}
+}
+
+// contentCacheMaxEntries 是单个缓存的最大条目数，超过后清空重建，防止被大量不同的参数撑满内存。
+const contentCacheMaxEntries = 512
+
+// contentCacheVersionName 是文章、分类和标签数据在 cache_versions 表中的名称。
+const contentCacheVersionName = "content"
+
+// contentCache 是依赖文章、分类和标签数据的文档缓存。
+// 每次内容变化时版本号加一并清空缓存。
+//
+// 缓存保存在进程内存中。多个实例共享数据库时，修改内容的实例通过 outbox 的订阅者把数据库中的
+// 共享版本号加一，其余实例读取缓存时发现共享版本号变化，清空自己的缓存。
+type contentCache struct {
+	mu      sync.RWMutex
+	version uint64
+	entries map[string]*RenderedDocument
+
+	syncMu    sync.Mutex
+	syncedAt  time.Time // 最近一次检查共享版本号的时间
+	sharedVer uint64    // 最近一次检查时读到的共享版本号
+}
+
+func newContentCache() *contentCache {
+	return &contentCache{entries: make(map[string]*RenderedDocument)}
+}
+
+func (c *contentCache) get(key string) (*RenderedDocument, bool) {
+	c.sync()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	doc, ok := c.entries[key]
+	return doc, ok
+}
+
+// currentVersion 返回当前的缓存版本。生成文档前记录版本，写入时版本已变化说明内容在生成期间被修改，
+// 此时不写入缓存，避免缓存旧数据。
+func (c *contentCache) currentVersion() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+func (c *contentCache) set(key string, doc *RenderedDocument, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if version != c.version {
+		return
+	}
+	if len(c.entries) >= contentCacheMaxEntries {
+		c.entries = make(map[string]*RenderedDocument)
+	}
+	c.entries[key] = doc
+}
+
+func (c *contentCache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.version++
+	c.entries = make(map[string]*RenderedDocument)
+}
+
+// sync 检查数据库中的共享版本号，其他实例修改了内容时清空缓存。
+// 两次检查的间隔不小于 content_cache.sync_interval，读取失败时继续使用现有的缓存。
+func (c *contentCache) sync() {
+	interval := config.Conf.ContentCache.SyncInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	c.syncMu.Lock()
+	defer c.syncMu.Unlock()
+	if time.Since(c.syncedAt) < interval {
+		return
+	}
+	c.syncedAt = time.Now()
+
+	var v model.CacheVersion
+	if err := dao.GetDB().Limit(1).Find(&v, "name = ?", contentCacheVersionName).Error; err != nil {
+		logger.L.Warn("Failed to check content cache version", zap.Error(err))
+		return
+	}
+	if v.Version != c.sharedVer {
+		c.sharedVer = v.Version
+		c.purge()
+	}
+}
+
+// bumpContentVersion 将共享版本号加一，使所有实例的缓存失效。
+func bumpContentVersion(db *gorm.DB) error {
+	return db.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&model.CacheVersion{Name: contentCacheVersionName, Version: 1}).Error
+}
+
+var (
+	// _feedCache 缓存按请求参数生成的订阅源。
+	_feedCache = newContentCache()
+	// _sitemapCache 缓存站点地图和站点地图索引。
+	_sitemapCache = newContentCache()
+)
+
+// 文章、分类或标签发生变化后使缓存失效。
+func init() {
+	subscribeContentChanged[event.PostCreated]()
+	subscribeContentChanged[event.PostUpdated]()
+	subscribeContentChanged[event.PostDeleted]()
+	subscribeContentChanged[event.CategoryUpdated]()
+	subscribeContentChanged[event.CategoryDeleted]()
+	subscribeContentChanged[event.TagUpdated]()
+	subscribeContentChanged[event.TagDeleted]()
+	subscribeContentChanged[event.TagsMerged]()
+}
+
+// subscribeContentChanged 在事件 E 发生后调用 notifyContentChanged。
+// 更新共享版本号失败时返回错误，由 outbox 稍后重试，以免其他实例一直使用旧的缓存。
+func subscribeContentChanged[E event.Event]() {
+	event.Subscribe("content_cache", func(context.Context, E) error {
+		return notifyContentChanged()
+	})
+}
+
+// notifyContentChanged 在文章、分类或标签发生变化后调用，使所有实例上依赖这些数据的缓存失效，
+// 并安排通知搜索引擎站点地图已更新。
+func notifyContentChanged() error {
+	_feedCache.purge()
+	_sitemapCache.purge()
+	if err := bumpContentVersion(dao.GetDB()); err != nil {
+		return err
+	}
+	scheduleSitemapPing()
+	return nil
+}
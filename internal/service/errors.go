@@ -0,0 +1,143 @@
+package service
+
+import "github.com/KeLes-Coding/gopress/internal/apperr"
+
+// 本文件是 service 层业务错误的目录。handler 通过 apperr 将它们映射为 HTTP 状态码和错误码，
+// 错误码一经发布就不应修改，客户端依赖它们区分错误类型。同一错误码下更具体的消息通过 Variant 定义，
+// 消息中的占位符通过 WithArgs 填充。消息的其他语言翻译位于 internal/i18n/locales。
+
+// 文章
+var (
+	ErrPostNotFound    = apperr.NotFound("post_not_found", "文章不存在")
+	ErrInvalidCategory = apperr.Invalid("invalid_category", "无效的分类 ID")
+	ErrInvalidOGImage  = apperr.Invalid("invalid_og_image", "无效的分享图片 ID")
+	ErrOGImageNotImage = apperr.Invalid("og_image_not_image", "分享图片必须是图片文件")
+	ErrInvalidPostURL  = apperr.Invalid("invalid_post_url", "不是本站的文章地址")
+	ErrPostTitleEmpty  = apperr.Invalid("post_title_empty", "文章标题不能为空")
+	ErrPostForbidden   = apperr.Forbidden("post_forbidden", "只能管理自己的文章")
+
+	ErrSiteURLNotConfigured = ErrInvalidPostURL.Variant("invalid_post_url.site_url", "站点地址未配置")
+	ErrMalformedURL         = ErrInvalidPostURL.Variant("invalid_post_url.malformed", "无效的地址")
+	ErrForeignURL           = ErrInvalidPostURL.Variant("invalid_post_url.foreign", "不是本站的地址")
+)
+
+// 多语言内容
+var (
+	ErrUnsupportedContentLanguage = apperr.Invalid("unsupported_content_language", "站点不支持该语言的内容: %s")
+	ErrTranslationSourceNotFound  = apperr.Invalid("invalid_translation_source", "原文不存在")
+	ErrTranslationExists          = apperr.Conflict("translation_exists", "该文章已有 %s 版本")
+)
+
+// 分类
+var (
+	ErrCategoryNotFound    = apperr.NotFound("category_not_found", "该分类不存在")
+	ErrCategoryNameEmpty   = apperr.Invalid("category_name_empty", "分类名称不能为空")
+	ErrCategoryNameTaken   = apperr.Conflict("category_name_taken", "该分类名称已存在")
+	ErrNoCategoryAvailable = apperr.Invalid("no_category_available", "请先创建一个分类")
+
+	ErrCategoryNamesNotFound = ErrCategoryNotFound.Variant("category_not_found.names", "分类不存在: %s")
+)
+
+// 标签
+var (
+	ErrTagNotFound       = apperr.NotFound("tag_not_found", "该标签不存在")
+	ErrTagNameEmpty      = apperr.Invalid("tag_name_empty", "标签名称不能为空")
+	ErrTagNameTooLong    = apperr.Invalid("tag_name_too_long", "标签名称 %q 超过 %d 个字符")
+	ErrTagNameTaken      = apperr.Conflict("tag_name_taken", "该标签名称已存在")
+	ErrInvalidTagIDs     = apperr.Invalid("invalid_tag_ids", "包含无效的标签 ID")
+	ErrMergeSourcesEmpty = apperr.Invalid("merge_sources_empty", "请至少选择一个与目标不同的源标签")
+
+	ErrMergeTargetNotFound = ErrTagNotFound.Variant("tag_not_found.merge_target", "目标标签不存在")
+	ErrInvalidMergeSources = ErrInvalidTagIDs.Variant("invalid_tag_ids.merge_sources", "包含无效的源标签 ID")
+)
+
+// 用户与认证
+var (
+	ErrSignUpTooShort      = apperr.Invalid("signup_too_short", "用户名长度不能少于4位，密码长度不能少于6位")
+	ErrEmailRequired       = apperr.Invalid("email_required", "邮箱不能为空")
+	ErrUnsupportedLanguage = apperr.Invalid("unsupported_language", "不支持的语言: %s")
+	ErrUsernameTaken       = apperr.Conflict("username_taken", "用户名已存在")
+	ErrEmailTaken          = apperr.Conflict("email_taken", "该邮箱已被注册")
+	ErrInvalidCredentials  = apperr.Unauthenticated("invalid_credentials", "用户名或密码错误")
+	ErrAuthorNotFound      = apperr.NotFound("author_not_found", "该作者不存在")
+	ErrInvalidAccessToken  = apperr.Unauthenticated("invalid_access_token", "无效的访问令牌")
+	ErrAccessTokenNotFound = apperr.NotFound("access_token_not_found", "令牌不存在")
+	ErrInsufficientScope   = apperr.Forbidden("insufficient_scope", "访问令牌缺少所需的权限范围: %s")
+	ErrInvalidTokenRequest = apperr.Invalid("invalid_token_request", "无效的令牌参数")
+
+	ErrInvalidTokenScope   = ErrInvalidTokenRequest.Variant("invalid_token_request.scope", "无效的权限范围: %s")
+	ErrTokenScopesEmpty    = ErrInvalidTokenRequest.Variant("invalid_token_request.scopes_empty", "至少需要一个权限范围")
+	ErrTokenNameEmpty      = ErrInvalidTokenRequest.Variant("invalid_token_request.name_empty", "令牌名称不能为空")
+	ErrTokenExpiryNegative = ErrInvalidTokenRequest.Variant("invalid_token_request.expires_in", "有效天数不能为负数")
+)
+
+// 评论
+var (
+	ErrCommentNotFound       = apperr.NotFound("comment_not_found", "评论不存在")
+	ErrCommentEmpty          = apperr.Invalid("comment_empty", "评论内容不能为空")
+	ErrCommentTooLong        = apperr.Invalid("comment_too_long", "评论内容过长")
+	ErrCommentsClosed        = apperr.Forbidden("comments_closed", "该文章已关闭评论")
+	ErrCommentLoginRequired  = apperr.Unauthenticated("comment_login_required", "请登录后再发表评论")
+	ErrCommentAuthorRequired = apperr.Invalid("comment_author_required", "请填写昵称和邮箱")
+	ErrInvalidParentComment  = apperr.Invalid("invalid_parent_comment", "回复的评论不存在")
+	ErrReplyTooDeep          = apperr.Invalid("reply_too_deep", "回复层数过多")
+	ErrInvalidCommentStatus  = apperr.Invalid("invalid_comment_status", "无效的评论状态")
+	ErrNoCommentsSelected    = apperr.Invalid("no_comments_selected", "请选择要处理的评论")
+)
+
+// Webmention
+var (
+	ErrWebmentionNotFound      = apperr.NotFound("webmention_not_found", "通知不存在")
+	ErrInvalidWebmention       = apperr.Invalid("invalid_webmention", "无效的 Webmention")
+	ErrInvalidWebmentionStatus = apperr.Invalid("invalid_webmention_status", "无效的审核状态")
+
+	ErrWebmentionURLScheme  = ErrInvalidWebmention.Variant("invalid_webmention.scheme", "source 和 target 必须是 http 或 https 地址")
+	ErrWebmentionURLTooLong = ErrInvalidWebmention.Variant("invalid_webmention.too_long", "source 或 target 过长")
+	ErrWebmentionSameURL    = ErrInvalidWebmention.Variant("invalid_webmention.same_url", "source 和 target 不能相同")
+	ErrWebmentionNoSiteURL  = ErrInvalidWebmention.Variant("invalid_webmention.site_url", "站点地址未配置，无法接收 Webmention")
+	ErrWebmentionTarget     = ErrInvalidWebmention.Variant("invalid_webmention.target", "target %s")
+)
+
+// Webhook
+var (
+	ErrWebhookNotFound         = apperr.NotFound("webhook_not_found", "Webhook 不存在")
+	ErrWebhookDeliveryNotFound = apperr.NotFound("webhook_delivery_not_found", "投递记录不存在")
+	ErrInvalidWebhook          = apperr.Invalid("invalid_webhook", "无效的 Webhook 参数")
+
+	ErrWebhookNameEmpty    = ErrInvalidWebhook.Variant("invalid_webhook.name_empty", "Webhook 名称不能为空")
+	ErrWebhookURL          = ErrInvalidWebhook.Variant("invalid_webhook.url", "地址必须是 http 或 https 地址")
+	ErrWebhookEvent        = ErrInvalidWebhook.Variant("invalid_webhook.event", "无效的事件: %s")
+	ErrWebhookEventsEmpty  = ErrInvalidWebhook.Variant("invalid_webhook.events_empty", "至少需要订阅一个事件")
+	ErrWebhookSecretLength = ErrInvalidWebhook.Variant("invalid_webhook.secret", "密钥长度必须在 %d 到 %d 个字符之间")
+)
+
+// 后台任务
+var (
+	ErrJobNotFound     = apperr.NotFound("job_not_found", "任务不存在")
+	ErrJobNotRetryable = apperr.Conflict("job_not_retryable", "只能重试等待执行或执行失败的任务")
+)
+
+// 媒体文件
+var (
+	ErrMediaNotFound       = apperr.NotFound("media_not_found", "媒体文件不存在")
+	ErrMediaInUse          = apperr.Conflict("media_in_use", "该文件正在被 %d 篇文章引用，如需删除请确认后强制删除")
+	ErrMediaEmpty          = apperr.Invalid("media_empty", "上传的文件为空")
+	ErrMediaTooLarge       = apperr.Invalid("media_too_large", "上传的文件过大")
+	ErrMediaTypeNotAllowed = apperr.Invalid("media_type_not_allowed", "不支持的文件类型: %s")
+	ErrMediaCorrupted      = apperr.Invalid("media_corrupted", "无法解析图片文件，文件可能已损坏")
+	ErrMediaNotProcessable = apperr.Invalid("media_not_processable", "该文件不是可处理的图片")
+	ErrPresignNotSupported = apperr.Invalid("presign_not_supported", "当前存储后端不支持直传")
+	ErrInvalidUploadKey    = apperr.Invalid("invalid_upload_key", "无效的上传凭证")
+	ErrUploadNotFound      = apperr.NotFound("upload_not_found", "未找到已上传的文件")
+	ErrMediaProcessingBusy = apperr.Unavailable("media_processing_busy", "图片处理队列繁忙，请稍后重试")
+	ErrMediaForbidden      = apperr.Forbidden("media_forbidden", "只能管理自己上传的文件")
+
+	ErrMediaSizeLimit  = ErrMediaTooLarge.Variant("media_too_large.limit", "文件大小不能超过 %d MB")
+	ErrMediaPixelLimit = ErrMediaTooLarge.Variant("media_too_large.pixels", "图片的像素数 (宽 x 高) 不能超过 %d")
+)
+
+// 站点页面
+var (
+	ErrSitePageNotFound = apperr.NotFound("page_not_found", "页面不存在")
+	ErrSitemapNotFound  = apperr.NotFound("sitemap_not_found", "站点地图不存在")
+)
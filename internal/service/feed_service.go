@@ -0,0 +1,249 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/feed"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/sanitize"
+	"github.com/KeLes-Coding/gopress/internal/util"
+	"gorm.io/gorm"
+)
+
+// 订阅源的内容模式。
+const (
+	FeedModeFull    = "full"    // 输出文章全文
+	FeedModeSummary = "summary" // 只输出摘要
+)
+
+// feedSummaryLength 是文章没有填写摘要时，从正文中截取的摘要长度（字符数）。
+const feedSummaryLength = 200
+
+// FeedService 结构体封装了生成订阅源的业务逻辑。
+// 生成的订阅源会被缓存，直到文章、分类或标签发生变化。
+type FeedService struct {
+	postService *PostService
+}
+
+// NewFeedService 是 FeedService 的工厂函数。
+func NewFeedService() *FeedService {
+	return &FeedService{
+		postService: NewPostService(),
+	}
+}
+
+// FeedQuery 封装了请求一个订阅源时的参数。CategoryID、TagID、UserID 最多只应设置一个。
+type FeedQuery struct {
+	Format     string // 订阅源格式: rss, atom, json
+	Mode       string // 内容模式: full, summary，为空时使用配置中的默认值
+	CategoryID uint
+	TagID      uint
+	UserID     uint
+	Lang       string // 只包含该语言的文章，分类和标签名称也使用该语言；为空时包含所有语言
+	FeedURL    string // 订阅源自身的地址
+}
+
+// Build 用于生成（或从缓存中读取）一个订阅源。
+func (s *FeedService) Build(q *FeedQuery) (*RenderedDocument, error) {
+	mode := q.Mode
+	if mode == "" {
+		mode = config.Conf.Site.FeedMode
+	}
+	if mode != FeedModeSummary {
+		mode = FeedModeFull
+	}
+
+	if q.Lang != "" {
+		lang, ok := MatchContentLanguage(q.Lang)
+		if !ok {
+			return nil, ErrUnsupportedContentLanguage.WithArgs(q.Lang)
+		}
+		q.Lang = lang
+	}
+
+	key := fmt.Sprintf("%s|%s|%d|%d|%d|%s|%s", q.Format, mode, q.CategoryID, q.TagID, q.UserID, q.Lang, q.FeedURL)
+	if result, ok := _feedCache.get(key); ok {
+		return result, nil
+	}
+	// 记录开始生成时的缓存版本，生成期间内容发生变化时不写入缓存，避免缓存旧数据
+	version := _feedCache.currentVersion()
+
+	f, err := s.buildFeed(q, mode)
+	if err != nil {
+		return nil, err
+	}
+	body, err := f.Render(q.Format)
+	if err != nil {
+		return nil, err
+	}
+	result := newRenderedDocument(body, feed.ContentType(q.Format), f.Updated)
+	_feedCache.set(key, result, version)
+	return result, nil
+}
+
+// buildFeed 查询文章并组装成与格式无关的订阅源。
+func (s *FeedService) buildFeed(q *FeedQuery, mode string) (*feed.Feed, error) {
+	site := config.Conf.Site
+	siteURL := strings.TrimRight(site.URL, "/")
+	f := &feed.Feed{
+		Title:       site.Title,
+		Description: site.Description,
+		Link:        siteURL + "/",
+		FeedURL:     q.FeedURL,
+		Language:    site.Language,
+	}
+	if q.Lang != "" {
+		f.Language = q.Lang
+	}
+
+	// 分类、标签、作者的订阅源需要先确认对象存在，并在标题中注明
+	db := dao.GetDB()
+	switch {
+	case q.CategoryID != 0:
+		var category model.Category
+		if err := db.First(&category, q.CategoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrCategoryNotFound
+			}
+			return nil, err
+		}
+		name, err := localizedCategoryName(db, &category, q.Lang)
+		if err != nil {
+			return nil, err
+		}
+		f.Title = fmt.Sprintf("%s - 分类: %s", site.Title, name)
+	case q.TagID != 0:
+		var tag model.Tag
+		if err := db.First(&tag, q.TagID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrTagNotFound
+			}
+			return nil, err
+		}
+		name, err := localizedTagName(db, &tag, q.Lang)
+		if err != nil {
+			return nil, err
+		}
+		f.Title = fmt.Sprintf("%s - 标签: %s", site.Title, name)
+	case q.UserID != 0:
+		var user model.User
+		if err := db.First(&user, q.UserID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrAuthorNotFound
+			}
+			return nil, err
+		}
+		f.Title = fmt.Sprintf("%s - 作者: %s", site.Title, authorName(&user))
+	}
+
+	limit := site.FeedLimit
+	if limit <= 0 {
+		limit = 20
+	}
+	posts, err := s.postService.ListPublished(&ListPublishedDTO{
+		CategoryID: q.CategoryID,
+		TagID:      q.TagID,
+		UserID:     q.UserID,
+		Lang:       q.Lang,
+		Limit:      limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+	alternates, err := publishedAlternates(db, posts)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range posts {
+		post := &posts[i]
+		link := PostURL(post.ID)
+		item := feed.Item{
+			ID:        link,
+			Title:     post.Title,
+			Link:      link,
+			Summary:   postSummary(post),
+			Author:    authorName(&post.User),
+			Published: post.CreatedAt,
+			Updated:   post.UpdatedAt,
+			Lang:      PostLang(post),
+		}
+		for _, alt := range alternates[post.ID] {
+			item.Alternates = append(item.Alternates, feed.Alternate{Lang: alt.Lang, Link: PostURL(alt.ID)})
+		}
+		if mode == FeedModeFull {
+			item.Content = sanitize.HTML(post.Content)
+		}
+		if post.Category.Name != "" {
+			item.Categories = append(item.Categories, post.Category.Name)
+		}
+		for _, tag := range post.Tags {
+			item.Categories = append(item.Categories, tag.Name)
+		}
+		if post.UpdatedAt.After(f.Updated) {
+			f.Updated = post.UpdatedAt
+		}
+		f.Items = append(f.Items, item)
+	}
+	if f.Updated.IsZero() {
+		f.Updated = time.Now()
+	}
+	return f, nil
+}
+
+// PostURL 返回文章在站点（前端）上的永久链接。
+func PostURL(id uint) string {
+	return strings.TrimRight(config.Conf.Site.URL, "/") + "/posts/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// postPathRe 匹配文章永久链接的路径部分。
+var postPathRe = regexp.MustCompile(`^/posts/(\d+)/?$`)
+
+// ParsePostURL 是 PostURL 的逆操作，从本站的文章永久链接中解析出文章 ID。
+func ParsePostURL(raw string) (uint, error) {
+	site, err := url.Parse(config.Conf.Site.URL)
+	if err != nil || site.Host == "" {
+		return 0, ErrSiteURLNotConfigured
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return 0, ErrMalformedURL
+	}
+	sitePath := strings.TrimRight(site.Path, "/")
+	if !strings.EqualFold(u.Host, site.Host) || !strings.HasPrefix(u.Path, sitePath+"/") {
+		return 0, ErrForeignURL
+	}
+	m := postPathRe.FindStringSubmatch(strings.TrimPrefix(u.Path, sitePath))
+	if m == nil {
+		return 0, ErrInvalidPostURL
+	}
+	id, err := strconv.ParseUint(m[1], 10, 32)
+	if err != nil {
+		return 0, ErrInvalidPostURL
+	}
+	return uint(id), nil
+}
+
+// postSummary 返回文章的摘要，未填写时从正文中截取。
+func postSummary(post *model.Post) string {
+	if summary := strings.TrimSpace(post.Summary); summary != "" {
+		return summary
+	}
+	return util.Excerpt(post.Content, feedSummaryLength)
+}
+
+// authorName 返回作者对外显示的名称，优先使用昵称。
+func authorName(user *model.User) string {
+	if user.Nickname != "" {
+		return user.Nickname
+	}
+	return user.Username
+}
@@ -0,0 +1,549 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 队列名称。任务类型没有指定队列时使用 JobQueueDefault。
+const (
+	JobQueueDefault     = "default"     // 一般的后台任务
+	JobQueueMaintenance = "maintenance" // 站点维护类的任务，例如通知搜索引擎
+)
+
+// jobCleanupInterval 是删除过期任务的间隔。
+const jobCleanupInterval = time.Hour
+
+// JobOptions 是任务类型的执行选项，零值表示使用默认值。
+type JobOptions struct {
+	Queue       string        // 所属的队列，默认为 JobQueueDefault
+	MaxAttempts int           // 最大尝试次数，默认使用配置中的 max_attempts
+	Timeout     time.Duration // 每次执行的超时时间，也是执行租约的时长，默认使用配置中的 timeout
+}
+
+// errJobKindUnregistered 表示本进程没有注册任务的类型，任务会被放回队列而不计入尝试次数。
+var errJobKindUnregistered = errors.New("未注册的任务类型")
+
+// jobHandler 是一个已注册的任务类型。
+type jobHandler struct {
+	opts JobOptions
+	run  func(ctx context.Context, payload []byte) error
+}
+
+var (
+	_jobHandlersMu sync.RWMutex
+	_jobHandlers   = make(map[string]*jobHandler)
+)
+
+// JobType 是参数类型为 P 的任务类型，通过它创建的任务只会交给对应的处理函数执行。
+type JobType[P any] struct {
+	kind string
+	opts JobOptions
+}
+
+// NewJobType 注册一个任务类型，kind 是任务类型的名称，保存在任务中，注册后不能修改。
+// 它应在包初始化时调用，同一名称只能注册一次。
+//
+// 处理函数返回错误时任务按指数退避重试，返回 PermanentJobError 包装的错误时直接标记为执行失败；
+// 执行超时或程序退出时 ctx 会被取消。任务至少执行一次，执行者崩溃时任务会在租约到期后重新执行，
+// 处理函数应当是幂等的。
+func NewJobType[P any](kind string, opts JobOptions, fn func(ctx context.Context, payload P) error) *JobType[P] {
+	if opts.Queue == "" {
+		opts.Queue = JobQueueDefault
+	}
+	_jobHandlersMu.Lock()
+	defer _jobHandlersMu.Unlock()
+	if _, ok := _jobHandlers[kind]; ok {
+		panic(fmt.Sprintf("service: 任务类型 %s 已经注册", kind))
+	}
+	_jobHandlers[kind] = &jobHandler{
+		opts: opts,
+		run: func(ctx context.Context, payload []byte) error {
+			var p P
+			if err := json.Unmarshal(payload, &p); err != nil {
+				return PermanentJobError(fmt.Errorf("无法解析任务参数: %w", err))
+			}
+			return fn(ctx, p)
+		},
+	}
+	return &JobType[P]{kind: kind, opts: opts}
+}
+
+// Enqueue 创建一个立即执行的任务。db 可以是事务，任务随事务一起提交。
+func (t *JobType[P]) Enqueue(db *gorm.DB, payload P) (*model.Job, error) {
+	return t.EnqueueAt(db, payload, time.Now())
+}
+
+// EnqueueAt 创建一个在 runAt 之后执行的任务。db 可以是事务，任务随事务一起提交。
+func (t *JobType[P]) EnqueueAt(db *gorm.DB, payload P, runAt time.Time) (*model.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	job := &model.Job{
+		Queue:       t.opts.Queue,
+		Kind:        t.kind,
+		Payload:     string(data),
+		RunAt:       runAt,
+		MaxAttempts: jobMaxAttempts(t.opts),
+	}
+	if err := db.Create(job).Error; err != nil {
+		return nil, err
+	}
+	if !runAt.After(time.Now()) {
+		wakeJobRunner()
+	}
+	return job, nil
+}
+
+// Debounce 合并短时间内的多次请求：已有等待执行的同类任务时，将它推迟到 delay 之后并替换参数，
+// 否则创建一个 delay 之后执行的任务。并发调用时可能创建多个任务。
+func (t *JobType[P]) Debounce(db *gorm.DB, payload P, delay time.Duration) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	runAt := time.Now().Add(delay)
+	result := db.Model(&model.Job{}).Where("kind = ? AND status = ?", t.kind, model.JobQueued).
+		Updates(map[string]interface{}{"payload": string(data), "run_at": runAt})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+	_, err = t.EnqueueAt(db, payload, runAt)
+	return err
+}
+
+// permanentJobError 表示重试也不会成功的错误。
+type permanentJobError struct {
+	err error
+}
+
+func (e *permanentJobError) Error() string { return e.err.Error() }
+func (e *permanentJobError) Unwrap() error { return e.err }
+
+// PermanentJobError 包装处理函数返回的错误，表示重试也不会成功，任务直接标记为执行失败。
+func PermanentJobError(err error) error {
+	return &permanentJobError{err: err}
+}
+
+// jobMaxAttempts 返回任务类型的最大尝试次数。
+func jobMaxAttempts(opts JobOptions) int {
+	if opts.MaxAttempts > 0 {
+		return opts.MaxAttempts
+	}
+	if n := config.Conf.Jobs.MaxAttempts; n > 0 {
+		return n
+	}
+	return 5
+}
+
+// jobTimeout 返回任务类型每次执行的超时时间。
+func jobTimeout(opts JobOptions) time.Duration {
+	if opts.Timeout > 0 {
+		return opts.Timeout
+	}
+	if timeout := config.Conf.Jobs.Timeout; timeout > 0 {
+		return timeout
+	}
+	return 5 * time.Minute
+}
+
+// JobDrainTimeout 返回关停时等待执行中的任务完成的最长时间。
+func JobDrainTimeout() time.Duration {
+	if timeout := config.Conf.Jobs.DrainTimeout; timeout > 0 {
+		return timeout
+	}
+	return 30 * time.Second
+}
+
+// jobRetryDelay 返回第 attempts 次失败后的重试间隔，每次翻倍，最长一小时。
+func jobRetryDelay(attempts int) time.Duration {
+	delay := config.Conf.Jobs.RetryDelay
+	if delay <= 0 {
+		delay = 30 * time.Second
+	}
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
+
+// jobQueueConcurrency 返回队列在本进程中的最大并发数。
+func jobQueueConcurrency(queue string) int {
+	if n := config.Conf.Jobs.Queues[queue]; n > 0 {
+		return n
+	}
+	return 1
+}
+
+// jobQueues 返回配置中的队列和已注册的任务类型使用的队列。
+func jobQueues() []string {
+	seen := make(map[string]bool)
+	for queue := range config.Conf.Jobs.Queues {
+		seen[queue] = true
+	}
+	_jobHandlersMu.RLock()
+	for _, h := range _jobHandlers {
+		seen[h.opts.Queue] = true
+	}
+	_jobHandlersMu.RUnlock()
+	queues := make([]string, 0, len(seen))
+	for queue := range seen {
+		queues = append(queues, queue)
+	}
+	sort.Strings(queues)
+	return queues
+}
+
+// JobRunner 在后台执行到期的任务。每个队列由一个 goroutine 取出任务，并发数不超过队列的限制；
+// 另有一个 goroutine 将租约到期的任务重新排队，并删除过期的任务。
+type JobRunner struct {
+	id string // 执行者的标识，写入任务的 locked_by
+
+	ctx    context.Context // 停止取出新任务
+	cancel context.CancelFunc
+	// jobCtx 是执行中的任务使用的 context，Shutdown 等待超时后才取消
+	jobCtx    context.Context
+	jobCancel context.CancelFunc
+
+	wake    map[string]chan struct{}
+	pollers sync.WaitGroup
+	running sync.WaitGroup
+
+	lastCleanup time.Time
+}
+
+var (
+	_jobRunner   *JobRunner
+	_jobRunnerMu sync.Mutex
+)
+
+// StartJobRunner 启动后台任务的执行者，它必须在数据库初始化之后调用，程序退出前应调用 Shutdown。
+func StartJobRunner() *JobRunner {
+	hostname, _ := os.Hostname()
+	ctx, cancel := context.WithCancel(context.Background())
+	jobCtx, jobCancel := context.WithCancel(context.Background())
+	r := &JobRunner{
+		id:        fmt.Sprintf("%s:%d", hostname, os.Getpid()),
+		ctx:       ctx,
+		cancel:    cancel,
+		jobCtx:    jobCtx,
+		jobCancel: jobCancel,
+		wake:      make(map[string]chan struct{}),
+	}
+	queues := jobQueues()
+	for _, queue := range queues {
+		r.wake[queue] = make(chan struct{}, 1)
+	}
+	_jobRunnerMu.Lock()
+	_jobRunner = r
+	_jobRunnerMu.Unlock()
+
+	for _, queue := range queues {
+		r.pollers.Add(1)
+		go r.poll(queue, jobQueueConcurrency(queue))
+	}
+	r.pollers.Add(1)
+	go r.maintain()
+	logger.L.Info("Job runner started", zap.Strings("queues", queues), zap.String("runner", r.id))
+	return r
+}
+
+// wakeJobRunner 通知执行者有新的到期任务，执行者未启动时什么也不做。
+func wakeJobRunner() {
+	_jobRunnerMu.Lock()
+	r := _jobRunner
+	_jobRunnerMu.Unlock()
+	if r == nil {
+		return
+	}
+	for _, wake := range r.wake {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Shutdown 停止取出新任务，并等待执行中的任务完成。ctx 到期时取消执行中的任务并返回，
+// 响应取消的任务会重新排队，没有响应的任务在租约到期后由其他执行者重新执行。
+func (r *JobRunner) Shutdown(ctx context.Context) error {
+	r.cancel()
+	drained := make(chan struct{})
+	go func() {
+		r.pollers.Wait()
+		r.running.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		r.jobCancel()
+		return nil
+	case <-ctx.Done():
+		r.jobCancel()
+		return ctx.Err()
+	}
+}
+
+// poll 是一个队列的主循环，有空闲的并发名额时取出到期的任务执行。
+func (r *JobRunner) poll(queue string, limit int) {
+	defer r.pollers.Done()
+	interval := config.Conf.Jobs.PollInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	slots := make(chan struct{}, limit)
+	freed := make(chan struct{}, 1)
+	for {
+		if free := limit - len(slots); free > 0 {
+			jobs, err := r.claim(queue, free)
+			if err != nil {
+				logger.L.Error("Failed to claim jobs", zap.String("queue", queue), zap.Error(err))
+			}
+			for i := range jobs {
+				slots <- struct{}{}
+				r.running.Add(1)
+				go func(job *model.Job) {
+					defer func() {
+						<-slots
+						r.running.Done()
+						select {
+						case freed <- struct{}{}:
+						default:
+						}
+					}()
+					r.execute(job)
+				}(&jobs[i])
+			}
+			// 取满了名额说明可能还有到期任务，名额空出后立即继续
+			if len(jobs) == free {
+				select {
+				case <-r.ctx.Done():
+					return
+				case <-freed:
+				}
+				continue
+			}
+		}
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.wake[queue]:
+		case <-freed:
+		}
+	}
+}
+
+// claim 取出队列中最多 n 个到期的任务并标记为正在执行。
+// 其他执行者已锁定的任务会被跳过 (SKIP LOCKED)，因此多个进程可以同时处理同一个队列。
+func (r *JobRunner) claim(queue string, n int) ([]model.Job, error) {
+	var jobs []model.Job
+	err := dao.GetDB().Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("queue = ? AND status = ? AND run_at <= ?", queue, model.JobQueued, now).
+			Order("run_at ASC, id ASC").Limit(n).Find(&jobs).Error; err != nil {
+			return err
+		}
+		for i := range jobs {
+			job := &jobs[i]
+			lockedUntil := now.Add(jobTimeout(jobOptions(job.Kind)))
+			job.Status = model.JobRunning
+			job.Attempts++
+			job.LockedBy = r.id
+			job.LockedUntil = &lockedUntil
+			if err := tx.Model(&model.Job{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+				"status":       job.Status,
+				"attempts":     job.Attempts,
+				"locked_by":    job.LockedBy,
+				"locked_until": job.LockedUntil,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// jobOptions 返回任务类型的执行选项，任务类型未注册时返回零值。
+func jobOptions(kind string) JobOptions {
+	_jobHandlersMu.RLock()
+	defer _jobHandlersMu.RUnlock()
+	if h, ok := _jobHandlers[kind]; ok {
+		return h.opts
+	}
+	return JobOptions{}
+}
+
+// execute 执行一个已取出的任务并保存结果。
+func (r *JobRunner) execute(job *model.Job) {
+	_jobHandlersMu.RLock()
+	h := _jobHandlers[job.Kind]
+	_jobHandlersMu.RUnlock()
+
+	start := time.Now()
+	var err error
+	if h == nil {
+		// 可能是新版本才有的任务类型，放回队列留给注册了该类型的执行者，见下面的 errJobKindUnregistered
+		err = fmt.Errorf("%w %s", errJobKindUnregistered, job.Kind)
+	} else {
+		ctx, cancel := context.WithTimeout(r.jobCtx, jobTimeout(h.opts))
+		err = runJob(ctx, h, job)
+		cancel()
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{"locked_by": "", "locked_until": nil}
+	var permanent *permanentJobError
+	switch {
+	case err == nil:
+		updates["status"] = model.JobSucceeded
+		updates["finished_at"] = now
+		updates["last_error"] = ""
+	case r.jobCtx.Err() != nil:
+		// 程序退出时被取消，不计入尝试次数
+		updates["status"] = model.JobQueued
+		updates["attempts"] = job.Attempts - 1
+		updates["run_at"] = now
+	case errors.Is(err, errJobKindUnregistered):
+		// 不计入尝试次数，推迟一段时间后放回队列，避免本进程反复取出同一个任务
+		updates["status"] = model.JobQueued
+		updates["attempts"] = job.Attempts - 1
+		updates["run_at"] = now.Add(jobRetryDelay(1))
+		updates["last_error"] = err.Error()
+	case errors.As(err, &permanent) || job.Attempts >= job.MaxAttempts:
+		updates["status"] = model.JobDead
+		updates["finished_at"] = now
+		updates["last_error"] = truncateRunes(err.Error(), 1000)
+	default:
+		updates["status"] = model.JobQueued
+		updates["run_at"] = now.Add(jobRetryDelay(job.Attempts))
+		updates["last_error"] = truncateRunes(err.Error(), 1000)
+	}
+	if err != nil {
+		logger.L.Warn("Job failed", zap.Uint("job_id", job.ID), zap.String("kind", job.Kind),
+			zap.Int("attempts", job.Attempts), zap.String("status", JobStatusNames[updates["status"].(int)]), zap.Error(err))
+	} else {
+		logger.L.Debug("Job succeeded", zap.Uint("job_id", job.ID), zap.String("kind", job.Kind), zap.Duration("duration", now.Sub(start)))
+	}
+
+	// 只有仍持有租约时才保存结果，租约到期后任务可能已被其他执行者取走
+	result := dao.GetDB().Model(&model.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ?", job.ID, model.JobRunning, r.id).Updates(updates)
+	if result.Error != nil {
+		logger.L.Error("Failed to update job", zap.Uint("job_id", job.ID), zap.Error(result.Error))
+	} else if result.RowsAffected == 0 {
+		logger.L.Warn("Job lease lost before completion", zap.Uint("job_id", job.ID), zap.String("kind", job.Kind))
+	}
+}
+
+// runJob 调用处理函数，并将其中的 panic 转换为错误。
+func runJob(ctx context.Context, h *jobHandler, job *model.Job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			logger.L.Error("Job panicked", zap.Uint("job_id", job.ID), zap.String("kind", job.Kind),
+				zap.String("panic", fmt.Sprint(rec)), zap.Stack("stack"))
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	return h.run(ctx, []byte(job.Payload))
+}
+
+// maintain 定期将租约到期的任务重新排队，并删除过期的已完成任务。
+func (r *JobRunner) maintain() {
+	defer r.pollers.Done()
+	interval := config.Conf.Jobs.PollInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.recoverExpired()
+		r.cleanup()
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// recoverExpired 处理执行者崩溃后留下的任务：租约到期的任务重新排队，尝试次数已用完的标记为执行失败。
+func (r *JobRunner) recoverExpired() {
+	db := dao.GetDB()
+	now := time.Now()
+	result := db.Model(&model.Job{}).Where("status = ? AND locked_until < ? AND attempts >= max_attempts", model.JobRunning, now).Updates(map[string]interface{}{
+		"status":       model.JobDead,
+		"finished_at":  now,
+		"locked_by":    "",
+		"locked_until": nil,
+		"last_error":   "执行超时或执行者已退出",
+	})
+	if result.Error != nil {
+		logger.L.Error("Failed to recover expired jobs", zap.Error(result.Error))
+		return
+	}
+	dead := result.RowsAffected
+	result = db.Model(&model.Job{}).Where("status = ? AND locked_until < ?", model.JobRunning, now).Updates(map[string]interface{}{
+		"status":       model.JobQueued,
+		"run_at":       now,
+		"locked_by":    "",
+		"locked_until": nil,
+		"last_error":   "执行超时或执行者已退出",
+	})
+	if result.Error != nil {
+		logger.L.Error("Failed to recover expired jobs", zap.Error(result.Error))
+		return
+	}
+	if dead > 0 || result.RowsAffected > 0 {
+		logger.L.Warn("Recovered expired jobs", zap.Int64("requeued", result.RowsAffected), zap.Int64("dead", dead))
+		wakeJobRunner()
+	}
+}
+
+// cleanup 删除超过保留时间的执行成功的任务，每小时最多执行一次。执行失败的任务保留到手动处理。
+func (r *JobRunner) cleanup() {
+	retention := config.Conf.Jobs.Retention
+	if retention <= 0 || time.Since(r.lastCleanup) < jobCleanupInterval {
+		return
+	}
+	r.lastCleanup = time.Now()
+	result := dao.GetDB().Where("status = ? AND finished_at < ?", model.JobSucceeded, time.Now().Add(-retention)).
+		Delete(&model.Job{})
+	if result.Error != nil {
+		logger.L.Error("Failed to clean up jobs", zap.Error(result.Error))
+		return
+	}
+	if result.RowsAffected > 0 {
+		logger.L.Info("Cleaned up jobs", zap.Int64("count", result.RowsAffected))
+	}
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/model"
+)
+
+// JobStatusNames 是任务状态在接口中的名称。
+var JobStatusNames = map[int]string{
+	model.JobQueued:    "queued",
+	model.JobRunning:   "running",
+	model.JobSucceeded: "succeeded",
+	model.JobDead:      "dead",
+}
+
+// ParseJobStatus 将接口中的任务状态名称转换为数据库中的取值。
+func ParseJobStatus(name string) (int, bool) {
+	return parseStatusName(JobStatusNames, name)
+}
+
+// JobService 结构体封装了后台任务的查询和管理功能。
+type JobService struct{}
+
+// NewJobService 是 JobService 的工厂函数。
+func NewJobService() *JobService {
+	return &JobService{}
+}
+
+// ListJobsDTO 定义了任务列表的查询条件。
+type ListJobsDTO struct {
+	Page     int
+	PageSize int
+	Queue    string // 按队列过滤，为空时返回全部
+	Kind     string // 按任务类型过滤，为空时返回全部
+	Status   *int   // 按状态过滤，为空时返回全部
+}
+
+// JobDTO 是一个后台任务。列表中不包含任务参数，查看单个任务时才返回。
+type JobDTO struct {
+	ID          uint            `json:"id"`
+	Queue       string          `json:"queue"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload,omitempty"`
+	Status      string          `json:"status"`
+	RunAt       time.Time       `json:"run_at"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LockedBy    string          `json:"locked_by,omitempty"`
+	LockedUntil *time.Time      `json:"locked_until,omitempty"`
+	LastError   string          `json:"last_error,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+func toJobDTO(job *model.Job, withPayload bool) JobDTO {
+	result := JobDTO{
+		ID:          job.ID,
+		Queue:       job.Queue,
+		Kind:        job.Kind,
+		Status:      JobStatusNames[job.Status],
+		RunAt:       job.RunAt,
+		Attempts:    job.Attempts,
+		MaxAttempts: job.MaxAttempts,
+		LockedBy:    job.LockedBy,
+		LockedUntil: job.LockedUntil,
+		LastError:   job.LastError,
+		FinishedAt:  job.FinishedAt,
+		CreatedAt:   job.CreatedAt,
+	}
+	if withPayload {
+		result.Payload = json.RawMessage(job.Payload)
+	}
+	return result
+}
+
+// ListJobsResponseDTO 封装了任务列表和总数。
+type ListJobsResponseDTO struct {
+	Jobs       []JobDTO `json:"jobs"`
+	TotalCount int64    `json:"total_count"`
+}
+
+// List 分页查询任务，最近创建的排在前面。
+func (s *JobService) List(dto *ListJobsDTO) (*ListJobsResponseDTO, error) {
+	query := dao.GetDB().Model(&model.Job{})
+	if dto.Queue != "" {
+		query = query.Where("queue = ?", dto.Queue)
+	}
+	if dto.Kind != "" {
+		query = query.Where("kind = ?", dto.Kind)
+	}
+	if dto.Status != nil {
+		query = query.Where("status = ?", *dto.Status)
+	}
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+	var jobs []model.Job
+	offset := (dto.Page - 1) * dto.PageSize
+	if err := query.Omit("payload").Order("id DESC").Limit(dto.PageSize).Offset(offset).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]JobDTO, len(jobs))
+	for i := range jobs {
+		result[i] = toJobDTO(&jobs[i], false)
+	}
+	return &ListJobsResponseDTO{Jobs: result, TotalCount: totalCount}, nil
+}
+
+// Get 获取一个任务，包含任务参数。
+func (s *JobService) Get(id uint) (*JobDTO, error) {
+	var job model.Job
+	if err := dao.GetDB().Limit(1).Find(&job, id).Error; err != nil {
+		return nil, err
+	}
+	if job.ID == 0 {
+		return nil, ErrJobNotFound
+	}
+	result := toJobDTO(&job, true)
+	return &result, nil
+}
+
+// Retry 让等待执行或执行失败的任务立即执行。执行失败的任务会清零尝试次数，重新获得完整的重试机会。
+func (s *JobService) Retry(id uint) (*JobDTO, error) {
+	db := dao.GetDB()
+	var job model.Job
+	if err := db.Limit(1).Find(&job, id).Error; err != nil {
+		return nil, err
+	}
+	if job.ID == 0 {
+		return nil, ErrJobNotFound
+	}
+	if job.Status != model.JobQueued && job.Status != model.JobDead {
+		return nil, ErrJobNotRetryable
+	}
+
+	updates := map[string]interface{}{"status": model.JobQueued, "run_at": time.Now()}
+	if job.Status == model.JobDead {
+		updates["attempts"] = 0
+		updates["finished_at"] = nil
+	}
+	// 条件更新，避免覆盖同时被执行者取走的任务
+	result := db.Model(&model.Job{}).Where("id = ? AND status = ?", job.ID, job.Status).Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrJobNotRetryable
+	}
+	wakeJobRunner()
+	return s.Get(id)
+}
+
+// JobQueueStatsDTO 是一个队列中各状态的任务数。
+type JobQueueStatsDTO struct {
+	Concurrency int   `json:"concurrency"` // 本进程中该队列的最大并发数
+	Queued      int64 `json:"queued"`      // 等待执行的任务数，包括定时执行和等待重试的任务
+	Due         int64 `json:"due"`         // 已到执行时间、尚未被取走的任务数
+	Running     int64 `json:"running"`     // 正在执行的任务数
+	Succeeded   int64 `json:"succeeded"`   // 保留期内执行成功的任务数
+	Dead        int64 `json:"dead"`        // 执行失败、需要处理的任务数
+}
+
+// JobStatsDTO 是按队列统计的任务数。
+type JobStatsDTO struct {
+	Queues map[string]*JobQueueStatsDTO `json:"queues"`
+}
+
+// Stats 返回每个队列中各状态的任务数。
+func (s *JobService) Stats() (*JobStatsDTO, error) {
+	db := dao.GetDB()
+	stats := &JobStatsDTO{Queues: make(map[string]*JobQueueStatsDTO)}
+	queue := func(name string) *JobQueueStatsDTO {
+		q, ok := stats.Queues[name]
+		if !ok {
+			q = &JobQueueStatsDTO{Concurrency: jobQueueConcurrency(name)}
+			stats.Queues[name] = q
+		}
+		return q
+	}
+	for _, name := range jobQueues() {
+		queue(name)
+	}
+
+	var rows []struct {
+		Queue  string
+		Status int
+		Count  int64
+	}
+	if err := db.Model(&model.Job{}).Select("queue, status, COUNT(*) AS count").
+		Group("queue, status").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		q := queue(row.Queue)
+		switch row.Status {
+		case model.JobQueued:
+			q.Queued = row.Count
+		case model.JobRunning:
+			q.Running = row.Count
+		case model.JobSucceeded:
+			q.Succeeded = row.Count
+		case model.JobDead:
+			q.Dead = row.Count
+		}
+	}
+
+	var due []struct {
+		Queue string
+		Count int64
+	}
+	if err := db.Model(&model.Job{}).Select("queue, COUNT(*) AS count").
+		Where("status = ? AND run_at <= ?", model.JobQueued, time.Now()).Group("queue").Scan(&due).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range due {
+		queue(row.Queue).Due = row.Count
+	}
+	return stats, nil
+}
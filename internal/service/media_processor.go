@@ -0,0 +1,409 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/imaging"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/storage"
+	"go.uber.org/zap"
+	_ "golang.org/x/image/webp" // 注册 WebP 解码器
+	"gorm.io/gorm"
+)
+
+// processableImageTypes 是后台会生成缩略图和占位信息的图片类型。
+var processableImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+	"image/webp": true,
+}
+
+// mediaQueueSize 是等待处理的媒体队列长度。
+// 队列满时新任务不会被丢失：记录仍处于待处理状态，下次启动时会重新入队。
+const mediaQueueSize = 1024
+
+// MediaProcessor 在后台为上传的图片生成各个尺寸的缩略图、WebP 版本以及 BlurHash 占位信息，
+// 避免这些耗时的操作拖慢上传请求。
+type MediaProcessor struct {
+	storage storage.Storage
+	queue   chan uint
+	stop    chan struct{}
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	stopped bool
+}
+
+// _mediaProcessor 是全局的后台图片处理器，由 StartMediaProcessor 创建。
+var _mediaProcessor *MediaProcessor
+
+// StartMediaProcessor 启动后台图片处理器，并把上次未处理完的媒体重新加入队列。
+// 它必须在数据库和存储初始化之后调用，程序退出前应调用 Shutdown。
+func StartMediaProcessor() *MediaProcessor {
+	workers := config.Conf.Media.Processing.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	p := &MediaProcessor{
+		storage: storage.GetStorage(),
+		queue:   make(chan uint, mediaQueueSize),
+		stop:    make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	_mediaProcessor = p
+
+	var pending []uint
+	err := dao.GetDB().Model(&model.Media{}).
+		Where("processing_status = ?", model.MediaProcessingPending).
+		Order("id ASC").
+		Pluck("id", &pending).Error
+	if err != nil {
+		logger.L.Error("Failed to load pending media", zap.Error(err))
+	}
+	for _, id := range pending {
+		p.Enqueue(id)
+	}
+	return p
+}
+
+// Enqueue 将一个媒体加入处理队列，返回是否成功入队。
+func (p *MediaProcessor) Enqueue(id uint) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stopped {
+		return false
+	}
+	select {
+	case p.queue <- id:
+		return true
+	default:
+		logger.L.Warn("Media processing queue is full", zap.Uint("media_id", id))
+		return false
+	}
+}
+
+// Shutdown 停止接收新任务，并等待正在处理的图片完成。
+// 队列中尚未开始处理的媒体保持待处理状态，下次启动时会重新处理。
+func (p *MediaProcessor) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.stop)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work 是处理队列的工作协程。
+func (p *MediaProcessor) work() {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case id := <-p.queue:
+			if err := p.process(id); err != nil {
+				logger.L.Error("Failed to process media", zap.Uint("media_id", id), zap.Error(err))
+				dao.GetDB().Model(&model.Media{}).Where("id = ?", id).
+					Update("processing_status", model.MediaProcessingFailed)
+			}
+		}
+	}
+}
+
+// process 为单个媒体生成所有尺寸和占位信息，并替换之前的处理结果。
+func (p *MediaProcessor) process(id uint) error {
+	db := dao.GetDB()
+	var media model.Media
+	if err := db.First(&media, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// 媒体在排队期间被删除了
+			return nil
+		}
+		return err
+	}
+	if !processableImageTypes[media.MimeType] {
+		return db.Model(&media).Update("processing_status", model.MediaProcessingDone).Error
+	}
+
+	obj, err := p.storage.Get(media.StorageKey)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(obj.Body)
+	_ = obj.Body.Close()
+	if err != nil {
+		return err
+	}
+	// 上传时已经检查过尺寸，这里再次检查，以免上限调低后处理之前上传的图片时耗尽内存
+	if err := checkImagePixels(data); err != nil {
+		return fmt.Errorf("check image size: %w", err)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("decode image: %w", err)
+	}
+
+	variants, err := p.generateVariants(&media, img)
+	if err != nil {
+		return err
+	}
+	blurHash := imaging.BlurHash(img, 4, 3)
+	dominantColor := imaging.DominantColor(img)
+
+	// 用新的处理结果替换旧的记录
+	var oldKeys []string
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.MediaVariant{}).Where("media_id = ?", media.ID).Pluck("storage_key", &oldKeys).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("media_id = ?", media.ID).Delete(&model.MediaVariant{}).Error; err != nil {
+			return err
+		}
+		if len(variants) > 0 {
+			if err := tx.Create(&variants).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&media).Updates(map[string]interface{}{
+			"processing_status": model.MediaProcessingDone,
+			"blur_hash":         blurHash,
+			"dominant_color":    dominantColor,
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// 重新处理后不再使用的旧文件可以删除
+	return deleteUnreferencedFiles(p.storage, oldKeys)
+}
+
+// generateVariants 按配置生成各个尺寸的图片并保存到存储中。
+// JPEG 和 WebP 原图输出 JPEG，PNG 和 GIF 原图输出 PNG 以保留透明度；
+// 启用 WebP 时，只有比对应的 JPEG/PNG 更小的 WebP 版本才会被保存。
+func (p *MediaProcessor) generateVariants(media *model.Media, img image.Image) ([]model.MediaVariant, error) {
+	cfg := config.Conf.Media.Processing
+	b := img.Bounds()
+
+	var variants []model.MediaVariant
+	for _, v := range cfg.Variants {
+		var out image.Image
+		if v.Crop {
+			if v.Width <= 0 || v.Height <= 0 {
+				continue
+			}
+			out = imaging.Fill(img, v.Width, v.Height)
+		} else {
+			// 不放大图片：原图已经不超过该尺寸时直接使用原图
+			if (v.Width <= 0 || b.Dx() <= v.Width) && (v.Height <= 0 || b.Dy() <= v.Height) {
+				continue
+			}
+			out = imaging.Fit(img, v.Width, v.Height)
+		}
+
+		data, mimeType, err := encodeVariant(media.MimeType, out, cfg.JPEGQuality)
+		if err != nil {
+			return nil, err
+		}
+		variant, err := p.putVariant(media.ID, v.Name, mimeType, out, data)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, *variant)
+
+		if cfg.WebP {
+			var buf bytes.Buffer
+			if err := imaging.EncodeWebP(&buf, out); err != nil {
+				// 宽或高超过 WebP 的上限时只保留原格式的版本
+				if errors.Is(err, imaging.ErrWebPDimensions) {
+					continue
+				}
+				return nil, err
+			}
+			if buf.Len() >= len(data) {
+				continue
+			}
+			webpVariant, err := p.putVariant(media.ID, v.Name, "image/webp", out, buf.Bytes())
+			if err != nil {
+				return nil, err
+			}
+			variants = append(variants, *webpVariant)
+		}
+	}
+	return variants, nil
+}
+
+// encodeVariant 根据原图的类型选择输出格式并编码图片。
+func encodeVariant(sourceType string, img image.Image, quality int) ([]byte, string, error) {
+	var buf bytes.Buffer
+	switch sourceType {
+	case "image/png", "image/gif":
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	default:
+		if err := jpeg.Encode(&buf, imaging.Flatten(img), &jpeg.Options{Quality: jpegQuality(quality)}); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/jpeg", nil
+	}
+}
+
+// jpegQuality 返回有效的 JPEG 质量，未配置时使用 82。
+func jpegQuality(quality int) int {
+	if quality <= 0 || quality > 100 {
+		return 82
+	}
+	return quality
+}
+
+// variantExtensions 是各输出格式对应的文件扩展名。
+var variantExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// putVariant 以内容寻址的方式保存一个尺寸的图片，并返回对应的记录（尚未写入数据库）。
+func (p *MediaProcessor) putVariant(mediaID uint, name, mimeType string, img image.Image, data []byte) (*model.MediaVariant, error) {
+	sum := sha256.Sum256(data)
+	key := storage.ContentKey(hex.EncodeToString(sum[:]), variantExtensions[mimeType])
+	exists, err := p.storage.Exists(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := p.storage.Put(key, bytes.NewReader(data), int64(len(data)), mimeType); err != nil {
+			return nil, err
+		}
+	}
+	return &model.MediaVariant{
+		MediaID:    mediaID,
+		Name:       name,
+		MimeType:   mimeType,
+		StorageKey: key,
+		Width:      img.Bounds().Dx(),
+		Height:     img.Bounds().Dy(),
+		Size:       int64(len(data)),
+	}, nil
+}
+
+// enqueueMediaProcessing 将媒体加入全局的后台处理队列。
+// 处理器未启动（例如在命令行工具中）时返回 false，记录会保持待处理状态。
+func enqueueMediaProcessing(id uint) bool {
+	if _mediaProcessor == nil {
+		return false
+	}
+	return _mediaProcessor.Enqueue(id)
+}
+
+// deleteUnreferencedFiles 删除不再被任何媒体记录或尺寸记录引用的存储文件。
+func deleteUnreferencedFiles(store storage.Storage, keys []string) error {
+	db := dao.GetDB()
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		var count int64
+		if err := db.Model(&model.Media{}).Where("storage_key = ?", key).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		if err := db.Model(&model.MediaVariant{}).Where("storage_key = ?", key).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		if err := store.Delete(key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillMediaURLs 填充媒体及其各个尺寸的公开访问地址，并生成 srcset。
+func fillMediaURLs(media *model.Media) {
+	media.URL = MediaURL(media.StorageKey)
+	for i := range media.Variants {
+		media.Variants[i].URL = MediaURL(media.Variants[i].StorageKey)
+	}
+	media.SrcSet = buildSrcSet(media)
+}
+
+// buildSrcSet 按 MIME 类型生成响应式图片的 srcset 字符串，例如
+// {"image/jpeg": "/uploads/ab/..jpg 768w, /uploads/cd/..jpg 1600w"}。
+// 裁剪过的尺寸（例如正方形缩略图）宽高比与原图不同，不会出现在 srcset 中。
+func buildSrcSet(media *model.Media) map[string]string {
+	if media.Width == 0 {
+		return nil
+	}
+	cropped := make(map[string]bool)
+	for _, v := range config.Conf.Media.Processing.Variants {
+		if v.Crop {
+			cropped[v.Name] = true
+		}
+	}
+
+	type candidate struct {
+		url   string
+		width int
+	}
+	groups := map[string][]candidate{
+		media.MimeType: {{url: media.URL, width: media.Width}},
+	}
+	for _, v := range media.Variants {
+		if cropped[v.Name] {
+			continue
+		}
+		groups[v.MimeType] = append(groups[v.MimeType], candidate{url: v.URL, width: v.Width})
+	}
+
+	srcSet := make(map[string]string, len(groups))
+	for mimeType, candidates := range groups {
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].width < candidates[j].width })
+		parts := make([]string, len(candidates))
+		for i, c := range candidates {
+			parts[i] = fmt.Sprintf("%s %dw", c.url, c.width)
+		}
+		srcSet[mimeType] = strings.Join(parts, ", ")
+	}
+	return srcSet
+}
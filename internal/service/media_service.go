@@ -0,0 +1,550 @@
+package service
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // 注册 GIF 解码器，用于读取图片尺寸
+	"image/jpeg"
+	_ "image/png" // 注册 PNG 解码器
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/imaging"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/storage"
+	"github.com/gabriel-vasile/mimetype"
+	"gorm.io/gorm"
+)
+
+// MediaService 结构体封装了所有与媒体库相关的业务逻辑。
+type MediaService struct {
+	storage storage.Storage
+}
+
+// NewMediaService 是 MediaService 的工厂函数。
+// 它使用在程序启动时由 storage.Init 创建的全局存储实例。
+func NewMediaService() *MediaService {
+	return &MediaService{
+		storage: storage.GetStorage(),
+	}
+}
+
+// Storage 返回媒体文件所使用的存储。
+func (s *MediaService) Storage() storage.Storage {
+	return s.storage
+}
+
+// MaxUploadSize 返回配置中允许的单个文件大小上限 (字节)。
+func MaxUploadSize() int64 {
+	maxSizeMB := config.Conf.Media.MaxSizeMB
+	if maxSizeMB <= 0 {
+		maxSizeMB = 10
+	}
+	return int64(maxSizeMB) << 20
+}
+
+// MaxImagePixels 返回配置中允许的图片像素数 (宽 x 高) 上限。
+func MaxImagePixels() int64 {
+	if n := config.Conf.Media.MaxPixels; n > 0 {
+		return n
+	}
+	return 40000000
+}
+
+// checkImagePixels 只读取图片头部声明的尺寸，拒绝像素数超过上限的图片。
+// 解码器按声明的尺寸分配内存，几 KB 的文件也可能声明 60000x60000 的尺寸，因此任何解码之前都要先调用它。
+func checkImagePixels(data []byte) error {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ErrMediaCorrupted
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return ErrMediaCorrupted
+	}
+	if limit := MaxImagePixels(); int64(cfg.Width)*int64(cfg.Height) > limit {
+		return ErrMediaPixelLimit.WithArgs(limit)
+	}
+	return nil
+}
+
+// MediaURL 根据存储键生成媒体文件的公开访问地址。
+// 配置了 storage.public_base_url（例如 CDN 地址）时直接指向该地址，否则通过本服务的 media.url_prefix 访问。
+func MediaURL(key string) string {
+	if base := strings.TrimRight(config.Conf.Storage.PublicBaseURL, "/"); base != "" {
+		return base + "/" + key
+	}
+	prefix := strings.TrimRight(config.Conf.Media.URLPrefix, "/")
+	if prefix == "" {
+		prefix = "/uploads"
+	}
+	return prefix + "/" + key
+}
+
+// UploadMediaDTO 封装了上传媒体文件时需要的数据。
+type UploadMediaDTO struct {
+	UserID   uint
+	FileName string
+	AltText  string
+	Reader   io.Reader // 文件内容
+}
+
+// Upload 用于保存一个上传的文件并创建对应的媒体记录。
+// 文件类型通过内容嗅探确定，而不是信任客户端提供的文件名或 Content-Type。
+// 图片中的 EXIF/GPS 等元数据会在保存前移除，缩略图等耗时的处理则交给后台的 MediaProcessor。
+func (s *MediaService) Upload(dto *UploadMediaDTO) (*model.Media, error) {
+	// 1. 读取文件内容并限制大小
+	maxSize := MaxUploadSize()
+	data, err := io.ReadAll(io.LimitReader(dto.Reader, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, ErrMediaEmpty
+	}
+	if int64(len(data)) > maxSize {
+		return nil, ErrMediaSizeLimit.WithArgs(maxSize >> 20)
+	}
+
+	// 2. 嗅探 MIME 类型并校验是否允许上传
+	mime := mimetype.Detect(data)
+	if !isAllowedMimeType(mime.String()) {
+		return nil, ErrMediaTypeNotAllowed.WithArgs(mime.String())
+	}
+
+	// 3. 检查图片尺寸，再移除图片元数据，避免拍摄地点等隐私信息被公开
+	if processableImageTypes[mime.String()] {
+		if err := checkImagePixels(data); err != nil {
+			return nil, err
+		}
+	}
+	data, err = stripImageMetadata(mime.String(), data)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	checksum := hex.EncodeToString(sum[:])
+
+	// 4. 读取图片尺寸，无法识别的格式尺寸记为 0
+	var width, height int
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+		width, height = cfg.Width, cfg.Height
+	}
+
+	// 5. 以内容寻址的方式保存文件，相同内容的文件只保存一次
+	key := storage.ContentKey(checksum, mime.Extension())
+	exists, err := s.storage.Exists(key)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if err := s.storage.Put(key, bytes.NewReader(data), int64(len(data)), mime.String()); err != nil {
+			return nil, err
+		}
+	}
+
+	// 6. 创建媒体记录，图片交给后台生成缩略图
+	status := model.MediaProcessingDone
+	if processableImageTypes[mime.String()] {
+		status = model.MediaProcessingPending
+	}
+	media := &model.Media{
+		UserID:           dto.UserID,
+		FileName:         dto.FileName,
+		StorageKey:       key,
+		MimeType:         mime.String(),
+		Size:             int64(len(data)),
+		Width:            width,
+		Height:           height,
+		Checksum:         checksum,
+		AltText:          strings.TrimSpace(dto.AltText),
+		ProcessingStatus: status,
+	}
+	db := dao.GetDB()
+	if err := db.Create(media).Error; err != nil {
+		return nil, err
+	}
+	if status == model.MediaProcessingPending {
+		enqueueMediaProcessing(media.ID)
+	}
+	fillMediaURLs(media)
+	return media, nil
+}
+
+// stripImageMetadata 移除图片中的元数据。
+// JPEG 的方向信息保存在 EXIF 中，移除前需要先按方向旋转像素并重新编码，否则图片会显示颠倒。
+func stripImageMetadata(mimeType string, data []byte) ([]byte, error) {
+	stripped, orientation, err := imaging.StripMetadata(mimeType, data)
+	if err != nil {
+		if errors.Is(err, imaging.ErrUnsupportedFormat) {
+			return nil, ErrMediaCorrupted
+		}
+		return nil, err
+	}
+	if orientation <= 1 {
+		return stripped, nil
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(stripped))
+	if err != nil {
+		return nil, ErrMediaCorrupted
+	}
+	var buf bytes.Buffer
+	quality := jpegQuality(config.Conf.Media.Processing.JPEGQuality)
+	if err := jpeg.Encode(&buf, imaging.Orient(img, orientation), &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// incomingPrefix 是客户端直传文件的临时存储键前缀。
+// 直传完成后文件会被转存到内容寻址的存储键下，临时文件随即删除。
+const incomingPrefix = "incoming/"
+
+// incomingContentType 是直传临时文件的类型。它参与上传地址的签名，客户端不能自行指定，
+// 因此临时文件即使被直接访问也不会被浏览器当作网页；登记时会重新嗅探文件的真实类型。
+const incomingContentType = "application/octet-stream"
+
+// PresignedUploadDTO 描述了一个客户端直传的上传地址。
+type PresignedUploadDTO struct {
+	Key         string    `json:"key"`          // 临时存储键，完成上传后需要回传给服务端
+	UploadURL   string    `json:"upload_url"`   // 预签名的上传地址
+	Method      string    `json:"method"`       // 上传时使用的 HTTP 方法
+	ContentType string    `json:"content_type"` // 上传时必须使用的 Content-Type 请求头
+	ExpiresAt   time.Time `json:"expires_at"`   // 上传地址的过期时间
+}
+
+// PresignUpload 为客户端直传生成一个预签名的上传地址。
+// 只有支持预签名的存储后端（例如 S3）可以使用该功能。
+func (s *MediaService) PresignUpload(userID uint, fileName string) (*PresignedUploadDTO, error) {
+	presigner, ok := s.storage.(storage.Presigner)
+	if !ok {
+		return nil, ErrPresignNotSupported
+	}
+
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s%d/%s%s", incomingPrefix, userID, hex.EncodeToString(random), strings.ToLower(path.Ext(fileName)))
+
+	expiry := config.Conf.Storage.PresignExpiry
+	if expiry <= 0 {
+		expiry = 15 * time.Minute
+	}
+	uploadURL, err := presigner.PresignPut(key, incomingContentType, expiry)
+	if err != nil {
+		return nil, err
+	}
+	return &PresignedUploadDTO{
+		Key:         key,
+		UploadURL:   uploadURL,
+		Method:      "PUT",
+		ContentType: incomingContentType,
+		ExpiresAt:   time.Now().Add(expiry),
+	}, nil
+}
+
+// CompleteUploadDTO 封装了完成直传时需要的数据。
+type CompleteUploadDTO struct {
+	UserID   uint
+	Key      string // PresignUpload 返回的临时存储键
+	FileName string
+	AltText  string
+}
+
+// CompleteUpload 用于在客户端直传完成后登记媒体文件。
+// 它会读取临时文件，按照普通上传的流程进行类型嗅探、校验和内容寻址存储，最后删除临时文件。
+func (s *MediaService) CompleteUpload(dto *CompleteUploadDTO) (*model.Media, error) {
+	// 只允许用户登记自己的临时文件
+	if !strings.HasPrefix(dto.Key, fmt.Sprintf("%s%d/", incomingPrefix, dto.UserID)) || strings.Contains(dto.Key, "..") {
+		return nil, ErrInvalidUploadKey
+	}
+
+	obj, err := s.storage.Get(dto.Key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, ErrUploadNotFound
+		}
+		return nil, err
+	}
+	defer obj.Body.Close()
+	// 无论登记是否成功，临时文件都不再需要
+	defer func() {
+		_ = s.storage.Delete(dto.Key)
+	}()
+
+	return s.Upload(&UploadMediaDTO{
+		UserID:   dto.UserID,
+		FileName: dto.FileName,
+		AltText:  dto.AltText,
+		Reader:   obj.Body,
+	})
+}
+
+// isAllowedMimeType 判断给定的 MIME 类型是否在配置允许的列表中。
+func isAllowedMimeType(mime string) bool {
+	for _, allowed := range config.Conf.Media.AllowedTypes {
+		if strings.EqualFold(allowed, mime) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListMediaDTO 封装了查询媒体列表时的参数。
+type ListMediaDTO struct {
+	Page     int    // 页码
+	PageSize int    // 每页数量
+	MimeType string // 按 MIME 类型前缀过滤，例如 "image/"
+}
+
+// ListMediaResponseDTO 封装了媒体列表和总数。
+type ListMediaResponseDTO struct {
+	Media      []model.Media `json:"media"`
+	TotalCount int64         `json:"total_count"`
+}
+
+// List 用于获取媒体文件的分页列表。
+func (s *MediaService) List(dto *ListMediaDTO) (*ListMediaResponseDTO, error) {
+	db := dao.GetDB()
+	query := db.Model(&model.Media{})
+	if dto.MimeType != "" {
+		query = query.Where("mime_type LIKE ?", dto.MimeType+"%")
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	var media []model.Media
+	offset := (dto.Page - 1) * dto.PageSize
+	if err := query.Preload("Variants").Order("created_at DESC").Limit(dto.PageSize).Offset(offset).Find(&media).Error; err != nil {
+		return nil, err
+	}
+	for i := range media {
+		fillMediaURLs(&media[i])
+	}
+
+	return &ListMediaResponseDTO{
+		Media:      media,
+		TotalCount: totalCount,
+	}, nil
+}
+
+// GetByID 用于根据 ID 获取单个媒体文件的信息。
+func (s *MediaService) GetByID(id uint) (*model.Media, error) {
+	db := dao.GetDB()
+	var media model.Media
+	if err := db.Preload("Variants").First(&media, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMediaNotFound
+		}
+		return nil, err
+	}
+	fillMediaURLs(&media)
+	return &media, nil
+}
+
+// GetByStorageKey 用于根据存储键获取媒体文件的信息。
+func (s *MediaService) GetByStorageKey(key string) (*model.Media, error) {
+	db := dao.GetDB()
+	var media model.Media
+	if err := db.Preload("Variants").Where("storage_key = ?", key).First(&media).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMediaNotFound
+		}
+		return nil, err
+	}
+	fillMediaURLs(&media)
+	return &media, nil
+}
+
+// getManaged 获取 actor 可以管理的媒体文件，文件属于其他用户且 actor 不是管理员时返回 ErrMediaForbidden。
+func (s *MediaService) getManaged(id uint, actor Actor) (*model.Media, error) {
+	media, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.CanManage(media.UserID) {
+		return nil, ErrMediaForbidden
+	}
+	return media, nil
+}
+
+// UpdateAltText 用于更新媒体文件的替代文本，只有上传者和管理员可以修改。
+func (s *MediaService) UpdateAltText(id uint, actor Actor, altText string) (*model.Media, error) {
+	media, err := s.getManaged(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	db := dao.GetDB()
+	if err := db.Model(media).Update("alt_text", strings.TrimSpace(altText)).Error; err != nil {
+		return nil, err
+	}
+	return media, nil
+}
+
+// MediaReferenceDTO 描述了一篇引用了某个媒体文件的文章。
+type MediaReferenceDTO struct {
+	ID    uint   `json:"id"`
+	Title string `json:"title"`
+}
+
+// MediaInUseError 表示要删除的媒体文件仍被文章内容引用。
+type MediaInUseError struct {
+	Posts []MediaReferenceDTO
+}
+
+// Error 实现了 error 接口。
+func (e *MediaInUseError) Error() string {
+	return e.Unwrap().Error()
+}
+
+// Unwrap 使该错误在错误目录中归类为 ErrMediaInUse。
+func (e *MediaInUseError) Unwrap() error {
+	return ErrMediaInUse.WithArgs(len(e.Posts))
+}
+
+// FindReferences 返回内容中引用了指定媒体文件（原图或任一尺寸），或将其用作分享图片的文章。
+func (s *MediaService) FindReferences(media *model.Media) ([]MediaReferenceDTO, error) {
+	db := dao.GetDB()
+	// 存储键只包含十六进制字符、斜杠和扩展名，不会包含 LIKE 通配符
+	query := db.Where("og_image_id = ?", media.ID).Or("content LIKE ?", "%"+media.StorageKey+"%")
+	for _, v := range media.Variants {
+		query = query.Or("content LIKE ?", "%"+v.StorageKey+"%")
+	}
+
+	refs := []MediaReferenceDTO{}
+	err := db.Model(&model.Post{}).
+		Select("id, title").
+		Where(query).
+		Order("id ASC").
+		Scan(&refs).Error
+	if err != nil {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// Delete 用于删除一个媒体文件。
+// 如果文件仍被文章引用且 force 为 false，会返回 *MediaInUseError。
+// 生成的各个尺寸会一并删除；只有当没有其他记录共享同一个存储文件时，存储中的文件才会被真正删除。
+// 只有上传者和管理员可以删除。
+func (s *MediaService) Delete(id uint, actor Actor, force bool) error {
+	media, err := s.getManaged(id, actor)
+	if err != nil {
+		return err
+	}
+
+	if !force {
+		refs, err := s.FindReferences(media)
+		if err != nil {
+			return err
+		}
+		if len(refs) > 0 {
+			return &MediaInUseError{Posts: refs}
+		}
+	}
+
+	// 删除媒体记录及其生成的所有尺寸，然后清理不再被引用的文件
+	db := dao.GetDB()
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("media_id = ?", media.ID).Delete(&model.MediaVariant{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&model.Media{}, media.ID).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	keys := []string{media.StorageKey}
+	for _, v := range media.Variants {
+		keys = append(keys, v.StorageKey)
+	}
+	return deleteUnreferencedFiles(s.storage, keys)
+}
+
+// Reprocess 用于重新生成图片的缩略图和占位信息，例如在修改了尺寸配置之后。只有上传者和管理员可以操作。
+func (s *MediaService) Reprocess(id uint, actor Actor) (*model.Media, error) {
+	media, err := s.getManaged(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	if !processableImageTypes[media.MimeType] {
+		return nil, ErrMediaNotProcessable
+	}
+	db := dao.GetDB()
+	if err := db.Model(media).Update("processing_status", model.MediaProcessingPending).Error; err != nil {
+		return nil, err
+	}
+	media.ProcessingStatus = model.MediaProcessingPending
+	if !enqueueMediaProcessing(media.ID) {
+		return nil, ErrMediaProcessingBusy
+	}
+	return media, nil
+}
+
+// PublishedMediaKeys 返回已发布文章引用的所有存储键，包括正文中的图片、分享图片，以及这些媒体文件生成的各个尺寸。
+// 主要用于导出静态站点时复制媒体文件。
+func (s *MediaService) PublishedMediaKeys() ([]string, error) {
+	db := dao.GetDB()
+	var posts []model.Post
+	if err := db.Select("id, content, og_image_id").Where("status = ?", model.PostStatusPublished).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var keys []string
+	add := func(key string) {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	var contentKeys []string
+	var ogImageIDs []uint
+	for _, post := range posts {
+		for _, key := range storageKeyRe.FindAllString(post.Content, -1) {
+			add(key)
+			contentKeys = append(contentKeys, key)
+		}
+		if post.OGImageID != nil {
+			ogImageIDs = append(ogImageIDs, *post.OGImageID)
+		}
+	}
+
+	// 补充被引用的媒体文件的原图和所有尺寸，主题可能会使用 srcset 引用其他尺寸
+	if len(contentKeys) == 0 && len(ogImageIDs) == 0 {
+		return keys, nil
+	}
+	query := db.Preload("Variants")
+	if len(contentKeys) > 0 {
+		query = query.Where("storage_key IN ?", contentKeys).
+			Or("id IN (?)", db.Model(&model.MediaVariant{}).Select("media_id").Where("storage_key IN ?", contentKeys))
+	}
+	if len(ogImageIDs) > 0 {
+		query = query.Or("id IN ?", ogImageIDs)
+	}
+	var media []model.Media
+	if err := query.Find(&media).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range media {
+		add(m.StorageKey)
+		for _, v := range m.Variants {
+			add(v.StorageKey)
+		}
+	}
+	return keys, nil
+}
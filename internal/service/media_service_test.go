@@ -0,0 +1,80 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/KeLes-Coding/gopress/internal/apperr"
+	"github.com/KeLes-Coding/gopress/internal/config"
+)
+
+// pngHeader 构造一个只有签名和 IHDR 块的 PNG，它声明了给定的尺寸但不包含像素数据。
+func pngHeader(width, height uint32) []byte {
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:4], width)
+	binary.BigEndian.PutUint32(ihdr[4:8], height)
+	ihdr[8] = 8 // 位深
+	ihdr[9] = 6 // RGBA
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(ihdr)))
+	chunk = append(chunk, "IHDR"...)
+	chunk = append(chunk, ihdr...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	return append([]byte("\x89PNG\r\n\x1a\n"), chunk...)
+}
+
+// gifHeader 构造一个只有逻辑屏幕描述符和图像描述符的 GIF，它声明了给定的尺寸但不包含像素数据。
+func gifHeader(width, height uint16) []byte {
+	data := []byte("GIF89a")
+	data = binary.LittleEndian.AppendUint16(data, width)
+	data = binary.LittleEndian.AppendUint16(data, height)
+	data = append(data, 0, 0, 0) // 没有全局调色板
+	data = append(data, 0x2C)    // 图像描述符
+	data = append(data, 0, 0, 0, 0)
+	data = binary.LittleEndian.AppendUint16(data, width)
+	data = binary.LittleEndian.AppendUint16(data, height)
+	return append(data, 0x80) // 局部调色板，2 种颜色
+}
+
+func TestCheckImagePixels(t *testing.T) {
+	var small bytes.Buffer
+	if err := png.Encode(&small, image.NewNRGBA(image.Rect(0, 0, 20, 10))); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		maxPixels int64
+		data      []byte
+		wantKey   string // 期望的错误的 Key，为空表示不应出错
+	}{
+		{"正常的图片", 0, small.Bytes(), ""},
+		{"声明了 60000x60000 的 PNG", 0, pngHeader(60000, 60000), "media_too_large.pixels"},
+		{"声明了 60000x60000 的 GIF", 0, gifHeader(60000, 60000), "media_too_large.pixels"},
+		{"恰好等于上限", 200, small.Bytes(), ""},
+		{"超过配置的上限", 199, small.Bytes(), "media_too_large.pixels"},
+		{"宽度为 0", 0, pngHeader(0, 10), "media_corrupted"},
+		{"无法识别的格式", 0, []byte("not an image"), "media_corrupted"},
+	}
+	defer func(n int64) { config.Conf.Media.MaxPixels = n }(config.Conf.Media.MaxPixels)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Conf.Media.MaxPixels = tt.maxPixels
+			err := checkImagePixels(tt.data)
+			if tt.wantKey == "" {
+				if err != nil {
+					t.Fatalf("checkImagePixels: %v", err)
+				}
+				return
+			}
+			var appErr *apperr.Error
+			if !errors.As(err, &appErr) || appErr.Key != tt.wantKey {
+				t.Fatalf("err = %v, want %s", err, tt.wantKey)
+			}
+		})
+	}
+}
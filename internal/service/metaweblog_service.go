@@ -0,0 +1,203 @@
+package service
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/model"
+)
+
+// MetaWeblogService 结构体将 MetaWeblog / Blogger API 的操作映射到文章、分类和媒体库的业务逻辑上，
+// 供桌面博客客户端（MarsEdit、Open Live Writer 等）通过 XML-RPC 调用。
+type MetaWeblogService struct {
+	postService     *PostService
+	categoryService *CategoryService
+	mediaService    *MediaService
+}
+
+// NewMetaWeblogService 是 MetaWeblogService 的工厂函数。
+func NewMetaWeblogService() *MetaWeblogService {
+	return &MetaWeblogService{
+		postService:     NewPostService(),
+		categoryService: NewCategoryService(),
+		mediaService:    NewMediaService(),
+	}
+}
+
+// MetaWeblogPostDTO 是客户端提交的文章，对应 MetaWeblog 的 post 结构体。
+// 指针和切片字段为 nil 表示客户端没有提交该字段，编辑文章时保持原值不变。
+type MetaWeblogPostDTO struct {
+	Title         string
+	Description   string   // 正文
+	TextMore      string   // 正文的“更多”部分 (mt_text_more)，追加在正文之后
+	Excerpt       *string  // 摘要 (mt_excerpt)
+	Categories    []string // 分类名称，本站每篇文章只属于一个分类，使用第一个存在的分类
+	Keywords      *string  // 以逗号分隔的标签 (mt_keywords)
+	AllowComments *bool    // 是否允许评论 (mt_allow_comments)
+	Publish       bool     // 是否发布，否则保存为草稿
+}
+
+// content 返回完整的正文。
+func (dto *MetaWeblogPostDTO) content() string {
+	if strings.TrimSpace(dto.TextMore) == "" {
+		return dto.Description
+	}
+	return dto.Description + "\n\n" + dto.TextMore
+}
+
+// status 返回文章状态。
+func (dto *MetaWeblogPostDTO) status() int {
+	if dto.Publish {
+		return model.PostStatusPublished
+	}
+	return model.PostStatusDraft
+}
+
+// tagNames 将以逗号分隔的标签拆分为标签名称列表。
+func (dto *MetaWeblogPostDTO) tagNames() []string {
+	var names []string
+	for _, name := range strings.FieldsFunc(*dto.Keywords, func(r rune) bool { return r == ',' || r == '，' }) {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// resolveCategory 根据分类名称查找分类 ID。
+func (s *MetaWeblogService) resolveCategory(names []string) (uint, error) {
+	db := dao.GetDB()
+	for _, name := range names {
+		var category model.Category
+		if err := db.Where("name = ?", strings.TrimSpace(name)).Limit(1).Find(&category).Error; err != nil {
+			return 0, err
+		}
+		if category.ID != 0 {
+			return category.ID, nil
+		}
+	}
+	if len(names) > 0 {
+		return 0, ErrCategoryNamesNotFound.WithArgs(strings.Join(names, ", "))
+	}
+	return defaultCategoryID(config.Conf.XMLRPC.DefaultCategoryID)
+}
+
+// NewPost 以 userID 的身份创建一篇文章。
+func (s *MetaWeblogService) NewPost(userID uint, dto *MetaWeblogPostDTO) (*model.Post, error) {
+	if strings.TrimSpace(dto.Title) == "" {
+		return nil, ErrPostTitleEmpty
+	}
+	categoryID, err := s.resolveCategory(dto.Categories)
+	if err != nil {
+		return nil, err
+	}
+	create := &CreatePostDTO{
+		Title:           dto.Title,
+		Content:         dto.content(),
+		Status:          dto.status(),
+		UserID:          userID,
+		CategoryID:      categoryID,
+		CommentsEnabled: dto.AllowComments,
+	}
+	if dto.Excerpt != nil {
+		create.Summary = *dto.Excerpt
+	}
+	if dto.Keywords != nil {
+		create.TagNames = dto.tagNames()
+	}
+	return s.postService.Create(create)
+}
+
+// getManaged 获取 actor 可以管理的文章，文章属于其他作者且 actor 不是管理员时返回 ErrPostForbidden。
+func (s *MetaWeblogService) getManaged(id uint, actor Actor) (*model.Post, error) {
+	post, err := s.postService.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.CanManage(post.UserID) {
+		return nil, ErrPostForbidden
+	}
+	return post, nil
+}
+
+// EditPost 更新一篇文章，只有作者和管理员可以修改。
+// 客户端没有提交的字段，以及 MetaWeblog 不支持的 SEO 字段都保持原值。
+func (s *MetaWeblogService) EditPost(id uint, actor Actor, dto *MetaWeblogPostDTO) (*model.Post, error) {
+	post, err := s.getManaged(id, actor)
+	if err != nil {
+		return nil, err
+	}
+	update := updateDTOFromPost(post)
+	update.Content = dto.content()
+	update.Status = dto.status()
+	update.CommentsEnabled = dto.AllowComments
+	if strings.TrimSpace(dto.Title) != "" {
+		update.Title = dto.Title
+	}
+	if dto.Excerpt != nil {
+		update.Summary = *dto.Excerpt
+	}
+	if dto.Categories != nil {
+		if update.CategoryID, err = s.resolveCategory(dto.Categories); err != nil {
+			return nil, err
+		}
+	}
+	if dto.Keywords != nil {
+		update.TagIDs = nil
+		update.TagNames = dto.tagNames()
+	}
+	return s.postService.Update(update)
+}
+
+// GetPost 获取一篇文章，包括草稿。只有作者和管理员可以获取。
+func (s *MetaWeblogService) GetPost(id uint, actor Actor) (*model.Post, error) {
+	return s.getManaged(id, actor)
+}
+
+// RecentPosts 获取 actor 最近的 n 篇文章，包括草稿；管理员获取所有作者的文章。
+func (s *MetaWeblogService) RecentPosts(actor Actor, n int) ([]model.Post, error) {
+	limit := config.Conf.XMLRPC.MaxRecentPosts
+	if limit <= 0 {
+		limit = 50
+	}
+	if n <= 0 || n > limit {
+		n = limit
+	}
+	dto := &ListPostsDTO{Page: 1, PageSize: n}
+	if !actor.Admin {
+		dto.UserID = actor.UserID
+	}
+	result, err := s.postService.List(dto)
+	if err != nil {
+		return nil, err
+	}
+	return result.Posts, nil
+}
+
+// DeletePost 删除一篇文章，只有作者和管理员可以删除。
+func (s *MetaWeblogService) DeletePost(id uint, actor Actor) error {
+	if _, err := s.getManaged(id, actor); err != nil {
+		return err
+	}
+	return s.postService.Delete(id)
+}
+
+// Categories 获取所有分类。
+func (s *MetaWeblogService) Categories() ([]model.Category, error) {
+	return s.categoryService.List()
+}
+
+// NewMediaObject 以 userID 的身份上传一个文件，文件类型以内容嗅探的结果为准。
+func (s *MetaWeblogService) NewMediaObject(userID uint, name string, bits []byte) (*model.Media, error) {
+	// 客户端上传的文件名可能包含目录，例如 2024/01/photo.jpg
+	if i := strings.LastIndexAny(name, "/\\"); i >= 0 {
+		name = name[i+1:]
+	}
+	return s.mediaService.Upload(&UploadMediaDTO{
+		UserID:   userID,
+		FileName: name,
+		Reader:   bytes.NewReader(bits),
+	})
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/util"
+)
+
+// Micropub 规范定义的错误码，作为错误响应中 error 字段的值。
+const (
+	MicropubUnauthorized      = "unauthorized"       // 没有提供令牌或令牌无效
+	MicropubForbidden         = "forbidden"          // 令牌有效但无权操作
+	MicropubInsufficientScope = "insufficient_scope" // 令牌缺少所需的权限范围
+	MicropubInvalidRequest    = "invalid_request"    // 请求缺少参数或参数无效
+	MicropubServerError       = "server_error"       // 服务器内部错误，规范之外的扩展
+)
+
+// MicropubError 表示应当以 Micropub 错误格式响应给客户端的错误。
+type MicropubError struct {
+	Code        string
+	Description string
+}
+
+// Error 实现了 error 接口。
+func (e *MicropubError) Error() string {
+	return e.Description
+}
+
+// micropubInvalid 返回一个 invalid_request 错误。
+func micropubInvalid(format string, args ...interface{}) error {
+	return &MicropubError{Code: MicropubInvalidRequest, Description: fmt.Sprintf(format, args...)}
+}
+
+// MicropubProperties 是 microformats2 格式的属性，每个属性都是一个值的列表。
+// 值可以是字符串，也可以是 JSON 请求中的对象，例如 {"html": "..."} 或 {"value": "...", "alt": "..."}。
+type MicropubProperties map[string][]interface{}
+
+// micropubValue 返回属性值中的字符串，对象形式的值取其 value 字段。
+func micropubValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		s, _ := v["value"].(string)
+		return s
+	}
+	return ""
+}
+
+// first 返回属性的第一个字符串值。
+func (p MicropubProperties) first(name string) string {
+	if values := p[name]; len(values) > 0 {
+		return strings.TrimSpace(micropubValue(values[0]))
+	}
+	return ""
+}
+
+// values 返回属性的所有非空字符串值。
+func (p MicropubProperties) values(name string) []string {
+	var result []string
+	for _, v := range p[name] {
+		if s := strings.TrimSpace(micropubValue(v)); s != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// content 返回 content 属性对应的 HTML 正文。纯文本会被转义并按段落转换为 HTML。
+func (p MicropubProperties) content() string {
+	values := p["content"]
+	if len(values) == 0 {
+		return ""
+	}
+	if v, ok := values[0].(map[string]interface{}); ok {
+		if s, ok := v["html"].(string); ok {
+			return strings.TrimSpace(s)
+		}
+	}
+	return util.TextToHTML(micropubValue(values[0]))
+}
+
+// photos 返回 photo 属性对应的图片 HTML。
+func (p MicropubProperties) photos() (string, error) {
+	var b strings.Builder
+	for _, v := range p["photo"] {
+		src := strings.TrimSpace(micropubValue(v))
+		if src == "" {
+			continue
+		}
+		if !strings.HasPrefix(src, "https://") && !strings.HasPrefix(src, "http://") && !strings.HasPrefix(src, "/") {
+			return "", micropubInvalid("无效的图片地址: %s", src)
+		}
+		var alt string
+		if m, ok := v.(map[string]interface{}); ok {
+			alt, _ = m["alt"].(string)
+		}
+		fmt.Fprintf(&b, "\n<p><img src=\"%s\" alt=\"%s\"></p>", html.EscapeString(src), html.EscapeString(alt))
+	}
+	return b.String(), nil
+}
+
+// categories 返回 category 属性中的标签名称。以 URL 表示的分类（例如标记某个人）没有对应的标签，会被忽略。
+func (p MicropubProperties) categories() []string {
+	var names []string
+	for _, name := range p.values("category") {
+		if !strings.HasPrefix(name, "http://") && !strings.HasPrefix(name, "https://") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// status 返回 post-status 属性对应的文章状态，没有该属性时返回 fallback。
+func (p MicropubProperties) status(fallback int) (int, error) {
+	switch p.first("post-status") {
+	case "":
+		return fallback, nil
+	case "published":
+		return model.PostStatusPublished, nil
+	case "draft":
+		return model.PostStatusDraft, nil
+	default:
+		return 0, micropubInvalid("不支持的 post-status: %s", p.first("post-status"))
+	}
+}
+
+// MicropubService 结构体将 Micropub 的创建、修改、删除和查询请求映射到文章的业务逻辑上。
+type MicropubService struct {
+	postService *PostService
+	tagService  *TagService
+}
+
+// NewMicropubService 是 MicropubService 的工厂函数。
+func NewMicropubService() *MicropubService {
+	return &MicropubService{
+		postService: NewPostService(),
+		tagService:  NewTagService(),
+	}
+}
+
+// noteTitle 为没有标题的笔记生成标题。
+func noteTitle(content string) string {
+	length := config.Conf.Micropub.NoteTitleLength
+	if length <= 0 {
+		length = 50
+	}
+	if title := util.Excerpt(content, length); title != "" {
+		return title
+	}
+	return "笔记 " + time.Now().Format("2006-01-02 15:04")
+}
+
+// MicropubCreateDTO 封装了创建文章的请求。
+type MicropubCreateDTO struct {
+	UserID     uint
+	Type       string // microformats2 类型，目前只支持 h-entry
+	Properties MicropubProperties
+	DraftOnly  bool // 令牌只有 draft 权限，无论客户端如何请求都保存为草稿
+}
+
+// Create 根据 h-entry 创建一篇文章。没有 name 属性的笔记会从正文中截取标题。
+func (s *MicropubService) Create(dto *MicropubCreateDTO) (*model.Post, error) {
+	if dto.Type != "h-entry" {
+		return nil, micropubInvalid("不支持的类型: %s", dto.Type)
+	}
+	props := dto.Properties
+
+	photos, err := props.photos()
+	if err != nil {
+		return nil, err
+	}
+	content := props.content() + photos
+	if strings.TrimSpace(content) == "" {
+		return nil, micropubInvalid("缺少 content 或 photo 属性")
+	}
+	title := props.first("name")
+	if title == "" {
+		title = noteTitle(content)
+	}
+	status, err := props.status(model.PostStatusPublished)
+	if err != nil {
+		return nil, err
+	}
+	if dto.DraftOnly {
+		status = model.PostStatusDraft
+	}
+	categoryID, err := defaultCategoryID(config.Conf.Micropub.DefaultCategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.postService.Create(&CreatePostDTO{
+		Title:      title,
+		Content:    strings.TrimSpace(content),
+		Summary:    props.first("summary"),
+		Status:     status,
+		UserID:     dto.UserID,
+		CategoryID: categoryID,
+		TagNames:   props.categories(),
+	})
+}
+
+// findPost 根据文章的永久链接查找文章，地址无效或文章不存在时返回 invalid_request 错误。
+func (s *MicropubService) findPost(rawURL string) (*model.Post, error) {
+	if rawURL == "" {
+		return nil, micropubInvalid("缺少 url 参数")
+	}
+	id, err := ParsePostURL(rawURL)
+	if err != nil {
+		return nil, micropubInvalid("%s: %s", err.Error(), rawURL)
+	}
+	var count int64
+	if err := dao.GetDB().Model(&model.Post{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, micropubInvalid("文章不存在: %s", rawURL)
+	}
+	return s.postService.GetByID(id)
+}
+
+// MicropubUpdateDTO 封装了修改文章的请求，对应 Micropub JSON 更新请求中的 replace、add 和 delete。
+type MicropubUpdateDTO struct {
+	URL     string
+	Replace MicropubProperties
+	Add     MicropubProperties
+	// Delete 删除属性中的指定值；DeleteNames 删除整个属性
+	Delete      MicropubProperties
+	DeleteNames []string
+}
+
+// Update 修改一篇文章。未涉及的属性以及 Micropub 无法表达的字段（分类、SEO 等）保持原值。
+func (s *MicropubService) Update(dto *MicropubUpdateDTO) (*model.Post, error) {
+	post, err := s.findPost(dto.URL)
+	if err != nil {
+		return nil, err
+	}
+	update := updateDTOFromPost(post)
+	update.TagIDs = nil
+	for _, tag := range post.Tags {
+		update.TagNames = append(update.TagNames, tag.Name)
+	}
+
+	for name, values := range dto.Replace {
+		props := MicropubProperties{name: values}
+		switch name {
+		case "name":
+			update.Title = props.first("name")
+		case "content":
+			update.Content = props.content()
+		case "summary":
+			update.Summary = props.first("summary")
+		case "category":
+			update.TagNames = props.categories()
+		case "post-status":
+			if update.Status, err = props.status(update.Status); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for name, values := range dto.Add {
+		props := MicropubProperties{name: values}
+		switch name {
+		case "category":
+			update.TagNames = append(update.TagNames, props.categories()...)
+		case "name":
+			// 笔记的标题是从正文中截取的，添加 name 即为笔记设置正式的标题
+			update.Title = props.first("name")
+		case "content", "summary":
+			// 文章的正文和摘要只能有一个值，只有原来为空时才能添加
+			if (name == "content" && update.Content != "") || (name == "summary" && update.Summary != "") {
+				return nil, micropubInvalid("属性 %s 只能有一个值，请使用 replace", name)
+			}
+			if name == "content" {
+				update.Content = props.content()
+			} else {
+				update.Summary = props.first("summary")
+			}
+		}
+	}
+
+	for _, name := range dto.DeleteNames {
+		switch name {
+		case "name":
+			update.Title = noteTitle(update.Content)
+		case "content":
+			return nil, micropubInvalid("不能删除 content 属性")
+		case "summary":
+			update.Summary = ""
+		case "category":
+			update.TagNames = nil
+		}
+	}
+	if removed := (MicropubProperties{"category": dto.Delete["category"]}).categories(); len(removed) > 0 {
+		var kept []string
+		for _, tag := range update.TagNames {
+			keep := true
+			for _, r := range removed {
+				if strings.EqualFold(NormalizeTagName(r), NormalizeTagName(tag)) {
+					keep = false
+					break
+				}
+			}
+			if keep {
+				kept = append(kept, tag)
+			}
+		}
+		update.TagNames = kept
+	}
+
+	if strings.TrimSpace(update.Title) == "" {
+		update.Title = noteTitle(update.Content)
+	}
+	if strings.TrimSpace(update.Content) == "" {
+		return nil, micropubInvalid("content 不能为空")
+	}
+	return s.postService.Update(update)
+}
+
+// Delete 删除一篇文章。
+func (s *MicropubService) Delete(rawURL string) error {
+	post, err := s.findPost(rawURL)
+	if err != nil {
+		return err
+	}
+	return s.postService.Delete(post.ID)
+}
+
+// Source 以 microformats2 JSON 格式返回文章，供客户端编辑前读取 (q=source)。
+// properties 不为空时只返回这些属性，并且按规范省略 type。
+func (s *MicropubService) Source(rawURL string, properties []string) (map[string]interface{}, error) {
+	post, err := s.findPost(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	status := "published"
+	if post.Status == model.PostStatusDraft {
+		status = "draft"
+	}
+	categories := make([]interface{}, 0, len(post.Tags))
+	for _, tag := range post.Tags {
+		categories = append(categories, tag.Name)
+	}
+	all := map[string][]interface{}{
+		"name":        {post.Title},
+		"content":     {map[string]interface{}{"html": post.Content}},
+		"category":    categories,
+		"post-status": {status},
+		"published":   {post.CreatedAt.Format(time.RFC3339)},
+		"updated":     {post.UpdatedAt.Format(time.RFC3339)},
+		"url":         {PostURL(post.ID)},
+	}
+	if post.Summary != "" {
+		all["summary"] = []interface{}{post.Summary}
+	}
+
+	if len(properties) == 0 {
+		return map[string]interface{}{"type": []string{"h-entry"}, "properties": all}, nil
+	}
+	selected := make(map[string][]interface{})
+	for _, name := range properties {
+		if values, ok := all[name]; ok {
+			selected[name] = values
+		}
+	}
+	return map[string]interface{}{"properties": selected}, nil
+}
+
+// Categories 返回可供客户端选择的分类 (q=category)，即本站的标签名称，filter 不为空时按前缀过滤。
+func (s *MicropubService) Categories(filter string) ([]string, error) {
+	var tags []model.Tag
+	var err error
+	if filter = strings.TrimSpace(filter); filter != "" {
+		tags, err = s.tagService.Suggest(filter, 100)
+	} else {
+		tags, err = s.tagService.List()
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	return names, nil
+}
+
+// MicropubEndpoint 返回本站对外声明的 Micropub 接口地址，未启用或无法确定地址时返回空字符串。
+func MicropubEndpoint() string {
+	if !config.Conf.Micropub.Enabled || config.Conf.Site.URL == "" {
+		return ""
+	}
+	return strings.TrimRight(config.Conf.Site.URL, "/") + "/micropub"
+}
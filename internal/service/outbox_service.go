@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/event"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const outboxBatchSize = 50
+
+// outboxCleanupInterval 是后台任务删除过期事件的间隔。
+const outboxCleanupInterval = time.Hour
+
+// 进程启动以来的投递统计，用于观察 outbox 的运行情况。
+var (
+	_outboxDispatched atomic.Int64 // 投递完成的事件数
+	_outboxRetries    atomic.Int64 // 有订阅者失败、安排重试的次数
+	_outboxFailed     atomic.Int64 // 多次重试后标记为投递失败的事件数
+)
+
+// outbox 收集在事务中写入的事件。用法：
+//
+//	var out outbox
+//	err := db.Transaction(func(tx *gorm.DB) error {
+//		// ... 修改业务数据
+//		return out.publish(tx, event.PostCreated{Post: post})
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	out.flush()
+//
+// 事务提交后 flush 立即投递写入的事件；进程在提交后、投递完成前崩溃时，
+// 由 OutboxDispatcher 在租约到期后重新投递。
+type outbox struct {
+	messages []*model.OutboxMessage
+}
+
+// publish 在事务 tx 中写入事件，去重键已存在时忽略该事件。
+func (o *outbox) publish(tx *gorm.DB, e event.Event) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	var key string
+	if d, ok := e.(event.Deduplicated); ok {
+		key = d.DedupKey()
+	} else {
+		random := make([]byte, 16)
+		if _, err := rand.Read(random); err != nil {
+			return err
+		}
+		key = e.Name() + ":" + hex.EncodeToString(random)
+	}
+
+	// 租约从写入时开始计算，提交者在此期间投递，超时后才由后台任务接手
+	msg := &model.OutboxMessage{
+		Event:         e.Name(),
+		DedupKey:      key,
+		Payload:       string(payload),
+		NextAttemptAt: time.Now().Add(outboxLease()),
+	}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(msg)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		o.messages = append(o.messages, msg)
+	}
+	return nil
+}
+
+// flush 在事务提交后调用，立即投递事务中写入的事件。
+func (o *outbox) flush() {
+	for _, msg := range o.messages {
+		dispatchOutboxMessage(context.Background(), msg)
+	}
+	o.messages = nil
+}
+
+// dispatchOutboxMessage 取得事件的租约并投递给尚未成功处理它的订阅者，然后保存投递结果。
+// 事件已被其他投递者取走时什么也不做。
+func dispatchOutboxMessage(ctx context.Context, msg *model.OutboxMessage) {
+	cfg := config.Conf.Outbox
+	db := dao.GetDB()
+
+	// 以尝试次数作为版本号取得租约，同一时刻只有一个投递者能成功
+	attempts := msg.Attempts + 1
+	result := db.Model(&model.OutboxMessage{}).
+		Where("id = ? AND status = ? AND attempts = ?", msg.ID, model.OutboxPending, msg.Attempts).
+		Updates(map[string]interface{}{"attempts": attempts, "next_attempt_at": time.Now().Add(outboxLease())})
+	if result.Error != nil {
+		logger.L.Error("Failed to claim outbox message", zap.Uint("message_id", msg.ID), zap.Error(result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	e, err := event.Decode(msg.Event, []byte(msg.Payload))
+	if err != nil {
+		// 事件类型已不存在或数据损坏，重试也不会成功
+		logger.L.Error("Failed to decode outbox message", zap.Uint("message_id", msg.ID), zap.String("event", msg.Event), zap.Error(err))
+		_outboxFailed.Add(1)
+		updateOutboxMessage(msg, map[string]interface{}{
+			"status":     model.OutboxFailed,
+			"last_error": truncateRunes(err.Error(), 500),
+		})
+		return
+	}
+
+	delivered := make(map[string]bool)
+	for _, name := range strings.Fields(msg.Delivered) {
+		delivered[name] = true
+	}
+	failed := event.Deliver(event.WithDedupKey(ctx, msg.DedupKey), e, delivered)
+
+	names := make([]string, 0, len(delivered))
+	for name := range delivered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	now := time.Now()
+	updates := map[string]interface{}{"delivered": strings.Join(names, " ")}
+	if len(failed) == 0 {
+		_outboxDispatched.Add(1)
+		updates["status"] = model.OutboxDispatched
+		updates["dispatched_at"] = now
+		updates["last_error"] = ""
+		updateOutboxMessage(msg, updates)
+		return
+	}
+
+	var errs []string
+	for name, err := range failed {
+		errs = append(errs, name+": "+err.Error())
+		logger.L.Warn("Event subscriber failed",
+			zap.Uint("message_id", msg.ID), zap.String("event", msg.Event), zap.String("subscriber", name), zap.Int("attempts", attempts), zap.Error(err))
+	}
+	sort.Strings(errs)
+	updates["last_error"] = truncateRunes(strings.Join(errs, "; "), 500)
+	if attempts >= cfg.MaxAttempts {
+		_outboxFailed.Add(1)
+		updates["status"] = model.OutboxFailed
+		logger.L.Error("Outbox message failed", zap.Uint("message_id", msg.ID), zap.String("event", msg.Event), zap.Int("attempts", attempts))
+	} else {
+		_outboxRetries.Add(1)
+		updates["next_attempt_at"] = now.Add(outboxRetryDelay(attempts))
+	}
+	updateOutboxMessage(msg, updates)
+}
+
+// updateOutboxMessage 保存事件的投递结果。
+func updateOutboxMessage(msg *model.OutboxMessage, updates map[string]interface{}) {
+	if err := dao.GetDB().Model(&model.OutboxMessage{}).Where("id = ?", msg.ID).Updates(updates).Error; err != nil {
+		// 结果没有保存时事件会在租约到期后重新投递，已成功的订阅者会再次收到
+		logger.L.Error("Failed to update outbox message", zap.Uint("message_id", msg.ID), zap.Error(err))
+	}
+}
+
+// outboxLease 返回投递者持有事件的时间。
+func outboxLease() time.Duration {
+	if lease := config.Conf.Outbox.Lease; lease > 0 {
+		return lease
+	}
+	return time.Minute
+}
+
+// outboxRetryDelay 返回第 attempts 次失败后的重试间隔，每次翻倍，最长一小时。
+func outboxRetryDelay(attempts int) time.Duration {
+	delay := config.Conf.Outbox.RetryDelay
+	if delay <= 0 {
+		delay = 10 * time.Second
+	}
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		delay = time.Hour
+	}
+	return delay
+}
+
+// OutboxService 结构体封装了 outbox 的查询和管理功能。
+type OutboxService struct{}
+
+// NewOutboxService 是 OutboxService 的工厂函数。
+func NewOutboxService() *OutboxService {
+	return &OutboxService{}
+}
+
+// OutboxStatsDTO 是 outbox 的积压情况和进程启动以来的投递统计。
+type OutboxStatsDTO struct {
+	Pending         int64            `json:"pending"`           // 等待投递和等待重试的事件数
+	PendingByEvent  map[string]int64 `json:"pending_by_event"`  // 按事件名称统计的等待投递的事件数
+	Failed          int64            `json:"failed"`            // 多次重试后仍然失败、需要处理的事件数
+	OldestPendingAt *time.Time       `json:"oldest_pending_at"` // 最早的等待投递的事件的创建时间
+	LagSeconds      float64          `json:"lag_seconds"`       // 最早的等待投递的事件已等待的秒数，没有积压时为 0
+
+	DispatchedTotal int64 `json:"dispatched_total"` // 进程启动以来投递完成的事件数
+	RetriesTotal    int64 `json:"retries_total"`    // 进程启动以来安排重试的次数
+	FailedTotal     int64 `json:"failed_total"`     // 进程启动以来标记为投递失败的事件数
+}
+
+// Stats 返回 outbox 的积压情况。
+func (s *OutboxService) Stats() (*OutboxStatsDTO, error) {
+	db := dao.GetDB()
+	stats := &OutboxStatsDTO{
+		PendingByEvent:  make(map[string]int64),
+		DispatchedTotal: _outboxDispatched.Load(),
+		RetriesTotal:    _outboxRetries.Load(),
+		FailedTotal:     _outboxFailed.Load(),
+	}
+
+	var rows []struct {
+		Event string
+		Count int64
+	}
+	if err := db.Model(&model.OutboxMessage{}).Select("event, COUNT(*) AS count").
+		Where("status = ?", model.OutboxPending).Group("event").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		stats.PendingByEvent[row.Event] = row.Count
+		stats.Pending += row.Count
+	}
+	if err := db.Model(&model.OutboxMessage{}).Where("status = ?", model.OutboxFailed).Count(&stats.Failed).Error; err != nil {
+		return nil, err
+	}
+
+	if stats.Pending > 0 {
+		var oldest model.OutboxMessage
+		if err := db.Select("created_at").Where("status = ?", model.OutboxPending).Order("id ASC").Limit(1).Find(&oldest).Error; err != nil {
+			return nil, err
+		}
+		stats.OldestPendingAt = &oldest.CreatedAt
+		stats.LagSeconds = time.Since(oldest.CreatedAt).Seconds()
+	}
+	return stats, nil
+}
+
+// RetryFailed 将所有投递失败的事件重新放回队列，只投递给之前失败的订阅者，返回重新放回的事件数。
+func (s *OutboxService) RetryFailed() (int64, error) {
+	result := dao.GetDB().Model(&model.OutboxMessage{}).Where("status = ?", model.OutboxFailed).Updates(map[string]interface{}{
+		"status":          model.OutboxPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+	})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected > 0 {
+		wakeOutboxDispatcher()
+	}
+	return result.RowsAffected, nil
+}
+
+// OutboxDispatcher 在后台投递租约已到期的事件和等待重试的事件，并删除过期的已投递事件。
+type OutboxDispatcher struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	done   chan struct{}
+
+	lastCleanup time.Time
+}
+
+var (
+	_outboxDispatcher   *OutboxDispatcher
+	_outboxDispatcherMu sync.Mutex
+)
+
+// StartOutboxDispatcher 启动 outbox 后台任务，它必须在数据库初始化之后调用，程序退出前应调用 Shutdown。
+func StartOutboxDispatcher() *OutboxDispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &OutboxDispatcher{
+		ctx:    ctx,
+		cancel: cancel,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	_outboxDispatcherMu.Lock()
+	_outboxDispatcher = d
+	_outboxDispatcherMu.Unlock()
+	go d.run()
+	return d
+}
+
+// wakeOutboxDispatcher 通知后台任务有需要投递的事件，后台任务未启动时什么也不做。
+func wakeOutboxDispatcher() {
+	_outboxDispatcherMu.Lock()
+	d := _outboxDispatcher
+	_outboxDispatcherMu.Unlock()
+	if d == nil {
+		return
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Shutdown 停止后台任务，未完成的事件在租约到期后由下次启动的后台任务继续投递。
+func (d *OutboxDispatcher) Shutdown(ctx context.Context) error {
+	d.cancel()
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 是后台任务的主循环。
+func (d *OutboxDispatcher) run() {
+	defer close(d.done)
+	interval := config.Conf.Outbox.PollInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		d.processDue()
+		d.cleanup()
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+// processDue 投递所有已到期的事件，直到没有到期事件或后台任务被停止。
+func (d *OutboxDispatcher) processDue() {
+	db := dao.GetDB()
+	for d.ctx.Err() == nil {
+		var messages []model.OutboxMessage
+		if err := db.Where("status = ? AND next_attempt_at <= ?", model.OutboxPending, time.Now()).
+			Order("next_attempt_at ASC").Limit(outboxBatchSize).Find(&messages).Error; err != nil {
+			logger.L.Error("Failed to load pending outbox messages", zap.Error(err))
+			return
+		}
+		if len(messages) == 0 {
+			return
+		}
+		for i := range messages {
+			if d.ctx.Err() != nil {
+				return
+			}
+			dispatchOutboxMessage(d.ctx, &messages[i])
+		}
+	}
+}
+
+// cleanup 删除超过保留时间的已投递事件，每小时最多执行一次。
+func (d *OutboxDispatcher) cleanup() {
+	retention := config.Conf.Outbox.Retention
+	if retention <= 0 || time.Since(d.lastCleanup) < outboxCleanupInterval {
+		return
+	}
+	d.lastCleanup = time.Now()
+	result := dao.GetDB().Where("status = ? AND dispatched_at < ?", model.OutboxDispatched, time.Now().Add(-retention)).
+		Delete(&model.OutboxMessage{})
+	if result.Error != nil {
+		logger.L.Error("Failed to clean up outbox messages", zap.Error(result.Error))
+		return
+	}
+	if result.RowsAffected > 0 {
+		logger.L.Info("Cleaned up outbox messages", zap.Int64("count", result.RowsAffected))
+	}
+}
@@ -3,9 +3,13 @@ package service
 
 import (
 	"errors"
+	"strings"
+	"time"
 
 	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/event"
 	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/sanitize"
 	"gorm.io/gorm"
 )
 
@@ -25,14 +29,61 @@ type CreatePostDTO struct {
 	Status     int
 	UserID     uint
 	CategoryID uint
-	TagIDs     []uint // 标签 ID 列表
+	TagIDs     []uint   // 已有标签的 ID 列表
+	TagNames   []string // 标签名称列表，不存在的标签会被自动创建
+	SEO        PostSEODTO
+	// CommentsEnabled 表示是否允许评论，为 nil 时默认允许
+	CommentsEnabled *bool
+	// Lang 是文章的语言，为空时使用站点的默认语言
+	Lang string
+	// TranslationOf 是原文的 ID，设置后新文章作为原文的一个翻译版本；指向的文章本身是翻译时，以它的原文为准
+	TranslationOf *uint
+}
+
+// PostSEODTO 封装了文章的 SEO 字段，均为可选。
+type PostSEODTO struct {
+	MetaTitle       string
+	MetaDescription string
+	CanonicalURL    string
+	NoIndex         bool
+	OGImageID       *uint // 分享卡片图片的媒体 ID，为 nil 时自动使用正文中的第一张图片
+}
+
+// validateOGImage 校验分享卡片图片是否存在且为图片。
+func validateOGImage(tx *gorm.DB, id *uint) error {
+	if id == nil {
+		return nil
+	}
+	var media model.Media
+	if err := tx.First(&media, *id).Error; err != nil {
+		return ErrInvalidOGImage
+	}
+	if !strings.HasPrefix(media.MimeType, "image/") {
+		return ErrOGImageNotImage
+	}
+	return nil
+}
+
+// applySEO 将 SEO 字段写入文章模型。
+func applySEO(post *model.Post, seo *PostSEODTO) {
+	post.MetaTitle = strings.TrimSpace(seo.MetaTitle)
+	post.MetaDescription = strings.TrimSpace(seo.MetaDescription)
+	post.CanonicalURL = strings.TrimSpace(seo.CanonicalURL)
+	post.NoIndex = seo.NoIndex
+	post.OGImageID = seo.OGImageID
+}
+
+// fillPostMediaURLs 填充文章中引用的媒体文件的访问地址。
+func fillPostMediaURLs(post *model.Post) {
+	if post.OGImage != nil {
+		fillMediaURLs(post.OGImage)
+	}
 }
 
 // Create 用于创建一篇新文章。
 func (s *PostService) Create(dto *CreatePostDTO) (*model.Post, error) {
 	db := dao.GetDB()
 	var category model.Category
-	var tags []model.Tag
 
 	// 声明一个 newPost 变量，用于在事务内外传递数据
 	newPost := &model.Post{
@@ -43,51 +94,94 @@ func (s *PostService) Create(dto *CreatePostDTO) (*model.Post, error) {
 		UserID:     dto.UserID,
 		CategoryID: dto.CategoryID,
 	}
+	applySEO(newPost, &dto.SEO)
+	newPost.CommentsEnabled = dto.CommentsEnabled == nil || *dto.CommentsEnabled
+
+	lang, err := normalizeContentLang(dto.Lang)
+	if err != nil {
+		return nil, err
+	}
+	newPost.Lang = lang
+
+	// 调用注册的过滤器，它们可以修改将要保存的内容
+	if err := event.ApplyFilters(newPost); err != nil {
+		return nil, err
+	}
+	// 正文会作为 HTML 输出到站点页面，在过滤器之后清理，去除脚本等不安全的内容
+	newPost.Content = sanitize.HTML(newPost.Content)
 
 	// 使用事务 (Transaction) 来确保数据一致性。
-	err := db.Transaction(func(tx *gorm.DB) error {
+	var out outbox
+	err = db.Transaction(func(tx *gorm.DB) error {
 		// 1. 校验 CategoryID 是否有效
 		if err := tx.First(&category, dto.CategoryID).Error; err != nil {
-			return errors.New("无效的分类 ID")
+			return ErrInvalidCategory
 		}
 
-		// 2. 校验所有 TagID 是否有效
-		if len(dto.TagIDs) > 0 {
-			var count int64
-			if err := tx.Model(&model.Tag{}).Where("id IN ?", dto.TagIDs).Count(&count).Error; err != nil {
+		if err := validateOGImage(tx, dto.SEO.OGImageID); err != nil {
+			return err
+		}
+
+		// 作为翻译创建时，原文下不能已有同一语言的版本
+		if dto.TranslationOf != nil {
+			var source model.Post
+			if err := tx.Select("id, translation_of").First(&source, *dto.TranslationOf).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return ErrTranslationSourceNotFound
+				}
 				return err
 			}
-			if count != int64(len(dto.TagIDs)) {
-				return errors.New("包含无效的标签 ID")
-			}
-			// 获取标签对象以便于关联
-			if err := tx.Find(&tags, dto.TagIDs).Error; err != nil {
+			root := translationRoot(&source)
+			if err := checkTranslationSlot(tx, root, lang, 0); err != nil {
 				return err
 			}
-			// 将查询到的 tag 实例赋给 newPost
-			newPost.Tags = tags
+			newPost.TranslationOf = &root
 		}
 
+		// 2. 解析标签：校验 TagID 是否有效，并按名称查找或创建标签
+		tags, err := resolveTags(tx, dto.TagIDs, dto.TagNames)
+		if err != nil {
+			return err
+		}
+		// 将解析出的 tag 实例赋给 newPost
+		newPost.Tags = tags
+
 		// 3. 创建 Post
 		// 在事务中创建 post 记录
 		if err := tx.Create(newPost).Error; err != nil {
 			return err
 		}
+		// comments_enabled 和 status 有默认值，GORM 创建记录时会忽略零值，需要单独更新
+		if !newPost.CommentsEnabled {
+			if err := tx.Model(newPost).Update("comments_enabled", false).Error; err != nil {
+				return err
+			}
+		}
+		if newPost.Status == model.PostStatusDraft {
+			if err := tx.Model(newPost).Update("status", model.PostStatusDraft).Error; err != nil {
+				return err
+			}
+		}
 
-		// 事务成功，返回 nil
-		return nil
+		// 事件与文章一起提交
+		return out.publish(tx, event.PostCreated{Post: newPost})
 	})
 
 	if err != nil {
 		return nil, err
 	}
+	out.flush()
 
 	// --- 错误修正 ---
 	// 在事务成功后, GORM 会自动将新创建记录的 ID 回填到 newPost.ID 字段中。
 	// 我们现在可以直接使用 newPost.ID 来查询完整的、预加载了关联数据的文章。
 	// 不再需要一个未定义的 lastInsertId 变量。
 	var createdPost model.Post
-	if err := db.Preload("User").Preload("Category").Preload("Tags").First(&createdPost, newPost.ID).Error; err != nil {
+	if err := db.Preload("User").Preload("Category").Preload("Tags").Preload("OGImage").First(&createdPost, newPost.ID).Error; err != nil {
+		return nil, err
+	}
+	fillPostMediaURLs(&createdPost)
+	if err := fillTranslations(db, &createdPost, false); err != nil {
 		return nil, err
 	}
 
@@ -96,8 +190,10 @@ func (s *PostService) Create(dto *CreatePostDTO) (*model.Post, error) {
 
 // ListPostsDTO 封装了查询文章列表时的参数。
 type ListPostsDTO struct {
-	Page     int // 页码
-	PageSize int // 每页数量
+	Page     int    // 页码
+	PageSize int    // 每页数量
+	Lang     string // 只列出该语言的文章，分类和标签名称也使用该语言；为空时不过滤
+	UserID   uint   // 只列出该作者的文章；为 0 时不过滤
 }
 
 // ListResponseDTO 封装了文章列表和总数，用于返回给上层。
@@ -115,13 +211,33 @@ func (s *PostService) List(dto *ListPostsDTO) (*ListResponseDTO, error) {
 	// 计算 offset
 	offset := (dto.Page - 1) * dto.PageSize
 
+	query := db.Model(&model.Post{})
+	if dto.Lang != "" {
+		lang, err := normalizeContentLang(dto.Lang)
+		if err != nil {
+			return nil, err
+		}
+		dto.Lang = lang
+		query = whereLang(query, "lang", lang)
+	}
+	if dto.UserID != 0 {
+		query = query.Where("user_id = ?", dto.UserID)
+	}
+
 	// 查询总数
-	if err := db.Model(&model.Post{}).Count(&totalCount).Error; err != nil {
+	if err := query.Count(&totalCount).Error; err != nil {
 		return nil, err
 	}
 
 	// 查询分页数据，并预加载关联数据
-	if err := db.Preload("User").Preload("Category").Preload("Tags").Order("created_at DESC").Limit(dto.PageSize).Offset(offset).Find(&posts).Error; err != nil {
+	if err := query.Preload("User").Preload("Category").Preload("Tags").Order("created_at DESC").Limit(dto.PageSize).Offset(offset).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	if err := localizeNames(db, dto.Lang, posts); err != nil {
+		return nil, err
+	}
+	// 评论数通过一次分组查询填充
+	if err := fillCommentCounts(db, posts); err != nil {
 		return nil, err
 	}
 
@@ -131,19 +247,159 @@ func (s *PostService) List(dto *ListPostsDTO) (*ListResponseDTO, error) {
 	}, nil
 }
 
+// ListPublishedDTO 封装了查询已发布文章时的过滤条件，各条件为 0 时表示不过滤。
+type ListPublishedDTO struct {
+	CategoryID uint
+	TagID      uint
+	UserID     uint
+	Lang       string // 只列出该语言的文章，分类和标签名称也使用该语言；为空时不过滤
+	Limit      int
+	Offset     int
+}
+
+// publishedQuery 根据过滤条件构造查询已发布文章的语句。
+func publishedQuery(db *gorm.DB, dto *ListPublishedDTO) *gorm.DB {
+	query := db.Model(&model.Post{}).Where("posts.status = ?", model.PostStatusPublished)
+	if dto.CategoryID != 0 {
+		query = query.Where("posts.category_id = ?", dto.CategoryID)
+	}
+	if dto.UserID != 0 {
+		query = query.Where("posts.user_id = ?", dto.UserID)
+	}
+	if dto.TagID != 0 {
+		query = query.Where("posts.id IN (?)", db.Table("post_tags").Select("post_id").Where("tag_id = ?", dto.TagID))
+	}
+	if dto.Lang != "" {
+		query = whereLang(query, "posts.lang", dto.Lang)
+	}
+	return query
+}
+
+// ListPublished 用于获取最新的已发布文章，按发布时间倒序排列，主要用于订阅源、公开站点等对外输出的场景。
+func (s *PostService) ListPublished(dto *ListPublishedDTO) ([]model.Post, error) {
+	db := dao.GetDB()
+	var posts []model.Post
+	if err := publishedQuery(db, dto).Preload("User").Preload("Category").Preload("Tags").
+		Order("posts.created_at DESC").Limit(dto.Limit).Offset(dto.Offset).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	if err := fillCommentCounts(db, posts); err != nil {
+		return nil, err
+	}
+	if err := localizeNames(db, dto.Lang, posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
+// CountPublished 用于统计符合条件的已发布文章数量，Limit 和 Offset 会被忽略。
+func (s *PostService) CountPublished(dto *ListPublishedDTO) (int64, error) {
+	db := dao.GetDB()
+	var count int64
+	err := publishedQuery(db, dto).Count(&count).Error
+	return count, err
+}
+
+// CountPublishedByUsers 用于批量统计用户已发布的文章数，返回用户 ID 到文章数的映射，没有文章的用户不在其中。
+func (s *PostService) CountPublishedByUsers(userIDs []uint) (map[uint]int64, error) {
+	if len(userIDs) == 0 {
+		return map[uint]int64{}, nil
+	}
+	var rows []idCountRow
+	if err := dao.GetDB().Model(&model.Post{}).Select("user_id AS id, COUNT(*) AS count").
+		Where("user_id IN ? AND status = ?", userIDs, 1).Group("user_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return countMap(rows), nil
+}
+
+// idCountRow 是按 ID 分组统计数量时使用的查询结果。
+type idCountRow struct {
+	ID    uint
+	Count int64
+}
+
+// countMap 将分组统计的结果转换为 ID 到数量的映射。
+func countMap(rows []idCountRow) map[uint]int64 {
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ID] = row.Count
+	}
+	return counts
+}
+
+// ArchivePostDTO 描述了归档页中的一篇文章。
+type ArchivePostDTO struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ArchiveMonthDTO 描述了归档页中一个月份下的所有文章。
+type ArchiveMonthDTO struct {
+	Year  int              `json:"year"`
+	Month int              `json:"month"`
+	Posts []ArchivePostDTO `json:"posts"`
+}
+
+// Archive 用于获取按月份分组的已发布文章列表，月份和文章均按时间倒序排列。
+func (s *PostService) Archive() ([]ArchiveMonthDTO, error) {
+	db := dao.GetDB()
+	var posts []ArchivePostDTO
+	if err := db.Model(&model.Post{}).Select("id, title, created_at").
+		Where("status = ?", model.PostStatusPublished).Order("created_at DESC").Scan(&posts).Error; err != nil {
+		return nil, err
+	}
+
+	months := []ArchiveMonthDTO{}
+	for _, post := range posts {
+		year, month := post.CreatedAt.Year(), int(post.CreatedAt.Month())
+		if n := len(months); n == 0 || months[n-1].Year != year || months[n-1].Month != month {
+			months = append(months, ArchiveMonthDTO{Year: year, Month: month})
+		}
+		last := &months[len(months)-1]
+		last.Posts = append(last.Posts, post)
+	}
+	return months, nil
+}
+
 // GetByID 用于根据 ID 获取单篇文章的详细信息。
 func (s *PostService) GetByID(id uint) (*model.Post, error) {
 	db := dao.GetDB()
 	var post model.Post
-	if err := db.Preload("User").Preload("Category").Preload("Tags").First(&post, id).Error; err != nil {
+	if err := db.Preload("User").Preload("Category").Preload("Tags").Preload("OGImage").First(&post, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("文章不存在")
+			return nil, ErrPostNotFound
 		}
 		return nil, err
 	}
+	fillPostMediaURLs(&post)
+	if err := db.Model(&model.Comment{}).Where("post_id = ? AND status = ?", post.ID, model.CommentStatusApproved).
+		Count(&post.CommentCount).Error; err != nil {
+		return nil, err
+	}
+	if err := fillTranslations(db, &post, false); err != nil {
+		return nil, err
+	}
 	return &post, nil
 }
 
+// GetByIDs 用于批量获取文章（包含草稿），作者、分类、标签和评论数一并填充，不存在的 ID 会被忽略，结果的顺序与 ids 无关。
+func (s *PostService) GetByIDs(ids []uint) ([]model.Post, error) {
+	var posts []model.Post
+	if len(ids) == 0 {
+		return posts, nil
+	}
+	db := dao.GetDB()
+	if err := db.Preload("User").Preload("Category").Preload("Tags").Where("id IN ?", ids).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	if err := fillCommentCounts(db, posts); err != nil {
+		return nil, err
+	}
+	return posts, nil
+}
+
 // UpdatePostDTO 封装了更新文章时需要的所有数据。
 type UpdatePostDTO struct {
 	ID         uint
@@ -153,6 +409,37 @@ type UpdatePostDTO struct {
 	Status     int
 	CategoryID uint
 	TagIDs     []uint
+	TagNames   []string
+	SEO        PostSEODTO
+	// CommentsEnabled 表示是否允许评论，为 nil 时保持不变
+	CommentsEnabled *bool
+	// Lang 是文章的语言，为空时保持不变
+	Lang string
+}
+
+// updateDTOFromPost 以文章的当前内容构造一个 UpdatePostDTO，
+// 供只修改部分字段的调用方（XML-RPC、Micropub 等）在此基础上修改。
+func updateDTOFromPost(post *model.Post) *UpdatePostDTO {
+	dto := &UpdatePostDTO{
+		ID:         post.ID,
+		Title:      post.Title,
+		Content:    post.Content,
+		Summary:    post.Summary,
+		Status:     post.Status,
+		CategoryID: post.CategoryID,
+		Lang:       post.Lang,
+		SEO: PostSEODTO{
+			MetaTitle:       post.MetaTitle,
+			MetaDescription: post.MetaDescription,
+			CanonicalURL:    post.CanonicalURL,
+			NoIndex:         post.NoIndex,
+			OGImageID:       post.OGImageID,
+		},
+	}
+	for _, tag := range post.Tags {
+		dto.TagIDs = append(dto.TagIDs, tag.ID)
+	}
+	return dto
 }
 
 // Update 用于更新一篇文章。
@@ -160,31 +447,43 @@ func (s *PostService) Update(dto *UpdatePostDTO) (*model.Post, error) {
 	db := dao.GetDB()
 	var post model.Post
 	var category model.Category
-	var tags []model.Tag
+	var previous model.Post
+	var out outbox
 
 	err := db.Transaction(func(tx *gorm.DB) error {
 		// 1. 查找要更新的文章是否存在
 		if err := tx.First(&post, dto.ID).Error; err != nil {
-			return errors.New("文章不存在")
+			return ErrPostNotFound
 		}
+		previous = post
 
 		// 2. 校验 CategoryID 是否有效
 		if err := tx.First(&category, dto.CategoryID).Error; err != nil {
-			return errors.New("无效的分类 ID")
+			return ErrInvalidCategory
+		}
+
+		if err := validateOGImage(tx, dto.SEO.OGImageID); err != nil {
+			return err
 		}
 
-		// 3. 校验所有 TagID 是否有效
-		if len(dto.TagIDs) > 0 {
-			var count int64
-			if err := tx.Model(&model.Tag{}).Where("id IN ?", dto.TagIDs).Count(&count).Error; err != nil {
+		// 修改语言时，同一原文下不能已有该语言的版本
+		if dto.Lang != "" {
+			lang, err := normalizeContentLang(dto.Lang)
+			if err != nil {
 				return err
 			}
-			if count != int64(len(dto.TagIDs)) {
-				return errors.New("包含无效的标签 ID")
-			}
-			if err := tx.Find(&tags, dto.TagIDs).Error; err != nil {
-				return err
+			if lang != PostLang(&post) {
+				if err := checkTranslationSlot(tx, translationRoot(&post), lang, post.ID); err != nil {
+					return err
+				}
 			}
+			post.Lang = lang
+		}
+
+		// 3. 解析标签：校验 TagID 是否有效，并按名称查找或创建标签
+		tags, err := resolveTags(tx, dto.TagIDs, dto.TagNames)
+		if err != nil {
+			return err
 		}
 
 		// 4. 更新文章基本信息
@@ -193,6 +492,16 @@ func (s *PostService) Update(dto *UpdatePostDTO) (*model.Post, error) {
 		post.Summary = dto.Summary
 		post.Status = dto.Status
 		post.CategoryID = dto.CategoryID
+		applySEO(&post, &dto.SEO)
+		if dto.CommentsEnabled != nil {
+			post.CommentsEnabled = *dto.CommentsEnabled
+		}
+
+		// 调用注册的过滤器，它们可以修改将要保存的内容
+		if err := event.ApplyFilters(&post); err != nil {
+			return err
+		}
+		post.Content = sanitize.HTML(post.Content)
 
 		if err := tx.Save(&post).Error; err != nil {
 			return err
@@ -204,16 +513,21 @@ func (s *PostService) Update(dto *UpdatePostDTO) (*model.Post, error) {
 			return err
 		}
 
-		return nil
+		return out.publish(tx, event.PostUpdated{Post: &post, Previous: &previous})
 	})
 
 	if err != nil {
 		return nil, err
 	}
+	out.flush()
 
 	// 重新查询以返回完整的、预加载了所有关联数据的文章
 	var updatedPost model.Post
-	if err := db.Preload("User").Preload("Category").Preload("Tags").First(&updatedPost, dto.ID).Error; err != nil {
+	if err := db.Preload("User").Preload("Category").Preload("Tags").Preload("OGImage").First(&updatedPost, dto.ID).Error; err != nil {
+		return nil, err
+	}
+	fillPostMediaURLs(&updatedPost)
+	if err := fillTranslations(db, &updatedPost, false); err != nil {
 		return nil, err
 	}
 
@@ -223,13 +537,14 @@ func (s *PostService) Update(dto *UpdatePostDTO) (*model.Post, error) {
 // Delete 用于根据 ID 删除一篇文章。
 func (s *PostService) Delete(id uint) error {
 	db := dao.GetDB()
+	var post model.Post
+	var out outbox
 
-	return db.Transaction(func(tx *gorm.DB) error {
-		var post model.Post
+	err := db.Transaction(func(tx *gorm.DB) error {
 		// 首先需要查找文章已进行关联删除
 		if err := tx.First(&post, id).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return errors.New("文章不存在")
+				return ErrPostNotFound
 			}
 			return err
 		}
@@ -240,11 +555,47 @@ func (s *PostService) Delete(id uint) error {
 			return err
 		}
 
+		// 删除文章下的所有评论
+		if err := tx.Where("post_id = ?", id).Delete(&model.Comment{}).Error; err != nil {
+			return err
+		}
+
+		// 删除文章收到和发出的 Webmention
+		if err := tx.Where("post_id = ?", id).Delete(&model.Webmention{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id = ?", id).Delete(&model.WebmentionSend{}).Error; err != nil {
+			return err
+		}
+
+		// 删除原文时，最早创建的翻译成为新的原文，其余翻译改为指向它
+		if post.TranslationOf == nil {
+			var successor model.Post
+			if err := tx.Select("id").Where("translation_of = ?", id).Order("id ASC").Limit(1).Find(&successor).Error; err != nil {
+				return err
+			}
+			if successor.ID != 0 {
+				if err := tx.Model(&model.Post{}).Where("translation_of = ? AND id <> ?", id, successor.ID).
+					Update("translation_of", successor.ID).Error; err != nil {
+					return err
+				}
+				if err := tx.Model(&model.Post{}).Where("id = ?", successor.ID).
+					Update("translation_of", nil).Error; err != nil {
+					return err
+				}
+			}
+		}
+
 		// 删除文章
 		if err := tx.Delete(&model.Post{}, id).Error; err != nil {
 			return err
 		}
 
-		return nil
+		return out.publish(tx, event.PostDeleted{Post: &post})
 	})
+	if err != nil {
+		return err
+	}
+	out.flush()
+	return nil
 }
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"encoding/json"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/util"
+)
+
+// metaDescriptionLength 是自动生成的描述的最大长度（字符数），与搜索引擎通常展示的长度相当。
+const metaDescriptionLength = 160
+
+// jsonLDHeadlineLength 是 JSON-LD 中 headline 的最大长度，超过后搜索引擎会忽略该字段。
+const jsonLDHeadlineLength = 110
+
+var (
+	// firstImageRe 匹配正文中第一张图片的 src 属性。
+	firstImageRe = regexp.MustCompile(`(?i)<img\s[^>]*?src\s*=\s*["']([^"']+)["']`)
+	// storageKeyRe 匹配地址中的内容寻址存储键，例如 ab/cd/abcd...ef.jpg。
+	storageKeyRe = regexp.MustCompile(`[0-9a-f]{2}/[0-9a-f]{2}/[0-9a-f]{64}\.[a-z0-9]+`)
+)
+
+// SEOService 结构体封装了生成页面元数据（SEO、Open Graph、Twitter 卡片和 JSON-LD）的业务逻辑。
+type SEOService struct {
+	postService *PostService
+}
+
+// NewSEOService 是 SEOService 的工厂函数。
+func NewSEOService() *SEOService {
+	return &SEOService{
+		postService: NewPostService(),
+	}
+}
+
+// MetaImageDTO 描述了分享卡片使用的图片。
+type MetaImageDTO struct {
+	URL    string `json:"url"`
+	Width  int    `json:"width,omitempty"`
+	Height int    `json:"height,omitempty"`
+	Alt    string `json:"alt,omitempty"`
+}
+
+// MetaTagDTO 描述了一个 <meta> 标签。Name 和 Property 只会设置其中一个，
+// Open Graph 使用 property 属性，其他标签使用 name 属性。
+type MetaTagDTO struct {
+	Name     string `json:"name,omitempty"`
+	Property string `json:"property,omitempty"`
+	Content  string `json:"content"`
+}
+
+// AlternateLinkDTO 描述了页面的一个语言版本，对应 <link rel="alternate" hreflang="..."> 标签。
+type AlternateLinkDTO struct {
+	Lang string `json:"lang"`
+	Href string `json:"href"`
+}
+
+// PostMetaDTO 是文章页面 <head> 中需要的全部元数据。
+type PostMetaDTO struct {
+	Title        string                 `json:"title"`         // <title> 的内容，包含站点名称
+	Description  string                 `json:"description"`   // 页面描述
+	CanonicalURL string                 `json:"canonical_url"` // 规范链接
+	Lang         string                 `json:"lang"`          // 文章的语言
+	Alternates   []AlternateLinkDTO     `json:"alternates"`    // 文章的所有已发布语言版本（包括自身），没有翻译时为空
+	Robots       string                 `json:"robots"`        // robots 指令，例如 "index, follow"
+	Image        *MetaImageDTO          `json:"image"`         // 分享卡片图片，没有图片时为 null
+	Tags         []MetaTagDTO           `json:"tags"`          // 所有 <meta> 标签
+	JSONLD       map[string]interface{} `json:"json_ld"`       // BlogPosting 结构化数据
+	HTML         string                 `json:"html"`          // 渲染好的 <head> 片段，可直接插入页面
+}
+
+// PostMeta 用于生成一篇文章的页面元数据。
+// 未填写的 SEO 字段会自动推导：标题使用文章标题，描述使用摘要或正文开头，图片使用正文中的第一张图片。
+func (s *SEOService) PostMeta(id uint) (*PostMetaDTO, error) {
+	post, err := s.postService.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	site := config.Conf.Site
+
+	// 对外输出时只列出已发布的语言版本，分类和标签名称使用文章的语言
+	db := dao.GetDB()
+	if err := fillTranslations(db, post, true); err != nil {
+		return nil, err
+	}
+	lang := PostLang(post)
+	localized := []model.Post{*post}
+	if err := localizeNames(db, lang, localized); err != nil {
+		return nil, err
+	}
+	*post = localized[0]
+
+	headline := post.Title
+	if post.MetaTitle != "" {
+		headline = post.MetaTitle
+	}
+	description := post.MetaDescription
+	if description == "" {
+		description = util.Excerpt(postSummary(post), metaDescriptionLength)
+	}
+	canonical := post.CanonicalURL
+	if canonical == "" {
+		canonical = PostURL(post.ID)
+	}
+	// 草稿不应被收录
+	robots := "index, follow"
+	if post.NoIndex || post.Status != model.PostStatusPublished {
+		robots = "noindex, follow"
+	}
+	image := s.postImage(post)
+
+	meta := &PostMetaDTO{
+		Title:        headline,
+		Description:  description,
+		CanonicalURL: canonical,
+		Lang:         lang,
+		Alternates:   []AlternateLinkDTO{},
+		Robots:       robots,
+		Image:        image,
+	}
+	if len(post.Translations) > 0 {
+		meta.Alternates = append(meta.Alternates, AlternateLinkDTO{Lang: lang, Href: PostURL(post.ID)})
+		for _, t := range post.Translations {
+			meta.Alternates = append(meta.Alternates, AlternateLinkDTO{Lang: t.Lang, Href: PostURL(t.ID)})
+		}
+	}
+	if site.Title != "" {
+		meta.Title = headline + " - " + site.Title
+	}
+
+	// --- 基本标签 ---
+	meta.Tags = append(meta.Tags,
+		MetaTagDTO{Name: "description", Content: description},
+		MetaTagDTO{Name: "robots", Content: robots},
+	)
+
+	// --- Open Graph ---
+	og := []MetaTagDTO{
+		{Property: "og:type", Content: "article"},
+		{Property: "og:title", Content: headline},
+		{Property: "og:description", Content: description},
+		{Property: "og:url", Content: canonical},
+		{Property: "og:site_name", Content: site.Title},
+	}
+	if lang != "" {
+		og = append(og, MetaTagDTO{Property: "og:locale", Content: strings.ReplaceAll(lang, "-", "_")})
+	}
+	for _, t := range post.Translations {
+		og = append(og, MetaTagDTO{Property: "og:locale:alternate", Content: strings.ReplaceAll(t.Lang, "-", "_")})
+	}
+	if image != nil {
+		og = append(og, MetaTagDTO{Property: "og:image", Content: image.URL})
+		if image.Width > 0 && image.Height > 0 {
+			og = append(og,
+				MetaTagDTO{Property: "og:image:width", Content: strconv.Itoa(image.Width)},
+				MetaTagDTO{Property: "og:image:height", Content: strconv.Itoa(image.Height)},
+			)
+		}
+		if image.Alt != "" {
+			og = append(og, MetaTagDTO{Property: "og:image:alt", Content: image.Alt})
+		}
+	}
+	og = append(og,
+		MetaTagDTO{Property: "article:published_time", Content: post.CreatedAt.Format(time.RFC3339)},
+		MetaTagDTO{Property: "article:modified_time", Content: post.UpdatedAt.Format(time.RFC3339)},
+		MetaTagDTO{Property: "article:author", Content: authorName(&post.User)},
+	)
+	if post.Category.Name != "" {
+		og = append(og, MetaTagDTO{Property: "article:section", Content: post.Category.Name})
+	}
+	for _, tag := range post.Tags {
+		og = append(og, MetaTagDTO{Property: "article:tag", Content: tag.Name})
+	}
+	meta.Tags = append(meta.Tags, og...)
+
+	// --- Twitter 卡片 ---
+	card := "summary"
+	if image != nil {
+		card = "summary_large_image"
+	}
+	meta.Tags = append(meta.Tags,
+		MetaTagDTO{Name: "twitter:card", Content: card},
+		MetaTagDTO{Name: "twitter:title", Content: headline},
+		MetaTagDTO{Name: "twitter:description", Content: description},
+	)
+	if image != nil {
+		meta.Tags = append(meta.Tags, MetaTagDTO{Name: "twitter:image", Content: image.URL})
+	}
+	if site.TwitterSite != "" {
+		meta.Tags = append(meta.Tags, MetaTagDTO{Name: "twitter:site", Content: site.TwitterSite})
+	}
+
+	meta.JSONLD = blogPostingJSONLD(post, headline, description, canonical, image)
+	meta.HTML, err = renderHeadHTML(meta)
+	if err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// postImage 返回文章的分享图片：优先使用指定的媒体文件，否则使用正文中的第一张图片。
+func (s *SEOService) postImage(post *model.Post) *MetaImageDTO {
+	if post.OGImage != nil {
+		return &MetaImageDTO{
+			URL:    absoluteURL(post.OGImage.URL),
+			Width:  post.OGImage.Width,
+			Height: post.OGImage.Height,
+			Alt:    post.OGImage.AltText,
+		}
+	}
+
+	match := firstImageRe.FindStringSubmatch(post.Content)
+	if match == nil {
+		return nil
+	}
+	image := &MetaImageDTO{URL: absoluteURL(html.UnescapeString(match[1]))}
+	// 图片来自媒体库时补充尺寸和替代文本
+	if key := storageKeyRe.FindString(match[1]); key != "" {
+		var media model.Media
+		if err := dao.GetDB().Where("storage_key = ?", key).First(&media).Error; err == nil {
+			image.Width, image.Height, image.Alt = media.Width, media.Height, media.AltText
+		} else {
+			var variant model.MediaVariant
+			if err := dao.GetDB().Where("storage_key = ?", key).First(&variant).Error; err == nil {
+				image.Width, image.Height = variant.Width, variant.Height
+			}
+		}
+	}
+	return image
+}
+
+// blogPostingJSONLD 生成 schema.org 的 BlogPosting 结构化数据。
+func blogPostingJSONLD(post *model.Post, headline, description, canonical string, image *MetaImageDTO) map[string]interface{} {
+	site := config.Conf.Site
+	if utf8.RuneCountInString(headline) > jsonLDHeadlineLength {
+		headline = string([]rune(headline)[:jsonLDHeadlineLength-1]) + "…"
+	}
+	ld := map[string]interface{}{
+		"@context":      "https://schema.org",
+		"@type":         "BlogPosting",
+		"headline":      headline,
+		"description":   description,
+		"url":           canonical,
+		"datePublished": post.CreatedAt.Format(time.RFC3339),
+		"dateModified":  post.UpdatedAt.Format(time.RFC3339),
+		"mainEntityOfPage": map[string]interface{}{
+			"@type": "WebPage",
+			"@id":   canonical,
+		},
+		"author": map[string]interface{}{
+			"@type": "Person",
+			"name":  authorName(&post.User),
+		},
+		"publisher": map[string]interface{}{
+			"@type": "Organization",
+			"name":  site.Title,
+			"url":   strings.TrimRight(site.URL, "/") + "/",
+		},
+	}
+	if lang := PostLang(post); lang != "" {
+		ld["inLanguage"] = lang
+	}
+	if image != nil {
+		ld["image"] = []string{image.URL}
+	}
+	if post.Category.Name != "" {
+		ld["articleSection"] = post.Category.Name
+	}
+	if len(post.Tags) > 0 {
+		keywords := make([]string, len(post.Tags))
+		for i, tag := range post.Tags {
+			keywords[i] = tag.Name
+		}
+		ld["keywords"] = strings.Join(keywords, ", ")
+	}
+	return ld
+}
+
+// renderHeadHTML 将元数据渲染为 <head> 中的 HTML 片段。
+func renderHeadHTML(meta *PostMetaDTO) (string, error) {
+	var b strings.Builder
+	b.WriteString("<title>" + html.EscapeString(meta.Title) + "</title>\n")
+	b.WriteString(`<link rel="canonical" href="` + html.EscapeString(meta.CanonicalURL) + "\">\n")
+	for _, alt := range meta.Alternates {
+		b.WriteString(`<link rel="alternate" hreflang="` + html.EscapeString(alt.Lang) + `" href="` + html.EscapeString(alt.Href) + "\">\n")
+	}
+	for _, tag := range meta.Tags {
+		if tag.Property != "" {
+			b.WriteString(`<meta property="` + html.EscapeString(tag.Property) + `" content="` + html.EscapeString(tag.Content) + "\">\n")
+		} else {
+			b.WriteString(`<meta name="` + html.EscapeString(tag.Name) + `" content="` + html.EscapeString(tag.Content) + "\">\n")
+		}
+	}
+	// json.Marshal 默认会把 <、>、& 转义为 \u003c 等形式，因此内容中的 </script> 不会提前结束标签
+	ld, err := json.Marshal(meta.JSONLD)
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(`<script type="application/ld+json">` + string(ld) + "</script>\n")
+	return b.String(), nil
+}
+
+// absoluteURL 将站内的相对地址（例如 /uploads/...）转换为基于站点地址的绝对地址。
+// 分享卡片和结构化数据中的地址必须是绝对地址。
+func absoluteURL(u string) string {
+	if strings.HasPrefix(u, "http://") || strings.HasPrefix(u, "https://") {
+		return u
+	}
+	if strings.HasPrefix(u, "//") {
+		return "https:" + u
+	}
+	return strings.TrimRight(config.Conf.Site.URL, "/") + "/" + strings.TrimLeft(u, "/")
+}
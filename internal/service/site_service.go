@@ -0,0 +1,255 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/util"
+	"gorm.io/gorm"
+)
+
+// SiteService 结构体封装了为服务端渲染的公开站点准备页面数据的业务逻辑。
+// 它只负责查询和组装数据，页面的渲染由主题完成。
+type SiteService struct {
+	postService *PostService
+	seoService  *SEOService
+	// pageURL 生成列表页第 N 页的路径
+	pageURL func(path string, page int) string
+}
+
+// NewSiteService 是 SiteService 的工厂函数，翻页链接使用 ?page=N 参数。
+func NewSiteService() *SiteService {
+	return &SiteService{
+		postService: NewPostService(),
+		seoService:  NewSEOService(),
+		pageURL:     pageURL,
+	}
+}
+
+// NewStaticSiteService 创建一个用于导出静态站点的 SiteService。
+// 静态文件服务器无法处理查询参数，因此翻页链接使用 /page/N/ 形式的路径。
+func NewStaticSiteService() *SiteService {
+	s := NewSiteService()
+	s.pageURL = StaticPageURL
+	return s
+}
+
+// SiteInfoDTO 是所有页面共用的站点信息。
+type SiteInfoDTO struct {
+	Title       string
+	Description string
+	URL         string
+	Language    string
+	Webmention  string // Webmention 接收地址，未启用时为空
+	Micropub    string // Micropub 接口地址，未启用时为空
+}
+
+// PaginationDTO 是列表页的分页信息。
+type PaginationDTO struct {
+	Page       int
+	TotalPages int
+	Total      int64
+	PrevURL    string // 没有上一页时为空
+	NextURL    string // 没有下一页时为空
+}
+
+// SitePageDTO 是传给主题模板的页面数据，不同页面只会填充其中的一部分字段。
+type SitePageDTO struct {
+	Site         SiteInfoDTO
+	Title        string // 页面标题，不含站点名称；首页为空
+	Description  string
+	CanonicalURL string
+	Head         string // 预先渲染好的 <head> 片段（文章页的 SEO 元数据），为空时由布局自行输出标题等信息
+
+	Posts      []model.Post      // 首页、分类页、标签页
+	Pagination *PaginationDTO    // 首页、分类页、标签页
+	Post       *model.Post       // 文章页
+	Category   *model.Category   // 分类页
+	Tag        *model.Tag        // 标签页
+	Archive    []ArchiveMonthDTO // 归档页
+}
+
+// newPage 创建一个填充了站点信息的页面。
+func newPage(title string) *SitePageDTO {
+	site := config.Conf.Site
+	return &SitePageDTO{
+		Site: SiteInfoDTO{
+			Title:       site.Title,
+			Description: site.Description,
+			URL:         strings.TrimRight(site.URL, "/"),
+			Language:    site.Language,
+			Webmention:  WebmentionEndpoint(),
+			Micropub:    MicropubEndpoint(),
+		},
+		Title: title,
+	}
+}
+
+// Home 用于获取首页第 page 页的数据。
+func (s *SiteService) Home(page int) (*SitePageDTO, error) {
+	p := newPage("")
+	p.Description = config.Conf.Site.Description
+	p.CanonicalURL = p.Site.URL + "/"
+	if err := s.fillPosts(p, &ListPublishedDTO{}, "/", page); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Category 用于获取分类页第 page 页的数据。
+func (s *SiteService) Category(id uint, page int) (*SitePageDTO, error) {
+	var category model.Category
+	if err := dao.GetDB().First(&category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSitePageNotFound
+		}
+		return nil, err
+	}
+	p := newPage(category.Name)
+	p.Category = &category
+	p.CanonicalURL = CategoryURL(category.ID)
+	if err := s.fillPosts(p, &ListPublishedDTO{CategoryID: id}, "/categories/"+strconv.FormatUint(uint64(id), 10), page); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Tag 用于获取标签页第 page 页的数据。
+func (s *SiteService) Tag(id uint, page int) (*SitePageDTO, error) {
+	var tag model.Tag
+	if err := dao.GetDB().First(&tag, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSitePageNotFound
+		}
+		return nil, err
+	}
+	p := newPage(tag.Name)
+	p.Tag = &tag
+	p.CanonicalURL = TagURL(tag.ID)
+	if err := s.fillPosts(p, &ListPublishedDTO{TagID: id}, "/tags/"+strconv.FormatUint(uint64(id), 10), page); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Post 用于获取文章页的数据。草稿不对外展示。
+func (s *SiteService) Post(id uint) (*SitePageDTO, error) {
+	var count int64
+	if err := dao.GetDB().Model(&model.Post{}).Where("id = ? AND status = ?", id, 1).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrSitePageNotFound
+	}
+	post, err := s.postService.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	meta, err := s.seoService.PostMeta(id)
+	if err != nil {
+		return nil, err
+	}
+	// 页面上只链接到已发布的语言版本，分类和标签名称使用文章的语言
+	db := dao.GetDB()
+	if err := fillTranslations(db, post, true); err != nil {
+		return nil, err
+	}
+	localized := []model.Post{*post}
+	if err := localizeNames(db, meta.Lang, localized); err != nil {
+		return nil, err
+	}
+	post = &localized[0]
+
+	p := newPage(post.Title)
+	p.Site.Language = meta.Lang
+	p.Post = post
+	p.Description = meta.Description
+	p.CanonicalURL = meta.CanonicalURL
+	p.Head = meta.HTML
+	return p, nil
+}
+
+// Archive 用于获取归档页的数据。
+func (s *SiteService) Archive() (*SitePageDTO, error) {
+	archive, err := s.postService.Archive()
+	if err != nil {
+		return nil, err
+	}
+	p := newPage("归档")
+	p.CanonicalURL = p.Site.URL + "/archive"
+	p.Archive = archive
+	return p, nil
+}
+
+// NotFound 用于获取 404 页面的数据。
+func (s *SiteService) NotFound() *SitePageDTO {
+	return newPage("页面不存在")
+}
+
+// fillPosts 查询第 page 页的文章并填充分页信息，path 是列表页自身的路径，用于生成翻页链接。
+// 第一页之后的页码超出范围时返回 ErrSitePageNotFound。
+func (s *SiteService) fillPosts(p *SitePageDTO, dto *ListPublishedDTO, path string, page int) error {
+	pageSize := config.Conf.Theme.PageSize
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	total, err := s.postService.CountPublished(dto)
+	if err != nil {
+		return err
+	}
+	totalPages := int((total + int64(pageSize) - 1) / int64(pageSize))
+	if page > 1 && page > totalPages {
+		return ErrSitePageNotFound
+	}
+
+	dto.Limit = pageSize
+	dto.Offset = (page - 1) * pageSize
+	posts, err := s.postService.ListPublished(dto)
+	if err != nil {
+		return err
+	}
+	for i := range posts {
+		// 列表页只展示摘要，提前截取可以让主题直接使用 Summary 字段
+		if strings.TrimSpace(posts[i].Summary) == "" {
+			posts[i].Summary = util.Excerpt(posts[i].Content, feedSummaryLength)
+		}
+	}
+
+	p.Posts = posts
+	p.Pagination = &PaginationDTO{Page: page, TotalPages: totalPages, Total: total}
+	if page > 1 {
+		p.Pagination.PrevURL = s.pageURL(path, page-1)
+	}
+	if page < totalPages {
+		p.Pagination.NextURL = s.pageURL(path, page+1)
+	}
+	if page > 1 {
+		p.Title = strings.TrimSpace(p.Title + " 第 " + strconv.Itoa(page) + " 页")
+		p.CanonicalURL = p.Site.URL + s.pageURL(path, page)
+	}
+	return nil
+}
+
+// pageURL 返回列表页第 page 页的路径，第一页不带页码参数。
+func pageURL(path string, page int) string {
+	if page <= 1 {
+		return path
+	}
+	return path + "?page=" + strconv.Itoa(page)
+}
+
+// StaticPageURL 返回静态站点中列表页第 page 页的路径，例如 /page/2/、/categories/3/page/2/，第一页为列表页自身的路径。
+func StaticPageURL(path string, page int) string {
+	if page <= 1 {
+		return path
+	}
+	return strings.TrimRight(path, "/") + "/page/" + strconv.Itoa(page) + "/"
+}
@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/sitemap"
+	"go.uber.org/zap"
+)
+
+// sitemapContentType 是站点地图的 HTTP Content-Type。
+const sitemapContentType = "application/xml; charset=utf-8"
+
+// SitemapService 结构体封装了生成站点地图和 robots.txt 的业务逻辑。
+// 站点地图包含首页、所有已发布的文章，以及至少有一篇已发布文章的分类和标签。
+type SitemapService struct{}
+
+// NewSitemapService 是 SitemapService 的工厂函数。
+func NewSitemapService() *SitemapService {
+	return &SitemapService{}
+}
+
+// Sitemap 用于生成 /sitemap.xml 的内容。
+// 地址数量不超过单个文件的上限时直接返回站点地图，否则返回指向各个分片的站点地图索引，
+// 分片的地址为 baseURL + "/sitemaps/sitemap-N.xml"（N 从 1 开始）。
+func (s *SitemapService) Sitemap(baseURL string) (*RenderedDocument, error) {
+	key := "index|" + baseURL
+	if doc, ok := _sitemapCache.get(key); ok {
+		return doc, nil
+	}
+	version := _sitemapCache.currentVersion()
+
+	chunks, err := s.chunks()
+	if err != nil {
+		return nil, err
+	}
+
+	var doc *RenderedDocument
+	if len(chunks) == 1 {
+		body, err := sitemap.URLSet(chunks[0])
+		if err != nil {
+			return nil, err
+		}
+		doc = newRenderedDocument(body, sitemapContentType, sitemap.LatestMod(chunks[0]))
+	} else {
+		entries := make([]sitemap.URL, len(chunks))
+		var latest time.Time
+		for i, chunk := range chunks {
+			entries[i] = sitemap.URL{
+				Loc:     fmt.Sprintf("%s/sitemaps/sitemap-%d.xml", strings.TrimRight(baseURL, "/"), i+1),
+				LastMod: sitemap.LatestMod(chunk),
+			}
+			if entries[i].LastMod.After(latest) {
+				latest = entries[i].LastMod
+			}
+		}
+		body, err := sitemap.Index(entries)
+		if err != nil {
+			return nil, err
+		}
+		doc = newRenderedDocument(body, sitemapContentType, latest)
+	}
+	_sitemapCache.set(key, doc, version)
+	return doc, nil
+}
+
+// Chunk 用于生成第 n 个（从 1 开始）站点地图分片。
+func (s *SitemapService) Chunk(n int) (*RenderedDocument, error) {
+	key := "chunk|" + strconv.Itoa(n)
+	if doc, ok := _sitemapCache.get(key); ok {
+		return doc, nil
+	}
+	version := _sitemapCache.currentVersion()
+
+	chunks, err := s.chunks()
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 || n > len(chunks) {
+		return nil, ErrSitemapNotFound
+	}
+	body, err := sitemap.URLSet(chunks[n-1])
+	if err != nil {
+		return nil, err
+	}
+	doc := newRenderedDocument(body, sitemapContentType, sitemap.LatestMod(chunks[n-1]))
+	_sitemapCache.set(key, doc, version)
+	return doc, nil
+}
+
+// chunks 查询站点地图中的所有地址，并按配置的大小拆分。
+func (s *SitemapService) chunks() ([][]sitemap.URL, error) {
+	urls, err := s.urls()
+	if err != nil {
+		return nil, err
+	}
+	return sitemap.Chunk(urls, config.Conf.Sitemap.ChunkSize), nil
+}
+
+// sitemapRow 是查询站点地图地址时使用的轻量结构，避免加载文章正文。
+type sitemapRow struct {
+	ID            uint
+	UpdatedAt     time.Time
+	Lang          string
+	TranslationOf *uint
+}
+
+// urls 查询站点地图中的所有地址。
+func (s *SitemapService) urls() ([]sitemap.URL, error) {
+	db := dao.GetDB()
+
+	var posts []sitemapRow
+	// 设置了 noindex 的文章不出现在站点地图中
+	if err := db.Model(&model.Post{}).Select("id, updated_at, lang, translation_of").
+		Where("status = ? AND no_index = ?", 1, false).Order("id ASC").Scan(&posts).Error; err != nil {
+		return nil, err
+	}
+
+	var categories []sitemapRow
+	if err := db.Model(&model.Category{}).Select("id, updated_at").
+		Where("EXISTS (SELECT 1 FROM posts WHERE posts.category_id = categories.id AND posts.status = ?)", 1).
+		Order("id ASC").Scan(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	var tags []sitemapRow
+	if err := db.Model(&model.Tag{}).Select("id, updated_at").
+		Where("EXISTS (SELECT 1 FROM post_tags JOIN posts ON posts.id = post_tags.post_id WHERE post_tags.tag_id = tags.id AND posts.status = ?)", 1).
+		Order("id ASC").Scan(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	urls := make([]sitemap.URL, 0, 1+len(posts)+len(categories)+len(tags))
+	// 首页的修改时间取最新文章的修改时间
+	home := sitemap.URL{Loc: strings.TrimRight(config.Conf.Site.URL, "/") + "/"}
+	for _, p := range posts {
+		if p.UpdatedAt.After(home.LastMod) {
+			home.LastMod = p.UpdatedAt
+		}
+	}
+	urls = append(urls, home)
+	for _, c := range categories {
+		urls = append(urls, sitemap.URL{Loc: CategoryURL(c.ID), LastMod: c.UpdatedAt})
+	}
+	for _, t := range tags {
+		urls = append(urls, sitemap.URL{Loc: TagURL(t.ID), LastMod: t.UpdatedAt})
+	}
+	alternates := postSitemapAlternates(posts)
+	for _, p := range posts {
+		urls = append(urls, sitemap.URL{Loc: PostURL(p.ID), LastMod: p.UpdatedAt, Alternates: alternates[p.ID]})
+	}
+	return urls, nil
+}
+
+// postSitemapAlternates 按原文对文章分组，返回有多个语言版本的文章 ID 到其所有语言版本（包括自身）的映射。
+// 只有出现在站点地图中的版本才会被列出。
+func postSitemapAlternates(posts []sitemapRow) map[uint][]sitemap.Alternate {
+	root := func(p sitemapRow) uint {
+		if p.TranslationOf != nil {
+			return *p.TranslationOf
+		}
+		return p.ID
+	}
+	groups := make(map[uint][]sitemap.Alternate)
+	for _, p := range posts {
+		lang := p.Lang
+		if lang == "" {
+			lang = DefaultContentLanguage()
+		}
+		// 没有配置站点语言时无法标注旧文章的语言
+		if lang == "" {
+			continue
+		}
+		groups[root(p)] = append(groups[root(p)], sitemap.Alternate{Lang: lang, Href: PostURL(p.ID)})
+	}
+	result := make(map[uint][]sitemap.Alternate)
+	for _, p := range posts {
+		if group := groups[root(p)]; len(group) > 1 {
+			result[p.ID] = group
+		}
+	}
+	return result
+}
+
+// CategoryURL 返回分类页面在站点（前端）上的地址。
+func CategoryURL(id uint) string {
+	return strings.TrimRight(config.Conf.Site.URL, "/") + "/categories/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// TagURL 返回标签页面在站点（前端）上的地址。
+func TagURL(id uint) string {
+	return strings.TrimRight(config.Conf.Site.URL, "/") + "/tags/" + strconv.FormatUint(uint64(id), 10)
+}
+
+// Robots 用于生成 robots.txt 的内容，sitemapURL 为站点地图的完整地址。
+func (s *SitemapService) Robots(sitemapURL string) string {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	disallow := config.Conf.Robots.Disallow
+	if len(disallow) == 0 {
+		// 空的 Disallow 表示允许抓取所有内容
+		b.WriteString("Disallow:\n")
+	}
+	for _, path := range disallow {
+		b.WriteString("Disallow: " + path + "\n")
+	}
+	b.WriteString("\nSitemap: " + sitemapURL + "\n")
+	if extra := strings.TrimSpace(config.Conf.Robots.Extra); extra != "" {
+		b.WriteString("\n" + extra + "\n")
+	}
+	return b.String()
+}
+
+// sitemapPingJob 在站点地图变化后通知搜索引擎，短时间内的多次变化会被合并为一次通知。
+var sitemapPingJob = NewJobType("sitemap.ping", JobOptions{Queue: JobQueueMaintenance}, pingSitemap)
+
+// scheduleSitemapPing 安排一次站点地图更新通知。未配置通知地址或站点地图公开地址时不做任何事。
+func scheduleSitemapPing() {
+	cfg := config.Conf.Sitemap
+	if len(cfg.PingURLs) == 0 || cfg.PublicURL == "" {
+		return
+	}
+	delay := cfg.PingDelay
+	if delay <= 0 {
+		delay = time.Minute
+	}
+	if err := sitemapPingJob.Debounce(dao.GetDB(), struct{}{}, delay); err != nil {
+		logger.L.Error("Failed to schedule sitemap ping", zap.Error(err))
+	}
+}
+
+// pingSitemap 依次请求配置的通知地址，有地址失败时返回错误，整个任务稍后重试。
+func pingSitemap(ctx context.Context, _ struct{}) error {
+	cfg := config.Conf.Sitemap
+	client := &http.Client{Timeout: 10 * time.Second}
+	var errs []error
+	for _, pingURL := range cfg.PingURLs {
+		target := strings.ReplaceAll(pingURL, "{sitemap}", url.QueryEscape(cfg.PublicURL))
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+		if err != nil {
+			// 地址本身无效，重试也不会成功
+			logger.L.Warn("Invalid sitemap ping URL", zap.String("url", target), zap.Error(err))
+			continue
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			logger.L.Warn("Failed to ping sitemap", zap.String("url", target), zap.Error(err))
+			errs = append(errs, err)
+			continue
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			logger.L.Warn("Sitemap ping rejected", zap.String("url", target), zap.Int("status", resp.StatusCode))
+			errs = append(errs, fmt.Errorf("%s 返回了 %d", target, resp.StatusCode))
+			continue
+		}
+		logger.L.Info("Sitemap ping sent", zap.String("url", target))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/spam"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// spamTokenMaxLength 是 spam_tokens 表中词的最大长度，更长的词不参与训练和打分。
+const spamTokenMaxLength = 128
+
+// spamFilter 是根据配置组装好的垃圾内容检测流水线，进程内只创建一次。
+type spamFilter struct {
+	pipeline *spam.Pipeline
+	bayes    *spam.Bayes
+	external spam.ExternalChecker
+	tokens   *spam.FormTokens
+}
+
+var (
+	_spamFilter     *spamFilter
+	_spamFilterOnce sync.Once
+)
+
+// getSpamFilter 返回全局的垃圾内容检测流水线。
+func getSpamFilter() *spamFilter {
+	_spamFilterOnce.Do(func() {
+		cfg := config.Conf.Spam
+		f := &spamFilter{
+			tokens: spam.NewFormTokens(config.Conf.JWTSecret),
+			bayes: &spam.Bayes{
+				Store:            dbBayesStore{},
+				MinDocs:          cfg.Bayes.MinDocs,
+				SpamThreshold:    cfg.Bayes.SpamThreshold,
+				SuspectThreshold: cfg.Bayes.SuspectThreshold,
+			},
+		}
+
+		var checkers []spam.Checker
+		if cfg.Honeypot {
+			checkers = append(checkers, spam.HoneypotChecker{})
+		}
+		checkers = append(checkers,
+			spam.MinSubmitTimeChecker{
+				Tokens:   f.tokens,
+				MinTime:  time.Duration(cfg.MinSubmitSeconds) * time.Second,
+				MaxAge:   cfg.FormTokenMaxAge,
+				Required: cfg.RequireToken,
+			},
+			spam.LinkCountChecker{Max: cfg.MaxLinks},
+			spam.BlocklistChecker{Words: cfg.BlockedWords, IPs: cfg.BlockedIPs, Emails: cfg.BlockedEmails},
+		)
+		if cfg.Bayes.Enabled {
+			checkers = append(checkers, f.bayes)
+		}
+		external, err := spam.NewExternalChecker(cfg.External.Driver, cfg.External.APIKey, config.Conf.Site.URL)
+		if err != nil {
+			logger.L.Error("Failed to initialize external spam checker", zap.Error(err))
+		} else if external != nil {
+			f.external = external
+			checkers = append(checkers, external)
+		}
+
+		f.pipeline = spam.NewPipeline(checkers...)
+		f.pipeline.OnError = func(name string, err error) {
+			logger.L.Warn("Spam checker failed", zap.String("checker", name), zap.Error(err))
+		}
+		_spamFilter = f
+	})
+	return _spamFilter
+}
+
+// SpamService 结构体封装了垃圾内容检测和分类器训练的业务逻辑。
+type SpamService struct{}
+
+// NewSpamService 是 SpamService 的工厂函数。
+func NewSpamService() *SpamService {
+	return &SpamService{}
+}
+
+// FormTokenDTO 是签发给前端的表单令牌。
+type FormTokenDTO struct {
+	Token            string `json:"token"`
+	MinSubmitSeconds int    `json:"min_submit_seconds"`
+}
+
+// FormToken 签发一个表单令牌，前端在展示评论或注册表单时获取，提交时通过 form_token 字段带回。
+func (s *SpamService) FormToken() *FormTokenDTO {
+	return &FormTokenDTO{
+		Token:            getSpamFilter().tokens.Issue(time.Now()),
+		MinSubmitSeconds: config.Conf.Spam.MinSubmitSeconds,
+	}
+}
+
+// Check 检测一次提交，未启用垃圾内容检测时总是通过。
+func (s *SpamService) Check(sub *spam.Submission) *spam.Result {
+	if !config.Conf.Spam.Enabled {
+		return &spam.Result{Verdict: spam.Pass, Score: -1}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result := getSpamFilter().pipeline.Check(ctx, sub)
+	if result.Verdict != spam.Pass {
+		logger.L.Info("Spam check flagged submission",
+			zap.String("kind", sub.Kind),
+			zap.String("verdict", result.Verdict.String()),
+			zap.Strings("reasons", result.Reasons),
+			zap.String("ip", sub.IP),
+		)
+	}
+	return result
+}
+
+// commentSubmission 将评论转换为检测和训练使用的提交。
+func commentSubmission(comment *model.Comment) *spam.Submission {
+	sub := &spam.Submission{
+		Kind:        spam.KindComment,
+		Content:     comment.Content,
+		AuthorName:  comment.AuthorName,
+		AuthorEmail: comment.AuthorEmail,
+		AuthorURL:   comment.AuthorURL,
+		IP:          comment.IP,
+		UserAgent:   comment.UserAgent,
+		Permalink:   PostURL(comment.PostID),
+	}
+	if comment.User != nil {
+		sub.AuthorName = authorName(comment.User)
+		sub.AuthorEmail = comment.User.Email
+	}
+	return sub
+}
+
+// TrainComment 根据审核人员的判定训练分类器：spam 为 true 表示垃圾内容，否则为正常内容。
+// 之前以相反类别训练过的评论会先撤销原来的训练；配置了外部检测服务时同时向其反馈。
+func (s *SpamService) TrainComment(comment *model.Comment, isSpam bool) error {
+	target := model.SpamTrainedHam
+	if isSpam {
+		target = model.SpamTrainedSpam
+	}
+	if comment.SpamTrained == target {
+		return nil
+	}
+
+	f := getSpamFilter()
+	sub := commentSubmission(comment)
+	text := sub.Text()
+	if comment.SpamTrained != model.SpamTrainedNone {
+		if err := f.bayes.Untrain(text, comment.SpamTrained == model.SpamTrainedSpam); err != nil {
+			return err
+		}
+	}
+	if err := f.bayes.Train(text, isSpam); err != nil {
+		return err
+	}
+	if err := dao.GetDB().Model(comment).Update("spam_trained", target).Error; err != nil {
+		return err
+	}
+	// 将审核结果反馈给外部服务，帮助其改进判定
+	if f.external != nil && config.Conf.Spam.Enabled {
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := f.external.Report(ctx, sub, isSpam); err != nil {
+				logger.L.Warn("Failed to report to spam checker", zap.Uint("comment_id", comment.ID), zap.Error(err))
+			}
+		}()
+	}
+	return nil
+}
+
+// SpamStatsDTO 是贝叶斯分类器的训练情况。
+type SpamStatsDTO struct {
+	SpamDocs int64 `json:"spam_docs"`
+	HamDocs  int64 `json:"ham_docs"`
+	Tokens   int64 `json:"tokens"`
+	Active   bool  `json:"active"` // 训练数据是否已足够，分类器是否已开始打分
+}
+
+// Stats 返回贝叶斯分类器的训练情况。
+func (s *SpamService) Stats() (*SpamStatsDTO, error) {
+	db := dao.GetDB()
+	var stat model.SpamStat
+	if err := db.Where("id = ?", 1).Limit(1).Find(&stat).Error; err != nil {
+		return nil, err
+	}
+	var tokens int64
+	if err := db.Model(&model.SpamToken{}).Count(&tokens).Error; err != nil {
+		return nil, err
+	}
+	minDocs := config.Conf.Spam.Bayes.MinDocs
+	return &SpamStatsDTO{
+		SpamDocs: stat.SpamDocs,
+		HamDocs:  stat.HamDocs,
+		Tokens:   tokens,
+		Active:   config.Conf.Spam.Bayes.Enabled && stat.SpamDocs >= minDocs && stat.HamDocs >= minDocs && stat.SpamDocs > 0 && stat.HamDocs > 0,
+	}, nil
+}
+
+// dbBayesStore 是保存在数据库中的贝叶斯分类器训练数据。
+type dbBayesStore struct{}
+
+// Load 实现了 spam.BayesStore 接口。
+func (dbBayesStore) Load(tokens []string) (int64, int64, map[string]spam.TokenCount, error) {
+	db := dao.GetDB()
+	var stat model.SpamStat
+	if err := db.Where("id = ?", 1).Limit(1).Find(&stat).Error; err != nil {
+		return 0, 0, nil, err
+	}
+
+	tokens = filterSpamTokens(tokens)
+	counts := make(map[string]spam.TokenCount, len(tokens))
+	if len(tokens) == 0 {
+		return stat.SpamDocs, stat.HamDocs, counts, nil
+	}
+	var rows []model.SpamToken
+	if err := db.Where("token IN ?", tokens).Find(&rows).Error; err != nil {
+		return 0, 0, nil, err
+	}
+	for _, row := range rows {
+		counts[row.Token] = spam.TokenCount{Spam: row.Spam, Ham: row.Ham}
+	}
+	return stat.SpamDocs, stat.HamDocs, counts, nil
+}
+
+// Update 实现了 spam.BayesStore 接口。计数使用 INSERT ... ON DUPLICATE KEY UPDATE 原子地增减，且不会小于 0。
+func (dbBayesStore) Update(tokens []string, isSpam bool, delta int) error {
+	column := "ham"
+	docsColumn := "ham_docs"
+	if isSpam {
+		column = "spam"
+		docsColumn = "spam_docs"
+	}
+	initial := int64(0)
+	if delta > 0 {
+		initial = int64(delta)
+	}
+
+	tokens = filterSpamTokens(tokens)
+	return dao.GetDB().Transaction(func(tx *gorm.DB) error {
+		stat := model.SpamStat{ID: 1}
+		if isSpam {
+			stat.SpamDocs = initial
+		} else {
+			stat.HamDocs = initial
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				docsColumn:   gorm.Expr("GREATEST("+docsColumn+" + ?, 0)", delta),
+				"updated_at": time.Now(),
+			}),
+		}).Create(&stat).Error; err != nil {
+			return err
+		}
+
+		if len(tokens) == 0 {
+			return nil
+		}
+		rows := make([]model.SpamToken, len(tokens))
+		for i, token := range tokens {
+			rows[i].Token = token
+			if isSpam {
+				rows[i].Spam = initial
+			} else {
+				rows[i].Ham = initial
+			}
+		}
+		return tx.Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				column:       gorm.Expr("GREATEST("+column+" + ?, 0)", delta),
+				"updated_at": time.Now(),
+			}),
+		}).CreateInBatches(rows, 200).Error
+	})
+}
+
+// filterSpamTokens 去掉超过数据库列长度的词。
+func filterSpamTokens(tokens []string) []string {
+	filtered := tokens[:0:0]
+	for _, token := range tokens {
+		if token != "" && len(token) <= spamTokenMaxLength && !strings.ContainsRune(token, 0) {
+			filtered = append(filtered, token)
+		}
+	}
+	return filtered
+}
@@ -3,9 +3,15 @@ package service
 
 import (
 	"errors"
+	"math"
+	"sort"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/KeLes-Coding/gopress/internal/config"
 	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/event"
 	"github.com/KeLes-Coding/gopress/internal/model"
 	"gorm.io/gorm"
 )
@@ -18,73 +24,453 @@ func NewTagService() *TagService {
 	return &TagService{}
 }
 
+// TagStore 是 TagHandler 和 gRPC 的 TagService 依赖的标签操作，由 TagService 实现。
+// 测试中可以替换为不访问数据库的实现，用同一份数据比较两种接口的结果。
+type TagStore interface {
+	Create(name string) (*model.Tag, error)
+	List() ([]model.Tag, error)
+	Update(id uint, name string) (*model.Tag, error)
+	Delete(id uint) error
+	SetTranslations(id uint, translations map[string]string) (*model.Tag, error)
+	Merge(dto *MergeTagsDTO) (*model.Tag, error)
+	ListWithUsage() ([]TagUsageDTO, error)
+	Cloud(limit int) ([]TagCloudItemDTO, error)
+	Suggest(prefix string, limit int) ([]model.Tag, error)
+}
+
+// defaultTagMaxLength 是未配置 tag.max_length 时标签名允许的最大字符数，与数据库列长度保持一致。
+const defaultTagMaxLength = 100
+
+// NormalizeTagName 按照配置中的规则规范化标签名称。
+// 它会去除首尾空白、折叠连续空白，并根据配置转换大小写和替换空白。
+func NormalizeTagName(name string) string {
+	rules := config.Conf.Tag
+	name = strings.Join(strings.Fields(name), " ")
+	if rules.SpaceReplacement != "" {
+		name = strings.ReplaceAll(name, " ", rules.SpaceReplacement)
+	}
+	if rules.Lowercase {
+		name = strings.ToLower(name)
+	}
+	return name
+}
+
+// validateTagName 校验规范化之后的标签名称是否合法。
+func validateTagName(name string) error {
+	if name == "" {
+		return ErrTagNameEmpty
+	}
+	maxLength := config.Conf.Tag.MaxLength
+	if maxLength <= 0 || maxLength > defaultTagMaxLength {
+		maxLength = defaultTagMaxLength
+	}
+	if utf8.RuneCountInString(name) > maxLength {
+		return ErrTagNameTooLong.WithArgs(name, maxLength)
+	}
+	return nil
+}
+
 // Create 用于创建一个新的标签。
 func (s *TagService) Create(name string) (*model.Tag, error) {
-	trimmedName := strings.TrimSpace(name)
-	if trimmedName == "" {
-		return nil, errors.New("抱歉名称不能为空")
+	normalizedName := NormalizeTagName(name)
+	if err := validateTagName(normalizedName); err != nil {
+		return nil, err
 	}
 
 	db := dao.GetDB()
 	var existingTag model.Tag
-	if err := db.Where("name = ?", trimmedName).First(&existingTag).Error; err == nil {
-		return nil, errors.New("该标签名称已存在")
+	// 标签名称的重复判断不区分大小写，避免出现 "Go" 和 "go" 这样的重复标签
+	if err := db.Where("LOWER(name) = LOWER(?)", normalizedName).First(&existingTag).Error; err == nil {
+		return nil, ErrTagNameTaken
 	}
 
-	newTag := &model.Tag{Name: trimmedName}
+	newTag := &model.Tag{Name: normalizedName}
 	if err := db.Create(newTag).Error; err != nil {
 		return nil, err
 	}
 	return newTag, nil
 }
 
+// resolveTags 在给定的事务中将标签 ID 列表和标签名称列表解析为标签实体。
+// 所有 ID 都必须指向已存在的标签；名称会先规范化，再不区分大小写地匹配已有标签，
+// 不存在的标签会在同一个事务中被创建。返回的结果已按 ID 去重。
+func resolveTags(tx *gorm.DB, tagIDs []uint, tagNames []string) ([]model.Tag, error) {
+	var tags []model.Tag
+
+	// 1. 校验所有 TagID 是否有效
+	if len(tagIDs) > 0 {
+		if err := tx.Find(&tags, tagIDs).Error; err != nil {
+			return nil, err
+		}
+		found := make(map[uint]bool, len(tags))
+		for _, tag := range tags {
+			found[tag.ID] = true
+		}
+		for _, id := range tagIDs {
+			if !found[id] {
+				return nil, ErrInvalidTagIDs
+			}
+		}
+	}
+
+	// 2. 规范化标签名称，并按小写形式去重
+	keys := make([]string, 0, len(tagNames))
+	names := make(map[string]string, len(tagNames))
+	for _, raw := range tagNames {
+		name := NormalizeTagName(raw)
+		if err := validateTagName(name); err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(name)
+		if _, ok := names[key]; ok {
+			continue
+		}
+		names[key] = name
+		keys = append(keys, key)
+	}
+
+	// 3. 查找已存在的同名标签，并创建缺失的标签
+	if len(keys) > 0 {
+		var existing []model.Tag
+		if err := tx.Where("LOWER(name) IN ?", keys).Find(&existing).Error; err != nil {
+			return nil, err
+		}
+		byKey := make(map[string]model.Tag, len(existing))
+		for _, tag := range existing {
+			byKey[strings.ToLower(tag.Name)] = tag
+		}
+		for _, key := range keys {
+			tag, ok := byKey[key]
+			if !ok {
+				tag = model.Tag{Name: names[key]}
+				if err := tx.Create(&tag).Error; err != nil {
+					return nil, err
+				}
+			}
+			tags = append(tags, tag)
+		}
+	}
+
+	// 4. 按 ID 去重，同一个标签可能同时通过 ID 和名称被引用
+	seen := make(map[uint]bool, len(tags))
+	result := make([]model.Tag, 0, len(tags))
+	for _, tag := range tags {
+		if seen[tag.ID] {
+			continue
+		}
+		seen[tag.ID] = true
+		result = append(result, tag)
+	}
+	return result, nil
+}
+
 // List 用于获取所有标签的列表。
 func (s *TagService) List() ([]model.Tag, error) {
 	db := dao.GetDB()
 	var tags []model.Tag
-	if err := db.Order("created_at DESC").Find(&tags).Error; err != nil {
+	if err := db.Preload("Translations").Order("created_at DESC").Find(&tags).Error; err != nil {
 		return nil, err
 	}
 	return tags, nil
 }
 
+// GetByIDs 用于批量获取标签及其名称的翻译，不存在的 ID 会被忽略，结果的顺序与 ids 无关。
+func (s *TagService) GetByIDs(ids []uint) ([]model.Tag, error) {
+	var tags []model.Tag
+	if len(ids) == 0 {
+		return tags, nil
+	}
+	err := dao.GetDB().Preload("Translations").Where("id IN ?", ids).Find(&tags).Error
+	return tags, err
+}
+
+// CountPublishedPosts 用于批量统计引用标签的已发布文章数，返回标签 ID 到文章数的映射，没有文章的标签不在其中。
+func (s *TagService) CountPublishedPosts(ids []uint) (map[uint]int64, error) {
+	if len(ids) == 0 {
+		return map[uint]int64{}, nil
+	}
+	var rows []idCountRow
+	if err := dao.GetDB().Table("post_tags").Select("post_tags.tag_id AS id, COUNT(*) AS count").
+		Joins("JOIN posts ON posts.id = post_tags.post_id").
+		Where("post_tags.tag_id IN ? AND posts.status = ?", ids, 1).
+		Group("post_tags.tag_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return countMap(rows), nil
+}
+
 // Update 用于更新一个已存在的标签。
 func (s *TagService) Update(id uint, name string) (*model.Tag, error) {
-	trimmedName := strings.TrimSpace(name)
-	if trimmedName == "" {
-		return nil, errors.New("标签名称不能为空")
+	normalizedName := NormalizeTagName(name)
+	if err := validateTagName(normalizedName); err != nil {
+		return nil, err
 	}
 
 	db := dao.GetDB()
 	var tag model.Tag
 	if err := db.First(&tag, id).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("该标签不存在")
+			return nil, ErrTagNotFound
 		}
 		return nil, err
 	}
 
 	var existingTag model.Tag
-	if err := db.Where("name = ? AND id != ?", trimmedName, id).First(&existingTag).Error; err == nil {
-		return nil, errors.New("该标签名称已存在")
+	if err := db.Where("LOWER(name) = LOWER(?) AND id != ?", normalizedName, id).First(&existingTag).Error; err == nil {
+		return nil, ErrTagNameTaken
 	}
 
-	tag.Name = trimmedName
-	if err := db.Save(&tag).Error; err != nil {
+	tag.Name = normalizedName
+	var out outbox
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&tag).Error; err != nil {
+			return err
+		}
+		return out.publish(tx, event.TagUpdated{Tag: &tag})
+	})
+	if err != nil {
 		return nil, err
 	}
+	out.flush()
 	return &tag, nil
 }
 
 // Delete 用于根据 ID 删除一个标签。
+// 删除标签的同时会清理 post_tags 中引用该标签的记录，避免留下悬空的关联。
 func (s *TagService) Delete(id uint) error {
 	db := dao.GetDB()
-	result := db.Delete(&model.Tag{}, id)
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return errors.New("该标签不存在")
+	var out outbox
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM post_tags WHERE tag_id = ?", id).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id = ?", id).Delete(&model.TagTranslation{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&model.Tag{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTagNotFound
+		}
+		return out.publish(tx, event.TagDeleted{ID: id})
+	})
+	if err != nil {
+		return err
 	}
+	out.flush()
 	return nil
 }
+
+// SetTranslations 用于设置标签名称在其他语言下的翻译，translations 是语言到名称的映射，会替换该标签现有的所有翻译。
+// 翻译与标签名称使用相同的规范化规则和长度限制，规范化后为空的翻译会被忽略。
+func (s *TagService) SetTranslations(id uint, translations map[string]string) (*model.Tag, error) {
+	names, err := normalizeNameTranslations(translations, NormalizeTagName, validateTagName)
+	if err != nil {
+		return nil, err
+	}
+
+	db := dao.GetDB()
+	var tag model.Tag
+	var out outbox
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&tag, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrTagNotFound
+			}
+			return err
+		}
+		if err := tx.Where("tag_id = ?", id).Delete(&model.TagTranslation{}).Error; err != nil {
+			return err
+		}
+		for lang, name := range names {
+			if err := tx.Create(&model.TagTranslation{TagID: id, Lang: lang, Name: name}).Error; err != nil {
+				return err
+			}
+		}
+		return out.publish(tx, event.TagUpdated{Tag: &tag})
+	})
+	if err != nil {
+		return nil, err
+	}
+	out.flush()
+
+	if err := db.Preload("Translations").First(&tag, id).Error; err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// MergeTagsDTO 封装了合并标签时需要的参数。
+type MergeTagsDTO struct {
+	SourceIDs []uint // 被合并的标签 ID 列表，合并完成后会被删除
+	TargetID  uint   // 合并的目标标签 ID
+}
+
+// Merge 用于将若干个源标签合并到目标标签中。
+// 所有引用源标签的文章都会改为引用目标标签，随后源标签被删除，整个过程在一个事务中完成。
+func (s *TagService) Merge(dto *MergeTagsDTO) (*model.Tag, error) {
+	// 过滤掉重复的 ID 以及与目标相同的 ID
+	seen := make(map[uint]bool, len(dto.SourceIDs))
+	sourceIDs := make([]uint, 0, len(dto.SourceIDs))
+	for _, id := range dto.SourceIDs {
+		if id == dto.TargetID || seen[id] {
+			continue
+		}
+		seen[id] = true
+		sourceIDs = append(sourceIDs, id)
+	}
+	if len(sourceIDs) == 0 {
+		return nil, ErrMergeSourcesEmpty
+	}
+
+	db := dao.GetDB()
+	var target model.Tag
+	var out outbox
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 1. 校验目标标签和源标签是否都存在
+		if err := tx.First(&target, dto.TargetID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrMergeTargetNotFound
+			}
+			return err
+		}
+		var count int64
+		if err := tx.Model(&model.Tag{}).Where("id IN ?", sourceIDs).Count(&count).Error; err != nil {
+			return err
+		}
+		if count != int64(len(sourceIDs)) {
+			return ErrInvalidMergeSources
+		}
+
+		// 2. 将源标签的文章关联重新指向目标标签。
+		// post_tags 以 (post_id, tag_id) 为联合主键，INSERT IGNORE 可以跳过已经同时拥有目标标签的文章。
+		if err := tx.Exec(
+			"INSERT IGNORE INTO post_tags (post_id, tag_id) SELECT post_id, ? FROM post_tags WHERE tag_id IN ?",
+			target.ID, sourceIDs,
+		).Error; err != nil {
+			return err
+		}
+
+		// 3. 删除源标签的旧关联、名称的翻译以及源标签本身，目标标签的翻译保持不变
+		if err := tx.Exec("DELETE FROM post_tags WHERE tag_id IN ?", sourceIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("tag_id IN ?", sourceIDs).Delete(&model.TagTranslation{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&model.Tag{}, sourceIDs).Error; err != nil {
+			return err
+		}
+		return out.publish(tx, event.TagsMerged{SourceIDs: sourceIDs, Target: &target})
+	})
+	if err != nil {
+		return nil, err
+	}
+	out.flush()
+	return &target, nil
+}
+
+// TagUsageDTO 描述了一个标签及其被文章引用的次数。
+type TagUsageDTO struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	PostCount int64     `json:"post_count"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ListWithUsage 用于获取所有标签及其被引用的文章数（包含草稿），供后台管理使用。
+func (s *TagService) ListWithUsage() ([]TagUsageDTO, error) {
+	db := dao.GetDB()
+	var usages []TagUsageDTO
+	// 使用 LEFT JOIN 保证没有任何文章引用的标签也会出现在结果中
+	err := db.Model(&model.Tag{}).
+		Select("tags.id, tags.name, COUNT(post_tags.post_id) AS post_count, tags.created_at, tags.updated_at").
+		Joins("LEFT JOIN post_tags ON post_tags.tag_id = tags.id").
+		Group("tags.id").
+		Order("post_count DESC, tags.name ASC").
+		Scan(&usages).Error
+	if err != nil {
+		return nil, err
+	}
+	return usages, nil
+}
+
+// tagCloudLevels 是标签云的权重等级数，权重取值范围为 [1, tagCloudLevels]。
+const tagCloudLevels = 5
+
+// TagCloudItemDTO 描述了标签云中的一个条目。
+type TagCloudItemDTO struct {
+	ID     uint   `json:"id"`
+	Name   string `json:"name"`
+	Count  int64  `json:"count"`  // 引用该标签的已发布文章数
+	Weight int    `json:"weight"` // 用于前端决定字号的权重等级
+}
+
+// Cloud 用于生成标签云，只统计已发布的文章。
+// limit 大于 0 时只返回引用次数最多的前 limit 个标签，结果按名称排序。
+func (s *TagService) Cloud(limit int) ([]TagCloudItemDTO, error) {
+	db := dao.GetDB()
+	var items []TagCloudItemDTO
+	query := db.Model(&model.Tag{}).
+		Select("tags.id, tags.name, COUNT(posts.id) AS count").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = post_tags.post_id AND posts.status = ?", model.PostStatusPublished).
+		Group("tags.id").
+		Order("count DESC, tags.name ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Scan(&items).Error; err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return items, nil
+	}
+
+	// 使用对数刻度计算权重，避免个别热门标签把其余标签全部压到最低一级
+	minCount, maxCount := items[0].Count, items[0].Count
+	for _, item := range items {
+		if item.Count < minCount {
+			minCount = item.Count
+		}
+		if item.Count > maxCount {
+			maxCount = item.Count
+		}
+	}
+	spread := math.Log(float64(maxCount)) - math.Log(float64(minCount))
+	for i := range items {
+		if spread == 0 {
+			items[i].Weight = 1
+			continue
+		}
+		ratio := (math.Log(float64(items[i].Count)) - math.Log(float64(minCount))) / spread
+		items[i].Weight = 1 + int(math.Round(ratio*float64(tagCloudLevels-1)))
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Name < items[j].Name
+	})
+	return items, nil
+}
+
+// Suggest 根据名称前缀返回匹配的标签，用于编辑器中的自动补全。
+func (s *TagService) Suggest(prefix string, limit int) ([]model.Tag, error) {
+	prefix = strings.TrimSpace(prefix)
+	tags := []model.Tag{}
+	if prefix == "" {
+		return tags, nil
+	}
+
+	// 转义 LIKE 中的通配符，防止用户输入的 % 和 _ 被当作通配符
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+
+	db := dao.GetDB()
+	if err := db.Where("name LIKE ?", escaped+"%").Order("name ASC").Limit(limit).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
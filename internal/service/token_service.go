@@ -0,0 +1,214 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"go.uber.org/zap"
+)
+
+// 个人访问令牌的权限范围，与 Micropub 规范中的 scope 名称一致。
+const (
+	ScopeCreate = "create" // 发布文章
+	ScopeUpdate = "update" // 修改文章
+	ScopeDelete = "delete" // 删除文章
+	ScopeMedia  = "media"  // 上传媒体文件
+	ScopeDraft  = "draft"  // 只能创建草稿，与 create 同时存在时以 create 为准
+)
+
+// AccessTokenScopes 是所有合法的权限范围。
+var AccessTokenScopes = []string{ScopeCreate, ScopeUpdate, ScopeDelete, ScopeMedia, ScopeDraft}
+
+// accessTokenPrefix 是令牌明文的固定前缀，便于在日志或代码中识别泄露的令牌。
+const accessTokenPrefix = "gp_"
+
+// accessTokenTouchInterval 是更新令牌最近使用时间的最小间隔，避免每个请求都写数据库。
+const accessTokenTouchInterval = time.Minute
+
+// TokenService 结构体封装了个人访问令牌的业务逻辑。
+type TokenService struct{}
+
+// NewTokenService 是 TokenService 的工厂函数。
+func NewTokenService() *TokenService {
+	return &TokenService{}
+}
+
+// CreateAccessTokenDTO 封装了创建令牌时需要的数据。
+type CreateAccessTokenDTO struct {
+	UserID    uint
+	Name      string
+	Scopes    []string
+	ExpiresIn int // 有效天数，0 表示永不过期
+}
+
+// AccessTokenDTO 是返回给前端的令牌信息，不包含令牌明文。
+type AccessTokenDTO struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Prefix     string     `json:"prefix"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreatedAccessTokenDTO 是创建令牌的结果，令牌明文只在此时返回一次。
+type CreatedAccessTokenDTO struct {
+	AccessTokenDTO
+	Token string `json:"token"`
+}
+
+// toAccessTokenDTO 将令牌模型转换为 DTO。
+func toAccessTokenDTO(token *model.AccessToken) AccessTokenDTO {
+	return AccessTokenDTO{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     strings.Fields(token.Scopes),
+		Prefix:     token.Prefix,
+		LastUsedAt: token.LastUsedAt,
+		ExpiresAt:  token.ExpiresAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+// hashAccessToken 返回令牌明文的 SHA-256 摘要。
+func hashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeScopes 校验权限范围并去除重复项。
+func normalizeScopes(scopes []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+	for _, scope := range scopes {
+		for _, s := range strings.Fields(scope) {
+			valid := false
+			for _, v := range AccessTokenScopes {
+				if s == v {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return nil, ErrInvalidTokenScope.WithArgs(s)
+			}
+			if !seen[s] {
+				seen[s] = true
+				result = append(result, s)
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil, ErrTokenScopesEmpty
+	}
+	return result, nil
+}
+
+// Create 为用户创建一个令牌。
+func (s *TokenService) Create(dto *CreateAccessTokenDTO) (*CreatedAccessTokenDTO, error) {
+	name := strings.TrimSpace(dto.Name)
+	if name == "" {
+		return nil, ErrTokenNameEmpty
+	}
+	scopes, err := normalizeScopes(dto.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if dto.ExpiresIn < 0 {
+		return nil, ErrTokenExpiryNegative
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	plain := accessTokenPrefix + base64.RawURLEncoding.EncodeToString(random)
+
+	token := &model.AccessToken{
+		UserID:    dto.UserID,
+		Name:      name,
+		TokenHash: hashAccessToken(plain),
+		Scopes:    strings.Join(scopes, " "),
+		Prefix:    plain[:len(accessTokenPrefix)+6],
+	}
+	if dto.ExpiresIn > 0 {
+		expiresAt := time.Now().AddDate(0, 0, dto.ExpiresIn)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := dao.GetDB().Create(token).Error; err != nil {
+		return nil, err
+	}
+	return &CreatedAccessTokenDTO{AccessTokenDTO: toAccessTokenDTO(token), Token: plain}, nil
+}
+
+// List 获取用户的所有令牌，最近创建的排在前面。
+func (s *TokenService) List(userID uint) ([]AccessTokenDTO, error) {
+	var tokens []model.AccessToken
+	if err := dao.GetDB().Where("user_id = ?", userID).Order("id DESC").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	result := make([]AccessTokenDTO, 0, len(tokens))
+	for i := range tokens {
+		result = append(result, toAccessTokenDTO(&tokens[i]))
+	}
+	return result, nil
+}
+
+// Revoke 撤销用户的一个令牌。
+func (s *TokenService) Revoke(userID, id uint) error {
+	result := dao.GetDB().Where("id = ? AND user_id = ?", id, userID).Delete(&model.AccessToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+// IsAccessToken 根据前缀判断 Bearer 令牌是否为个人访问令牌，用于同时接受 JWT 和个人访问令牌的接口。
+func IsAccessToken(plain string) bool {
+	return strings.HasPrefix(plain, accessTokenPrefix)
+}
+
+// Authenticate 校验令牌明文，返回预加载了所有者的令牌。
+func (s *TokenService) Authenticate(plain string) (*model.AccessToken, error) {
+	if !IsAccessToken(plain) {
+		return nil, ErrInvalidAccessToken
+	}
+	db := dao.GetDB()
+	var token model.AccessToken
+	if err := db.Preload("User").Where("token_hash = ?", hashAccessToken(plain)).Limit(1).Find(&token).Error; err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if token.ID == 0 || token.User == nil || (token.ExpiresAt != nil && now.After(*token.ExpiresAt)) {
+		return nil, ErrInvalidAccessToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= accessTokenTouchInterval {
+		token.LastUsedAt = &now
+		if err := db.Model(&token).UpdateColumn("last_used_at", now).Error; err != nil {
+			logger.L.Warn("Failed to update access token last used time", zap.Uint("id", token.ID), zap.Error(err))
+		}
+	}
+	return &token, nil
+}
+
+// TokenHasScope 判断令牌是否拥有指定的权限范围。
+func TokenHasScope(token *model.AccessToken, scope string) bool {
+	for _, s := range strings.Fields(token.Scopes) {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,309 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"gorm.io/gorm"
+)
+
+// 文章的多语言：每个语言版本都是一篇独立的文章，有自己的 ID 和永久链接，翻译通过 TranslationOf 指向原文。
+// 分类和标签只翻译名称，翻译存放在单独的表中，缺少翻译时使用原名称。
+// 请求的语言没有对应的文章版本时，依次回退到站点的默认语言版本和原文。
+
+// ContentLanguages 返回文章可以使用的语言，默认语言排在第一位。
+func ContentLanguages() []string {
+	site := config.Conf.Site
+	langs := make([]string, 0, len(site.Languages)+1)
+	if site.Language != "" {
+		langs = append(langs, site.Language)
+	}
+	for _, lang := range site.Languages {
+		if lang != "" && !strings.EqualFold(lang, site.Language) {
+			langs = append(langs, lang)
+		}
+	}
+	return langs
+}
+
+// DefaultContentLanguage 返回站点的默认语言，没有配置时返回空字符串。
+func DefaultContentLanguage() string {
+	if langs := ContentLanguages(); len(langs) > 0 {
+		return langs[0]
+	}
+	return ""
+}
+
+// MatchContentLanguage 返回与语言标签 tag 对应的文章语言，比较时不区分大小写。
+// 没有完全相同的语言时，en-US 可以匹配 en，zh 可以匹配 zh-CN。
+func MatchContentLanguage(tag string) (string, bool) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", false
+	}
+	langs := ContentLanguages()
+	for _, lang := range langs {
+		if strings.EqualFold(lang, tag) {
+			return lang, true
+		}
+	}
+	primary := func(s string) string {
+		s, _, _ = strings.Cut(strings.ReplaceAll(s, "_", "-"), "-")
+		return s
+	}
+	for _, lang := range langs {
+		if strings.EqualFold(primary(lang), primary(tag)) {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// normalizeContentLang 校验并规范化文章的语言，为空时使用站点的默认语言。
+func normalizeContentLang(lang string) (string, error) {
+	if strings.TrimSpace(lang) == "" {
+		return DefaultContentLanguage(), nil
+	}
+	matched, ok := MatchContentLanguage(lang)
+	if !ok {
+		return "", ErrUnsupportedContentLanguage.WithArgs(lang)
+	}
+	return matched, nil
+}
+
+// PostLang 返回文章的语言。升级前创建的文章没有记录语言，视为站点的默认语言。
+func PostLang(post *model.Post) string {
+	if post.Lang != "" {
+		return post.Lang
+	}
+	return DefaultContentLanguage()
+}
+
+// whereLang 为查询文章的语句添加语言条件，column 是语言列的名称，例如 posts.lang。
+// 查询默认语言时，同时包含没有记录语言的文章。
+func whereLang(query *gorm.DB, column, lang string) *gorm.DB {
+	if strings.EqualFold(lang, DefaultContentLanguage()) {
+		return query.Where(column+" IN ?", []string{lang, ""})
+	}
+	return query.Where(column+" = ?", lang)
+}
+
+// translationRoot 返回文章所属的原文 ID，原文返回自身的 ID。
+func translationRoot(post *model.Post) uint {
+	if post.TranslationOf != nil {
+		return *post.TranslationOf
+	}
+	return post.ID
+}
+
+// translationGroup 返回查询原文 root 的所有语言版本（包括原文本身）的语句。
+func translationGroup(db *gorm.DB, root uint) *gorm.DB {
+	return db.Model(&model.Post{}).Where("id = ? OR translation_of = ?", root, root)
+}
+
+// checkTranslationSlot 检查原文 root 下是否已经有 lang 语言的版本，exclude 是需要排除的文章（更新时为文章自身）。
+func checkTranslationSlot(tx *gorm.DB, root uint, lang string, exclude uint) error {
+	var count int64
+	query := whereLang(translationGroup(tx, root), "lang", lang)
+	if exclude != 0 {
+		query = query.Where("id <> ?", exclude)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrTranslationExists.WithArgs(lang)
+	}
+	return nil
+}
+
+// fillTranslations 填充文章的其他语言版本。publishedOnly 为 true 时只包含已发布的版本，用于对外输出。
+func fillTranslations(db *gorm.DB, post *model.Post, publishedOnly bool) error {
+	var rows []model.Post
+	query := translationGroup(db, translationRoot(post)).Select("id, lang, title").
+		Where("id <> ?", post.ID).Order("id ASC")
+	if publishedOnly {
+		query = query.Where("status = ?", model.PostStatusPublished)
+	}
+	if err := query.Find(&rows).Error; err != nil {
+		return err
+	}
+	post.Translations = make([]model.PostTranslation, len(rows))
+	for i := range rows {
+		post.Translations[i] = model.PostTranslation{ID: rows[i].ID, Lang: PostLang(&rows[i]), Title: rows[i].Title}
+	}
+	return nil
+}
+
+// publishedAlternates 批量查询文章的其他已发布语言版本，返回文章 ID 到其他版本的映射，用于订阅源等对外输出。
+func publishedAlternates(db *gorm.DB, posts []model.Post) (map[uint][]model.PostTranslation, error) {
+	if len(posts) == 0 {
+		return nil, nil
+	}
+	roots := make([]uint, 0, len(posts))
+	for i := range posts {
+		roots = append(roots, translationRoot(&posts[i]))
+	}
+	var rows []model.Post
+	if err := db.Model(&model.Post{}).Select("id, lang, title, translation_of").
+		Where("status = ? AND (id IN ? OR translation_of IN ?)", 1, roots, roots).
+		Order("id ASC").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	groups := make(map[uint][]model.PostTranslation)
+	for i := range rows {
+		root := translationRoot(&rows[i])
+		groups[root] = append(groups[root], model.PostTranslation{ID: rows[i].ID, Lang: PostLang(&rows[i]), Title: rows[i].Title})
+	}
+	result := make(map[uint][]model.PostTranslation, len(posts))
+	for i := range posts {
+		for _, t := range groups[translationRoot(&posts[i])] {
+			if t.ID != posts[i].ID {
+				result[posts[i].ID] = append(result[posts[i].ID], t)
+			}
+		}
+	}
+	return result, nil
+}
+
+// PublishedTranslations 用于批量获取文章的其他已发布语言版本，返回文章 ID 到其他版本的映射，没有其他版本的文章不在其中。
+func (s *PostService) PublishedTranslations(ids []uint) (map[uint][]model.PostTranslation, error) {
+	if len(ids) == 0 {
+		return map[uint][]model.PostTranslation{}, nil
+	}
+	db := dao.GetDB()
+	var posts []model.Post
+	if err := db.Select("id, lang, translation_of").Where("id IN ?", ids).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+	return publishedAlternates(db, posts)
+}
+
+// GetTranslation 用于获取文章在 lang 语言下的版本，文章的详细信息与 GetByID 相同。
+// 没有该语言的版本时依次回退到默认语言的版本和原文，返回的文章的 Lang 是实际的语言。lang 为空时直接返回文章本身。
+func (s *PostService) GetTranslation(id uint, lang string) (*model.Post, error) {
+	post, err := s.GetByID(id)
+	if err != nil || lang == "" || strings.EqualFold(PostLang(post), lang) {
+		return post, err
+	}
+	if _, ok := MatchContentLanguage(lang); !ok {
+		return nil, ErrUnsupportedContentLanguage.WithArgs(lang)
+	}
+
+	db := dao.GetDB()
+	root := translationRoot(post)
+	for _, candidate := range []string{lang, DefaultContentLanguage()} {
+		var target model.Post
+		err := whereLang(translationGroup(db, root), "lang", candidate).Select("id").Order("id ASC").First(&target).Error
+		if err == nil {
+			if target.ID == post.ID {
+				return post, nil
+			}
+			return s.GetByID(target.ID)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+	}
+	if root == post.ID {
+		return post, nil
+	}
+	return s.GetByID(root)
+}
+
+// localizeNames 将文章的分类和标签名称替换为 lang 语言的翻译，没有翻译的名称保持不变。
+// lang 为空或是默认语言时不做任何事，原名称即是默认语言。
+func localizeNames(db *gorm.DB, lang string, posts []model.Post) error {
+	if lang == "" || strings.EqualFold(lang, DefaultContentLanguage()) || len(posts) == 0 {
+		return nil
+	}
+	var categoryIDs, tagIDs []uint
+	for i := range posts {
+		categoryIDs = append(categoryIDs, posts[i].CategoryID)
+		for _, tag := range posts[i].Tags {
+			tagIDs = append(tagIDs, tag.ID)
+		}
+	}
+
+	categoryNames := make(map[uint]string)
+	var categoryTranslations []model.CategoryTranslation
+	if err := db.Where("category_id IN ? AND lang = ?", categoryIDs, lang).Find(&categoryTranslations).Error; err != nil {
+		return err
+	}
+	for _, t := range categoryTranslations {
+		categoryNames[t.CategoryID] = t.Name
+	}
+
+	tagNames := make(map[uint]string)
+	if len(tagIDs) > 0 {
+		var tagTranslations []model.TagTranslation
+		if err := db.Where("tag_id IN ? AND lang = ?", tagIDs, lang).Find(&tagTranslations).Error; err != nil {
+			return err
+		}
+		for _, t := range tagTranslations {
+			tagNames[t.TagID] = t.Name
+		}
+	}
+
+	for i := range posts {
+		if name, ok := categoryNames[posts[i].CategoryID]; ok {
+			posts[i].Category.Name = name
+		}
+		for j := range posts[i].Tags {
+			if name, ok := tagNames[posts[i].Tags[j].ID]; ok {
+				posts[i].Tags[j].Name = name
+			}
+		}
+	}
+	return nil
+}
+
+// localizedCategoryName 返回分类在 lang 语言下的名称，没有翻译时返回原名称。
+func localizedCategoryName(db *gorm.DB, category *model.Category, lang string) (string, error) {
+	if lang == "" {
+		return category.Name, nil
+	}
+	var t model.CategoryTranslation
+	err := db.Where("category_id = ? AND lang = ?", category.ID, lang).Limit(1).Find(&t).Error
+	if err != nil || t.ID == 0 {
+		return category.Name, err
+	}
+	return t.Name, nil
+}
+
+// localizedTagName 返回标签在 lang 语言下的名称，没有翻译时返回原名称。
+func localizedTagName(db *gorm.DB, tag *model.Tag, lang string) (string, error) {
+	if lang == "" {
+		return tag.Name, nil
+	}
+	var t model.TagTranslation
+	err := db.Where("tag_id = ? AND lang = ?", tag.ID, lang).Limit(1).Find(&t).Error
+	if err != nil || t.ID == 0 {
+		return tag.Name, err
+	}
+	return t.Name, nil
+}
+
+// normalizeNameTranslations 规范化名称的翻译：语言必须是文章可以使用的语言，规范化后为空的名称表示删除该语言的翻译。
+// normalize 是名称的规范化函数，validate 校验规范化之后的名称。
+func normalizeNameTranslations(translations map[string]string, normalize func(string) string, validate func(string) error) (map[string]string, error) {
+	result := make(map[string]string, len(translations))
+	for lang, name := range translations {
+		matched, ok := MatchContentLanguage(lang)
+		if !ok {
+			return nil, ErrUnsupportedContentLanguage.WithArgs(lang)
+		}
+		name = normalize(name)
+		if name == "" {
+			continue
+		}
+		if err := validate(name); err != nil {
+			return nil, err
+		}
+		result[matched] = name
+	}
+	return result, nil
+}
@@ -4,12 +4,25 @@ import (
 	"errors"
 
 	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/event"
+	"github.com/KeLes-Coding/gopress/internal/i18n"
 	"github.com/KeLes-Coding/gopress/internal/model"
 	"github.com/KeLes-Coding/gopress/internal/util"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+// Actor 是执行操作的用户，用于判断能否修改属于其他用户的内容。
+type Actor struct {
+	UserID uint
+	Admin  bool // 管理员可以管理所有用户的内容
+}
+
+// CanManage 判断 a 能否管理属于 ownerID 的内容：只有内容的所有者和管理员可以。
+func (a Actor) CanManage(ownerID uint) bool {
+	return a.Admin || a.UserID == ownerID
+}
+
 // UserService 结构体封装了所有与用户相关的业务逻辑。
 type UserService struct{}
 
@@ -24,10 +37,10 @@ func NewUserService() *UserService {
 func (s *UserService) SignUp(username, password string, email string) error {
 	// 1. 参数校验
 	if len(username) < 4 || len(password) < 6 {
-		return errors.New("用户名长度不能少于4位，密码长度不能少于6位")
+		return ErrSignUpTooShort
 	}
 	if email == "" {
-		return errors.New(" 邮箱不能为空")
+		return ErrEmailRequired
 	}
 
 	// 2. 检查用户名是否存在
@@ -39,9 +52,9 @@ func (s *UserService) SignUp(username, password string, email string) error {
 	err := db.Where("username = ?", username).First(&user).Error
 	if err == nil {
 		// 如果 err 为 nil，说明找到了记录，用户名已存在。
-		return errors.New("用户名已存在")
+		return ErrUsernameTaken
 	} else if user.Email == email {
-		return errors.New("该邮箱已被注册")
+		return ErrEmailTaken
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
 		// 如果是一个非 "记录未找到" 的其他数据库错误，直接返回。
 		return err
@@ -65,18 +78,26 @@ func (s *UserService) SignUp(username, password string, email string) error {
 		// Nickname, Email, Role 等字段会使用其零值或数据库定义的默认值。
 	}
 
-	// 调用 GORM 的 Create 方法将新用户记录插入数据库。
-	if err := db.Create(&newUser).Error; err != nil {
+	// 调用 GORM 的 Create 方法将新用户记录插入数据库，注册事件随用户一起提交。
+	var out outbox
+	err = db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&newUser).Error; err != nil {
+			return err
+		}
+		return out.publish(tx, event.UserSignedUp{UserID: newUser.ID, Username: newUser.Username})
+	})
+	if err != nil {
 		return err
 	}
+	out.flush()
 
 	// 注册成功，返回 nil。
 	return nil
 }
 
-// Login 处理用户登录的业务逻辑
-// 成功是返回生成的 JWT ，失败时返回错误
-func (s *UserService) Login(username, password string) (string, error) {
+// Authenticate 校验用户名和密码，成功时返回对应的用户。
+// 除了登录接口，XML-RPC 等每次请求都携带用户名和密码的接口也使用它来认证。
+func (s *UserService) Authenticate(username, password string) (*model.User, error) {
 	// 1. 根据用户名查询用户
 	db := dao.GetDB()
 	var user model.User
@@ -84,10 +105,10 @@ func (s *UserService) Login(username, password string) (string, error) {
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			// 如果记录未找到，返回一个对用户更友好的错误信息
-			return "", errors.New("用户名或密码错误")
+			return nil, ErrInvalidCredentials
 		}
 		// 其他数据库错误
-		return "", err
+		return nil, err
 	}
 
 	// 2. 校验密码
@@ -97,17 +118,73 @@ func (s *UserService) Login(username, password string) (string, error) {
 	if err != nil {
 		// 如果密码不匹配，err 会是 bcrypt.ErrMismatchedHashAndPassword。
 		// 为了安全，我们同样返回一个模糊的错误提示。
-		return "", errors.New("用户名或密码错误")
+		return nil, ErrInvalidCredentials
 	}
+	return &user, nil
+}
 
-	// 3. 生成 JWT
+// Login 处理用户登录的业务逻辑
+// 成功是返回生成的 JWT ，失败时返回错误
+func (s *UserService) Login(username, password string) (string, error) {
+	// 1. 校验用户名和密码
+	user, err := s.Authenticate(username, password)
+	if err != nil {
+		return "", err
+	}
+
+	// 2. 生成 JWT
 	// 登陆成功，调用 util 包中的 GenerateToken 函数生成 token。
-	token, err := util.GenerateToken(user.ID, user.Username)
+	// 角色和接口语言写入 token，认证时不必每次查询数据库；角色变更在重新登录后生效。
+	token, err := util.GenerateToken(user)
 	if err != nil {
 		// 如果 token 生成失败，这是一个服务端内部错误
 		return "", err
 	}
 
-	// 4. 返回 JWT
+	// 3. 返回 JWT
 	return token, nil
 }
+
+// GetByIDs 用于批量获取用户，不存在的 ID 会被忽略，结果的顺序与 ids 无关。
+func (s *UserService) GetByIDs(ids []uint) ([]model.User, error) {
+	var users []model.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	err := dao.GetDB().Where("id IN ?", ids).Find(&users).Error
+	return users, err
+}
+
+// PreferredLanguage 返回用户设置的接口语言，没有设置时返回空字符串。
+func (s *UserService) PreferredLanguage(userID uint) (string, error) {
+	var user model.User
+	if err := dao.GetDB().Select("language").First(&user, userID).Error; err != nil {
+		return "", err
+	}
+	return user.Language, nil
+}
+
+// RefreshToken 按用户当前的数据重新签发 JWT，用于修改接口语言等 token 中记录的信息之后。
+func (s *UserService) RefreshToken(userID uint) (string, error) {
+	var user model.User
+	if err := dao.GetDB().First(&user, userID).Error; err != nil {
+		return "", err
+	}
+	return util.GenerateToken(&user)
+}
+
+// SetPreferredLanguage 设置用户的接口语言，language 必须是受支持的语言，为空表示跟随请求的 Accept-Language。
+// 返回实际保存的语言标签，例如传入 en-US 时保存为 en。
+func (s *UserService) SetPreferredLanguage(userID uint, language string) (string, error) {
+	if language != "" {
+		lang, ok := i18n.Match(language)
+		if !ok {
+			return "", ErrUnsupportedLanguage.WithArgs(language)
+		}
+		language = lang
+	}
+	if err := dao.GetDB().Model(&model.User{}).Where("id = ?", userID).Update("language", language).Error; err != nil {
+		return "", err
+	}
+	return language, nil
+}
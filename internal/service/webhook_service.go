@@ -0,0 +1,737 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/event"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/webmention"
+	"go.uber.org/zap"
+	"gorm.io/gorm/clause"
+)
+
+// Webhook 事件。文章事件只关心对外可见的变化：草稿的创建和修改不会触发事件。
+const (
+	WebhookPostPublished   = "post.published"   // 文章发布，包括创建时直接发布和草稿改为发布
+	WebhookPostUpdated     = "post.updated"     // 已发布的文章被修改
+	WebhookPostUnpublished = "post.unpublished" // 已发布的文章改回草稿
+	WebhookPostDeleted     = "post.deleted"     // 文章被删除
+	WebhookCommentCreated  = "comment.created"  // 收到新评论，不包括被判定为垃圾内容的评论
+)
+
+// WebhookEvents 是所有可以订阅的事件。
+var WebhookEvents = []string{
+	WebhookPostPublished, WebhookPostUpdated, WebhookPostUnpublished, WebhookPostDeleted, WebhookCommentCreated,
+}
+
+// 请求头的名称。接收方用 X-Gopress-Signature 校验请求，签名方式见 SignWebhookPayload。
+const (
+	WebhookEventHeader     = "X-Gopress-Event"
+	WebhookDeliveryHeader  = "X-Gopress-Delivery"
+	WebhookTimestampHeader = "X-Gopress-Timestamp"
+	WebhookSignatureHeader = "X-Gopress-Signature"
+)
+
+// 密钥的长度限制，不指定密钥时自动生成。
+const (
+	webhookSecretMinLength = 16
+	webhookSecretMaxLength = 100
+)
+
+// webhookResponseBodyLength 是投递日志中保存的响应正文的最大字符数。
+const webhookResponseBodyLength = 1000
+
+const webhookBatchSize = 20
+
+const webhookUserAgent = "gopress-webhook/1.0"
+
+// WebhookDeliveryStatusNames 是投递状态在接口中的名称。
+var WebhookDeliveryStatusNames = map[int]string{
+	model.WebhookDeliveryPending:   "pending",
+	model.WebhookDeliveryDelivered: "delivered",
+	model.WebhookDeliveryFailed:    "failed",
+}
+
+// ParseWebhookDeliveryStatus 将接口中的投递状态名称转换为数据库中的取值。
+func ParseWebhookDeliveryStatus(name string) (int, bool) {
+	return parseStatusName(WebhookDeliveryStatusNames, name)
+}
+
+// SignWebhookPayload 计算请求的签名：以密钥为 key，对 "{timestamp}.{请求体}" 计算 HMAC-SHA256，
+// 结果为 "sha256=" 加十六进制编码。timestamp 是 X-Gopress-Timestamp 请求头中的 Unix 秒数，
+// 接收方应同时检查它与当前时间的差距，拒绝过旧的请求以防止重放。
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WebhookService 结构体封装了 Webhook 订阅和投递相关的业务逻辑。
+type WebhookService struct{}
+
+// NewWebhookService 是 WebhookService 的工厂函数。
+func NewWebhookService() *WebhookService {
+	return &WebhookService{}
+}
+
+// WebhookDTO 是返回给前端的订阅信息。
+type WebhookDTO struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Active    bool      `json:"active"`
+	Secret    string    `json:"secret,omitempty"` // 签名密钥，只在创建和更换密钥时返回
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// toWebhookDTO 将订阅转换为 DTO，不包含密钥。
+func toWebhookDTO(w *model.Webhook) WebhookDTO {
+	return WebhookDTO{
+		ID:        w.ID,
+		Name:      w.Name,
+		URL:       w.URL,
+		Events:    strings.Fields(w.Events),
+		Active:    w.Active,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+// SaveWebhookDTO 封装了创建和修改订阅时需要的数据。
+type SaveWebhookDTO struct {
+	ID     uint // 修改时为订阅的 ID
+	Name   string
+	URL    string
+	Events []string
+	Active *bool  // 为 nil 时创建的订阅默认启用，修改时保持不变
+	Secret string // 为空时创建的订阅自动生成密钥，修改时保持不变
+}
+
+// normalizeWebhookEvents 校验事件名称并去除重复项。
+func normalizeWebhookEvents(events []string) ([]string, error) {
+	var result []string
+	seen := make(map[string]bool)
+	for _, e := range events {
+		valid := false
+		for _, v := range WebhookEvents {
+			if e == v {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, ErrWebhookEvent.WithArgs(e)
+		}
+		if !seen[e] {
+			seen[e] = true
+			result = append(result, e)
+		}
+	}
+	if len(result) == 0 {
+		return nil, ErrWebhookEventsEmpty
+	}
+	return result, nil
+}
+
+// applyWebhook 校验参数并写入订阅，返回新的密钥，没有更换密钥时返回空字符串。
+func applyWebhook(w *model.Webhook, dto *SaveWebhookDTO) (string, error) {
+	name := strings.TrimSpace(dto.Name)
+	if name == "" {
+		return "", ErrWebhookNameEmpty
+	}
+	if !webmention.ValidURL(dto.URL) || len(dto.URL) > webmentionURLMaxLength {
+		return "", ErrWebhookURL
+	}
+	events, err := normalizeWebhookEvents(dto.Events)
+	if err != nil {
+		return "", err
+	}
+
+	secret := dto.Secret
+	if secret == "" && w.ID == 0 {
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			return "", err
+		}
+		secret = base64.RawURLEncoding.EncodeToString(random)
+	}
+	if secret != "" {
+		if n := utf8.RuneCountInString(secret); n < webhookSecretMinLength || n > webhookSecretMaxLength {
+			return "", ErrWebhookSecretLength.WithArgs(webhookSecretMinLength, webhookSecretMaxLength)
+		}
+		w.Secret = secret
+	}
+
+	w.Name = name
+	w.URL = dto.URL
+	w.Events = strings.Join(events, " ")
+	if dto.Active != nil {
+		w.Active = *dto.Active
+	}
+	return secret, nil
+}
+
+// List 获取所有订阅，最近创建的排在前面。
+func (s *WebhookService) List() ([]WebhookDTO, error) {
+	var webhooks []model.Webhook
+	if err := dao.GetDB().Order("id DESC").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	result := make([]WebhookDTO, len(webhooks))
+	for i := range webhooks {
+		result[i] = toWebhookDTO(&webhooks[i])
+	}
+	return result, nil
+}
+
+// Create 创建一个订阅，返回的 DTO 中包含密钥。
+func (s *WebhookService) Create(dto *SaveWebhookDTO) (*WebhookDTO, error) {
+	webhook := &model.Webhook{Active: true}
+	secret, err := applyWebhook(webhook, dto)
+	if err != nil {
+		return nil, err
+	}
+	db := dao.GetDB()
+	if err := db.Create(webhook).Error; err != nil {
+		return nil, err
+	}
+	// active 有默认值，GORM 创建记录时会忽略零值，需要单独更新
+	if !webhook.Active {
+		if err := db.Model(webhook).Update("active", false).Error; err != nil {
+			return nil, err
+		}
+	}
+	result := toWebhookDTO(webhook)
+	result.Secret = secret
+	return &result, nil
+}
+
+// Update 修改一个订阅，更换了密钥时返回的 DTO 中包含新的密钥。
+func (s *WebhookService) Update(dto *SaveWebhookDTO) (*WebhookDTO, error) {
+	db := dao.GetDB()
+	var webhook model.Webhook
+	if err := db.Limit(1).Find(&webhook, dto.ID).Error; err != nil {
+		return nil, err
+	}
+	if webhook.ID == 0 {
+		return nil, ErrWebhookNotFound
+	}
+	secret, err := applyWebhook(&webhook, dto)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Save(&webhook).Error; err != nil {
+		return nil, err
+	}
+	result := toWebhookDTO(&webhook)
+	result.Secret = secret
+	return &result, nil
+}
+
+// Delete 删除一个订阅及其投递记录。
+func (s *WebhookService) Delete(id uint) error {
+	db := dao.GetDB()
+	if err := db.Where("webhook_id = ?", id).Delete(&model.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	result := db.Delete(&model.Webhook{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
+
+// ListWebhookDeliveriesDTO 定义了查询投递记录的参数。
+type ListWebhookDeliveriesDTO struct {
+	WebhookID uint
+	Page      int
+	PageSize  int
+	Status    *int // 按状态过滤，为空时返回全部
+}
+
+// WebhookDeliveryDTO 是一次投递的记录。列表中不包含请求体和响应正文，查看单条记录时才返回。
+type WebhookDeliveryDTO struct {
+	ID            uint       `json:"id"`
+	WebhookID     uint       `json:"webhook_id"`
+	Event         string     `json:"event"`
+	RedeliveryOf  *uint      `json:"redelivery_of"` // 手动重新投递时为原始记录的 ID
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"` // 等待重试时的下次投递时间
+	ResponseCode  int        `json:"response_code"`   // 最近一次响应的 HTTP 状态码，没有收到响应时为 0
+	Duration      int64      `json:"duration_ms"`     // 最近一次请求的耗时（毫秒）
+	LastError     string     `json:"last_error"`
+	DeliveredAt   *time.Time `json:"delivered_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+
+	Payload      json.RawMessage `json:"payload,omitempty"`       // 请求体
+	ResponseBody string          `json:"response_body,omitempty"` // 最近一次响应的正文，只保留开头部分
+}
+
+// toWebhookDeliveryDTO 将投递记录转换为 DTO，withBody 为 true 时包含请求体和响应正文。
+func toWebhookDeliveryDTO(d *model.WebhookDelivery, withBody bool) WebhookDeliveryDTO {
+	result := WebhookDeliveryDTO{
+		ID:           d.ID,
+		WebhookID:    d.WebhookID,
+		Event:        d.Event,
+		RedeliveryOf: d.RedeliveryOf,
+		Status:       WebhookDeliveryStatusNames[d.Status],
+		Attempts:     d.Attempts,
+		ResponseCode: d.ResponseCode,
+		Duration:     d.Duration,
+		LastError:    d.LastError,
+		DeliveredAt:  d.DeliveredAt,
+		CreatedAt:    d.CreatedAt,
+	}
+	if d.Status == model.WebhookDeliveryPending {
+		result.NextAttemptAt = &d.NextAttemptAt
+	}
+	if withBody {
+		result.Payload = json.RawMessage(d.Payload)
+		result.ResponseBody = d.ResponseBody
+	}
+	return result
+}
+
+// ListWebhookDeliveriesResponseDTO 封装了投递记录列表和总数。
+type ListWebhookDeliveriesResponseDTO struct {
+	Deliveries []WebhookDeliveryDTO `json:"deliveries"`
+	TotalCount int64                `json:"total_count"`
+}
+
+// ListDeliveries 分页查询一个订阅的投递记录，最近创建的排在前面。
+func (s *WebhookService) ListDeliveries(dto *ListWebhookDeliveriesDTO) (*ListWebhookDeliveriesResponseDTO, error) {
+	db := dao.GetDB()
+	var count int64
+	if err := db.Model(&model.Webhook{}).Where("id = ?", dto.WebhookID).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, ErrWebhookNotFound
+	}
+
+	query := db.Model(&model.WebhookDelivery{}).Where("webhook_id = ?", dto.WebhookID)
+	if dto.Status != nil {
+		query = query.Where("status = ?", *dto.Status)
+	}
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+	var deliveries []model.WebhookDelivery
+	offset := (dto.Page - 1) * dto.PageSize
+	if err := query.Omit("payload", "response_body").Order("id DESC").Limit(dto.PageSize).Offset(offset).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]WebhookDeliveryDTO, len(deliveries))
+	for i := range deliveries {
+		result[i] = toWebhookDeliveryDTO(&deliveries[i], false)
+	}
+	return &ListWebhookDeliveriesResponseDTO{Deliveries: result, TotalCount: totalCount}, nil
+}
+
+// GetDelivery 获取一条投递记录，包含请求体和最近一次响应的正文。
+func (s *WebhookService) GetDelivery(id uint) (*WebhookDeliveryDTO, error) {
+	var delivery model.WebhookDelivery
+	if err := dao.GetDB().Limit(1).Find(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	if delivery.ID == 0 {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	result := toWebhookDeliveryDTO(&delivery, true)
+	return &result, nil
+}
+
+// Redeliver 使用原来的请求体重新投递一次，不论原记录的状态如何。
+// 重新投递会创建一条新的记录，原记录保持不变，请求头中的签名使用订阅当前的密钥计算。
+func (s *WebhookService) Redeliver(id uint) (*WebhookDeliveryDTO, error) {
+	db := dao.GetDB()
+	var original model.WebhookDelivery
+	if err := db.Limit(1).Find(&original, id).Error; err != nil {
+		return nil, err
+	}
+	if original.ID == 0 {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	delivery := &model.WebhookDelivery{
+		WebhookID:     original.WebhookID,
+		Event:         original.Event,
+		Payload:       original.Payload,
+		RedeliveryOf:  &original.ID,
+		NextAttemptAt: time.Now(),
+	}
+	if err := db.Create(delivery).Error; err != nil {
+		return nil, err
+	}
+	wakeWebhookWorker()
+	result := toWebhookDeliveryDTO(delivery, true)
+	return &result, nil
+}
+
+// webhookPayload 是投递的请求体。
+type webhookPayload struct {
+	ID        string      `json:"id,omitempty"` // 事件的唯一标识，重试和重新投递时不变，接收方可以据此去重
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"` // 事件发生的时间
+	Data      interface{} `json:"data"`
+}
+
+// WebhookPostData 是文章事件的 data 字段。
+type WebhookPostData struct {
+	ID     uint   `json:"id"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`    // 文章的永久链接，站点地址未配置时只有路径
+	Lang   string `json:"lang"`   // 文章的语言
+	Status string `json:"status"` // 事件发生后文章的状态 (draft, published)，删除时为删除前的状态
+}
+
+// webhookPostData 返回文章事件的数据。
+func webhookPostData(post *model.Post) WebhookPostData {
+	status := "draft"
+	if post.Status == model.PostStatusPublished {
+		status = "published"
+	}
+	return WebhookPostData{ID: post.ID, Title: post.Title, URL: PostURL(post.ID), Lang: PostLang(post), Status: status}
+}
+
+// WebhookCommentData 是评论事件的 data 字段。
+type WebhookCommentData struct {
+	ID         uint   `json:"id"`
+	PostID     uint   `json:"post_id"`
+	PostURL    string `json:"post_url"`
+	ParentID   *uint  `json:"parent_id"`
+	AuthorName string `json:"author_name"`
+	Content    string `json:"content"`
+	Status     string `json:"status"` // 评论的审核状态 (pending, approved)
+}
+
+// 将领域事件转换为 Webhook 事件。
+func init() {
+	event.Subscribe("webhook", func(ctx context.Context, e event.PostCreated) error {
+		if e.Post.Status != model.PostStatusPublished {
+			return nil
+		}
+		return queueWebhook(ctx, WebhookPostPublished, webhookPostData(e.Post))
+	})
+	event.Subscribe("webhook", func(ctx context.Context, e event.PostUpdated) error {
+		wasPublished := e.Previous.Status == model.PostStatusPublished
+		switch {
+		case !wasPublished && e.Post.Status == model.PostStatusPublished:
+			return queueWebhook(ctx, WebhookPostPublished, webhookPostData(e.Post))
+		case wasPublished && e.Post.Status == model.PostStatusPublished:
+			return queueWebhook(ctx, WebhookPostUpdated, webhookPostData(e.Post))
+		case wasPublished:
+			return queueWebhook(ctx, WebhookPostUnpublished, webhookPostData(e.Post))
+		}
+		return nil
+	})
+	event.Subscribe("webhook", func(ctx context.Context, e event.PostDeleted) error {
+		return queueWebhook(ctx, WebhookPostDeleted, webhookPostData(e.Post))
+	})
+	event.Subscribe("webhook", func(ctx context.Context, e event.CommentCreated) error {
+		if e.Comment.Status == model.CommentStatusSpam {
+			return nil
+		}
+		return queueWebhook(ctx, WebhookCommentCreated, WebhookCommentData{
+			ID:         e.Comment.ID,
+			PostID:     e.Comment.PostID,
+			PostURL:    PostURL(e.Comment.PostID),
+			ParentID:   e.Comment.ParentID,
+			AuthorName: e.AuthorName,
+			Content:    e.Comment.Content,
+			Status:     CommentStatusNames[e.Comment.Status],
+		})
+	})
+}
+
+// queueWebhook 为订阅了事件 name 的所有启用的订阅创建投递，data 是请求体中的 data 字段。
+// 领域事件的去重键 (event.DedupKey) 作为请求体中的 id，同一事件重复调用时不会重复创建投递。
+func queueWebhook(ctx context.Context, name string, data interface{}) error {
+	if !config.Conf.Webhooks.Enabled {
+		return nil
+	}
+	db := dao.GetDB()
+	var webhooks []model.Webhook
+	if err := db.Select("id", "events").Where("active = ?", true).Find(&webhooks).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	key := event.DedupKey(ctx)
+	var eventKey *string
+	if key != "" {
+		eventKey = &key
+	}
+	var deliveries []model.WebhookDelivery
+	for _, w := range webhooks {
+		for _, e := range strings.Fields(w.Events) {
+			if e == name {
+				deliveries = append(deliveries, model.WebhookDelivery{WebhookID: w.ID, EventKey: eventKey, Event: name, NextAttemptAt: now})
+				break
+			}
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(webhookPayload{ID: key, Event: name, CreatedAt: now, Data: data})
+	if err != nil {
+		return err
+	}
+	for i := range deliveries {
+		deliveries[i].Payload = string(payload)
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error; err != nil {
+		return err
+	}
+	wakeWebhookWorker()
+	return nil
+}
+
+// WebhookWorker 在后台投递 Webhook，并按退避策略重试失败的投递。
+// 投递保存在数据库中，程序重启后会继续处理。
+type WebhookWorker struct {
+	client *http.Client
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	done   chan struct{}
+}
+
+var (
+	_webhookWorker   *WebhookWorker
+	_webhookWorkerMu sync.Mutex
+)
+
+// StartWebhookWorker 启动 Webhook 后台任务，它必须在数据库初始化之后调用，程序退出前应调用 Shutdown。
+func StartWebhookWorker() *WebhookWorker {
+	cfg := config.Conf.Webhooks
+	client := webmention.NewClient(cfg.Timeout, cfg.AllowPrivate)
+	// 接收方返回的重定向视为失败，避免 POST 请求被转换为 GET 请求后投递到其他地址
+	client.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &WebhookWorker{
+		client: client,
+		ctx:    ctx,
+		cancel: cancel,
+		wake:   make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	_webhookWorkerMu.Lock()
+	_webhookWorker = w
+	_webhookWorkerMu.Unlock()
+	go w.run()
+	return w
+}
+
+// wakeWebhookWorker 通知后台任务有新的投递，后台任务未启动时什么也不做。
+func wakeWebhookWorker() {
+	_webhookWorkerMu.Lock()
+	w := _webhookWorker
+	_webhookWorkerMu.Unlock()
+	if w == nil {
+		return
+	}
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Shutdown 停止后台任务，正在进行的请求会被取消，对应的投递在下次启动时重新处理。
+func (w *WebhookWorker) Shutdown(ctx context.Context) error {
+	w.cancel()
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 是后台任务的主循环。
+func (w *WebhookWorker) run() {
+	defer close(w.done)
+	interval := config.Conf.Webhooks.PollInterval
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		w.processDue()
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+	}
+}
+
+// processDue 处理所有已到期的投递，直到没有到期投递或后台任务被停止。
+func (w *WebhookWorker) processDue() {
+	db := dao.GetDB()
+	for w.ctx.Err() == nil {
+		var deliveries []model.WebhookDelivery
+		if err := db.Preload("Webhook").Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, time.Now()).
+			Order("next_attempt_at ASC").Limit(webhookBatchSize).Find(&deliveries).Error; err != nil {
+			logger.L.Error("Failed to load pending webhook deliveries", zap.Error(err))
+			return
+		}
+		if len(deliveries) == 0 {
+			return
+		}
+		for i := range deliveries {
+			if w.ctx.Err() != nil {
+				return
+			}
+			w.deliver(&deliveries[i])
+		}
+	}
+}
+
+// deliver 发送一次投递并记录结果。接收方返回 2xx 状态码视为成功，其他情况按退避策略重试。
+func (w *WebhookWorker) deliver(d *model.WebhookDelivery) {
+	cfg := config.Conf.Webhooks
+	timeout := cfg.Timeout + 5*time.Second
+
+	// 以尝试次数作为版本号取得租约，多个实例同时运行时只有一个能发送；
+	// 发送期间投递推迟到租约到期之后，实例中途退出时由其他实例在到期后重新发送
+	attempts := d.Attempts + 1
+	result := dao.GetDB().Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", d.ID, model.WebhookDeliveryPending, d.Attempts).
+		Updates(map[string]interface{}{"attempts": attempts, "next_attempt_at": time.Now().Add(timeout + time.Minute)})
+	if result.Error != nil {
+		logger.L.Error("Failed to claim webhook delivery", zap.Uint("delivery_id", d.ID), zap.Error(result.Error))
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	updates := map[string]interface{}{}
+	if d.Webhook == nil || !d.Webhook.Active {
+		updates["status"] = model.WebhookDeliveryFailed
+		updates["last_error"] = "订阅已停用"
+		updateWebhookDelivery(d.ID, attempts, updates)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(w.ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	code, body, err := w.post(ctx, d)
+	if err != nil && w.ctx.Err() != nil {
+		// 后台任务正在停止，不计入尝试次数，下次启动时立即重新发送
+		updateWebhookDelivery(d.ID, attempts, map[string]interface{}{"attempts": d.Attempts, "next_attempt_at": time.Now()})
+		return
+	}
+
+	now := time.Now()
+	updates["response_code"] = code
+	updates["response_body"] = truncateRunes(body, webhookResponseBodyLength)
+	updates["duration"] = now.Sub(start).Milliseconds()
+	if err == nil && (code < 200 || code > 299) {
+		err = fmt.Errorf("接收方返回了状态码 %d", code)
+	}
+	switch {
+	case err == nil:
+		updates["status"] = model.WebhookDeliveryDelivered
+		updates["delivered_at"] = now
+		updates["last_error"] = ""
+	case attempts >= cfg.MaxAttempts:
+		updates["status"] = model.WebhookDeliveryFailed
+		updates["last_error"] = truncateRunes(err.Error(), 500)
+	default:
+		updates["next_attempt_at"] = now.Add(webhookRetryDelay(attempts))
+		updates["last_error"] = truncateRunes(err.Error(), 500)
+	}
+	if err != nil {
+		logger.L.Info("Webhook not delivered", zap.Uint("webhook_id", d.WebhookID), zap.Uint("delivery_id", d.ID), zap.Error(err))
+	}
+	updateWebhookDelivery(d.ID, attempts, updates)
+}
+
+// updateWebhookDelivery 保存投递的结果。只有投递仍由本次尝试持有时才更新，
+// 租约到期后投递可能已被其他实例重新取得，此时以对方的结果为准。
+func updateWebhookDelivery(id uint, attempts int, updates map[string]interface{}) {
+	result := dao.GetDB().Model(&model.WebhookDelivery{}).
+		Where("id = ? AND status = ? AND attempts = ?", id, model.WebhookDeliveryPending, attempts).Updates(updates)
+	if result.Error != nil {
+		logger.L.Error("Failed to update webhook delivery", zap.Uint("delivery_id", id), zap.Error(result.Error))
+	} else if result.RowsAffected == 0 {
+		logger.L.Warn("Webhook delivery lease lost before completion", zap.Uint("delivery_id", id))
+	}
+}
+
+// post 发送投递的请求，返回响应的状态码和正文的开头部分。
+func (w *WebhookWorker) post(ctx context.Context, d *model.WebhookDelivery) (int, string, error) {
+	payload := []byte(d.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.Webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(WebhookEventHeader, d.Event)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatUint(uint64(d.ID), 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(d.Webhook.Secret, timestamp, payload))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	// 多读取一些字节，截断时按字符计算
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4*webhookResponseBodyLength))
+	return resp.StatusCode, strings.ToValidUTF8(string(body), ""), nil
+}
+
+// webhookRetryDelay 返回第 attempts 次失败后的重试间隔，每次翻倍，最长一天。
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := config.Conf.Webhooks.RetryDelay
+	if delay <= 0 {
+		delay = 30 * time.Second
+	}
+	for i := 1; i < attempts && delay < 24*time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > 24*time.Hour {
+		delay = 24 * time.Hour
+	}
+	return delay
+}
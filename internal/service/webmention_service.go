@@ -0,0 +1,734 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/dao"
+	"github.com/KeLes-Coding/gopress/internal/event"
+	"github.com/KeLes-Coding/gopress/internal/logger"
+	"github.com/KeLes-Coding/gopress/internal/model"
+	"github.com/KeLes-Coding/gopress/internal/webmention"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// webmentionURLMaxLength 是来源和目标地址的最大长度，与数据库列的长度一致。
+const webmentionURLMaxLength = 700
+
+// webmentionMaxLinks 是一篇文章最多通知的外部页面数。
+const webmentionMaxLinks = 100
+
+// webmentionBatchSize 是后台每次取出的待处理任务数。
+const webmentionBatchSize = 20
+
+// webmentionUserAgent 是抓取页面和发送通知时使用的 User-Agent。
+const webmentionUserAgent = "gopress-webmention/1.0"
+
+// WebmentionStatusNames 是审核状态与其名称的对应关系，用于接口的输入和输出。
+var WebmentionStatusNames = map[int]string{
+	model.WebmentionStatusPending:  "pending",
+	model.WebmentionStatusApproved: "approved",
+	model.WebmentionStatusRejected: "rejected",
+}
+
+// WebmentionVerificationNames 是校验状态与其名称的对应关系。
+var WebmentionVerificationNames = map[int]string{
+	model.WebmentionVerifyQueued:   "queued",
+	model.WebmentionVerifyVerified: "verified",
+	model.WebmentionVerifyFailed:   "failed",
+}
+
+// WebmentionSendStatusNames 是发出的通知的状态与其名称的对应关系。
+var WebmentionSendStatusNames = map[int]string{
+	model.WebmentionSendPending:    "pending",
+	model.WebmentionSendSent:       "sent",
+	model.WebmentionSendNoEndpoint: "no_endpoint",
+	model.WebmentionSendFailed:     "failed",
+}
+
+// parseStatusName 在 names 中查找 name 对应的状态值。
+func parseStatusName(names map[int]string, name string) (int, bool) {
+	for status, n := range names {
+		if n == name {
+			return status, true
+		}
+	}
+	return 0, false
+}
+
+// ParseWebmentionStatus 将审核状态名称转换为状态值。
+func ParseWebmentionStatus(name string) (int, bool) {
+	return parseStatusName(WebmentionStatusNames, name)
+}
+
+// ParseWebmentionVerification 将校验状态名称转换为状态值。
+func ParseWebmentionVerification(name string) (int, bool) {
+	return parseStatusName(WebmentionVerificationNames, name)
+}
+
+// ParseWebmentionSendStatus 将发出的通知的状态名称转换为状态值。
+func ParseWebmentionSendStatus(name string) (int, bool) {
+	return parseStatusName(WebmentionSendStatusNames, name)
+}
+
+// WebmentionEndpoint 返回本站对外声明的 Webmention 接收地址，未启用接收或无法确定地址时返回空字符串。
+func WebmentionEndpoint() string {
+	cfg := config.Conf.Webmention
+	if !cfg.Enabled {
+		return ""
+	}
+	if cfg.EndpointURL != "" {
+		return cfg.EndpointURL
+	}
+	if config.Conf.Theme.Enabled && config.Conf.Site.URL != "" {
+		return strings.TrimRight(config.Conf.Site.URL, "/") + "/webmention"
+	}
+	return ""
+}
+
+// WebmentionService 结构体封装了 Webmention 的接收、发送和审核的业务逻辑。
+type WebmentionService struct{}
+
+// NewWebmentionService 是 WebmentionService 的工厂函数。
+func NewWebmentionService() *WebmentionService {
+	return &WebmentionService{}
+}
+
+// Validate 校验收到的通知，返回被提到的文章 ID。
+// 返回的 *apperr.Error 都是请求本身的问题，应当以 400 响应给发送方，其他错误是查询数据库失败。
+func (s *WebmentionService) Validate(source, target string) (uint, error) {
+	if !webmention.ValidURL(source) || !webmention.ValidURL(target) {
+		return 0, ErrWebmentionURLScheme
+	}
+	if len(source) > webmentionURLMaxLength || len(target) > webmentionURLMaxLength {
+		return 0, ErrWebmentionURLTooLong
+	}
+	if source == target {
+		return 0, ErrWebmentionSameURL
+	}
+	if config.Conf.Site.URL == "" {
+		return 0, ErrWebmentionNoSiteURL
+	}
+	id, err := ParsePostURL(target)
+	if err != nil {
+		return 0, ErrWebmentionTarget.WithArgs(err)
+	}
+
+	var count int64
+	if err := dao.GetDB().Model(&model.Post{}).Where("id = ? AND status = ?", id, 1).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, ErrPostNotFound
+	}
+	return id, nil
+}
+
+// Receive 保存一条已通过 Validate 的通知，并安排后台校验来源页面。
+// 同一来源页面重复发送时不会产生新记录，而是重新校验，来源页面的更新或删除由此同步到本站。
+func (s *WebmentionService) Receive(postID uint, source, target, ip string) error {
+	now := time.Now()
+	mention := &model.Webmention{
+		PostID:        postID,
+		Source:        source,
+		Target:        target,
+		Verification:  model.WebmentionVerifyQueued,
+		NextAttemptAt: now,
+		IP:            ip,
+	}
+	err := dao.GetDB().Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"target":          target,
+			"verification":    model.WebmentionVerifyQueued,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"last_error":      "",
+			"ip":              ip,
+			"updated_at":      now,
+		}),
+	}).Create(mention).Error
+	if err != nil {
+		return err
+	}
+	wakeWebmentionWorker()
+	return nil
+}
+
+// WebmentionDTO 是对外展示的通知。
+type WebmentionDTO struct {
+	ID         uint      `json:"id"`
+	Type       string    `json:"type"`
+	Source     string    `json:"source"`
+	Title      string    `json:"title"`
+	Excerpt    string    `json:"excerpt"`
+	AuthorName string    `json:"author_name"`
+	AuthorURL  string    `json:"author_url"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PostWebmentionsDTO 是一篇文章下已通过的通知，以及按类型统计的数量。
+type PostWebmentionsDTO struct {
+	Mentions []WebmentionDTO `json:"mentions"`
+	Counts   map[string]int  `json:"counts"` // 例如 {"like": 3, "reply": 1}
+}
+
+// toWebmentionDTO 将通知模型转换为对外展示的 DTO。
+func toWebmentionDTO(m *model.Webmention) WebmentionDTO {
+	return WebmentionDTO{
+		ID:         m.ID,
+		Type:       m.Type,
+		Source:     m.Source,
+		Title:      m.Title,
+		Excerpt:    m.Excerpt,
+		AuthorName: m.AuthorName,
+		AuthorURL:  m.AuthorURL,
+		CreatedAt:  m.CreatedAt,
+	}
+}
+
+// ListForPost 返回文章下已校验且已通过审核的通知，按时间正序排列。
+func (s *WebmentionService) ListForPost(postID uint) (*PostWebmentionsDTO, error) {
+	var mentions []model.Webmention
+	err := dao.GetDB().
+		Where("post_id = ? AND status = ? AND verification = ?", postID, model.WebmentionStatusApproved, model.WebmentionVerifyVerified).
+		Order("created_at ASC").
+		Find(&mentions).Error
+	if err != nil {
+		return nil, err
+	}
+	result := &PostWebmentionsDTO{
+		Mentions: make([]WebmentionDTO, len(mentions)),
+		Counts:   make(map[string]int),
+	}
+	for i := range mentions {
+		result.Mentions[i] = toWebmentionDTO(&mentions[i])
+		result.Counts[mentions[i].Type]++
+	}
+	return result, nil
+}
+
+// ListWebmentionsDTO 定义了后台查询通知列表的参数。
+type ListWebmentionsDTO struct {
+	Page         int
+	PageSize     int
+	Status       *int // 按审核状态过滤，为空时返回全部
+	Verification *int // 按校验状态过滤，为空时返回全部
+	PostID       uint // 按文章过滤，为 0 时返回全部
+}
+
+// AdminWebmentionDTO 是后台审核时使用的通知，包含校验的详细信息。
+type AdminWebmentionDTO struct {
+	WebmentionDTO
+	Target       string     `json:"target"`
+	Status       string     `json:"status"`
+	Verification string     `json:"verification"`
+	Attempts     int        `json:"attempts"`
+	LastError    string     `json:"last_error"`
+	VerifiedAt   *time.Time `json:"verified_at"`
+	IP           string     `json:"ip"`
+	PostID       uint       `json:"post_id"`
+	PostTitle    string     `json:"post_title"`
+}
+
+// ListWebmentionsResponseDTO 封装了后台的通知列表和总数。
+type ListWebmentionsResponseDTO struct {
+	Mentions   []AdminWebmentionDTO `json:"mentions"`
+	TotalCount int64                `json:"total_count"`
+}
+
+// List 用于后台分页查询收到的通知，默认按时间倒序排列。
+func (s *WebmentionService) List(dto *ListWebmentionsDTO) (*ListWebmentionsResponseDTO, error) {
+	query := dao.GetDB().Model(&model.Webmention{})
+	if dto.Status != nil {
+		query = query.Where("status = ?", *dto.Status)
+	}
+	if dto.Verification != nil {
+		query = query.Where("verification = ?", *dto.Verification)
+	}
+	if dto.PostID != 0 {
+		query = query.Where("post_id = ?", dto.PostID)
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+
+	var mentions []model.Webmention
+	offset := (dto.Page - 1) * dto.PageSize
+	if err := query.Preload("Post", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id, title")
+	}).Order("created_at DESC").Limit(dto.PageSize).Offset(offset).Find(&mentions).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]AdminWebmentionDTO, len(mentions))
+	for i := range mentions {
+		m := &mentions[i]
+		result[i] = AdminWebmentionDTO{
+			WebmentionDTO: toWebmentionDTO(m),
+			Target:        m.Target,
+			Status:        WebmentionStatusNames[m.Status],
+			Verification:  WebmentionVerificationNames[m.Verification],
+			Attempts:      m.Attempts,
+			LastError:     m.LastError,
+			VerifiedAt:    m.VerifiedAt,
+			IP:            m.IP,
+			PostID:        m.PostID,
+		}
+		if m.Post != nil {
+			result[i].PostTitle = m.Post.Title
+		}
+	}
+	return &ListWebmentionsResponseDTO{
+		Mentions:   result,
+		TotalCount: totalCount,
+	}, nil
+}
+
+// Moderate 用于批量修改通知的审核状态，返回实际修改的条数。
+func (s *WebmentionService) Moderate(ids []uint, status int) (int64, error) {
+	if _, ok := WebmentionStatusNames[status]; !ok {
+		return 0, ErrInvalidWebmentionStatus
+	}
+	result := dao.GetDB().Model(&model.Webmention{}).Where("id IN ?", ids).Update("status", status)
+	return result.RowsAffected, result.Error
+}
+
+// Verify 用于重新校验一条通知，例如来源页面修复了链接之后。
+func (s *WebmentionService) Verify(id uint) error {
+	result := dao.GetDB().Model(&model.Webmention{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"verification":    model.WebmentionVerifyQueued,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+		"last_error":      "",
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebmentionNotFound
+	}
+	wakeWebmentionWorker()
+	return nil
+}
+
+// Delete 用于删除一条收到的通知。发送方再次发送时会重新创建。
+func (s *WebmentionService) Delete(id uint) error {
+	result := dao.GetDB().Delete(&model.Webmention{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebmentionNotFound
+	}
+	return nil
+}
+
+// ListWebmentionSendsDTO 定义了后台查询发出的通知的参数。
+type ListWebmentionSendsDTO struct {
+	Page     int
+	PageSize int
+	Status   *int // 按状态过滤，为空时返回全部
+	PostID   uint // 按文章过滤，为 0 时返回全部
+}
+
+// WebmentionSendDTO 是发出的一条通知及其发送情况。
+type WebmentionSendDTO struct {
+	ID            uint       `json:"id"`
+	PostID        uint       `json:"post_id"`
+	Target        string     `json:"target"`
+	Endpoint      string     `json:"endpoint"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt *time.Time `json:"next_attempt_at"` // 等待重试时的下次发送时间
+	LastError     string     `json:"last_error"`
+	SentAt        *time.Time `json:"sent_at"`
+}
+
+// ListWebmentionSendsResponseDTO 封装了发出的通知列表和总数。
+type ListWebmentionSendsResponseDTO struct {
+	Sends      []WebmentionSendDTO `json:"sends"`
+	TotalCount int64               `json:"total_count"`
+}
+
+// ListSends 用于后台分页查询发出的通知，默认按更新时间倒序排列。
+func (s *WebmentionService) ListSends(dto *ListWebmentionSendsDTO) (*ListWebmentionSendsResponseDTO, error) {
+	query := dao.GetDB().Model(&model.WebmentionSend{})
+	if dto.Status != nil {
+		query = query.Where("status = ?", *dto.Status)
+	}
+	if dto.PostID != 0 {
+		query = query.Where("post_id = ?", dto.PostID)
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, err
+	}
+	var sends []model.WebmentionSend
+	offset := (dto.Page - 1) * dto.PageSize
+	if err := query.Order("updated_at DESC").Limit(dto.PageSize).Offset(offset).Find(&sends).Error; err != nil {
+		return nil, err
+	}
+
+	result := make([]WebmentionSendDTO, len(sends))
+	for i := range sends {
+		send := &sends[i]
+		result[i] = WebmentionSendDTO{
+			ID:        send.ID,
+			PostID:    send.PostID,
+			Target:    send.Target,
+			Endpoint:  send.Endpoint,
+			Status:    WebmentionSendStatusNames[send.Status],
+			Attempts:  send.Attempts,
+			LastError: send.LastError,
+			SentAt:    send.SentAt,
+		}
+		if send.Status == model.WebmentionSendPending {
+			result[i].NextAttemptAt = &send.NextAttemptAt
+		}
+	}
+	return &ListWebmentionSendsResponseDTO{
+		Sends:      result,
+		TotalCount: totalCount,
+	}, nil
+}
+
+// RetrySend 用于立即重新发送一条通知，不论它之前的状态如何。
+func (s *WebmentionService) RetrySend(id uint) error {
+	result := dao.GetDB().Model(&model.WebmentionSend{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          model.WebmentionSendPending,
+		"attempts":        0,
+		"next_attempt_at": time.Now(),
+		"last_error":      "",
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrWebmentionNotFound
+	}
+	wakeWebmentionWorker()
+	return nil
+}
+
+// 文章发布或更新后发送 Webmention。
+func init() {
+	event.Subscribe("webmention", func(_ context.Context, e event.PostCreated) error { return queueWebmentions(e.Post) })
+	event.Subscribe("webmention", func(_ context.Context, e event.PostUpdated) error { return queueWebmentions(e.Post) })
+}
+
+// queueWebmentions 在文章发布或更新后调用，为文章中链接的外部页面安排发送通知。
+// 之前链接过、但本次更新中删掉的页面也会重新通知，对方据此发现链接已被移除。
+// 重复调用是安全的，同一目标页面只保留一条发送任务。
+func queueWebmentions(post *model.Post) error {
+	if !config.Conf.Webmention.Send || post.Status != model.PostStatusPublished {
+		return nil
+	}
+	site, err := url.Parse(config.Conf.Site.URL)
+	if err != nil || site.Host == "" {
+		// 没有站点地址就无法生成对方可以访问的文章地址
+		return nil
+	}
+
+	var targets []string
+	for _, link := range webmention.ExtractLinks(post.Content) {
+		u, err := url.Parse(link)
+		if err != nil || strings.EqualFold(u.Host, site.Host) || len(link) > webmentionURLMaxLength {
+			continue
+		}
+		targets = append(targets, link)
+		if len(targets) == webmentionMaxLinks {
+			break
+		}
+	}
+
+	now := time.Now()
+	err = dao.GetDB().Transaction(func(tx *gorm.DB) error {
+		if len(targets) > 0 {
+			sends := make([]model.WebmentionSend, len(targets))
+			for i, target := range targets {
+				sends[i] = model.WebmentionSend{PostID: post.ID, Target: target, NextAttemptAt: now}
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&sends).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&model.WebmentionSend{}).Where("post_id = ?", post.ID).Updates(map[string]interface{}{
+			"status":          model.WebmentionSendPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"last_error":      "",
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	wakeWebmentionWorker()
+	return nil
+}
+
+// WebmentionWorker 在后台校验收到的通知、发送待发的通知，并按退避策略重试失败的任务。
+// 任务保存在数据库中，程序重启后会继续处理。
+type WebmentionWorker struct {
+	sender   *webmention.Sender
+	verifier *webmention.Verifier
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wake   chan struct{}
+	done   chan struct{}
+}
+
+var (
+	_webmentionWorker   *WebmentionWorker
+	_webmentionWorkerMu sync.Mutex
+)
+
+// StartWebmentionWorker 启动 Webmention 后台任务，它必须在数据库初始化之后调用，程序退出前应调用 Shutdown。
+func StartWebmentionWorker() *WebmentionWorker {
+	cfg := config.Conf.Webmention
+	client := webmention.NewClient(cfg.Timeout, cfg.AllowPrivate)
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &WebmentionWorker{
+		sender:   &webmention.Sender{Client: client, UserAgent: webmentionUserAgent},
+		verifier: &webmention.Verifier{Client: client, UserAgent: webmentionUserAgent},
+		ctx:      ctx,
+		cancel:   cancel,
+		wake:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	_webmentionWorkerMu.Lock()
+	_webmentionWorker = w
+	_webmentionWorkerMu.Unlock()
+	go w.run()
+	return w
+}
+
+// wakeWebmentionWorker 通知后台任务有新的待处理任务，后台任务未启动时什么也不做。
+func wakeWebmentionWorker() {
+	_webmentionWorkerMu.Lock()
+	w := _webmentionWorker
+	_webmentionWorkerMu.Unlock()
+	if w == nil {
+		return
+	}
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Shutdown 停止后台任务，正在进行的请求会被取消，对应的任务在下次启动时重新处理。
+func (w *WebmentionWorker) Shutdown(ctx context.Context) error {
+	w.cancel()
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run 是后台任务的主循环。
+func (w *WebmentionWorker) run() {
+	defer close(w.done)
+	interval := config.Conf.Webmention.PollInterval
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		w.processDue()
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-w.wake:
+		}
+	}
+}
+
+// processDue 处理所有已到期的任务，直到没有到期任务或后台任务被停止。
+func (w *WebmentionWorker) processDue() {
+	db := dao.GetDB()
+	for w.ctx.Err() == nil {
+		now := time.Now()
+		var mentions []model.Webmention
+		if err := db.Where("verification = ? AND next_attempt_at <= ?", model.WebmentionVerifyQueued, now).
+			Order("next_attempt_at ASC").Limit(webmentionBatchSize).Find(&mentions).Error; err != nil {
+			logger.L.Error("Failed to load queued webmentions", zap.Error(err))
+			return
+		}
+		var sends []model.WebmentionSend
+		if err := db.Where("status = ? AND next_attempt_at <= ?", model.WebmentionSendPending, now).
+			Order("next_attempt_at ASC").Limit(webmentionBatchSize).Find(&sends).Error; err != nil {
+			logger.L.Error("Failed to load pending webmention sends", zap.Error(err))
+			return
+		}
+		if len(mentions) == 0 && len(sends) == 0 {
+			return
+		}
+		for i := range mentions {
+			if w.ctx.Err() != nil {
+				return
+			}
+			w.verify(&mentions[i])
+		}
+		for i := range sends {
+			if w.ctx.Err() != nil {
+				return
+			}
+			w.send(&sends[i])
+		}
+	}
+}
+
+// verify 抓取来源页面，校验一条收到的通知。
+func (w *WebmentionWorker) verify(m *model.Webmention) {
+	cfg := config.Conf.Webmention
+	timeout := cfg.Timeout + 5*time.Second
+
+	// 以尝试次数作为版本号取得租约，多个实例同时运行时只有一个能校验；
+	// 校验期间任务推迟到租约到期之后，实例中途退出时由其他实例在到期后重新校验
+	attempts := m.Attempts + 1
+	claim := dao.GetDB().Model(&model.Webmention{}).
+		Where("id = ? AND verification = ? AND attempts = ?", m.ID, model.WebmentionVerifyQueued, m.Attempts)
+	if !claimWebmentionTask(claim, attempts, timeout) {
+		return
+	}
+	held := dao.GetDB().Model(&model.Webmention{}).
+		Where("id = ? AND verification = ? AND attempts = ?", m.ID, model.WebmentionVerifyQueued, attempts)
+
+	ctx, cancel := context.WithTimeout(w.ctx, timeout)
+	defer cancel()
+	source, err := w.verifier.Verify(ctx, m.Source, m.Target)
+	if err != nil && w.ctx.Err() != nil {
+		// 后台任务正在停止，不计入尝试次数，下次启动时立即重新校验
+		updateWebmentionTask(held, map[string]interface{}{"attempts": m.Attempts, "next_attempt_at": time.Now()})
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{}
+	switch {
+	case err == nil:
+		updates["verification"] = model.WebmentionVerifyVerified
+		updates["verified_at"] = now
+		updates["last_error"] = ""
+		updates["type"] = source.Type
+		updates["title"] = source.Title
+		updates["excerpt"] = source.Excerpt
+		updates["author_name"] = source.AuthorName
+		updates["author_url"] = truncateRunes(source.AuthorURL, webmentionURLMaxLength)
+		if cfg.AutoApprove && m.Status == model.WebmentionStatusPending {
+			updates["status"] = model.WebmentionStatusApproved
+		}
+	case webmention.IsPermanent(err) || attempts >= cfg.MaxAttempts:
+		// 来源页面已删除或不再链接文章时，之前通过的通知也不再展示
+		updates["verification"] = model.WebmentionVerifyFailed
+		updates["last_error"] = truncateRunes(err.Error(), 500)
+	default:
+		updates["next_attempt_at"] = now.Add(webmentionRetryDelay(attempts))
+		updates["last_error"] = truncateRunes(err.Error(), 500)
+	}
+	updateWebmentionTask(held, updates)
+}
+
+// send 发现对方的接收地址并发送一条通知。
+func (w *WebmentionWorker) send(send *model.WebmentionSend) {
+	cfg := config.Conf.Webmention
+	timeout := 2*cfg.Timeout + 5*time.Second
+
+	// 与 verify 一样先取得租约，避免多个实例向对方重复发送同一条通知
+	attempts := send.Attempts + 1
+	claim := dao.GetDB().Model(&model.WebmentionSend{}).
+		Where("id = ? AND status = ? AND attempts = ?", send.ID, model.WebmentionSendPending, send.Attempts)
+	if !claimWebmentionTask(claim, attempts, timeout) {
+		return
+	}
+	held := dao.GetDB().Model(&model.WebmentionSend{}).
+		Where("id = ? AND status = ? AND attempts = ?", send.ID, model.WebmentionSendPending, attempts)
+
+	ctx, cancel := context.WithTimeout(w.ctx, timeout)
+	defer cancel()
+	// 每次发送前都重新发现接收地址，对方可能更换了地址
+	endpoint, err := w.sender.Discover(ctx, send.Target)
+	if err == nil {
+		err = w.sender.Send(ctx, endpoint, PostURL(send.PostID), send.Target)
+	}
+	if err != nil && w.ctx.Err() != nil {
+		updateWebmentionTask(held, map[string]interface{}{"attempts": send.Attempts, "next_attempt_at": time.Now()})
+		return
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"endpoint": truncateRunes(endpoint, webmentionURLMaxLength),
+	}
+	switch {
+	case err == nil:
+		updates["status"] = model.WebmentionSendSent
+		updates["sent_at"] = now
+		updates["last_error"] = ""
+	case errors.Is(err, webmention.ErrNoEndpoint):
+		updates["status"] = model.WebmentionSendNoEndpoint
+		updates["last_error"] = ""
+	case webmention.IsPermanent(err) || attempts >= cfg.MaxAttempts:
+		updates["status"] = model.WebmentionSendFailed
+		updates["last_error"] = truncateRunes(err.Error(), 500)
+	default:
+		updates["next_attempt_at"] = now.Add(webmentionRetryDelay(attempts))
+		updates["last_error"] = truncateRunes(err.Error(), 500)
+	}
+	if err != nil {
+		logger.L.Info("Webmention not sent", zap.Uint("post_id", send.PostID), zap.String("target", send.Target), zap.Error(err))
+	}
+	updateWebmentionTask(held, updates)
+}
+
+// claimWebmentionTask 通过条件更新取得任务的租约：尝试次数设为 attempts，下次尝试的时间推迟到租约到期之后。
+// query 限定了任务仍在等待处理且尝试次数没有变化，返回 false 表示任务已被其他实例取得或状态已改变。
+func claimWebmentionTask(query *gorm.DB, attempts int, timeout time.Duration) bool {
+	result := query.Updates(map[string]interface{}{"attempts": attempts, "next_attempt_at": time.Now().Add(timeout + time.Minute)})
+	if result.Error != nil {
+		logger.L.Error("Failed to claim webmention task", zap.Error(result.Error))
+		return false
+	}
+	return result.RowsAffected > 0
+}
+
+// updateWebmentionTask 保存任务的结果。query 限定了任务仍由本次尝试持有，
+// 租约到期后任务可能已被其他实例重新取得，或者被管理员重新排队，此时不覆盖。
+func updateWebmentionTask(query *gorm.DB, updates map[string]interface{}) {
+	result := query.Updates(updates)
+	if result.Error != nil {
+		logger.L.Error("Failed to update webmention task", zap.Error(result.Error))
+	} else if result.RowsAffected == 0 {
+		logger.L.Warn("Webmention task lease lost before completion")
+	}
+}
+
+// webmentionRetryDelay 返回第 attempts 次失败后的重试间隔，每次翻倍，最长一天。
+func webmentionRetryDelay(attempts int) time.Duration {
+	delay := config.Conf.Webmention.RetryDelay
+	if delay <= 0 {
+		delay = time.Minute
+	}
+	for i := 1; i < attempts && delay < 24*time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > 24*time.Hour {
+		delay = 24 * time.Hour
+	}
+	return delay
+}
@@ -0,0 +1,121 @@
+// package sitemap 负责生成符合 sitemaps.org 协议的 XML 站点地图和站点地图索引。
+package sitemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"time"
+)
+
+// MaxURLs 是协议规定的单个站点地图文件最多包含的地址数量。
+const MaxURLs = 50000
+
+// URL 描述了站点地图中的一个地址。
+type URL struct {
+	Loc        string
+	LastMod    time.Time   // 为零值时不输出 <lastmod>
+	Alternates []Alternate // 该页面的所有语言版本（包括自身），为空时不输出 <xhtml:link>
+}
+
+// Alternate 描述了页面的一个语言版本，输出为 <xhtml:link rel="alternate" hreflang="..."> 元素。
+type Alternate struct {
+	Lang string // 语言标签，例如 en
+	Href string
+}
+
+// Chunk 将地址列表按每份最多 size 个拆分，size 无效时使用 MaxURLs。
+func Chunk(urls []URL, size int) [][]URL {
+	if size <= 0 || size > MaxURLs {
+		size = MaxURLs
+	}
+	var chunks [][]URL
+	for len(urls) > size {
+		chunks = append(chunks, urls[:size])
+		urls = urls[size:]
+	}
+	return append(chunks, urls)
+}
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	XHTMLNS string       `xml:"xmlns:xhtml,attr,omitempty"`
+	URLs    []urlElement `xml:"url"`
+}
+
+type urlElement struct {
+	Loc     string      `xml:"loc"`
+	LastMod string      `xml:"lastmod,omitempty"`
+	Links   []xhtmlLink `xml:"xhtml:link"`
+}
+
+type xhtmlLink struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// URLSet 将地址列表渲染为一个站点地图文件。
+func URLSet(urls []URL) ([]byte, error) {
+	doc := urlSet{URLs: make([]urlElement, 0, len(urls))}
+	for _, u := range urls {
+		el := urlElement{Loc: u.Loc, LastMod: formatLastMod(u.LastMod)}
+		for _, alt := range u.Alternates {
+			el.Links = append(el.Links, xhtmlLink{Rel: "alternate", Hreflang: alt.Lang, Href: alt.Href})
+		}
+		if len(el.Links) > 0 {
+			doc.XHTMLNS = "http://www.w3.org/1999/xhtml"
+		}
+		doc.URLs = append(doc.URLs, el)
+	}
+	return marshal(doc)
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name         `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 sitemapindex"`
+	Sitemaps []sitemapElement `xml:"sitemap"`
+}
+
+type sitemapElement struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Index 将若干个站点地图文件的地址渲染为站点地图索引。
+func Index(sitemaps []URL) ([]byte, error) {
+	doc := sitemapIndex{Sitemaps: make([]sitemapElement, 0, len(sitemaps))}
+	for _, s := range sitemaps {
+		doc.Sitemaps = append(doc.Sitemaps, sitemapElement{Loc: s.Loc, LastMod: formatLastMod(s.LastMod)})
+	}
+	return marshal(doc)
+}
+
+// LatestMod 返回地址列表中最新的修改时间。
+func LatestMod(urls []URL) time.Time {
+	var latest time.Time
+	for _, u := range urls {
+		if u.LastMod.After(latest) {
+			latest = u.LastMod
+		}
+	}
+	return latest
+}
+
+// formatLastMod 按 W3C Datetime 格式输出时间。
+func formatLastMod(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
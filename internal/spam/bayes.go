@@ -0,0 +1,183 @@
+package spam
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 分词和打分的参数。
+const (
+	maxTokens        = 1000 // 单次提交最多使用的词数
+	maxTokenLength   = 30   // 超过该长度的英文单词会被丢弃，通常是随机字符串
+	interestingCount = 15   // 打分时使用的“最有区分度”的词数
+	robinsonS        = 1.0  // Robinson 平滑的强度
+	robinsonX        = 0.5  // 没有训练数据的词的默认概率
+)
+
+// TokenCount 是一个词在垃圾内容和正常内容中分别出现过的文档数。
+type TokenCount struct {
+	Spam int64
+	Ham  int64
+}
+
+// BayesStore 是贝叶斯分类器的训练数据存储。
+type BayesStore interface {
+	// Load 返回两类文档的总数，以及 tokens 中每个词的计数（没有出现过的词可以不返回）。
+	Load(tokens []string) (spamDocs, hamDocs int64, counts map[string]TokenCount, err error)
+	// Update 将一篇文档计入（delta 为 1）或移出（delta 为 -1）指定的类别，tokens 中的词不重复。
+	Update(tokens []string, spam bool, delta int) error
+}
+
+// Bayes 是一个可训练的朴素贝叶斯分类器，同时也是流水线中的一个 Checker。
+// 打分方式参考 Paul Graham 的《A Plan for Spam》和 Gary Robinson 的改进：
+// 每个词的概率经过平滑处理，再取最有区分度的若干个词合并为整体概率。
+type Bayes struct {
+	Store            BayesStore
+	MinDocs          int64   // 两类文档都至少有这么多篇后才开始打分，避免训练数据不足时误判
+	SpamThreshold    float64 // 概率不低于该值时判定为垃圾内容
+	SuspectThreshold float64 // 概率不低于该值时判定为可疑
+}
+
+// Name 实现了 Checker 接口。
+func (b *Bayes) Name() string { return "bayes" }
+
+// Check 实现了 Checker 接口。
+func (b *Bayes) Check(_ context.Context, sub *Submission) (*Result, error) {
+	score, ok, err := b.Score(sub.Text())
+	if err != nil || !ok {
+		return nil, err
+	}
+	result := &Result{Verdict: Pass, Score: score}
+	switch {
+	case score >= b.SpamThreshold:
+		result.Verdict = Spam
+	case score >= b.SuspectThreshold:
+		result.Verdict = Suspect
+	}
+	if result.Verdict != Pass {
+		result.Reasons = []string{fmt.Sprintf("score %.3f", score)}
+	}
+	return result, nil
+}
+
+// Score 返回 text 是垃圾内容的概率。训练数据不足时 ok 为 false。
+func (b *Bayes) Score(text string) (score float64, ok bool, err error) {
+	tokens := Tokenize(text)
+	if len(tokens) == 0 {
+		return 0, false, nil
+	}
+	spamDocs, hamDocs, counts, err := b.Store.Load(tokens)
+	if err != nil {
+		return 0, false, err
+	}
+	if spamDocs < b.MinDocs || hamDocs < b.MinDocs || spamDocs == 0 || hamDocs == 0 {
+		return 0, false, nil
+	}
+
+	probs := make([]float64, 0, len(tokens))
+	for _, token := range tokens {
+		c := counts[token]
+		n := float64(c.Spam + c.Ham)
+		if n == 0 {
+			continue
+		}
+		spamFreq := math.Min(1, float64(c.Spam)/float64(spamDocs))
+		hamFreq := math.Min(1, float64(c.Ham)/float64(hamDocs))
+		p := spamFreq / (spamFreq + hamFreq)
+		// Robinson 平滑：出现次数越少，越接近默认概率
+		probs = append(probs, (robinsonS*robinsonX+n*p)/(robinsonS+n))
+	}
+	if len(probs) == 0 {
+		return robinsonX, true, nil
+	}
+
+	// 取离 0.5 最远的若干个词
+	sort.Slice(probs, func(i, j int) bool {
+		return math.Abs(probs[i]-0.5) > math.Abs(probs[j]-0.5)
+	})
+	if len(probs) > interestingCount {
+		probs = probs[:interestingCount]
+	}
+
+	// 在对数空间中合并，避免连乘下溢
+	var logSpam, logHam float64
+	for _, p := range probs {
+		p = math.Min(math.Max(p, 0.01), 0.99)
+		logSpam += math.Log(p)
+		logHam += math.Log(1 - p)
+	}
+	return 1 / (1 + math.Exp(logHam-logSpam)), true, nil
+}
+
+// Train 将 text 作为一篇垃圾内容（spam 为 true）或正常内容训练分类器。
+func (b *Bayes) Train(text string, spam bool) error {
+	return b.Store.Update(Tokenize(text), spam, 1)
+}
+
+// Untrain 撤销一次训练，用于审核人员更正之前的判定。
+func (b *Bayes) Untrain(text string, spam bool) error {
+	return b.Store.Update(Tokenize(text), spam, -1)
+}
+
+// Tokenize 将文本切分为去重后的词。
+// 英文和数字按单词切分；中日韩文字没有空格分隔，按相邻的两个字切分；链接额外记录其域名。
+func Tokenize(text string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	add := func(token string) {
+		if token != "" && !seen[token] && len(tokens) < maxTokens {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	for _, field := range strings.Fields(text) {
+		if u, err := url.Parse(field); err == nil && u.Host != "" && (u.Scheme == "http" || u.Scheme == "https") {
+			add("host:" + strings.ToLower(strings.TrimPrefix(u.Hostname(), "www.")))
+		}
+	}
+
+	var word []rune
+	var cjk []rune
+	flushWord := func() {
+		if len(word) >= 2 && len(word) <= maxTokenLength {
+			add(string(word))
+		}
+		word = word[:0]
+	}
+	flushCJK := func() {
+		if len(cjk) == 1 {
+			add(string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			add(string(cjk[i : i+2]))
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case isCJK(r):
+			flushWord()
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' || r == '$':
+			flushCJK()
+			word = append(word, r)
+		default:
+			flushWord()
+			flushCJK()
+		}
+	}
+	flushWord()
+	flushCJK()
+	return tokens
+}
+
+// isCJK 判断 r 是否为中日韩文字。
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
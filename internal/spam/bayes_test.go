@@ -0,0 +1,145 @@
+package spam
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"Hello, hello WORLD a", []string{"hello", "world"}},
+		{"don't pay $100", []string{"don't", "pay", "$100"}},
+		{"visit https://www.Example.com/x now", []string{"host:example.com", "visit", "https", "www", "example", "com", "now"}},
+		{"垃圾评论", []string{"垃圾", "圾评", "评论"}},
+		{"好 ok", []string{"好", "ok"}},
+		{"abcdefghijklmnopqrstuvwxyzabcde ok", []string{"ok"}}, // 超过 maxTokenLength 的单词
+	}
+	for _, tt := range tests {
+		if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Tokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+// trainedBayes 返回用几篇典型的垃圾内容和正常内容训练过的分类器。
+func trainedBayes(t *testing.T) (*Bayes, *memoryStore) {
+	t.Helper()
+	store := newMemoryStore()
+	b := &Bayes{Store: store, MinDocs: 2, SpamThreshold: 0.9, SuspectThreshold: 0.6}
+	for _, text := range []string{
+		"cheap viagra online casino bonus",
+		"casino bonus free money click now",
+		"buy cheap pills online free shipping",
+	} {
+		if err := b.Train(text, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, text := range []string{
+		"great article about go generics",
+		"thanks for the detailed explanation of generics",
+		"the benchmark in this article helped me",
+	} {
+		if err := b.Train(text, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b, store
+}
+
+func TestBayesTrain(t *testing.T) {
+	b, store := trainedBayes(t)
+	if store.spamDocs != 3 || store.hamDocs != 3 {
+		t.Fatalf("docs = %d spam, %d ham, want 3 and 3", store.spamDocs, store.hamDocs)
+	}
+	if c := store.counts["casino"]; c != (TokenCount{Spam: 2}) {
+		t.Errorf("casino = %+v, want 2 spam", c)
+	}
+	if c := store.counts["article"]; c != (TokenCount{Ham: 2}) {
+		t.Errorf("article = %+v, want 2 ham", c)
+	}
+
+	if err := b.Untrain("casino bonus free money click now", true); err != nil {
+		t.Fatal(err)
+	}
+	if store.spamDocs != 2 {
+		t.Errorf("spam docs after untrain = %d, want 2", store.spamDocs)
+	}
+	if c := store.counts["casino"]; c != (TokenCount{Spam: 1}) {
+		t.Errorf("casino after untrain = %+v, want 1 spam", c)
+	}
+}
+
+func TestBayesScore(t *testing.T) {
+	b, _ := trainedBayes(t)
+
+	spam, ok, err := b.Score("free casino bonus, cheap pills")
+	if err != nil || !ok {
+		t.Fatalf("Score = %v, %v", ok, err)
+	}
+	ham, ok, err := b.Score("a detailed article about generics")
+	if err != nil || !ok {
+		t.Fatalf("Score = %v, %v", ok, err)
+	}
+	if spam < 0.9 {
+		t.Errorf("spam score = %.3f, want >= 0.9", spam)
+	}
+	if ham > 0.1 {
+		t.Errorf("ham score = %.3f, want <= 0.1", ham)
+	}
+
+	// 没有训练过的词取默认概率
+	if score, ok, _ := b.Score("zebra quantum"); !ok || score != robinsonX {
+		t.Errorf("unknown words: score = %v, ok = %v, want %v", score, ok, robinsonX)
+	}
+	if _, ok, _ := b.Score("   "); ok {
+		t.Error("empty text was scored")
+	}
+}
+
+func TestBayesMinDocs(t *testing.T) {
+	b, _ := trainedBayes(t)
+	b.MinDocs = 4
+	if _, ok, _ := b.Score("free casino bonus"); ok {
+		t.Error("scored before reaching MinDocs")
+	}
+	r, err := b.Check(context.Background(), &Submission{Content: "free casino bonus"})
+	if err != nil || r != nil {
+		t.Errorf("Check = %+v, %v, want nil", r, err)
+	}
+}
+
+func TestBayesCheck(t *testing.T) {
+	b, _ := trainedBayes(t)
+	tests := []struct {
+		name    string
+		content string
+		want    Verdict
+	}{
+		{"spam", "free casino bonus, cheap pills", Spam},
+		{"ham", "a detailed article about generics", Pass},
+		{"unknown", "zebra quantum", Pass},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verdictOf(t, b, &Submission{Content: tt.content}); got != tt.want {
+				t.Errorf("verdict = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// 介于两个阈值之间时为可疑
+	b.SpamThreshold, b.SuspectThreshold = 1.1, 0.4
+	r, err := b.Check(context.Background(), &Submission{Content: "free casino bonus"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Verdict != Suspect || len(r.Reasons) != 1 {
+		t.Errorf("result = %+v, want suspect with a reason", r)
+	}
+}
@@ -0,0 +1,184 @@
+package spam
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// HoneypotChecker 检查蜜罐字段。蜜罐字段在页面上对用户隐藏，只有自动填表的机器人会填写它。
+type HoneypotChecker struct{}
+
+// Name 实现了 Checker 接口。
+func (HoneypotChecker) Name() string { return "honeypot" }
+
+// Check 实现了 Checker 接口。
+func (HoneypotChecker) Check(_ context.Context, sub *Submission) (*Result, error) {
+	if strings.TrimSpace(sub.Honeypot) != "" {
+		return &Result{Verdict: Spam, Score: -1}, nil
+	}
+	return nil, nil
+}
+
+// formTokenVersion 是表单令牌的格式版本。
+const formTokenVersion = 1
+
+// FormTokens 负责签发和校验表单令牌。
+// 令牌中记录了表单展示的时间，并使用 HMAC 签名防止伪造，用于检查从展示表单到提交的最短时间。
+type FormTokens struct {
+	secret []byte
+}
+
+// NewFormTokens 创建一个使用 secret 签名的 FormTokens。
+func NewFormTokens(secret string) *FormTokens {
+	return &FormTokens{secret: []byte(secret)}
+}
+
+// Issue 签发一个记录了当前时间的令牌。
+func (t *FormTokens) Issue(now time.Time) string {
+	payload := make([]byte, 9)
+	payload[0] = formTokenVersion
+	binary.BigEndian.PutUint64(payload[1:], uint64(now.UnixMilli()))
+	return base64.RawURLEncoding.EncodeToString(append(payload, t.sign(payload)...))
+}
+
+// Parse 校验令牌的签名并返回签发时间。
+func (t *FormTokens) Parse(token string) (time.Time, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) != 9+sha256.Size || data[0] != formTokenVersion {
+		return time.Time{}, errors.New("invalid form token")
+	}
+	if !hmac.Equal(data[9:], t.sign(data[:9])) {
+		return time.Time{}, errors.New("invalid form token signature")
+	}
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(data[1:9]))), nil
+}
+
+func (t *FormTokens) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte("gopress-form-token"))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// MinSubmitTimeChecker 检查从展示表单到提交的时间。机器人通常在页面加载后立刻提交。
+type MinSubmitTimeChecker struct {
+	Tokens  *FormTokens
+	MinTime time.Duration // 最短填写时间
+	MaxAge  time.Duration // 令牌的有效期，0 表示不限制
+	// Required 表示是否必须携带令牌。为 false 时没有令牌的提交不做检查，兼容尚未接入令牌的客户端。
+	Required bool
+}
+
+// Name 实现了 Checker 接口。
+func (MinSubmitTimeChecker) Name() string { return "submit_time" }
+
+// Check 实现了 Checker 接口。
+func (c MinSubmitTimeChecker) Check(_ context.Context, sub *Submission) (*Result, error) {
+	if sub.FormToken == "" {
+		if c.Required {
+			return &Result{Verdict: Spam, Score: -1, Reasons: []string{"missing form token"}}, nil
+		}
+		return nil, nil
+	}
+	issued, err := c.Tokens.Parse(sub.FormToken)
+	if err != nil {
+		return &Result{Verdict: Spam, Score: -1, Reasons: []string{err.Error()}}, nil
+	}
+	elapsed := sub.Now.Sub(issued)
+	if elapsed < c.MinTime {
+		return &Result{Verdict: Spam, Score: -1, Reasons: []string{fmt.Sprintf("submitted after %s", elapsed.Round(time.Millisecond))}}, nil
+	}
+	// 过期的令牌可能是用户开着页面很久才提交，只标记为可疑
+	if c.MaxAge > 0 && elapsed > c.MaxAge {
+		return &Result{Verdict: Suspect, Score: -1, Reasons: []string{"form token expired"}}, nil
+	}
+	return nil, nil
+}
+
+// linkRe 匹配文本中的链接。
+var linkRe = regexp.MustCompile(`(?i)(https?://|www\.)[^\s<>"']+|<a\s`)
+
+// LinkCountChecker 检查内容中的链接数量。超过 Max 时为可疑，超过 2*Max 时为垃圾内容。
+type LinkCountChecker struct {
+	Max int
+}
+
+// Name 实现了 Checker 接口。
+func (LinkCountChecker) Name() string { return "links" }
+
+// Check 实现了 Checker 接口。
+func (c LinkCountChecker) Check(_ context.Context, sub *Submission) (*Result, error) {
+	if c.Max < 0 {
+		return nil, nil
+	}
+	n := len(linkRe.FindAllStringIndex(sub.Content, -1))
+	switch {
+	case n > 2*c.Max:
+		return &Result{Verdict: Spam, Score: -1, Reasons: []string{fmt.Sprintf("%d links", n)}}, nil
+	case n > c.Max:
+		return &Result{Verdict: Suspect, Score: -1, Reasons: []string{fmt.Sprintf("%d links", n)}}, nil
+	}
+	return nil, nil
+}
+
+// BlocklistChecker 检查关键词、IP 和邮箱黑名单，命中任意一项即判定为垃圾内容。
+type BlocklistChecker struct {
+	Words  []string // 关键词，不区分大小写，匹配昵称、邮箱、网址和内容
+	IPs    []string // IP 地址或 CIDR 网段，例如 203.0.113.7、198.51.100.0/24
+	Emails []string // 邮箱地址或域名，例如 spam@example.com、@example.com
+}
+
+// Name 实现了 Checker 接口。
+func (BlocklistChecker) Name() string { return "blocklist" }
+
+// Check 实现了 Checker 接口。
+func (c BlocklistChecker) Check(_ context.Context, sub *Submission) (*Result, error) {
+	text := strings.ToLower(sub.Text())
+	for _, word := range c.Words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" && strings.Contains(text, word) {
+			return &Result{Verdict: Spam, Score: -1, Reasons: []string{"word " + word}}, nil
+		}
+	}
+
+	if ip := net.ParseIP(sub.IP); ip != nil {
+		for _, entry := range c.IPs {
+			if matchIP(ip, strings.TrimSpace(entry)) {
+				return &Result{Verdict: Spam, Score: -1, Reasons: []string{"ip " + sub.IP}}, nil
+			}
+		}
+	}
+
+	email := strings.ToLower(strings.TrimSpace(sub.AuthorEmail))
+	if email != "" {
+		for _, entry := range c.Emails {
+			entry = strings.ToLower(strings.TrimSpace(entry))
+			if entry == "" {
+				continue
+			}
+			if email == entry || (strings.HasPrefix(entry, "@") && strings.HasSuffix(email, entry)) {
+				return &Result{Verdict: Spam, Score: -1, Reasons: []string{"email " + email}}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// matchIP 判断 ip 是否与黑名单中的一项（IP 地址或 CIDR 网段）匹配。
+func matchIP(ip net.IP, entry string) bool {
+	if strings.Contains(entry, "/") {
+		_, network, err := net.ParseCIDR(entry)
+		return err == nil && network.Contains(ip)
+	}
+	blocked := net.ParseIP(entry)
+	return blocked != nil && blocked.Equal(ip)
+}
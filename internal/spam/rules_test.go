@@ -0,0 +1,143 @@
+package spam
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// verdictOf 执行 checker 并返回判定结果，没有发现问题时为 Pass。
+func verdictOf(t *testing.T, checker Checker, sub *Submission) Verdict {
+	t.Helper()
+	r, err := checker.Check(context.Background(), sub)
+	if err != nil {
+		t.Fatalf("%s: %v", checker.Name(), err)
+	}
+	if r == nil {
+		return Pass
+	}
+	return r.Verdict
+}
+
+func TestHoneypotChecker(t *testing.T) {
+	tests := []struct {
+		honeypot string
+		want     Verdict
+	}{
+		{"", Pass},
+		{"   ", Pass},
+		{"http://spam.example", Spam},
+	}
+	for _, tt := range tests {
+		if got := verdictOf(t, HoneypotChecker{}, &Submission{Honeypot: tt.honeypot}); got != tt.want {
+			t.Errorf("honeypot %q: verdict = %v, want %v", tt.honeypot, got, tt.want)
+		}
+	}
+}
+
+func TestMinSubmitTimeChecker(t *testing.T) {
+	tokens := NewFormTokens("secret")
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	issued := tokens.Issue(now)
+	forged := NewFormTokens("other").Issue(now)
+
+	tests := []struct {
+		name     string
+		token    string
+		elapsed  time.Duration
+		required bool
+		want     Verdict
+	}{
+		{"no token", "", 0, false, Pass},
+		{"no token required", "", 0, true, Spam},
+		{"garbage", "not-a-token", time.Minute, false, Spam},
+		{"forged", forged, time.Minute, false, Spam},
+		{"too fast", issued, time.Second, false, Spam},
+		{"at minimum", issued, 3 * time.Second, false, Pass},
+		{"normal", issued, time.Minute, false, Pass},
+		{"expired", issued, 25 * time.Hour, false, Suspect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := MinSubmitTimeChecker{Tokens: tokens, MinTime: 3 * time.Second, MaxAge: 24 * time.Hour, Required: tt.required}
+			sub := &Submission{FormToken: tt.token, Now: now.Add(tt.elapsed)}
+			if got := verdictOf(t, checker, sub); got != tt.want {
+				t.Errorf("verdict = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormTokensRoundTrip(t *testing.T) {
+	tokens := NewFormTokens("secret")
+	now := time.UnixMilli(1714564800123)
+	got, err := tokens.Parse(tokens.Issue(now))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(now) {
+		t.Errorf("issued at %v, want %v", got, now)
+	}
+}
+
+func TestLinkCountChecker(t *testing.T) {
+	links := func(n int) string {
+		return strings.Repeat("see https://example.com/x ", n)
+	}
+	tests := []struct {
+		name    string
+		max     int
+		content string
+		want    Verdict
+	}{
+		{"no links", 2, "hello world", Pass},
+		{"at max", 2, links(2), Pass},
+		{"over max", 2, links(3), Suspect},
+		{"at twice max", 2, links(4), Suspect},
+		{"over twice max", 2, links(5), Spam},
+		{"www and anchors", 1, `www.a.example <a href="x">y</a> www.b.example`, Spam},
+		{"zero allows none", 0, links(1), Spam},
+		{"disabled", -1, links(50), Pass},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verdictOf(t, LinkCountChecker{Max: tt.max}, &Submission{Content: tt.content}); got != tt.want {
+				t.Errorf("verdict = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlocklistChecker(t *testing.T) {
+	checker := BlocklistChecker{
+		Words:  []string{"Casino", " ", ""},
+		IPs:    []string{"203.0.113.7", "198.51.100.0/24", "2001:db8::/32", "not-an-ip"},
+		Emails: []string{"spam@example.com", "@bad.example", ""},
+	}
+	tests := []struct {
+		name string
+		sub  Submission
+		want Verdict
+	}{
+		{"clean", Submission{Content: "nice post", IP: "192.0.2.1", AuthorEmail: "alice@example.com"}, Pass},
+		{"word in content", Submission{Content: "best CASINO bonus"}, Spam},
+		{"word in name", Submission{AuthorName: "casino king"}, Spam},
+		{"word in url", Submission{AuthorURL: "https://casino.example"}, Spam},
+		{"exact ip", Submission{IP: "203.0.113.7"}, Spam},
+		{"other ip", Submission{IP: "203.0.113.8"}, Pass},
+		{"cidr", Submission{IP: "198.51.100.42"}, Spam},
+		{"ipv6 cidr", Submission{IP: "2001:db8::1"}, Spam},
+		{"invalid ip", Submission{IP: "unknown"}, Pass},
+		{"exact email", Submission{AuthorEmail: " Spam@Example.com "}, Spam},
+		{"email domain", Submission{AuthorEmail: "bob@bad.example"}, Spam},
+		{"email subdomain is not the domain", Submission{AuthorEmail: "bob@notbad.example"}, Pass},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verdictOf(t, checker, &tt.sub); got != tt.want {
+				t.Errorf("verdict = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
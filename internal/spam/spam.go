@@ -0,0 +1,126 @@
+// package spam 实现了公开写入接口（评论、注册）使用的垃圾内容检测流水线。
+//
+// 一次提交会依次经过多个 Checker：蜜罐字段、最短填写时间、链接数量、黑名单、
+// 朴素贝叶斯分类器以及可选的外部检测服务。任意一个 Checker 判定为垃圾内容时立即停止，
+// 判定为可疑时继续执行后续的 Checker，最终结果取所有 Checker 中最严重的判定。
+package spam
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Verdict 是检测的判定结果，数值越大越严重。
+type Verdict int
+
+const (
+	Pass    Verdict = iota // 正常内容
+	Suspect                // 可疑内容，评论进入人工审核
+	Spam                   // 垃圾内容
+)
+
+// String 返回判定结果的名称。
+func (v Verdict) String() string {
+	switch v {
+	case Suspect:
+		return "suspect"
+	case Spam:
+		return "spam"
+	default:
+		return "pass"
+	}
+}
+
+// 提交的类型。
+const (
+	KindComment = "comment"
+	KindSignup  = "signup"
+)
+
+// Submission 是一次需要检测的提交。
+type Submission struct {
+	Kind        string // 提交类型: comment, signup
+	Content     string // 评论内容，注册时为空
+	AuthorName  string // 评论者昵称或注册的用户名
+	AuthorEmail string
+	AuthorURL   string
+	IP          string
+	UserAgent   string
+	Referrer    string
+	Permalink   string // 评论所属文章的地址
+
+	Honeypot  string    // 蜜罐字段的值，正常用户看不到该字段，应当为空
+	FormToken string    // 表单令牌，记录了表单展示的时间
+	Now       time.Time // 提交时间，为零值时使用当前时间
+}
+
+// Text 返回提交中所有用户填写的文本，用于关键词匹配和分类。
+func (s *Submission) Text() string {
+	return strings.Join([]string{s.AuthorName, s.AuthorEmail, s.AuthorURL, s.Content}, "\n")
+}
+
+// Result 是单个 Checker 或整条流水线的检测结果。
+type Result struct {
+	Verdict Verdict
+	Score   float64  // 贝叶斯分类器给出的垃圾内容概率 (0~1)，未参与分类时为 -1
+	Reasons []string // 判定的原因，例如 "honeypot"、"links: 5"
+}
+
+// Checker 是流水线中的一个检测步骤。
+type Checker interface {
+	// Name 返回检测步骤的名称，用于日志和判定原因。
+	Name() string
+	// Check 检测一次提交。返回 nil 表示没有发现问题。
+	Check(ctx context.Context, sub *Submission) (*Result, error)
+}
+
+// Pipeline 依次执行多个 Checker。
+type Pipeline struct {
+	checkers []Checker
+	// OnError 在某个 Checker 出错时调用。出错的 Checker 会被跳过，不影响提交（fail-open）。
+	OnError func(name string, err error)
+}
+
+// NewPipeline 创建一条由 checkers 组成的流水线。
+func NewPipeline(checkers ...Checker) *Pipeline {
+	return &Pipeline{checkers: checkers}
+}
+
+// Check 依次执行所有 Checker，遇到垃圾内容的判定时立即返回。
+func (p *Pipeline) Check(ctx context.Context, sub *Submission) *Result {
+	if sub.Now.IsZero() {
+		sub.Now = time.Now()
+	}
+	result := &Result{Verdict: Pass, Score: -1}
+	for _, checker := range p.checkers {
+		r, err := checker.Check(ctx, sub)
+		if err != nil {
+			if p.OnError != nil {
+				p.OnError(checker.Name(), err)
+			}
+			continue
+		}
+		if r == nil {
+			continue
+		}
+		if r.Score >= 0 {
+			result.Score = r.Score
+		}
+		if r.Verdict > Pass {
+			for _, reason := range r.Reasons {
+				result.Reasons = append(result.Reasons, checker.Name()+": "+reason)
+			}
+			if len(r.Reasons) == 0 {
+				result.Reasons = append(result.Reasons, checker.Name())
+			}
+		}
+		if r.Verdict > result.Verdict {
+			result.Verdict = r.Verdict
+		}
+		if result.Verdict == Spam {
+			break
+		}
+	}
+	return result
+}
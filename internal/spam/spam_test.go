@@ -0,0 +1,115 @@
+package spam
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// fakeChecker 返回预先设定的结果，并记录被调用的次数。
+type fakeChecker struct {
+	name   string
+	result *Result
+	err    error
+	calls  int
+}
+
+func (f *fakeChecker) Name() string { return f.name }
+
+func (f *fakeChecker) Check(_ context.Context, _ *Submission) (*Result, error) {
+	f.calls++
+	return f.result, f.err
+}
+
+// memoryStore 是保存在内存中的 BayesStore。
+type memoryStore struct {
+	spamDocs, hamDocs int64
+	counts            map[string]TokenCount
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{counts: make(map[string]TokenCount)}
+}
+
+func (s *memoryStore) Load(tokens []string) (int64, int64, map[string]TokenCount, error) {
+	counts := make(map[string]TokenCount)
+	for _, token := range tokens {
+		if c, ok := s.counts[token]; ok {
+			counts[token] = c
+		}
+	}
+	return s.spamDocs, s.hamDocs, counts, nil
+}
+
+func (s *memoryStore) Update(tokens []string, spam bool, delta int) error {
+	if spam {
+		s.spamDocs += int64(delta)
+	} else {
+		s.hamDocs += int64(delta)
+	}
+	for _, token := range tokens {
+		c := s.counts[token]
+		if spam {
+			c.Spam += int64(delta)
+		} else {
+			c.Ham += int64(delta)
+		}
+		s.counts[token] = c
+	}
+	return nil
+}
+
+func TestPipeline(t *testing.T) {
+	suspect := &Result{Verdict: Suspect, Score: -1, Reasons: []string{"odd"}}
+	spam := &Result{Verdict: Spam, Score: -1}
+	scored := &Result{Verdict: Pass, Score: 0.2}
+	tests := []struct {
+		name     string
+		checkers []*fakeChecker
+		verdict  Verdict
+		score    float64
+		reasons  []string
+		calls    []int // 每个 Checker 被调用的次数
+		errors   []string
+	}{
+		{"empty", nil, Pass, -1, nil, nil, nil},
+		{"all pass", []*fakeChecker{{name: "a"}, {name: "b", result: scored}}, Pass, 0.2, nil, []int{1, 1}, nil},
+		{"suspect continues", []*fakeChecker{{name: "a", result: suspect}, {name: "b", result: scored}},
+			Suspect, 0.2, []string{"a: odd"}, []int{1, 1}, nil},
+		{"spam stops", []*fakeChecker{{name: "a", result: spam}, {name: "b", result: scored}},
+			Spam, -1, []string{"a"}, []int{1, 0}, nil},
+		{"worst verdict wins", []*fakeChecker{{name: "a", result: suspect}, {name: "b", result: spam}},
+			Spam, -1, []string{"a: odd", "b"}, []int{1, 1}, nil},
+		{"error fails open", []*fakeChecker{{name: "a", err: errors.New("down")}, {name: "b"}},
+			Pass, -1, nil, []int{1, 1}, []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkers := make([]Checker, len(tt.checkers))
+			for i, c := range tt.checkers {
+				checkers[i] = c
+			}
+			p := NewPipeline(checkers...)
+			var failed []string
+			p.OnError = func(name string, _ error) { failed = append(failed, name) }
+
+			sub := &Submission{Kind: KindComment}
+			r := p.Check(context.Background(), sub)
+			if r.Verdict != tt.verdict || r.Score != tt.score || !reflect.DeepEqual(r.Reasons, tt.reasons) {
+				t.Errorf("result = %+v, want verdict %v score %v reasons %v", r, tt.verdict, tt.score, tt.reasons)
+			}
+			for i, c := range tt.checkers {
+				if c.calls != tt.calls[i] {
+					t.Errorf("checker %s called %d times, want %d", c.name, c.calls, tt.calls[i])
+				}
+			}
+			if !reflect.DeepEqual(failed, tt.errors) {
+				t.Errorf("OnError called for %v, want %v", failed, tt.errors)
+			}
+			if sub.Now.IsZero() {
+				t.Error("Now was not set")
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+)
+
+// unsignedPayload 表示请求体不参与签名，S3 和 MinIO 都支持这种模式，
+// 这样上传时无需先完整读取文件来计算摘要。
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3Storage 是基于 S3 兼容对象存储（AWS S3、MinIO 等）的存储实现。
+// 它直接使用 AWS Signature Version 4 对 HTTP 请求签名，不依赖官方 SDK。
+type S3Storage struct {
+	endpoint     *url.URL
+	region       string
+	bucket       string
+	accessKey    string
+	secretKey    string
+	usePathStyle bool
+	client       *http.Client
+}
+
+// NewS3Storage 是 S3Storage 的构造函数。
+func NewS3Storage(c config.S3Storage) (*S3Storage, error) {
+	if c.Endpoint == "" || c.Bucket == "" {
+		return nil, errors.New("s3 storage requires endpoint and bucket")
+	}
+	endpoint, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid s3 endpoint: %w", err)
+	}
+	region := c.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	return &S3Storage{
+		endpoint:     endpoint,
+		region:       region,
+		bucket:       c.Bucket,
+		accessKey:    c.AccessKeyID,
+		secretKey:    c.SecretAccessKey,
+		usePathStyle: c.UsePathStyle,
+		client:       &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// objectURL 返回 key 对应对象的请求地址。
+// 路径风格为 {endpoint}/{bucket}/{key}（MinIO 默认使用），
+// 虚拟主机风格为 {scheme}://{bucket}.{host}/{key}（AWS S3 推荐使用）。
+// 与 LocalStorage 一样拒绝包含 .、.. 或空路径段的键，否则同一个对象可以通过不同的键访问。
+func (s *S3Storage) objectURL(key string) (*url.URL, error) {
+	key = strings.TrimPrefix(key, "/")
+	if key == "" || path.Clean("/"+key) != "/"+key {
+		return nil, errInvalidKey
+	}
+	u := *s.endpoint
+	if s.usePathStyle {
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + s.bucket + "/" + key
+	} else {
+		u.Host = s.bucket + "." + u.Host
+		u.Path = strings.TrimRight(u.Path, "/") + "/" + key
+	}
+	u.RawPath = uriEncode(u.Path, false)
+	return &u, nil
+}
+
+// Put 将 r 中的内容上传到 key 对应的对象。S3 要求预先知道内容长度，因此 size 必须准确。
+func (s *S3Storage) Put(key string, r io.Reader, size int64, contentType string) error {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, u.String(), r)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkS3Response(resp)
+}
+
+// Get 下载 key 对应的对象，调用方负责关闭返回的对象。
+func (s *S3Storage) Get(key string) (*Object, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkS3Response(resp); err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &Object{
+		Body:        resp.Body,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+		ModTime:     modTime,
+	}, nil
+}
+
+// Exists 通过 HEAD 请求判断 key 对应的对象是否存在。
+func (s *S3Storage) Exists(key string) (bool, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequest(http.MethodHead, u.String(), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if err := checkS3Response(resp); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// Delete 删除 key 对应的对象。S3 删除不存在的对象同样返回成功。
+func (s *S3Storage) Delete(key string) error {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkS3Response(resp); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// PresignPut 生成一个预签名的上传地址，客户端可以直接用 PUT 方法把文件上传到对象存储。
+// Content-Type 请求头参与签名，客户端上传时必须使用 contentType，不能自行指定对象的类型。
+func (s *S3Storage) PresignPut(key, contentType string, expires time.Duration) (string, error) {
+	return s.presign(http.MethodPut, key, contentType, expires, time.Now().UTC())
+}
+
+// presign 使用查询参数签名的方式生成一个预签名地址，contentType 不为空时 Content-Type 请求头参与签名。
+func (s *S3Storage) presign(method, key, contentType string, expires time.Duration, now time.Time) (string, error) {
+	u, err := s.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+	date := now.Format("20060102")
+
+	canonicalHeaders := "host:" + u.Host + "\n"
+	signedHeaders := "host"
+	if contentType != "" {
+		canonicalHeaders = "content-type:" + contentType + "\n" + canonicalHeaders
+		signedHeaders = "content-type;host"
+	}
+
+	query := url.Values{}
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", s.accessKey+"/"+s.scope(date))
+	query.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", signedHeaders)
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		canonicalURI(u.Path),
+		canonicalQuery(query),
+		canonicalHeaders,
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+	query.Set("X-Amz-Signature", s.signature(now, canonicalRequest))
+
+	u.RawQuery = canonicalQuery(query)
+	return u.String(), nil
+}
+
+// do 对请求进行签名并发送。
+func (s *S3Storage) do(req *http.Request) (*http.Response, error) {
+	s.sign(req, time.Now().UTC())
+	return s.client.Do(req)
+}
+
+// sign 使用 AWS Signature Version 4 为请求添加 Authorization 头。
+func (s *S3Storage) sign(req *http.Request, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+
+	// 参与签名的请求头：host、content-type 以及所有 x-amz-* 头，必须按名称排序
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI(req.URL.Path),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		unsignedPayload,
+	}, "\n")
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.accessKey, s.scope(now.Format("20060102")), signedHeaders, s.signature(now, canonicalRequest),
+	))
+}
+
+// scope 返回签名的凭证范围，格式为 {date}/{region}/s3/aws4_request。
+func (s *S3Storage) scope(date string) string {
+	return date + "/" + s.region + "/s3/aws4_request"
+}
+
+// signature 根据规范请求计算签名。
+func (s *S3Storage) signature(now time.Time, canonicalRequest string) string {
+	date := now.Format("20060102")
+	hashed := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		now.Format("20060102T150405Z"),
+		s.scope(date),
+		hex.EncodeToString(hashed[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.secretKey), date)
+	key = hmacSHA256(key, s.region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+// hmacSHA256 计算 HMAC-SHA256。
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// canonicalURI 按照 SigV4 的要求对路径进行编码：除未保留字符和 '/' 外全部百分号编码。
+func canonicalURI(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return uriEncode(path, false)
+}
+
+// canonicalQuery 按照 SigV4 的要求对查询参数排序并编码。
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode 实现了 SigV4 规定的 URI 编码规则，encodeSlash 控制是否编码 '/'。
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// checkS3Response 将非 2xx 的响应转换为错误。
+func checkS3Response(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 request failed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+}
@@ -0,0 +1,90 @@
+// package storage 负责媒体文件的持久化存储。
+// 文件以“内容寻址”的方式保存：存储键由文件内容的 SHA-256 摘要推导而来，
+// 相同内容的文件只会被保存一次，且同一个存储键对应的内容永远不会改变。
+//
+// 具体的存储后端通过 Storage 接口抽象，目前支持本地文件系统 (local) 和 S3 兼容的对象存储 (s3)。
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+)
+
+// ErrNotFound 表示请求的文件在存储中不存在。
+var ErrNotFound = errors.New("文件不存在")
+
+// errInvalidKey 表示存储键为空或者包含 .、.. 等路径段。
+var errInvalidKey = errors.New("无效的存储键")
+
+// Object 表示从存储中读取到的一个文件。
+type Object struct {
+	Body        io.ReadCloser // 文件内容，调用方负责关闭
+	Size        int64         // 文件大小 (字节)
+	ContentType string        // 文件的 MIME 类型，后端未记录时为空
+	ModTime     time.Time     // 最后修改时间
+}
+
+// Storage 是所有存储后端都需要实现的接口。
+type Storage interface {
+	// Put 将 r 中的内容写入到 key 对应的位置，size 为内容的字节数。
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Get 读取 key 对应的文件，文件不存在时返回 ErrNotFound。
+	Get(key string) (*Object, error)
+	// Exists 判断 key 对应的文件是否存在。
+	Exists(key string) (bool, error)
+	// Delete 删除 key 对应的文件，文件不存在时不视为错误。
+	Delete(key string) error
+}
+
+// Presigner 是支持客户端直传的存储后端额外实现的接口。
+type Presigner interface {
+	// PresignPut 生成一个预签名的 URL，客户端可以在 expires 时间内直接用 PUT 方法上传文件到 key，
+	// 上传时的 Content-Type 请求头必须是 contentType。
+	PresignPut(key, contentType string, expires time.Duration) (string, error)
+}
+
+// ContentKey 根据文件内容的十六进制 SHA-256 摘要和扩展名生成存储键。
+// 为了避免单个目录下文件过多，摘要的前四个字符会被用作两级子目录，
+// 例如 "ab/cd/abcd1234....jpg"。
+func ContentKey(checksum, ext string) string {
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return fmt.Sprintf("%s/%s/%s%s", checksum[0:2], checksum[2:4], checksum, strings.ToLower(ext))
+}
+
+// _storage 是包内私有的全局存储实例，由 Init 根据配置创建。
+var _storage Storage
+
+// Init 根据配置中指定的驱动初始化全局存储实例。
+func Init() (err error) {
+	_storage, err = New(config.Conf.Storage.Driver, config.Conf.Storage)
+	return err
+}
+
+// GetStorage 返回全局存储实例。
+func GetStorage() Storage {
+	return _storage
+}
+
+// New 根据驱动名称和配置创建一个存储实例。
+// driver 为空时使用本地文件系统。
+func New(driver string, c config.Storage) (Storage, error) {
+	switch driver {
+	case "", "local":
+		dir := c.Local.Dir
+		if dir == "" {
+			dir = "./uploads"
+		}
+		return NewLocalStorage(dir), nil
+	case "s3":
+		return NewS3Storage(c.S3)
+	default:
+		return nil, fmt.Errorf("unknown storage driver: %s", driver)
+	}
+}
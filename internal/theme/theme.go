@@ -0,0 +1,271 @@
+// package theme 实现了服务端渲染公开站点时使用的主题系统。
+//
+// 一个主题是一个目录，结构如下：
+//
+//	layouts/  布局模板，必须定义名为 "base" 的模板作为页面的入口
+//	partials/ 可在各页面中复用的片段模板
+//	pages/    页面模板: home.html、post.html、category.html、tag.html、archive.html、404.html，
+//	          每个页面通过 {{define "content"}} 填充布局
+//	assets/   样式、脚本、图片等静态文件，通过 /theme/ 路径访问
+//
+// 主题可以从配置的主题目录加载，也可以使用编译进程序的内置主题 default。
+package theme
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/sanitize"
+	"github.com/KeLes-Coding/gopress/internal/util"
+)
+
+// DefaultName 是内置主题的名称。
+const DefaultName = "default"
+
+// RequiredPages 是每个主题都必须提供的页面模板。
+var RequiredPages = []string{"home", "post", "category", "tag", "archive", "404"}
+
+//go:embed all:default
+var embedded embed.FS
+
+// ErrPageNotFound 表示主题中没有请求的页面模板。
+var ErrPageNotFound = errors.New("theme page not found")
+
+// Theme 是一个已解析好的主题。
+type Theme struct {
+	Name  string
+	fsys  fs.FS
+	pages map[string]*template.Template
+}
+
+// Load 从 fsys 中解析主题的所有模板。
+func Load(name string, fsys fs.FS) (*Theme, error) {
+	shared, err := globAll(fsys, "layouts/*.html", "partials/*.html")
+	if err != nil {
+		return nil, err
+	}
+	if len(shared) == 0 {
+		return nil, fmt.Errorf("theme %q: no layout templates found", name)
+	}
+
+	t := &Theme{Name: name, fsys: fsys, pages: make(map[string]*template.Template)}
+	for _, page := range RequiredPages {
+		file := "pages/" + page + ".html"
+		if _, err := fs.Stat(fsys, file); err != nil {
+			return nil, fmt.Errorf("theme %q: missing page template %s", name, file)
+		}
+		tmpl, err := template.New(page).Funcs(funcMap()).ParseFS(fsys, append(shared, file)...)
+		if err != nil {
+			return nil, fmt.Errorf("theme %q: %w", name, err)
+		}
+		if tmpl.Lookup("base") == nil {
+			return nil, fmt.Errorf("theme %q: layouts must define a \"base\" template", name)
+		}
+		t.pages[page] = tmpl
+	}
+	return t, nil
+}
+
+// Render 使用页面模板渲染数据。模板先渲染到缓冲区，出错时不会向 w 写入不完整的页面。
+func (t *Theme) Render(w io.Writer, page string, data interface{}) error {
+	tmpl, ok := t.pages[page]
+	if !ok {
+		return ErrPageNotFound
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "base", data); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// Assets 返回主题的静态文件目录。
+func (t *Theme) Assets() fs.FS {
+	sub, err := fs.Sub(t.fsys, "assets")
+	if err != nil {
+		return emptyFS{}
+	}
+	return sub
+}
+
+// Fingerprint 返回主题所有文件内容的摘要，主题的任何文件发生变化时摘要都会改变。
+func (t *Theme) Fingerprint() (string, error) {
+	h := sha256.New()
+	err := fs.WalkDir(t.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(t.fsys, name)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(data))
+		h.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// globAll 返回匹配任意一个模式的所有文件。
+func globAll(fsys fs.FS, patterns ...string) ([]string, error) {
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+// emptyFS 是一个没有任何文件的文件系统，用于没有 assets 目录的主题。
+type emptyFS struct{}
+
+func (emptyFS) Open(name string) (fs.File, error) {
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// funcMap 返回主题模板中可以使用的函数。
+func funcMap() template.FuncMap {
+	return template.FuncMap{
+		// 站内链接
+		"postURL":     func(id uint) string { return "/posts/" + strconv.FormatUint(uint64(id), 10) },
+		"categoryURL": func(id uint) string { return "/categories/" + strconv.FormatUint(uint64(id), 10) },
+		"tagURL":      func(id uint) string { return "/tags/" + strconv.FormatUint(uint64(id), 10) },
+		"asset":       func(name string) string { return "/theme/" + strings.TrimLeft(name, "/") },
+		// 格式化
+		"date": func(t time.Time, layout string) string { return t.Format(layout) },
+		"excerpt": func(content string, n int) string {
+			return util.Excerpt(content, n)
+		},
+		// 由程序生成的可信 HTML 片段（例如 .Head）原样输出
+		"html": func(s string) template.HTML { return template.HTML(s) },
+		// 用户提交的 HTML（例如文章正文）按白名单清理后输出。正文在保存时已经清理过，
+		// 这里再清理一次，覆盖升级之前保存的文章
+		"sanitize": func(s string) template.HTML { return template.HTML(sanitize.HTML(s)) },
+		"add":      func(a, b int) int { return a + b },
+		"sub":      func(a, b int) int { return a - b },
+		"year":     func() int { return time.Now().Year() },
+	}
+}
+
+// Manager 负责加载和切换当前主题。
+// 从目录加载的主题在 debug 模式下会在每次渲染前重新解析，修改模板后刷新页面即可看到效果。
+type Manager struct {
+	name      string
+	dir       string
+	hotReload bool
+
+	mu      sync.RWMutex
+	current *Theme
+}
+
+// _manager 是全局的主题管理器，由 Init 创建。
+var _manager *Manager
+
+// Init 根据配置加载当前主题。
+func Init() error {
+	cfg := config.Conf.Theme
+	name := cfg.Name
+	if name == "" {
+		name = DefaultName
+	}
+	m := &Manager{
+		name:      name,
+		dir:       cfg.Dir,
+		hotReload: config.Conf.Server.Mode == "debug",
+	}
+	t, err := m.load()
+	if err != nil {
+		return err
+	}
+	m.current = t
+	_manager = m
+	return nil
+}
+
+// GetManager 返回全局的主题管理器，未调用 Init 时为 nil。
+func GetManager() *Manager {
+	return _manager
+}
+
+// load 加载配置的主题：主题目录中存在同名子目录时从目录加载，否则使用内置主题。
+func (m *Manager) load() (*Theme, error) {
+	if m.dir != "" {
+		dir := filepath.Join(m.dir, m.name)
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return Load(m.name, os.DirFS(dir))
+		}
+	}
+	if m.name != DefaultName {
+		return nil, fmt.Errorf("theme %q not found in %s", m.name, m.dir)
+	}
+	// 内置主题编译在程序中，不需要热重载
+	m.hotReload = false
+	sub, err := fs.Sub(embedded, DefaultName)
+	if err != nil {
+		return nil, err
+	}
+	return Load(DefaultName, sub)
+}
+
+// Theme 返回当前主题，开启热重载时会重新解析模板。
+// 重新解析失败时返回错误，便于开发者在页面上直接看到模板错误。
+func (m *Manager) Theme() (*Theme, error) {
+	if m.hotReload {
+		t, err := m.load()
+		if err != nil {
+			return nil, err
+		}
+		m.mu.Lock()
+		m.current = t
+		m.mu.Unlock()
+		return t, nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current, nil
+}
+
+// Render 使用当前主题渲染页面。
+func (m *Manager) Render(w io.Writer, page string, data interface{}) error {
+	t, err := m.Theme()
+	if err != nil {
+		return err
+	}
+	return t.Render(w, page, data)
+}
+
+// HotReload 返回是否开启了模板热重载。
+func (m *Manager) HotReload() bool {
+	return m.hotReload
+}
+
+// AssetPath 清理静态文件路径，防止访问 assets 目录之外的文件。
+func AssetPath(p string) (string, bool) {
+	p = strings.TrimPrefix(path.Clean("/"+p), "/")
+	if p == "" || !fs.ValidPath(p) {
+		return "", false
+	}
+	return p, true
+}
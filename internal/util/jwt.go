@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/KeLes-Coding/gopress/internal/config"
+	"github.com/KeLes-Coding/gopress/internal/model"
 	"github.com/golang-jwt/jwt/v5"
 )
 
@@ -14,15 +15,23 @@ import (
 type MyClaims struct {
 	UserID   uint   `json:"user_id"`
 	Username string `json:"username"`
+	// Admin 表示签发时用户是否为管理员。使用布尔值而不是角色编号，
+	// 这样不包含该字段的旧 token 会被当作普通用户，而不是角色编号的零值 (管理员)。
+	Admin bool `json:"admin,omitempty"`
+	// Language 是签发时用户设置的接口语言，为空表示跟随请求的 Accept-Language。
+	// 写入 token 后认证中间件不必每次查询数据库，用户修改语言时会签发新的 token。
+	Language string `json:"lang,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken 函数用于根据用户 ID 和用户名生成一个新的 JWT。
-func GenerateToken(userID uint, username string) (string, error) {
+// GenerateToken 函数用于为用户生成一个新的 JWT，其中包含用户 ID、用户名、是否为管理员以及接口语言。
+func GenerateToken(user *model.User) (string, error) {
 	// 创建自定义的 claims
 	claims := MyClaims{
-		UserID:   userID,
-		Username: username,
+		UserID:   user.ID,
+		Username: user.Username,
+		Admin:    user.Role == model.RoleAdmin,
+		Language: user.Language,
 		RegisteredClaims: jwt.RegisteredClaims{
 			// 设置过期时间，例如 7 天后过期
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
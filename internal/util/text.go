@@ -0,0 +1,47 @@
+package util
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// scriptStyleRe 匹配 <script> 和 <style> 元素，它们的内容不属于正文。
+	scriptStyleRe = regexp.MustCompile(`(?is)<(script|style)[^>]*>.*?</(script|style)>`)
+	// tagRe 匹配任意 HTML 标签。
+	tagRe = regexp.MustCompile(`(?s)<[^>]*>`)
+	// blankLineRe 匹配纯文本中分隔段落的空行。
+	blankLineRe = regexp.MustCompile(`\n[ \t]*\n`)
+)
+
+// StripHTML 去除 HTML 中的标签，返回折叠了连续空白的纯文本。
+func StripHTML(s string) string {
+	s = scriptStyleRe.ReplaceAllString(s, " ")
+	s = tagRe.ReplaceAllString(s, " ")
+	s = html.UnescapeString(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// Excerpt 从 HTML 内容中提取不超过 maxRunes 个字符的纯文本摘要，被截断时以 "…" 结尾。
+func Excerpt(content string, maxRunes int) string {
+	text := StripHTML(content)
+	if utf8.RuneCountInString(text) <= maxRunes {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:maxRunes])) + "…"
+}
+
+// TextToHTML 将纯文本转换为 HTML：转义特殊字符，以空行分隔的段落放入 <p>，段落内的换行转换为 <br>。
+func TextToHTML(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var paragraphs []string
+	for _, p := range blankLineRe.Split(text, -1) {
+		if p = strings.TrimSpace(p); p != "" {
+			paragraphs = append(paragraphs, "<p>"+strings.ReplaceAll(html.EscapeString(p), "\n", "<br>\n")+"</p>")
+		}
+	}
+	return strings.Join(paragraphs, "\n")
+}
@@ -0,0 +1,363 @@
+package webmention
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// 通知的类型，根据来源页面中链接的 microformats2 class 判断。
+const (
+	TypeMention  = "mention"
+	TypeReply    = "reply"
+	TypeLike     = "like"
+	TypeRepost   = "repost"
+	TypeBookmark = "bookmark"
+)
+
+// linkTypes 是 microformats2 中表示回复、喜欢等关系的 class 与通知类型的对应关系。
+var linkTypes = map[string]string{
+	"u-in-reply-to": TypeReply,
+	"u-like-of":     TypeLike,
+	"u-repost-of":   TypeRepost,
+	"u-bookmark-of": TypeBookmark,
+}
+
+// maxExcerptLength 是来源页面摘要的最大字数。
+const maxExcerptLength = 300
+
+// Source 是校验通过的来源页面的信息。
+type Source struct {
+	Type       string // mention, reply, like, repost, bookmark
+	Title      string
+	Excerpt    string
+	AuthorName string
+	AuthorURL  string
+}
+
+// Verifier 负责校验收到的通知。
+type Verifier struct {
+	Client    *http.Client
+	UserAgent string
+}
+
+// Verify 抓取 source 页面并确认其中包含指向 target 的链接。
+// 来源页面返回 410 时返回 ErrSourceGone，没有找到链接时返回 ErrNoLink。
+func (v *Verifier) Verify(ctx context.Context, source, target string) (*Source, error) {
+	resp, body, err := get(ctx, v.Client, source, v.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusGone {
+		return nil, ErrSourceGone
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &StatusError{URL: source, StatusCode: resp.StatusCode}
+	}
+
+	if !isHTML(resp.Header.Get("Content-Type")) {
+		// 纯文本、JSON 等格式只要求内容中出现目标地址
+		if !bytes.Contains(body, []byte(target)) {
+			return nil, ErrNoLink
+		}
+		return &Source{Type: TypeMention}, nil
+	}
+
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	p := &sourceParser{base: resp.Request.URL, target: normalizeURL(target)}
+	p.walk(doc, false)
+	if !p.found {
+		return nil, ErrNoLink
+	}
+	return p.result(), nil
+}
+
+// sourceParser 在来源页面的 HTML 中查找链接，并读取标题、摘要、作者等 microformats2 信息。
+type sourceParser struct {
+	base   *url.URL
+	target string
+
+	found    bool
+	linkType string
+
+	pageTitle       string
+	metaDescription string
+	metaAuthor      string
+
+	// h-entry 中的信息
+	entryName    string
+	entryContent string
+	authorName   string
+	authorURL    string
+}
+
+// walk 深度优先遍历节点，inEntry 表示当前是否处于 h-entry 中。
+func (p *sourceParser) walk(n *html.Node, inEntry bool) {
+	if n.Type == html.ElementNode {
+		classes := strings.Fields(attr(n, "class"))
+		if hasClass(classes, "h-entry") {
+			inEntry = true
+		}
+		p.checkLink(n, classes)
+
+		switch {
+		case n.DataAtom == atom.Title && p.pageTitle == "":
+			p.pageTitle = textContent(n)
+		case n.DataAtom == atom.Meta:
+			switch strings.ToLower(attr(n, "name")) {
+			case "description":
+				p.metaDescription = attr(n, "content")
+			case "author":
+				p.metaAuthor = attr(n, "content")
+			}
+		}
+
+		if inEntry {
+			if hasClass(classes, "p-name") && p.entryName == "" && !hasClass(classes, "h-card") {
+				p.entryName = textContent(n)
+			}
+			if (hasClass(classes, "e-content") || hasClass(classes, "p-summary")) && p.entryContent == "" {
+				p.entryContent = textContent(n)
+			}
+			if hasClass(classes, "p-author") || hasClass(classes, "u-author") {
+				p.readAuthor(n, classes)
+				// 作者信息中的 p-name 不是文章标题
+				return
+			}
+		}
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		p.walk(c, inEntry)
+	}
+}
+
+// checkLink 检查元素是否链接到目标地址，并根据 class 判断通知的类型。
+func (p *sourceParser) checkLink(n *html.Node, classes []string) {
+	var ref string
+	switch n.DataAtom {
+	case atom.A, atom.Link, atom.Area:
+		ref = attr(n, "href")
+	case atom.Img, atom.Video, atom.Audio, atom.Source, atom.Iframe:
+		ref = attr(n, "src")
+	case atom.Blockquote, atom.Q:
+		ref = attr(n, "cite")
+	default:
+		return
+	}
+	if ref == "" {
+		return
+	}
+	u, err := p.base.Parse(strings.TrimSpace(ref))
+	if err != nil || normalizeURL(u.String()) != p.target {
+		return
+	}
+	p.found = true
+	for _, class := range classes {
+		if t, ok := linkTypes[class]; ok && p.linkType == "" {
+			p.linkType = t
+		}
+	}
+}
+
+// readAuthor 读取 h-entry 中的作者信息，作者可以是一个 h-card，也可以只是一个链接或一段文字。
+func (p *sourceParser) readAuthor(n *html.Node, classes []string) {
+	if p.authorName != "" || p.authorURL != "" {
+		return
+	}
+	if hasClass(classes, "h-card") {
+		var visit func(*html.Node)
+		visit = func(c *html.Node) {
+			if c.Type == html.ElementNode {
+				cc := strings.Fields(attr(c, "class"))
+				if hasClass(cc, "p-name") && p.authorName == "" {
+					p.authorName = textContent(c)
+				}
+				if hasClass(cc, "u-url") && p.authorURL == "" {
+					p.authorURL = p.resolve(attr(c, "href"))
+				}
+			}
+			for child := c.FirstChild; child != nil; child = child.NextSibling {
+				visit(child)
+			}
+		}
+		visit(n)
+		if p.authorURL == "" && n.DataAtom == atom.A {
+			p.authorURL = p.resolve(attr(n, "href"))
+		}
+		if p.authorName == "" {
+			p.authorName = textContent(n)
+		}
+		return
+	}
+	p.authorName = textContent(n)
+	if n.DataAtom == atom.A {
+		p.authorURL = p.resolve(attr(n, "href"))
+	}
+}
+
+// resolve 将相对地址解析为绝对地址。
+func (p *sourceParser) resolve(ref string) string {
+	if ref == "" {
+		return ""
+	}
+	u, err := p.base.Parse(strings.TrimSpace(ref))
+	if err != nil || checkScheme(u) != nil {
+		return ""
+	}
+	return u.String()
+}
+
+// result 汇总解析出的信息，h-entry 中的信息优先于页面的 <title> 和 <meta>。
+func (p *sourceParser) result() *Source {
+	s := &Source{
+		Type:       p.linkType,
+		Title:      p.entryName,
+		Excerpt:    p.entryContent,
+		AuthorName: p.authorName,
+		AuthorURL:  p.authorURL,
+	}
+	if s.Type == "" {
+		s.Type = TypeMention
+	}
+	if s.Title == "" {
+		s.Title = p.pageTitle
+	}
+	if s.Excerpt == "" {
+		s.Excerpt = p.metaDescription
+	}
+	if s.AuthorName == "" {
+		s.AuthorName = p.metaAuthor
+	}
+	s.Title = truncate(s.Title, 255)
+	s.Excerpt = truncate(s.Excerpt, maxExcerptLength)
+	s.AuthorName = truncate(s.AuthorName, 100)
+	return s
+}
+
+// endpointFromHTML 查找文档中第一个 rel 包含 webmention 的 <link> 或 <a> 元素。
+func endpointFromHTML(body []byte) (string, bool) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return "", false
+	}
+	var endpoint string
+	var found bool
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if found {
+			return
+		}
+		if n.Type == html.ElementNode && (n.DataAtom == atom.Link || n.DataAtom == atom.A) && hasRel(attr(n, "rel"), "webmention") {
+			if href, ok := attrOK(n, "href"); ok {
+				endpoint, found = href, true
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(doc)
+	return endpoint, found
+}
+
+// normalizeURL 将地址规范化以便比较：去掉片段、统一协议和域名的大小写、去掉默认端口和末尾的斜杠。
+func normalizeURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return raw
+	}
+	u.Fragment = ""
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Host)
+	host = strings.TrimSuffix(host, map[string]string{"http": ":80", "https": ":443"}[u.Scheme])
+	u.Host = host
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	u.RawPath = ""
+	return u.String()
+}
+
+// linkRe 匹配文本中的 http(s) 链接，同时适用于 HTML 的 href 属性、Markdown 链接和裸链接。
+var linkRe = regexp.MustCompile("https?://[^\\s<>\"'\\[\\]{}`]+")
+
+// ExtractLinks 返回文章内容（HTML 或 Markdown）中出现的所有去重后的 http(s) 链接。
+func ExtractLinks(content string) []string {
+	seen := make(map[string]bool)
+	var links []string
+	for _, m := range linkRe.FindAllString(content, -1) {
+		m = strings.TrimRight(m, ".,;:!?'\"")
+		// Markdown 链接 [text](url) 中的右括号不属于地址，除非地址本身包含成对的括号
+		for strings.HasSuffix(m, ")") && strings.Count(m, "(") < strings.Count(m, ")") {
+			m = strings.TrimSuffix(m, ")")
+		}
+		m = html.UnescapeString(m)
+		if !ValidURL(m) || seen[m] {
+			continue
+		}
+		seen[m] = true
+		links = append(links, m)
+	}
+	return links
+}
+
+func attr(n *html.Node, key string) string {
+	v, _ := attrOK(n, key)
+	return v
+}
+
+func attrOK(n *html.Node, key string) (string, bool) {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && strings.EqualFold(a.Key, key) {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+func hasClass(classes []string, want string) bool {
+	for _, c := range classes {
+		if c == want {
+			return true
+		}
+	}
+	return false
+}
+
+// textContent 返回节点中的纯文本，连续的空白合并为一个空格。
+func textContent(n *html.Node) string {
+	var b strings.Builder
+	var visit func(*html.Node)
+	visit = func(c *html.Node) {
+		switch {
+		case c.Type == html.TextNode:
+			b.WriteString(c.Data)
+			b.WriteByte(' ')
+		case c.Type == html.ElementNode && (c.DataAtom == atom.Script || c.DataAtom == atom.Style):
+			return
+		}
+		for child := c.FirstChild; child != nil; child = child.NextSibling {
+			visit(child)
+		}
+	}
+	visit(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// truncate 将 s 截断为最多 n 个字符。
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "…"
+}
@@ -0,0 +1,231 @@
+// package webmention 实现了 W3C Webmention (https://www.w3.org/TR/webmention/) 协议：
+// 发现目标页面的 Webmention 接收地址、发送通知，以及校验收到的通知的来源页面确实链接了目标页面。
+package webmention
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxBodySize 是读取远程页面时的最大字节数，超出的部分会被忽略。
+const maxBodySize = 1 << 20
+
+var (
+	// ErrNoEndpoint 表示目标页面没有声明 Webmention 接收地址。
+	ErrNoEndpoint = errors.New("webmention: no endpoint found")
+	// ErrSourceGone 表示来源页面已被删除（返回 410 Gone），对应的通知应当移除。
+	ErrSourceGone = errors.New("webmention: source is gone")
+	// ErrNoLink 表示来源页面中没有指向目标页面的链接。
+	ErrNoLink = errors.New("webmention: source does not link to target")
+	// ErrForbiddenAddress 表示请求的地址解析到了内网或本机，为防止 SSRF 被拒绝。
+	ErrForbiddenAddress = errors.New("webmention: address is not allowed")
+)
+
+// StatusError 表示远程服务器返回了非成功的状态码。
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("webmention: %s returned status %d", e.URL, e.StatusCode)
+}
+
+// Temporary 判断该错误是否值得稍后重试：服务器错误和限流可以重试，其余的客户端错误不会自行恢复。
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusRequestTimeout
+}
+
+// IsPermanent 判断 err 是否为重试也不会成功的错误。
+func IsPermanent(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return !statusErr.Temporary()
+	}
+	return errors.Is(err, ErrNoEndpoint) || errors.Is(err, ErrSourceGone) ||
+		errors.Is(err, ErrNoLink) || errors.Is(err, ErrForbiddenAddress)
+}
+
+// NewClient 创建一个用于 Webmention 的 HTTP 客户端。
+// allowPrivate 为 false 时拒绝连接内网、本机和链路本地地址，防止通过伪造的来源或目标地址探测内网服务。
+func NewClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !isPublicIP(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	transport.Proxy = nil
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("webmention: too many redirects")
+			}
+			return checkScheme(req.URL)
+		},
+	}
+}
+
+// isPublicIP 判断 ip 是否为公网地址。
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast())
+}
+
+// checkScheme 只允许 http 和 https 地址。
+func checkScheme(u *url.URL) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webmention: unsupported scheme %q", u.Scheme)
+	}
+	return nil
+}
+
+// ValidURL 判断 raw 是否为一个可以用作来源或目标的绝对 http(s) 地址。
+func ValidURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && u.Host != "" && checkScheme(u) == nil
+}
+
+// get 发起一次 GET 请求，返回响应和最多 maxBodySize 字节的内容。
+func get(ctx context.Context, client *http.Client, target, userAgent string) (*http.Response, []byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkScheme(req.URL); err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "text/html, application/xhtml+xml;q=0.9, */*;q=0.5")
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
+
+// Sender 负责向其他站点发送 Webmention。
+type Sender struct {
+	Client    *http.Client
+	UserAgent string
+}
+
+// Discover 返回 target 页面声明的 Webmention 接收地址。
+// 依次检查 HTTP Link 头、HTML 中的 <link> 和 <a> 元素，没有找到时返回 ErrNoEndpoint。
+func (s *Sender) Discover(ctx context.Context, target string) (string, error) {
+	resp, body, err := get(ctx, s.Client, target, s.UserAgent)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", &StatusError{URL: target, StatusCode: resp.StatusCode}
+	}
+	base := resp.Request.URL
+
+	endpoint, ok := endpointFromLinkHeader(resp.Header.Values("Link"))
+	if !ok && isHTML(resp.Header.Get("Content-Type")) {
+		endpoint, ok = endpointFromHTML(body)
+	}
+	if !ok {
+		return "", ErrNoEndpoint
+	}
+	// 接收地址可以是相对地址，空字符串表示目标页面本身
+	ref, err := url.Parse(endpoint)
+	if err != nil {
+		return "", ErrNoEndpoint
+	}
+	resolved := base.ResolveReference(ref)
+	if checkScheme(resolved) != nil {
+		return "", ErrNoEndpoint
+	}
+	return resolved.String(), nil
+}
+
+// Send 向 endpoint 发送一条 source 提到了 target 的通知。
+func (s *Sender) Send(ctx context.Context, endpoint, source, target string) error {
+	form := url.Values{"source": {source}, "target": {target}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if s.UserAgent != "" {
+		req.Header.Set("User-Agent", s.UserAgent)
+	}
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodySize))
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &StatusError{URL: endpoint, StatusCode: resp.StatusCode}
+	}
+	return nil
+}
+
+// linkHeaderRe 匹配 Link 头中的一项，例如 <https://example.com/webmention>; rel="webmention"。
+var linkHeaderRe = regexp.MustCompile(`<([^>]*)>\s*((?:;\s*[^;,]+)*)`)
+
+// relParamRe 匹配 Link 头中的 rel 参数。
+var relParamRe = regexp.MustCompile(`(?i);\s*rel\s*=\s*(?:"([^"]*)"|([^\s";,]+))`)
+
+// endpointFromLinkHeader 从 HTTP Link 头中查找 rel 包含 webmention 的地址。
+func endpointFromLinkHeader(values []string) (string, bool) {
+	for _, value := range values {
+		for _, m := range linkHeaderRe.FindAllStringSubmatch(value, -1) {
+			rel := relParamRe.FindStringSubmatch(m[2])
+			if rel == nil {
+				continue
+			}
+			if hasRel(rel[1]+rel[2], "webmention") {
+				return m[1], true
+			}
+		}
+	}
+	return "", false
+}
+
+// hasRel 判断以空格分隔的 rel 属性值中是否包含 want。
+func hasRel(rel, want string) bool {
+	for _, r := range strings.Fields(rel) {
+		if strings.EqualFold(r, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// isHTML 判断响应的内容类型是否为 HTML。
+func isHTML(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return contentType == "" || strings.Contains(contentType, "text/html") || strings.Contains(contentType, "application/xhtml")
+}
@@ -0,0 +1,534 @@
+// package xmlrpc 实现了 XML-RPC (http://xmlrpc.com/spec.md) 请求的解析和响应的编码，
+// 供 MetaWeblog、Blogger 等基于 XML-RPC 的博客客户端接口使用。
+//
+// XML-RPC 的值与 Go 类型的对应关系：
+//
+//	int, i4, i8       int64
+//	boolean           bool
+//	string            string
+//	double            float64
+//	dateTime.iso8601  time.Time
+//	base64            []byte
+//	struct            map[string]interface{}
+//	array             []interface{}
+//	nil               nil
+package xmlrpc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 规范中约定的错误码 (http://xmlrpc-epi.sourceforge.net/specs/rfc.fault_codes.php)。
+const (
+	FaultParse          = -32700 // 请求不是合法的 XML
+	FaultInvalidRequest = -32600 // 请求不是合法的 XML-RPC 调用
+	FaultMethodNotFound = -32601 // 方法不存在
+	FaultInvalidParams  = -32602 // 参数错误
+	FaultInternal       = -32603 // 服务器内部错误
+)
+
+// Fault 是 XML-RPC 的错误响应。
+type Fault struct {
+	Code    int
+	Message string
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("xmlrpc fault %d: %s", f.Code, f.Message)
+}
+
+// Call 是一次方法调用。
+type Call struct {
+	Method string
+	Params []interface{}
+}
+
+// dateTimeLayouts 是解析 dateTime.iso8601 时接受的格式，不同客户端的写法并不统一。
+var dateTimeLayouts = []string{
+	"20060102T15:04:05",
+	"20060102T15:04:05Z",
+	"20060102T15:04:05Z07:00",
+	"20060102T150405",
+	"20060102T150405Z",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05.999999999Z07:00",
+}
+
+// DecodeCall 解析一个 methodCall 请求。
+func DecodeCall(r io.Reader) (*Call, error) {
+	d := xml.NewDecoder(r)
+	// XML-RPC 规定使用 UTF-8，个别客户端声明了其他编码但实际内容仍是 UTF-8
+	d.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+
+	root, err := nextStart(d)
+	if err != nil {
+		return nil, err
+	}
+	if root.Name.Local != "methodCall" {
+		return nil, &Fault{Code: FaultInvalidRequest, Message: "expected methodCall"}
+	}
+	call := &Call{}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "methodName":
+				name, err := text(d)
+				if err != nil {
+					return nil, err
+				}
+				call.Method = strings.TrimSpace(name)
+			case "params":
+				if call.Params, err = decodeParams(d); err != nil {
+					return nil, err
+				}
+			default:
+				if err := d.Skip(); err != nil {
+					return nil, err
+				}
+			}
+		case xml.EndElement:
+			if call.Method == "" {
+				return nil, &Fault{Code: FaultInvalidRequest, Message: "missing methodName"}
+			}
+			return call, nil
+		}
+	}
+}
+
+// nextStart 返回下一个开始标签。
+func nextStart(d *xml.Decoder) (xml.StartElement, error) {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.StartElement{}, err
+		}
+		if t, ok := tok.(xml.StartElement); ok {
+			return t, nil
+		}
+	}
+}
+
+// text 读取当前元素的文本内容，直到对应的结束标签。
+func text(d *xml.Decoder) (string, error) {
+	var b strings.Builder
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			b.Write(t)
+		case xml.StartElement:
+			return "", &Fault{Code: FaultInvalidRequest, Message: "unexpected element " + t.Name.Local}
+		case xml.EndElement:
+			return b.String(), nil
+		}
+	}
+}
+
+// decodeParams 解析 <params> 中的所有参数。
+func decodeParams(d *xml.Decoder) ([]interface{}, error) {
+	var params []interface{}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "param" {
+				return nil, &Fault{Code: FaultInvalidRequest, Message: "expected param"}
+			}
+			start, err := nextStart(d)
+			if err != nil {
+				return nil, err
+			}
+			if start.Name.Local != "value" {
+				return nil, &Fault{Code: FaultInvalidRequest, Message: "expected value"}
+			}
+			v, err := decodeValue(d)
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, v)
+			if err := skipToEnd(d); err != nil { // </param>
+				return nil, err
+			}
+		case xml.EndElement:
+			return params, nil
+		}
+	}
+}
+
+// skipToEnd 跳过当前元素中剩余的内容，直到对应的结束标签。
+func skipToEnd(d *xml.Decoder) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch tok.(type) {
+		case xml.StartElement:
+			if err := d.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// decodeValue 解析一个 <value> 元素的内容，调用时 <value> 开始标签已被读取。
+func decodeValue(d *xml.Decoder) (interface{}, error) {
+	var chars strings.Builder
+	var result interface{}
+	typed := false
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.CharData:
+			chars.Write(t)
+		case xml.StartElement:
+			if typed {
+				return nil, &Fault{Code: FaultInvalidRequest, Message: "multiple types in value"}
+			}
+			typed = true
+			if result, err = decodeTyped(d, t.Name.Local); err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			if !typed {
+				// 没有类型标签的值按字符串处理
+				return chars.String(), nil
+			}
+			return result, nil
+		}
+	}
+}
+
+// decodeTyped 解析带类型标签的值，调用时类型的开始标签已被读取。
+func decodeTyped(d *xml.Decoder, typ string) (interface{}, error) {
+	switch typ {
+	case "struct":
+		return decodeStruct(d)
+	case "array":
+		return decodeArray(d)
+	case "nil":
+		return nil, skipToEnd(d)
+	}
+
+	s, err := text(d)
+	if err != nil {
+		return nil, err
+	}
+	switch typ {
+	case "string":
+		return s, nil
+	case "int", "i4", "i8":
+		n, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+		if err != nil {
+			return nil, &Fault{Code: FaultInvalidParams, Message: "invalid int " + s}
+		}
+		return n, nil
+	case "boolean":
+		switch strings.TrimSpace(s) {
+		case "1", "true":
+			return true, nil
+		case "0", "false":
+			return false, nil
+		}
+		return nil, &Fault{Code: FaultInvalidParams, Message: "invalid boolean " + s}
+	case "double":
+		f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil {
+			return nil, &Fault{Code: FaultInvalidParams, Message: "invalid double " + s}
+		}
+		return f, nil
+	case "dateTime.iso8601":
+		s = strings.TrimSpace(s)
+		for _, layout := range dateTimeLayouts {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t, nil
+			}
+		}
+		return nil, &Fault{Code: FaultInvalidParams, Message: "invalid dateTime " + s}
+	case "base64":
+		data, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+		if err != nil {
+			return nil, &Fault{Code: FaultInvalidParams, Message: "invalid base64"}
+		}
+		return data, nil
+	default:
+		return nil, &Fault{Code: FaultInvalidParams, Message: "unknown type " + typ}
+	}
+}
+
+// decodeStruct 解析 <struct> 中的所有成员。
+func decodeStruct(d *xml.Decoder) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local != "member" {
+				return nil, &Fault{Code: FaultInvalidRequest, Message: "expected member"}
+			}
+			var name string
+			var value interface{}
+			for done := false; !done; {
+				tok, err := d.Token()
+				if err != nil {
+					return nil, err
+				}
+				switch m := tok.(type) {
+				case xml.StartElement:
+					switch m.Name.Local {
+					case "name":
+						if name, err = text(d); err != nil {
+							return nil, err
+						}
+					case "value":
+						if value, err = decodeValue(d); err != nil {
+							return nil, err
+						}
+					default:
+						if err := d.Skip(); err != nil {
+							return nil, err
+						}
+					}
+				case xml.EndElement:
+					done = true
+				}
+			}
+			result[name] = value
+		case xml.EndElement:
+			return result, nil
+		}
+	}
+}
+
+// decodeArray 解析 <array><data> 中的所有值。
+func decodeArray(d *xml.Decoder) ([]interface{}, error) {
+	result := []interface{}{}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "data":
+				// <data> 只是一层包装，继续读取其中的 <value>
+			case "value":
+				v, err := decodeValue(d)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, v)
+			default:
+				return nil, &Fault{Code: FaultInvalidRequest, Message: "unexpected element " + t.Name.Local}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "array" {
+				return result, nil
+			}
+		}
+	}
+}
+
+// EncodeResponse 将 v 编码为只有一个返回值的 methodResponse。
+func EncodeResponse(w io.Writer, v interface{}) error {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString("<methodResponse><params><param>")
+	if err := encodeValue(&b, v); err != nil {
+		return err
+	}
+	b.WriteString("</param></params></methodResponse>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// EncodeFault 将 f 编码为 methodResponse 中的 fault。
+func EncodeFault(w io.Writer, f *Fault) error {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString("<methodResponse><fault>")
+	if err := encodeValue(&b, map[string]interface{}{
+		"faultCode":   f.Code,
+		"faultString": f.Message,
+	}); err != nil {
+		return err
+	}
+	b.WriteString("</fault></methodResponse>\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// encodeValue 将 v 编码为一个 <value> 元素。
+func encodeValue(b *bytes.Buffer, v interface{}) error {
+	// 常用的切片类型先转换为 []interface{}
+	switch x := v.(type) {
+	case []string:
+		items := make([]interface{}, len(x))
+		for i, s := range x {
+			items[i] = s
+		}
+		v = items
+	case []map[string]interface{}:
+		items := make([]interface{}, len(x))
+		for i, m := range x {
+			items[i] = m
+		}
+		v = items
+	}
+
+	b.WriteString("<value>")
+	switch x := v.(type) {
+	case nil:
+		b.WriteString("<nil/>")
+	case bool:
+		if x {
+			b.WriteString("<boolean>1</boolean>")
+		} else {
+			b.WriteString("<boolean>0</boolean>")
+		}
+	case int:
+		b.WriteString("<int>" + strconv.Itoa(x) + "</int>")
+	case int64:
+		b.WriteString("<int>" + strconv.FormatInt(x, 10) + "</int>")
+	case uint:
+		b.WriteString("<int>" + strconv.FormatUint(uint64(x), 10) + "</int>")
+	case float64:
+		b.WriteString("<double>" + strconv.FormatFloat(x, 'f', -1, 64) + "</double>")
+	case string:
+		b.WriteString("<string>")
+		if err := xml.EscapeText(b, []byte(x)); err != nil {
+			return err
+		}
+		b.WriteString("</string>")
+	case time.Time:
+		b.WriteString("<dateTime.iso8601>" + x.UTC().Format("20060102T15:04:05Z") + "</dateTime.iso8601>")
+	case []byte:
+		b.WriteString("<base64>" + base64.StdEncoding.EncodeToString(x) + "</base64>")
+	case map[string]interface{}:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b.WriteString("<struct>")
+		for _, k := range keys {
+			b.WriteString("<member><name>")
+			if err := xml.EscapeText(b, []byte(k)); err != nil {
+				return err
+			}
+			b.WriteString("</name>")
+			if err := encodeValue(b, x[k]); err != nil {
+				return err
+			}
+			b.WriteString("</member>")
+		}
+		b.WriteString("</struct>")
+	case []interface{}:
+		b.WriteString("<array><data>")
+		for _, item := range x {
+			if err := encodeValue(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteString("</data></array>")
+	default:
+		return fmt.Errorf("xmlrpc: unsupported type %T", v)
+	}
+	b.WriteString("</value>")
+	return nil
+}
+
+// param 返回第 i 个参数。
+func (c *Call) param(i int) (interface{}, error) {
+	if i >= len(c.Params) {
+		return nil, &Fault{Code: FaultInvalidParams, Message: fmt.Sprintf("%s: parameter %d is required", c.Method, i+1)}
+	}
+	return c.Params[i], nil
+}
+
+// String 返回第 i 个参数的字符串形式，整数参数会被转换为字符串（部分客户端用整数传递文章 ID）。
+func (c *Call) String(i int) (string, error) {
+	v, err := c.param(i)
+	if err != nil {
+		return "", err
+	}
+	switch x := v.(type) {
+	case string:
+		return x, nil
+	case int64:
+		return strconv.FormatInt(x, 10), nil
+	}
+	return "", &Fault{Code: FaultInvalidParams, Message: fmt.Sprintf("%s: parameter %d must be a string", c.Method, i+1)}
+}
+
+// Int 返回第 i 个整数参数，字符串形式的数字也会被接受。
+func (c *Call) Int(i int) (int64, error) {
+	v, err := c.param(i)
+	if err != nil {
+		return 0, err
+	}
+	switch x := v.(type) {
+	case int64:
+		return x, nil
+	case string:
+		if n, err := strconv.ParseInt(strings.TrimSpace(x), 10, 64); err == nil {
+			return n, nil
+		}
+	}
+	return 0, &Fault{Code: FaultInvalidParams, Message: fmt.Sprintf("%s: parameter %d must be an int", c.Method, i+1)}
+}
+
+// Bool 返回第 i 个布尔参数，缺少该参数时返回 def。
+func (c *Call) Bool(i int, def bool) (bool, error) {
+	if i >= len(c.Params) {
+		return def, nil
+	}
+	switch x := c.Params[i].(type) {
+	case bool:
+		return x, nil
+	case int64:
+		return x != 0, nil
+	}
+	return false, &Fault{Code: FaultInvalidParams, Message: fmt.Sprintf("%s: parameter %d must be a boolean", c.Method, i+1)}
+}
+
+// Struct 返回第 i 个结构体参数。
+func (c *Call) Struct(i int) (map[string]interface{}, error) {
+	v, err := c.param(i)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := v.(map[string]interface{}); ok {
+		return m, nil
+	}
+	return nil, &Fault{Code: FaultInvalidParams, Message: fmt.Sprintf("%s: parameter %d must be a struct", c.Method, i+1)}
+}